@@ -5,7 +5,7 @@ import "api-chatbot/domain"
 // GetConversationsRequest request for getting conversations
 type GetConversationsRequest struct {
 	domain.Base
-	Filter string `json:"filter" validate:"omitempty,oneof=all unread blocked active" doc:"Filter conversations: all, unread, blocked, active"`
+	Filter string `json:"filter" validate:"omitempty,oneof=all unread blocked active handoff" doc:"Filter conversations: all, unread, blocked, active, handoff (needing an admin)"`
 	Limit  int    `json:"limit" validate:"omitempty,min=1,max=100" doc:"Number of conversations to return (default: 50)"`
 	Offset int    `json:"offset" validate:"omitempty,min=0" doc:"Offset for pagination (default: 0)"`
 }
@@ -51,3 +51,9 @@ type SetTemporaryRequest struct {
 	Temporary        bool `json:"temporary" doc:"True to enable temporary, false to disable"`
 	HoursUntilExpiry int  `json:"hoursUntilExpiry" validate:"omitempty,min=1,max=720" doc:"Hours until conversation expires (default: 24, max: 720/30 days)"`
 }
+
+// ResolveHandoffRequest request for clearing a conversation's handoff flag
+type ResolveHandoffRequest struct {
+	domain.Base
+	ConversationID int `json:"conversationId" validate:"required,min=1" doc:"Conversation ID"`
+}
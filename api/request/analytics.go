@@ -81,6 +81,33 @@ type GetKnowledgeUsageRequest struct {
 	Period string `json:"period" validate:"omitempty,oneof=day week month all"` // Default: "month"
 }
 
+// =====================================================
+// Knowledge Gap Requests
+// =====================================================
+
+type AnalyzeKnowledgeGapsRequest struct {
+	domain.Base
+	StartDate           *time.Time `json:"startDate,omitempty"`
+	EndDate             *time.Time `json:"endDate,omitempty"`
+	Period              string     `json:"period" validate:"omitempty,oneof=day week month"`              // Default: "week" (ignored with startDate)
+	SimilarityThreshold *float64   `json:"similarityThreshold,omitempty" validate:"omitempty,gt=0,lte=1"` // Overrides KNOWLEDGE_GAP_CONFIG
+	MinClusterSize      *int       `json:"minClusterSize,omitempty" validate:"omitempty,gte=1,lte=100"`   // Overrides KNOWLEDGE_GAP_CONFIG
+	GoodScore           *float64   `json:"goodScore,omitempty" validate:"omitempty,gte=0,lte=1"`          // Overrides KNOWLEDGE_GAP_CONFIG
+	Save                bool       `json:"save"`                                                          // Store the run as the latest gaps
+}
+
+type GetKnowledgeGapsRequest struct {
+	domain.Base
+	RunID              *int    `json:"runId,omitempty"`                                     // Default: the latest run
+	MinUnansweredShare float64 `json:"minUnansweredShare" validate:"omitempty,gte=0,lte=1"` // Default: 0 (every cluster)
+	Limit              int     `json:"limit" validate:"omitempty,min=1,max=200"`            // Default: 50
+}
+
+type GetKnowledgeGapRunsRequest struct {
+	domain.Base
+	Limit int `json:"limit" validate:"omitempty,min=1,max=100"` // Default: 20
+}
+
 // =====================================================
 // Report Generation Requests
 // =====================================================
@@ -25,10 +25,20 @@ type SimilaritySearchRequest struct {
 
 type HybridSearchRequest struct {
 	domain.Base
-	QueryText     string  `json:"queryText" validate:"required,min=1" doc:"Text query for hybrid search (converted to embedding + full-text search)"`
-	Limit         int     `json:"limit" validate:"omitempty,gte=1,lte=100" doc:"Maximum number of results (default: 10)"`
-	MinSimilarity float64 `json:"minSimilarity" validate:"omitempty,gte=0,lte=1" doc:"Minimum similarity score 0-1 (default: 0.2)"`
-	KeywordWeight float64 `json:"keywordWeight" validate:"omitempty,gte=0,lte=1" doc:"Weight for keyword/FTS score 0-1 (default: 0.15)"`
+	QueryText            string               `json:"queryText" validate:"required,min=1" doc:"Text query for hybrid search (converted to embedding + full-text search)"`
+	Limit                int                  `json:"limit" validate:"omitempty,gte=1,lte=100" doc:"Maximum number of results (default: 10)"`
+	MinSimilarity        float64              `json:"minSimilarity" validate:"omitempty,gte=0,lte=1" doc:"Minimum similarity score 0-1 (default: 0.2)"`
+	KeywordWeight        float64              `json:"keywordWeight" validate:"omitempty,gte=0,lte=1" doc:"Weight for keyword/FTS score 0-1 (default: 0.15)"`
+	Fusion               *string              `json:"fusion,omitempty" validate:"omitempty,oneof=weighted rrf" doc:"Fusion strategy: weighted or rrf (default: RAG_FUSION_STRATEGY)"`
+	RRFK                 *int                 `json:"rrfK,omitempty" validate:"omitempty,gte=1,lte=1000" doc:"Rank constant k for RRF (default: RAG_RRF_K)"`
+	MMR                  *bool                `json:"mmr,omitempty" doc:"Apply the MMR diversity pass (default: RAG_MMR_ENABLED)"`
+	MMRLambda            *float64             `json:"mmrLambda,omitempty" validate:"omitempty,gte=0,lte=1" doc:"MMR relevance/diversity trade-off 0-1 (default: RAG_MMR_LAMBDA)"`
+	MaxChunksPerDocument *int                 `json:"maxChunksPerDocument,omitempty" validate:"omitempty,gte=0,lte=50" doc:"Maximum chunks per document, 0 = no cap (default: RAG_MAX_CHUNKS_PER_DOCUMENT)"`
+	NeighborWindow       *int                 `json:"neighborWindow,omitempty" validate:"omitempty,gte=0,lte=5" doc:"Expand each hit with its ±N neighbouring chunks, 0 = disabled (default: RAG_NEIGHBOR_WINDOW)"`
+	RecencyWeight        *float64             `json:"recencyWeight,omitempty" validate:"omitempty,gte=0,lte=5" doc:"Boost newer documents by up to this fraction of their score, 0 = disabled (default: RAG_RECENCY_WEIGHT)"`
+	QueryStrategy        *string              `json:"queryStrategy,omitempty" validate:"omitempty,oneof=none multi_query hyde" doc:"Query strategy: none, multi_query or hyde (default: QUERY_STRATEGY_CONFIG)"`
+	Filter               *domain.SearchFilter `json:"filter,omitempty" doc:"Metadata filters: categories, includeTags, excludeTags, publishedFrom, publishedTo, sources, documentIds"`
+	Role                 *string              `json:"role,omitempty" validate:"omitempty,startswith=ROLE_,max=50" doc:"Search as this role, applying document audience rules (default: every document)"`
 }
 
 type CreateChunkRequest struct {
@@ -32,21 +32,29 @@ type SearchDocumentsByTitleRequest struct {
 
 type CreateDocumentRequest struct {
 	domain.Base
-	Category    string  `json:"category" validate:"required"`
-	Title       string  `json:"title" validate:"required,min=1,max=200"`
-	Summary     *string `json:"summary" validate:"omitempty,max=5000"`
-	Source      *string `json:"source" validate:"omitempty,max=500"`
-	PublishedAt *string `json:"publishedAt" validate:"omitempty"` // ISO 8601 timestamp string
+	Category    string   `json:"category" validate:"required"`
+	Title       string   `json:"title" validate:"required,min=1,max=200"`
+	Summary     *string  `json:"summary" validate:"omitempty,max=5000"`
+	Source      *string  `json:"source" validate:"omitempty,max=500"`
+	PublishedAt *string  `json:"publishedAt" validate:"omitempty"` // ISO 8601 timestamp string
+	Tags        []string `json:"tags,omitempty" validate:"omitempty,max=30,dive,min=1,max=50"`
+	Audience    []string `json:"audience,omitempty" validate:"omitempty,max=10,dive,startswith=ROLE_,max=50" doc:"Roles allowed to retrieve the document (e.g. ROLE_PROFESSOR); empty inherits the category audience"`
+	ValidFrom   *string  `json:"validFrom,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" doc:"Start of validity (RFC 3339); the document is not retrieved before it"`
+	ValidUntil  *string  `json:"validUntil,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" doc:"End of validity (RFC 3339); the document is then excluded from search and deactivated"`
 }
 
 type UpdateDocumentRequest struct {
 	domain.Base
-	DocID       int     `json:"docId" validate:"required,gte=1"`
-	Category    string  `json:"category" validate:"required"`
-	Title       string  `json:"title" validate:"required,min=1,max=200"`
-	Summary     *string `json:"summary" validate:"omitempty,max=5000"`
-	Source      *string `json:"source" validate:"omitempty,max=500"`
-	PublishedAt *string `json:"publishedAt" validate:"omitempty"` // ISO 8601 timestamp string
+	DocID       int      `json:"docId" validate:"required,gte=1"`
+	Category    string   `json:"category" validate:"required"`
+	Title       string   `json:"title" validate:"required,min=1,max=200"`
+	Summary     *string  `json:"summary" validate:"omitempty,max=5000"`
+	Source      *string  `json:"source" validate:"omitempty,max=500"`
+	PublishedAt *string  `json:"publishedAt" validate:"omitempty"` // ISO 8601 timestamp string
+	Tags        []string `json:"tags,omitempty" validate:"omitempty,max=30,dive,min=1,max=50"`
+	Audience    []string `json:"audience,omitempty" validate:"omitempty,max=10,dive,startswith=ROLE_,max=50" doc:"Roles allowed to retrieve the document (e.g. ROLE_PROFESSOR); empty inherits the category audience"`
+	ValidFrom   *string  `json:"validFrom,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" doc:"Start of validity (RFC 3339); the document is not retrieved before it"`
+	ValidUntil  *string  `json:"validUntil,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" doc:"End of validity (RFC 3339); the document is then excluded from search and deactivated"`
 }
 
 type DeleteDocumentRequest struct {
@@ -56,10 +64,91 @@ type DeleteDocumentRequest struct {
 
 type UploadPDFDocumentRequest struct {
 	domain.Base
-	Category     string  `json:"category" validate:"required"`
-	Title        string  `json:"title" validate:"required,min=1,max=200"`
-	Source       *string `json:"source" validate:"omitempty,max=500"`
-	FileBase64   string  `json:"fileBase64" validate:"required"`
-	ChunkSize    *int    `json:"chunkSize" validate:"omitempty,gte=100,lte=5000"`
-	ChunkOverlap *int    `json:"chunkOverlap" validate:"omitempty,gte=0,lte=500"`
+	Category     string   `json:"category" validate:"required"`
+	Title        string   `json:"title" validate:"required,min=1,max=200"`
+	Source       *string  `json:"source" validate:"omitempty,max=500"`
+	Tags         []string `json:"tags,omitempty" validate:"omitempty,max=30,dive,min=1,max=50"`
+	Audience     []string `json:"audience,omitempty" validate:"omitempty,max=10,dive,startswith=ROLE_,max=50" doc:"Roles allowed to retrieve the document (e.g. ROLE_PROFESSOR); empty inherits the category audience"`
+	ValidFrom    *string  `json:"validFrom,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" doc:"Start of validity (RFC 3339); the document is not retrieved before it"`
+	ValidUntil   *string  `json:"validUntil,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" doc:"End of validity (RFC 3339); the document is then excluded from search and deactivated"`
+	FileBase64   string   `json:"fileBase64" validate:"required"`
+	ChunkSize    *int     `json:"chunkSize" validate:"omitempty,gte=100,lte=5000"`
+	ChunkOverlap *int     `json:"chunkOverlap" validate:"omitempty,gte=0,lte=500"`
+}
+
+type UploadDocumentRequest struct {
+	domain.Base
+	Category      string   `json:"category" validate:"required"`
+	Title         string   `json:"title" validate:"required,min=1,max=200"`
+	Source        *string  `json:"source" validate:"omitempty,max=500"`
+	Tags          []string `json:"tags,omitempty" validate:"omitempty,max=30,dive,min=1,max=50"`
+	Audience      []string `json:"audience,omitempty" validate:"omitempty,max=10,dive,startswith=ROLE_,max=50" doc:"Roles allowed to retrieve the document (e.g. ROLE_PROFESSOR); empty inherits the category audience"`
+	ValidFrom     *string  `json:"validFrom,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" doc:"Start of validity (RFC 3339); the document is not retrieved before it"`
+	ValidUntil    *string  `json:"validUntil,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" doc:"End of validity (RFC 3339); the document is then excluded from search and deactivated"`
+	FileBase64    string   `json:"fileBase64" validate:"required"`
+	FileType      string   `json:"fileType,omitempty" validate:"omitempty,oneof=pdf docx odt md txt xlsx csv" doc:"pdf, docx, odt, md (Markdown), txt, xlsx or csv (imported as tables); detected from fileName or the content when empty"`
+	FileName      *string  `json:"fileName,omitempty" validate:"omitempty,max=255"`
+	ChunkStrategy string   `json:"chunkStrategy,omitempty" validate:"omitempty,oneof=sentence recursive tokens headings" doc:"sentence, recursive, tokens (size in estimated tokens) or headings; the category strategy, else headings for md/txt and sentence otherwise, when empty"`
+	ChunkSize     *int     `json:"chunkSize" validate:"omitempty,gte=100,lte=5000"`
+	ChunkOverlap  *int     `json:"chunkOverlap" validate:"omitempty,gte=0,lte=500"`
+}
+
+type CreateTextDocumentRequest struct {
+	domain.Base
+	Category      string   `json:"category" validate:"required"`
+	Title         string   `json:"title" validate:"required,min=1,max=200"`
+	Source        *string  `json:"source" validate:"omitempty,max=500"`
+	Tags          []string `json:"tags,omitempty" validate:"omitempty,max=30,dive,min=1,max=50"`
+	Audience      []string `json:"audience,omitempty" validate:"omitempty,max=10,dive,startswith=ROLE_,max=50" doc:"Roles allowed to retrieve the document (e.g. ROLE_PROFESSOR); empty inherits the category audience"`
+	ValidFrom     *string  `json:"validFrom,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" doc:"Start of validity (RFC 3339); the document is not retrieved before it"`
+	ValidUntil    *string  `json:"validUntil,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" doc:"End of validity (RFC 3339); the document is then excluded from search and deactivated"`
+	Content       string   `json:"content" validate:"required,min=1,max=5000000" doc:"Markdown or plain text; chunks follow its headings and start with their breadcrumb (e.g. Admisiones > Requisitos)"`
+	Format        string   `json:"format,omitempty" validate:"omitempty,oneof=md txt" doc:"md (default) or txt"`
+	ChunkStrategy string   `json:"chunkStrategy,omitempty" validate:"omitempty,oneof=sentence recursive tokens headings" doc:"sentence, recursive, tokens (size in estimated tokens) or headings; the category strategy, else headings for md/txt and sentence otherwise, when empty"`
+	ChunkSize     *int     `json:"chunkSize" validate:"omitempty,gte=100,lte=5000"`
+	ChunkOverlap  *int     `json:"chunkOverlap" validate:"omitempty,gte=0,lte=500"`
+}
+
+type ImportTableRequest struct {
+	domain.Base
+	Category   string               `json:"category" validate:"required"`
+	Title      string               `json:"title" validate:"required,min=1,max=200"`
+	Source     *string              `json:"source" validate:"omitempty,max=500"`
+	Tags       []string             `json:"tags,omitempty" validate:"omitempty,max=30,dive,min=1,max=50"`
+	Audience   []string             `json:"audience,omitempty" validate:"omitempty,max=10,dive,startswith=ROLE_,max=50" doc:"Roles allowed to retrieve the document (e.g. ROLE_PROFESSOR); empty inherits the category audience"`
+	ValidFrom  *string              `json:"validFrom,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" doc:"Start of validity (RFC 3339); the document is not retrieved before it"`
+	ValidUntil *string              `json:"validUntil,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00" doc:"End of validity (RFC 3339); the document is then excluded from search and deactivated"`
+	FileBase64 string               `json:"fileBase64" validate:"required"`
+	FileType   string               `json:"fileType,omitempty" validate:"omitempty,oneof=xlsx csv" doc:"xlsx or csv; detected from fileName or the content when empty"`
+	FileName   *string              `json:"fileName,omitempty" validate:"omitempty,max=255"`
+	Mapping    *domain.TableMapping `json:"mapping,omitempty" doc:"How rows become chunks; by default every named column of every visible sheet, one row per chunk"`
+	ChunkSize  *int                 `json:"chunkSize" validate:"omitempty,gte=100,lte=5000" doc:"Maximum size of a chunk of grouped rows"`
+}
+
+type PreviewChunksRequest struct {
+	domain.Base
+	Category      string               `json:"category,omitempty" doc:"Category whose chunking configuration applies"`
+	Content       string               `json:"content,omitempty" validate:"omitempty,max=5000000" doc:"Text to chunk, when no file is given"`
+	Format        string               `json:"format,omitempty" validate:"omitempty,oneof=md txt" doc:"Format of content: md (default) or txt"`
+	FileBase64    string               `json:"fileBase64,omitempty" doc:"File to chunk (base64); takes precedence over content"`
+	FileType      string               `json:"fileType,omitempty" validate:"omitempty,oneof=pdf docx odt md txt xlsx csv" doc:"pdf, docx, odt, md, txt, xlsx or csv; detected from fileName or the content when empty"`
+	FileName      *string              `json:"fileName,omitempty" validate:"omitempty,max=255"`
+	ChunkStrategy string               `json:"chunkStrategy,omitempty" validate:"omitempty,oneof=sentence recursive tokens headings" doc:"sentence, recursive, tokens (size in estimated tokens) or headings; ignored for tables"`
+	ChunkSize     *int                 `json:"chunkSize" validate:"omitempty,gte=100,lte=5000"`
+	ChunkOverlap  *int                 `json:"chunkOverlap" validate:"omitempty,gte=0,lte=500"`
+	Mapping       *domain.TableMapping `json:"mapping,omitempty" doc:"How the rows of an XLSX or CSV file become chunks"`
+}
+
+type ReimportTableRequest struct {
+	domain.Base
+	DocID      int                  `json:"docId" validate:"required,gte=1"`
+	FileBase64 string               `json:"fileBase64" validate:"required"`
+	FileType   string               `json:"fileType,omitempty" validate:"omitempty,oneof=xlsx csv" doc:"xlsx or csv; detected from fileName or the content when empty"`
+	FileName   *string              `json:"fileName,omitempty" validate:"omitempty,max=255"`
+	Mapping    *domain.TableMapping `json:"mapping,omitempty" doc:"How rows become chunks; the mapping of the previous import when empty"`
+	ChunkSize  *int                 `json:"chunkSize" validate:"omitempty,gte=100,lte=5000" doc:"Maximum size of a chunk of grouped rows"`
+}
+
+type ExpireDocumentsRequest struct {
+	domain.Base
 }
@@ -0,0 +1,16 @@
+package request
+
+import "api-chatbot/domain"
+
+// GetEmbeddingCacheStatsRequest request for embedding cache size and hit rate
+type GetEmbeddingCacheStatsRequest struct {
+	domain.Base
+	Days int `json:"days" validate:"omitempty,min=1,max=365" doc:"Hit rate window in days (default: 30)"`
+}
+
+// PurgeEmbeddingCacheRequest request for deleting cached embeddings
+type PurgeEmbeddingCacheRequest struct {
+	domain.Base
+	Model      *string `json:"model,omitempty" validate:"omitempty,max=100" doc:"Only purge entries of this model (default: all models)"`
+	UnusedDays *int    `json:"unusedDays,omitempty" validate:"omitempty,min=1" doc:"Only purge entries not used in this many days (default: all entries)"`
+}
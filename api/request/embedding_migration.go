@@ -0,0 +1,23 @@
+package request
+
+import "api-chatbot/domain"
+
+// StartEmbeddingMigrationRequest request for re-embedding every chunk with a new embedding configuration
+type StartEmbeddingMigrationRequest struct {
+	domain.Base
+	ConfigCode string `json:"configCode" validate:"required,max=100" doc:"Parameter holding the target embedding configuration, same shape as EMBEDDING_CONFIG (e.g. EMBEDDING_CONFIG_NEXT)"`
+	BatchSize  int    `json:"batchSize" validate:"omitempty,min=1,max=2048" doc:"Chunks embedded per request (default: 100)"`
+	AutoSwap   bool   `json:"autoSwap" doc:"Activate the new embeddings as soon as every chunk is re-embedded"`
+}
+
+// EmbeddingJobRequest request addressing one re-embedding job
+type EmbeddingJobRequest struct {
+	domain.Base
+	JobID int `json:"jobId" validate:"required,min=1" doc:"Embedding job ID"`
+}
+
+// GetEmbeddingJobsRequest request for re-embedding job progress
+type GetEmbeddingJobsRequest struct {
+	domain.Base
+	JobID *int `json:"jobId,omitempty" validate:"omitempty,min=1" doc:"Only this job (default: latest jobs)"`
+}
@@ -0,0 +1,68 @@
+package request
+
+import (
+	"api-chatbot/domain"
+)
+
+// CreateEvalQuestionRequest request for adding a labelled question to a golden set
+type CreateEvalQuestionRequest struct {
+	domain.Base
+	Set                 string  `json:"set" validate:"omitempty,max=50" doc:"Golden set name (default: default)"`
+	Question            string  `json:"question" validate:"required,min=1" doc:"Question as a student would ask it"`
+	Category            *string `json:"category,omitempty" validate:"omitempty,max=50" doc:"Document category to search in (as the WhatsApp flow does)"`
+	RelevantChunkIDs    []int   `json:"relevantChunkIds" validate:"omitempty,dive,gte=1" doc:"Chunks that answer the question"`
+	RelevantDocumentIDs []int   `json:"relevantDocumentIds" validate:"omitempty,dive,gte=1" doc:"Documents that answer the question (any of their chunks counts as relevant)"`
+}
+
+// DeleteEvalQuestionRequest request for removing a question from its golden set
+type DeleteEvalQuestionRequest struct {
+	domain.Base
+	QuestionID int `json:"questionId" validate:"required,gte=1" doc:"Question ID"`
+}
+
+// GetEvalQuestionsRequest request for listing the questions of a golden set
+type GetEvalQuestionsRequest struct {
+	domain.Base
+	Set string `json:"set" validate:"omitempty,max=50" doc:"Golden set name (default: default)"`
+}
+
+// ImportEvalQuestionsRequest request for adding several labelled questions at once
+// (same fields as the JSONL golden set file)
+type ImportEvalQuestionsRequest struct {
+	domain.Base
+	Set       string                `json:"set" validate:"omitempty,max=50" doc:"Golden set name (default: default)"`
+	Questions []domain.EvalQuestion `json:"questions" validate:"required,min=1,max=1000" doc:"Questions with question, category, relevantChunkIds and/or relevantDocumentIds"`
+}
+
+// RunEvaluationRequest request for evaluating a golden set against the current index and settings
+type RunEvaluationRequest struct {
+	domain.Base
+	Set                  string               `json:"set" validate:"omitempty,max=50" doc:"Golden set name (default: default)"`
+	Label                *string              `json:"label,omitempty" validate:"omitempty,max=150" doc:"Label to tell runs apart (e.g. the change being measured)"`
+	Save                 bool                 `json:"save" doc:"Store the report so later runs can be compared with it"`
+	K                    *int                 `json:"k,omitempty" validate:"omitempty,gte=1,lte=50" doc:"Results per question (default: RAG_SEARCH_LIMIT)"`
+	MinSimilarity        *float64             `json:"minSimilarity,omitempty" validate:"omitempty,gte=0,lte=1" doc:"Minimum similarity score 0-1 (default: RAG_MIN_SIMILARITY)"`
+	KeywordWeight        *float64             `json:"keywordWeight,omitempty" validate:"omitempty,gte=0,lte=1" doc:"Weight for keyword/FTS score 0-1 (default: RAG_KEYWORD_WEIGHT)"`
+	Fusion               *string              `json:"fusion,omitempty" validate:"omitempty,oneof=weighted rrf" doc:"Fusion strategy: weighted or rrf (default: RAG_FUSION_STRATEGY)"`
+	RRFK                 *int                 `json:"rrfK,omitempty" validate:"omitempty,gte=1,lte=1000" doc:"Rank constant k for RRF (default: RAG_RRF_K)"`
+	MMR                  *bool                `json:"mmr,omitempty" doc:"Apply the MMR diversity pass (default: RAG_MMR_ENABLED)"`
+	MMRLambda            *float64             `json:"mmrLambda,omitempty" validate:"omitempty,gte=0,lte=1" doc:"MMR relevance/diversity trade-off 0-1 (default: RAG_MMR_LAMBDA)"`
+	MaxChunksPerDocument *int                 `json:"maxChunksPerDocument,omitempty" validate:"omitempty,gte=0,lte=50" doc:"Maximum chunks per document, 0 = no cap (default: RAG_MAX_CHUNKS_PER_DOCUMENT)"`
+	NeighborWindow       *int                 `json:"neighborWindow,omitempty" validate:"omitempty,gte=0,lte=5" doc:"Expand each hit with its ±N neighbouring chunks, 0 = disabled (default: RAG_NEIGHBOR_WINDOW)"`
+	RecencyWeight        *float64             `json:"recencyWeight,omitempty" validate:"omitempty,gte=0,lte=5" doc:"Boost newer documents by up to this fraction of their score, 0 = disabled (default: RAG_RECENCY_WEIGHT)"`
+	QueryStrategy        *string              `json:"queryStrategy,omitempty" validate:"omitempty,oneof=none multi_query hyde" doc:"Query strategy: none, multi_query or hyde (default: QUERY_STRATEGY_CONFIG)"`
+	Filter               *domain.SearchFilter `json:"filter,omitempty" doc:"Metadata filters applied to every question"`
+}
+
+// GetEvalRunsRequest request for listing stored evaluation runs
+type GetEvalRunsRequest struct {
+	domain.Base
+	Set   *string `json:"set,omitempty" validate:"omitempty,max=50" doc:"Only runs of this golden set"`
+	Limit int     `json:"limit" validate:"omitempty,gte=1,lte=100" doc:"Maximum number of runs (default: 20)"`
+}
+
+// GetEvalRunRequest request for one stored evaluation run with its per-question results
+type GetEvalRunRequest struct {
+	domain.Base
+	RunID int `json:"runId" validate:"required,gte=1" doc:"Evaluation run ID"`
+}
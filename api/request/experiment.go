@@ -0,0 +1,43 @@
+package request
+
+import (
+	"time"
+
+	"api-chatbot/domain"
+)
+
+// ExperimentVariantRequest describes one variant of an experiment
+type ExperimentVariantRequest struct {
+	Code   string                 `json:"code" validate:"required,min=1,max=50" doc:"Variant code (e.g. A, B)"`
+	Weight int                    `json:"weight" validate:"omitempty,min=1,max=1000" doc:"Relative traffic weight (default: 1)"`
+	Config domain.VariantSettings `json:"config" doc:"Overrides: promptVersion, promptCode or systemPrompt, model, temperature, searchLimit, keywordWeight"`
+}
+
+// CreateExperimentRequest request for creating an A/B experiment
+type CreateExperimentRequest struct {
+	domain.Base
+	Code        string                     `json:"code" validate:"required,min=1,max=50" doc:"Unique experiment code"`
+	Name        string                     `json:"name" validate:"required,min=1,max=150" doc:"Experiment name"`
+	Description *string                    `json:"description,omitempty" doc:"What the experiment compares"`
+	Variants    []ExperimentVariantRequest `json:"variants" validate:"required,min=2,dive" doc:"Variants to compare (at least two)"`
+}
+
+// SetExperimentStatusRequest request for starting or stopping an experiment
+type SetExperimentStatusRequest struct {
+	domain.Base
+	ExperimentID int  `json:"experimentId" validate:"required,min=1" doc:"Experiment ID"`
+	Active       bool `json:"active" doc:"true starts the experiment (stopping any other running one), false stops it"`
+}
+
+// GetExperimentsRequest request for listing experiments
+type GetExperimentsRequest struct {
+	domain.Base
+}
+
+// CompareExperimentVariantsRequest request for comparing the variants of an experiment
+type CompareExperimentVariantsRequest struct {
+	domain.Base
+	ExperimentID int        `json:"experimentId" validate:"required,min=1" doc:"Experiment ID"`
+	StartDate    *time.Time `json:"startDate,omitempty" doc:"Only messages created after this date"`
+	EndDate      *time.Time `json:"endDate,omitempty" doc:"Only messages created before this date"`
+}
@@ -26,6 +26,23 @@ type RAGConfig struct {
 	MinSimilarity float64  `json:"min_similarity" validate:"omitempty,gte=0,lte=1"`
 	KeywordWeight float64  `json:"keyword_weight" validate:"omitempty,gte=0,lte=1"`
 	EventFilter   []string `json:"event_filter,omitempty"` // Filter by event categories (e.g., ["EVENT_INDTEC"])
+	// Retrieval overrides (RAG_FUSION_STRATEGY, RAG_RRF_K, RAG_MMR_*, RAG_MAX_CHUNKS_PER_DOCUMENT, RAG_NEIGHBOR_WINDOW and RAG_RECENCY_WEIGHT when omitted)
+	Fusion               *string              `json:"fusion,omitempty" validate:"omitempty,oneof=weighted rrf"`
+	RRFK                 *int                 `json:"rrf_k,omitempty" validate:"omitempty,gte=1,lte=1000"`
+	MMR                  *bool                `json:"mmr,omitempty"`
+	MMRLambda            *float64             `json:"mmr_lambda,omitempty" validate:"omitempty,gte=0,lte=1"`
+	MaxChunksPerDocument *int                 `json:"max_chunks_per_document,omitempty" validate:"omitempty,gte=0,lte=50"`
+	NeighborWindow       *int                 `json:"neighbor_window,omitempty" validate:"omitempty,gte=0,lte=5"`                // Expand hits with ±N neighbouring chunks
+	RecencyWeight        *float64             `json:"recency_weight,omitempty" validate:"omitempty,gte=0,lte=5"`                 // Boost newer documents, 0 = disabled
+	QueryStrategy        *string              `json:"query_strategy,omitempty" validate:"omitempty,oneof=none multi_query hyde"` // none, multi_query or hyde
+	Filter               *domain.SearchFilter `json:"filter,omitempty"`                                                          // Metadata filters (categories, tags, published range, sources, document IDs)
+}
+
+// ChatFeedbackRequest rates a completion returned by the chat completions endpoint
+type ChatFeedbackRequest struct {
+	domain.Base
+	CompletionID string `json:"completion_id" validate:"required" doc:"ID of the rated completion (chatcmpl-...)"`
+	Rating       int    `json:"rating" validate:"oneof=-1 0 1" doc:"1 useful, -1 not useful, 0 clears the rating"`
 }
 
 // EmbeddingsRequest represents the OpenAI-compatible embeddings request
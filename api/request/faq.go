@@ -0,0 +1,41 @@
+package request
+
+import "api-chatbot/domain"
+
+// GetFAQsRequest request for listing the curated FAQs
+type GetFAQsRequest struct {
+	domain.Base
+	IncludeInactive bool `json:"includeInactive,omitempty" doc:"Also list inactive FAQs"`
+}
+
+// SaveFAQRequest request for creating or updating an FAQ
+type SaveFAQRequest struct {
+	domain.Base
+	FAQID     *int     `json:"faqId,omitempty" validate:"omitempty,gte=1" doc:"FAQ to update (omit to create a new one)"`
+	Question  string   `json:"question" validate:"required,min=3,max=500" doc:"Canonical question, e.g. ¿Cuánto cuesta la matrícula?"`
+	Variants  []string `json:"variants,omitempty" validate:"omitempty,max=30,dive,min=3,max=500" doc:"Other ways users ask the same question"`
+	Answer    string   `json:"answer" validate:"required,min=1,max=4000" doc:"Approved answer, sent exactly as written"`
+	Category  *string  `json:"category,omitempty" validate:"omitempty,max=100" doc:"Only match for this document category (default: every channel)"`
+	MediaURL  *string  `json:"mediaUrl,omitempty" validate:"omitempty,url,max=1000" doc:"Image or document sent after the answer"`
+	MediaType *string  `json:"mediaType,omitempty" validate:"omitempty,oneof=image document" doc:"Media type: image or document (required with mediaUrl)"`
+	Threshold *float64 `json:"threshold,omitempty" validate:"omitempty,gte=0,lte=1" doc:"Score at which this FAQ overrides generation (default: FAQ_CONFIG.threshold)"`
+	Active    *bool    `json:"active,omitempty" doc:"Whether the FAQ is matched (default: true)"`
+}
+
+// DeleteFAQRequest request for removing an FAQ
+type DeleteFAQRequest struct {
+	domain.Base
+	FAQID int `json:"faqId" validate:"required,gte=1" doc:"FAQ ID"`
+}
+
+// MatchFAQRequest request for previewing how a question matches the FAQs
+type MatchFAQRequest struct {
+	domain.Base
+	Query    string  `json:"query" validate:"required,min=1,max=1000" doc:"Question as a user would type it"`
+	Category *string `json:"category,omitempty" validate:"omitempty,max=100" doc:"Category of the channel asking (default: none)"`
+}
+
+// ReembedFAQsRequest request for re-embedding the FAQs with the current embedding model
+type ReembedFAQsRequest struct {
+	domain.Base
+}
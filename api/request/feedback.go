@@ -0,0 +1,26 @@
+package request
+
+import (
+	"api-chatbot/domain"
+)
+
+// SetMessageFeedbackRequest request for rating a bot answer from the admin panel
+type SetMessageFeedbackRequest struct {
+	domain.Base
+	MessageID string `json:"messageId" validate:"required" doc:"Message ID of the bot answer (WhatsApp ID or msg-<completion id>-assistant)"`
+	Rating    int    `json:"rating" validate:"oneof=-1 0 1" doc:"1 useful, -1 not useful, 0 clears the rating"`
+}
+
+// SetChunkRelevanceRequest request for labelling one chunk of a bot answer
+type SetChunkRelevanceRequest struct {
+	domain.Base
+	MessageID int   `json:"messageId" validate:"required,gte=1" doc:"Bot answer ID (cvm_id)"`
+	ChunkID   int   `json:"chunkId" validate:"required,gte=1" doc:"Chunk ID"`
+	Relevant  *bool `json:"relevant,omitempty" doc:"Whether the chunk was relevant to the answer (omit to remove the label and fall back to the answer rating)"`
+}
+
+// GetMessageChunksRequest request for listing the chunks a bot answer was built from
+type GetMessageChunksRequest struct {
+	domain.Base
+	MessageID int `json:"messageId" validate:"required,gte=1" doc:"Bot answer ID (cvm_id)"`
+}
@@ -0,0 +1,35 @@
+package request
+
+import "api-chatbot/domain"
+
+// GetGlossaryEntriesRequest request for listing the search glossary
+type GetGlossaryEntriesRequest struct {
+	domain.Base
+	Type *string `json:"type,omitempty" validate:"omitempty,oneof=synonym acronym" doc:"Filter by type: synonym, acronym"`
+}
+
+// SaveGlossaryEntryRequest request for creating or updating a glossary entry
+type SaveGlossaryEntryRequest struct {
+	domain.Base
+	EntryID    *int     `json:"entryId,omitempty" validate:"omitempty,gte=1" doc:"Entry to update (omit to create a new one)"`
+	Term       string   `json:"term" validate:"required,min=1,max=150" doc:"Term or acronym, e.g. ISTS"`
+	Type       string   `json:"type" validate:"omitempty,oneof=synonym acronym" doc:"Entry type: synonym (default) or acronym"`
+	Expansions []string `json:"expansions" validate:"required,min=1,max=20,dive,min=1,max=200" doc:"Equivalent words or phrases, e.g. the full institute name"`
+}
+
+// DeleteGlossaryEntryRequest request for removing a glossary entry
+type DeleteGlossaryEntryRequest struct {
+	domain.Base
+	EntryID int `json:"entryId" validate:"required,gte=1" doc:"Entry ID"`
+}
+
+// PreviewSearchQueryRequest request for showing how a query is matched against chunks
+type PreviewSearchQueryRequest struct {
+	domain.Base
+	Query string `json:"query" validate:"required,min=1,max=1000" doc:"Search query as a user would type it"`
+}
+
+// ReindexChunkFTSRequest request for recomputing the full-text vectors of every chunk
+type ReindexChunkFTSRequest struct {
+	domain.Base
+}
@@ -0,0 +1,20 @@
+package request
+
+import "api-chatbot/domain"
+
+// GetGuardrailEventsRequest request for listing guardrail triggers
+type GetGuardrailEventsRequest struct {
+	domain.Base
+	Stage       *string `json:"stage,omitempty" validate:"omitempty,oneof=input output" doc:"Filter by stage: input, output"`
+	Action      *string `json:"action,omitempty" validate:"omitempty,oneof=block rewrite escalate" doc:"Filter by action: block, rewrite, escalate"`
+	OnlyPending bool    `json:"onlyPending" doc:"Only return events not yet reviewed"`
+	Limit       int     `json:"limit" validate:"omitempty,min=1,max=200" doc:"Number of events to return (default: 50)"`
+	Offset      int     `json:"offset" validate:"omitempty,min=0" doc:"Offset for pagination (default: 0)"`
+}
+
+// ReviewGuardrailEventRequest request for marking a guardrail trigger as reviewed
+type ReviewGuardrailEventRequest struct {
+	domain.Base
+	EventID int     `json:"eventId" validate:"required,min=1" doc:"Guardrail event ID"`
+	Notes   *string `json:"notes,omitempty" validate:"omitempty,max=1000" doc:"Review notes (optional)"`
+}
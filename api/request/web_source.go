@@ -0,0 +1,40 @@
+package request
+
+import "api-chatbot/domain"
+
+// GetWebSourcesRequest request for listing the crawled websites
+type GetWebSourcesRequest struct {
+	domain.Base
+	IncludeInactive bool `json:"includeInactive,omitempty" doc:"Also list inactive web sources"`
+}
+
+// SaveWebSourceRequest request for creating or updating a web source
+type SaveWebSourceRequest struct {
+	domain.Base
+	SourceID        *int     `json:"sourceId,omitempty" validate:"omitempty,gte=1" doc:"Web source to update (omit to create a new one)"`
+	URL             string   `json:"url" validate:"required,url,max=500" doc:"Start page, or sitemap.xml when isSitemap is set"`
+	IsSitemap       bool     `json:"isSitemap,omitempty" doc:"Crawl the pages listed in the sitemap (or sitemap index) at url"`
+	Category        string   `json:"category" validate:"required" doc:"Category of the page documents"`
+	Tags            []string `json:"tags,omitempty" validate:"omitempty,max=30,dive,min=1,max=50"`
+	Audience        []string `json:"audience,omitempty" validate:"omitempty,max=10,dive,startswith=ROLE_,max=50" doc:"Roles allowed to retrieve the page documents; empty inherits the category audience"`
+	MaxDepth        *int     `json:"maxDepth,omitempty" validate:"omitempty,gte=0,lte=10" doc:"Link levels followed from the start page or the sitemap pages (default: 2)"`
+	MaxPages        *int     `json:"maxPages,omitempty" validate:"omitempty,gte=1,lte=10000" doc:"Pages fetched per crawl (default: 100, capped by WEB_CRAWL_CONFIG.maxPages)"`
+	IncludePatterns []string `json:"includePatterns,omitempty" validate:"omitempty,max=50,dive,min=1,max=500" doc:"Regexps: only matching page URLs become documents"`
+	ExcludePatterns []string `json:"excludePatterns,omitempty" validate:"omitempty,max=50,dive,min=1,max=500" doc:"Regexps: matching URLs are neither fetched nor followed"`
+	RecrawlHours    *int     `json:"recrawlHours,omitempty" validate:"omitempty,gte=1,lte=8760" doc:"Re-crawl interval in hours (omit to crawl on demand only)"`
+	ChunkSize       *int     `json:"chunkSize,omitempty" validate:"omitempty,gte=100,lte=5000"`
+	ChunkOverlap    *int     `json:"chunkOverlap,omitempty" validate:"omitempty,gte=0,lte=500"`
+	Active          *bool    `json:"active,omitempty" doc:"Whether the source is re-crawled (default: true)"`
+}
+
+// DeleteWebSourceRequest request for removing a web source (its page documents are kept)
+type DeleteWebSourceRequest struct {
+	domain.Base
+	SourceID int `json:"sourceId" validate:"required,gte=1" doc:"Web source ID"`
+}
+
+// CrawlWebSourceRequest request for crawling a web source now
+type CrawlWebSourceRequest struct {
+	domain.Base
+	SourceID int `json:"sourceId" validate:"required,gte=1" doc:"Web source ID"`
+}
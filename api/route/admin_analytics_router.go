@@ -46,8 +46,16 @@ type AnalyticsOverviewResponse struct {
 	Body d.Result[*d.AnalyticsOverview]
 }
 
+type KnowledgeGapRunResponse struct {
+	Body d.Result[*d.KnowledgeGapRun]
+}
+
+type KnowledgeGapRunsResponse struct {
+	Body d.Result[[]d.KnowledgeGapRun]
+}
+
 // RegisterAnalyticsRoutes registers all analytics endpoints
-func RegisterAnalyticsRoutes(humaAPI huma.API, analyticsUC d.AnalyticsUseCase) {
+func RegisterAnalyticsRoutes(humaAPI huma.API, analyticsUC d.AnalyticsUseCase, knowledgeGapUC d.KnowledgeGapUseCase) {
 
 	// =====================================================
 	// Dashboard Overview
@@ -237,4 +245,61 @@ func RegisterAnalyticsRoutes(humaAPI huma.API, analyticsUC d.AnalyticsUseCase) {
 		result := analyticsUC.GetSystemHealth(ctx)
 		return &SystemHealthResponse{Body: result}, nil
 	})
+
+	// =====================================================
+	// Knowledge Gaps
+	// =====================================================
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "get-knowledge-gaps",
+		Method:      "POST",
+		Path:        "/api/v1/admin/analytics/gaps",
+		Summary:     "Get the gaps to document",
+		Description: "Returns the clusters of similar user questions of the latest (or a given) stored analysis, the topics answered without good sources first. Each cluster has representative questions, the average retrieval confidence and the share answered without good sources.",
+		Tags:        []string{"Analytics"},
+	}, func(ctx context.Context, input *struct {
+		Body request.GetKnowledgeGapsRequest
+	}) (*KnowledgeGapRunResponse, error) {
+		limit := input.Body.Limit
+		if limit == 0 {
+			limit = 50
+		}
+		result := knowledgeGapUC.GetGaps(ctx, input.Body.RunID, input.Body.MinUnansweredShare, limit)
+		return &KnowledgeGapRunResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "analyze-knowledge-gaps",
+		Method:      "POST",
+		Path:        "/api/v1/admin/analytics/gaps/analyze",
+		Summary:     "Cluster the user questions of a period",
+		Description: "Embeds the user questions of a period and clusters them by meaning. Settings default to KNOWLEDGE_GAP_CONFIG. With save, the run becomes the latest gaps to document.",
+		Tags:        []string{"Analytics"},
+	}, func(ctx context.Context, input *struct {
+		Body request.AnalyzeKnowledgeGapsRequest
+	}) (*KnowledgeGapRunResponse, error) {
+		result := knowledgeGapUC.Analyze(ctx, d.AnalyzeKnowledgeGapsParams{
+			StartDate:           input.Body.StartDate,
+			EndDate:             input.Body.EndDate,
+			Period:              input.Body.Period,
+			SimilarityThreshold: input.Body.SimilarityThreshold,
+			MinClusterSize:      input.Body.MinClusterSize,
+			GoodScore:           input.Body.GoodScore,
+			Save:                input.Body.Save,
+		})
+		return &KnowledgeGapRunResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "get-knowledge-gap-runs",
+		Method:      "POST",
+		Path:        "/api/v1/admin/analytics/gaps/runs",
+		Summary:     "List knowledge gap analyses",
+		Description: "Returns the stored knowledge gap analyses, newest first, without their clusters.",
+		Tags:        []string{"Analytics"},
+	}, func(ctx context.Context, input *struct {
+		Body request.GetKnowledgeGapRunsRequest
+	}) (*KnowledgeGapRunsResponse, error) {
+		result := knowledgeGapUC.GetRuns(ctx, input.Body.Limit)
+		return &KnowledgeGapRunsResponse{Body: result}, nil
+	})
 }
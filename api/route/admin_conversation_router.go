@@ -39,6 +39,10 @@ type SetTemporaryResponse struct {
 	Body d.Result[d.Data]
 }
 
+type ResolveHandoffResponse struct {
+	Body d.Result[d.Data]
+}
+
 func SetupAdminConversationRoutes(humaAPI huma.API, adminConvUC d.AdminConversationUseCase) {
 
 	// GET /api/v1/admin/conversations - List all conversations
@@ -74,7 +78,7 @@ func SetupAdminConversationRoutes(humaAPI huma.API, adminConvUC d.AdminConversat
 		Method:      "POST",
 		Path:        "/api/v1/admin/conversations/get-messages",
 		Summary:     "Get conversation message history",
-		Description: "Retrieves all messages for a conversation",
+		Description: "Retrieves all messages for a conversation. Bot answers include their rating, best retrieval score and retrieval trace (query embedded, filters, candidate chunks with their scores, chunks sent to the LLM, prompt and model).",
 		Tags:        []string{"Admin - Conversations"},
 	}, func(ctx context.Context, input *struct {
 		Body request.GetConversationMessagesRequest
@@ -193,6 +197,21 @@ func SetupAdminConversationRoutes(humaAPI huma.API, adminConvUC d.AdminConversat
 		result := adminConvUC.SetConversationTemporary(ctx, params)
 		return &SetTemporaryResponse{Body: result}, nil
 	})
+
+	// POST /api/v1/admin/conversations/:id/resolve-handoff - Clear the needs-admin flag
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "resolve-conversation-handoff",
+		Method:      "POST",
+		Path:        "/api/v1/admin/conversations/resolve-handoff",
+		Summary:     "Resolve conversation handoff",
+		Description: "Clears the needs-admin flag set when the bot handed the conversation off for low confidence. Sending an admin message also clears it.",
+		Tags:        []string{"Admin - Conversations"},
+	}, func(ctx context.Context, input *struct {
+		Body request.ResolveHandoffRequest
+	}) (*ResolveHandoffResponse, error) {
+		result := adminConvUC.ResolveHandoff(ctx, input.Body.ConversationID)
+		return &ResolveHandoffResponse{Body: result}, nil
+	})
 }
 
 // generateMessageID generates a unique message ID for admin messages
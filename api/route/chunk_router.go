@@ -90,12 +90,24 @@ func NewChunkRouter(chunkUseCase d.ChunkUseCase, mux *http.ServeMux, humaAPI hum
 		Method:      "POST",
 		Path:        "/api/v1/chunks/hybrid-search",
 		Summary:     "Hybrid search (vector + full-text)",
-		Description: "Performs hybrid search combining semantic similarity (embeddings) and keyword matching (full-text search). Returns top K chunks ordered by combined score.",
+		Description: "Performs hybrid search combining semantic similarity (embeddings) and keyword matching (full-text search). Returns top K chunks ordered by the fusion score (weighted or RRF), optionally diversified with MMR and a per-document cap.",
 		Tags:        []string{"Chunks", "RAG"},
 	}, func(ctx context.Context, input *struct {
 		Body request.HybridSearchRequest
 	}) (*HybridSearchResponse, error) {
-		result := chunkUseCase.HybridSearch(ctx, input.Body.QueryText, input.Body.Limit, input.Body.MinSimilarity, input.Body.KeywordWeight)
+		opts := d.RetrievalOptions{
+			Fusion:               input.Body.Fusion,
+			RRFK:                 input.Body.RRFK,
+			MMR:                  input.Body.MMR,
+			MMRLambda:            input.Body.MMRLambda,
+			MaxChunksPerDocument: input.Body.MaxChunksPerDocument,
+			NeighborWindow:       input.Body.NeighborWindow,
+			RecencyWeight:        input.Body.RecencyWeight,
+			Filter:               input.Body.Filter,
+			QueryStrategy:        input.Body.QueryStrategy,
+			Role:                 input.Body.Role,
+		}
+		result := chunkUseCase.HybridSearch(ctx, input.Body.QueryText, input.Body.Limit, input.Body.MinSimilarity, input.Body.KeywordWeight, opts)
 		return &HybridSearchResponse{Body: result}, nil
 	})
 
@@ -44,6 +44,18 @@ type UploadPDFDocumentResponse struct {
 	Body d.Result[d.Data]
 }
 
+type UploadDocumentResponse struct {
+	Body d.Result[d.Data]
+}
+
+type PreviewChunksResponse struct {
+	Body d.Result[*d.ChunkPreviewResult]
+}
+
+type ExpireDocumentsResponse struct {
+	Body d.Result[[]d.ExpiredDocument]
+}
+
 func NewDocumentRouter(docUseCase d.DocumentUseCase, mux *http.ServeMux, humaAPI huma.API) {
 	// Huma documented routes with /api/v1/ prefix
 	huma.Register(humaAPI, huma.Operation{
@@ -127,6 +139,10 @@ func NewDocumentRouter(docUseCase d.DocumentUseCase, mux *http.ServeMux, humaAPI
 			Summary:     input.Body.Summary,
 			Source:      input.Body.Source,
 			PublishedAt: publishedAt,
+			Tags:        input.Body.Tags,
+			Audience:    input.Body.Audience,
+			ValidFrom:   parseOptionalTime(input.Body.ValidFrom),
+			ValidUntil:  parseOptionalTime(input.Body.ValidUntil),
 		}
 		result := docUseCase.Create(ctx, params)
 		return &CreateDocumentResponse{Body: result}, nil
@@ -158,6 +174,10 @@ func NewDocumentRouter(docUseCase d.DocumentUseCase, mux *http.ServeMux, humaAPI
 			Summary:     input.Body.Summary,
 			Source:      input.Body.Source,
 			PublishedAt: publishedAt,
+			Tags:        input.Body.Tags,
+			Audience:    input.Body.Audience,
+			ValidFrom:   parseOptionalTime(input.Body.ValidFrom),
+			ValidUntil:  parseOptionalTime(input.Body.ValidUntil),
 		}
 		result := docUseCase.Update(ctx, params)
 		return &UpdateDocumentResponse{Body: result}, nil
@@ -202,6 +222,10 @@ func NewDocumentRouter(docUseCase d.DocumentUseCase, mux *http.ServeMux, humaAPI
 			Category:     input.Body.Category,
 			Title:        input.Body.Title,
 			Source:       input.Body.Source,
+			Tags:         input.Body.Tags,
+			Audience:     input.Body.Audience,
+			ValidFrom:    parseOptionalTime(input.Body.ValidFrom),
+			ValidUntil:   parseOptionalTime(input.Body.ValidUntil),
 			FileBase64:   input.Body.FileBase64,
 			ChunkSize:    chunkSize,
 			ChunkOverlap: chunkOverlap,
@@ -209,4 +233,195 @@ func NewDocumentRouter(docUseCase d.DocumentUseCase, mux *http.ServeMux, humaAPI
 		result := docUseCase.UploadPDF(ctx, params)
 		return &UploadPDFDocumentResponse{Body: result}, nil
 	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID:  "upload-document",
+		Method:       "POST",
+		Path:         "/api/v1/documents/upload",
+		Summary:      "Upload document file",
+		Description:  "Uploads a PDF, DOCX, ODT, Markdown or plain-text file (base64 encoded), extracts its text keeping headings, lists and tables, creates a document, and generates chunks. Markdown and plain text are chunked along their headings, each chunk starting with its heading breadcrumb. XLSX and CSV files are imported as tables, one chunk per row (see /api/v1/documents/table to configure the mapping). The file type is taken from fileType, the fileName extension or the content.",
+		Tags:         []string{"Documents"},
+		MaxBodyBytes: 20 * 1024 * 1024, // 20MB limit for file uploads
+	}, func(ctx context.Context, input *struct {
+		Body request.UploadDocumentRequest
+	}) (*UploadDocumentResponse, error) {
+		// Size and overlap left unset come from the category or the strategy defaults
+		chunkSize := 0
+		if input.Body.ChunkSize != nil {
+			chunkSize = *input.Body.ChunkSize
+		}
+
+		params := d.UploadDocumentParams{
+			Category:      input.Body.Category,
+			Title:         input.Body.Title,
+			Source:        input.Body.Source,
+			Tags:          input.Body.Tags,
+			Audience:      input.Body.Audience,
+			ValidFrom:     parseOptionalTime(input.Body.ValidFrom),
+			ValidUntil:    parseOptionalTime(input.Body.ValidUntil),
+			FileBase64:    input.Body.FileBase64,
+			FileType:      input.Body.FileType,
+			FileName:      input.Body.FileName,
+			ChunkStrategy: input.Body.ChunkStrategy,
+			ChunkSize:     chunkSize,
+			ChunkOverlap:  input.Body.ChunkOverlap,
+		}
+		result := docUseCase.UploadDocument(ctx, params)
+		return &UploadDocumentResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID:  "create-text-document",
+		Method:       "POST",
+		Path:         "/api/v1/documents/text",
+		Summary:      "Create document from text",
+		Description:  "Creates a document from pasted Markdown or plain text. The text is chunked along its heading hierarchy: each chunk starts with its heading breadcrumb (e.g. Admisiones > Requisitos) and stores the headings for filtering and citation.",
+		Tags:         []string{"Documents"},
+		MaxBodyBytes: 20 * 1024 * 1024, // 20MB limit, same as file uploads
+	}, func(ctx context.Context, input *struct {
+		Body request.CreateTextDocumentRequest
+	}) (*UploadDocumentResponse, error) {
+		// Size and overlap left unset come from the category or the strategy defaults
+		chunkSize := 0
+		if input.Body.ChunkSize != nil {
+			chunkSize = *input.Body.ChunkSize
+		}
+
+		params := d.CreateTextDocumentParams{
+			Category:      input.Body.Category,
+			Title:         input.Body.Title,
+			Source:        input.Body.Source,
+			Tags:          input.Body.Tags,
+			Audience:      input.Body.Audience,
+			ValidFrom:     parseOptionalTime(input.Body.ValidFrom),
+			ValidUntil:    parseOptionalTime(input.Body.ValidUntil),
+			Content:       input.Body.Content,
+			Format:        input.Body.Format,
+			ChunkStrategy: input.Body.ChunkStrategy,
+			ChunkSize:     chunkSize,
+			ChunkOverlap:  input.Body.ChunkOverlap,
+		}
+		result := docUseCase.CreateFromText(ctx, params)
+		return &UploadDocumentResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID:  "import-table-document",
+		Method:       "POST",
+		Path:         "/api/v1/documents/table",
+		Summary:      "Import spreadsheet or CSV",
+		Description:  "Creates a document from an XLSX or CSV file (base64). Each row, or group of rows sharing the groupBy columns, becomes a self-describing chunk such as \"Carrera: Software | Semestre: 3 | Lunes: 08:00\". The mapping selects sheets, the header row, columns and their labels, and is kept for re-imports.",
+		Tags:         []string{"Documents"},
+		MaxBodyBytes: 20 * 1024 * 1024, // 20MB limit, same as file uploads
+	}, func(ctx context.Context, input *struct {
+		Body request.ImportTableRequest
+	}) (*UploadDocumentResponse, error) {
+		chunkSize := 1000
+		if input.Body.ChunkSize != nil {
+			chunkSize = *input.Body.ChunkSize
+		}
+
+		params := d.ImportTableParams{
+			Category:   input.Body.Category,
+			Title:      input.Body.Title,
+			Source:     input.Body.Source,
+			Tags:       input.Body.Tags,
+			Audience:   input.Body.Audience,
+			ValidFrom:  parseOptionalTime(input.Body.ValidFrom),
+			ValidUntil: parseOptionalTime(input.Body.ValidUntil),
+			FileBase64: input.Body.FileBase64,
+			FileType:   input.Body.FileType,
+			FileName:   input.Body.FileName,
+			Mapping:    input.Body.Mapping,
+			ChunkSize:  chunkSize,
+		}
+		result := docUseCase.ImportTable(ctx, params)
+		return &UploadDocumentResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID:  "reimport-table-document",
+		Method:       "POST",
+		Path:         "/api/v1/documents/table/reimport",
+		Summary:      "Re-import spreadsheet or CSV",
+		Description:  "Replaces the rows of a document imported from a spreadsheet or CSV with those of a new version of the file. The previous chunks are swapped for the new ones in one transaction; without a mapping the one of the previous import is reused.",
+		Tags:         []string{"Documents"},
+		MaxBodyBytes: 20 * 1024 * 1024, // 20MB limit, same as file uploads
+	}, func(ctx context.Context, input *struct {
+		Body request.ReimportTableRequest
+	}) (*UploadDocumentResponse, error) {
+		chunkSize := 1000
+		if input.Body.ChunkSize != nil {
+			chunkSize = *input.Body.ChunkSize
+		}
+
+		params := d.ImportTableParams{
+			DocID:      &input.Body.DocID,
+			FileBase64: input.Body.FileBase64,
+			FileType:   input.Body.FileType,
+			FileName:   input.Body.FileName,
+			Mapping:    input.Body.Mapping,
+			ChunkSize:  chunkSize,
+		}
+		result := docUseCase.ImportTable(ctx, params)
+		return &UploadDocumentResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID:  "preview-document-chunks",
+		Method:       "POST",
+		Path:         "/api/v1/documents/chunks/preview",
+		Summary:      "Preview document chunks",
+		Description:  "Splits a file (base64) or pasted text into chunks without storing anything, to compare chunking strategies before uploading. The strategy, size and overlap resolve as on upload: the request, then the chunking of the category, then the defaults. Each chunk reports its characters and estimated tokens.",
+		Tags:         []string{"Documents"},
+		MaxBodyBytes: 20 * 1024 * 1024, // 20MB limit, same as file uploads
+	}, func(ctx context.Context, input *struct {
+		Body request.PreviewChunksRequest
+	}) (*PreviewChunksResponse, error) {
+		chunkSize := 0
+		if input.Body.ChunkSize != nil {
+			chunkSize = *input.Body.ChunkSize
+		}
+
+		params := d.PreviewChunksParams{
+			Category:      input.Body.Category,
+			Content:       input.Body.Content,
+			Format:        input.Body.Format,
+			FileBase64:    input.Body.FileBase64,
+			FileType:      input.Body.FileType,
+			FileName:      input.Body.FileName,
+			ChunkStrategy: input.Body.ChunkStrategy,
+			ChunkSize:     chunkSize,
+			ChunkOverlap:  input.Body.ChunkOverlap,
+			Mapping:       input.Body.Mapping,
+		}
+		result := docUseCase.PreviewChunks(ctx, params)
+		return &PreviewChunksResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "expire-documents",
+		Method:      "POST",
+		Path:        "/api/v1/documents/expire",
+		Summary:     "Deactivate expired documents",
+		Description: "Deactivates the documents whose validity has ended (the scheduled job does it every DOCUMENT_EXPIRY_CONFIG.intervalMinutes) and returns them",
+		Tags:        []string{"Documents"},
+	}, func(ctx context.Context, input *struct {
+		Body request.ExpireDocumentsRequest
+	}) (*ExpireDocumentsResponse, error) {
+		result := docUseCase.ExpireDocuments(ctx)
+		return &ExpireDocumentsResponse{Body: result}, nil
+	})
+}
+
+// parseOptionalTime parses an RFC 3339 timestamp, ignoring empty or invalid values
+func parseOptionalTime(value *string) *time.Time {
+	if value == nil || *value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil
+	}
+	return &t
 }
@@ -0,0 +1,58 @@
+package route
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"api-chatbot/api/request"
+	d "api-chatbot/domain"
+)
+
+type GetEmbeddingCacheStatsResponse struct {
+	Body d.Result[[]d.EmbeddingCacheStats]
+}
+
+type PurgeEmbeddingCacheResponse struct {
+	Body d.Result[d.Data]
+}
+
+func NewEmbeddingCacheRouter(embeddingCacheUC d.EmbeddingCacheUseCase, humaAPI huma.API) {
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "get-embedding-cache-stats",
+		Method:      "POST",
+		Path:        "/api/v1/admin/embedding-cache/stats",
+		Summary:     "Get embedding cache stats",
+		Description: "Returns cached entries, hits, misses and hit rate per embedding model",
+		Tags:        []string{"Admin - Embeddings"},
+	}, func(ctx context.Context, input *struct {
+		Body request.GetEmbeddingCacheStatsRequest
+	}) (*GetEmbeddingCacheStatsResponse, error) {
+		days := input.Body.Days
+		if days == 0 {
+			days = 30
+		}
+
+		result := embeddingCacheUC.GetStats(ctx, days)
+		return &GetEmbeddingCacheStatsResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "purge-embedding-cache",
+		Method:      "POST",
+		Path:        "/api/v1/admin/embedding-cache/purge",
+		Summary:     "Purge embedding cache",
+		Description: "Deletes cached embeddings, optionally only for one model and/or entries unused for a number of days",
+		Tags:        []string{"Admin - Embeddings"},
+	}, func(ctx context.Context, input *struct {
+		Body request.PurgeEmbeddingCacheRequest
+	}) (*PurgeEmbeddingCacheResponse, error) {
+		params := d.PurgeEmbeddingCacheParams{
+			Model:      input.Body.Model,
+			UnusedDays: input.Body.UnusedDays,
+		}
+
+		result := embeddingCacheUC.Purge(ctx, params)
+		return &PurgeEmbeddingCacheResponse{Body: result}, nil
+	})
+}
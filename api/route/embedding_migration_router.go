@@ -0,0 +1,115 @@
+package route
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"api-chatbot/api/request"
+	d "api-chatbot/domain"
+)
+
+type EmbeddingMigrationResponse struct {
+	Body d.Result[d.Data]
+}
+
+type GetEmbeddingJobsResponse struct {
+	Body d.Result[[]d.EmbeddingJob]
+}
+
+func NewEmbeddingMigrationRouter(embeddingMigrationUC d.EmbeddingMigrationUseCase, humaAPI huma.API) {
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "start-embedding-migration",
+		Method:      "POST",
+		Path:        "/api/v1/admin/embedding-migration/start",
+		Summary:     "Start embedding migration",
+		Description: "Re-embeds every chunk in the background with the configuration in configCode. Searches keep using the current embeddings until the swap.",
+		Tags:        []string{"Admin - Embeddings"},
+	}, func(ctx context.Context, input *struct {
+		Body request.StartEmbeddingMigrationRequest
+	}) (*EmbeddingMigrationResponse, error) {
+		batchSize := input.Body.BatchSize
+		if batchSize == 0 {
+			batchSize = 100
+		}
+
+		params := d.StartEmbeddingMigrationParams{
+			ConfigCode: input.Body.ConfigCode,
+			BatchSize:  batchSize,
+			AutoSwap:   input.Body.AutoSwap,
+		}
+
+		result := embeddingMigrationUC.Start(ctx, params)
+		return &EmbeddingMigrationResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "pause-embedding-migration",
+		Method:      "POST",
+		Path:        "/api/v1/admin/embedding-migration/pause",
+		Summary:     "Pause embedding migration",
+		Description: "Stops the background worker, keeping the embeddings generated so far",
+		Tags:        []string{"Admin - Embeddings"},
+	}, func(ctx context.Context, input *struct {
+		Body request.EmbeddingJobRequest
+	}) (*EmbeddingMigrationResponse, error) {
+		result := embeddingMigrationUC.Pause(ctx, input.Body.JobID)
+		return &EmbeddingMigrationResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "resume-embedding-migration",
+		Method:      "POST",
+		Path:        "/api/v1/admin/embedding-migration/resume",
+		Summary:     "Resume embedding migration",
+		Description: "Continues a paused or completed job with the chunks that still lack a new embedding",
+		Tags:        []string{"Admin - Embeddings"},
+	}, func(ctx context.Context, input *struct {
+		Body request.EmbeddingJobRequest
+	}) (*EmbeddingMigrationResponse, error) {
+		result := embeddingMigrationUC.Resume(ctx, input.Body.JobID)
+		return &EmbeddingMigrationResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "cancel-embedding-migration",
+		Method:      "POST",
+		Path:        "/api/v1/admin/embedding-migration/cancel",
+		Summary:     "Cancel embedding migration",
+		Description: "Stops the job and discards the embeddings generated for it",
+		Tags:        []string{"Admin - Embeddings"},
+	}, func(ctx context.Context, input *struct {
+		Body request.EmbeddingJobRequest
+	}) (*EmbeddingMigrationResponse, error) {
+		result := embeddingMigrationUC.Cancel(ctx, input.Body.JobID)
+		return &EmbeddingMigrationResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "swap-embedding-set",
+		Method:      "POST",
+		Path:        "/api/v1/admin/embedding-migration/swap",
+		Summary:     "Swap embedding set",
+		Description: "Atomically replaces the active embeddings with the re-embedded ones, rebuilds the vector index and switches EMBEDDING_CONFIG to the new model. If chunks changed after the job completed, the job embeds them first and swaps when done (status running).",
+		Tags:        []string{"Admin - Embeddings"},
+	}, func(ctx context.Context, input *struct {
+		Body request.EmbeddingJobRequest
+	}) (*EmbeddingMigrationResponse, error) {
+		result := embeddingMigrationUC.Swap(ctx, input.Body.JobID)
+		return &EmbeddingMigrationResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "get-embedding-migration-progress",
+		Method:      "POST",
+		Path:        "/api/v1/admin/embedding-migration/progress",
+		Summary:     "Get embedding migration progress",
+		Description: "Returns status and progress of one job or of the latest jobs",
+		Tags:        []string{"Admin - Embeddings"},
+	}, func(ctx context.Context, input *struct {
+		Body request.GetEmbeddingJobsRequest
+	}) (*GetEmbeddingJobsResponse, error) {
+		result := embeddingMigrationUC.GetJobs(ctx, input.Body.JobID)
+		return &GetEmbeddingJobsResponse{Body: result}, nil
+	})
+}
@@ -0,0 +1,152 @@
+package route
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"api-chatbot/api/request"
+	d "api-chatbot/domain"
+)
+
+type EvaluationResponse struct {
+	Body d.Result[d.Data]
+}
+
+type GetEvalQuestionsResponse struct {
+	Body d.Result[[]d.EvalQuestion]
+}
+
+type EvalRunResponse struct {
+	Body d.Result[*d.EvalRun]
+}
+
+type GetEvalRunsResponse struct {
+	Body d.Result[[]d.EvalRun]
+}
+
+func NewEvaluationRouter(evaluationUC d.EvaluationUseCase, humaAPI huma.API) {
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "create-eval-question",
+		Method:      "POST",
+		Path:        "/api/v1/admin/evaluation/questions/create",
+		Summary:     "Create golden set question",
+		Description: "Adds a question to a golden set, labelled with the chunk and/or document IDs that answer it",
+		Tags:        []string{"Admin - Evaluation"},
+	}, func(ctx context.Context, input *struct {
+		Body request.CreateEvalQuestionRequest
+	}) (*EvaluationResponse, error) {
+		params := d.CreateEvalQuestionParams{
+			Set:                 input.Body.Set,
+			Question:            input.Body.Question,
+			Category:            input.Body.Category,
+			RelevantChunkIDs:    input.Body.RelevantChunkIDs,
+			RelevantDocumentIDs: input.Body.RelevantDocumentIDs,
+		}
+		result := evaluationUC.CreateQuestion(ctx, params)
+		return &EvaluationResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "delete-eval-question",
+		Method:      "POST",
+		Path:        "/api/v1/admin/evaluation/questions/delete",
+		Summary:     "Delete golden set question",
+		Description: "Removes a question from its golden set. Stored run reports are kept.",
+		Tags:        []string{"Admin - Evaluation"},
+	}, func(ctx context.Context, input *struct {
+		Body request.DeleteEvalQuestionRequest
+	}) (*EvaluationResponse, error) {
+		result := evaluationUC.DeleteQuestion(ctx, input.Body.QuestionID)
+		return &EvaluationResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "get-eval-questions",
+		Method:      "POST",
+		Path:        "/api/v1/admin/evaluation/questions",
+		Summary:     "Get golden set questions",
+		Description: "Lists the questions of a golden set with their relevance labels",
+		Tags:        []string{"Admin - Evaluation"},
+	}, func(ctx context.Context, input *struct {
+		Body request.GetEvalQuestionsRequest
+	}) (*GetEvalQuestionsResponse, error) {
+		result := evaluationUC.GetQuestions(ctx, input.Body.Set)
+		return &GetEvalQuestionsResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "import-eval-questions",
+		Method:      "POST",
+		Path:        "/api/v1/admin/evaluation/questions/import",
+		Summary:     "Import golden set questions",
+		Description: "Adds several labelled questions to a golden set (same fields as the JSONL file used by cmd/evaluate). Invalid questions are reported and skipped.",
+		Tags:        []string{"Admin - Evaluation"},
+	}, func(ctx context.Context, input *struct {
+		Body request.ImportEvalQuestionsRequest
+	}) (*EvaluationResponse, error) {
+		result := evaluationUC.ImportQuestions(ctx, input.Body.Set, input.Body.Questions)
+		return &EvaluationResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "run-evaluation",
+		Method:      "POST",
+		Path:        "/api/v1/admin/evaluation/run",
+		Summary:     "Run retrieval evaluation",
+		Description: "Searches every golden set question with the current index and search settings (optionally overridden) and reports Precision@k, Recall@k, F1@k, MRR, MAP, NDCG and hit rate, averaged and per question. With save, the report is stored for comparison.",
+		Tags:        []string{"Admin - Evaluation"},
+	}, func(ctx context.Context, input *struct {
+		Body request.RunEvaluationRequest
+	}) (*EvalRunResponse, error) {
+		params := d.RunEvaluationParams{
+			Set:           input.Body.Set,
+			Label:         input.Body.Label,
+			K:             input.Body.K,
+			MinSimilarity: input.Body.MinSimilarity,
+			KeywordWeight: input.Body.KeywordWeight,
+			Options: d.RetrievalOptions{
+				Fusion:               input.Body.Fusion,
+				RRFK:                 input.Body.RRFK,
+				MMR:                  input.Body.MMR,
+				MMRLambda:            input.Body.MMRLambda,
+				MaxChunksPerDocument: input.Body.MaxChunksPerDocument,
+				NeighborWindow:       input.Body.NeighborWindow,
+				RecencyWeight:        input.Body.RecencyWeight,
+				Filter:               input.Body.Filter,
+				QueryStrategy:        input.Body.QueryStrategy,
+			},
+			Save: input.Body.Save,
+		}
+		result := evaluationUC.RunEvaluation(ctx, params)
+		return &EvalRunResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "get-eval-runs",
+		Method:      "POST",
+		Path:        "/api/v1/admin/evaluation/runs",
+		Summary:     "Get evaluation runs",
+		Description: "Lists stored evaluation runs, newest first, with their settings and averaged metrics",
+		Tags:        []string{"Admin - Evaluation"},
+	}, func(ctx context.Context, input *struct {
+		Body request.GetEvalRunsRequest
+	}) (*GetEvalRunsResponse, error) {
+		result := evaluationUC.GetRuns(ctx, input.Body.Set, input.Body.Limit)
+		return &GetEvalRunsResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "get-eval-run",
+		Method:      "POST",
+		Path:        "/api/v1/admin/evaluation/runs/get",
+		Summary:     "Get evaluation run",
+		Description: "Returns a stored evaluation run with its per-question results",
+		Tags:        []string{"Admin - Evaluation"},
+	}, func(ctx context.Context, input *struct {
+		Body request.GetEvalRunRequest
+	}) (*EvalRunResponse, error) {
+		result := evaluationUC.GetRun(ctx, input.Body.RunID)
+		return &EvalRunResponse{Body: result}, nil
+	})
+}
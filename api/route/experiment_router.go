@@ -0,0 +1,106 @@
+package route
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"api-chatbot/api/request"
+	d "api-chatbot/domain"
+)
+
+type ExperimentResponse struct {
+	Body d.Result[d.Data]
+}
+
+type GetExperimentsResponse struct {
+	Body d.Result[[]d.Experiment]
+}
+
+type CompareExperimentVariantsResponse struct {
+	Body d.Result[[]d.VariantComparison]
+}
+
+func NewExperimentRouter(experimentUC d.ExperimentUseCase, humaAPI huma.API) {
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "create-experiment",
+		Method:      "POST",
+		Path:        "/api/v1/admin/experiments/create",
+		Summary:     "Create experiment",
+		Description: "Creates an A/B experiment with variants over prompt version, model, temperature, search limit and keyword weight. The experiment starts inactive.",
+		Tags:        []string{"Admin - Experiments"},
+	}, func(ctx context.Context, input *struct {
+		Body request.CreateExperimentRequest
+	}) (*ExperimentResponse, error) {
+		variants := make([]d.CreateExperimentVariantParams, 0, len(input.Body.Variants))
+		for _, variant := range input.Body.Variants {
+			weight := variant.Weight
+			if weight == 0 {
+				weight = 1
+			}
+			variants = append(variants, d.CreateExperimentVariantParams{
+				Code:   variant.Code,
+				Weight: weight,
+				Config: variant.Config,
+			})
+		}
+
+		params := d.CreateExperimentParams{
+			Code:        input.Body.Code,
+			Name:        input.Body.Name,
+			Description: input.Body.Description,
+			Variants:    variants,
+		}
+
+		result := experimentUC.CreateExperiment(ctx, params)
+		return &ExperimentResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "set-experiment-status",
+		Method:      "POST",
+		Path:        "/api/v1/admin/experiments/set-status",
+		Summary:     "Start or stop experiment",
+		Description: "Starts an experiment (stopping any other running one) or stops it. Conversations are assigned to variants sticky by chat ID.",
+		Tags:        []string{"Admin - Experiments"},
+	}, func(ctx context.Context, input *struct {
+		Body request.SetExperimentStatusRequest
+	}) (*ExperimentResponse, error) {
+		result := experimentUC.SetExperimentStatus(ctx, input.Body.ExperimentID, input.Body.Active)
+		return &ExperimentResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "get-experiments",
+		Method:      "POST",
+		Path:        "/api/v1/admin/experiments/get-all",
+		Summary:     "Get experiments",
+		Description: "Lists experiments with their variants",
+		Tags:        []string{"Admin - Experiments"},
+	}, func(ctx context.Context, input *struct {
+		Body request.GetExperimentsRequest
+	}) (*GetExperimentsResponse, error) {
+		result := experimentUC.GetExperiments(ctx)
+		return &GetExperimentsResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "compare-experiment-variants",
+		Method:      "POST",
+		Path:        "/api/v1/admin/analytics/experiments/compare",
+		Summary:     "Compare experiment variants",
+		Description: "Compares the variants of an experiment on latency, tokens, retrieval similarity, user feedback and human-handoff rate",
+		Tags:        []string{"Analytics"},
+	}, func(ctx context.Context, input *struct {
+		Body request.CompareExperimentVariantsRequest
+	}) (*CompareExperimentVariantsResponse, error) {
+		params := d.CompareVariantsParams{
+			ExperimentID: input.Body.ExperimentID,
+			StartDate:    input.Body.StartDate,
+			EndDate:      input.Body.EndDate,
+		}
+
+		result := experimentUC.CompareVariants(ctx, params)
+		return &CompareExperimentVariantsResponse{Body: result}, nil
+	})
+}
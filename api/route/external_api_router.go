@@ -9,8 +9,11 @@ import (
 	"strings"
 	"time"
 
+	"api-chatbot/api/middleware"
 	"api-chatbot/api/request"
 	d "api-chatbot/domain"
+	"api-chatbot/internal/guardrails"
+	"api-chatbot/internal/handoff"
 	"api-chatbot/internal/llm"
 	"api-chatbot/internal/logger"
 
@@ -22,14 +25,22 @@ type ChatCompletionsResponse struct {
 	Body d.Result[d.ChatCompletionsResponse]
 }
 
+type ChatFeedbackResponse struct {
+	Body d.Result[d.Data]
+}
+
 func NewExternalAPIRouter(
 	chunkUseCase d.ChunkUseCase,
+	faqUseCase d.FAQUseCase,
 	embeddingService d.EmbeddingService,
 	llmProvider llm.Provider,
+	guardrailPipeline *guardrails.Pipeline,
+	experimentUseCase d.ExperimentUseCase,
 	cache d.ParameterCache,
 	apiKeyUseCase d.APIKeyUseCase,
 	apiUsageRepo d.APIUsageRepository,
 	conversationUseCase d.ConversationUseCase,
+	feedbackUseCase d.FeedbackUseCase,
 	mux *http.ServeMux,
 	humaAPI huma.API,
 ) {
@@ -125,6 +136,59 @@ func NewExternalAPIRouter(
 			}
 		}
 
+		// Input guardrails run before retrieval
+		guardMeta := guardrails.Meta{Channel: "api", ChatID: chatID, ConversationID: conversationID}
+		inputCheck := guardrailPipeline.Run(ctx, guardrails.StageInput, userMessage, guardMeta)
+		userMessage = inputCheck.Text
+
+		if inputCheck.Stopped() {
+			logger.LogWarn(ctx, "Chat completion stopped by input guardrail",
+				"operation", "ChatCompletions",
+				"action", inputCheck.Action,
+				"deviceID", chatID,
+			)
+			reply := getGuardrailMessage(cache, inputCheck.Action)
+			if conversationID > 0 {
+				// Stored so admins see what was stopped and, when escalated, what to answer
+				now := time.Now().Unix()
+				for _, message := range []d.CreateConversationMessageParams{
+					{MessageID: fmt.Sprintf("msg-%s-user", completionID), SenderType: "user", Body: &userMessage, Timestamp: now},
+					{MessageID: fmt.Sprintf("msg-%s-assistant", completionID), FromMe: true, SenderType: "bot", Body: &reply, Timestamp: now,
+						Metadata: d.Data{"guardrail": string(inputCheck.Action)}},
+				} {
+					message.ConversationID = conversationID
+					message.MessageType = "text"
+					if result := conversationUseCase.StoreMessageWithStats(ctx, message); !result.Success {
+						logger.LogWarn(ctx, "Failed to store guardrail exchange",
+							"operation", "ChatCompletions",
+							"code", result.Code,
+						)
+					}
+				}
+			}
+			if inputCheck.Action == guardrails.ActionEscalate {
+				escalateConversation(ctx, conversationUseCase, conversationID)
+			}
+			return &ChatCompletionsResponse{
+				Body: d.Success(d.ChatCompletionsResponse{
+					ID:      completionID,
+					Object:  "chat.completion",
+					Created: time.Now().Unix(),
+					Model:   input.Body.Model,
+					Choices: []d.ChatCompletionChoice{
+						{
+							Index: 0,
+							Message: d.ChatMessage{
+								Role:    "assistant",
+								Content: reply,
+							},
+							FinishReason: "content_filter",
+						},
+					},
+				}),
+			}, nil
+		}
+
 		// Query expansion: If event_filter is provided, expand the query with event keywords
 		// This improves semantic similarity for generic queries like "De qué es este evento"
 		expandedQuery := userMessage
@@ -162,14 +226,37 @@ func NewExternalAPIRouter(
 			)
 		}
 
+		// Running experiment: the device's variant fills in settings the request leaves unset
+		variant := experimentUseCase.AssignVariant(ctx, chatID)
+
 		// Prepare RAG context if enabled
 		var ragContext *d.RAGContextInfo
 		var retrievedContext string
 		var selectedCategory *string
+		var retrievalTrace *d.RetrievalTrace
+		var faqMatch *d.FAQMatch
 
+		// Curated FAQs are matched before retrieval; one reaching its threshold is answered as written
 		if input.Body.RAGConfig != nil && input.Body.RAGConfig.Enabled {
+			if len(input.Body.RAGConfig.EventFilter) > 0 {
+				selectedCategory = &input.Body.RAGConfig.EventFilter[0]
+			}
+			faqResult := faqUseCase.Answer(ctx, userMessage, selectedCategory)
+			if !faqResult.Success {
+				logger.LogWarn(ctx, "FAQ matching failed, continuing with RAG",
+					"operation", "ChatCompletions",
+					"code", faqResult.Code,
+				)
+			}
+			faqMatch = faqResult.Data
+		}
+
+		if input.Body.RAGConfig != nil && input.Body.RAGConfig.Enabled && faqMatch == nil {
 			// Set defaults
 			searchLimit := input.Body.RAGConfig.SearchLimit
+			if searchLimit == 0 && variant != nil && variant.Config.SearchLimit != nil {
+				searchLimit = *variant.Config.SearchLimit
+			}
 			if searchLimit == 0 {
 				searchLimit = 7
 			}
@@ -178,6 +265,9 @@ func NewExternalAPIRouter(
 				minSimilarity = 0.7
 			}
 			keywordWeight := input.Body.RAGConfig.KeywordWeight
+			if keywordWeight == 0 && variant != nil && variant.Config.KeywordWeight != nil {
+				keywordWeight = *variant.Config.KeywordWeight
+			}
 			if keywordWeight == 0 {
 				keywordWeight = 0.3
 			}
@@ -224,13 +314,28 @@ func NewExternalAPIRouter(
 				}(),
 			)
 
+			retrievalOpts := d.RetrievalOptions{
+				Fusion:               input.Body.RAGConfig.Fusion,
+				RRFK:                 input.Body.RAGConfig.RRFK,
+				MMR:                  input.Body.RAGConfig.MMR,
+				MMRLambda:            input.Body.RAGConfig.MMRLambda,
+				MaxChunksPerDocument: input.Body.RAGConfig.MaxChunksPerDocument,
+				NeighborWindow:       input.Body.RAGConfig.NeighborWindow,
+				RecencyWeight:        input.Body.RAGConfig.RecencyWeight,
+				Filter:               input.Body.RAGConfig.Filter,
+				QueryStrategy:        input.Body.RAGConfig.QueryStrategy,
+				Role:                 apiKeyRole(ctx),
+			}
+			retrievalTrace = &d.RetrievalTrace{}
+			retrievalOpts.Trace = retrievalTrace
+
 			var searchResult d.Result[[]d.ChunkWithHybridSimilarity]
 			if selectedCategory != nil {
 				// Use category-filtered search with expanded query
-				searchResult = chunkUseCase.HybridSearchWithCategory(ctx, expandedQuery, searchLimit, minSimilarity, keywordWeight, selectedCategory)
+				searchResult = chunkUseCase.HybridSearchWithCategory(ctx, expandedQuery, searchLimit, minSimilarity, keywordWeight, selectedCategory, retrievalOpts)
 			} else {
 				// Use regular search with expanded query
-				searchResult = chunkUseCase.HybridSearch(ctx, expandedQuery, searchLimit, minSimilarity, keywordWeight)
+				searchResult = chunkUseCase.HybridSearch(ctx, expandedQuery, searchLimit, minSimilarity, keywordWeight, retrievalOpts)
 			}
 
 			if searchResult.Success && len(searchResult.Data) > 0 {
@@ -240,6 +345,7 @@ func NewExternalAPIRouter(
 				ragContext = &d.RAGContextInfo{
 					ChunksRetrieved: len(chunks),
 					Sources:         make([]d.SourceInfo, 0, len(chunks)),
+					QueryStrategy:   chunks[0].QueryStrategy,
 				}
 
 				contextBuilder.WriteString("Relevant information from knowledge base:\n\n")
@@ -252,7 +358,9 @@ func NewExternalAPIRouter(
 						DocumentID:    chunk.DocumentID,
 						DocumentTitle: chunk.DocTitle,
 						ChunkID:       chunk.ID,
+						Headings:      chunk.Headings,
 						Similarity:    chunk.CombinedScore,
+						RerankScore:   chunk.RerankScore,
 					})
 				}
 			} else {
@@ -276,9 +384,15 @@ func NewExternalAPIRouter(
 
 		// Set parameters
 		if input.Body.Temperature != nil {
-			llmRequest.Temperature = *input.Body.Temperature
+			llmRequest.Temperature = input.Body.Temperature
+		} else if variant != nil && variant.Config.Temperature != nil {
+			llmRequest.Temperature = variant.Config.Temperature
 		} else {
-			llmRequest.Temperature = 0.7
+			llmRequest.Temperature = llm.Temperature(0.7)
+		}
+
+		if variant != nil {
+			llmRequest.Model = variant.Config.Model
 		}
 
 		if input.Body.MaxTokens != nil {
@@ -287,6 +401,9 @@ func NewExternalAPIRouter(
 			llmRequest.MaxTokens = 1000
 		}
 
+		// Parameter the system prompt was read from (recorded in the retrieval trace)
+		var promptCode string
+
 		// Set system prompt based on category
 		// If category is specified, try to get category-specific prompt first (e.g., "RAG_SYSTEM_PROMPT_DOC_INDECT")
 		// Otherwise, fallback to general "RAG_SYSTEM_PROMPT"
@@ -296,6 +413,7 @@ func NewExternalAPIRouter(
 				dataMap, _ := param.GetDataAsMap()
 				if systemPrompt, ok := dataMap["message"].(string); ok {
 					llmRequest.SystemPrompt = systemPrompt
+					promptCode = categoryPromptCode
 					logger.LogInfo(ctx, "Using category-specific system prompt",
 						"operation", "ChatCompletions",
 						"promptCode", categoryPromptCode,
@@ -304,12 +422,19 @@ func NewExternalAPIRouter(
 			}
 		}
 
+		// Experiment variant prompt replaces the general system prompt (category prompts keep precedence)
+		if llmRequest.SystemPrompt == "" && variant != nil {
+			llmRequest.SystemPrompt = variant.Config.ResolveSystemPrompt(cache)
+			promptCode = variant.Config.PromptCode
+		}
+
 		// Fallback to general system prompt if no category-specific prompt was found
 		if llmRequest.SystemPrompt == "" {
 			if param, exists := cache.Get("RAG_SYSTEM_PROMPT"); exists {
 				dataMap, _ := param.GetDataAsMap()
 				if systemPrompt, ok := dataMap["message"].(string); ok {
 					llmRequest.SystemPrompt = systemPrompt
+					promptCode = "RAG_SYSTEM_PROMPT"
 					logger.LogInfo(ctx, "Using general system prompt",
 						"operation", "ChatCompletions",
 					)
@@ -317,13 +442,33 @@ func NewExternalAPIRouter(
 			}
 		}
 
-		// Call LLM
-		llmResponse, err := llmProvider.GenerateResponse(ctx, llmRequest)
-		if err != nil {
-			logger.LogError(ctx, "LLM generation failed", err,
-				"operation", "ChatCompletions",
-			)
-			return nil, huma.Error500InternalServerError("Failed to generate response")
+		var llmResponse *llm.GenerateResponse
+		if faqMatch != nil {
+			// Approved wording: neither generated nor rewritten by the output guardrails
+			llmResponse = &llm.GenerateResponse{Content: faqMatch.Answer, FinishReason: "stop"}
+		} else {
+			// Call LLM
+			var err error
+			llmResponse, err = llmProvider.GenerateResponse(ctx, llmRequest)
+			if err != nil {
+				logger.LogError(ctx, "LLM generation failed", err,
+					"operation", "ChatCompletions",
+				)
+				return nil, huma.Error500InternalServerError("Failed to generate response")
+			}
+
+			// Output guardrails run on the generated answer before it is stored and returned
+			outputCtx := guardrails.WithSystemPrompt(ctx, llmRequest.SystemPrompt)
+			outputCheck := guardrailPipeline.Run(outputCtx, guardrails.StageOutput, llmResponse.Content, guardMeta)
+			if outputCheck.Stopped() {
+				llmResponse.Content = getGuardrailMessage(cache, outputCheck.Action)
+				llmResponse.FinishReason = "content_filter"
+				if outputCheck.Action == guardrails.ActionEscalate {
+					escalateConversation(ctx, conversationUseCase, conversationID)
+				}
+			} else {
+				llmResponse.Content = outputCheck.Text
+			}
 		}
 
 		// Save assistant response to database
@@ -341,6 +486,56 @@ func NewExternalAPIRouter(
 				PromptTokens:     llmResponse.PromptTokens,
 				CompletionTokens: llmResponse.CompletionTokens,
 				TotalTokens:      llmResponse.TotalTokens,
+				Metadata: d.Data{
+					"responseTimeMs": time.Since(startTime).Milliseconds(),
+				},
+			}
+			if ragContext != nil {
+				assistantParams.Metadata["ragChunks"] = ragContext.ChunksRetrieved
+				// Sources are in rerank order when reranking is enabled, so look for the best similarity
+				var bestSimilarity float64
+				var rerankScores []float64
+				for i, source := range ragContext.Sources {
+					// Chunks the answer was built from, so feedback can be attributed to them
+					assistantParams.ChunkIDs = append(assistantParams.ChunkIDs, source.ChunkID)
+					assistantParams.ChunkScores = append(assistantParams.ChunkScores, source.Similarity)
+					if i == 0 || source.Similarity > bestSimilarity {
+						bestSimilarity = source.Similarity
+					}
+					if source.RerankScore != nil {
+						rerankScores = append(rerankScores, *source.RerankScore)
+					}
+				}
+				if len(ragContext.Sources) > 0 {
+					assistantParams.Metadata["ragBestSimilarity"] = bestSimilarity
+				}
+				if len(rerankScores) > 0 {
+					assistantParams.Metadata["ragRerankScores"] = rerankScores
+				}
+				if ragContext.QueryStrategy != "" {
+					assistantParams.Metadata["ragQueryStrategy"] = ragContext.QueryStrategy
+				}
+			} else if faqMatch != nil {
+				assistantParams.Metadata["faqId"] = faqMatch.FAQID
+				assistantParams.Metadata["faqScore"] = faqMatch.Score
+			} else if input.Body.RAGConfig != nil && input.Body.RAGConfig.Enabled {
+				assistantParams.Metadata["ragChunks"] = 0
+			}
+			if variant != nil {
+				assistantParams.VariantID = &variant.ID
+				for key, value := range variant.MessageMetadata() {
+					assistantParams.Metadata[key] = value
+				}
+			}
+			if retrievalTrace != nil {
+				// The search filled the query and candidates; record what was sent to the LLM
+				retrievalTrace.ContextChunkIDs = assistantParams.ChunkIDs
+				retrievalTrace.PromptCode = promptCode
+				if variant != nil {
+					retrievalTrace.PromptVersion = variant.Config.PromptVersion
+				}
+				retrievalTrace.Model = llmResponse.Model
+				assistantParams.RetrievalTrace = retrievalTrace
 			}
 			conversationUseCase.StoreMessageWithStats(ctx, assistantParams)
 		}
@@ -363,6 +558,15 @@ func NewExternalAPIRouter(
 			},
 			RAGContext: ragContext,
 		}
+		if faqMatch != nil {
+			completionData.FAQ = &d.FAQAnswerInfo{
+				FAQID:     faqMatch.FAQID,
+				Question:  faqMatch.Question,
+				Score:     faqMatch.Score,
+				MediaURL:  faqMatch.MediaURL,
+				MediaType: faqMatch.MediaType,
+			}
+		}
 
 		// Add usage info if available
 		if llmResponse.TotalTokens != nil {
@@ -385,6 +589,28 @@ func NewExternalAPIRouter(
 		}, nil
 	})
 
+	// POST /v1/chat/feedback
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "chat-feedback",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/chat/feedback",
+		Summary:     "Rate a chat completion",
+		Description: "Rates a completion returned by /api/v1/chat/completions (1 useful, -1 not useful, 0 clears the rating). The rating feeds the quality metrics of the chunks the answer was built from.",
+		Tags:        []string{"External API"},
+	}, func(ctx context.Context, input *struct {
+		Body request.ChatFeedbackRequest
+	}) (*ChatFeedbackResponse, error) {
+		// Completions are stored in the device's conversation, so only that device can rate them
+		chatID := input.Body.IdDevice
+		result := feedbackUseCase.SetMessageFeedback(ctx, d.SetMessageFeedbackParams{
+			MessageID: fmt.Sprintf("msg-%s-assistant", input.Body.CompletionID),
+			Rating:    input.Body.Rating,
+			Source:    d.FeedbackSourceAPI,
+			ChatID:    &chatID,
+		})
+		return &ChatFeedbackResponse{Body: result}, nil
+	})
+
 }
 
 // Helper functions
@@ -408,6 +634,54 @@ func filterChunksByEvent(chunks []d.ChunkWithHybridSimilarity, eventFilter []str
 	return filtered
 }
 
+// getGuardrailMessage returns the reply used when a guardrail blocks or escalates
+func getGuardrailMessage(cache d.ParameterCache, action guardrails.Action) string {
+	code := "GUARDRAILS_BLOCKED_MESSAGE"
+	message := "No puedo ayudarte con esa solicitud."
+	if action == guardrails.ActionEscalate {
+		code = "GUARDRAILS_ESCALATED_MESSAGE"
+		message = "Tu mensaje fue derivado a una persona del equipo."
+	}
+
+	if param, exists := cache.Get(code); exists {
+		if dataMap, err := param.GetDataAsMap(); err == nil {
+			if msg, ok := dataMap["message"].(string); ok && msg != "" {
+				message = msg
+			}
+		}
+	}
+	return message
+}
+
+// escalateConversation flags the conversation as needing an admin, who answers it from the panel
+func escalateConversation(ctx context.Context, conversationUseCase d.ConversationUseCase, conversationID int) {
+	if conversationID <= 0 {
+		return
+	}
+	result := conversationUseCase.RequestHandoff(ctx, d.RequestHandoffParams{
+		ConversationID: conversationID,
+		Reason:         handoff.ReasonGuardrail,
+	})
+	if !result.Success {
+		logger.LogWarn(ctx, "Failed to flag conversation for an admin",
+			"operation", "ChatCompletions",
+			"code", result.Code,
+		)
+	}
+}
+
+// apiKeyRole returns the role the caller searches as: the "role" claim of its API key,
+// or ROLE_EXTERNAL when the key has none (or the request carries no key)
+func apiKeyRole(ctx context.Context) *string {
+	role := "ROLE_EXTERNAL"
+	if apiKey, ok := middleware.GetAPIKeyFromContext(ctx); ok && apiKey != nil {
+		if claim, ok := apiKey.Claims["role"].(string); ok && claim != "" {
+			role = strings.ToUpper(claim)
+		}
+	}
+	return &role
+}
+
 func generateCompletionID() string {
 	bytes := make([]byte, 16)
 	rand.Read(bytes)
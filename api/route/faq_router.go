@@ -0,0 +1,106 @@
+package route
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"api-chatbot/api/request"
+	d "api-chatbot/domain"
+)
+
+type GetFAQsResponse struct {
+	Body d.Result[[]d.FAQ]
+}
+
+type FAQActionResponse struct {
+	Body d.Result[d.Data]
+}
+
+type MatchFAQResponse struct {
+	Body d.Result[[]d.FAQMatch]
+}
+
+func NewFAQRouter(faqUC d.FAQUseCase, humaAPI huma.API) {
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "get-faqs",
+		Method:      "POST",
+		Path:        "/api/v1/admin/faq/list",
+		Summary:     "List FAQs",
+		Description: "Retrieves the curated FAQs with their hit counts, most used first",
+		Tags:        []string{"Admin - FAQ"},
+	}, func(ctx context.Context, input *struct {
+		Body request.GetFAQsRequest
+	}) (*GetFAQsResponse, error) {
+		result := faqUC.GetFAQs(ctx, input.Body.IncludeInactive)
+		return &GetFAQsResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "save-faq",
+		Method:      "POST",
+		Path:        "/api/v1/admin/faq/save",
+		Summary:     "Create or update FAQ",
+		Description: "Saves a question, its variants and the approved answer. Every phrasing is embedded with the current embedding model",
+		Tags:        []string{"Admin - FAQ"},
+	}, func(ctx context.Context, input *struct {
+		Body request.SaveFAQRequest
+	}) (*FAQActionResponse, error) {
+		params := d.SaveFAQParams{
+			ID:        input.Body.FAQID,
+			Question:  input.Body.Question,
+			Variants:  input.Body.Variants,
+			Answer:    input.Body.Answer,
+			Category:  input.Body.Category,
+			MediaURL:  input.Body.MediaURL,
+			MediaType: input.Body.MediaType,
+			Threshold: input.Body.Threshold,
+			Active:    input.Body.Active,
+		}
+
+		result := faqUC.Save(ctx, params)
+		return &FAQActionResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "delete-faq",
+		Method:      "POST",
+		Path:        "/api/v1/admin/faq/delete",
+		Summary:     "Delete FAQ",
+		Description: "Removes an FAQ and its phrasings",
+		Tags:        []string{"Admin - FAQ"},
+	}, func(ctx context.Context, input *struct {
+		Body request.DeleteFAQRequest
+	}) (*FAQActionResponse, error) {
+		result := faqUC.Delete(ctx, input.Body.FAQID)
+		return &FAQActionResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "match-faq",
+		Method:      "POST",
+		Path:        "/api/v1/admin/faq/match",
+		Summary:     "Preview FAQ matching",
+		Description: "Scores the FAQs against a question and shows which one would override generation, without counting a hit",
+		Tags:        []string{"Admin - FAQ"},
+	}, func(ctx context.Context, input *struct {
+		Body request.MatchFAQRequest
+	}) (*MatchFAQResponse, error) {
+		result := faqUC.Match(ctx, input.Body.Query, input.Body.Category)
+		return &MatchFAQResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "reembed-faqs",
+		Method:      "POST",
+		Path:        "/api/v1/admin/faq/reembed",
+		Summary:     "Re-embed FAQs",
+		Description: "Re-embeds every FAQ with the current embedding model; run it after swapping embedding models",
+		Tags:        []string{"Admin - FAQ"},
+	}, func(ctx context.Context, input *struct {
+		Body request.ReembedFAQsRequest
+	}) (*FAQActionResponse, error) {
+		result := faqUC.Reembed(ctx)
+		return &FAQActionResponse{Body: result}, nil
+	})
+}
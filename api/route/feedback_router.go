@@ -0,0 +1,72 @@
+package route
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"api-chatbot/api/request"
+	d "api-chatbot/domain"
+)
+
+type FeedbackResponse struct {
+	Body d.Result[d.Data]
+}
+
+type GetMessageChunksResponse struct {
+	Body d.Result[[]d.MessageChunk]
+}
+
+func NewFeedbackRouter(feedbackUC d.FeedbackUseCase, humaAPI huma.API) {
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "set-message-feedback",
+		Method:      "POST",
+		Path:        "/api/v1/admin/feedback/set",
+		Summary:     "Rate bot answer",
+		Description: "Marks a bot answer as useful (1) or not useful (-1), or clears the rating (0). The quality metrics of the chunks the answer was built from are recomputed.",
+		Tags:        []string{"Admin - Feedback"},
+	}, func(ctx context.Context, input *struct {
+		Body request.SetMessageFeedbackRequest
+	}) (*FeedbackResponse, error) {
+		params := d.SetMessageFeedbackParams{
+			MessageID: input.Body.MessageID,
+			Rating:    input.Body.Rating,
+			Source:    d.FeedbackSourceAdmin,
+		}
+		result := feedbackUC.SetMessageFeedback(ctx, params)
+		return &FeedbackResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "set-message-chunk-relevance",
+		Method:      "POST",
+		Path:        "/api/v1/admin/feedback/chunk-relevance",
+		Summary:     "Label chunk relevance",
+		Description: "Marks whether one chunk of a bot answer was relevant. The label overrides the answer rating for that chunk in the quality metrics.",
+		Tags:        []string{"Admin - Feedback"},
+	}, func(ctx context.Context, input *struct {
+		Body request.SetChunkRelevanceRequest
+	}) (*FeedbackResponse, error) {
+		params := d.SetChunkRelevanceParams{
+			MessageID: input.Body.MessageID,
+			ChunkID:   input.Body.ChunkID,
+			Relevant:  input.Body.Relevant,
+		}
+		result := feedbackUC.SetChunkRelevance(ctx, params)
+		return &FeedbackResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "get-message-chunks",
+		Method:      "POST",
+		Path:        "/api/v1/admin/feedback/message-chunks",
+		Summary:     "Get answer chunks",
+		Description: "Lists the chunks a bot answer was built from, in retrieval order, with the answer rating and their relevance labels",
+		Tags:        []string{"Admin - Feedback"},
+	}, func(ctx context.Context, input *struct {
+		Body request.GetMessageChunksRequest
+	}) (*GetMessageChunksResponse, error) {
+		result := feedbackUC.GetMessageChunks(ctx, input.Body.MessageID)
+		return &GetMessageChunksResponse{Body: result}, nil
+	})
+}
@@ -0,0 +1,101 @@
+package route
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"api-chatbot/api/request"
+	d "api-chatbot/domain"
+)
+
+type GetGlossaryEntriesResponse struct {
+	Body d.Result[[]d.GlossaryEntry]
+}
+
+type GlossaryActionResponse struct {
+	Body d.Result[d.Data]
+}
+
+type PreviewSearchQueryResponse struct {
+	Body d.Result[*d.SearchQueryExpansion]
+}
+
+func NewGlossaryRouter(glossaryUC d.GlossaryUseCase, humaAPI huma.API) {
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "get-glossary-entries",
+		Method:      "POST",
+		Path:        "/api/v1/admin/glossary/list",
+		Summary:     "List search glossary",
+		Description: "Retrieves the synonyms and acronyms applied to keyword search",
+		Tags:        []string{"Admin - Search Glossary"},
+	}, func(ctx context.Context, input *struct {
+		Body request.GetGlossaryEntriesRequest
+	}) (*GetGlossaryEntriesResponse, error) {
+		result := glossaryUC.GetEntries(ctx, input.Body.Type)
+		return &GetGlossaryEntriesResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "save-glossary-entry",
+		Method:      "POST",
+		Path:        "/api/v1/admin/glossary/save",
+		Summary:     "Create or update glossary entry",
+		Description: "Saves a term with its equivalent expansions. Chunks mentioning any of them are reindexed so keyword search matches all of them",
+		Tags:        []string{"Admin - Search Glossary"},
+	}, func(ctx context.Context, input *struct {
+		Body request.SaveGlossaryEntryRequest
+	}) (*GlossaryActionResponse, error) {
+		params := d.SaveGlossaryEntryParams{
+			ID:         input.Body.EntryID,
+			Term:       input.Body.Term,
+			Type:       input.Body.Type,
+			Expansions: input.Body.Expansions,
+		}
+
+		result := glossaryUC.SaveEntry(ctx, params)
+		return &GlossaryActionResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "delete-glossary-entry",
+		Method:      "POST",
+		Path:        "/api/v1/admin/glossary/delete",
+		Summary:     "Delete glossary entry",
+		Description: "Removes a glossary entry and reindexes the chunks it expanded",
+		Tags:        []string{"Admin - Search Glossary"},
+	}, func(ctx context.Context, input *struct {
+		Body request.DeleteGlossaryEntryRequest
+	}) (*GlossaryActionResponse, error) {
+		result := glossaryUC.DeleteEntry(ctx, input.Body.EntryID)
+		return &GlossaryActionResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "reindex-chunk-fts",
+		Method:      "POST",
+		Path:        "/api/v1/admin/glossary/reindex",
+		Summary:     "Reindex keyword search",
+		Description: "Recomputes the full-text vectors of every chunk with the current glossary",
+		Tags:        []string{"Admin - Search Glossary"},
+	}, func(ctx context.Context, input *struct {
+		Body request.ReindexChunkFTSRequest
+	}) (*GlossaryActionResponse, error) {
+		result := glossaryUC.ReindexChunks(ctx)
+		return &GlossaryActionResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "preview-search-query",
+		Method:      "POST",
+		Path:        "/api/v1/admin/glossary/preview",
+		Summary:     "Preview keyword query",
+		Description: "Shows the full-text query built from a search text, before and after applying the glossary",
+		Tags:        []string{"Admin - Search Glossary"},
+	}, func(ctx context.Context, input *struct {
+		Body request.PreviewSearchQueryRequest
+	}) (*PreviewSearchQueryResponse, error) {
+		result := glossaryUC.PreviewQuery(ctx, input.Body.Query)
+		return &PreviewSearchQueryResponse{Body: result}, nil
+	})
+}
@@ -0,0 +1,70 @@
+package route
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"api-chatbot/api/request"
+	d "api-chatbot/domain"
+)
+
+type GetGuardrailEventsResponse struct {
+	Body d.Result[[]d.GuardrailEvent]
+}
+
+type ReviewGuardrailEventResponse struct {
+	Body d.Result[d.Data]
+}
+
+func NewGuardrailRouter(guardrailUC d.GuardrailUseCase, humaAPI huma.API) {
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "get-guardrail-events",
+		Method:      "POST",
+		Path:        "/api/v1/admin/guardrails/events",
+		Summary:     "Get guardrail events",
+		Description: "Retrieves guardrail triggers (blocked, rewritten or escalated messages) for admin review",
+		Tags:        []string{"Admin - Guardrails"},
+	}, func(ctx context.Context, input *struct {
+		Body request.GetGuardrailEventsRequest
+	}) (*GetGuardrailEventsResponse, error) {
+		limit := input.Body.Limit
+		if limit == 0 {
+			limit = 50
+		}
+
+		params := d.GetGuardrailEventsParams{
+			Stage:       input.Body.Stage,
+			Action:      input.Body.Action,
+			OnlyPending: input.Body.OnlyPending,
+			Limit:       limit,
+			Offset:      input.Body.Offset,
+		}
+
+		result := guardrailUC.GetEvents(ctx, params)
+		return &GetGuardrailEventsResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "review-guardrail-event",
+		Method:      "POST",
+		Path:        "/api/v1/admin/guardrails/review",
+		Summary:     "Mark guardrail event as reviewed",
+		Description: "Marks a guardrail trigger as reviewed by an admin with optional notes",
+		Tags:        []string{"Admin - Guardrails"},
+	}, func(ctx context.Context, input *struct {
+		Body request.ReviewGuardrailEventRequest
+	}) (*ReviewGuardrailEventResponse, error) {
+		// TODO: Extract admin ID from JWT token
+		adminID := 1 // Placeholder
+
+		params := d.ReviewGuardrailEventParams{
+			EventID: input.Body.EventID,
+			AdminID: adminID,
+			Notes:   input.Body.Notes,
+		}
+
+		result := guardrailUC.ReviewEvent(ctx, params)
+		return &ReviewGuardrailEventResponse{Body: result}, nil
+	})
+}
@@ -1,6 +1,7 @@
 package route
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -10,10 +11,16 @@ import (
 	"api-chatbot/api/dal"
 	"api-chatbot/domain"
 	"api-chatbot/internal/embedding"
+	"api-chatbot/internal/guardrails"
 	"api-chatbot/internal/httpclient"
+	"api-chatbot/internal/jobs"
 	"api-chatbot/internal/jwttoken"
 	"api-chatbot/internal/llm"
+	"api-chatbot/internal/queryexpansion"
 	"api-chatbot/internal/reports"
+	"api-chatbot/internal/rerank"
+	"api-chatbot/internal/webcrawler"
+	"api-chatbot/internal/whatsapp"
 	"api-chatbot/repository"
 	"api-chatbot/usecase"
 )
@@ -36,18 +43,34 @@ func Setup(paramCache domain.ParameterCache, timeout time.Duration, db *pgxpool.
 	analyticsRepo := repository.NewAnalyticsRepository(dataAccess)
 	apiKeyRepo := repository.NewAPIKeyRepository(dataAccess)
 	apiUsageRepo := repository.NewAPIUsageRepository(dataAccess)
+	guardrailRepo := repository.NewGuardrailRepository(dataAccess)
+	experimentRepo := repository.NewExperimentRepository(dataAccess)
+	embeddingCacheRepo := repository.NewEmbeddingCacheRepository(dataAccess)
+	embeddingMigrationRepo := repository.NewEmbeddingMigrationRepository(dataAccess)
+	evaluationRepo := repository.NewEvaluationRepository(dataAccess)
+	feedbackRepo := repository.NewFeedbackRepository(dataAccess)
+	glossaryRepo := repository.NewGlossaryRepository(dataAccess)
+	faqRepo := repository.NewFAQRepository(dataAccess)
+	knowledgeGapRepo := repository.NewKnowledgeGapRepository(dataAccess)
+	webSourceRepo := repository.NewWebSourceRepository(dataAccess)
 
 	// Initialize clients
 	httpClient := httpclient.NewHTTPClient(paramCache)
 
+	// Initialize LLM provider for external API and reranking (rebuilt automatically when LLM_CONFIG changes)
+	llmProvider := llm.NewReloadableProvider(paramCache)
+
 	// Initialize services
-	embeddingService := embedding.NewOpenAIEmbeddingService(paramCache, httpClient)
+	embeddingService := embedding.NewCachedEmbeddingService(embedding.NewOpenAIEmbeddingService(paramCache, httpClient), embeddingCacheRepo, paramCache)
+	reranker := rerank.NewReranker(paramCache, llmProvider, httpClient)
+	queryExpander := queryexpansion.NewExpander(paramCache, llmProvider)
 	tokenService := jwttoken.NewTokenService(paramCache)
 	reportGenerator := reports.NewReportGenerator("./templates/typst", "./reports")
+	webCrawler := webcrawler.NewCrawler(nil)
 
 	// Initialize use cases
 	paramUseCase := usecase.NewParameterUseCase(paramRepo, paramCache, timeout)
-	chunkUseCase := usecase.NewChunkUseCase(chunkRepo, statsRepo, paramCache, embeddingService, timeout)
+	chunkUseCase := usecase.NewChunkUseCase(chunkRepo, statsRepo, paramCache, embeddingService, reranker, queryExpander, timeout)
 	docUseCase := usecase.NewDocumentUseCase(docRepo, chunkUseCase, paramCache, timeout)
 	statsUseCase := usecase.NewChunkStatisticsUseCase(statsRepo, paramCache, timeout)
 	sessionUseCase := usecase.NewWhatsAppSessionUseCase(sessionRepo, paramCache, timeout)
@@ -58,9 +81,21 @@ func Setup(paramCache domain.ParameterCache, timeout time.Duration, db *pgxpool.
 	analyticsUseCase := usecase.NewAnalyticsUseCase(analyticsRepo, paramCache, timeout)
 	reportUseCase := usecase.NewReportUseCase(analyticsRepo, reportGenerator, timeout)
 	apiKeyUseCase := usecase.NewAPIKeyUseCase(apiKeyRepo, paramCache, timeout)
-
-	// Initialize LLM provider for external API
-	llmProvider := createLLMProvider(paramCache)
+	guardrailUseCase := usecase.NewGuardrailUseCase(guardrailRepo, paramCache, timeout)
+	experimentUseCase := usecase.NewExperimentUseCase(experimentRepo, paramCache, timeout)
+	evaluationUseCase := usecase.NewEvaluationUseCase(evaluationRepo, chunkUseCase, paramCache, timeout)
+	feedbackUseCase := usecase.NewFeedbackUseCase(feedbackRepo, paramCache, timeout)
+	glossaryUseCase := usecase.NewGlossaryUseCase(glossaryRepo, paramCache, timeout)
+	faqUseCase := usecase.NewFAQUseCase(faqRepo, embeddingService, paramCache, timeout)
+	knowledgeGapUseCase := usecase.NewKnowledgeGapUseCase(knowledgeGapRepo, embeddingService, paramCache, timeout)
+	webSourceUseCase := usecase.NewWebSourceUseCase(webSourceRepo, chunkUseCase, webCrawler, paramCache, timeout)
+	embeddingCacheUseCase := usecase.NewEmbeddingCacheUseCase(embeddingCacheRepo, paramCache, timeout)
+	embeddingMigrationUseCase := usecase.NewEmbeddingMigrationUseCase(embeddingMigrationRepo, paramCache, func(configCode string) domain.EmbeddingService {
+		return embedding.NewCachedEmbeddingService(embedding.NewOpenAIEmbeddingServiceWithConfig(paramCache, httpClient, configCode), embeddingCacheRepo, paramCache)
+	}, timeout)
+
+	// Guardrail pipeline for input/output checks
+	guardrailPipeline := guardrails.NewDefaultPipeline(paramCache, guardrailUseCase, llmProvider)
 
 	// Register all routes
 	// All routes are now registered via Huma which uses the ServeMux
@@ -71,7 +106,17 @@ func Setup(paramCache domain.ParameterCache, timeout time.Duration, db *pgxpool.
 
 	// Knowledge module routes
 	NewDocumentRouter(docUseCase, mux, humaAPI)
+
+	// Deactivate documents past their validity and notify the admins (DOCUMENT_EXPIRY_CONFIG)
+	go jobs.NewDocumentExpiry(docUseCase, paramCache, whatsapp.GetManager()).Run(context.Background())
 	NewChunkRouter(chunkUseCase, mux, humaAPI)
+
+	// Website and sitemap ingestion routes (one document per page)
+	NewWebSourceRouter(webSourceUseCase, humaAPI)
+
+	// Re-crawl web sources whose interval is due (WEB_CRAWL_CONFIG.schedule)
+	go jobs.NewWebRecrawl(webSourceUseCase, paramCache).Run(context.Background())
+
 	NewChunkStatisticsRouter(statsUseCase, mux, humaAPI)
 
 	// WhatsApp admin routes
@@ -84,51 +129,41 @@ func Setup(paramCache domain.ParameterCache, timeout time.Duration, db *pgxpool.
 	SetupAdminConversationRoutes(humaAPI, adminConvUseCase)
 
 	// Admin analytics routes
-	RegisterAnalyticsRoutes(humaAPI, analyticsUseCase)
+	RegisterAnalyticsRoutes(humaAPI, analyticsUseCase, knowledgeGapUseCase)
+
+	// Cluster the questions of the last period into gaps to document (KNOWLEDGE_GAP_CONFIG.schedule)
+	go jobs.NewKnowledgeGapAnalysis(knowledgeGapUseCase, paramCache).Run(context.Background())
 
 	// Report generation routes
 	RegisterReportRoutes(humaAPI, reportUseCase)
 
-	// External API routes (Claude-style endpoints with event filtering)
-	if llmProvider != nil {
-		NewExternalAPIRouter(chunkUseCase, embeddingService, llmProvider, paramCache, apiKeyUseCase, apiUsageRepo, convUseCase, mux, humaAPI)
-	}
-}
+	// Guardrail review routes
+	NewGuardrailRouter(guardrailUseCase, humaAPI)
 
-func createLLMProvider(cache domain.ParameterCache) llm.Provider {
-	param, exists := cache.Get("LLM_CONFIG")
-	if !exists {
-		return nil // LLM provider is optional
-	}
-
-	data, err := param.GetDataAsMap()
-	if err != nil {
-		return nil
-	}
-
-	provider, _ := data["provider"].(string)
-	apiKey, _ := data["apiKey"].(string)
-	baseURL, _ := data["baseURL"].(string)
-	model, _ := data["model"].(string)
-	temperature, _ := data["temperature"].(float64)
-	maxTokens, _ := data["maxTokens"].(float64)
-	timeout, _ := data["timeout"].(float64)
-	systemPrompt, _ := data["systemPrompt"].(string)
-
-	if apiKey == "" || baseURL == "" || model == "" {
-		return nil
-	}
-
-	config := llm.Config{
-		Provider:     provider,
-		APIKey:       apiKey,
-		BaseURL:      baseURL,
-		Model:        model,
-		Temperature:  temperature,
-		MaxTokens:    int(maxTokens),
-		Timeout:      int(timeout),
-		SystemPrompt: systemPrompt,
-	}
-
-	return llm.NewOpenAICompatibleProvider(config)
+	// Experiment routes (A/B variants and their comparison)
+	NewExperimentRouter(experimentUseCase, humaAPI)
+
+	// Retrieval evaluation routes (golden set and run reports)
+	NewEvaluationRouter(evaluationUseCase, humaAPI)
+
+	// Answer feedback routes (ratings and chunk relevance labels)
+	NewFeedbackRouter(feedbackUseCase, humaAPI)
+
+	// Search glossary routes (synonyms and acronyms for keyword search)
+	NewGlossaryRouter(glossaryUseCase, humaAPI)
+
+	// Curated FAQ routes (approved answers matched before RAG)
+	NewFAQRouter(faqUseCase, humaAPI)
+
+	// Embedding cache stats and purge routes
+	NewEmbeddingCacheRouter(embeddingCacheUseCase, humaAPI)
+
+	// Embedding model migration routes (re-embed, progress, swap)
+	NewEmbeddingMigrationRouter(embeddingMigrationUseCase, humaAPI)
+
+	// Continue re-embedding jobs interrupted by a restart
+	go embeddingMigrationUseCase.ResumeRunning(context.Background())
+
+	// External API routes (Claude-style endpoints with event filtering)
+	NewExternalAPIRouter(chunkUseCase, faqUseCase, embeddingService, llmProvider, guardrailPipeline, experimentUseCase, paramCache, apiKeyUseCase, apiUsageRepo, convUseCase, feedbackUseCase, mux, humaAPI)
 }
@@ -0,0 +1,112 @@
+package route
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"api-chatbot/api/request"
+	d "api-chatbot/domain"
+)
+
+type GetWebSourcesResponse struct {
+	Body d.Result[[]d.WebSource]
+}
+
+type WebSourceActionResponse struct {
+	Body d.Result[d.Data]
+}
+
+type CrawlWebSourceResponse struct {
+	Body d.Result[*d.WebCrawlResult]
+}
+
+func NewWebSourceRouter(webSourceUC d.WebSourceUseCase, humaAPI huma.API) {
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "get-web-sources",
+		Method:      "POST",
+		Path:        "/api/v1/admin/web-sources/list",
+		Summary:     "List web sources",
+		Description: "Retrieves the websites crawled into documents with the result of their last crawl",
+		Tags:        []string{"Admin - Web Sources"},
+	}, func(ctx context.Context, input *struct {
+		Body request.GetWebSourcesRequest
+	}) (*GetWebSourcesResponse, error) {
+		result := webSourceUC.GetSources(ctx, input.Body.IncludeInactive)
+		return &GetWebSourcesResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "save-web-source",
+		Method:      "POST",
+		Path:        "/api/v1/admin/web-sources/save",
+		Summary:     "Create or update web source",
+		Description: "Saves a start page or sitemap to crawl, its depth and page limits, URL include/exclude patterns and re-crawl interval",
+		Tags:        []string{"Admin - Web Sources"},
+	}, func(ctx context.Context, input *struct {
+		Body request.SaveWebSourceRequest
+	}) (*WebSourceActionResponse, error) {
+		maxDepth, maxPages := 2, 100
+		chunkSize, chunkOverlap := 1000, 200
+		if input.Body.MaxDepth != nil {
+			maxDepth = *input.Body.MaxDepth
+		}
+		if input.Body.MaxPages != nil {
+			maxPages = *input.Body.MaxPages
+		}
+		if input.Body.ChunkSize != nil {
+			chunkSize = *input.Body.ChunkSize
+		}
+		if input.Body.ChunkOverlap != nil {
+			chunkOverlap = *input.Body.ChunkOverlap
+		}
+
+		params := d.SaveWebSourceParams{
+			ID:              input.Body.SourceID,
+			URL:             input.Body.URL,
+			IsSitemap:       input.Body.IsSitemap,
+			Category:        input.Body.Category,
+			Tags:            input.Body.Tags,
+			Audience:        input.Body.Audience,
+			MaxDepth:        maxDepth,
+			MaxPages:        maxPages,
+			IncludePatterns: input.Body.IncludePatterns,
+			ExcludePatterns: input.Body.ExcludePatterns,
+			RecrawlHours:    input.Body.RecrawlHours,
+			ChunkSize:       chunkSize,
+			ChunkOverlap:    chunkOverlap,
+			Active:          input.Body.Active,
+		}
+
+		result := webSourceUC.Save(ctx, params)
+		return &WebSourceActionResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "delete-web-source",
+		Method:      "POST",
+		Path:        "/api/v1/admin/web-sources/delete",
+		Summary:     "Delete web source",
+		Description: "Removes a web source; the documents of its pages are kept",
+		Tags:        []string{"Admin - Web Sources"},
+	}, func(ctx context.Context, input *struct {
+		Body request.DeleteWebSourceRequest
+	}) (*WebSourceActionResponse, error) {
+		result := webSourceUC.Delete(ctx, input.Body.SourceID)
+		return &WebSourceActionResponse{Body: result}, nil
+	})
+
+	huma.Register(humaAPI, huma.Operation{
+		OperationID: "crawl-web-source",
+		Method:      "POST",
+		Path:        "/api/v1/admin/web-sources/crawl",
+		Summary:     "Crawl web source",
+		Description: "Crawls a web source now, honouring robots.txt. Creates one document per page (source = page URL) and only re-embeds pages whose content changed since the last crawl",
+		Tags:        []string{"Admin - Web Sources"},
+	}, func(ctx context.Context, input *struct {
+		Body request.CrawlWebSourceRequest
+	}) (*CrawlWebSourceResponse, error) {
+		result := webSourceUC.Crawl(ctx, input.Body.SourceID)
+		return &CrawlWebSourceResponse{Body: result}, nil
+	})
+}
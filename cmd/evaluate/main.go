@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"api-chatbot/api/dal"
+	"api-chatbot/config"
+	"api-chatbot/domain"
+	"api-chatbot/internal/embedding"
+	"api-chatbot/internal/evaluation"
+	"api-chatbot/internal/httpclient"
+	"api-chatbot/internal/llm"
+	"api-chatbot/internal/queryexpansion"
+	"api-chatbot/internal/rerank"
+	"api-chatbot/repository"
+	"api-chatbot/usecase"
+)
+
+// options are the command line flags
+type options struct {
+	set     string
+	file    string
+	k       int
+	label   string
+	save    bool
+	compare int
+	json    bool
+}
+
+func main() {
+	// Define CLI flags
+	var opts options
+	flag.StringVar(&opts.set, "set", domain.DefaultEvalSet, "Golden set stored in the database")
+	flag.StringVar(&opts.file, "file", "", "JSONL golden set file (evaluated instead of the stored set)")
+	flag.IntVar(&opts.k, "k", 0, "Results per question (default: RAG_SEARCH_LIMIT)")
+	flag.StringVar(&opts.label, "label", "", "Label stored with the run")
+	flag.BoolVar(&opts.save, "save", false, "Store the report in the database")
+	flag.IntVar(&opts.compare, "compare", 0, "Stored run ID to compare the metrics with")
+	flag.BoolVar(&opts.json, "json", false, "Print the full report as JSON")
+
+	flag.Parse()
+
+	app := config.App()
+	err := run(&app, opts)
+	// Shutdown runs before exiting so the DB pool is closed on errors too
+	app.Shutdown()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run evaluates the golden set and prints the report
+func run(app *config.Application, opts options) error {
+	// Get context timeout from parameter cache
+	timeout := 2 * time.Second // default
+	if param, exists := app.Cache.Get("APP_CONFIG"); exists {
+		if data, err := param.GetDataAsMap(); err == nil {
+			if ctxTimeout, ok := data["contextTimeout"].(float64); ok {
+				timeout = time.Duration(ctxTimeout) * time.Second
+			}
+		}
+	}
+
+	// Same retrieval stack as the API
+	dataAccess := dal.NewDAL(app.Db)
+	httpClient := httpclient.NewHTTPClient(app.Cache)
+	chunkRepo := repository.NewChunkRepository(dataAccess)
+	statsRepo := repository.NewChunkStatisticsRepository(dataAccess)
+	embeddingCacheRepo := repository.NewEmbeddingCacheRepository(dataAccess)
+	embeddingService := embedding.NewCachedEmbeddingService(embedding.NewOpenAIEmbeddingService(app.Cache, httpClient), embeddingCacheRepo, app.Cache)
+	retrievalLLM := llm.NewReloadableProvider(app.Cache)
+	reranker := rerank.NewReranker(app.Cache, retrievalLLM, httpClient)
+	queryExpander := queryexpansion.NewExpander(app.Cache, retrievalLLM)
+	chunkUC := usecase.NewChunkUseCase(chunkRepo, statsRepo, app.Cache, embeddingService, reranker, queryExpander, timeout)
+	evaluationUC := usecase.NewEvaluationUseCase(repository.NewEvaluationRepository(dataAccess), chunkUC, app.Cache, timeout)
+
+	params := domain.RunEvaluationParams{
+		Set:  opts.set,
+		Save: opts.save,
+	}
+	if opts.file != "" {
+		questions, err := evaluation.LoadJSONL(opts.file)
+		if err != nil {
+			return fmt.Errorf("failed to load golden set: %w", err)
+		}
+		params.Questions = questions
+	}
+	if opts.k > 0 {
+		params.K = &opts.k
+	}
+	if opts.label != "" {
+		params.Label = &opts.label
+	}
+
+	ctx := context.Background()
+	result := evaluationUC.RunEvaluation(ctx, params)
+	if !result.Success {
+		return fmt.Errorf("evaluation failed: %s (%s)", result.Info, result.Code)
+	}
+	report := result.Data
+
+	if opts.json {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Printf("Set: %s  Questions: %d  k: %d\n", report.Set, report.QuestionCount, report.K)
+	if report.ID > 0 {
+		fmt.Printf("Stored as run %d\n", report.ID)
+	}
+	fmt.Println()
+	for _, r := range report.Results {
+		status := fmt.Sprintf("ranks %v", r.RelevantRanks)
+		if r.Error != "" {
+			status = "ERROR " + r.Error
+		} else if len(r.RelevantRanks) == 0 {
+			status = "MISS"
+		}
+		fmt.Printf("  R@k %.2f  RR %.2f  %-60.60s  %s\n", r.Metrics.RecallAtK, r.Metrics.MRR, r.Question, status)
+	}
+
+	var baseline *domain.EvalRun
+	if opts.compare > 0 {
+		stored := evaluationUC.GetRun(ctx, opts.compare)
+		if !stored.Success {
+			return fmt.Errorf("failed to load run %d: %s (%s)", opts.compare, stored.Info, stored.Code)
+		}
+		baseline = stored.Data
+	}
+
+	fmt.Println()
+	printMetric("Precision@k", report.Metrics.PrecisionAtK, baseline, func(m domain.EvalMetrics) float64 { return m.PrecisionAtK })
+	printMetric("Recall@k", report.Metrics.RecallAtK, baseline, func(m domain.EvalMetrics) float64 { return m.RecallAtK })
+	printMetric("F1@k", report.Metrics.F1AtK, baseline, func(m domain.EvalMetrics) float64 { return m.F1AtK })
+	printMetric("MRR", report.Metrics.MRR, baseline, func(m domain.EvalMetrics) float64 { return m.MRR })
+	printMetric("MAP", report.Metrics.MAP, baseline, func(m domain.EvalMetrics) float64 { return m.MAP })
+	printMetric("NDCG", report.Metrics.NDCG, baseline, func(m domain.EvalMetrics) float64 { return m.NDCG })
+	printMetric("Hit rate", report.Metrics.HitRate, baseline, func(m domain.EvalMetrics) float64 { return m.HitRate })
+	return nil
+}
+
+// printMetric prints a metric, with the change against the baseline run when there is one
+func printMetric(name string, value float64, baseline *domain.EvalRun, pick func(domain.EvalMetrics) float64) {
+	if baseline == nil {
+		fmt.Printf("%-12s %.4f\n", name, value)
+		return
+	}
+	fmt.Printf("%-12s %.4f  (run %d: %.4f, %+.4f)\n", name, value, baseline.ID, pick(baseline.Metrics), value-pick(baseline.Metrics))
+}
@@ -11,6 +11,9 @@ import (
 	"api-chatbot/config"
 	"api-chatbot/internal/embedding"
 	"api-chatbot/internal/httpclient"
+	"api-chatbot/internal/llm"
+	"api-chatbot/internal/queryexpansion"
+	"api-chatbot/internal/rerank"
 	"api-chatbot/internal/whatsapp"
 	"api-chatbot/repository"
 	"api-chatbot/usecase"
@@ -87,11 +90,31 @@ func initializeWhatsAppService(app config.Application, timeout time.Duration) *w
 	// Chunk use case for RAG
 	chunkRepo := repository.NewChunkRepository(dataAccess)
 	statsRepo := repository.NewChunkStatisticsRepository(dataAccess)
-	embeddingService := embedding.NewOpenAIEmbeddingService(app.Cache, httpClient)
-	chunkUC := usecase.NewChunkUseCase(chunkRepo, statsRepo, app.Cache, embeddingService, timeout)
+	embeddingCacheRepo := repository.NewEmbeddingCacheRepository(dataAccess)
+	embeddingService := embedding.NewCachedEmbeddingService(embedding.NewOpenAIEmbeddingService(app.Cache, httpClient), embeddingCacheRepo, app.Cache)
+	retrievalLLM := llm.NewReloadableProvider(app.Cache)
+	reranker := rerank.NewReranker(app.Cache, retrievalLLM, httpClient)
+	queryExpander := queryexpansion.NewExpander(app.Cache, retrievalLLM)
+	chunkUC := usecase.NewChunkUseCase(chunkRepo, statsRepo, app.Cache, embeddingService, reranker, queryExpander, timeout)
+
+	// FAQ use case for curated answers matched before RAG
+	faqRepo := repository.NewFAQRepository(dataAccess)
+	faqUC := usecase.NewFAQUseCase(faqRepo, embeddingService, app.Cache, timeout)
+
+	// Guardrail use case for logging input/output triggers
+	guardrailRepo := repository.NewGuardrailRepository(dataAccess)
+	guardrailUC := usecase.NewGuardrailUseCase(guardrailRepo, app.Cache, timeout)
+
+	// Experiment use case for A/B variant assignment
+	experimentRepo := repository.NewExperimentRepository(dataAccess)
+	experimentUC := usecase.NewExperimentUseCase(experimentRepo, app.Cache, timeout)
+
+	// Feedback use case for reactions to bot answers
+	feedbackRepo := repository.NewFeedbackRepository(dataAccess)
+	feedbackUC := usecase.NewFeedbackUseCase(feedbackRepo, app.Cache, timeout)
 
 	// Initialize WhatsApp service (returns nil if disabled in config)
-	service, err := config.InitializeWhatsAppService(app, sessionUC, chunkUC, userUC, regUC, convUC)
+	service, err := config.InitializeWhatsAppService(app, sessionUC, chunkUC, faqUC, userUC, regUC, convUC, guardrailUC, experimentUC, feedbackUC)
 	if err != nil {
 		slog.Error("Failed to initialize WhatsApp service", "error", err)
 		return nil
@@ -11,6 +11,8 @@ import (
 	"go.mau.fi/whatsmeow/store/sqlstore"
 
 	"api-chatbot/domain"
+	"api-chatbot/internal/guardrails"
+	"api-chatbot/internal/handoff"
 	"api-chatbot/internal/llm"
 	"api-chatbot/internal/mailer"
 	"api-chatbot/internal/whatsapp"
@@ -22,9 +24,13 @@ func InitializeWhatsAppService(
 	app Application,
 	sessionUC domain.WhatsAppSessionUseCase,
 	chunkUC domain.ChunkUseCase,
+	faqUC domain.FAQUseCase,
 	userUC domain.WhatsAppUserUseCase,
 	regUC domain.RegistrationUseCase,
 	convUC domain.ConversationUseCase,
+	guardrailUC domain.GuardrailUseCase,
+	experimentUC domain.ExperimentUseCase,
+	feedbackUC domain.FeedbackUseCase,
 ) (*whatsapp.Service, error) {
 	param, exists := app.Cache.Get("WHATSAPP_CONFIG")
 	if !exists {
@@ -81,15 +87,20 @@ func InitializeWhatsAppService(
 		return nil, fmt.Errorf("failed to create WhatsApp client: %w", err)
 	}
 
-	llmProvider, err := createLLMProvider(app.Cache)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create LLM provider: %w", err)
+	// LLM provider is rebuilt automatically when LLM_CONFIG changes
+	llmProvider := llm.NewReloadableProvider(app.Cache)
+	if !llmProvider.IsAvailable() {
+		slog.Warn("LLM provider not available yet - RAG answers will fall back until LLM_CONFIG is valid")
 	}
 
+	guardrailPipeline := guardrails.NewDefaultPipeline(app.Cache, guardrailUC, llmProvider)
+	handoffPolicy := handoff.NewPolicy(app.Cache, llmProvider)
+
 	messageHandlers := []whatsapp.MessageHandler{
+		handlers.NewFeedbackHandler(feedbackUC, 2000),
 		handlers.NewCommandHandler(waClient, app.Cache, regUC, userUC, convUC, 100),
 		handlers.NewRegistrationHandler(regUC, userUC, convUC, waClient, app.Cache, 1000),
-		handlers.NewRAGHandler(chunkUC, convUC, userUC, llmProvider, waClient, app.Cache, 50),
+		handlers.NewRAGHandler(chunkUC, faqUC, convUC, userUC, llmProvider, guardrailPipeline, handoffPolicy, experimentUC, waClient, app.Cache, 50),
 	}
 
 	service, err := whatsapp.NewServiceWithClient(waClient, sessionName, sessionUC, messageHandlers, app.Cache, container)
@@ -126,52 +137,6 @@ func createDeviceStore(connString string) (*sqlstore.Container, error) {
 	return container, nil
 }
 
-func createLLMProvider(cache domain.ParameterCache) (llm.Provider, error) {
-	param, exists := cache.Get("LLM_CONFIG")
-	if !exists {
-		return nil, fmt.Errorf("LLM_CONFIG parameter not found")
-	}
-
-	data, err := param.GetDataAsMap()
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse LLM_CONFIG: %w", err)
-	}
-
-	provider, _ := data["provider"].(string)
-	apiKey, _ := data["apiKey"].(string)
-	baseURL, _ := data["baseURL"].(string)
-	model, _ := data["model"].(string)
-	temperature, _ := data["temperature"].(float64)
-	maxTokens, _ := data["maxTokens"].(float64)
-	timeout, _ := data["timeout"].(float64)
-	systemPrompt, _ := data["systemPrompt"].(string)
-
-	if apiKey == "" || baseURL == "" || model == "" {
-		return nil, fmt.Errorf("LLM_CONFIG missing required fields (apiKey, baseURL, model)")
-	}
-
-	config := llm.Config{
-		Provider:     provider,
-		APIKey:       apiKey,
-		BaseURL:      baseURL,
-		Model:        model,
-		Temperature:  temperature,
-		MaxTokens:    int(maxTokens),
-		Timeout:      int(timeout),
-		SystemPrompt: systemPrompt,
-	}
-
-	llmProvider := llm.NewOpenAICompatibleProvider(config)
-
-	slog.Info("LLM provider initialized",
-		"provider", provider,
-		"model", model,
-		"baseURL", baseURL,
-	)
-
-	return llmProvider, nil
-}
-
 // InitializeRegistrationUseCase creates and initializes the registration use case with OTP mailer
 func InitializeRegistrationUseCase(
 	regRepo domain.RegistrationRepository,
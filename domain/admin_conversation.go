@@ -21,6 +21,9 @@ type AdminConversationListItem struct {
 	AdminIntervened bool       `json:"adminIntervened" db:"cnv_admin_intervened"`
 	Temporary       bool       `json:"temporary" db:"cnv_temporary"`
 	ExpiresAt       *time.Time `json:"expiresAt,omitempty" db:"cnv_expires_at"`
+	NeedsAdmin      bool       `json:"needsAdmin" db:"cnv_needs_admin"` // The bot handed the conversation off (low confidence)
+	HandoffReason   *string    `json:"handoffReason,omitempty" db:"cnv_handoff_reason"`
+	HandoffAt       *time.Time `json:"handoffAt,omitempty" db:"cnv_handoff_at"`
 
 	// User data
 	UserID             *int    `json:"userId,omitempty" db:"usr_id"`
@@ -50,6 +53,11 @@ type AdminConversationMessage struct {
 	Read          bool      `json:"read" db:"cvm_read"`
 	CreatedAt     time.Time `json:"createdAt" db:"cvm_created_at"`
 	AdminName     *string   `json:"adminName,omitempty" db:"admin_name"`
+
+	// Bot answers: rating and how the answer was retrieved and generated
+	Feedback          *int16          `json:"feedback,omitempty" db:"cvm_feedback"`
+	RAGBestSimilarity *float64        `json:"ragBestSimilarity,omitempty" db:"cvm_rag_best_similarity"`
+	RetrievalTrace    *RetrievalTrace `json:"retrievalTrace,omitempty" db:"cvm_retrieval_trace"`
 }
 
 // BlockUserParams parameters for blocking a user
@@ -100,6 +108,12 @@ type MarkReadResult struct {
 	Code    string `json:"code" db:"code"`
 }
 
+// ResolveHandoffResult result of clearing a conversation's handoff flag
+type ResolveHandoffResult struct {
+	Success bool   `json:"success" db:"success"`
+	Code    string `json:"code" db:"code"`
+}
+
 // DeleteConversationResult result of deleting conversation
 type DeleteConversationResult struct {
 	Success bool   `json:"success" db:"success"`
@@ -129,6 +143,9 @@ type AdminConversationRepository interface {
 	// SetConversationTemporary enables/disables temporary conversation
 	SetConversationTemporary(ctx context.Context, params SetTemporaryParams) (*SetTemporaryResult, error)
 
+	// ResolveHandoff clears the needs-admin flag of a conversation
+	ResolveHandoff(ctx context.Context, conversationID int) (*ResolveHandoffResult, error)
+
 	// GetConversationByChatID retrieves conversation by WhatsApp chat ID
 	GetConversationByChatID(ctx context.Context, chatID string) (*Conversation, error)
 }
@@ -155,4 +172,7 @@ type AdminConversationUseCase interface {
 
 	// SetConversationTemporary enables/disables temporary conversation
 	SetConversationTemporary(ctx context.Context, params SetTemporaryParams) Result[Data]
+
+	// ResolveHandoff clears the needs-admin flag of a conversation (an admin reply also clears it)
+	ResolveHandoff(ctx context.Context, conversationID int) Result[Data]
 }
@@ -9,9 +9,11 @@ import (
 )
 
 type Chunk struct {
-	ID         int    `json:"id" db:"chk_id"`
-	DocumentID int    `json:"documentId" db:"chk_fk_document"`
-	Content    string `json:"content" db:"chk_content"`
+	ID         int      `json:"id" db:"chk_id"`
+	DocumentID int      `json:"documentId" db:"chk_fk_document"`
+	Content    string   `json:"content" db:"chk_content"`
+	Position   int      `json:"position" db:"chk_position"` // 0-based position within the document
+	Headings   []string `json:"headings" db:"chk_headings"` // Heading path within the document, outermost first
 	// Embedding  *[]float32 `json:"embedding,omitempty" db:"chk_embedding"`
 	CreatedAt time.Time `json:"createdAt" db:"chk_created_at"`
 	UpdatedAt time.Time `json:"updatedAt" db:"chk_updated_at"`
@@ -35,8 +37,129 @@ type ChunkWithHybridSimilarity struct {
 	SimilarityScore float64 `json:"similarityScore" db:"similarity_score"`
 	KeywordScore    float64 `json:"keywordScore" db:"keyword_score"`
 	CombinedScore   float64 `json:"combinedScore" db:"combined_score"`
+	FusionScore     float64 `json:"fusionScore" db:"fusion_score"` // Ranking score of the fusion strategy (equals CombinedScore for "weighted")
 	DocTitle        string  `json:"docTitle" db:"doc_title"`
 	DocCategory     string  `json:"docCategory" db:"doc_category"`
+	// Headings is the heading path of the chunk within its document, for citations
+	Headings []string `json:"headings,omitempty" db:"chk_headings"`
+	// Embedding is only loaded for the MMR diversity pass
+	Embedding *pgvector.Vector `json:"-" db:"chk_embedding"`
+	// RerankScore is set when the reranking stage rescored the result (0-1 for the LLM reranker)
+	RerankScore *float64 `json:"rerankScore,omitempty" db:"-"`
+	// NeighborIDs lists the chunks merged into Content by neighbour expansion, in document order
+	NeighborIDs []int `json:"neighborIds,omitempty" db:"-"`
+	// QueryStrategy is the retrieval strategy that produced the result ("multi_query", "hyde"; empty for none)
+	QueryStrategy string `json:"queryStrategy,omitempty" db:"-"`
+	// MatchedQuery is the query (question or paraphrase) that ranked the result best (multi-query only)
+	MatchedQuery string `json:"matchedQuery,omitempty" db:"-"`
+}
+
+// ChunkNeighbor is a chunk within the neighbour window of a search hit
+type ChunkNeighbor struct {
+	HitID      int    `json:"hitId" db:"hit_chk_id"`
+	ID         int    `json:"id" db:"chk_id"`
+	DocumentID int    `json:"documentId" db:"chk_fk_document"`
+	Content    string `json:"content" db:"chk_content"`
+	Position   int    `json:"position" db:"chk_position"`
+	Ordinal    int    `json:"ordinal" db:"chk_ordinal"` // Gap-free order within the document
+}
+
+// Hybrid search fusion strategies
+const (
+	FusionWeighted = "weighted" // Linear mix of semantic and keyword scores by KeywordWeight
+	FusionRRF      = "rrf"      // Reciprocal Rank Fusion of the semantic and keyword rankings
+)
+
+// Query strategies (how the question is turned into search queries)
+const (
+	QueryStrategyNone       = "none"        // Search with the question as is
+	QueryStrategyMultiQuery = "multi_query" // Search with LLM paraphrases too and fuse the results with RRF
+	QueryStrategyHyDE       = "hyde"        // Embed an LLM-written hypothetical answer instead of the question
+)
+
+// RetrievalOptions are per-request overrides of the RAG retrieval parameters.
+// Nil fields fall back to RAG_FUSION_STRATEGY, RAG_RRF_K, RAG_MMR_ENABLED,
+// RAG_MMR_LAMBDA, RAG_MAX_CHUNKS_PER_DOCUMENT and RAG_NEIGHBOR_WINDOW.
+type RetrievalOptions struct {
+	Fusion               *string
+	RRFK                 *int
+	MMR                  *bool
+	MMRLambda            *float64
+	MaxChunksPerDocument *int
+	NeighborWindow       *int            // Expand each hit with its ±N neighbouring chunks
+	Filter               *SearchFilter   // Restrict the candidate documents
+	QueryStrategy        *string         // Overrides the per-category QUERY_STRATEGY_CONFIG
+	SkipStatistics       bool            // Do not count the search in the chunk usage statistics (evaluation runs)
+	Trace                *RetrievalTrace // When set, filled with the query embedded, the settings and the candidates of the search
+	Role                 *string         // Caller's role: only documents visible to it are searched (nil searches every document)
+	RecencyWeight        *float64        // Overrides RAG_RECENCY_WEIGHT (0 disables the recency boost)
+}
+
+// SearchFilter restricts hybrid search to matching documents. Empty fields do not filter;
+// a published date range excludes documents without a publication date.
+type SearchFilter struct {
+	Categories    []string   `json:"categories,omitempty" doc:"Only documents in one of these categories"`
+	IncludeTags   []string   `json:"includeTags,omitempty" doc:"Only documents having at least one of these tags"`
+	ExcludeTags   []string   `json:"excludeTags,omitempty" doc:"Skip documents having any of these tags"`
+	PublishedFrom *time.Time `json:"publishedFrom,omitempty" doc:"Only documents published at or after this time (RFC 3339)"`
+	PublishedTo   *time.Time `json:"publishedTo,omitempty" doc:"Only documents published at or before this time (RFC 3339)"`
+	Sources       []string   `json:"sources,omitempty" doc:"Only documents with one of these sources"`
+	DocumentIDs   []int      `json:"documentIds,omitempty" doc:"Only these documents"`
+	Headings      []string   `json:"headings,omitempty" doc:"Only chunks under one of these headings (case-insensitive), e.g. Requisitos"`
+}
+
+// RetrievalTrace records how a bot answer was retrieved and generated. The search fills the
+// query and candidate fields; the caller adds the chunks sent to the LLM, the prompt and the model.
+type RetrievalTrace struct {
+	Query           string           `json:"query"`                 // Question as searched
+	EmbeddedQuery   string           `json:"embeddedQuery"`         // Text actually embedded (the HyDE answer with that strategy)
+	Paraphrases     []string         `json:"paraphrases,omitempty"` // Multi-query paraphrases also searched
+	QueryStrategy   string           `json:"queryStrategy"`
+	Category        *string          `json:"category,omitempty"`
+	Filter          *SearchFilter    `json:"filter,omitempty"`
+	Role            *string          `json:"role,omitempty"` // Audience the search was restricted to
+	RecencyWeight   float64          `json:"recencyWeight,omitempty"`
+	Limit           int              `json:"limit"`
+	MinSimilarity   float64          `json:"minSimilarity"`
+	KeywordWeight   float64          `json:"keywordWeight"`
+	Fusion          string           `json:"fusion"`
+	Candidates      []TraceCandidate `json:"candidates"`      // Database results before reranking and diversity, in fused order
+	ContextChunkIDs []int            `json:"contextChunkIds"` // Chunks sent to the LLM, in prompt order
+	PromptCode      string           `json:"promptCode,omitempty"`
+	PromptVersion   string           `json:"promptVersion,omitempty"`
+	Model           string           `json:"model,omitempty"`
+}
+
+// TraceCandidate is a chunk returned by the database search, with its scores
+type TraceCandidate struct {
+	ChunkID         int      `json:"chunkId"`
+	DocumentID      int      `json:"documentId"`
+	SimilarityScore float64  `json:"similarityScore"`
+	KeywordScore    float64  `json:"keywordScore"`
+	CombinedScore   float64  `json:"combinedScore"`
+	RerankScore     *float64 `json:"rerankScore,omitempty"`
+}
+
+// QueryExpander runs the LLM step of the multi-query and HyDE strategies
+type QueryExpander interface {
+	// Strategy returns the query strategy configured for a document category; "" (no category)
+	// and categories without their own entry get the default strategy
+	Strategy(category string) string
+
+	// Paraphrases returns alternative phrasings of the query, without the query itself
+	Paraphrases(ctx context.Context, query string) ([]string, error)
+
+	// HypotheticalAnswer returns a passage that would answer the query
+	HypotheticalAnswer(ctx context.Context, query string) (string, error)
+}
+
+// Reranker rescores hybrid search candidates against the query
+type Reranker interface {
+	// CandidateLimit returns how many candidates to fetch for a final top-k (top-k itself when reranking is disabled)
+	CandidateLimit(topK int) int
+
+	// Rerank sets RerankScore on the candidates and keeps the best topK
+	Rerank(ctx context.Context, query string, candidates []ChunkWithHybridSimilarity, topK int) ([]ChunkWithHybridSimilarity, error)
 }
 
 // Chunk Repository Params & Results
@@ -69,6 +192,8 @@ type BulkCreateChunksParams struct {
 	DocumentID int
 	Contents   []string
 	Embeddings *[]pgvector.Vector
+	Headings   [][]string // Heading path of each content (nil: none)
+	Replace    bool       // Delete the existing chunks of the document in the same transaction
 }
 
 type BulkCreateChunksResult struct {
@@ -83,12 +208,19 @@ type SimilaritySearchParams struct {
 }
 
 type HybridSearchParams struct {
-	QueryEmbedding pgvector.Vector
-	QueryText      string
-	Limit          int
-	MinSimilarity  float64
-	KeywordWeight  float64
-	Category       *string // Optional: filter by document category (e.g., "DOC_INDECT")
+	QueryEmbedding      pgvector.Vector
+	QueryText           string
+	Limit               int
+	MinSimilarity       float64
+	KeywordWeight       float64
+	Category            *string // Optional: filter by document category (e.g., "DOC_INDECT")
+	Fusion              string  // FusionWeighted or FusionRRF
+	RRFK                int
+	WithEmbeddings      bool          // Load chunk embeddings for the MMR pass
+	Filter              *SearchFilter // Optional: metadata filters
+	Role                *string       // Optional: restrict to documents visible to this role
+	RecencyWeight       float64       // Boost newer documents by up to this fraction of their score (0 disables)
+	RecencyHalfLifeDays float64       // Age in days at which the boost halves
 }
 
 // Chunk Repository & UseCase Interfaces
@@ -97,6 +229,7 @@ type ChunkRepository interface {
 	GetByID(ctx context.Context, chunkID int) (*Chunk, error)
 	SimilaritySearch(ctx context.Context, params SimilaritySearchParams) ([]ChunkWithSimilarity, error)
 	HybridSearch(ctx context.Context, params HybridSearchParams) ([]ChunkWithHybridSimilarity, error)
+	GetNeighbors(ctx context.Context, chunkIDs []int, window int) ([]ChunkNeighbor, error)
 	Create(ctx context.Context, params CreateChunkParams) (*CreateChunkResult, error)
 	UpdateEmbedding(ctx context.Context, params UpdateChunkEmbeddingParams) (*UpdateChunkEmbeddingResult, error)
 	Delete(ctx context.Context, chunkID int) (*DeleteChunkResult, error)
@@ -107,10 +240,15 @@ type ChunkUseCase interface {
 	GetByDocument(ctx context.Context, docID int) Result[[]Chunk]
 	GetByID(ctx context.Context, chunkID int) Result[*Chunk]
 	SimilaritySearch(ctx context.Context, queryText string, limit int, minSimilarity float64) Result[[]ChunkWithSimilarity]
-	HybridSearch(ctx context.Context, queryText string, limit int, minSimilarity float64, keywordWeight float64) Result[[]ChunkWithHybridSimilarity]
-	HybridSearchWithCategory(ctx context.Context, queryText string, limit int, minSimilarity float64, keywordWeight float64, category *string) Result[[]ChunkWithHybridSimilarity]
+	HybridSearch(ctx context.Context, queryText string, limit int, minSimilarity float64, keywordWeight float64, opts RetrievalOptions) Result[[]ChunkWithHybridSimilarity]
+	HybridSearchWithCategory(ctx context.Context, queryText string, limit int, minSimilarity float64, keywordWeight float64, category *string, opts RetrievalOptions) Result[[]ChunkWithHybridSimilarity]
 	Create(ctx context.Context, documentID int, content string) Result[Data]
 	UpdateContent(ctx context.Context, chunkID int, content string) Result[Data]
 	Delete(ctx context.Context, chunkID int) Result[Data]
 	BulkCreate(ctx context.Context, documentID int, contents []string) Result[Data]
+	// BulkCreateWithHeadings is BulkCreate storing the heading path of each content
+	BulkCreateWithHeadings(ctx context.Context, documentID int, contents []string, headings [][]string) Result[Data]
+	// ReplaceWithHeadings is BulkCreateWithHeadings deleting the previous chunks of the document
+	// atomically, once the new ones are embedded
+	ReplaceWithHeadings(ctx context.Context, documentID int, contents []string, headings [][]string) Result[Data]
 }
@@ -90,6 +90,11 @@ type CreateConversationMessageParams struct {
 	CompletionTimeMs *int
 	TotalTokens      *int
 	TotalTimeMs      *int
+	VariantID        *int
+	Metadata         Data
+	ChunkIDs         []int     // Chunks the answer was built from, in retrieval order
+	ChunkScores      []float64 // Retrieval score of each chunk in ChunkIDs
+	RetrievalTrace   *RetrievalTrace
 }
 
 type CreateConversationMessageResult struct {
@@ -97,6 +102,20 @@ type CreateConversationMessageResult struct {
 	MessageID *int `json:"messageId,omitempty" db:"o_cvm_id"`
 }
 
+type LinkMessageChunksResult struct {
+	dal.DbResult
+}
+
+// RequestHandoffParams flags a conversation as needing an admin
+type RequestHandoffParams struct {
+	ConversationID int
+	Reason         string // no_results, few_hits, low_score, self_check or guardrail
+}
+
+type RequestHandoffResult struct {
+	dal.DbResult
+}
+
 // Conversation Repository & UseCase Interfaces
 type ConversationRepository interface {
 	GetByChatID(ctx context.Context, chatID string) (*Conversation, error)
@@ -104,6 +123,8 @@ type ConversationRepository interface {
 	LinkUser(ctx context.Context, params LinkUserToConversationParams) (*LinkUserToConversationResult, error)
 	GetHistory(ctx context.Context, chatID string, limit int) ([]ConversationMessage, error)
 	CreateMessage(ctx context.Context, params CreateConversationMessageParams) (*CreateConversationMessageResult, error)
+	LinkMessageChunks(ctx context.Context, messageID int, chunkIDs []int, scores []float64) (*LinkMessageChunksResult, error)
+	RequestHandoff(ctx context.Context, params RequestHandoffParams) (*RequestHandoffResult, error)
 }
 
 type ConversationUseCase interface {
@@ -112,4 +133,6 @@ type ConversationUseCase interface {
 	GetConversationHistory(ctx context.Context, chatID string, limit int) Result[[]ConversationMessage]
 	StoreMessage(ctx context.Context, conversationID int, messageID string, fromMe bool, body string, timestamp int64) Result[Data]
 	StoreMessageWithStats(ctx context.Context, params CreateConversationMessageParams) Result[Data]
+	// RequestHandoff flags the conversation as needing an admin and bumps its unread count
+	RequestHandoff(ctx context.Context, params RequestHandoffParams) Result[Data]
 }
@@ -14,6 +14,10 @@ type Document struct {
 	Summary     *string    `json:"summary" db:"doc_summary"`
 	Source      *string    `json:"source" db:"doc_source"`
 	PublishedAt *time.Time `json:"publishedAt" db:"doc_published_at"`
+	Tags        []string   `json:"tags" db:"doc_tags"`
+	Audience    []string   `json:"audience" db:"doc_audience"`      // Roles allowed to retrieve it; empty inherits the category audience
+	ValidFrom   *time.Time `json:"validFrom" db:"doc_valid_from"`   // Not retrieved before this time
+	ValidUntil  *time.Time `json:"validUntil" db:"doc_valid_until"` // Not retrieved from this time on; deactivated by the expiry job
 	Active      bool       `json:"active" db:"doc_active"`
 	CreatedAt   time.Time  `json:"createdAt" db:"doc_created_at"`
 	UpdatedAt   time.Time  `json:"updatedAt" db:"doc_updated_at"`
@@ -26,6 +30,10 @@ type CreateDocumentParams struct {
 	Summary     *string
 	Source      *string
 	PublishedAt *time.Time
+	Tags        []string
+	Audience    []string
+	ValidFrom   *time.Time
+	ValidUntil  *time.Time
 }
 
 type CreateDocumentResult struct {
@@ -40,6 +48,10 @@ type UpdateDocumentParams struct {
 	Summary     *string
 	Source      *string
 	PublishedAt *time.Time
+	Tags        []string
+	Audience    []string
+	ValidFrom   *time.Time
+	ValidUntil  *time.Time
 }
 
 type UpdateDocumentResult struct {
@@ -50,6 +62,19 @@ type DeleteDocumentResult struct {
 	dal.DbResult
 }
 
+// ExpiredDocument is a document deactivated because its validity ended
+type ExpiredDocument struct {
+	ID         int    `json:"id"`
+	Title      string `json:"title"`
+	Category   string `json:"category"`
+	ValidUntil string `json:"validUntil"` // YYYY-MM-DD HH:MM
+}
+
+type ExpireDocumentsResult struct {
+	dal.DbResult
+	Expired []ExpiredDocument `json:"expired" db:"o_expired"`
+}
+
 // Document Repository & UseCase Interfaces
 type DocumentRepository interface {
 	GetAll(ctx context.Context, limit, offset int) ([]Document, error)
@@ -59,12 +84,142 @@ type DocumentRepository interface {
 	Create(ctx context.Context, params CreateDocumentParams) (*CreateDocumentResult, error)
 	Update(ctx context.Context, params UpdateDocumentParams) (*UpdateDocumentResult, error)
 	Delete(ctx context.Context, docID int) (*DeleteDocumentResult, error)
+	// ExpireDocuments deactivates the documents whose validity has ended
+	ExpireDocuments(ctx context.Context) (*ExpireDocumentsResult, error)
+	// GetTableMapping returns the document with the mapping it was imported with (nil when not found)
+	GetTableMapping(ctx context.Context, docID int) (*DocumentTableMapping, error)
+	SetTableMapping(ctx context.Context, docID int, summary *string, mapping TableMapping) (*SetDocumentTableMappingResult, error)
+}
+
+// Document file types accepted by UploadDocument
+const (
+	FileTypePDF      = "pdf"
+	FileTypeDOCX     = "docx"
+	FileTypeODT      = "odt"
+	FileTypeMarkdown = "md"   // Chunked along its headings
+	FileTypeText     = "txt"  // Chunked along its headings, if it has any
+	FileTypeXLSX     = "xlsx" // Imported as a table: one chunk per row or group of rows
+	FileTypeCSV      = "csv"  // Imported as a table: one chunk per row or group of rows
+)
+
+// UploadDocumentParams uploads a PDF, DOCX, ODT, Markdown, plain-text, XLSX or CSV file. FileType may be
+// empty: it is then taken from the FileName extension or sniffed from the content.
+type UploadDocumentParams struct {
+	Category      string
+	Title         string
+	Source        *string
+	Tags          []string
+	Audience      []string
+	ValidFrom     *time.Time
+	ValidUntil    *time.Time
+	FileBase64    string
+	FileType      string
+	FileName      *string
+	ChunkStrategy string // Empty: the category strategy, else headings for Markdown and text, sentence otherwise
+	ChunkSize     int    // 0: the category or strategy default
+	ChunkOverlap  *int   // nil: the category or strategy default
+}
+
+// CreateTextDocumentParams creates a document from pasted Markdown or plain text
+type CreateTextDocumentParams struct {
+	Category      string
+	Title         string
+	Source        *string
+	Tags          []string
+	Audience      []string
+	ValidFrom     *time.Time
+	ValidUntil    *time.Time
+	Content       string
+	Format        string // FileTypeMarkdown or FileTypeText
+	ChunkStrategy string // Empty: the category strategy, else headings
+	ChunkSize     int    // 0: the category or strategy default
+	ChunkOverlap  *int   // nil: the category or strategy default
+}
+
+// PreviewChunksParams chunks a file or a text as an upload would, without storing anything
+type PreviewChunksParams struct {
+	Category      string // Optional: applies the chunking configured for the category
+	Content       string
+	Format        string
+	FileBase64    string
+	FileType      string
+	FileName      *string
+	ChunkStrategy string
+	ChunkSize     int
+	ChunkOverlap  *int
+	Mapping       *TableMapping // Spreadsheets and CSV files
+}
+
+// ChunkPreview is a chunk as it would be stored
+type ChunkPreview struct {
+	Position   int      `json:"position"`
+	Content    string   `json:"content"`
+	Headings   []string `json:"headings"`
+	Characters int      `json:"characters"`
+	Tokens     int      `json:"tokens"` // Estimated
+}
+
+// ChunkPreviewResult lists the chunks a file or text would be split into and how
+type ChunkPreviewResult struct {
+	FileType     string         `json:"fileType"`
+	Strategy     string         `json:"strategy"` // "table" for spreadsheets and CSV files
+	ChunkSize    int            `json:"chunkSize"`
+	ChunkOverlap int            `json:"chunkOverlap"`
+	TextLength   int            `json:"textLength"`
+	TotalChunks  int            `json:"totalChunks"`
+	Chunks       []ChunkPreview `json:"chunks"`
+}
+
+// TableMapping configures how the rows of a spreadsheet or CSV become chunks. It is stored
+// with the document and reused when the file is re-imported without a mapping.
+type TableMapping struct {
+	Sheets       []string          `json:"sheets,omitempty" doc:"XLSX sheets to import (default: every visible sheet)"`
+	HeaderRow    int               `json:"headerRow,omitempty" doc:"1-based row holding the column names (default: 1)"`
+	Columns      []string          `json:"columns,omitempty" doc:"Columns to keep, in this order (default: every column with a name)"`
+	Labels       map[string]string `json:"labels,omitempty" doc:"Column name to the label written in the chunks, e.g. {\"CARR\": \"Carrera\"}"`
+	GroupBy      []string          `json:"groupBy,omitempty" doc:"Rows sharing the values of these columns become one chunk, e.g. [\"Carrera\", \"Semestre\"]"`
+	RowsPerChunk int               `json:"rowsPerChunk,omitempty" doc:"Rows per chunk when not grouping (default: 1)"`
+	Delimiter    string            `json:"delimiter,omitempty" doc:"CSV delimiter (default: detected among , ; tab and |)"`
+}
+
+// ImportTableParams imports a spreadsheet or CSV as a new document or, with DocID, re-imports
+// it into an existing document, replacing its previous rows. A nil Mapping reuses the one the
+// document was imported with.
+type ImportTableParams struct {
+	DocID      *int
+	Category   string
+	Title      string
+	Source     *string
+	Tags       []string
+	Audience   []string
+	ValidFrom  *time.Time
+	ValidUntil *time.Time
+	FileBase64 string
+	FileType   string
+	FileName   *string
+	Mapping    *TableMapping
+	ChunkSize  int
+}
+
+// DocumentTableMapping is a document with the mapping it was imported with (nil for other documents)
+type DocumentTableMapping struct {
+	DocID   int           `db:"doc_id"`
+	Active  bool          `db:"doc_active"`
+	Mapping *TableMapping `db:"doc_table_mapping"`
+}
+
+type SetDocumentTableMappingResult struct {
+	dal.DbResult
 }
 
 type UploadPDFDocumentParams struct {
 	Category     string
 	Title        string
 	Source       *string
+	Tags         []string
+	Audience     []string
+	ValidFrom    *time.Time
+	ValidUntil   *time.Time
 	FileBase64   string
 	ChunkSize    int
 	ChunkOverlap int
@@ -79,4 +234,15 @@ type DocumentUseCase interface {
 	Update(ctx context.Context, params UpdateDocumentParams) Result[Data]
 	Delete(ctx context.Context, docID int) Result[Data]
 	UploadPDF(ctx context.Context, params UploadPDFDocumentParams) Result[Data]
+	// UploadDocument extracts the text of a PDF, DOCX, ODT, Markdown or plain-text file, creates
+	// the document and chunks it. Markdown and plain text are chunked along their headings.
+	UploadDocument(ctx context.Context, params UploadDocumentParams) Result[Data]
+	// CreateFromText creates a document from pasted Markdown or plain text, chunked along its headings
+	CreateFromText(ctx context.Context, params CreateTextDocumentParams) Result[Data]
+	// ImportTable turns each row (or group of rows) of an XLSX or CSV file into a
+	// self-describing chunk, creating the document or replacing the rows of an existing one
+	ImportTable(ctx context.Context, params ImportTableParams) Result[Data]
+	// PreviewChunks returns the chunks a file or text would be split into, without storing them
+	PreviewChunks(ctx context.Context, params PreviewChunksParams) Result[*ChunkPreviewResult]
+	ExpireDocuments(ctx context.Context) Result[[]ExpiredDocument]
 }
@@ -1,12 +1,76 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"api-chatbot/api/dal"
+	"github.com/pgvector/pgvector-go"
+)
 
 // EmbeddingService generates vector embeddings from text
 type EmbeddingService interface {
 	// GenerateEmbedding generates a single embedding vector from text
 	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
 
-	// GenerateEmbeddings generates embeddings for multiple texts (batch)
+	// GenerateEmbeddings generates embeddings for multiple texts (batch).
+	// The result has one entry per input; empty texts get a nil embedding.
 	GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error)
+
+	// Model returns the embedding model currently configured (cached vectors are keyed by it)
+	Model() (string, error)
+}
+
+// CachedEmbedding is a cached vector looked up by text hash
+type CachedEmbedding struct {
+	TextHash  string          `db:"emc_text_hash"`
+	Embedding pgvector.Vector `db:"emc_embedding"`
+}
+
+// EmbeddingCacheStats holds the cache size and hit rate of one model
+type EmbeddingCacheStats struct {
+	Model         string     `json:"model" db:"model"`
+	Entries       int64      `json:"entries" db:"entries"`
+	Hits          int64      `json:"hits" db:"hits"`
+	Misses        int64      `json:"misses" db:"misses"`
+	HitRate       *float64   `json:"hitRate" db:"hit_rate"`
+	OldestEntryAt *time.Time `json:"oldestEntryAt,omitempty" db:"oldest_entry_at"`
+	LastUsedAt    *time.Time `json:"lastUsedAt,omitempty" db:"last_used_at"`
+}
+
+// Embedding Cache Repository Params & Results
+
+type RecordEmbeddingCacheParams struct {
+	Model          string
+	HitHashes      []string
+	MissHashes     []string
+	MissEmbeddings []pgvector.Vector
+}
+
+type RecordEmbeddingCacheResult struct {
+	dal.DbResult
+}
+
+type PurgeEmbeddingCacheParams struct {
+	Model      *string // nil purges every model
+	UnusedDays *int    // nil purges regardless of last use
+}
+
+type PurgeEmbeddingCacheResult struct {
+	dal.DbResult
+	Deleted *int `json:"deleted" db:"o_deleted"`
+}
+
+// Embedding Cache Repository & UseCase Interfaces
+
+type EmbeddingCacheRepository interface {
+	GetCached(ctx context.Context, model string, textHashes []string) ([]CachedEmbedding, error)
+	Record(ctx context.Context, params RecordEmbeddingCacheParams) (*RecordEmbeddingCacheResult, error)
+	GetStats(ctx context.Context, days int) ([]EmbeddingCacheStats, error)
+	Purge(ctx context.Context, params PurgeEmbeddingCacheParams) (*PurgeEmbeddingCacheResult, error)
+}
+
+type EmbeddingCacheUseCase interface {
+	GetStats(ctx context.Context, days int) Result[[]EmbeddingCacheStats]
+	Purge(ctx context.Context, params PurgeEmbeddingCacheParams) Result[Data]
 }
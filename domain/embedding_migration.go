@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"api-chatbot/api/dal"
+	"github.com/pgvector/pgvector-go"
+)
+
+// Embedding job statuses
+const (
+	EmbeddingJobRunning   = "running"
+	EmbeddingJobPaused    = "paused"
+	EmbeddingJobCompleted = "completed"
+	EmbeddingJobSwapped   = "swapped"
+	EmbeddingJobCancelled = "cancelled"
+)
+
+// EmbeddingJob is a re-embedding job with its live progress
+type EmbeddingJob struct {
+	ID               int        `json:"id" db:"ejb_id"`
+	Status           string     `json:"status" db:"ejb_status"`
+	ConfigCode       string     `json:"configCode" db:"ejb_config_code"`
+	TargetModel      string     `json:"targetModel" db:"ejb_target_model"`
+	TargetDimensions int        `json:"targetDimensions" db:"ejb_target_dimensions"`
+	BatchSize        int        `json:"batchSize" db:"ejb_batch_size"`
+	AutoSwap         bool       `json:"autoSwap" db:"ejb_auto_swap"`
+	TotalChunks      int        `json:"totalChunks" db:"ejb_total_chunks"`
+	ProcessedChunks  int        `json:"processedChunks" db:"ejb_processed_chunks"`
+	PendingChunks    int        `json:"pendingChunks" db:"ejb_pending_chunks"`
+	ProgressPercent  float64    `json:"progressPercent" db:"ejb_progress_percent"`
+	FailedChunks     int        `json:"failedChunks" db:"ejb_failed_chunks"`
+	LastError        *string    `json:"lastError,omitempty" db:"ejb_last_error"`
+	StartedAt        time.Time  `json:"startedAt" db:"ejb_started_at"`
+	CompletedAt      *time.Time `json:"completedAt,omitempty" db:"ejb_completed_at"`
+	SwappedAt        *time.Time `json:"swappedAt,omitempty" db:"ejb_swapped_at"`
+	UpdatedAt        time.Time  `json:"updatedAt" db:"ejb_updated_at"`
+}
+
+// PendingChunk is a chunk still waiting for its shadow embedding
+type PendingChunk struct {
+	ID      int    `db:"chk_id"`
+	Content string `db:"chk_content"`
+}
+
+// Embedding Migration Repository Params & Results
+
+type CreateEmbeddingJobParams struct {
+	ConfigCode       string
+	TargetModel      string
+	TargetDimensions int
+	BatchSize        int
+	AutoSwap         bool
+}
+
+type CreateEmbeddingJobResult struct {
+	dal.DbResult
+	ID *int `json:"id" db:"o_ejb_id"`
+}
+
+type StoreNextEmbeddingsParams struct {
+	JobID         int
+	ChunkIDs      []int
+	ContentHashes []string // md5 of the content the embedding was generated from
+	Embeddings    []pgvector.Vector
+}
+
+type StoreNextEmbeddingsResult struct {
+	dal.DbResult
+	Updated *int `json:"updated" db:"o_updated"`
+}
+
+type UpdateEmbeddingJobStatusParams struct {
+	JobID        int
+	Status       string
+	FailedChunks *int
+	Error        *string
+}
+
+type UpdateEmbeddingJobStatusResult struct {
+	dal.DbResult
+}
+
+type SwapEmbeddingSetResult struct {
+	dal.DbResult
+}
+
+// StartEmbeddingMigrationParams starts re-embedding every chunk with the configuration stored in ConfigCode
+type StartEmbeddingMigrationParams struct {
+	ConfigCode string
+	BatchSize  int
+	AutoSwap   bool
+}
+
+// Embedding Migration Repository & UseCase Interfaces
+
+type EmbeddingMigrationRepository interface {
+	CreateJob(ctx context.Context, params CreateEmbeddingJobParams) (*CreateEmbeddingJobResult, error)
+	GetJobs(ctx context.Context, jobID *int, limit int) ([]EmbeddingJob, error)
+	GetPendingChunks(ctx context.Context, afterID int, limit int) ([]PendingChunk, error)
+	StoreNextEmbeddings(ctx context.Context, params StoreNextEmbeddingsParams) (*StoreNextEmbeddingsResult, error)
+	UpdateJobStatus(ctx context.Context, params UpdateEmbeddingJobStatusParams) (*UpdateEmbeddingJobStatusResult, error)
+	SwapEmbeddingSet(ctx context.Context, jobID int) (*SwapEmbeddingSetResult, error)
+}
+
+type EmbeddingMigrationUseCase interface {
+	Start(ctx context.Context, params StartEmbeddingMigrationParams) Result[Data]
+	Pause(ctx context.Context, jobID int) Result[Data]
+	Resume(ctx context.Context, jobID int) Result[Data]
+	Cancel(ctx context.Context, jobID int) Result[Data]
+	Swap(ctx context.Context, jobID int) Result[Data]
+	GetJobs(ctx context.Context, jobID *int) Result[[]EmbeddingJob]
+
+	// ResumeRunning restarts workers for jobs left running by a previous process
+	ResumeRunning(ctx context.Context)
+}
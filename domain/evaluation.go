@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"api-chatbot/api/dal"
+)
+
+// DefaultEvalSet is the golden set used when none is given
+const DefaultEvalSet = "default"
+
+// EvalQuestion is a golden set question labelled with the chunks and/or documents that answer it.
+// A retrieved chunk counts as relevant when its id (or one of its neighbours) is labelled
+// or when its document is labelled. The JSON form is also the JSONL golden set file format.
+type EvalQuestion struct {
+	ID                  int       `json:"id,omitempty" db:"evq_id"`
+	Set                 string    `json:"set,omitempty" db:"evq_set"`
+	Question            string    `json:"question" db:"evq_question"`
+	Category            *string   `json:"category,omitempty" db:"evq_category"`
+	RelevantChunkIDs    []int     `json:"relevantChunkIds,omitempty" db:"evq_relevant_chunk_ids"`
+	RelevantDocumentIDs []int     `json:"relevantDocumentIds,omitempty" db:"evq_relevant_document_ids"`
+	CreatedAt           time.Time `json:"createdAt,omitzero" db:"evq_created_at"`
+}
+
+// EvalMetrics are retrieval quality metrics at k (per question, or averaged over a run)
+type EvalMetrics struct {
+	PrecisionAtK float64 `json:"precisionAtK"`
+	RecallAtK    float64 `json:"recallAtK"`
+	F1AtK        float64 `json:"f1AtK"`
+	MRR          float64 `json:"mrr"`
+	MAP          float64 `json:"map"`
+	NDCG         float64 `json:"ndcg"`
+	HitRate      float64 `json:"hitRate"` // 1 when at least one relevant chunk was retrieved
+}
+
+// EvalQuestionResult is the outcome of one golden set question in a run
+type EvalQuestionResult struct {
+	QuestionID        int         `json:"questionId,omitempty"`
+	Question          string      `json:"question"`
+	RetrievedChunkIDs []int       `json:"retrievedChunkIds"`
+	RelevantRanks     []int       `json:"relevantRanks"` // 1-based ranks of the relevant retrieved chunks
+	Metrics           EvalMetrics `json:"metrics"`
+	Error             string      `json:"error,omitempty"` // Search failure (counted as zero metrics)
+}
+
+// EvalRun is the report of an evaluation run: the search settings it ran with,
+// the averaged metrics and the per-question results
+type EvalRun struct {
+	ID            int                  `json:"id" db:"evr_id"`
+	Set           string               `json:"set" db:"evr_set"`
+	Label         *string              `json:"label,omitempty" db:"evr_label"`
+	K             int                  `json:"k" db:"evr_k"`
+	QuestionCount int                  `json:"questionCount" db:"evr_question_count"`
+	Settings      Data                 `json:"settings" db:"evr_settings"`
+	Metrics       EvalMetrics          `json:"metrics" db:"evr_metrics"`
+	Results       []EvalQuestionResult `json:"results,omitempty" db:"evr_results"`
+	CreatedAt     time.Time            `json:"createdAt" db:"evr_created_at"`
+}
+
+// Evaluation Repository Params & Results
+
+type CreateEvalQuestionParams struct {
+	Set                 string
+	Question            string
+	Category            *string
+	RelevantChunkIDs    []int
+	RelevantDocumentIDs []int
+}
+
+type CreateEvalQuestionResult struct {
+	dal.DbResult
+	QuestionID *int `json:"questionId" db:"o_evq_id"`
+}
+
+type DeleteEvalQuestionResult struct {
+	dal.DbResult
+}
+
+type CreateEvalRunParams struct {
+	Set           string
+	Label         *string
+	K             int
+	QuestionCount int
+	Settings      Data
+	Metrics       EvalMetrics
+	Results       []EvalQuestionResult
+}
+
+type CreateEvalRunResult struct {
+	dal.DbResult
+	RunID *int `json:"runId" db:"o_evr_id"`
+}
+
+// RunEvaluationParams configures an evaluation run. Nil search settings fall back to
+// RAG_SEARCH_LIMIT, RAG_MIN_SIMILARITY and RAG_KEYWORD_WEIGHT; Options override the
+// retrieval parameters as for a regular search. When Questions is set (e.g. read from
+// a JSONL file) it is evaluated instead of the stored set.
+type RunEvaluationParams struct {
+	Set           string
+	Label         *string
+	K             *int
+	MinSimilarity *float64
+	KeywordWeight *float64
+	Options       RetrievalOptions
+	Questions     []EvalQuestion
+	Save          bool
+}
+
+// Evaluation Repository & UseCase Interfaces
+
+type EvaluationRepository interface {
+	CreateQuestion(ctx context.Context, params CreateEvalQuestionParams) (*CreateEvalQuestionResult, error)
+	DeleteQuestion(ctx context.Context, questionID int) (*DeleteEvalQuestionResult, error)
+	GetQuestions(ctx context.Context, set string) ([]EvalQuestion, error)
+	CreateRun(ctx context.Context, params CreateEvalRunParams) (*CreateEvalRunResult, error)
+	GetRuns(ctx context.Context, runID *int, set *string, limit int) ([]EvalRun, error)
+}
+
+type EvaluationUseCase interface {
+	CreateQuestion(ctx context.Context, params CreateEvalQuestionParams) Result[Data]
+	DeleteQuestion(ctx context.Context, questionID int) Result[Data]
+	GetQuestions(ctx context.Context, set string) Result[[]EvalQuestion]
+	// ImportQuestions adds several labelled questions to a set, skipping the invalid ones
+	ImportQuestions(ctx context.Context, set string, questions []EvalQuestion) Result[Data]
+	// RunEvaluation searches every golden question with the current index and settings
+	// and returns the report, storing it when params.Save is set
+	RunEvaluation(ctx context.Context, params RunEvaluationParams) Result[*EvalRun]
+	GetRuns(ctx context.Context, set *string, limit int) Result[[]EvalRun]
+	GetRun(ctx context.Context, runID int) Result[*EvalRun]
+}
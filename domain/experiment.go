@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"api-chatbot/api/dal"
+)
+
+// Experiment is an A/B test over prompt, model and retrieval settings.
+// Only one experiment runs at a time.
+type Experiment struct {
+	ID          int                 `json:"id" db:"exp_id"`
+	Code        string              `json:"code" db:"exp_code"`
+	Name        string              `json:"name" db:"exp_name"`
+	Description *string             `json:"description,omitempty" db:"exp_description"`
+	Active      bool                `json:"active" db:"exp_active"`
+	StartedAt   *time.Time          `json:"startedAt,omitempty" db:"exp_started_at"`
+	EndedAt     *time.Time          `json:"endedAt,omitempty" db:"exp_ended_at"`
+	CreatedAt   time.Time           `json:"createdAt" db:"exp_created_at"`
+	Variants    []ExperimentVariant `json:"variants" db:"exp_variants"`
+}
+
+// ExperimentVariant is one arm of an experiment
+type ExperimentVariant struct {
+	ID             int             `json:"id" db:"exv_id"`
+	ExperimentID   int             `json:"experimentId" db:"exv_fk_experiment"`
+	ExperimentCode string          `json:"experimentCode,omitempty" db:"exp_code"`
+	Code           string          `json:"code" db:"exv_code"`
+	Weight         int             `json:"weight" db:"exv_weight"`
+	Config         VariantSettings `json:"config" db:"exv_config"`
+}
+
+// VariantSettings overrides the RAG defaults for conversations assigned to a variant.
+// Unset fields keep the value from the parameters (RAG_SYSTEM_PROMPT, LLM_CONFIG, RAG_SEARCH_LIMIT...).
+type VariantSettings struct {
+	PromptVersion string   `json:"promptVersion,omitempty"` // label stored with the message
+	PromptCode    string   `json:"promptCode,omitempty"`    // parameter holding the system prompt
+	SystemPrompt  string   `json:"systemPrompt,omitempty"`  // inline system prompt (used when promptCode is empty)
+	Model         string   `json:"model,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	SearchLimit   *int     `json:"searchLimit,omitempty"`
+	KeywordWeight *float64 `json:"keywordWeight,omitempty"`
+}
+
+// ResolveSystemPrompt returns the variant system prompt, read from the promptCode parameter
+// or taken inline, or "" when the variant keeps the default prompt
+func (s VariantSettings) ResolveSystemPrompt(cache ParameterCache) string {
+	if s.PromptCode != "" {
+		if param, exists := cache.Get(s.PromptCode); exists {
+			if data, err := param.GetDataAsMap(); err == nil {
+				if prompt, ok := data["message"].(string); ok && prompt != "" {
+					return prompt
+				}
+			}
+		}
+	}
+	return s.SystemPrompt
+}
+
+// MessageMetadata returns the variant tag stored in the metadata of the messages it produces
+func (v *ExperimentVariant) MessageMetadata() Data {
+	metadata := Data{
+		"experiment": v.ExperimentCode,
+		"variant":    v.Code,
+	}
+	if v.Config.PromptVersion != "" {
+		metadata["promptVersion"] = v.Config.PromptVersion
+	}
+	if v.Config.Model != "" {
+		metadata["model"] = v.Config.Model
+	}
+	return metadata
+}
+
+// VariantComparison holds the outcome metrics of one variant
+type VariantComparison struct {
+	VariantID           int      `json:"variantId" db:"variant_id"`
+	VariantCode         string   `json:"variantCode" db:"variant_code"`
+	VariantWeight       int      `json:"variantWeight" db:"variant_weight"`
+	ConversationCount   int64    `json:"conversationCount" db:"conversation_count"`
+	ResponseCount       int64    `json:"responseCount" db:"response_count"`
+	AvgResponseTimeMs   *float64 `json:"avgResponseTimeMs" db:"avg_response_time_ms"`
+	AvgLLMTimeMs        *float64 `json:"avgLlmTimeMs" db:"avg_llm_time_ms"`
+	AvgPromptTokens     *float64 `json:"avgPromptTokens" db:"avg_prompt_tokens"`
+	AvgCompletionTokens *float64 `json:"avgCompletionTokens" db:"avg_completion_tokens"`
+	AvgTotalTokens      *float64 `json:"avgTotalTokens" db:"avg_total_tokens"`
+	AvgBestSimilarity   *float64 `json:"avgBestSimilarity" db:"avg_best_similarity"`
+	NoResultsRate       *float64 `json:"noResultsRate" db:"no_results_rate"`
+	PositiveFeedback    int64    `json:"positiveFeedback" db:"positive_feedback"`
+	NegativeFeedback    int64    `json:"negativeFeedback" db:"negative_feedback"`
+	SatisfactionRate    *float64 `json:"satisfactionRate" db:"satisfaction_rate"`
+	HandoffRate         *float64 `json:"handoffRate" db:"handoff_rate"`
+}
+
+// Experiment Repository Params & Results
+
+type CreateExperimentVariantParams struct {
+	Code   string          `json:"code"`
+	Weight int             `json:"weight"`
+	Config VariantSettings `json:"config"`
+}
+
+type CreateExperimentParams struct {
+	Code        string
+	Name        string
+	Description *string
+	Variants    []CreateExperimentVariantParams
+}
+
+type CreateExperimentResult struct {
+	dal.DbResult
+	ExperimentID *int `json:"experimentId" db:"o_exp_id"`
+}
+
+type SetExperimentStatusResult struct {
+	dal.DbResult
+}
+
+type CompareVariantsParams struct {
+	ExperimentID int
+	StartDate    *time.Time
+	EndDate      *time.Time
+}
+
+// Experiment Repository & UseCase Interfaces
+
+type ExperimentRepository interface {
+	Create(ctx context.Context, params CreateExperimentParams) (*CreateExperimentResult, error)
+	SetStatus(ctx context.Context, experimentID int, active bool) (*SetExperimentStatusResult, error)
+	GetAll(ctx context.Context) ([]Experiment, error)
+	GetActiveVariants(ctx context.Context) ([]ExperimentVariant, error)
+	CompareVariants(ctx context.Context, params CompareVariantsParams) ([]VariantComparison, error)
+}
+
+type ExperimentUseCase interface {
+	CreateExperiment(ctx context.Context, params CreateExperimentParams) Result[Data]
+	SetExperimentStatus(ctx context.Context, experimentID int, active bool) Result[Data]
+	GetExperiments(ctx context.Context) Result[[]Experiment]
+	// AssignVariant returns the variant of the running experiment for a chat (sticky by chat ID),
+	// or nil when no experiment is running
+	AssignVariant(ctx context.Context, chatID string) *ExperimentVariant
+	CompareVariants(ctx context.Context, params CompareVariantsParams) Result[[]VariantComparison]
+}
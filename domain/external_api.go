@@ -11,6 +11,7 @@ type ChatCompletionsResponse struct {
 	Choices    []ChatCompletionChoice `json:"choices"`
 	Usage      *UsageInfo             `json:"usage,omitempty"`
 	RAGContext *RAGContextInfo        `json:"rag_context,omitempty"`
+	FAQ        *FAQAnswerInfo         `json:"faq,omitempty"` // Set when a curated FAQ answered instead of the LLM
 }
 
 // ChatCompletionChoice represents a choice in the chat completion
@@ -37,14 +38,26 @@ type UsageInfo struct {
 type RAGContextInfo struct {
 	ChunksRetrieved int          `json:"chunks_retrieved"`
 	Sources         []SourceInfo `json:"sources"`
+	QueryStrategy   string       `json:"query_strategy,omitempty"` // "multi_query" or "hyde" when a query strategy was applied
+}
+
+// FAQAnswerInfo identifies the curated FAQ a completion was answered with
+type FAQAnswerInfo struct {
+	FAQID     int     `json:"faq_id"`
+	Question  string  `json:"question"`
+	Score     float64 `json:"score"`
+	MediaURL  *string `json:"media_url,omitempty"`
+	MediaType *string `json:"media_type,omitempty"`
 }
 
 // SourceInfo represents information about a source document
 type SourceInfo struct {
-	DocumentID    int     `json:"document_id"`
-	DocumentTitle string  `json:"document_title"`
-	ChunkID       int     `json:"chunk_id"`
-	Similarity    float64 `json:"similarity"`
+	DocumentID    int      `json:"document_id"`
+	DocumentTitle string   `json:"document_title"`
+	ChunkID       int      `json:"chunk_id"`
+	Headings      []string `json:"headings,omitempty"` // Section of the document the chunk belongs to
+	Similarity    float64  `json:"similarity"`
+	RerankScore   *float64 `json:"rerank_score,omitempty"` // Set when the reranking stage is enabled
 }
 
 // EmbeddingsResponse represents the OpenAI-compatible embeddings response
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"api-chatbot/api/dal"
+	"github.com/pgvector/pgvector-go"
+)
+
+// FAQ media types
+const (
+	FAQMediaImage    = "image"
+	FAQMediaDocument = "document"
+)
+
+// FAQ is a curated question with an approved answer. A question matching it closely
+// enough is answered with that exact wording instead of a generated one.
+type FAQ struct {
+	ID             int        `json:"id" db:"faq_id"`
+	Question       string     `json:"question" db:"faq_question"`
+	Variants       []string   `json:"variants" db:"faq_variants"` // Other phrasings of the question
+	Answer         string     `json:"answer" db:"faq_answer"`
+	Category       *string    `json:"category" db:"faq_category"` // Only matched for this category (nil: always)
+	MediaURL       *string    `json:"mediaUrl" db:"faq_media_url"`
+	MediaType      *string    `json:"mediaType" db:"faq_media_type"` // image, document
+	Threshold      *float64   `json:"threshold" db:"faq_threshold"`  // Overrides FAQ_CONFIG.threshold
+	EmbeddingModel *string    `json:"embeddingModel" db:"faq_embedding_model"`
+	HitCount       int        `json:"hitCount" db:"faq_hit_count"`
+	LastHitAt      *time.Time `json:"lastHitAt" db:"faq_last_hit_at"`
+	Active         bool       `json:"active" db:"faq_active"`
+	CreatedAt      time.Time  `json:"createdAt" db:"faq_created_at"`
+	UpdatedAt      time.Time  `json:"updatedAt" db:"faq_updated_at"`
+}
+
+// FAQMatch is an FAQ scored against a question by its best-matching phrasing
+type FAQMatch struct {
+	FAQID           int      `json:"faqId" db:"faq_id"`
+	Question        string   `json:"question" db:"faq_question"`
+	Answer          string   `json:"answer" db:"faq_answer"`
+	Category        *string  `json:"category,omitempty" db:"faq_category"`
+	MediaURL        *string  `json:"mediaUrl,omitempty" db:"faq_media_url"`
+	MediaType       *string  `json:"mediaType,omitempty" db:"faq_media_type"`
+	OwnThreshold    *float64 `json:"-" db:"faq_threshold"`
+	MatchedVariant  string   `json:"matchedVariant" db:"matched_variant"`
+	SimilarityScore float64  `json:"similarityScore" db:"similarity_score"`
+	KeywordScore    float64  `json:"keywordScore" db:"keyword_score"`
+	Score           float64  `json:"score" db:"score"`
+	Threshold       float64  `json:"threshold" db:"-"` // Score at which the FAQ overrides generation
+	Overrides       bool     `json:"overrides" db:"-"`
+}
+
+// FAQ Repository Params & Results
+
+// SaveFAQParams creates an FAQ (ID nil) or updates an existing one
+type SaveFAQParams struct {
+	ID        *int
+	Question  string
+	Variants  []string
+	Answer    string
+	Category  *string
+	MediaURL  *string
+	MediaType *string
+	Threshold *float64
+	Active    *bool
+}
+
+// MatchFAQParams holds a question embedded with the current model
+type MatchFAQParams struct {
+	QueryEmbedding *pgvector.Vector // nil matches by keywords only
+	QueryText      string
+	Model          string
+	KeywordWeight  float64
+	Category       *string
+	Limit          int
+}
+
+type SaveFAQResult struct {
+	dal.DbResult
+	FAQID *int `json:"faqId" db:"o_faq_id"`
+}
+
+type DeleteFAQResult struct {
+	dal.DbResult
+}
+
+type RecordFAQHitResult struct {
+	dal.DbResult
+}
+
+// FAQ Repository & UseCase Interfaces
+
+type FAQRepository interface {
+	GetFAQs(ctx context.Context, includeInactive bool) ([]FAQ, error)
+	Match(ctx context.Context, params MatchFAQParams) ([]FAQMatch, error)
+	// Save creates or updates an FAQ and rebuilds its phrasings with their embeddings
+	// (the question first, then the variants)
+	Save(ctx context.Context, params SaveFAQParams, embeddings []pgvector.Vector, model string) (*SaveFAQResult, error)
+	Delete(ctx context.Context, faqID int) (*DeleteFAQResult, error)
+	RecordHit(ctx context.Context, faqID int) (*RecordFAQHitResult, error)
+}
+
+type FAQUseCase interface {
+	GetFAQs(ctx context.Context, includeInactive bool) Result[[]FAQ]
+	Save(ctx context.Context, params SaveFAQParams) Result[Data]
+	Delete(ctx context.Context, faqID int) Result[Data]
+	// Reembed re-embeds the phrasings of every FAQ with the current embedding model
+	Reembed(ctx context.Context) Result[Data]
+	// Match scores the FAQs against a question without counting hits (admin preview)
+	Match(ctx context.Context, query string, category *string) Result[[]FAQMatch]
+	// Answer returns the FAQ overriding generation for a question, counting the hit,
+	// or nil when no FAQ reaches its threshold or FAQs are disabled
+	Answer(ctx context.Context, query string, category *string) Result[*FAQMatch]
+}
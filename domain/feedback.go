@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"context"
+
+	"api-chatbot/api/dal"
+)
+
+// Feedback sources
+const (
+	FeedbackSourceWhatsApp = "whatsapp" // Reaction on the bot message
+	FeedbackSourceAPI      = "api"      // Rating sent through the external API
+	FeedbackSourceAdmin    = "admin"    // Marked in the admin panel
+)
+
+// MessageChunk is a chunk a bot answer was built from, with the answer feedback and the
+// admin relevance label (which overrides the feedback for that chunk)
+type MessageChunk struct {
+	MessageID  int      `json:"messageId" db:"mck_fk_message"`
+	ChunkID    int      `json:"chunkId" db:"mck_fk_chunk"`
+	Rank       int      `json:"rank" db:"mck_rank"`
+	Score      *float64 `json:"score,omitempty" db:"mck_score"`
+	Relevant   *bool    `json:"relevant,omitempty" db:"mck_relevant"`
+	Feedback   *int16   `json:"feedback,omitempty" db:"cvm_feedback"`
+	DocumentID int      `json:"documentId" db:"chk_fk_document"`
+	DocTitle   string   `json:"docTitle" db:"doc_title"`
+	Content    string   `json:"content" db:"chk_content"`
+}
+
+// Feedback Repository Params & Results
+
+// SetMessageFeedbackParams rates a bot answer by its message ID
+// (WhatsApp message ID, or msg-<completion id>-assistant for the external API)
+type SetMessageFeedbackParams struct {
+	MessageID string
+	Rating    int     // 1 positive, -1 negative, 0 clears the rating
+	Source    string  // FeedbackSource*
+	ChatID    *string // When set, the answer must belong to this conversation
+}
+
+type SetMessageFeedbackResult struct {
+	dal.DbResult
+	MessageID *int `json:"messageId" db:"o_cvm_id"`
+}
+
+type SetChunkRelevanceParams struct {
+	MessageID int // cvm_id of the answer
+	ChunkID   int
+	Relevant  *bool // nil removes the label
+}
+
+type SetChunkRelevanceResult struct {
+	dal.DbResult
+}
+
+// Feedback Repository & UseCase Interfaces
+
+type FeedbackRepository interface {
+	// SetMessageFeedback rates an answer and recomputes the metrics of the chunks it used
+	SetMessageFeedback(ctx context.Context, params SetMessageFeedbackParams) (*SetMessageFeedbackResult, error)
+	// SetChunkRelevance labels one chunk of an answer and recomputes its metrics
+	SetChunkRelevance(ctx context.Context, params SetChunkRelevanceParams) (*SetChunkRelevanceResult, error)
+	GetMessageChunks(ctx context.Context, messageID int) ([]MessageChunk, error)
+}
+
+type FeedbackUseCase interface {
+	SetMessageFeedback(ctx context.Context, params SetMessageFeedbackParams) Result[Data]
+	SetChunkRelevance(ctx context.Context, params SetChunkRelevanceParams) Result[Data]
+	GetMessageChunks(ctx context.Context, messageID int) Result[[]MessageChunk]
+	// ReactionRating maps a WhatsApp reaction to a rating per FEEDBACK_CONFIG
+	// (an empty reaction, i.e. a removed one, clears the rating); ok is false when it is not feedback
+	ReactionRating(reaction string) (rating int, ok bool)
+}
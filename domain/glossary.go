@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"api-chatbot/api/dal"
+)
+
+// Glossary entry types
+const (
+	GlossaryTypeSynonym = "synonym"
+	GlossaryTypeAcronym = "acronym"
+)
+
+// GlossaryEntry is a search glossary term and its equivalent expansions. Chunks mentioning
+// any member of the group are indexed with all of them, and queries match any of them.
+type GlossaryEntry struct {
+	ID         int       `json:"id" db:"gls_id"`
+	Term       string    `json:"term" db:"gls_term"`
+	Type       string    `json:"type" db:"gls_type"` // synonym, acronym
+	Expansions []string  `json:"expansions" db:"gls_expansions"`
+	CreatedAt  time.Time `json:"createdAt" db:"gls_created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" db:"gls_updated_at"`
+}
+
+// SearchQueryExpansion shows how a search query is parsed for keyword matching,
+// before and after applying the glossary
+type SearchQueryExpansion struct {
+	ParsedQuery   string `json:"parsedQuery" db:"parsed_query"`
+	ExpandedQuery string `json:"expandedQuery" db:"expanded_query"`
+}
+
+// Glossary Repository Params & Results
+
+// SaveGlossaryEntryParams creates an entry (ID nil) or updates an existing one
+type SaveGlossaryEntryParams struct {
+	ID         *int
+	Term       string
+	Type       string
+	Expansions []string
+}
+
+type SaveGlossaryEntryResult struct {
+	dal.DbResult
+	EntryID   *int `json:"entryId" db:"o_gls_id"`
+	Reindexed int  `json:"reindexed" db:"o_reindexed"`
+}
+
+type DeleteGlossaryEntryResult struct {
+	dal.DbResult
+	Reindexed int `json:"reindexed" db:"o_reindexed"`
+}
+
+type ReindexChunkFTSResult struct {
+	dal.DbResult
+	Updated int `json:"updated" db:"o_updated"`
+}
+
+// Glossary Repository & UseCase Interfaces
+
+type GlossaryRepository interface {
+	GetEntries(ctx context.Context, entryType *string) ([]GlossaryEntry, error)
+	// SaveEntry creates or updates an entry and reindexes the chunks it affects
+	SaveEntry(ctx context.Context, params SaveGlossaryEntryParams) (*SaveGlossaryEntryResult, error)
+	// DeleteEntry deactivates an entry and reindexes the chunks it expanded
+	DeleteEntry(ctx context.Context, entryID int) (*DeleteGlossaryEntryResult, error)
+	// ReindexChunks recomputes the full-text vectors of every chunk
+	ReindexChunks(ctx context.Context) (*ReindexChunkFTSResult, error)
+	GetQueryExpansion(ctx context.Context, query string) (*SearchQueryExpansion, error)
+}
+
+type GlossaryUseCase interface {
+	GetEntries(ctx context.Context, entryType *string) Result[[]GlossaryEntry]
+	SaveEntry(ctx context.Context, params SaveGlossaryEntryParams) Result[Data]
+	DeleteEntry(ctx context.Context, entryID int) Result[Data]
+	ReindexChunks(ctx context.Context) Result[Data]
+	PreviewQuery(ctx context.Context, query string) Result[*SearchQueryExpansion]
+}
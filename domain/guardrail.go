@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"api-chatbot/api/dal"
+)
+
+// GuardrailEvent represents a guardrail trigger on user input or generated output
+type GuardrailEvent struct {
+	ID             int        `json:"id" db:"gev_id"`
+	Stage          string     `json:"stage" db:"gev_stage"` // input, output
+	Check          string     `json:"check" db:"gev_check"` // rules, pii, secrets, classifier
+	Rule           *string    `json:"rule,omitempty" db:"gev_rule"`
+	Action         string     `json:"action" db:"gev_action"` // block, rewrite, escalate
+	Reason         *string    `json:"reason,omitempty" db:"gev_reason"`
+	Channel        string     `json:"channel" db:"gev_channel"` // whatsapp, api
+	ChatID         *string    `json:"chatId,omitempty" db:"gev_chat_id"`
+	ConversationID *int       `json:"conversationId,omitempty" db:"gev_fk_conversation"`
+	OriginalText   string     `json:"originalText" db:"gev_original_text"`
+	RewrittenText  *string    `json:"rewrittenText,omitempty" db:"gev_rewritten_text"`
+	Reviewed       bool       `json:"reviewed" db:"gev_reviewed"`
+	ReviewedBy     *int       `json:"reviewedBy,omitempty" db:"gev_reviewed_by"`
+	ReviewedAt     *time.Time `json:"reviewedAt,omitempty" db:"gev_reviewed_at"`
+	ReviewNotes    *string    `json:"reviewNotes,omitempty" db:"gev_review_notes"`
+	CreatedAt      time.Time  `json:"createdAt" db:"gev_created_at"`
+}
+
+// Guardrail Repository Params & Results
+
+type CreateGuardrailEventParams struct {
+	Stage          string
+	Check          string
+	Rule           *string
+	Action         string
+	Reason         *string
+	Channel        string
+	ChatID         *string
+	ConversationID int
+	OriginalText   string
+	RewrittenText  *string
+}
+
+type CreateGuardrailEventResult struct {
+	dal.DbResult
+	EventID *int `json:"eventId" db:"o_gev_id"`
+}
+
+type GetGuardrailEventsParams struct {
+	Stage       *string
+	Action      *string
+	OnlyPending bool
+	Limit       int
+	Offset      int
+}
+
+type ReviewGuardrailEventParams struct {
+	EventID int
+	AdminID int
+	Notes   *string
+}
+
+type ReviewGuardrailEventResult struct {
+	dal.DbResult
+}
+
+// Guardrail Repository & UseCase Interfaces
+
+type GuardrailRepository interface {
+	CreateEvent(ctx context.Context, params CreateGuardrailEventParams) (*CreateGuardrailEventResult, error)
+	GetEvents(ctx context.Context, params GetGuardrailEventsParams) ([]GuardrailEvent, error)
+	ReviewEvent(ctx context.Context, params ReviewGuardrailEventParams) (*ReviewGuardrailEventResult, error)
+}
+
+type GuardrailUseCase interface {
+	LogEvent(ctx context.Context, params CreateGuardrailEventParams) Result[Data]
+	GetEvents(ctx context.Context, params GetGuardrailEventsParams) Result[[]GuardrailEvent]
+	ReviewEvent(ctx context.Context, params ReviewGuardrailEventParams) Result[Data]
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"fmt"
 )
 
 type HTTPHeader struct {
@@ -19,6 +20,16 @@ type HTTPRequest struct {
 	AdditionalHeaders []HTTPHeader
 }
 
+// HTTPStatusError is returned by HTTPClient.Do when the response status is not 2xx
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("Error in external service: status %d. Message: %s", e.StatusCode, e.Body)
+}
+
 type HTTPClient interface {
 	Do(ctx context.Context, req HTTPRequest, response any) error
 }
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"api-chatbot/api/dal"
+)
+
+// GapQuestion is a user question of the analysed period with how it was answered
+type GapQuestion struct {
+	MessageID      int       `json:"messageId" db:"cvm_id"`
+	Question       string    `json:"question" db:"question"`
+	AskedAt        time.Time `json:"askedAt" db:"asked_at"`
+	BestSimilarity *float64  `json:"bestSimilarity" db:"best_similarity"` // nil: nothing retrieved
+	HandedOff      bool      `json:"handedOff" db:"handed_off"`
+	FAQAnswered    bool      `json:"faqAnswered" db:"faq_answered"`
+}
+
+// KnowledgeGap is a cluster of questions asking the same thing. A high unanswered share
+// means the topic is asked about but the documents do not cover it: a gap to document.
+type KnowledgeGap struct {
+	Label                   string    `json:"label"`                   // Most central question
+	RepresentativeQuestions []string  `json:"representativeQuestions"` // Distinct questions closest to the centre
+	QuestionCount           int       `json:"questionCount"`
+	AvgConfidence           *float64  `json:"avgConfidence"` // Mean best retrieval score (nil: nothing retrieved)
+	UnansweredCount         int       `json:"unansweredCount"`
+	UnansweredShare         float64   `json:"unansweredShare"` // Share answered without good sources
+	HandoffCount            int       `json:"handoffCount"`
+	FAQAnsweredCount        int       `json:"faqAnsweredCount"`
+	FirstAsked              time.Time `json:"firstAsked"`
+	LastAsked               time.Time `json:"lastAsked"`
+}
+
+// KnowledgeGapRun is a clustering of the questions of a period, worst documented clusters first
+type KnowledgeGapRun struct {
+	ID            int            `json:"id" db:"kgr_id"`
+	PeriodStart   time.Time      `json:"periodStart" db:"kgr_period_start"`
+	PeriodEnd     time.Time      `json:"periodEnd" db:"kgr_period_end"`
+	QuestionCount int            `json:"questionCount" db:"kgr_question_count"`
+	ClusterCount  int            `json:"clusterCount" db:"kgr_cluster_count"`
+	Settings      Data           `json:"settings" db:"kgr_settings"`
+	Clusters      []KnowledgeGap `json:"clusters,omitempty" db:"kgr_clusters"`
+	CreatedAt     time.Time      `json:"createdAt" db:"kgr_created_at"`
+}
+
+// Knowledge Gap Repository Params & Results
+
+type CreateKnowledgeGapRunParams struct {
+	PeriodStart   time.Time
+	PeriodEnd     time.Time
+	QuestionCount int
+	Settings      Data
+	Clusters      []KnowledgeGap
+}
+
+type CreateKnowledgeGapRunResult struct {
+	dal.DbResult
+	RunID *int `json:"runId" db:"o_kgr_id"`
+}
+
+// AnalyzeKnowledgeGapsParams selects the period to cluster: StartDate/EndDate, or else the
+// last Period (day, week, month; default week). Nil settings fall back to KNOWLEDGE_GAP_CONFIG.
+type AnalyzeKnowledgeGapsParams struct {
+	StartDate           *time.Time
+	EndDate             *time.Time
+	Period              string
+	SimilarityThreshold *float64
+	MinClusterSize      *int
+	GoodScore           *float64
+	Save                bool
+}
+
+// Knowledge Gap Repository & UseCase Interfaces
+
+type KnowledgeGapRepository interface {
+	GetQuestions(ctx context.Context, startDate, endDate time.Time, limit int) ([]GapQuestion, error)
+	CreateRun(ctx context.Context, params CreateKnowledgeGapRunParams) (*CreateKnowledgeGapRunResult, error)
+	// GetRuns returns runs newest first; clusters are only loaded for a single or the latest run
+	GetRuns(ctx context.Context, runID *int, latest bool, limit int) ([]KnowledgeGapRun, error)
+}
+
+type KnowledgeGapUseCase interface {
+	// Analyze embeds and clusters the user questions of a period and returns the clusters,
+	// storing the run when params.Save is set
+	Analyze(ctx context.Context, params AnalyzeKnowledgeGapsParams) Result[*KnowledgeGapRun]
+	// GetGaps returns the clusters of a stored run (nil: the latest) with at least
+	// minUnansweredShare of their questions answered without good sources
+	GetGaps(ctx context.Context, runID *int, minUnansweredShare float64, limit int) Result[*KnowledgeGapRun]
+	GetRuns(ctx context.Context, limit int) Result[[]KnowledgeGapRun]
+}
@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"api-chatbot/api/dal"
+)
+
+// Web document sync statuses (sp_sync_web_document)
+const (
+	WebDocumentCreated   = "created"
+	WebDocumentUpdated   = "updated"
+	WebDocumentUnchanged = "unchanged"
+	WebDocumentInactive  = "inactive" // Deactivated by an admin: left untouched
+)
+
+// WebSource is a website crawled into one document per page (doc_source = page URL),
+// starting from a page or from a sitemap
+type WebSource struct {
+	ID              int             `json:"id" db:"wbs_id"`
+	URL             string          `json:"url" db:"wbs_url"`
+	IsSitemap       bool            `json:"isSitemap" db:"wbs_is_sitemap"`
+	Category        string          `json:"category" db:"wbs_category"`
+	Tags            []string        `json:"tags" db:"wbs_tags"`
+	Audience        []string        `json:"audience" db:"wbs_audience"`
+	MaxDepth        int             `json:"maxDepth" db:"wbs_max_depth"` // Link levels followed from the start page (or sitemap pages)
+	MaxPages        int             `json:"maxPages" db:"wbs_max_pages"`
+	IncludePatterns []string        `json:"includePatterns" db:"wbs_include_patterns"` // Regexps: only matching URLs become documents
+	ExcludePatterns []string        `json:"excludePatterns" db:"wbs_exclude_patterns"` // Regexps: matching URLs are not crawled
+	RecrawlHours    *int            `json:"recrawlHours" db:"wbs_recrawl_hours"`       // nil: crawled on demand only
+	ChunkSize       int             `json:"chunkSize" db:"wbs_chunk_size"`
+	ChunkOverlap    int             `json:"chunkOverlap" db:"wbs_chunk_overlap"`
+	LastCrawledAt   *time.Time      `json:"lastCrawledAt" db:"wbs_last_crawled_at"`
+	LastResult      *WebCrawlResult `json:"lastResult" db:"wbs_last_result"`
+	Active          bool            `json:"active" db:"wbs_active"`
+	CreatedAt       time.Time       `json:"createdAt" db:"wbs_created_at"`
+	UpdatedAt       time.Time       `json:"updatedAt" db:"wbs_updated_at"`
+}
+
+// WebPageError is a page of a crawl that could not be fetched or stored
+type WebPageError struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// WebCrawlResult summarises a crawl: pages whose content hash did not change are
+// counted as unchanged and not re-embedded
+type WebCrawlResult struct {
+	SourceID      int            `json:"sourceId"`
+	URL           string         `json:"url"`
+	PagesFetched  int            `json:"pagesFetched"`
+	PagesFound    int            `json:"pagesFound"` // Pages with content to ingest
+	Created       int            `json:"created"`
+	Updated       int            `json:"updated"`
+	Unchanged     int            `json:"unchanged"`
+	Inactive      int            `json:"inactive"`
+	Skipped       int            `json:"skipped"` // Disallowed, excluded, noindex or empty pages
+	Failed        int            `json:"failed"`
+	ChunksCreated int            `json:"chunksCreated"`
+	Errors        []WebPageError `json:"errors"`
+	StartedAt     time.Time      `json:"startedAt"`
+	FinishedAt    time.Time      `json:"finishedAt"`
+}
+
+// Web Source Repository Params & Results
+
+// SaveWebSourceParams creates a web source (ID nil) or updates an existing one
+type SaveWebSourceParams struct {
+	ID              *int
+	URL             string
+	IsSitemap       bool
+	Category        string
+	Tags            []string
+	Audience        []string
+	MaxDepth        int
+	MaxPages        int
+	IncludePatterns []string
+	ExcludePatterns []string
+	RecrawlHours    *int
+	ChunkSize       int
+	ChunkOverlap    int
+	Active          *bool
+}
+
+// SyncWebDocumentParams is a crawled page to create or update as a document
+type SyncWebDocumentParams struct {
+	Source      string
+	Category    string
+	Title       string
+	Summary     *string
+	ContentHash string
+	Tags        []string
+	Audience    []string
+}
+
+type SaveWebSourceResult struct {
+	dal.DbResult
+	SourceID *int `json:"sourceId" db:"o_source_id"`
+}
+
+type DeleteWebSourceResult struct {
+	dal.DbResult
+}
+
+type RecordWebCrawlResult struct {
+	dal.DbResult
+}
+
+type SyncWebDocumentResult struct {
+	dal.DbResult
+	DocID  *int    `json:"docId" db:"o_doc_id"`
+	Status *string `json:"status" db:"o_status"`
+}
+
+type SetDocumentContentHashResult struct {
+	dal.DbResult
+}
+
+// Web Source Repository & UseCase Interfaces
+
+type WebSourceRepository interface {
+	GetSources(ctx context.Context, includeInactive bool, sourceID *int) ([]WebSource, error)
+	// GetDueSources returns the active sources whose re-crawl interval has elapsed
+	GetDueSources(ctx context.Context) ([]WebSource, error)
+	Save(ctx context.Context, params SaveWebSourceParams) (*SaveWebSourceResult, error)
+	Delete(ctx context.Context, sourceID int) (*DeleteWebSourceResult, error)
+	RecordCrawl(ctx context.Context, sourceID int, result WebCrawlResult) (*RecordWebCrawlResult, error)
+	// SyncDocument creates the document of a page or, when its content hash changed, updates it
+	// and deletes its chunks (its hash is cleared until SetContentHash)
+	SyncDocument(ctx context.Context, params SyncWebDocumentParams) (*SyncWebDocumentResult, error)
+	SetContentHash(ctx context.Context, docID int, contentHash string) (*SetDocumentContentHashResult, error)
+}
+
+type WebSourceUseCase interface {
+	GetSources(ctx context.Context, includeInactive bool) Result[[]WebSource]
+	Save(ctx context.Context, params SaveWebSourceParams) Result[Data]
+	Delete(ctx context.Context, sourceID int) Result[Data]
+	// Crawl crawls a source and re-embeds only the pages whose content changed
+	Crawl(ctx context.Context, sourceID int) Result[*WebCrawlResult]
+	// CrawlDue crawls every source due a re-crawl
+	CrawlDue(ctx context.Context) Result[[]WebCrawlResult]
+}
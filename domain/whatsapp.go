@@ -38,6 +38,7 @@ type IncomingMessage struct {
 	QuotedMessage string
 	MediaURL      string
 	IsForwarded   bool
+	ReactionTo    string // Message a "reaction" message reacts to (Body holds the emoji, empty when removed)
 }
 
 // WhatsApp Repository Params & Results
@@ -0,0 +1,42 @@
+package embedding
+
+import "unicode/utf8"
+
+// charsPerToken is a conservative characters-per-token ratio for Spanish and
+// English text; overestimating tokens only makes sub-batches smaller
+const charsPerToken = 3
+
+// estimateTokens approximates the token count of a text without a tokenizer
+func estimateTokens(text string) int {
+	return utf8.RuneCountInString(text)/charsPerToken + 1
+}
+
+// splitBatches groups the positions of non-empty texts into sub-batches of at most
+// maxSize inputs and about maxTokens estimated tokens. A single text over the token
+// budget gets a sub-batch of its own and is left for the API to accept or reject.
+func splitBatches(texts []string, maxSize, maxTokens int) [][]int {
+	var batches [][]int
+	var current []int
+	currentTokens := 0
+
+	for pos, text := range texts {
+		if text == "" {
+			continue
+		}
+
+		tokens := estimateTokens(text)
+		if len(current) > 0 && (len(current) >= maxSize || currentTokens+tokens > maxTokens) {
+			batches = append(batches, current)
+			current = nil
+			currentTokens = 0
+		}
+
+		current = append(current, pos)
+		currentTokens += tokens
+	}
+
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
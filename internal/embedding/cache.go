@@ -0,0 +1,178 @@
+package embedding
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pgvector/pgvector-go"
+	"golang.org/x/text/unicode/norm"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/logger"
+)
+
+// CachedEmbeddingService decorates an EmbeddingService with a persistent cache
+// keyed by (model, normalised-text hash). Texts embedded before are served from
+// the database; only misses reach the wrapped service.
+// Disabled through EMBEDDING_CACHE_CONFIG {"enabled": false}.
+type CachedEmbeddingService struct {
+	next       domain.EmbeddingService
+	cacheRepo  domain.EmbeddingCacheRepository
+	paramCache domain.ParameterCache
+}
+
+func NewCachedEmbeddingService(next domain.EmbeddingService, cacheRepo domain.EmbeddingCacheRepository, paramCache domain.ParameterCache) *CachedEmbeddingService {
+	return &CachedEmbeddingService{
+		next:       next,
+		cacheRepo:  cacheRepo,
+		paramCache: paramCache,
+	}
+}
+
+// normalizeText applies Unicode NFC and collapses whitespace so trivially different copies share a cache entry
+func normalizeText(text string) string {
+	return strings.Join(strings.Fields(norm.NFC.String(text)), " ")
+}
+
+// hashText returns the hex SHA-256 of the normalised text
+func hashText(text string) string {
+	sum := sha256.Sum256([]byte(normalizeText(text)))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *CachedEmbeddingService) enabled() bool {
+	param, exists := s.paramCache.Get("EMBEDDING_CACHE_CONFIG")
+	if !exists {
+		return false
+	}
+	data, err := param.GetDataAsMap()
+	if err != nil {
+		return false
+	}
+	enabled, _ := data["enabled"].(bool)
+	return enabled
+}
+
+func (s *CachedEmbeddingService) Model() (string, error) {
+	return s.next.Model()
+}
+
+// GenerateEmbedding returns the cached vector for the text or generates and caches it
+func (s *CachedEmbeddingService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := s.GenerateEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) == 0 || embeddings[0] == nil {
+		return nil, fmt.Errorf("no embedding data in response")
+	}
+	return embeddings[0], nil
+}
+
+// GenerateEmbeddings serves cached vectors and sends only the missing texts to the wrapped service.
+// The result is aligned with texts; empty texts get a nil embedding, as in the wrapped service.
+func (s *CachedEmbeddingService) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	if !s.enabled() {
+		return s.next.GenerateEmbeddings(ctx, texts)
+	}
+
+	model, err := s.next.Model()
+	if err != nil {
+		return nil, err
+	}
+
+	// Hash per input position ("" for empty texts) and unique hashes in input order
+	hashes := make([]string, len(texts))
+	textByHash := make(map[string]string, len(texts))
+	var uniqueHashes []string
+	for i, text := range texts {
+		if text == "" {
+			continue
+		}
+		hash := hashText(text)
+		hashes[i] = hash
+		if _, ok := textByHash[hash]; !ok {
+			textByHash[hash] = text
+			uniqueHashes = append(uniqueHashes, hash)
+		}
+	}
+	if len(uniqueHashes) == 0 {
+		return make([][]float32, len(texts)), nil
+	}
+
+	vectors := make(map[string][]float32, len(uniqueHashes))
+	cached, err := s.cacheRepo.GetCached(ctx, model, uniqueHashes)
+	if err != nil {
+		// The cache is an optimisation: fall back to generating everything
+		logger.LogWarn(ctx, "Embedding cache lookup failed", "error", err.Error(), "model", model)
+	}
+	for _, entry := range cached {
+		vectors[entry.TextHash] = entry.Embedding.Slice()
+	}
+
+	var hitHashes, missHashes, missTexts []string
+	for _, hash := range uniqueHashes {
+		if _, ok := vectors[hash]; ok {
+			hitHashes = append(hitHashes, hash)
+		} else {
+			missHashes = append(missHashes, hash)
+			missTexts = append(missTexts, textByHash[hash])
+		}
+	}
+
+	var missEmbeddings []pgvector.Vector
+	if len(missTexts) > 0 {
+		generated, err := s.next.GenerateEmbeddings(ctx, missTexts)
+		if err != nil {
+			return nil, err
+		}
+		if len(generated) != len(missTexts) {
+			return nil, fmt.Errorf("embedding service returned %d embeddings, expected %d", len(generated), len(missTexts))
+		}
+		missEmbeddings = make([]pgvector.Vector, len(generated))
+		for i, embedding := range generated {
+			vectors[missHashes[i]] = embedding
+			missEmbeddings[i] = pgvector.NewVector(embedding)
+		}
+	}
+
+	go s.record(domain.RecordEmbeddingCacheParams{
+		Model:          model,
+		HitHashes:      hitHashes,
+		MissHashes:     missHashes,
+		MissEmbeddings: missEmbeddings,
+	})
+
+	embeddings := make([][]float32, len(texts))
+	for i, hash := range hashes {
+		if hash != "" {
+			embeddings[i] = vectors[hash]
+		}
+	}
+	return embeddings, nil
+}
+
+// record stores new vectors and hit/miss counters without delaying the caller
+func (s *CachedEmbeddingService) record(params domain.RecordEmbeddingCacheParams) {
+	asyncCtx, asyncCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer asyncCancel()
+
+	result, err := s.cacheRepo.Record(asyncCtx, params)
+	if err != nil || result == nil {
+		logger.LogError(asyncCtx, "Failed to record embedding cache", err,
+			"operation", "RecordEmbeddingCache",
+			"model", params.Model,
+		)
+		return
+	}
+	if !result.Success {
+		logger.LogWarn(asyncCtx, "Embedding cache record failed with business logic error",
+			"operation", "RecordEmbeddingCache",
+			"code", result.Code,
+		)
+	}
+}
@@ -1,16 +1,41 @@
 package embedding
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
 
 	"api-chatbot/domain"
+	"api-chatbot/internal/logger"
+)
+
+// defaultDimensions is the vector size assumed when EMBEDDING_CONFIG does not set "dimensions"
+const defaultDimensions = 1536
+
+// defaultConfigCode is the parameter holding the active embedding configuration
+const defaultConfigCode = "EMBEDDING_CONFIG"
+
+// Batch defaults, overridable in the embedding configuration parameter
+const (
+	defaultMaxBatchSize   = 2048   // OpenAI maximum inputs per request
+	defaultMaxBatchTokens = 250000 // Below the 300k tokens per request limit, leaving room for estimation error
+	defaultConcurrency    = 4
+	defaultMaxRetries     = 3
 )
 
 type openAIRequest struct {
 	Input          any    `json:"input"` // Supports string (single) or []string (batch)
 	Model          string `json:"model"`
 	EncodingFormat string `json:"encoding_format,omitempty"` // Added based on curl request
+	Dimensions     int    `json:"dimensions,omitempty"`      // Only sent when configured explicitly
 }
 
 type usage struct {
@@ -34,53 +59,183 @@ type openAIResponse struct {
 type OpenAIEmbeddingService struct {
 	paramCache domain.ParameterCache
 	httpClient domain.HTTPClient
+	configCode string
+
+	mu        sync.RWMutex
+	rawData   []byte // EMBEDDING_CONFIG data the current config was resolved from
+	config    *embeddingConfig
+	configErr error
+}
+
+// embeddingConfig is the resolved embedding configuration parameter
+type embeddingConfig struct {
+	apiURL            string
+	apiKey            string
+	model             string
+	dimensions        int  // Expected vector size, validated on every response
+	requestDimensions bool // Send "dimensions" to the API (text-embedding-3 models)
+	maxBatchSize      int  // Inputs per API request
+	maxBatchTokens    int  // Estimated tokens per API request
+	concurrency       int  // API requests in flight per GenerateEmbeddings call
+	maxRetries        int  // Retries of a failed API request
 }
 
 func NewOpenAIEmbeddingService(paramCache domain.ParameterCache, httpClient domain.HTTPClient) *OpenAIEmbeddingService {
+	return NewOpenAIEmbeddingServiceWithConfig(paramCache, httpClient, defaultConfigCode)
+}
+
+// NewOpenAIEmbeddingServiceWithConfig reads its configuration from another parameter
+// with the EMBEDDING_CONFIG shape (used by re-embedding jobs for the target model)
+func NewOpenAIEmbeddingServiceWithConfig(paramCache domain.ParameterCache, httpClient domain.HTTPClient, configCode string) *OpenAIEmbeddingService {
 	return &OpenAIEmbeddingService{
 		paramCache: paramCache,
 		httpClient: httpClient,
+		configCode: configCode,
 	}
 }
 
-// getConfig retrieves necessary configuration from the parameter cache.
-func (s *OpenAIEmbeddingService) getConfig() (apiURL, apiKey, model string, err error) {
-	if param, exists := s.paramCache.Get("EMBEDDING_CONFIG"); exists {
-		if data, mapErr := param.GetDataAsMap(); mapErr == nil {
-			apiURL, _ = data["openaiUrl"].(string)
-			apiKey, _ = data["openaiApiKey"].(string)
-			model, _ = data["openaiModel"].(string)
-		}
+// getConfig retrieves the configuration from the parameter cache.
+// It is re-resolved only when the parameter changes (e.g. after /reload-cache or an embedding swap).
+func (s *OpenAIEmbeddingService) getConfig() (*embeddingConfig, error) {
+	param, exists := s.paramCache.Get(s.configCode)
+	if !exists {
+		return nil, fmt.Errorf("OpenAI embedding configuration %s not found in parameters", s.configCode)
+	}
+
+	s.mu.RLock()
+	if s.rawData != nil && bytes.Equal(s.rawData, param.Data) {
+		config, err := s.config, s.configErr
+		s.mu.RUnlock()
+		return config, err
+	}
+	s.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rawData != nil && bytes.Equal(s.rawData, param.Data) {
+		return s.config, s.configErr
+	}
+
+	previous := s.config
+	s.rawData = append([]byte(nil), param.Data...)
+	s.config, s.configErr = parseEmbeddingConfig(param)
+
+	if s.configErr != nil {
+		slog.Error("Embedding configuration not reloaded", "config", s.configCode, "error", s.configErr)
+		return nil, s.configErr
+	}
+
+	if previous != nil && (previous.model != s.config.model || previous.dimensions != s.config.dimensions) {
+		slog.Warn("Embedding model changed - existing chunk embeddings must be regenerated",
+			"config", s.configCode,
+			"previousModel", previous.model,
+			"previousDimensions", previous.dimensions,
+			"model", s.config.model,
+			"dimensions", s.config.dimensions,
+		)
+	} else {
+		slog.Info("Embedding configuration loaded", "config", s.configCode, "model", s.config.model, "dimensions", s.config.dimensions)
+	}
+
+	return s.config, nil
+}
+
+// parseEmbeddingConfig validates an embedding configuration parameter.
+// The dimension is not checked against the chk_embedding column here: a different
+// size is only valid through a re-embedding job, whose swap resizes the column.
+func parseEmbeddingConfig(param *domain.Parameter) (*embeddingConfig, error) {
+	data, err := param.GetDataAsMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", param.Code, err)
+	}
+
+	config := &embeddingConfig{
+		dimensions:     defaultDimensions,
+		maxBatchSize:   defaultMaxBatchSize,
+		maxBatchTokens: defaultMaxBatchTokens,
+		concurrency:    defaultConcurrency,
+		maxRetries:     defaultMaxRetries,
+	}
+	config.apiURL, _ = data["openaiUrl"].(string)
+	config.apiKey, _ = data["openaiApiKey"].(string)
+	config.model, _ = data["openaiModel"].(string)
+
+	if dims, ok := data["dimensions"].(float64); ok && dims > 0 {
+		config.dimensions = int(dims)
+		config.requestDimensions = true
+	}
+	if size, ok := data["maxBatchSize"].(float64); ok && size > 0 {
+		config.maxBatchSize = min(int(size), defaultMaxBatchSize)
+	}
+	if tokens, ok := data["maxBatchTokens"].(float64); ok && tokens > 0 {
+		config.maxBatchTokens = int(tokens)
+	}
+	if concurrency, ok := data["concurrency"].(float64); ok && concurrency > 0 {
+		config.concurrency = int(concurrency)
+	}
+	if retries, ok := data["maxRetries"].(float64); ok && retries >= 0 {
+		config.maxRetries = int(retries)
+	}
+
+	if config.apiURL == "" || config.apiKey == "" || config.model == "" {
+		return nil, fmt.Errorf("OpenAI embedding configuration not found in parameters (apiURL: %s, apiKey: [hidden], model: %s)", config.apiURL, config.model)
 	}
 
-	if apiURL == "" || apiKey == "" || model == "" {
-		err = fmt.Errorf("OpenAI embedding configuration not found in parameters (apiURL: %s, apiKey: [hidden], model: %s)", apiURL, model)
+	return config, nil
+}
+
+// newRequest builds the API request body for the configured model
+func (c *embeddingConfig) newRequest(input any) openAIRequest {
+	req := openAIRequest{
+		Input:          input,
+		Model:          c.model,
+		EncodingFormat: "float", // Explicitly set encoding format
 	}
+	if c.requestDimensions {
+		req.Dimensions = c.dimensions
+	}
+	return req
+}
 
-	return
+// checkDimensions validates that the API returned vectors of the configured size
+func (c *embeddingConfig) checkDimensions(embedding []float32) error {
+	if len(embedding) != c.dimensions {
+		return fmt.Errorf("model %s returned %d dimensions, expected %d", c.model, len(embedding), c.dimensions)
+	}
+	return nil
+}
+
+// Model returns the configured embedding model. Explicitly requested dimensions are
+// part of the name because the same model yields different vectors per size.
+func (s *OpenAIEmbeddingService) Model() (string, error) {
+	config, err := s.getConfig()
+	if err != nil {
+		return "", err
+	}
+	if config.requestDimensions {
+		return fmt.Sprintf("%s:%d", config.model, config.dimensions), nil
+	}
+	return config.model, nil
 }
 
 // GenerateEmbedding generates an embedding vector from a single text string.
 func (s *OpenAIEmbeddingService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
-	apiURL, apiKey, model, err := s.getConfig()
+	config, err := s.getConfig()
 
 	if err != nil {
 		return nil, err
 	}
 
-	reqBody := openAIRequest{
-		Input:          text, // single string
-		Model:          model,
-		EncodingFormat: "float", // Explicitly set encoding format
-	}
+	reqBody := config.newRequest(text) // single string
 
 	// Create HTTP request
 	httpReq := domain.HTTPRequest{
-		URL:    apiURL,
+		URL:    config.apiURL,
 		Method: "POST",
 		Body:   reqBody,
 		AdditionalHeaders: []domain.HTTPHeader{
-			{Key: "Authorization", Value: "Bearer " + apiKey},
+			{Key: "Authorization", Value: "Bearer " + config.apiKey},
 		},
 	}
 
@@ -98,75 +253,139 @@ func (s *OpenAIEmbeddingService) GenerateEmbedding(ctx context.Context, text str
 
 	var embedding []float32
 	embedding = openAIResp.Data[0].Embedding
+	if err := config.checkDimensions(embedding); err != nil {
+		return nil, err
+	}
 	// Since it's a single request, we return the first embedding.
 	return embedding, nil
 }
 
-// GenerateEmbeddings generates embeddings for multiple texts using a single batch API call.
+// GenerateEmbeddings generates embeddings for multiple texts. Inputs are split into
+// sub-batches by count and estimated tokens, sent with bounded concurrency and retried
+// on failure. The result is aligned with texts; empty texts get a nil embedding.
 func (s *OpenAIEmbeddingService) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
 	if len(texts) == 0 {
 		return nil, nil
 	}
 
-	const maxBatchSize = 2048 // Standard maximum for V3 models
-	if len(texts) > maxBatchSize {
-		return nil, fmt.Errorf("batch size of %d exceeds the OpenAI maximum of %d texts per request. Please chunk your input", len(texts), maxBatchSize)
+	config, err := s.getConfig()
+	if err != nil {
+		return nil, err
 	}
 
-	var filteredTexts []string
-	for _, text := range texts {
-		if text != "" {
-			filteredTexts = append(filteredTexts, text)
-		}
+	embeddings := make([][]float32, len(texts))
+	batches := splitBatches(texts, config.maxBatchSize, config.maxBatchTokens)
+	if len(batches) == 0 {
+		return embeddings, nil // All inputs were empty
 	}
 
-	if len(filteredTexts) == 0 {
-		return nil, nil // All inputs were empty
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(config.concurrency)
+
+	for _, positions := range batches {
+		group.Go(func() error {
+			inputs := make([]string, len(positions))
+			for i, pos := range positions {
+				inputs[i] = texts[pos]
+			}
+
+			vectors, err := s.embedBatchWithRetry(groupCtx, config, inputs)
+			if err != nil {
+				return err
+			}
+
+			// Sub-batches cover disjoint positions, so no locking is needed
+			for i, pos := range positions {
+				embeddings[pos] = vectors[i]
+			}
+			return nil
+		})
 	}
 
-	textsToSend := filteredTexts
-
-	apiURL, apiKey, model, err := s.getConfig()
-	if err != nil {
-		fmt.Printf("ERROR: Failed to generate embeddings: %v\n", err)
+	if err := group.Wait(); err != nil {
 		return nil, err
 	}
 
-	reqBody := openAIRequest{
-		Input:          textsToSend, // Use the filtered list
-		Model:          model,
-		EncodingFormat: "float",
+	return embeddings, nil
+}
+
+// embedBatchWithRetry sends one sub-batch, retrying network errors, rate limits (429) and
+// server errors (5xx) with exponential backoff; any other error fails at once
+func (s *OpenAIEmbeddingService) embedBatchWithRetry(ctx context.Context, config *embeddingConfig, inputs []string) ([][]float32, error) {
+	var lastErr error
+	for attempt := 0; attempt <= config.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := time.Duration(1<<(attempt-1)) * time.Second
+			logger.LogWarn(ctx, "Retrying embedding batch",
+				"operation", "GenerateEmbeddings",
+				"model", config.model,
+				"inputs", len(inputs),
+				"attempt", attempt,
+				"delay", delay.String(),
+				"error", lastErr.Error(),
+			)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		embeddings, err := s.embedBatch(ctx, config, inputs)
+		if err == nil {
+			return embeddings, nil
+		}
+		if ctx.Err() != nil || !retryable(err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// retryable reports whether a failed request may succeed when sent again: the API was not
+// reached, rate limited the request or failed on its side
+func retryable(err error) bool {
+	var statusErr *domain.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
 	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// embedBatch sends one API request for non-empty inputs
+func (s *OpenAIEmbeddingService) embedBatch(ctx context.Context, config *embeddingConfig, inputs []string) ([][]float32, error) {
+	reqBody := config.newRequest(inputs)
 
 	httpReq := domain.HTTPRequest{
-		URL:    apiURL,
+		URL:    config.apiURL,
 		Method: "POST",
 		Body:   reqBody,
 		AdditionalHeaders: []domain.HTTPHeader{
-			{Key: "Authorization", Value: "Bearer " + apiKey},
+			{Key: "Authorization", Value: "Bearer " + config.apiKey},
 		},
 	}
 
 	var openAIResp openAIResponse
-	err = s.httpClient.Do(ctx, httpReq, &openAIResp)
-
-	if err != nil {
-		fmt.Printf("ERROR: Failed to calling embeddings: %v\n", err)
+	if err := s.httpClient.Do(ctx, httpReq, &openAIResp); err != nil {
 		return nil, fmt.Errorf("error calling OpenAI API for batch embeddings: %w", err)
 	}
 
-	if len(openAIResp.Data) != len(textsToSend) {
-		return nil, fmt.Errorf("OpenAI API returned %d embeddings, expected %d based on filtered input", len(openAIResp.Data), len(textsToSend))
+	if len(openAIResp.Data) != len(inputs) {
+		return nil, fmt.Errorf("OpenAI API returned %d embeddings, expected %d", len(openAIResp.Data), len(inputs))
 	}
 
-	embeddings := make([][]float32, len(textsToSend))
+	embeddings := make([][]float32, len(inputs))
 
 	for _, item := range openAIResp.Data {
-		if item.Index >= 0 && item.Index < len(textsToSend) {
-			embeddings[item.Index] = item.Embedding
-		} else {
+		if item.Index < 0 || item.Index >= len(inputs) {
 			return nil, fmt.Errorf("received embedding with out-of-bounds index: %d", item.Index)
 		}
+		if err := config.checkDimensions(item.Embedding); err != nil {
+			return nil, err
+		}
+		embeddings[item.Index] = item.Embedding
 	}
 
 	return embeddings, nil
@@ -0,0 +1,130 @@
+package evaluation
+
+import (
+	"context"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/metrics"
+)
+
+// SearchFunc retrieves the chunks for a golden set question with the settings under evaluation
+type SearchFunc func(ctx context.Context, question domain.EvalQuestion) ([]domain.ChunkWithHybridSimilarity, error)
+
+// Run searches every question and scores the results against its labels.
+// A failed search is recorded in the question result and scores zero,
+// so runs over the same set stay comparable.
+func Run(ctx context.Context, questions []domain.EvalQuestion, search SearchFunc) ([]domain.EvalQuestionResult, domain.EvalMetrics) {
+	results := make([]domain.EvalQuestionResult, 0, len(questions))
+	for _, question := range questions {
+		if ctx.Err() != nil {
+			break
+		}
+
+		chunks, err := search(ctx, question)
+		result := Score(question, chunks)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, Average(results)
+}
+
+// Score computes the metrics of one question. Relevance is binary: a retrieved chunk is
+// relevant when it, one of its expanded neighbours or its document is labelled. Recall is the
+// share of labels (chunks and documents) covered by the results.
+func Score(question domain.EvalQuestion, chunks []domain.ChunkWithHybridSimilarity) domain.EvalQuestionResult {
+	relevantChunks := toSet(question.RelevantChunkIDs)
+	relevantDocuments := toSet(question.RelevantDocumentIDs)
+	foundChunks := make(map[int]bool)
+	foundDocuments := make(map[int]bool)
+
+	result := domain.EvalQuestionResult{
+		QuestionID:        question.ID,
+		Question:          question.Question,
+		RetrievedChunkIDs: make([]int, 0, len(chunks)),
+		RelevantRanks:     []int{},
+	}
+
+	retrieved := make([]metrics.RetrievedChunk, len(chunks))
+	for i, chunk := range chunks {
+		relevant := false
+		for _, id := range append([]int{chunk.ID}, chunk.NeighborIDs...) {
+			if relevantChunks[id] {
+				foundChunks[id] = true
+				relevant = true
+			}
+		}
+		if relevantDocuments[chunk.DocumentID] {
+			foundDocuments[chunk.DocumentID] = true
+			relevant = true
+		}
+
+		result.RetrievedChunkIDs = append(result.RetrievedChunkIDs, chunk.ID)
+		if relevant {
+			result.RelevantRanks = append(result.RelevantRanks, i+1)
+		}
+		retrieved[i] = metrics.RetrievedChunk{
+			ChunkID:         chunk.ID,
+			SimilarityScore: 1, // Binary gain for NDCG
+			Position:        i + 1,
+			IsRelevant:      relevant,
+		}
+	}
+
+	calculator := metrics.NewRAGMetrics()
+	precision := calculator.CalculatePrecisionAtK(retrieved)
+	recall := 0.0
+	if labels := len(relevantChunks) + len(relevantDocuments); labels > 0 {
+		recall = float64(len(foundChunks)+len(foundDocuments)) / float64(labels)
+	}
+
+	result.Metrics = domain.EvalMetrics{
+		PrecisionAtK: precision,
+		RecallAtK:    recall,
+		F1AtK:        calculator.CalculateF1AtK(precision, recall),
+		MRR:          calculator.CalculateMRR(retrieved),
+		MAP:          calculator.CalculateMAP(retrieved),
+		NDCG:         calculator.CalculateNDCG(retrieved),
+	}
+	if len(result.RelevantRanks) > 0 {
+		result.Metrics.HitRate = 1
+	}
+	return result
+}
+
+// Average returns the mean of the per-question metrics
+func Average(results []domain.EvalQuestionResult) domain.EvalMetrics {
+	var mean domain.EvalMetrics
+	if len(results) == 0 {
+		return mean
+	}
+
+	for _, r := range results {
+		mean.PrecisionAtK += r.Metrics.PrecisionAtK
+		mean.RecallAtK += r.Metrics.RecallAtK
+		mean.F1AtK += r.Metrics.F1AtK
+		mean.MRR += r.Metrics.MRR
+		mean.MAP += r.Metrics.MAP
+		mean.NDCG += r.Metrics.NDCG
+		mean.HitRate += r.Metrics.HitRate
+	}
+
+	n := float64(len(results))
+	mean.PrecisionAtK /= n
+	mean.RecallAtK /= n
+	mean.F1AtK /= n
+	mean.MRR /= n
+	mean.MAP /= n
+	mean.NDCG /= n
+	mean.HitRate /= n
+	return mean
+}
+
+func toSet(ids []int) map[int]bool {
+	set := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,63 @@
+package evaluation
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"api-chatbot/domain"
+)
+
+// LoadJSONL reads a golden set file with one question per line, e.g.
+//
+//	{"question": "¿Cuándo son las matrículas?", "category": "DOC_INDTEC", "relevantChunkIds": [12, 13]}
+//	{"question": "Requisitos de la beca", "relevantDocumentIds": [4]}
+//
+// Blank lines and lines starting with # are skipped.
+func LoadJSONL(path string) ([]domain.EvalQuestion, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open golden set file: %w", err)
+	}
+	defer file.Close()
+
+	var questions []domain.EvalQuestion
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var question domain.EvalQuestion
+		if err := json.Unmarshal([]byte(text), &question); err != nil {
+			return nil, fmt.Errorf("invalid golden set line %d: %w", line, err)
+		}
+		if err := Validate(question); err != nil {
+			return nil, fmt.Errorf("invalid golden set line %d: %w", line, err)
+		}
+		questions = append(questions, question)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read golden set file: %w", err)
+	}
+
+	return questions, nil
+}
+
+// Validate checks that a question has text and at least one relevance label
+func Validate(question domain.EvalQuestion) error {
+	if strings.TrimSpace(question.Question) == "" {
+		return fmt.Errorf("question is empty")
+	}
+	if len(question.RelevantChunkIDs) == 0 && len(question.RelevantDocumentIDs) == 0 {
+		return fmt.Errorf("question %q has no relevant chunk or document ids", question.Question)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package guardrails
+
+import (
+	"context"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/llm"
+	"api-chatbot/internal/logger"
+)
+
+// Stage identifies where in the RAG flow a check runs
+type Stage string
+
+const (
+	// StageInput runs on the user message before retrieval
+	StageInput Stage = "input"
+	// StageOutput runs on the generated answer before it is sent
+	StageOutput Stage = "output"
+)
+
+// Action is what the pipeline does when a check triggers
+type Action string
+
+const (
+	ActionAllow    Action = "allow"
+	ActionRewrite  Action = "rewrite"
+	ActionBlock    Action = "block"
+	ActionEscalate Action = "escalate"
+)
+
+// Verdict is the result of a single check that triggered
+type Verdict struct {
+	Check     string
+	Rule      string
+	Action    Action
+	Reason    string
+	Rewritten string // Only used when Action is ActionRewrite
+}
+
+// Check evaluates a text at a given stage.
+// It returns a nil verdict when nothing triggered.
+type Check interface {
+	Name() string
+	Evaluate(ctx context.Context, stage Stage, text string) (*Verdict, error)
+}
+
+// Meta identifies where the guarded text comes from (used for event logging)
+type Meta struct {
+	Channel        string // whatsapp, api
+	ChatID         string
+	ConversationID int
+}
+
+// Outcome is the final decision of the pipeline for a text
+type Outcome struct {
+	Action   Action
+	Text     string // Text after rewrites (original text if nothing was rewritten)
+	Verdicts []Verdict
+}
+
+// Stopped returns true if the text must not continue through the RAG flow
+func (o Outcome) Stopped() bool {
+	return o.Action == ActionBlock || o.Action == ActionEscalate
+}
+
+// Pipeline runs a chain of checks. Rewrites are applied in order and passed to
+// the next check; the first block or escalate stops the chain.
+type Pipeline struct {
+	checks      []Check
+	paramCache  domain.ParameterCache
+	guardrailUC domain.GuardrailUseCase
+}
+
+// NewPipeline creates a pipeline with the given checks
+func NewPipeline(paramCache domain.ParameterCache, guardrailUC domain.GuardrailUseCase, checks ...Check) *Pipeline {
+	return &Pipeline{
+		checks:      checks,
+		paramCache:  paramCache,
+		guardrailUC: guardrailUC,
+	}
+}
+
+// NewDefaultPipeline creates the standard chain: parameter rules, PII, secrets, system prompt
+// leaks and LLM classifier.
+// All checks read their configuration from the parameter cache on every run (hot-reloadable).
+func NewDefaultPipeline(paramCache domain.ParameterCache, guardrailUC domain.GuardrailUseCase, llmProvider llm.Provider) *Pipeline {
+	return NewPipeline(paramCache, guardrailUC,
+		NewRuleCheck(paramCache),
+		NewPIICheck(paramCache),
+		NewSecretCheck(paramCache),
+		NewPromptLeakCheck(paramCache),
+		NewClassifierCheck(paramCache, llmProvider),
+	)
+}
+
+// Run evaluates the text through every check and logs each trigger
+func (p *Pipeline) Run(ctx context.Context, stage Stage, text string, meta Meta) Outcome {
+	outcome := Outcome{Action: ActionAllow, Text: text}
+
+	if p == nil || !isEnabled(p.paramCache) {
+		return outcome
+	}
+
+	for _, check := range p.checks {
+		verdict, err := check.Evaluate(ctx, stage, outcome.Text)
+		if err != nil {
+			logger.LogWarn(ctx, "Guardrail check failed, skipping",
+				"check", check.Name(),
+				"stage", stage,
+				"error", err.Error(),
+			)
+			continue
+		}
+		if verdict == nil {
+			continue
+		}
+
+		p.logEvent(ctx, stage, *verdict, outcome.Text, meta)
+		outcome.Verdicts = append(outcome.Verdicts, *verdict)
+
+		switch verdict.Action {
+		case ActionRewrite:
+			outcome.Text = verdict.Rewritten
+			outcome.Action = ActionRewrite
+		case ActionBlock, ActionEscalate:
+			outcome.Action = verdict.Action
+			return outcome
+		}
+	}
+
+	return outcome
+}
+
+// logEvent stores the trigger so admins can review it. Failures are only logged.
+func (p *Pipeline) logEvent(ctx context.Context, stage Stage, verdict Verdict, original string, meta Meta) {
+	logger.LogWarn(ctx, "Guardrail triggered",
+		"stage", stage,
+		"check", verdict.Check,
+		"rule", verdict.Rule,
+		"action", verdict.Action,
+		"reason", verdict.Reason,
+		"chatID", meta.ChatID,
+	)
+
+	if p.guardrailUC == nil {
+		return
+	}
+
+	params := domain.CreateGuardrailEventParams{
+		Stage:          string(stage),
+		Check:          verdict.Check,
+		Rule:           optionalString(verdict.Rule),
+		Action:         string(verdict.Action),
+		Reason:         optionalString(verdict.Reason),
+		Channel:        meta.Channel,
+		ChatID:         optionalString(meta.ChatID),
+		ConversationID: meta.ConversationID,
+		OriginalText:   original,
+	}
+	if verdict.Action == ActionRewrite {
+		params.RewrittenText = optionalString(verdict.Rewritten)
+	}
+
+	result := p.guardrailUC.LogEvent(ctx, params)
+	if !result.Success {
+		logger.LogWarn(ctx, "Failed to store guardrail event", "code", result.Code)
+	}
+}
+
+// =====================================================
+// Configuration helpers (GUARDRAILS_CONFIG parameter)
+// =====================================================
+
+// getConfig returns the GUARDRAILS_CONFIG parameter data
+func getConfig(paramCache domain.ParameterCache) map[string]any {
+	param, exists := paramCache.Get("GUARDRAILS_CONFIG")
+	if !exists {
+		return nil
+	}
+	data, err := param.GetDataAsMap()
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// isEnabled checks the global switch; the pipeline is disabled if the parameter is missing
+func isEnabled(paramCache domain.ParameterCache) bool {
+	data := getConfig(paramCache)
+	if data == nil {
+		return false
+	}
+	enabled, ok := data["enabled"].(bool)
+	return ok && enabled
+}
+
+// getSection returns a check's configuration section (e.g. "pii", "classifier")
+func getSection(paramCache domain.ParameterCache, name string) map[string]any {
+	data := getConfig(paramCache)
+	if data == nil {
+		return nil
+	}
+	section, _ := data[name].(map[string]any)
+	return section
+}
+
+// sectionApplies checks that a section is enabled and configured for the stage
+func sectionApplies(section map[string]any, stage Stage) bool {
+	if section == nil {
+		return false
+	}
+	if enabled, ok := section["enabled"].(bool); !ok || !enabled {
+		return false
+	}
+	return stageListed(section["stages"], stage)
+}
+
+// stageListed checks a JSON array of stage names; a missing list means both stages
+func stageListed(raw any, stage Stage) bool {
+	stages, ok := raw.([]any)
+	if !ok || len(stages) == 0 {
+		return true
+	}
+	for _, s := range stages {
+		if name, ok := s.(string); ok && Stage(name) == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAction converts a configured action name, falling back to the default
+func parseAction(raw any, defaultAction Action) Action {
+	name, _ := raw.(string)
+	switch Action(name) {
+	case ActionRewrite, ActionBlock, ActionEscalate:
+		return Action(name)
+	default:
+		return defaultAction
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
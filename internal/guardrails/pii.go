@@ -0,0 +1,136 @@
+package guardrails
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"api-chatbot/domain"
+)
+
+// detector masks one kind of sensitive data
+type detector struct {
+	kind        string
+	pattern     *regexp.Regexp
+	replacement string
+	validate    func(match string) bool // optional extra validation (e.g. Luhn)
+}
+
+var piiDetectors = []detector{
+	{kind: "email", pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`), replacement: "[CORREO]"},
+	{kind: "card", pattern: regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`), replacement: "[TARJETA]", validate: luhnValid},
+	{kind: "phone", pattern: regexp.MustCompile(`(?:\+?593[ \-]?|\b0)9\d{1}[ \-]?\d{3}[ \-]?\d{4}\b`), replacement: "[TELEFONO]"},
+	{kind: "id_number", pattern: regexp.MustCompile(`\b[0-2]\d{9}(?:001)?\b`), replacement: "[IDENTIFICACION]"},
+}
+
+var secretDetectors = []detector{
+	{kind: "api_key", pattern: regexp.MustCompile(`\b(?:sk|gsk|xai|pk)[-_][A-Za-z0-9_\-]{20,}\b`), replacement: "[SECRETO]"},
+	{kind: "aws_key", pattern: regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), replacement: "[SECRETO]"},
+	{kind: "jwt", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\b`), replacement: "[SECRETO]"},
+	{kind: "password", pattern: regexp.MustCompile(`(?i)\b(password|contraseña|contrasena|clave|pwd)\s*[:=]\s*\S+`), replacement: "$1: [SECRETO]"},
+}
+
+// PIICheck detects personal data (emails, phones, ID numbers, cards).
+// Configured by the "pii" section of GUARDRAILS_CONFIG.
+type PIICheck struct {
+	paramCache domain.ParameterCache
+}
+
+// NewPIICheck creates a PII detection check
+func NewPIICheck(paramCache domain.ParameterCache) *PIICheck {
+	return &PIICheck{paramCache: paramCache}
+}
+
+func (c *PIICheck) Name() string {
+	return "pii"
+}
+
+func (c *PIICheck) Evaluate(ctx context.Context, stage Stage, text string) (*Verdict, error) {
+	section := getSection(c.paramCache, "pii")
+	if !sectionApplies(section, stage) {
+		return nil, nil
+	}
+	return detect(c.Name(), piiDetectors, text, parseAction(section["action"], ActionRewrite)), nil
+}
+
+// SecretCheck detects credentials (API keys, tokens, passwords).
+// Configured by the "secrets" section of GUARDRAILS_CONFIG.
+type SecretCheck struct {
+	paramCache domain.ParameterCache
+}
+
+// NewSecretCheck creates a secret detection check
+func NewSecretCheck(paramCache domain.ParameterCache) *SecretCheck {
+	return &SecretCheck{paramCache: paramCache}
+}
+
+func (c *SecretCheck) Name() string {
+	return "secrets"
+}
+
+func (c *SecretCheck) Evaluate(ctx context.Context, stage Stage, text string) (*Verdict, error) {
+	section := getSection(c.paramCache, "secrets")
+	if !sectionApplies(section, stage) {
+		return nil, nil
+	}
+	return detect(c.Name(), secretDetectors, text, parseAction(section["action"], ActionRewrite)), nil
+}
+
+// detect runs the detectors and builds a verdict with the masked text
+func detect(check string, detectors []detector, text string, action Action) *Verdict {
+	var kinds []string
+	masked := text
+
+	for _, det := range detectors {
+		found := false
+		masked = det.pattern.ReplaceAllStringFunc(masked, func(match string) string {
+			if det.validate != nil && !det.validate(match) {
+				return match
+			}
+			found = true
+			return det.pattern.ReplaceAllString(match, det.replacement)
+		})
+		if found {
+			kinds = append(kinds, det.kind)
+		}
+	}
+
+	if len(kinds) == 0 {
+		return nil
+	}
+
+	verdict := &Verdict{
+		Check:  check,
+		Rule:   strings.Join(kinds, ","),
+		Action: action,
+		Reason: "detected " + strings.Join(kinds, ", "),
+	}
+	if action == ActionRewrite {
+		verdict.Rewritten = masked
+	}
+	return verdict
+}
+
+// luhnValid checks a card number candidate with the Luhn algorithm
+func luhnValid(candidate string) bool {
+	sum := 0
+	digits := 0
+	double := false
+	for i := len(candidate) - 1; i >= 0; i-- {
+		ch := candidate[i]
+		if ch < '0' || ch > '9' {
+			continue
+		}
+		n := int(ch - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		digits++
+		double = !double
+	}
+	return digits >= 13 && sum%10 == 0
+}
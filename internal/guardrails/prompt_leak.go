@@ -0,0 +1,115 @@
+package guardrails
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"api-chatbot/domain"
+)
+
+const (
+	defaultLeakNGram      = 8   // Words per sequence compared with the prompt
+	defaultLeakMinOverlap = 0.2 // Share of the prompt's sequences found in the answer
+	defaultLeakMinMatches = 10  // Sequences found in the answer, for long prompts leaked in part
+)
+
+type systemPromptKey struct{}
+
+// WithSystemPrompt returns a context carrying the system prompt the answer was generated
+// with, compared by the prompt leak check on the output stage
+func WithSystemPrompt(ctx context.Context, prompt string) context.Context {
+	return context.WithValue(ctx, systemPromptKey{}, prompt)
+}
+
+// PromptLeakCheck detects answers that reproduce the system prompt, by the word sequences
+// (n-grams) they share with it. The prompt is the one set with WithSystemPrompt, else
+// RAG_SYSTEM_PROMPT. Configured by the "promptLeak" section of GUARDRAILS_CONFIG:
+//
+//	{"enabled": true, "stages": ["output"], "action": "block", "ngram": 8, "minOverlap": 0.2, "minMatches": 10}
+type PromptLeakCheck struct {
+	paramCache domain.ParameterCache
+}
+
+// NewPromptLeakCheck creates a system prompt leak check
+func NewPromptLeakCheck(paramCache domain.ParameterCache) *PromptLeakCheck {
+	return &PromptLeakCheck{paramCache: paramCache}
+}
+
+func (c *PromptLeakCheck) Name() string {
+	return "prompt_leak"
+}
+
+func (c *PromptLeakCheck) Evaluate(ctx context.Context, stage Stage, text string) (*Verdict, error) {
+	section := getSection(c.paramCache, "promptLeak")
+	if !sectionApplies(section, stage) {
+		return nil, nil
+	}
+
+	prompt, _ := ctx.Value(systemPromptKey{}).(string)
+	if prompt == "" {
+		prompt = c.defaultPrompt()
+	}
+
+	size := defaultLeakNGram
+	if val, ok := section["ngram"].(float64); ok && val >= 3 {
+		size = int(val)
+	}
+	minOverlap := defaultLeakMinOverlap
+	if val, ok := section["minOverlap"].(float64); ok && val > 0 {
+		minOverlap = val
+	}
+	minMatches := defaultLeakMinMatches
+	if val, ok := section["minMatches"].(float64); ok && val > 0 {
+		minMatches = int(val)
+	}
+
+	promptGrams := ngrams(prompt, size)
+	if len(promptGrams) == 0 {
+		return nil, nil
+	}
+	matched := 0
+	for gram := range ngrams(text, size) {
+		if promptGrams[gram] {
+			matched++
+		}
+	}
+
+	overlap := float64(matched) / float64(len(promptGrams))
+	if matched == 0 || (overlap < minOverlap && matched < minMatches) {
+		return nil, nil
+	}
+	return &Verdict{
+		Check:  c.Name(),
+		Action: parseAction(section["action"], ActionBlock),
+		Reason: fmt.Sprintf("answer shares %d of %d %d-word sequences with the system prompt", matched, len(promptGrams), size),
+	}, nil
+}
+
+// defaultPrompt returns the general system prompt (RAG_SYSTEM_PROMPT)
+func (c *PromptLeakCheck) defaultPrompt() string {
+	param, exists := c.paramCache.Get("RAG_SYSTEM_PROMPT")
+	if !exists {
+		return ""
+	}
+	data, err := param.GetDataAsMap()
+	if err != nil {
+		return ""
+	}
+	prompt, _ := data["message"].(string)
+	return prompt
+}
+
+// ngrams returns the distinct sequences of size words of a text, lowercased and without
+// punctuation so formatting changes do not hide a copy
+func ngrams(text string, size int) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	grams := make(map[string]bool)
+	for i := 0; i+size <= len(words); i++ {
+		grams[strings.Join(words[i:i+size], " ")] = true
+	}
+	return grams
+}
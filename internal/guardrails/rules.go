@@ -0,0 +1,205 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/logger"
+)
+
+// RuleCheck applies regex and keyword rules from the GUARDRAILS_RULES parameter.
+//
+// Parameter format:
+//
+//	{"rules": [{"name": "...", "type": "regex|keyword", "pattern": "...", "keywords": ["..."],
+//	            "stages": ["input", "output"], "action": "block|rewrite|escalate", "replacement": "***"}]}
+//
+// Patterns are compiled once per version of the parameter; a rule with an invalid
+// pattern is logged and skipped, the others keep applying.
+type RuleCheck struct {
+	paramCache domain.ParameterCache
+	mu         sync.Mutex
+	version    string // Parameter data the rules were compiled from
+	rules      []rule
+}
+
+// rule is a GUARDRAILS_RULES entry with its patterns compiled
+type rule struct {
+	name        string
+	ruleType    string
+	stages      any
+	action      Action
+	replacement string
+	pattern     *regexp.Regexp   // regex rules
+	keywords    []*regexp.Regexp // keyword rules, one case-insensitive pattern per keyword
+}
+
+// NewRuleCheck creates a parameter-driven rule check
+func NewRuleCheck(paramCache domain.ParameterCache) *RuleCheck {
+	return &RuleCheck{paramCache: paramCache}
+}
+
+func (c *RuleCheck) Name() string {
+	return "rules"
+}
+
+func (c *RuleCheck) Evaluate(ctx context.Context, stage Stage, text string) (*Verdict, error) {
+	param, exists := c.paramCache.Get("GUARDRAILS_RULES")
+	if !exists {
+		return nil, nil
+	}
+	rules, err := c.load(ctx, param.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	current := text
+	var rewritten *Verdict
+
+	for _, rule := range rules {
+		if !stageListed(rule.stages, stage) {
+			continue
+		}
+
+		var matched bool
+		var result string
+
+		switch rule.ruleType {
+		case "regex":
+			if rule.pattern == nil || !rule.pattern.MatchString(current) {
+				continue
+			}
+			matched = true
+			result = rule.pattern.ReplaceAllString(current, rule.replacement)
+		case "keyword":
+			result, matched = replaceKeywords(current, rule.keywords, rule.replacement)
+		}
+
+		if !matched {
+			continue
+		}
+
+		if rule.action != ActionRewrite {
+			return &Verdict{
+				Check:  c.Name(),
+				Rule:   rule.name,
+				Action: rule.action,
+				Reason: fmt.Sprintf("matched %s rule", rule.ruleType),
+			}, nil
+		}
+
+		// Keep applying rewrite rules; a later block/escalate still wins
+		current = result
+		if rewritten == nil {
+			rewritten = &Verdict{Check: c.Name(), Rule: rule.name, Action: ActionRewrite, Reason: fmt.Sprintf("matched %s rule", rule.ruleType)}
+		} else {
+			rewritten.Rule += "," + rule.name
+		}
+	}
+
+	if rewritten != nil {
+		rewritten.Rewritten = current
+	}
+	return rewritten, nil
+}
+
+// load returns the compiled rules, compiling them again only when the parameter changed.
+// Invalid rules are logged once per version and left out.
+func (c *RuleCheck) load(ctx context.Context, data json.RawMessage) ([]rule, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rules != nil && c.version == string(data) {
+		return c.rules, nil
+	}
+
+	rules, ruleErrs, err := parseRules(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, ruleErr := range ruleErrs {
+		logger.LogWarn(ctx, "Invalid guardrail rule, skipping",
+			"check", c.Name(),
+			"error", ruleErr.Error(),
+		)
+	}
+
+	c.version = string(data)
+	c.rules = rules
+	return rules, nil
+}
+
+// ValidateRules checks the GUARDRAILS_RULES data before it is saved; the error lists
+// every rule that would be skipped
+func ValidateRules(data json.RawMessage) error {
+	_, ruleErrs, err := parseRules(data)
+	if err != nil {
+		return err
+	}
+	return errors.Join(ruleErrs...)
+}
+
+// parseRules compiles the rules of the parameter data. It returns the valid rules and
+// one error per invalid rule; err is only set when the data itself cannot be read.
+func parseRules(data json.RawMessage) (rules []rule, ruleErrs []error, err error) {
+	var config struct {
+		Rules []map[string]any `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse GUARDRAILS_RULES: %w", err)
+	}
+
+	rules = make([]rule, 0, len(config.Rules))
+	for _, raw := range config.Rules {
+		r := rule{stages: raw["stages"], action: parseAction(raw["action"], ActionBlock)}
+		r.name, _ = raw["name"].(string)
+		r.ruleType, _ = raw["type"].(string)
+		replacement, ok := raw["replacement"].(string)
+		if !ok {
+			replacement = "***"
+		}
+		r.replacement = replacement
+
+		switch r.ruleType {
+		case "regex":
+			pattern, _ := raw["pattern"].(string)
+			if pattern != "" {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					ruleErrs = append(ruleErrs, fmt.Errorf("invalid pattern in rule %q: %w", r.name, err))
+					continue
+				}
+				r.pattern = re
+			}
+		case "keyword":
+			keywords, _ := raw["keywords"].([]any)
+			for _, rawKeyword := range keywords {
+				keyword, ok := rawKeyword.(string)
+				if !ok || keyword == "" {
+					continue
+				}
+				r.keywords = append(r.keywords, regexp.MustCompile("(?i)"+regexp.QuoteMeta(keyword)))
+			}
+		}
+
+		rules = append(rules, r)
+	}
+	return rules, ruleErrs, nil
+}
+
+// replaceKeywords replaces every case-insensitive occurrence of the keywords
+func replaceKeywords(text string, keywords []*regexp.Regexp, replacement string) (string, bool) {
+	matched := false
+	for _, re := range keywords {
+		if re.MatchString(text) {
+			matched = true
+			text = re.ReplaceAllString(text, replacement)
+		}
+	}
+	return text, matched
+}
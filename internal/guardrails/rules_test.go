@@ -0,0 +1,68 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/cache"
+)
+
+const rulesWithInvalidPattern = `{"rules": [
+	{"name": "broken", "type": "regex", "pattern": "([a-z", "action": "block"},
+	{"name": "cedula", "type": "regex", "pattern": "\\d{10}", "action": "rewrite", "replacement": "[cédula]"},
+	{"name": "insultos", "type": "keyword", "keywords": ["Tonto"], "action": "block"}
+]}`
+
+func newRuleCheck(rules string) *RuleCheck {
+	paramCache := cache.NewParameterCache()
+	paramCache.Set("GUARDRAILS_RULES", &domain.Parameter{Code: "GUARDRAILS_RULES", Data: json.RawMessage(rules)})
+	return NewRuleCheck(paramCache)
+}
+
+func TestRuleCheckSkipsInvalidRule(t *testing.T) {
+	check := newRuleCheck(rulesWithInvalidPattern)
+
+	verdict, err := check.Evaluate(context.Background(), StageInput, "mi cédula es 0912345678")
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if verdict == nil || verdict.Action != ActionRewrite || verdict.Rewritten != "mi cédula es [cédula]" {
+		t.Fatalf("verdict = %+v, want the cedula rewrite", verdict)
+	}
+
+	verdict, err = check.Evaluate(context.Background(), StageInput, "eres un TONTO")
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if verdict == nil || verdict.Action != ActionBlock || verdict.Rule != "insultos" {
+		t.Fatalf("verdict = %+v, want the keyword block", verdict)
+	}
+}
+
+func TestRuleCheckRecompilesOnChange(t *testing.T) {
+	check := newRuleCheck(`{"rules": [{"name": "a", "type": "keyword", "keywords": ["uno"]}]}`)
+	if verdict, _ := check.Evaluate(context.Background(), StageInput, "dos"); verdict != nil {
+		t.Fatalf("verdict = %+v, want none", verdict)
+	}
+
+	check.paramCache.Set("GUARDRAILS_RULES", &domain.Parameter{
+		Code: "GUARDRAILS_RULES",
+		Data: json.RawMessage(`{"rules": [{"name": "b", "type": "keyword", "keywords": ["dos"]}]}`),
+	})
+	if verdict, _ := check.Evaluate(context.Background(), StageInput, "dos"); verdict == nil || verdict.Rule != "b" {
+		t.Fatalf("verdict = %+v, want rule b", verdict)
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	err := ValidateRules(json.RawMessage(rulesWithInvalidPattern))
+	if err == nil || !strings.Contains(err.Error(), `"broken"`) {
+		t.Fatalf("ValidateRules error = %v, want the broken rule", err)
+	}
+	if err := ValidateRules(json.RawMessage(`{"rules": [{"name": "ok", "type": "regex", "pattern": "\\d+"}]}`)); err != nil {
+		t.Fatalf("ValidateRules error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,234 @@
+package handoff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/llm"
+)
+
+// Handoff reasons, stored on the conversation
+const (
+	ReasonNoResults = "no_results" // Hybrid search returned nothing
+	ReasonFewHits   = "few_hits"   // Fewer hits than minHits
+	ReasonLowScore  = "low_score"  // Best combined score below minBestScore
+	ReasonSelfCheck = "self_check" // The LLM judged its answer unsupported by the context
+	ReasonGuardrail = "guardrail"  // A guardrail rule escalated the message
+)
+
+const (
+	defaultMinBestScore       = 0.3
+	defaultMinHits            = 1
+	defaultSelfCheckMaxTokens = 20
+)
+
+const defaultSelfCheckPrompt = `Eres un revisor de respuestas de un asistente virtual de un instituto educativo.
+Recibirás la pregunta de un estudiante, la respuesta del asistente y, como contexto, los fragmentos de documentos con los que se generó.
+Indica si la respuesta está respaldada por el contexto y responde realmente la pregunta, sin inventar datos.
+Responde ÚNICAMENTE con JSON en el formato: {"supported": true} o {"supported": false}`
+
+// Decision is the outcome of a confidence check
+type Decision struct {
+	Handoff   bool
+	Reason    string
+	BestScore float64 // Best combined score of the hits
+	Hits      int
+}
+
+// Contact is an admin notified of handoffs
+type Contact struct {
+	Name     string
+	WhatsApp string // Phone number, digits only
+}
+
+// Policy decides when the bot is not confident enough to answer and the conversation
+// is handed to an admin. Configured by HANDOFF_CONFIG, read on every message:
+//
+//	{"enabled": true, "minBestScore": 0.3, "minHits": 1,
+//	 "selfCheck": {"enabled": false, "prompt": "...", "model": "..."},
+//	 "onDuty": [{"name": "Secretaría", "whatsapp": "593991234567", "days": [1, 2, 3, 4, 5], "from": "08:00", "to": "17:00"}]}
+//
+// minBestScore is compared with the combined score, the weighted semantic/keyword score in
+// both fusion modes (RRF only sets the fusion score the results are ordered by), so switching
+// to RRF does not call for a lower threshold. Days follow time.Weekday (0 = Sunday);
+// an on-duty entry without days or hours is always on duty.
+type Policy struct {
+	paramCache  domain.ParameterCache
+	llmProvider llm.Provider
+}
+
+// NewPolicy creates a confidence policy; the LLM provider is only used by the self-check
+func NewPolicy(paramCache domain.ParameterCache, llmProvider llm.Provider) *Policy {
+	return &Policy{
+		paramCache:  paramCache,
+		llmProvider: llmProvider,
+	}
+}
+
+// Enabled reports whether low-confidence handoff is configured
+func (p *Policy) Enabled() bool {
+	if p == nil {
+		return false
+	}
+	enabled, _ := p.config()["enabled"].(bool)
+	return enabled
+}
+
+// CheckRetrieval decides from the search results whether the question can be answered
+func (p *Policy) CheckRetrieval(chunks []domain.ChunkWithHybridSimilarity) Decision {
+	decision := Decision{Hits: len(chunks)}
+	for i, chunk := range chunks {
+		if i == 0 || chunk.CombinedScore > decision.BestScore {
+			decision.BestScore = chunk.CombinedScore
+		}
+	}
+	if !p.Enabled() {
+		return decision
+	}
+
+	config := p.config()
+	minHits := defaultMinHits
+	if value, ok := config["minHits"].(float64); ok && value >= 0 {
+		minHits = int(value)
+	}
+	minBestScore := defaultMinBestScore
+	if value, ok := config["minBestScore"].(float64); ok && value >= 0 {
+		minBestScore = value
+	}
+
+	switch {
+	case len(chunks) == 0:
+		decision.Handoff, decision.Reason = true, ReasonNoResults
+	case len(chunks) < minHits:
+		decision.Handoff, decision.Reason = true, ReasonFewHits
+	case decision.BestScore < minBestScore:
+		decision.Handoff, decision.Reason = true, ReasonLowScore
+	}
+	return decision
+}
+
+// CheckAnswer runs the optional LLM self-check of a generated answer against its context.
+// A failed self-check call is not fatal: the answer is kept.
+func (p *Policy) CheckAnswer(ctx context.Context, query, ragContext, answer string) (Decision, error) {
+	if !p.Enabled() {
+		return Decision{}, nil
+	}
+	section, _ := p.config()["selfCheck"].(map[string]any)
+	if enabled, _ := section["enabled"].(bool); !enabled {
+		return Decision{}, nil
+	}
+	if p.llmProvider == nil || !p.llmProvider.IsAvailable() {
+		return Decision{}, fmt.Errorf("LLM provider not available")
+	}
+
+	prompt, ok := section["prompt"].(string)
+	if !ok || prompt == "" {
+		prompt = defaultSelfCheckPrompt
+	}
+	model, _ := section["model"].(string)
+
+	response, err := p.llmProvider.GenerateResponse(ctx, llm.GenerateRequest{
+		SystemPrompt: prompt,
+		UserMessage:  fmt.Sprintf("Pregunta: %s\n\nRespuesta del asistente: %s", query, answer),
+		Context:      ragContext,
+		Temperature:  llm.Temperature(0),
+		MaxTokens:    defaultSelfCheckMaxTokens,
+		Model:        model,
+	})
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to run answer self-check: %w", err)
+	}
+
+	start := strings.Index(response.Content, "{")
+	end := strings.LastIndex(response.Content, "}")
+	if start < 0 || end <= start {
+		return Decision{}, fmt.Errorf("self-check returned no JSON: %q", response.Content)
+	}
+	var result struct {
+		Supported *bool `json:"supported"`
+	}
+	if err := json.Unmarshal([]byte(response.Content[start:end+1]), &result); err != nil || result.Supported == nil {
+		return Decision{}, fmt.Errorf("failed to parse self-check result: %q", response.Content)
+	}
+
+	if !*result.Supported {
+		return Decision{Handoff: true, Reason: ReasonSelfCheck}, nil
+	}
+	return Decision{}, nil
+}
+
+// OnDuty returns the admins on duty at the given time
+func (p *Policy) OnDuty(now time.Time) []Contact {
+	if p == nil {
+		return nil
+	}
+	entries, _ := p.config()["onDuty"].([]any)
+
+	var contacts []Contact
+	for _, item := range entries {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		whatsapp, _ := entry["whatsapp"].(string)
+		whatsapp = strings.TrimPrefix(strings.TrimSpace(whatsapp), "+")
+		if whatsapp == "" || !onDutyAt(entry, now) {
+			continue
+		}
+		name, _ := entry["name"].(string)
+		contacts = append(contacts, Contact{Name: name, WhatsApp: whatsapp})
+	}
+	return contacts
+}
+
+// onDutyAt checks the entry's days and hours (HH:MM, "to" exclusive; a range past midnight wraps)
+func onDutyAt(entry map[string]any, now time.Time) bool {
+	if days, ok := entry["days"].([]any); ok && len(days) > 0 {
+		matched := false
+		for _, day := range days {
+			if value, ok := day.(float64); ok && time.Weekday(int(value)) == now.Weekday() {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	from, hasFrom := parseClock(entry["from"])
+	to, hasTo := parseClock(entry["to"])
+	if !hasFrom || !hasTo {
+		return true
+	}
+	current := now.Hour()*60 + now.Minute()
+	if from <= to {
+		return current >= from && current < to
+	}
+	return current >= from || current < to
+}
+
+// parseClock parses HH:MM into minutes since midnight
+func parseClock(value any) (int, bool) {
+	text, ok := value.(string)
+	if !ok || text == "" {
+		return 0, false
+	}
+	clock, err := time.Parse("15:04", text)
+	if err != nil {
+		return 0, false
+	}
+	return clock.Hour()*60 + clock.Minute(), true
+}
+
+func (p *Policy) config() map[string]any {
+	data, exists := p.paramCache.GetValue("HANDOFF_CONFIG")
+	if !exists {
+		return nil
+	}
+	return data
+}
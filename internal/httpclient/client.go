@@ -95,8 +95,7 @@ func (c *httpClient) Do(ctx context.Context, req domain.HTTPRequest, result any)
 	// Check for HTTP errors
 	if response.StatusCode < 200 || response.StatusCode >= 300 {
 		errBody, _ := io.ReadAll(response.Body)
-		err = fmt.Errorf("Error in external service: status %d. Message: %s", response.StatusCode, string(errBody))
-		return err
+		return &domain.HTTPStatusError{StatusCode: response.StatusCode, Body: string(errBody)}
 	}
 
 	// Decode the response body
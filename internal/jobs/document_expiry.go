@@ -0,0 +1,148 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/logger"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+const defaultExpiryIntervalMinutes = 60
+
+// defaultExpiryNotification is used without DOCUMENT_EXPIRY_NOTIFICATION; {count} and
+// {documents} are replaced with the number and the list of expired documents
+const defaultExpiryNotification = "📅 Se desactivaron {count} documentos vencidos:\n{documents}"
+
+// Notifier sends a WhatsApp text message
+type Notifier interface {
+	SendText(chatID, text string) error
+}
+
+// DocumentExpiry periodically deactivates the documents whose validity has ended and tells
+// the admins about them. Configured by DOCUMENT_EXPIRY_CONFIG, read before every run:
+//
+//	{"enabled": true, "intervalMinutes": 60, "notify": ["593991234567"]}
+//
+// Search already excludes expired documents; deactivating them keeps the document list honest.
+type DocumentExpiry struct {
+	docUseCase domain.DocumentUseCase
+	paramCache domain.ParameterCache
+	notifier   Notifier
+}
+
+// NewDocumentExpiry creates the expiry job; the notifier may be nil to skip notifications
+func NewDocumentExpiry(docUseCase domain.DocumentUseCase, paramCache domain.ParameterCache, notifier Notifier) *DocumentExpiry {
+	return &DocumentExpiry{
+		docUseCase: docUseCase,
+		paramCache: paramCache,
+		notifier:   notifier,
+	}
+}
+
+// Run expires documents every intervalMinutes until the context is cancelled
+func (j *DocumentExpiry) Run(ctx context.Context) {
+	for {
+		if j.enabled() {
+			j.RunOnce(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(j.interval()):
+		}
+	}
+}
+
+// RunOnce deactivates the expired documents and notifies the configured admins
+func (j *DocumentExpiry) RunOnce(ctx context.Context) {
+	result := j.docUseCase.ExpireDocuments(ctx)
+	if !result.Success {
+		logger.LogWarn(ctx, "Document expiry run failed",
+			"operation", "DocumentExpiry",
+			"code", result.Code,
+		)
+		return
+	}
+	if len(result.Data) == 0 {
+		return
+	}
+
+	logger.LogInfo(ctx, "Expired documents deactivated",
+		"operation", "DocumentExpiry",
+		"count", len(result.Data),
+	)
+	j.notify(ctx, result.Data)
+}
+
+// notify sends the list of expired documents to DOCUMENT_EXPIRY_CONFIG.notify
+func (j *DocumentExpiry) notify(ctx context.Context, expired []domain.ExpiredDocument) {
+	numbers := j.notifyNumbers()
+	if j.notifier == nil || len(numbers) == 0 {
+		return
+	}
+
+	lines := make([]string, len(expired))
+	for i, doc := range expired {
+		lines[i] = fmt.Sprintf("- %s (%s, %s)", doc.Title, doc.Category, doc.ValidUntil)
+	}
+	template := defaultExpiryNotification
+	if data, exists := j.paramCache.GetValue("DOCUMENT_EXPIRY_NOTIFICATION"); exists {
+		if msg, ok := data["message"].(string); ok && msg != "" {
+			template = msg
+		}
+	}
+	notification := strings.NewReplacer(
+		"{count}", strconv.Itoa(len(expired)),
+		"{documents}", strings.Join(lines, "\n"),
+	).Replace(template)
+
+	for _, number := range numbers {
+		jid := types.NewJID(number, types.DefaultUserServer).String()
+		if err := j.notifier.SendText(jid, notification); err != nil {
+			logger.LogWarn(ctx, "Failed to notify admin of expired documents",
+				"operation", "DocumentExpiry",
+				"whatsapp", number,
+				"error", err.Error(),
+			)
+		}
+	}
+}
+
+func (j *DocumentExpiry) enabled() bool {
+	enabled, ok := j.config()["enabled"].(bool)
+	return !ok || enabled
+}
+
+func (j *DocumentExpiry) interval() time.Duration {
+	minutes, ok := j.config()["intervalMinutes"].(float64)
+	if !ok || minutes <= 0 {
+		minutes = defaultExpiryIntervalMinutes
+	}
+	return time.Duration(minutes * float64(time.Minute))
+}
+
+func (j *DocumentExpiry) notifyNumbers() []string {
+	raw, _ := j.config()["notify"].([]any)
+	numbers := make([]string, 0, len(raw))
+	for _, item := range raw {
+		if number, ok := item.(string); ok && number != "" {
+			numbers = append(numbers, number)
+		}
+	}
+	return numbers
+}
+
+func (j *DocumentExpiry) config() map[string]any {
+	data, exists := j.paramCache.GetValue("DOCUMENT_EXPIRY_CONFIG")
+	if !exists {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/cache"
+)
+
+type fakeDocuments struct {
+	domain.DocumentUseCase
+	expired []domain.ExpiredDocument
+}
+
+func (f fakeDocuments) ExpireDocuments(ctx context.Context) domain.Result[[]domain.ExpiredDocument] {
+	return domain.Success(f.expired)
+}
+
+type sentText struct {
+	chatID string
+	text   string
+}
+
+type fakeNotifier struct {
+	sent []sentText
+}
+
+func (n *fakeNotifier) SendText(chatID, text string) error {
+	n.sent = append(n.sent, sentText{chatID: chatID, text: text})
+	return nil
+}
+
+func TestDocumentExpiryNotification(t *testing.T) {
+	expired := []domain.ExpiredDocument{
+		{ID: 1, Title: "Calendario 2025", Category: "DOC_INDTEC", ValidUntil: "2025-12-31 23:59"},
+		{ID: 2, Title: "Becas 100%", Category: "DOC_BECAS", ValidUntil: "2026-01-15 00:00"},
+	}
+	list := "- Calendario 2025 (DOC_INDTEC, 2025-12-31 23:59)\n- Becas 100% (DOC_BECAS, 2026-01-15 00:00)"
+
+	tests := []struct {
+		name    string
+		message string // DOCUMENT_EXPIRY_NOTIFICATION message, empty for none
+		want    string
+	}{
+		{"default message", "", "📅 Se desactivaron 2 documentos vencidos:\n" + list},
+		{"placeholders in any order", "Vencidos:\n{documents}\nTotal: {count}", "Vencidos:\n" + list + "\nTotal: 2"},
+		{"format verbs are literal", "100% revisado: %d %s {count}", "100% revisado: %d %s 2"},
+		{"no placeholders", "Hay documentos vencidos", "Hay documentos vencidos"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paramCache := cache.NewParameterCache()
+			paramCache.Set("DOCUMENT_EXPIRY_CONFIG", &domain.Parameter{
+				Code: "DOCUMENT_EXPIRY_CONFIG",
+				Data: json.RawMessage(`{"enabled": true, "notify": ["593991234567"]}`),
+			})
+			if tt.message != "" {
+				data, _ := json.Marshal(map[string]string{"message": tt.message})
+				paramCache.Set("DOCUMENT_EXPIRY_NOTIFICATION", &domain.Parameter{Code: "DOCUMENT_EXPIRY_NOTIFICATION", Data: data})
+			}
+			notifier := &fakeNotifier{}
+
+			NewDocumentExpiry(fakeDocuments{expired: expired}, paramCache, notifier).RunOnce(context.Background())
+
+			if len(notifier.sent) != 1 {
+				t.Fatalf("sent %d notifications, want 1", len(notifier.sent))
+			}
+			if notifier.sent[0].chatID != "593991234567@s.whatsapp.net" {
+				t.Errorf("chatID = %q", notifier.sent[0].chatID)
+			}
+			if notifier.sent[0].text != tt.want {
+				t.Errorf("text = %q, want %q", notifier.sent[0].text, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/logger"
+)
+
+const defaultKnowledgeGapIntervalHours = 168
+
+// KnowledgeGapAnalysis periodically clusters the user questions of the last period and stores
+// the run as the latest gaps to document. Configured by KNOWLEDGE_GAP_CONFIG.schedule, read
+// before every run:
+//
+//	{"schedule": {"enabled": true, "period": "week", "intervalHours": 168}}
+type KnowledgeGapAnalysis struct {
+	knowledgeGapUseCase domain.KnowledgeGapUseCase
+	paramCache          domain.ParameterCache
+}
+
+// NewKnowledgeGapAnalysis creates the knowledge-gap analysis job
+func NewKnowledgeGapAnalysis(knowledgeGapUseCase domain.KnowledgeGapUseCase, paramCache domain.ParameterCache) *KnowledgeGapAnalysis {
+	return &KnowledgeGapAnalysis{
+		knowledgeGapUseCase: knowledgeGapUseCase,
+		paramCache:          paramCache,
+	}
+}
+
+// Run analyses the last period every intervalHours until the context is cancelled.
+// The first run waits a full interval so restarts do not trigger an analysis.
+func (j *KnowledgeGapAnalysis) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(j.interval()):
+		}
+
+		if j.enabled() {
+			j.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce clusters the questions of the last period and stores the run
+func (j *KnowledgeGapAnalysis) RunOnce(ctx context.Context) {
+	period, _ := j.schedule()["period"].(string)
+	result := j.knowledgeGapUseCase.Analyze(ctx, domain.AnalyzeKnowledgeGapsParams{
+		Period: period,
+		Save:   true,
+	})
+	if !result.Success {
+		logger.LogWarn(ctx, "Knowledge gap analysis run failed",
+			"operation", "KnowledgeGapAnalysis",
+			"code", result.Code,
+		)
+		return
+	}
+
+	logger.LogInfo(ctx, "Knowledge gap analysis stored",
+		"operation", "KnowledgeGapAnalysis",
+		"runID", result.Data.ID,
+		"clusters", result.Data.ClusterCount,
+	)
+}
+
+func (j *KnowledgeGapAnalysis) enabled() bool {
+	enabled, _ := j.schedule()["enabled"].(bool)
+	return enabled
+}
+
+func (j *KnowledgeGapAnalysis) interval() time.Duration {
+	hours, ok := j.schedule()["intervalHours"].(float64)
+	if !ok || hours <= 0 {
+		hours = defaultKnowledgeGapIntervalHours
+	}
+	return time.Duration(hours * float64(time.Hour))
+}
+
+func (j *KnowledgeGapAnalysis) schedule() map[string]any {
+	data, exists := j.paramCache.GetValue("KNOWLEDGE_GAP_CONFIG")
+	if !exists {
+		return nil
+	}
+	schedule, _ := data["schedule"].(map[string]any)
+	return schedule
+}
@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/logger"
+)
+
+const defaultWebRecrawlCheckMinutes = 60
+
+// WebRecrawl periodically crawls the web sources whose re-crawl interval (recrawlHours) is
+// due. Only pages whose content changed are re-embedded. Configured by
+// WEB_CRAWL_CONFIG.schedule, read before every check:
+//
+//	{"schedule": {"enabled": true, "checkIntervalMinutes": 60}}
+type WebRecrawl struct {
+	webSourceUseCase domain.WebSourceUseCase
+	paramCache       domain.ParameterCache
+}
+
+// NewWebRecrawl creates the web re-crawl job
+func NewWebRecrawl(webSourceUseCase domain.WebSourceUseCase, paramCache domain.ParameterCache) *WebRecrawl {
+	return &WebRecrawl{
+		webSourceUseCase: webSourceUseCase,
+		paramCache:       paramCache,
+	}
+}
+
+// Run checks for due web sources every checkIntervalMinutes until the context is cancelled
+func (j *WebRecrawl) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(j.interval()):
+		}
+
+		if j.enabled() {
+			j.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce crawls the web sources that are due
+func (j *WebRecrawl) RunOnce(ctx context.Context) {
+	result := j.webSourceUseCase.CrawlDue(ctx)
+	if !result.Success {
+		logger.LogWarn(ctx, "Web re-crawl run failed",
+			"operation", "WebRecrawl",
+			"code", result.Code,
+		)
+		return
+	}
+	if len(result.Data) == 0 {
+		return
+	}
+
+	created, updated, unchanged, failed := 0, 0, 0, 0
+	for _, crawl := range result.Data {
+		created += crawl.Created
+		updated += crawl.Updated
+		unchanged += crawl.Unchanged
+		failed += crawl.Failed
+	}
+
+	logger.LogInfo(ctx, "Web sources re-crawled",
+		"operation", "WebRecrawl",
+		"sources", len(result.Data),
+		"created", created,
+		"updated", updated,
+		"unchanged", unchanged,
+		"failed", failed,
+	)
+}
+
+func (j *WebRecrawl) enabled() bool {
+	enabled, _ := j.schedule()["enabled"].(bool)
+	return enabled
+}
+
+func (j *WebRecrawl) interval() time.Duration {
+	minutes, ok := j.schedule()["checkIntervalMinutes"].(float64)
+	if !ok || minutes <= 0 {
+		minutes = defaultWebRecrawlCheckMinutes
+	}
+	return time.Duration(minutes * float64(time.Minute))
+}
+
+func (j *WebRecrawl) schedule() map[string]any {
+	data, exists := j.paramCache.GetValue("WEB_CRAWL_CONFIG")
+	if !exists {
+		return nil
+	}
+	schedule, _ := data["schedule"].(map[string]any)
+	return schedule
+}
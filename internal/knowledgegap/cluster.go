@@ -0,0 +1,175 @@
+package knowledgegap
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"api-chatbot/domain"
+)
+
+// Options configures the clustering of questions
+type Options struct {
+	SimilarityThreshold float64 // Minimum cosine similarity to a cluster centre to join it
+	MinClusterSize      int     // Smaller clusters are dropped as noise
+	GoodScore           float64 // Best retrieval score at which an answer had good sources
+	Representatives     int     // Representative questions kept per cluster
+}
+
+type cluster struct {
+	sum     []float64 // Sum of the normalised member embeddings (direction of the centre)
+	members []int
+}
+
+// Cluster groups the questions by meaning and describes every cluster of at least
+// MinClusterSize questions. vectors[i] is the embedding of questions[i]; questions without
+// an embedding are skipped. Each question joins the most similar cluster centre reaching
+// SimilarityThreshold or starts a new cluster, so the result depends on the question order
+// (newest first gives recent phrasings the centre). Clusters are returned with the most
+// questions answered without good sources first.
+func Cluster(questions []domain.GapQuestion, vectors [][]float32, opts Options) []domain.KnowledgeGap {
+	normalised := make([][]float64, len(questions))
+	clusters := make([]*cluster, 0)
+
+	for i := range questions {
+		if i >= len(vectors) || len(vectors[i]) == 0 {
+			continue
+		}
+		vector := normalise(vectors[i])
+		if vector == nil {
+			continue
+		}
+		normalised[i] = vector
+
+		var best *cluster
+		bestSimilarity := opts.SimilarityThreshold
+		for _, c := range clusters {
+			if similarity := centreSimilarity(c, vector); similarity >= bestSimilarity {
+				best = c
+				bestSimilarity = similarity
+			}
+		}
+		if best == nil {
+			best = &cluster{sum: make([]float64, len(vector))}
+			clusters = append(clusters, best)
+		}
+		best.members = append(best.members, i)
+		for j := range vector {
+			best.sum[j] += vector[j]
+		}
+	}
+
+	gaps := make([]domain.KnowledgeGap, 0, len(clusters))
+	for _, c := range clusters {
+		if len(c.members) < max(opts.MinClusterSize, 1) {
+			continue
+		}
+		gaps = append(gaps, describe(c, questions, normalised, opts))
+	}
+
+	sort.SliceStable(gaps, func(i, j int) bool {
+		if gaps[i].UnansweredCount != gaps[j].UnansweredCount {
+			return gaps[i].UnansweredCount > gaps[j].UnansweredCount
+		}
+		return gaps[i].QuestionCount > gaps[j].QuestionCount
+	})
+	return gaps
+}
+
+// Unanswered reports whether a question was answered without good sources: nothing
+// retrieved, a best score below goodScore or a handoff. FAQ answers count as answered.
+func Unanswered(question domain.GapQuestion, goodScore float64) bool {
+	if question.FAQAnswered {
+		return false
+	}
+	if question.HandedOff {
+		return true
+	}
+	return question.BestSimilarity == nil || *question.BestSimilarity < goodScore
+}
+
+// describe labels a cluster with its most central questions and its answer statistics
+func describe(c *cluster, questions []domain.GapQuestion, normalised [][]float64, opts Options) domain.KnowledgeGap {
+	members := append([]int(nil), c.members...)
+	sort.SliceStable(members, func(i, j int) bool {
+		return centreSimilarity(c, normalised[members[i]]) > centreSimilarity(c, normalised[members[j]])
+	})
+
+	gap := domain.KnowledgeGap{
+		QuestionCount:           len(members),
+		RepresentativeQuestions: make([]string, 0, opts.Representatives),
+	}
+
+	seen := make(map[string]bool)
+	var confidenceSum float64
+	var confidenceCount int
+	for _, i := range members {
+		question := questions[i]
+
+		key := strings.ToLower(strings.Join(strings.Fields(question.Question), " "))
+		if !seen[key] && len(gap.RepresentativeQuestions) < max(opts.Representatives, 1) {
+			seen[key] = true
+			gap.RepresentativeQuestions = append(gap.RepresentativeQuestions, question.Question)
+		}
+
+		if question.BestSimilarity != nil {
+			confidenceSum += *question.BestSimilarity
+			confidenceCount++
+		}
+		if Unanswered(question, opts.GoodScore) {
+			gap.UnansweredCount++
+		}
+		if question.HandedOff {
+			gap.HandoffCount++
+		}
+		if question.FAQAnswered {
+			gap.FAQAnsweredCount++
+		}
+		if gap.FirstAsked.IsZero() || question.AskedAt.Before(gap.FirstAsked) {
+			gap.FirstAsked = question.AskedAt
+		}
+		if question.AskedAt.After(gap.LastAsked) {
+			gap.LastAsked = question.AskedAt
+		}
+	}
+
+	gap.Label = gap.RepresentativeQuestions[0]
+	if confidenceCount > 0 {
+		avg := confidenceSum / float64(confidenceCount)
+		gap.AvgConfidence = &avg
+	}
+	gap.UnansweredShare = float64(gap.UnansweredCount) / float64(gap.QuestionCount)
+	return gap
+}
+
+// centreSimilarity is the cosine similarity of a normalised vector to the cluster centre
+func centreSimilarity(c *cluster, vector []float64) float64 {
+	if len(c.sum) != len(vector) {
+		return 0
+	}
+	var dot, norm float64
+	for i := range vector {
+		dot += c.sum[i] * vector[i]
+		norm += c.sum[i] * c.sum[i]
+	}
+	if norm == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(norm)
+}
+
+func normalise(vector []float32) []float64 {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return nil
+	}
+	norm = math.Sqrt(norm)
+	normalised := make([]float64, len(vector))
+	for i, v := range vector {
+		normalised[i] = float64(v) / norm
+	}
+	return normalised
+}
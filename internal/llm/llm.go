@@ -28,14 +28,23 @@ type GenerateRequest struct {
 	// Context provides relevant information to help answer the question
 	Context string
 
-	// Temperature controls randomness (0.0 = deterministic, 1.0 = creative)
-	Temperature float64
+	// Temperature controls randomness (0.0 = deterministic, 1.0 = creative).
+	// Nil uses the provider default; see Temperature to set it inline.
+	Temperature *float64
 
 	// MaxTokens limits the response length
 	MaxTokens int
 
 	// ConversationHistory for multi-turn conversations (optional)
 	ConversationHistory []Message
+
+	// Model overrides the configured model for this request (optional, e.g. experiment variants)
+	Model string
+}
+
+// Temperature returns a pointer to a temperature value, for GenerateRequest.Temperature
+func Temperature(value float64) *float64 {
+	return &value
 }
 
 type GenerateResponse struct {
@@ -71,8 +80,8 @@ type Config struct {
 	// Model name (e.g., "llama-3.3-70b-versatile", "gpt-4o-mini", "claude-3-opus")
 	Model string
 
-	// Default temperature
-	Temperature float64
+	// Default temperature (nil leaves it to the API)
+	Temperature *float64
 
 	// Default max tokens
 	MaxTokens int
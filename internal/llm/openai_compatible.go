@@ -71,17 +71,22 @@ func (p *OpenAICompatibleProvider) GenerateResponse(ctx context.Context, req Gen
 		"content": req.UserMessage,
 	})
 
+	model := p.config.Model
+	if req.Model != "" {
+		model = req.Model
+	}
+
 	// Build request body
 	requestBody := map[string]interface{}{
-		"model":    p.config.Model,
+		"model":    model,
 		"messages": messages,
 	}
 
 	// Add optional parameters
-	if req.Temperature > 0 {
-		requestBody["temperature"] = req.Temperature
-	} else if p.config.Temperature > 0 {
-		requestBody["temperature"] = p.config.Temperature
+	if req.Temperature != nil {
+		requestBody["temperature"] = *req.Temperature
+	} else if p.config.Temperature != nil {
+		requestBody["temperature"] = *p.config.Temperature
 	}
 
 	if req.MaxTokens > 0 {
@@ -118,7 +123,7 @@ func (p *OpenAICompatibleProvider) GenerateResponse(ctx context.Context, req Gen
 	// Log request
 	logger.LogInfo(ctx, "Sending LLM request",
 		"provider", p.config.Provider,
-		"model", p.config.Model,
+		"model", model,
 		"baseURL", p.baseURL,
 		"messagesCount", len(messages),
 	)
@@ -128,7 +133,7 @@ func (p *OpenAICompatibleProvider) GenerateResponse(ctx context.Context, req Gen
 	if err != nil {
 		logger.LogError(ctx, "LLM API request failed", err,
 			"provider", p.config.Provider,
-			"model", p.config.Model,
+			"model", model,
 		)
 		return nil, &Error{
 			Code:    ErrCodeAPIError,
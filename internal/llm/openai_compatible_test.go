@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestServer serves chat completions and hands each request body to received
+func newTestServer(t *testing.T, received func(body map[string]any)) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		received(body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"model": "test-model", "choices": [{"message": {"role": "assistant", "content": "ok"}, "finish_reason": "stop"}]}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGenerateResponseTemperature(t *testing.T) {
+	tests := []struct {
+		name        string
+		configured  *float64
+		requested   *float64
+		want        float64
+		wantPresent bool
+	}{
+		{"zero request temperature is sent", Temperature(0.7), Temperature(0), 0, true},
+		{"request temperature wins", Temperature(0.7), Temperature(0.2), 0.2, true},
+		{"zero configured temperature is sent", Temperature(0), nil, 0, true},
+		{"unset temperature is left to the API", nil, nil, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]any
+			server := newTestServer(t, func(b map[string]any) { body = b })
+			provider := NewOpenAICompatibleProvider(Config{
+				APIKey:      "test",
+				BaseURL:     server.URL,
+				Model:       "test-model",
+				Temperature: tt.configured,
+			})
+
+			if _, err := provider.GenerateResponse(context.Background(), GenerateRequest{
+				UserMessage: "hola",
+				Temperature: tt.requested,
+			}); err != nil {
+				t.Fatalf("GenerateResponse: %v", err)
+			}
+
+			temperature, present := body["temperature"]
+			if present != tt.wantPresent {
+				t.Fatalf("temperature present = %v, want %v (body %v)", present, tt.wantPresent, body)
+			}
+			if present && temperature != tt.want {
+				t.Fatalf("temperature = %v, want %v", temperature, tt.want)
+			}
+		})
+	}
+}
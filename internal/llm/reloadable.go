@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"api-chatbot/domain"
+)
+
+// ConfigFromParameter builds a provider Config from the LLM_CONFIG parameter data
+func ConfigFromParameter(data domain.Data) (Config, error) {
+	provider, _ := data["provider"].(string)
+	apiKey, _ := data["apiKey"].(string)
+	baseURL, _ := data["baseURL"].(string)
+	model, _ := data["model"].(string)
+	var temperature *float64
+	if val, ok := data["temperature"].(float64); ok {
+		temperature = &val
+	}
+	maxTokens, _ := data["maxTokens"].(float64)
+	timeout, _ := data["timeout"].(float64)
+	systemPrompt, _ := data["systemPrompt"].(string)
+
+	if apiKey == "" || baseURL == "" || model == "" {
+		return Config{}, fmt.Errorf("LLM_CONFIG missing required fields (apiKey, baseURL, model)")
+	}
+
+	return Config{
+		Provider:     provider,
+		APIKey:       apiKey,
+		BaseURL:      baseURL,
+		Model:        model,
+		Temperature:  temperature,
+		MaxTokens:    int(maxTokens),
+		Timeout:      int(timeout),
+		SystemPrompt: systemPrompt,
+	}, nil
+}
+
+// ReloadableProvider wraps a Provider built from the LLM_CONFIG parameter and
+// rebuilds it whenever the parameter data changes in the cache (e.g. after
+// /parameters/update + /reload-cache). Each call resolves the instance once,
+// so in-flight requests finish on the instance they started with.
+type ReloadableProvider struct {
+	paramCache domain.ParameterCache
+
+	mu       sync.RWMutex
+	current  Provider
+	rawData  []byte // LLM_CONFIG data the current instance was built from
+	buildErr error
+}
+
+// NewReloadableProvider creates a provider that follows LLM_CONFIG changes
+func NewReloadableProvider(paramCache domain.ParameterCache) *ReloadableProvider {
+	p := &ReloadableProvider{paramCache: paramCache}
+	p.resolve()
+	return p
+}
+
+// resolve returns the provider for the current LLM_CONFIG, rebuilding it if the parameter changed
+func (p *ReloadableProvider) resolve() (Provider, error) {
+	param, exists := p.paramCache.Get("LLM_CONFIG")
+	if !exists {
+		return nil, &Error{Code: ErrCodeInvalidConfig, Message: "LLM_CONFIG parameter not found"}
+	}
+
+	p.mu.RLock()
+	if p.rawData != nil && bytes.Equal(p.rawData, param.Data) {
+		current, err := p.current, p.buildErr
+		p.mu.RUnlock()
+		return current, err
+	}
+	p.mu.RUnlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Another goroutine may have rebuilt it while we waited for the lock
+	if p.rawData != nil && bytes.Equal(p.rawData, param.Data) {
+		return p.current, p.buildErr
+	}
+
+	p.rawData = append([]byte(nil), param.Data...)
+
+	data, err := param.GetDataAsMap()
+	if err != nil {
+		p.current, p.buildErr = nil, &Error{Code: ErrCodeInvalidConfig, Message: "failed to parse LLM_CONFIG", Err: err}
+		slog.Error("LLM provider not rebuilt", "error", p.buildErr)
+		return nil, p.buildErr
+	}
+
+	config, err := ConfigFromParameter(data)
+	if err != nil {
+		p.current, p.buildErr = nil, &Error{Code: ErrCodeInvalidConfig, Message: "invalid LLM_CONFIG", Err: err}
+		slog.Error("LLM provider not rebuilt", "error", p.buildErr)
+		return nil, p.buildErr
+	}
+
+	p.current, p.buildErr = NewOpenAICompatibleProvider(config), nil
+
+	slog.Info("LLM provider initialized",
+		"provider", config.Provider,
+		"model", config.Model,
+		"baseURL", config.BaseURL,
+	)
+
+	return p.current, nil
+}
+
+// GenerateResponse generates a response with the provider built from the current LLM_CONFIG
+func (p *ReloadableProvider) GenerateResponse(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	provider, err := p.resolve()
+	if err != nil {
+		return nil, err
+	}
+	return provider.GenerateResponse(ctx, req)
+}
+
+// GetProviderName returns the name of the current provider
+func (p *ReloadableProvider) GetProviderName() string {
+	provider, err := p.resolve()
+	if err != nil {
+		return ""
+	}
+	return provider.GetProviderName()
+}
+
+// IsAvailable checks if LLM_CONFIG currently yields a usable provider
+func (p *ReloadableProvider) IsAvailable() bool {
+	provider, err := p.resolve()
+	if err != nil {
+		return false
+	}
+	return provider.IsAvailable()
+}
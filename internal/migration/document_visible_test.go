@@ -0,0 +1,78 @@
+package migration
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+)
+
+// TestDocumentVisible runs the migrations on the database in TEST_DATABASE_DSN and checks
+// fn_document_visible for the roles the search channels use. It is skipped without one.
+func TestDocumentVisible(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	runner, err := NewRunner(Config{DSN: dsn})
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	defer runner.Close()
+	if err := runner.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+
+	// Categories are created inside the transaction and rolled back with it
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description) VALUES
+			('DOCUMENT_CATEGORY', 'TEST_CAT_EXTERNAL', '{"audience": ["ROLE_EXTERNAL", "ROLE_STUDENT"]}'::jsonb, 'Test category'),
+			('DOCUMENT_CATEGORY', 'TEST_CAT_STAFF', '{"audience": ["ROLE_PROFESSOR"]}'::jsonb, 'Test category'),
+			('DOCUMENT_CATEGORY', 'TEST_CAT_PUBLIC', '{}'::jsonb, 'Test category')`); err != nil {
+		t.Fatalf("insert categories: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		audience string // Postgres array literal
+		category string
+		role     *string
+		want     bool
+	}{
+		{"public document", "{}", "TEST_CAT_PUBLIC", role("ROLE_EXTERNAL"), true},
+		{"document for external users", "{ROLE_EXTERNAL}", "TEST_CAT_PUBLIC", role("ROLE_EXTERNAL"), true},
+		{"document for students", "{ROLE_STUDENT}", "TEST_CAT_PUBLIC", role("ROLE_EXTERNAL"), false},
+		{"category for external users", "{}", "TEST_CAT_EXTERNAL", role("ROLE_EXTERNAL"), true},
+		{"category for staff", "{}", "TEST_CAT_STAFF", role("ROLE_EXTERNAL"), false},
+		{"document audience wins over category", "{ROLE_EXTERNAL}", "TEST_CAT_STAFF", role("ROLE_EXTERNAL"), true},
+		{"role outside every audience", "{ROLE_EXTERNAL}", "TEST_CAT_PUBLIC", role("ROLE_GUEST"), false},
+		{"no role sees everything", "{ROLE_PROFESSOR}", "TEST_CAT_STAFF", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var visible bool
+			if err := tx.QueryRow(`SELECT fn_document_visible($1::text[], $2, $3)`, tt.audience, tt.category, tt.role).Scan(&visible); err != nil {
+				t.Fatalf("fn_document_visible: %v", err)
+			}
+			if visible != tt.want {
+				t.Errorf("fn_document_visible(%s, %s, %v) = %v, want %v", tt.audience, tt.category, tt.role, visible, tt.want)
+			}
+		})
+	}
+}
+
+func role(name string) *string {
+	return &name
+}
@@ -0,0 +1,24 @@
+-- =====================================================
+-- Guardrails Pipeline
+-- Migration: 000044_guardrails.down.sql
+-- =====================================================
+
+DROP FUNCTION IF EXISTS fn_get_guardrail_events(VARCHAR, VARCHAR, BOOLEAN, INT, INT);
+DROP PROCEDURE IF EXISTS sp_review_guardrail_event(BOOLEAN, VARCHAR, INT, INT, TEXT);
+DROP PROCEDURE IF EXISTS sp_create_guardrail_event(BOOLEAN, VARCHAR, INT, VARCHAR, VARCHAR, VARCHAR, VARCHAR, TEXT, VARCHAR, VARCHAR, INT, TEXT, TEXT);
+
+DROP INDEX IF EXISTS idx_guardrail_events_action;
+DROP INDEX IF EXISTS idx_guardrail_events_pending;
+DROP INDEX IF EXISTS idx_guardrail_events_created;
+
+DROP TABLE IF EXISTS cht_guardrail_events;
+
+DELETE FROM cht_parameters WHERE prm_code IN (
+    'GUARDRAILS_CONFIG',
+    'GUARDRAILS_RULES',
+    'GUARDRAILS_BLOCKED_MESSAGE',
+    'GUARDRAILS_ESCALATED_MESSAGE',
+    'ERR_CREATE_GUARDRAIL_EVENT',
+    'ERR_GUARDRAIL_EVENT_NOT_FOUND',
+    'ERR_REVIEW_GUARDRAIL_EVENT'
+);
@@ -0,0 +1,250 @@
+-- =====================================================
+-- Guardrails Pipeline
+-- Migration: 000044_guardrails.up.sql
+-- Purpose: Store guardrail triggers for admin review and seed configuration
+-- =====================================================
+
+-- =====================================================
+-- Table: cht_guardrail_events
+-- Description: Every input/output guardrail trigger (block, rewrite, escalate)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS public.cht_guardrail_events (
+    gev_id              SERIAL PRIMARY KEY,
+    gev_stage           VARCHAR(10) NOT NULL CHECK (gev_stage IN ('input', 'output')),
+    gev_check           VARCHAR(50) NOT NULL,
+    gev_rule            VARCHAR(100),
+    gev_action          VARCHAR(20) NOT NULL CHECK (gev_action IN ('block', 'rewrite', 'escalate')),
+    gev_reason          TEXT,
+    gev_channel         VARCHAR(20) NOT NULL DEFAULT 'whatsapp',
+    gev_chat_id         VARCHAR(100),
+    gev_fk_conversation INT REFERENCES cht_conversations(cnv_id) ON DELETE SET NULL,
+    gev_original_text   TEXT NOT NULL,
+    gev_rewritten_text  TEXT,
+    gev_reviewed        BOOLEAN NOT NULL DEFAULT false,
+    gev_reviewed_by     INT REFERENCES cht_admin_users(adm_id),
+    gev_reviewed_at     TIMESTAMP,
+    gev_review_notes    TEXT,
+    gev_created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_guardrail_events_created ON cht_guardrail_events(gev_created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_guardrail_events_pending ON cht_guardrail_events(gev_reviewed) WHERE gev_reviewed = false;
+CREATE INDEX IF NOT EXISTS idx_guardrail_events_action ON cht_guardrail_events(gev_action);
+
+-- =====================================================
+-- Stored Procedure: sp_create_guardrail_event
+-- Description: Log a guardrail trigger
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_create_guardrail_event(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_gev_id INT,
+    IN p_stage VARCHAR,
+    IN p_check VARCHAR,
+    IN p_rule VARCHAR,
+    IN p_action VARCHAR,
+    IN p_reason TEXT,
+    IN p_channel VARCHAR,
+    IN p_chat_id VARCHAR,
+    IN p_conversation_id INT,
+    IN p_original_text TEXT,
+    IN p_rewritten_text TEXT
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := true;
+    code := 'OK';
+
+    INSERT INTO cht_guardrail_events (
+        gev_stage, gev_check, gev_rule, gev_action, gev_reason, gev_channel,
+        gev_chat_id, gev_fk_conversation, gev_original_text, gev_rewritten_text
+    ) VALUES (
+        p_stage, p_check, p_rule, p_action, p_reason, COALESCE(p_channel, 'whatsapp'),
+        p_chat_id, NULLIF(p_conversation_id, 0), p_original_text, p_rewritten_text
+    )
+    RETURNING gev_id INTO o_gev_id;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_CREATE_GUARDRAIL_EVENT';
+        o_gev_id := NULL;
+        RAISE NOTICE 'Error creating guardrail event: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_review_guardrail_event
+-- Description: Mark a guardrail trigger as reviewed by an admin
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_review_guardrail_event(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_gev_id INT,
+    IN p_admin_id INT,
+    IN p_notes TEXT DEFAULT NULL
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := true;
+    code := 'OK';
+
+    IF NOT EXISTS (SELECT 1 FROM cht_guardrail_events WHERE gev_id = p_gev_id) THEN
+        success := false;
+        code := 'ERR_GUARDRAIL_EVENT_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    UPDATE cht_guardrail_events
+    SET gev_reviewed = true,
+        gev_reviewed_by = p_admin_id,
+        gev_reviewed_at = CURRENT_TIMESTAMP,
+        gev_review_notes = p_notes
+    WHERE gev_id = p_gev_id;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_REVIEW_GUARDRAIL_EVENT';
+        RAISE NOTICE 'Error reviewing guardrail event: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_get_guardrail_events
+-- Description: List guardrail triggers for admin review
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_guardrail_events(
+    p_stage VARCHAR DEFAULT NULL,
+    p_action VARCHAR DEFAULT NULL,
+    p_only_pending BOOLEAN DEFAULT false,
+    p_limit INT DEFAULT 50,
+    p_offset INT DEFAULT 0
+)
+RETURNS TABLE (
+    gev_id INT,
+    gev_stage VARCHAR(10),
+    gev_check VARCHAR(50),
+    gev_rule VARCHAR(100),
+    gev_action VARCHAR(20),
+    gev_reason TEXT,
+    gev_channel VARCHAR(20),
+    gev_chat_id VARCHAR(100),
+    gev_fk_conversation INT,
+    gev_original_text TEXT,
+    gev_rewritten_text TEXT,
+    gev_reviewed BOOLEAN,
+    gev_reviewed_by INT,
+    gev_reviewed_at TIMESTAMP,
+    gev_review_notes TEXT,
+    gev_created_at TIMESTAMP
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        e.gev_id, e.gev_stage, e.gev_check, e.gev_rule, e.gev_action, e.gev_reason,
+        e.gev_channel, e.gev_chat_id, e.gev_fk_conversation, e.gev_original_text,
+        e.gev_rewritten_text, e.gev_reviewed, e.gev_reviewed_by, e.gev_reviewed_at,
+        e.gev_review_notes, e.gev_created_at
+    FROM cht_guardrail_events e
+    WHERE (p_stage IS NULL OR e.gev_stage = p_stage)
+      AND (p_action IS NULL OR e.gev_action = p_action)
+      AND (NOT p_only_pending OR e.gev_reviewed = false)
+    ORDER BY e.gev_created_at DESC
+    LIMIT p_limit OFFSET p_offset;
+END;
+$$;
+
+-- =====================================================
+-- Parameters
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'GUARDRAILS_CONFIG') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES (
+            'GUARDRAILS',
+            'GUARDRAILS_CONFIG',
+            '{
+                "enabled": true,
+                "pii": {"enabled": true, "stages": ["input"], "action": "rewrite"},
+                "secrets": {"enabled": true, "stages": ["input", "output"], "action": "rewrite"},
+                "classifier": {"enabled": false, "stages": ["input"], "action": "escalate"}
+            }'::jsonb,
+            'Guardrail pipeline configuration (PII/secret detection and optional LLM classifier)'
+        );
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'GUARDRAILS_RULES') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES (
+            'GUARDRAILS',
+            'GUARDRAILS_RULES',
+            '{
+                "rules": [
+                    {
+                        "name": "prompt_injection",
+                        "type": "regex",
+                        "pattern": "(?i)(ignora|olvida|ignore|forget)\\s+(todas\\s+)?(las\\s+|all\\s+)?(instrucciones|instructions|previous)",
+                        "stages": ["input"],
+                        "action": "block"
+                    },
+                    {
+                        "name": "self_harm",
+                        "type": "keyword",
+                        "keywords": ["suicidio", "quitarme la vida", "hacerme daño"],
+                        "stages": ["input"],
+                        "action": "escalate"
+                    }
+                ]
+            }'::jsonb,
+            'Regex/keyword guardrail rules. Actions: block, rewrite (uses replacement), escalate'
+        );
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'GUARDRAILS_BLOCKED_MESSAGE') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES (
+            'GUARDRAILS',
+            'GUARDRAILS_BLOCKED_MESSAGE',
+            '{"message": "⚠️ No puedo ayudarte con esa solicitud. Si tienes otra consulta sobre el Instituto, con gusto te ayudo."}'::jsonb,
+            'Message sent when a guardrail blocks a request or response'
+        );
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'GUARDRAILS_ESCALATED_MESSAGE') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES (
+            'GUARDRAILS',
+            'GUARDRAILS_ESCALATED_MESSAGE',
+            '{"message": "🙋 Tu mensaje fue derivado a una persona del equipo, quien te responderá lo antes posible."}'::jsonb,
+            'Message sent when a guardrail escalates a conversation to a human'
+        );
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_CREATE_GUARDRAIL_EVENT') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_CREATE_GUARDRAIL_EVENT', '{"message": "Error al registrar el evento de guardrail"}'::jsonb, 'Error creating guardrail event');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_GUARDRAIL_EVENT_NOT_FOUND') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_GUARDRAIL_EVENT_NOT_FOUND', '{"message": "Evento de guardrail no encontrado"}'::jsonb, 'Guardrail event not found');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_REVIEW_GUARDRAIL_EVENT') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_REVIEW_GUARDRAIL_EVENT', '{"message": "Error al revisar el evento de guardrail"}'::jsonb, 'Error reviewing guardrail event');
+    END IF;
+END $$;
+
+-- Comments
+COMMENT ON TABLE cht_guardrail_events IS 'Guardrail triggers on user input and generated output, kept for admin review';
+COMMENT ON COLUMN cht_guardrail_events.gev_stage IS 'Pipeline stage: input (before retrieval) or output (after generation)';
+COMMENT ON COLUMN cht_guardrail_events.gev_check IS 'Check that triggered: rules, pii, secrets, classifier';
+COMMENT ON COLUMN cht_guardrail_events.gev_action IS 'Action taken: block, rewrite, escalate';
+COMMENT ON COLUMN cht_guardrail_events.gev_channel IS 'Channel where it happened: whatsapp or api';
@@ -0,0 +1,480 @@
+-- =====================================================
+-- Prompt/Model Experiments
+-- Migration: 000045_experiments.up.sql
+-- Purpose: A/B experiments over prompt version, model and retrieval settings,
+--          with bot messages tagged by the variant that produced them
+-- =====================================================
+
+-- =====================================================
+-- Table: cht_experiments
+-- Description: An experiment groups the variants being compared (only one active at a time)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS public.cht_experiments (
+    exp_id              SERIAL PRIMARY KEY,
+    exp_code            VARCHAR(50) NOT NULL UNIQUE,
+    exp_name            VARCHAR(150) NOT NULL,
+    exp_description     TEXT,
+    exp_active          BOOLEAN NOT NULL DEFAULT false,
+    exp_started_at      TIMESTAMP,
+    exp_ended_at        TIMESTAMP,
+    exp_created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    exp_updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_experiments_single_active ON cht_experiments(exp_active) WHERE exp_active = true;
+
+-- =====================================================
+-- Table: cht_experiment_variants
+-- Description: Variant settings (prompt version, model, temperature, search limit, keyword weight)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS public.cht_experiment_variants (
+    exv_id              SERIAL PRIMARY KEY,
+    exv_fk_experiment   INT NOT NULL REFERENCES cht_experiments(exp_id) ON DELETE CASCADE,
+    exv_code            VARCHAR(50) NOT NULL,
+    exv_weight          INT NOT NULL DEFAULT 1 CHECK (exv_weight > 0),
+    exv_config          JSONB NOT NULL DEFAULT '{}'::JSONB,
+    exv_created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_experiment_variant_code UNIQUE (exv_fk_experiment, exv_code)
+);
+
+CREATE INDEX IF NOT EXISTS idx_experiment_variants_experiment ON cht_experiment_variants(exv_fk_experiment);
+
+-- =====================================================
+-- Message columns: variant tag and user feedback
+-- =====================================================
+ALTER TABLE cht_conversation_messages
+    ADD COLUMN IF NOT EXISTS cvm_fk_variant INT REFERENCES cht_experiment_variants(exv_id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS cvm_feedback SMALLINT CHECK (cvm_feedback IN (-1, 1));
+
+CREATE INDEX IF NOT EXISTS idx_conversation_messages_variant ON cht_conversation_messages(cvm_fk_variant) WHERE cvm_fk_variant IS NOT NULL;
+
+-- =====================================================
+-- Stored Procedure: sp_create_conversation_message
+-- Description: Recreated with variant tag and message metadata
+-- =====================================================
+DROP PROCEDURE IF EXISTS sp_create_conversation_message;
+
+CREATE OR REPLACE PROCEDURE sp_create_conversation_message(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_cvm_id INT,
+    IN p_conversation_id INT,
+    IN p_message_id VARCHAR,
+    IN p_from_me BOOLEAN,
+    IN p_sender_name VARCHAR DEFAULT NULL,
+    IN p_sender_type VARCHAR DEFAULT 'user',
+    IN p_message_type VARCHAR DEFAULT 'text',
+    IN p_body TEXT DEFAULT NULL,
+    IN p_media_url VARCHAR DEFAULT NULL,
+    IN p_quoted_message VARCHAR DEFAULT NULL,
+    IN p_timestamp BIGINT DEFAULT NULL,
+    IN p_is_forwarded BOOLEAN DEFAULT FALSE,
+    IN p_queue_time_ms INT DEFAULT NULL,
+    IN p_prompt_tokens INT DEFAULT NULL,
+    IN p_prompt_time_ms INT DEFAULT NULL,
+    IN p_completion_tokens INT DEFAULT NULL,
+    IN p_completion_time_ms INT DEFAULT NULL,
+    IN p_total_tokens INT DEFAULT NULL,
+    IN p_total_time_ms INT DEFAULT NULL,
+    IN p_variant_id INT DEFAULT NULL,
+    IN p_metadata JSONB DEFAULT NULL
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_exists BOOLEAN;
+BEGIN
+    success := TRUE;
+    code := 'OK';
+    o_cvm_id := NULL;
+
+    -- Check if conversation exists
+    SELECT EXISTS(
+        SELECT 1
+        FROM cht_conversations
+        WHERE cnv_id = p_conversation_id
+    ) INTO v_exists;
+
+    IF NOT v_exists THEN
+        success := FALSE;
+        code := 'ERR_CONVERSATION_NOT_FOUND';
+        RAISE NOTICE 'Conversation % not found', p_conversation_id;
+        RETURN;
+    END IF;
+
+    -- Check if message_id already exists
+    SELECT EXISTS(
+        SELECT 1
+        FROM cht_conversation_messages
+        WHERE cvm_message_id = p_message_id
+    ) INTO v_exists;
+
+    IF v_exists THEN
+        success := FALSE;
+        code := 'ERR_DUPLICATE_MESSAGE_ID';
+        RAISE NOTICE 'Message ID % already exists', p_message_id;
+        RETURN;
+    END IF;
+
+    -- Insert message
+    INSERT INTO cht_conversation_messages (
+        cvm_fk_conversation,
+        cvm_message_id,
+        cvm_from_me,
+        cvm_sender_name,
+        cvm_sender_type,
+        cvm_message_type,
+        cvm_body,
+        cvm_media_url,
+        cvm_quoted_message,
+        cvm_timestamp,
+        cvm_is_forwarded,
+        cvm_queue_time_ms,
+        cvm_prompt_tokens,
+        cvm_prompt_time_ms,
+        cvm_completion_tokens,
+        cvm_completion_time_ms,
+        cvm_total_tokens,
+        cvm_total_time_ms,
+        cvm_fk_variant,
+        cvm_metadata
+    ) VALUES (
+        p_conversation_id,
+        p_message_id,
+        p_from_me,
+        p_sender_name,
+        p_sender_type,
+        p_message_type,
+        p_body,
+        p_media_url,
+        p_quoted_message,
+        COALESCE(p_timestamp, EXTRACT(EPOCH FROM CURRENT_TIMESTAMP)::BIGINT),
+        p_is_forwarded,
+        p_queue_time_ms,
+        p_prompt_tokens,
+        p_prompt_time_ms,
+        p_completion_tokens,
+        p_completion_time_ms,
+        p_total_tokens,
+        p_total_time_ms,
+        NULLIF(p_variant_id, 0),
+        COALESCE(p_metadata, '{}'::JSONB)
+    )
+    RETURNING cvm_id INTO o_cvm_id;
+
+    -- Update conversation stats
+    UPDATE cht_conversations
+    SET
+        cnv_message_count = cnv_message_count + 1,
+        cnv_last_message_at = CURRENT_TIMESTAMP
+    WHERE cnv_id = p_conversation_id;
+
+EXCEPTION
+    WHEN unique_violation THEN
+        success := FALSE;
+        code := 'ERR_DUPLICATE_MESSAGE_ID';
+        o_cvm_id := NULL;
+        RAISE NOTICE 'Duplicate message ID: % (SQLSTATE: %)', p_message_id, SQLSTATE;
+    WHEN foreign_key_violation THEN
+        success := FALSE;
+        code := 'ERR_INVALID_CONVERSATION';
+        o_cvm_id := NULL;
+        RAISE NOTICE 'Invalid conversation ID: % (SQLSTATE: %)', p_conversation_id, SQLSTATE;
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_CREATE_MESSAGE';
+        o_cvm_id := NULL;
+        RAISE NOTICE 'Error creating message: % (SQLSTATE: %)', SQLERRM, SQLSTATE;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_create_experiment
+-- Description: Create an experiment with its variants
+--   p_variants: [{"code": "A", "weight": 50, "config": {...}}, ...]
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_create_experiment(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_exp_id INT,
+    IN p_code VARCHAR,
+    IN p_name VARCHAR,
+    IN p_description TEXT,
+    IN p_variants JSONB
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_variant JSONB;
+BEGIN
+    success := true;
+    code := 'OK';
+
+    IF EXISTS (SELECT 1 FROM cht_experiments WHERE exp_code = p_code) THEN
+        success := false;
+        code := 'ERR_EXPERIMENT_CODE_EXISTS';
+        o_exp_id := NULL;
+        RETURN;
+    END IF;
+
+    IF p_variants IS NULL OR jsonb_typeof(p_variants) <> 'array' OR jsonb_array_length(p_variants) < 2 THEN
+        success := false;
+        code := 'ERR_EXPERIMENT_VARIANTS_REQUIRED';
+        o_exp_id := NULL;
+        RETURN;
+    END IF;
+
+    INSERT INTO cht_experiments (exp_code, exp_name, exp_description)
+    VALUES (p_code, p_name, p_description)
+    RETURNING exp_id INTO o_exp_id;
+
+    FOR v_variant IN SELECT * FROM jsonb_array_elements(p_variants)
+    LOOP
+        INSERT INTO cht_experiment_variants (exv_fk_experiment, exv_code, exv_weight, exv_config)
+        VALUES (
+            o_exp_id,
+            v_variant->>'code',
+            COALESCE((v_variant->>'weight')::INT, 1),
+            COALESCE(v_variant->'config', '{}'::JSONB)
+        );
+    END LOOP;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_CREATE_EXPERIMENT';
+        o_exp_id := NULL;
+        RAISE NOTICE 'Error creating experiment: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_set_experiment_status
+-- Description: Start or stop an experiment. Starting one stops any other running experiment.
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_set_experiment_status(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_exp_id INT,
+    IN p_active BOOLEAN
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := true;
+    code := 'OK';
+
+    IF NOT EXISTS (SELECT 1 FROM cht_experiments WHERE exp_id = p_exp_id) THEN
+        success := false;
+        code := 'ERR_EXPERIMENT_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    IF p_active THEN
+        UPDATE cht_experiments
+        SET exp_active = false,
+            exp_ended_at = CURRENT_TIMESTAMP,
+            exp_updated_at = CURRENT_TIMESTAMP
+        WHERE exp_active = true AND exp_id <> p_exp_id;
+
+        UPDATE cht_experiments
+        SET exp_active = true,
+            exp_started_at = COALESCE(exp_started_at, CURRENT_TIMESTAMP),
+            exp_ended_at = NULL,
+            exp_updated_at = CURRENT_TIMESTAMP
+        WHERE exp_id = p_exp_id;
+    ELSE
+        UPDATE cht_experiments
+        SET exp_active = false,
+            exp_ended_at = CURRENT_TIMESTAMP,
+            exp_updated_at = CURRENT_TIMESTAMP
+        WHERE exp_id = p_exp_id AND exp_active = true;
+    END IF;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_UPDATE_EXPERIMENT';
+        RAISE NOTICE 'Error updating experiment status: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_get_experiments
+-- Description: List experiments with their variants
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_experiments()
+RETURNS TABLE (
+    exp_id INT,
+    exp_code VARCHAR(50),
+    exp_name VARCHAR(150),
+    exp_description TEXT,
+    exp_active BOOLEAN,
+    exp_started_at TIMESTAMP,
+    exp_ended_at TIMESTAMP,
+    exp_created_at TIMESTAMP,
+    exp_variants JSONB
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        e.exp_id, e.exp_code, e.exp_name, e.exp_description, e.exp_active,
+        e.exp_started_at, e.exp_ended_at, e.exp_created_at,
+        COALESCE(
+            (SELECT jsonb_agg(jsonb_build_object(
+                        'id', v.exv_id,
+                        'experimentId', v.exv_fk_experiment,
+                        'code', v.exv_code,
+                        'weight', v.exv_weight,
+                        'config', v.exv_config
+                    ) ORDER BY v.exv_id)
+             FROM cht_experiment_variants v
+             WHERE v.exv_fk_experiment = e.exp_id),
+            '[]'::JSONB
+        )
+    FROM cht_experiments e
+    ORDER BY e.exp_active DESC, e.exp_created_at DESC;
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_get_active_experiment_variants
+-- Description: Variants of the running experiment, in a stable order for assignment
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_active_experiment_variants()
+RETURNS TABLE (
+    exv_id INT,
+    exv_fk_experiment INT,
+    exp_code VARCHAR(50),
+    exv_code VARCHAR(50),
+    exv_weight INT,
+    exv_config JSONB
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RETURN QUERY
+    SELECT v.exv_id, v.exv_fk_experiment, e.exp_code, v.exv_code, v.exv_weight, v.exv_config
+    FROM cht_experiment_variants v
+    INNER JOIN cht_experiments e ON e.exp_id = v.exv_fk_experiment
+    WHERE e.exp_active = true
+    ORDER BY v.exv_id;
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_compare_experiment_variants
+-- Description: Per-variant latency, tokens, retrieval similarity, feedback and handoff rate
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_compare_experiment_variants(
+    p_exp_id INT,
+    p_start_date TIMESTAMP DEFAULT NULL,
+    p_end_date TIMESTAMP DEFAULT NULL
+)
+RETURNS TABLE (
+    variant_id INT,
+    variant_code VARCHAR(50),
+    variant_weight INT,
+    conversation_count BIGINT,
+    response_count BIGINT,
+    avg_response_time_ms DOUBLE PRECISION,
+    avg_llm_time_ms DOUBLE PRECISION,
+    avg_prompt_tokens DOUBLE PRECISION,
+    avg_completion_tokens DOUBLE PRECISION,
+    avg_total_tokens DOUBLE PRECISION,
+    avg_best_similarity DOUBLE PRECISION,
+    no_results_rate DOUBLE PRECISION,
+    positive_feedback BIGINT,
+    negative_feedback BIGINT,
+    satisfaction_rate DOUBLE PRECISION,
+    handoff_rate DOUBLE PRECISION
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RETURN QUERY
+    WITH responses AS (
+        SELECT
+            m.cvm_fk_variant,
+            m.cvm_fk_conversation,
+            m.cvm_total_time_ms,
+            m.cvm_prompt_tokens,
+            m.cvm_completion_tokens,
+            m.cvm_total_tokens,
+            m.cvm_feedback,
+            (m.cvm_metadata->>'responseTimeMs')::DOUBLE PRECISION AS response_time_ms,
+            (m.cvm_metadata->>'ragBestSimilarity')::DOUBLE PRECISION AS best_similarity,
+            (m.cvm_metadata->>'ragChunks')::INT AS rag_chunks
+        FROM cht_conversation_messages m
+        WHERE m.cvm_fk_variant IN (SELECT exv_id FROM cht_experiment_variants WHERE exv_fk_experiment = p_exp_id)
+          AND m.cvm_from_me = true
+          AND (p_start_date IS NULL OR m.cvm_created_at >= p_start_date)
+          AND (p_end_date IS NULL OR m.cvm_created_at <= p_end_date)
+    )
+    SELECT
+        v.exv_id,
+        v.exv_code,
+        v.exv_weight,
+        COUNT(DISTINCT r.cvm_fk_conversation),
+        COUNT(r.cvm_fk_conversation),
+        AVG(r.response_time_ms),
+        AVG(r.cvm_total_time_ms)::DOUBLE PRECISION,
+        AVG(r.cvm_prompt_tokens)::DOUBLE PRECISION,
+        AVG(r.cvm_completion_tokens)::DOUBLE PRECISION,
+        AVG(r.cvm_total_tokens)::DOUBLE PRECISION,
+        AVG(r.best_similarity),
+        (COUNT(*) FILTER (WHERE r.rag_chunks = 0))::DOUBLE PRECISION / NULLIF(COUNT(r.rag_chunks), 0),
+        COUNT(*) FILTER (WHERE r.cvm_feedback = 1),
+        COUNT(*) FILTER (WHERE r.cvm_feedback = -1),
+        (COUNT(*) FILTER (WHERE r.cvm_feedback = 1))::DOUBLE PRECISION / NULLIF(COUNT(r.cvm_feedback), 0),
+        (SELECT COUNT(*) FILTER (WHERE c.cnv_admin_intervened)::DOUBLE PRECISION / NULLIF(COUNT(*), 0)
+         FROM cht_conversations c
+         WHERE c.cnv_id IN (SELECT r2.cvm_fk_conversation FROM responses r2 WHERE r2.cvm_fk_variant = v.exv_id))
+    FROM cht_experiment_variants v
+    LEFT JOIN responses r ON r.cvm_fk_variant = v.exv_id
+    WHERE v.exv_fk_experiment = p_exp_id
+    GROUP BY v.exv_id, v.exv_code, v.exv_weight
+    ORDER BY v.exv_id;
+END;
+$$;
+
+-- =====================================================
+-- Error Codes
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_CREATE_EXPERIMENT') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_CREATE_EXPERIMENT', '{"message": "Error al crear el experimento"}'::jsonb, 'Error creating experiment');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EXPERIMENT_CODE_EXISTS') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EXPERIMENT_CODE_EXISTS', '{"message": "Ya existe un experimento con ese código"}'::jsonb, 'Experiment code already exists');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EXPERIMENT_VARIANTS_REQUIRED') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EXPERIMENT_VARIANTS_REQUIRED', '{"message": "El experimento debe tener al menos dos variantes"}'::jsonb, 'Experiment needs at least two variants');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EXPERIMENT_NOT_FOUND') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EXPERIMENT_NOT_FOUND', '{"message": "Experimento no encontrado"}'::jsonb, 'Experiment not found');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_UPDATE_EXPERIMENT') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_UPDATE_EXPERIMENT', '{"message": "Error al actualizar el experimento"}'::jsonb, 'Error updating experiment');
+    END IF;
+END $$;
+
+-- Comments
+COMMENT ON TABLE cht_experiments IS 'A/B experiments over prompt, model and retrieval settings';
+COMMENT ON TABLE cht_experiment_variants IS 'Experiment variants; chats are assigned by a stable hash of the chat ID over the weights';
+COMMENT ON COLUMN cht_experiment_variants.exv_config IS 'Overrides: promptVersion, promptCode, systemPrompt, model, temperature, searchLimit, keywordWeight';
+COMMENT ON COLUMN cht_conversation_messages.cvm_fk_variant IS 'Experiment variant that produced this bot message';
+COMMENT ON COLUMN cht_conversation_messages.cvm_feedback IS 'User feedback on a bot message: 1 positive, -1 negative';
+COMMENT ON PROCEDURE sp_create_conversation_message IS 'Create new message in conversation, tagged with the experiment variant when one applies';
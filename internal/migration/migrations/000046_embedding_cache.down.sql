@@ -0,0 +1,21 @@
+-- =====================================================
+-- Embedding Cache
+-- Migration: 000046_embedding_cache.down.sql
+-- =====================================================
+
+DROP PROCEDURE IF EXISTS sp_purge_embedding_cache(BOOLEAN, VARCHAR, INT, VARCHAR, INT);
+DROP FUNCTION IF EXISTS fn_get_embedding_cache_stats(INT);
+DROP PROCEDURE IF EXISTS sp_record_embedding_cache(BOOLEAN, VARCHAR, VARCHAR, TEXT[], TEXT[], VECTOR[]);
+DROP FUNCTION IF EXISTS fn_get_cached_embeddings(VARCHAR, TEXT[]);
+
+DROP INDEX IF EXISTS idx_embedding_cache_last_used;
+
+DROP TABLE IF EXISTS cht_embedding_cache_stats;
+DROP TABLE IF EXISTS cht_embedding_cache;
+
+DELETE FROM cht_parameters WHERE prm_code IN (
+    'EMBEDDING_CACHE_CONFIG',
+    'ERR_EMBEDDING_CACHE_MISMATCH',
+    'ERR_EMBEDDING_CACHE_WRITE',
+    'ERR_EMBEDDING_CACHE_PURGE'
+);
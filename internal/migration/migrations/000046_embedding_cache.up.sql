@@ -0,0 +1,238 @@
+-- =====================================================
+-- Embedding Cache
+-- Migration: 000046_embedding_cache.up.sql
+-- Purpose: Persist embeddings by (model, normalised-text hash) so text embedded
+--          before (re-uploaded documents, repeated queries) is not paid for again
+-- =====================================================
+
+-- =====================================================
+-- Table: cht_embedding_cache
+-- Description: Cached embedding vectors. The vector column has no fixed size so
+--              entries for models with different dimensions can coexist.
+-- =====================================================
+CREATE TABLE IF NOT EXISTS public.cht_embedding_cache (
+    emc_id              BIGSERIAL PRIMARY KEY,
+    emc_model           VARCHAR(100) NOT NULL,
+    emc_text_hash       CHAR(64) NOT NULL,
+    emc_embedding       VECTOR NOT NULL,
+    emc_hit_count       INT NOT NULL DEFAULT 0,
+    emc_created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    emc_last_used_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_embedding_cache_model_hash UNIQUE (emc_model, emc_text_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_embedding_cache_last_used ON cht_embedding_cache(emc_last_used_at);
+
+-- =====================================================
+-- Table: cht_embedding_cache_stats
+-- Description: Daily hit/miss counters per model
+-- =====================================================
+CREATE TABLE IF NOT EXISTS public.cht_embedding_cache_stats (
+    ecs_date            DATE NOT NULL DEFAULT CURRENT_DATE,
+    ecs_model           VARCHAR(100) NOT NULL,
+    ecs_hits            BIGINT NOT NULL DEFAULT 0,
+    ecs_misses          BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (ecs_date, ecs_model)
+);
+
+-- =====================================================
+-- Function: fn_get_cached_embeddings
+-- Description: Look up cached vectors for a batch of text hashes
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_cached_embeddings(
+    p_model VARCHAR,
+    p_text_hashes TEXT[]
+)
+RETURNS TABLE (
+    emc_text_hash CHAR(64),
+    emc_embedding VECTOR
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RETURN QUERY
+    SELECT c.emc_text_hash, c.emc_embedding
+    FROM cht_embedding_cache c
+    WHERE c.emc_model = p_model
+      AND c.emc_text_hash = ANY(p_text_hashes);
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_record_embedding_cache
+-- Description: Store newly generated vectors, touch the entries that were hit
+--              and add the lookup to the daily hit/miss counters
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_record_embedding_cache(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_model VARCHAR,
+    IN p_hit_hashes TEXT[],
+    IN p_miss_hashes TEXT[],
+    IN p_miss_embeddings VECTOR[]
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_hits INT := COALESCE(array_length(p_hit_hashes, 1), 0);
+    v_misses INT := COALESCE(array_length(p_miss_hashes, 1), 0);
+BEGIN
+    success := true;
+    code := 'OK';
+
+    IF v_misses <> COALESCE(array_length(p_miss_embeddings, 1), 0) THEN
+        success := false;
+        code := 'ERR_EMBEDDING_CACHE_MISMATCH';
+        RETURN;
+    END IF;
+
+    IF v_hits > 0 THEN
+        UPDATE cht_embedding_cache
+        SET emc_hit_count = emc_hit_count + 1,
+            emc_last_used_at = CURRENT_TIMESTAMP
+        WHERE emc_model = p_model
+          AND emc_text_hash = ANY(p_hit_hashes);
+    END IF;
+
+    IF v_misses > 0 THEN
+        INSERT INTO cht_embedding_cache (emc_model, emc_text_hash, emc_embedding)
+        SELECT p_model, h.hash, h.embedding
+        FROM unnest(p_miss_hashes, p_miss_embeddings) AS h(hash, embedding)
+        ON CONFLICT (emc_model, emc_text_hash) DO UPDATE
+        SET emc_last_used_at = CURRENT_TIMESTAMP;
+    END IF;
+
+    INSERT INTO cht_embedding_cache_stats (ecs_date, ecs_model, ecs_hits, ecs_misses)
+    VALUES (CURRENT_DATE, p_model, v_hits, v_misses)
+    ON CONFLICT (ecs_date, ecs_model) DO UPDATE
+    SET ecs_hits = cht_embedding_cache_stats.ecs_hits + EXCLUDED.ecs_hits,
+        ecs_misses = cht_embedding_cache_stats.ecs_misses + EXCLUDED.ecs_misses;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_EMBEDDING_CACHE_WRITE';
+        RAISE NOTICE 'Error recording embedding cache: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_get_embedding_cache_stats
+-- Description: Entries and hit rate per model over the last p_days days
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_embedding_cache_stats(
+    p_days INT DEFAULT 30
+)
+RETURNS TABLE (
+    model VARCHAR(100),
+    entries BIGINT,
+    hits BIGINT,
+    misses BIGINT,
+    hit_rate DOUBLE PRECISION,
+    oldest_entry_at TIMESTAMP,
+    last_used_at TIMESTAMP
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RETURN QUERY
+    WITH cache_models AS (
+        SELECT c.emc_model AS m FROM cht_embedding_cache c
+        UNION
+        SELECT s.ecs_model FROM cht_embedding_cache_stats s
+        WHERE s.ecs_date > CURRENT_DATE - p_days
+    ),
+    cache_entries AS (
+        SELECT c.emc_model AS m, COUNT(*) AS cnt, MIN(c.emc_created_at) AS oldest, MAX(c.emc_last_used_at) AS last_used
+        FROM cht_embedding_cache c
+        GROUP BY c.emc_model
+    ),
+    cache_usage AS (
+        SELECT s.ecs_model AS m, SUM(s.ecs_hits)::BIGINT AS h, SUM(s.ecs_misses)::BIGINT AS ms
+        FROM cht_embedding_cache_stats s
+        WHERE s.ecs_date > CURRENT_DATE - p_days
+        GROUP BY s.ecs_model
+    )
+    SELECT
+        cache_models.m,
+        COALESCE(e.cnt, 0),
+        COALESCE(u.h, 0),
+        COALESCE(u.ms, 0),
+        u.h::DOUBLE PRECISION / NULLIF(u.h + u.ms, 0),
+        e.oldest,
+        e.last_used
+    FROM cache_models
+    LEFT JOIN cache_entries e ON e.m = cache_models.m
+    LEFT JOIN cache_usage u ON u.m = cache_models.m
+    ORDER BY cache_models.m;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_purge_embedding_cache
+-- Description: Delete cached vectors, optionally only for one model and/or
+--              entries not used in the last p_unused_days days
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_purge_embedding_cache(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_deleted INT,
+    IN p_model VARCHAR DEFAULT NULL,
+    IN p_unused_days INT DEFAULT NULL
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := true;
+    code := 'OK';
+
+    DELETE FROM cht_embedding_cache
+    WHERE (p_model IS NULL OR emc_model = p_model)
+      AND (p_unused_days IS NULL OR emc_last_used_at < CURRENT_TIMESTAMP - make_interval(days => p_unused_days));
+
+    GET DIAGNOSTICS o_deleted = ROW_COUNT;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_EMBEDDING_CACHE_PURGE';
+        o_deleted := 0;
+        RAISE NOTICE 'Error purging embedding cache: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Parameters
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'EMBEDDING_CACHE_CONFIG') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES (
+            'EMBEDDING',
+            'EMBEDDING_CACHE_CONFIG',
+            '{"enabled": true}'::jsonb,
+            'Persistent embedding cache keyed by (model, normalised-text hash)'
+        );
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EMBEDDING_CACHE_MISMATCH') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EMBEDDING_CACHE_MISMATCH', '{"message": "La cantidad de textos y embeddings no coincide"}'::jsonb, 'Embedding cache hashes/vectors mismatch');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EMBEDDING_CACHE_WRITE') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EMBEDDING_CACHE_WRITE', '{"message": "Error al guardar en la caché de embeddings"}'::jsonb, 'Error writing embedding cache');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EMBEDDING_CACHE_PURGE') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EMBEDDING_CACHE_PURGE', '{"message": "Error al limpiar la caché de embeddings"}'::jsonb, 'Error purging embedding cache');
+    END IF;
+END $$;
+
+-- Comments
+COMMENT ON TABLE cht_embedding_cache IS 'Embedding vectors keyed by (model, SHA-256 of the normalised text)';
+COMMENT ON COLUMN cht_embedding_cache.emc_text_hash IS 'SHA-256 (hex) of the text after Unicode NFC and whitespace normalisation';
+COMMENT ON TABLE cht_embedding_cache_stats IS 'Daily embedding cache hits and misses per model';
@@ -0,0 +1,36 @@
+-- =====================================================
+-- Embedding Model Migration
+-- Migration: 000047_embedding_migration.down.sql
+-- =====================================================
+
+DROP PROCEDURE IF EXISTS sp_swap_embedding_set(BOOLEAN, VARCHAR, INT);
+DROP FUNCTION IF EXISTS fn_get_embedding_jobs(INT, INT);
+DROP PROCEDURE IF EXISTS sp_update_embedding_job_status(BOOLEAN, VARCHAR, INT, VARCHAR, INT, TEXT);
+DROP PROCEDURE IF EXISTS sp_store_chunk_embeddings_next(BOOLEAN, VARCHAR, INT, INT, INT[], TEXT[], VECTOR[]);
+DROP FUNCTION IF EXISTS fn_get_chunks_pending_reembedding(INT, INT);
+DROP PROCEDURE IF EXISTS sp_create_embedding_job(BOOLEAN, VARCHAR, INT, VARCHAR, VARCHAR, INT, INT, BOOLEAN);
+
+DROP INDEX IF EXISTS idx_embedding_jobs_status;
+DROP TABLE IF EXISTS cht_embedding_jobs;
+
+DROP TRIGGER IF EXISTS trg_reset_chunk_embedding_next ON cht_chunks;
+DROP FUNCTION IF EXISTS fn_reset_chunk_embedding_next();
+
+ALTER TABLE cht_chunks DROP COLUMN IF EXISTS chk_embedding_next;
+
+DELETE FROM cht_parameters WHERE prm_code IN (
+    'ERR_EMBEDDING_JOB_ACTIVE',
+    'ERR_CREATE_EMBEDDING_JOB',
+    'ERR_EMBEDDING_JOB_NOT_FOUND',
+    'ERR_EMBEDDING_JOB_NOT_RUNNING',
+    'ERR_EMBEDDING_JOB_FINISHED',
+    'ERR_EMBEDDING_JOB_NOT_READY',
+    'ERR_EMBEDDING_JOB_PENDING_CHUNKS',
+    'ERR_EMBEDDING_DIMENSION_MISMATCH',
+    'ERR_EMBEDDING_CONFIG_NOT_FOUND',
+    'ERR_EMBEDDING_CONFIG_INVALID',
+    'ERR_EMBEDDING_JOB_NOT_PAUSED',
+    'ERR_STORE_EMBEDDINGS_NEXT',
+    'ERR_UPDATE_EMBEDDING_JOB',
+    'ERR_EMBEDDING_SWAP'
+);
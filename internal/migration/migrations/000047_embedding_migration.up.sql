@@ -0,0 +1,473 @@
+-- =====================================================
+-- Embedding Model Migration
+-- Migration: 000047_embedding_migration.up.sql
+-- Purpose: Re-embed all chunks with a new embedding model into a shadow column
+--          while searches keep using chk_embedding, then swap atomically
+-- =====================================================
+
+-- =====================================================
+-- Shadow column: chk_embedding_next
+-- Untyped VECTOR so the target model may have a different dimension
+-- =====================================================
+ALTER TABLE cht_chunks ADD COLUMN IF NOT EXISTS chk_embedding_next VECTOR;
+
+-- Editing a chunk during a migration invalidates its shadow embedding
+CREATE OR REPLACE FUNCTION fn_reset_chunk_embedding_next()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.chk_content IS DISTINCT FROM OLD.chk_content THEN
+        NEW.chk_embedding_next := NULL;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_reset_chunk_embedding_next ON cht_chunks;
+CREATE TRIGGER trg_reset_chunk_embedding_next
+    BEFORE UPDATE OF chk_content ON cht_chunks
+    FOR EACH ROW
+    EXECUTE FUNCTION fn_reset_chunk_embedding_next();
+
+-- =====================================================
+-- Table: cht_embedding_jobs
+-- Description: Re-embedding jobs. At most one job is open (running, paused or completed) at a time;
+--              swapped and cancelled jobs are finished.
+-- =====================================================
+CREATE TABLE IF NOT EXISTS public.cht_embedding_jobs (
+    ejb_id                  SERIAL PRIMARY KEY,
+    ejb_status              VARCHAR(20) NOT NULL DEFAULT 'running'
+                            CHECK (ejb_status IN ('running', 'paused', 'completed', 'swapped', 'cancelled')),
+    ejb_config_code         VARCHAR(100) NOT NULL,
+    ejb_target_model        VARCHAR(100) NOT NULL,
+    ejb_target_dimensions   INT NOT NULL CHECK (ejb_target_dimensions > 0),
+    ejb_batch_size          INT NOT NULL DEFAULT 100,
+    ejb_auto_swap           BOOLEAN NOT NULL DEFAULT false,
+    ejb_total_chunks        INT NOT NULL DEFAULT 0,
+    ejb_processed_chunks    INT NOT NULL DEFAULT 0,
+    ejb_failed_chunks       INT NOT NULL DEFAULT 0,
+    ejb_last_error          TEXT,
+    ejb_started_at          TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ejb_completed_at        TIMESTAMP,
+    ejb_swapped_at          TIMESTAMP,
+    ejb_updated_at          TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_embedding_jobs_status ON cht_embedding_jobs(ejb_status);
+
+-- =====================================================
+-- Stored Procedure: sp_create_embedding_job
+-- Description: Open a re-embedding job and clear any stale shadow embeddings
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_create_embedding_job(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_ejb_id INT,
+    IN p_config_code VARCHAR,
+    IN p_target_model VARCHAR,
+    IN p_target_dimensions INT,
+    IN p_batch_size INT DEFAULT 100,
+    IN p_auto_swap BOOLEAN DEFAULT false
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := true;
+    code := 'OK';
+
+    IF EXISTS (SELECT 1 FROM cht_embedding_jobs WHERE ejb_status IN ('running', 'paused', 'completed')) THEN
+        success := false;
+        code := 'ERR_EMBEDDING_JOB_ACTIVE';
+        o_ejb_id := NULL;
+        RETURN;
+    END IF;
+
+    UPDATE cht_chunks SET chk_embedding_next = NULL WHERE chk_embedding_next IS NOT NULL;
+
+    INSERT INTO cht_embedding_jobs (
+        ejb_config_code, ejb_target_model, ejb_target_dimensions, ejb_batch_size,
+        ejb_auto_swap, ejb_total_chunks
+    ) VALUES (
+        p_config_code, p_target_model, p_target_dimensions, COALESCE(p_batch_size, 100),
+        COALESCE(p_auto_swap, false), (SELECT COUNT(*) FROM cht_chunks)
+    )
+    RETURNING ejb_id INTO o_ejb_id;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_CREATE_EMBEDDING_JOB';
+        o_ejb_id := NULL;
+        RAISE NOTICE 'Error creating embedding job: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_get_chunks_pending_reembedding
+-- Description: Next batch of chunks without a shadow embedding, after a chunk ID cursor
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_chunks_pending_reembedding(
+    p_after_id INT,
+    p_limit INT
+)
+RETURNS TABLE (
+    chk_id INT,
+    chk_content TEXT
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RETURN QUERY
+    SELECT c.chk_id, c.chk_content
+    FROM cht_chunks c
+    WHERE c.chk_embedding_next IS NULL
+      AND c.chk_id > p_after_id
+    ORDER BY c.chk_id
+    LIMIT p_limit;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_store_chunk_embeddings_next
+-- Description: Store shadow embeddings for a batch. Chunks whose content changed
+--              since they were read (md5 mismatch) are skipped and picked up again.
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_store_chunk_embeddings_next(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_updated INT,
+    IN p_ejb_id INT,
+    IN p_chunk_ids INT[],
+    IN p_content_hashes TEXT[],
+    IN p_embeddings VECTOR[]
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := true;
+    code := 'OK';
+
+    IF NOT EXISTS (SELECT 1 FROM cht_embedding_jobs WHERE ejb_id = p_ejb_id AND ejb_status = 'running') THEN
+        success := false;
+        code := 'ERR_EMBEDDING_JOB_NOT_RUNNING';
+        o_updated := 0;
+        RETURN;
+    END IF;
+
+    UPDATE cht_chunks c
+    SET chk_embedding_next = u.embedding
+    FROM unnest(p_chunk_ids, p_content_hashes, p_embeddings) AS u(chunk_id, content_hash, embedding)
+    WHERE c.chk_id = u.chunk_id
+      AND md5(c.chk_content) = u.content_hash;
+
+    GET DIAGNOSTICS o_updated = ROW_COUNT;
+
+    UPDATE cht_embedding_jobs
+    SET ejb_processed_chunks = (SELECT COUNT(*) FROM cht_chunks WHERE chk_embedding_next IS NOT NULL),
+        ejb_total_chunks = (SELECT COUNT(*) FROM cht_chunks),
+        ejb_updated_at = CURRENT_TIMESTAMP
+    WHERE ejb_id = p_ejb_id;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_STORE_EMBEDDINGS_NEXT';
+        o_updated := 0;
+        RAISE NOTICE 'Error storing shadow embeddings: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_update_embedding_job_status
+-- Description: Pause, resume, cancel or complete a job.
+--              Errors pause the job with ejb_last_error so it can be resumed.
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_update_embedding_job_status(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_ejb_id INT,
+    IN p_status VARCHAR,
+    IN p_failed_chunks INT DEFAULT NULL,
+    IN p_error TEXT DEFAULT NULL
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_current VARCHAR(20);
+BEGIN
+    success := true;
+    code := 'OK';
+
+    SELECT ejb_status INTO v_current FROM cht_embedding_jobs WHERE ejb_id = p_ejb_id;
+
+    IF v_current IS NULL THEN
+        success := false;
+        code := 'ERR_EMBEDDING_JOB_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    IF v_current IN ('swapped', 'cancelled') THEN
+        success := false;
+        code := 'ERR_EMBEDDING_JOB_FINISHED';
+        RETURN;
+    END IF;
+
+    UPDATE cht_embedding_jobs
+    SET ejb_status = p_status,
+        ejb_failed_chunks = COALESCE(p_failed_chunks, ejb_failed_chunks),
+        ejb_last_error = COALESCE(p_error, ejb_last_error),
+        ejb_completed_at = CASE WHEN p_status = 'completed' THEN CURRENT_TIMESTAMP ELSE ejb_completed_at END,
+        ejb_updated_at = CURRENT_TIMESTAMP
+    WHERE ejb_id = p_ejb_id;
+
+    -- A cancelled migration leaves no shadow embeddings behind
+    IF p_status = 'cancelled' THEN
+        UPDATE cht_chunks SET chk_embedding_next = NULL WHERE chk_embedding_next IS NOT NULL;
+    END IF;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_UPDATE_EMBEDDING_JOB';
+        RAISE NOTICE 'Error updating embedding job: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_get_embedding_jobs
+-- Description: Job progress with live chunk counts (latest first)
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_embedding_jobs(
+    p_ejb_id INT DEFAULT NULL,
+    p_limit INT DEFAULT 20
+)
+RETURNS TABLE (
+    ejb_id INT,
+    ejb_status VARCHAR(20),
+    ejb_config_code VARCHAR(100),
+    ejb_target_model VARCHAR(100),
+    ejb_target_dimensions INT,
+    ejb_batch_size INT,
+    ejb_auto_swap BOOLEAN,
+    ejb_total_chunks INT,
+    ejb_processed_chunks INT,
+    ejb_pending_chunks INT,
+    ejb_progress_percent DOUBLE PRECISION,
+    ejb_failed_chunks INT,
+    ejb_last_error TEXT,
+    ejb_started_at TIMESTAMP,
+    ejb_completed_at TIMESTAMP,
+    ejb_swapped_at TIMESTAMP,
+    ejb_updated_at TIMESTAMP
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_total INT;
+    v_embedded INT;
+BEGIN
+    SELECT COUNT(*), COUNT(c.chk_embedding_next)
+    INTO v_total, v_embedded
+    FROM cht_chunks c;
+
+    RETURN QUERY
+    SELECT
+        j.ejb_id,
+        j.ejb_status,
+        j.ejb_config_code,
+        j.ejb_target_model,
+        j.ejb_target_dimensions,
+        j.ejb_batch_size,
+        j.ejb_auto_swap,
+        -- Open jobs report live counts; finished jobs keep their last snapshot
+        CASE WHEN j.ejb_status IN ('running', 'paused', 'completed') THEN v_total ELSE j.ejb_total_chunks END,
+        CASE WHEN j.ejb_status IN ('running', 'paused', 'completed') THEN v_embedded ELSE j.ejb_processed_chunks END,
+        CASE WHEN j.ejb_status IN ('running', 'paused', 'completed') THEN v_total - v_embedded ELSE j.ejb_total_chunks - j.ejb_processed_chunks END,
+        CASE WHEN j.ejb_status IN ('running', 'paused', 'completed')
+             THEN COALESCE(v_embedded::DOUBLE PRECISION * 100 / NULLIF(v_total, 0), 100)
+             ELSE COALESCE(j.ejb_processed_chunks::DOUBLE PRECISION * 100 / NULLIF(j.ejb_total_chunks, 0), 100)
+        END,
+        j.ejb_failed_chunks,
+        j.ejb_last_error,
+        j.ejb_started_at,
+        j.ejb_completed_at,
+        j.ejb_swapped_at,
+        j.ejb_updated_at
+    FROM cht_embedding_jobs j
+    WHERE p_ejb_id IS NULL OR j.ejb_id = p_ejb_id
+    ORDER BY j.ejb_id DESC
+    LIMIT p_limit;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_swap_embedding_set
+-- Description: Atomically make the shadow embeddings active:
+--   replace chk_embedding, rebuild the IVFFLAT index with the new dimension
+--   and copy the job's configuration into EMBEDDING_CONFIG.
+--   Everything runs in the CALL transaction, so a failure leaves the old set in place.
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_swap_embedding_set(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_ejb_id INT
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_job cht_embedding_jobs%ROWTYPE;
+    v_pending INT;
+    v_wrong_dims INT;
+BEGIN
+    success := true;
+    code := 'OK';
+
+    SELECT * INTO v_job FROM cht_embedding_jobs WHERE ejb_id = p_ejb_id;
+
+    IF v_job.ejb_id IS NULL THEN
+        success := false;
+        code := 'ERR_EMBEDDING_JOB_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    IF v_job.ejb_status NOT IN ('running', 'completed') THEN
+        success := false;
+        code := 'ERR_EMBEDDING_JOB_NOT_READY';
+        RETURN;
+    END IF;
+
+    -- Block chunk writes until the swap commits; searches wait only for the swap itself
+    LOCK TABLE cht_chunks IN SHARE ROW EXCLUSIVE MODE;
+
+    SELECT COUNT(*) FILTER (WHERE chk_embedding_next IS NULL),
+           COUNT(*) FILTER (WHERE chk_embedding_next IS NOT NULL AND vector_dims(chk_embedding_next) <> v_job.ejb_target_dimensions)
+    INTO v_pending, v_wrong_dims
+    FROM cht_chunks;
+
+    IF v_pending > 0 THEN
+        success := false;
+        code := 'ERR_EMBEDDING_JOB_PENDING_CHUNKS';
+        RETURN;
+    END IF;
+
+    IF v_wrong_dims > 0 THEN
+        success := false;
+        code := 'ERR_EMBEDDING_DIMENSION_MISMATCH';
+        RETURN;
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = v_job.ejb_config_code) THEN
+        success := false;
+        code := 'ERR_EMBEDDING_CONFIG_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    DROP INDEX IF EXISTS idx_cht_chunks_embedding;
+    ALTER TABLE cht_chunks DROP COLUMN chk_embedding;
+    ALTER TABLE cht_chunks RENAME COLUMN chk_embedding_next TO chk_embedding;
+    EXECUTE format('ALTER TABLE cht_chunks ALTER COLUMN chk_embedding TYPE VECTOR(%s)', v_job.ejb_target_dimensions);
+    CREATE INDEX idx_cht_chunks_embedding ON cht_chunks USING IVFFLAT(chk_embedding vector_cosine_ops) WITH (lists = 100);
+    ALTER TABLE cht_chunks ADD COLUMN chk_embedding_next VECTOR;
+
+    UPDATE cht_parameters
+    SET prm_data = (SELECT p.prm_data FROM cht_parameters p WHERE p.prm_code = v_job.ejb_config_code),
+        prm_updated_at = CURRENT_TIMESTAMP
+    WHERE prm_code = 'EMBEDDING_CONFIG';
+
+    UPDATE cht_embedding_jobs
+    SET ejb_status = 'swapped',
+        ejb_total_chunks = (SELECT COUNT(*) FROM cht_chunks),
+        ejb_processed_chunks = (SELECT COUNT(*) FROM cht_chunks),
+        ejb_completed_at = COALESCE(ejb_completed_at, CURRENT_TIMESTAMP),
+        ejb_swapped_at = CURRENT_TIMESTAMP,
+        ejb_updated_at = CURRENT_TIMESTAMP
+    WHERE ejb_id = p_ejb_id;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_EMBEDDING_SWAP';
+        RAISE NOTICE 'Error swapping embedding set: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Parameters
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EMBEDDING_JOB_ACTIVE') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EMBEDDING_JOB_ACTIVE', '{"message": "Ya existe una migración de embeddings en curso"}'::jsonb, 'An embedding migration job is already open');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_CREATE_EMBEDDING_JOB') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_CREATE_EMBEDDING_JOB', '{"message": "Error al crear la migración de embeddings"}'::jsonb, 'Error creating embedding job');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EMBEDDING_JOB_NOT_FOUND') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EMBEDDING_JOB_NOT_FOUND', '{"message": "Migración de embeddings no encontrada"}'::jsonb, 'Embedding job not found');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EMBEDDING_JOB_NOT_RUNNING') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EMBEDDING_JOB_NOT_RUNNING', '{"message": "La migración de embeddings no está en ejecución"}'::jsonb, 'Embedding job is not running');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EMBEDDING_JOB_FINISHED') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EMBEDDING_JOB_FINISHED', '{"message": "La migración de embeddings ya finalizó"}'::jsonb, 'Embedding job already finished');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EMBEDDING_JOB_NOT_READY') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EMBEDDING_JOB_NOT_READY', '{"message": "La migración de embeddings no está lista para activarse"}'::jsonb, 'Embedding job is not ready to swap');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EMBEDDING_JOB_PENDING_CHUNKS') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EMBEDDING_JOB_PENDING_CHUNKS', '{"message": "Aún hay fragmentos sin el nuevo embedding"}'::jsonb, 'Chunks still pending re-embedding');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EMBEDDING_DIMENSION_MISMATCH') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EMBEDDING_DIMENSION_MISMATCH', '{"message": "Las dimensiones de los embeddings no coinciden con la configuración"}'::jsonb, 'Shadow embeddings have unexpected dimensions');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EMBEDDING_CONFIG_NOT_FOUND') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EMBEDDING_CONFIG_NOT_FOUND', '{"message": "Configuración de embeddings no encontrada"}'::jsonb, 'Target embedding configuration parameter not found');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EMBEDDING_CONFIG_INVALID') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EMBEDDING_CONFIG_INVALID', '{"message": "La configuración de embeddings de destino no es válida"}'::jsonb, 'Target embedding configuration failed to produce an embedding');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EMBEDDING_JOB_NOT_PAUSED') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EMBEDDING_JOB_NOT_PAUSED', '{"message": "La migración de embeddings no está en pausa"}'::jsonb, 'Embedding job is not paused');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_STORE_EMBEDDINGS_NEXT') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_STORE_EMBEDDINGS_NEXT', '{"message": "Error al guardar los nuevos embeddings"}'::jsonb, 'Error storing shadow embeddings');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_UPDATE_EMBEDDING_JOB') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_UPDATE_EMBEDDING_JOB', '{"message": "Error al actualizar la migración de embeddings"}'::jsonb, 'Error updating embedding job');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EMBEDDING_SWAP') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EMBEDDING_SWAP', '{"message": "Error al activar los nuevos embeddings"}'::jsonb, 'Error swapping embedding set');
+    END IF;
+END $$;
+
+-- Comments
+COMMENT ON COLUMN cht_chunks.chk_embedding_next IS 'Shadow embedding written by a re-embedding job; becomes chk_embedding on swap';
+COMMENT ON TABLE cht_embedding_jobs IS 'Resumable re-embedding jobs for embedding model migrations';
+COMMENT ON COLUMN cht_embedding_jobs.ejb_config_code IS 'Parameter holding the target embedding configuration (same shape as EMBEDDING_CONFIG)';
+COMMENT ON PROCEDURE sp_swap_embedding_set IS 'Atomically activate shadow embeddings, rebuild the vector index and switch EMBEDDING_CONFIG';
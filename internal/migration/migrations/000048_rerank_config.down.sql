@@ -0,0 +1,6 @@
+-- =====================================================
+-- Reranking Stage
+-- Migration: 000048_rerank_config.down.sql
+-- =====================================================
+
+DELETE FROM cht_parameters WHERE prm_code = 'RERANK_CONFIG';
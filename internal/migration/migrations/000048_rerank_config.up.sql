@@ -0,0 +1,24 @@
+-- =====================================================
+-- Reranking Stage
+-- Migration: 000048_rerank_config.up.sql
+-- Purpose: Configuration for the optional reranking stage after hybrid search
+-- =====================================================
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'RERANK_CONFIG') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES (
+            'RAG_CONFIGURATION',
+            'RERANK_CONFIG',
+            '{
+                "enabled": false,
+                "provider": "llm",
+                "candidates": 20,
+                "llm": {"model": "", "maxChars": 1500},
+                "crossEncoder": {"url": "", "apiKey": "", "model": ""}
+            }'::jsonb,
+            'Reranking after hybrid search: over-fetch "candidates", rescore with "llm" or "cross-encoder" and keep the requested top-k. Optional "minScore" drops low-scored candidates.'
+        );
+    END IF;
+END $$;
@@ -0,0 +1,133 @@
+-- =====================================================
+-- Hybrid Search Fusion Strategies
+-- Migration: 000049_hybrid_search_fusion.up.sql
+-- Purpose: Selectable fusion of semantic and keyword rankings (weighted or
+--          Reciprocal Rank Fusion) and optional embeddings for the MMR pass
+-- =====================================================
+
+DROP FUNCTION IF EXISTS fn_similarity_search_chunks_hybrid(vector, text, int, float, float, varchar);
+
+-- =====================================================
+-- Function: fn_similarity_search_chunks_hybrid
+-- Description: combined_score keeps the weighted semantic/keyword score in both
+--              modes; results are ordered by fusion_score, which is the weighted
+--              score or, with p_fusion = 'rrf', 1/(k + semantic rank) + 1/(k + keyword rank)
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_similarity_search_chunks_hybrid(
+    p_query_embedding vector,
+    p_query_text text,
+    p_limit int default 5,
+    p_min_similarity float default 0.2,
+    p_keyword_weight float default 0.15,
+    p_category varchar default null,
+    p_fusion varchar default 'weighted',
+    p_rrf_k int default 60,
+    p_with_embeddings boolean default false
+)
+RETURNS TABLE (
+    chk_id int,
+    chk_fk_document int,
+    chk_content text,
+    similarity_score float,
+    keyword_score float,
+    combined_score float,
+    fusion_score float,
+    doc_title varchar,
+    doc_category varchar,
+    chk_embedding vector
+) AS $$
+DECLARE
+    v_tsquery tsquery;
+BEGIN
+    v_tsquery := plainto_tsquery('spanish', p_query_text);
+
+    RETURN QUERY
+    WITH candidate_chunks AS (
+        SELECT
+            c.chk_id,
+            c.chk_fk_document,
+            c.chk_content,
+            c.chk_embedding,
+            (1 - (c.chk_embedding <=> p_query_embedding)) as semantic_score,
+            ts_rank(c.chk_fts_vector, v_tsquery)::double precision as keyword_rank,
+            (c.chk_fts_vector @@ v_tsquery) as keyword_match,
+            d.doc_title,
+            d.doc_category
+        FROM public.cht_chunks c
+        INNER JOIN public.cht_documents d ON c.chk_fk_document = d.doc_id
+        WHERE d.doc_active = true
+          AND c.chk_embedding IS NOT NULL
+          AND (p_category IS NULL OR p_category = '' OR d.doc_category = p_category)
+          AND ((1 - (c.chk_embedding <=> p_query_embedding)) >= p_min_similarity
+               OR c.chk_fts_vector @@ v_tsquery)
+    ),
+    ranked_chunks AS (
+        SELECT
+            cc.*,
+            (cc.semantic_score * (1 - p_keyword_weight)) + (cc.keyword_rank * p_keyword_weight) as weighted_score,
+            ROW_NUMBER() OVER (ORDER BY cc.semantic_score DESC) as semantic_position,
+            -- Only chunks matching the full-text query take part in the keyword ranking
+            CASE WHEN cc.keyword_match
+                 THEN ROW_NUMBER() OVER (PARTITION BY cc.keyword_match ORDER BY cc.keyword_rank DESC)
+            END as keyword_position
+        FROM candidate_chunks cc
+    ),
+    fused_chunks AS (
+        SELECT
+            rc.*,
+            CASE WHEN p_fusion = 'rrf'
+                 THEN 1.0 / (p_rrf_k + rc.semantic_position)
+                      + COALESCE(1.0 / (p_rrf_k + rc.keyword_position), 0)
+                 ELSE rc.weighted_score
+            END::double precision as fused
+        FROM ranked_chunks rc
+    )
+    SELECT
+        fc.chk_id,
+        fc.chk_fk_document,
+        fc.chk_content,
+        fc.semantic_score,
+        fc.keyword_rank,
+        fc.weighted_score,
+        fc.fused,
+        fc.doc_title,
+        fc.doc_category,
+        CASE WHEN p_with_embeddings THEN fc.chk_embedding END
+    FROM fused_chunks fc
+    ORDER BY fc.fused DESC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- =====================================================
+-- Parameters
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'RAG_FUSION_STRATEGY') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('RAG_CONFIGURATION', 'RAG_FUSION_STRATEGY', '{"value": "weighted"}'::jsonb, 'Hybrid search fusion: "weighted" (RAG_KEYWORD_WEIGHT mix) or "rrf" (Reciprocal Rank Fusion)');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'RAG_RRF_K') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('RAG_CONFIGURATION', 'RAG_RRF_K', '{"value": 60}'::jsonb, 'Rank constant k for Reciprocal Rank Fusion');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'RAG_MMR_ENABLED') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('RAG_CONFIGURATION', 'RAG_MMR_ENABLED', '{"value": false}'::jsonb, 'Apply a Maximal Marginal Relevance diversity pass to hybrid search results');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'RAG_MMR_LAMBDA') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('RAG_CONFIGURATION', 'RAG_MMR_LAMBDA', '{"value": 0.7}'::jsonb, 'MMR trade-off between relevance (1.0) and diversity (0.0)');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'RAG_MAX_CHUNKS_PER_DOCUMENT') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('RAG_CONFIGURATION', 'RAG_MAX_CHUNKS_PER_DOCUMENT', '{"value": 0}'::jsonb, 'Maximum chunks from the same document in search results (0 = no cap)');
+    END IF;
+END $$;
+
+COMMENT ON FUNCTION fn_similarity_search_chunks_hybrid(vector, text, int, float, float, varchar, varchar, int, boolean) IS 'Hybrid semantic + full-text search with weighted or RRF fusion';
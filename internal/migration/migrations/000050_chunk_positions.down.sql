@@ -0,0 +1,222 @@
+-- =====================================================
+-- Chunk Ordinal Positions
+-- Migration: 000050_chunk_positions.down.sql
+-- =====================================================
+
+DROP FUNCTION IF EXISTS fn_get_chunk_neighbors(int[], int);
+DROP FUNCTION IF EXISTS fn_get_chunks_by_document(int);
+DROP FUNCTION IF EXISTS fn_get_chunk_by_id(int);
+
+-- Restore the versions from 000005
+-- =====================================================
+-- Function: fn_get_chunks_by_document
+-- Description: Get all chunks for a specific document
+-- =====================================================
+create or replace function fn_get_chunks_by_document(
+    p_doc_id int
+)
+returns table (
+    chk_id int,
+    chk_fk_document int,
+    chk_content text,
+    chk_created_at timestamp,
+    chk_updated_at timestamp
+) as $$
+begin
+    return query
+    select
+        c.chk_id,
+        c.chk_fk_document,
+        c.chk_content,
+        c.chk_created_at,
+        c.chk_updated_at
+    from public.cht_chunks c
+    where c.chk_fk_document = p_doc_id
+    order by c.chk_id;
+end;
+$$ language plpgsql;
+
+-- =====================================================
+-- Function: fn_get_chunk_by_id
+-- Description: Get specific chunk by ID with embedding
+-- =====================================================
+create or replace function fn_get_chunk_by_id(
+    p_chk_id int
+)
+returns table (
+    chk_id int,
+    chk_fk_document int,
+    chk_content text,
+    chk_embedding vector(1536),
+    chk_created_at timestamp,
+    chk_updated_at timestamp
+) as $$
+begin
+    return query
+    select
+        c.chk_id,
+        c.chk_fk_document,
+        c.chk_content,
+        c.chk_embedding,
+        c.chk_created_at,
+        c.chk_updated_at
+    from public.cht_chunks c
+    where c.chk_id = p_chk_id;
+end;
+$$ language plpgsql;
+
+
+
+-- =====================================================
+-- Procedure: sp_create_chunk
+-- Description: Creates a new chunk with optional embedding
+-- Returns: success (boolean), code (varchar), chk_id (int)
+-- =====================================================
+create or replace procedure sp_create_chunk(
+    out success boolean,
+    out code varchar,
+    out o_chk_id int,
+    in p_doc_id int,
+    in p_content text,
+    in p_embedding vector(1536)
+)
+language plpgsql
+as $$
+declare
+    v_doc_exists boolean;
+begin
+    success := true;
+    code := 'OK';
+    o_chk_id := null;
+
+    -- Validate document exists
+    select exists(
+        select 1
+        from public.cht_documents
+        where doc_id = p_doc_id
+        and doc_active = true
+    ) into v_doc_exists;
+
+    if not v_doc_exists then
+        success := false;
+        code := 'ERR_DOCUMENT_NOT_FOUND';
+        return;
+    end if;
+
+    -- Insert chunk
+    insert into public.cht_chunks (
+        chk_fk_document,
+        chk_content,
+        chk_embedding
+    ) values (
+        p_doc_id,
+        p_content,
+        p_embedding
+    )
+    returning chk_id into o_chk_id;
+
+    -- Initialize statistics record for this chunk
+    insert into public.cht_chunk_statistics (
+        cst_fk_chunk,
+        cst_usage_count
+    ) values (
+        o_chk_id,
+        0
+    );
+
+exception
+    when others then
+        success := false;
+        code := 'ERR_CREATE_CHUNK';
+        raise notice 'Error creating chunk: %', sqlerrm;
+end;
+$$;
+
+
+-- =====================================================
+-- Procedure: sp_bulk_create_chunks
+-- Description: Creates multiple chunks for a document at once
+-- Returns: success (boolean), code (varchar), chunks_created (int)
+-- =====================================================
+create or replace procedure sp_bulk_create_chunks(
+    out success boolean,
+    out code varchar,
+    out o_chunks_created int,
+    in p_doc_id int,
+    in p_contents text[],
+    in p_embeddings vector(1536)[]
+)
+language plpgsql
+as $$
+declare
+    v_doc_exists boolean;
+begin
+    success := true;
+    code := 'OK';
+    o_chunks_created := 0;
+
+    -- 1. Validate document exists
+    select exists(
+        select 1
+        from public.cht_documents
+        where doc_id = p_doc_id
+        and doc_active = true
+    ) into v_doc_exists;
+
+    if not v_doc_exists then
+        success := false;
+        code := 'ERR_DOCUMENT_NOT_FOUND';
+        return;
+    end if;
+
+    -- Check for array length mismatch (optional, but good practice)
+    if array_length(p_contents, 1) != array_length(p_embeddings, 1) then
+        success := false;
+        code := 'ERR_ARRAY_LENGTH_MISMATCH';
+        raise notice 'Error: p_contents array length does not match p_embeddings array length.';
+        return;
+    end if;
+
+    -- 2. Bulk Insert Chunks and corresponding Statistics using a CTE
+    -- This single statement replaces the entire loop in the original code.
+    with inserted_chunks as (
+        -- INSERT the chunk data using UNNEST to turn the arrays into rows
+        insert into public.cht_chunks (
+            chk_fk_document,
+            chk_content,
+            chk_embedding
+        )
+        select
+            p_doc_id,
+            content,
+            embedding
+        from unnest(p_contents, p_embeddings) as t(content, embedding)
+
+        -- Capture the newly created chk_id for the statistics table
+        returning chk_id
+    )
+    -- INSERT the statistics records in bulk, using the IDs from the CTE
+    insert into public.cht_chunk_statistics (
+        cst_fk_chunk,
+        cst_usage_count
+    )
+    select
+        chk_id,
+        0
+    from inserted_chunks;
+
+    -- 3. Set output parameter
+    o_chunks_created := array_length(p_contents, 1);
+
+exception
+    when others then
+        success := false;
+        code := 'ERR_BULK_CREATE_CHUNKS';
+        o_chunks_created := 0;
+        raise notice 'Error bulk creating chunks: %', sqlerrm;
+end;
+
+DROP INDEX IF EXISTS idx_chunks_document_position;
+ALTER TABLE cht_chunks DROP COLUMN IF EXISTS chk_position;
+
+DELETE FROM cht_parameters WHERE prm_code = 'RAG_NEIGHBOR_WINDOW';
@@ -0,0 +1,316 @@
+-- =====================================================
+-- Chunk Ordinal Positions
+-- Migration: 000050_chunk_positions.up.sql
+-- Purpose: Persist the position of each chunk within its document so that
+--          search hits can be expanded with their neighbouring chunks
+-- =====================================================
+
+ALTER TABLE cht_chunks ADD COLUMN IF NOT EXISTS chk_position INT;
+
+-- Backfill: chunks were inserted in document order, so chk_id gives the original order
+UPDATE cht_chunks c
+SET chk_position = p.position
+FROM (
+    SELECT chk_id, ROW_NUMBER() OVER (PARTITION BY chk_fk_document ORDER BY chk_id) - 1 AS position
+    FROM cht_chunks
+) p
+WHERE c.chk_id = p.chk_id
+  AND c.chk_position IS NULL;
+
+ALTER TABLE cht_chunks ALTER COLUMN chk_position SET DEFAULT 0;
+ALTER TABLE cht_chunks ALTER COLUMN chk_position SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_chunks_document_position ON cht_chunks(chk_fk_document, chk_position);
+
+COMMENT ON COLUMN cht_chunks.chk_position IS '0-based position of the chunk within its document (gaps allowed after deletes)';
+
+-- =====================================================
+-- Function: fn_get_chunks_by_document
+-- Description: Get all chunks for a specific document in document order
+-- =====================================================
+DROP FUNCTION IF EXISTS fn_get_chunks_by_document(int);
+
+CREATE OR REPLACE FUNCTION fn_get_chunks_by_document(
+    p_doc_id int
+)
+RETURNS TABLE (
+    chk_id int,
+    chk_fk_document int,
+    chk_content text,
+    chk_position int,
+    chk_created_at timestamp,
+    chk_updated_at timestamp
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        c.chk_id,
+        c.chk_fk_document,
+        c.chk_content,
+        c.chk_position,
+        c.chk_created_at,
+        c.chk_updated_at
+    FROM public.cht_chunks c
+    WHERE c.chk_fk_document = p_doc_id
+    ORDER BY c.chk_position, c.chk_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- =====================================================
+-- Function: fn_get_chunk_by_id
+-- Description: Get specific chunk by ID
+-- =====================================================
+DROP FUNCTION IF EXISTS fn_get_chunk_by_id(int);
+
+CREATE OR REPLACE FUNCTION fn_get_chunk_by_id(
+    p_chk_id int
+)
+RETURNS TABLE (
+    chk_id int,
+    chk_fk_document int,
+    chk_content text,
+    chk_position int,
+    chk_created_at timestamp,
+    chk_updated_at timestamp
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        c.chk_id,
+        c.chk_fk_document,
+        c.chk_content,
+        c.chk_position,
+        c.chk_created_at,
+        c.chk_updated_at
+    FROM public.cht_chunks c
+    WHERE c.chk_id = p_chk_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- =====================================================
+-- Function: fn_get_chunk_neighbors
+-- Description: Returns every chunk within p_window positions of each hit,
+--              in the same document, ordered by hit and position.
+--              Neighbour distance counts existing chunks, so gaps left by
+--              deleted chunks are skipped.
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_chunk_neighbors(
+    p_chunk_ids int[],
+    p_window int default 1
+)
+RETURNS TABLE (
+    hit_chk_id int,
+    chk_id int,
+    chk_fk_document int,
+    chk_content text,
+    chk_position int,
+    chk_ordinal int
+) AS $$
+BEGIN
+    RETURN QUERY
+    WITH ordered_chunks AS (
+        SELECT
+            c.chk_id,
+            c.chk_fk_document,
+            c.chk_content,
+            c.chk_position,
+            ROW_NUMBER() OVER (PARTITION BY c.chk_fk_document ORDER BY c.chk_position, c.chk_id)::int AS ordinal
+        FROM public.cht_chunks c
+        WHERE c.chk_fk_document IN (
+            SELECT h.chk_fk_document FROM public.cht_chunks h WHERE h.chk_id = ANY(p_chunk_ids)
+        )
+    ),
+    hits AS (
+        SELECT oc.chk_id, oc.chk_fk_document, oc.ordinal
+        FROM ordered_chunks oc
+        WHERE oc.chk_id = ANY(p_chunk_ids)
+    )
+    SELECT
+        h.chk_id,
+        oc.chk_id,
+        oc.chk_fk_document,
+        oc.chk_content,
+        oc.chk_position,
+        oc.ordinal
+    FROM hits h
+    INNER JOIN ordered_chunks oc
+        ON oc.chk_fk_document = h.chk_fk_document
+       AND oc.ordinal BETWEEN h.ordinal - GREATEST(p_window, 0) AND h.ordinal + GREATEST(p_window, 0)
+    ORDER BY h.chk_id, oc.ordinal;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- =====================================================
+-- Procedure: sp_create_chunk
+-- Description: Creates a new chunk appended at the end of its document
+-- Returns: success (boolean), code (varchar), chk_id (int)
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_create_chunk(
+    OUT success boolean,
+    OUT code varchar,
+    OUT o_chk_id int,
+    IN p_doc_id int,
+    IN p_content text,
+    IN p_embedding vector
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_doc_exists boolean;
+    v_position int;
+BEGIN
+    success := true;
+    code := 'OK';
+    o_chk_id := null;
+
+    -- Validate document exists
+    SELECT EXISTS(
+        SELECT 1
+        FROM public.cht_documents
+        WHERE doc_id = p_doc_id
+        AND doc_active = true
+    ) INTO v_doc_exists;
+
+    IF NOT v_doc_exists THEN
+        success := false;
+        code := 'ERR_DOCUMENT_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    SELECT COALESCE(MAX(c.chk_position) + 1, 0) INTO v_position
+    FROM public.cht_chunks c
+    WHERE c.chk_fk_document = p_doc_id;
+
+    -- Insert chunk
+    INSERT INTO public.cht_chunks (
+        chk_fk_document,
+        chk_content,
+        chk_embedding,
+        chk_position
+    ) VALUES (
+        p_doc_id,
+        p_content,
+        p_embedding,
+        v_position
+    )
+    RETURNING chk_id INTO o_chk_id;
+
+    -- Initialize statistics record for this chunk
+    INSERT INTO public.cht_chunk_statistics (
+        cst_fk_chunk,
+        cst_usage_count
+    ) VALUES (
+        o_chk_id,
+        0
+    );
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_CREATE_CHUNK';
+        RAISE NOTICE 'Error creating chunk: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Procedure: sp_bulk_create_chunks
+-- Description: Creates multiple chunks for a document at once, keeping the
+--              array order as their positions after any existing chunks
+-- Returns: success (boolean), code (varchar), chunks_created (int)
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_bulk_create_chunks(
+    OUT success boolean,
+    OUT code varchar,
+    OUT o_chunks_created int,
+    IN p_doc_id int,
+    IN p_contents text[],
+    IN p_embeddings vector[]
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_doc_exists boolean;
+    v_next_position int;
+BEGIN
+    success := true;
+    code := 'OK';
+    o_chunks_created := 0;
+
+    -- 1. Validate document exists
+    SELECT EXISTS(
+        SELECT 1
+        FROM public.cht_documents
+        WHERE doc_id = p_doc_id
+        AND doc_active = true
+    ) INTO v_doc_exists;
+
+    IF NOT v_doc_exists THEN
+        success := false;
+        code := 'ERR_DOCUMENT_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    IF array_length(p_contents, 1) != array_length(p_embeddings, 1) THEN
+        success := false;
+        code := 'ERR_ARRAY_LENGTH_MISMATCH';
+        RAISE NOTICE 'Error: p_contents array length does not match p_embeddings array length.';
+        RETURN;
+    END IF;
+
+    SELECT COALESCE(MAX(c.chk_position) + 1, 0) INTO v_next_position
+    FROM public.cht_chunks c
+    WHERE c.chk_fk_document = p_doc_id;
+
+    -- 2. Bulk insert chunks (array index = position) and their statistics
+    WITH inserted_chunks AS (
+        INSERT INTO public.cht_chunks (
+            chk_fk_document,
+            chk_content,
+            chk_embedding,
+            chk_position
+        )
+        SELECT
+            p_doc_id,
+            t.content,
+            t.embedding,
+            v_next_position + (t.idx - 1)::int
+        FROM unnest(p_contents, p_embeddings) WITH ORDINALITY AS t(content, embedding, idx)
+        RETURNING chk_id
+    )
+    INSERT INTO public.cht_chunk_statistics (
+        cst_fk_chunk,
+        cst_usage_count
+    )
+    SELECT
+        chk_id,
+        0
+    FROM inserted_chunks;
+
+    -- 3. Set output parameter
+    o_chunks_created := array_length(p_contents, 1);
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_BULK_CREATE_CHUNKS';
+        o_chunks_created := 0;
+        RAISE NOTICE 'Error bulk creating chunks: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Parameters
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'RAG_NEIGHBOR_WINDOW') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('RAG_CONFIGURATION', 'RAG_NEIGHBOR_WINDOW', '{"value": 0}'::jsonb, 'Expand each search hit with its ±N neighbouring chunks of the same document (0 = disabled)');
+    END IF;
+END $$;
+
+COMMENT ON FUNCTION fn_get_chunks_by_document(int) IS 'Get all chunks for a specific document ordered by position';
+COMMENT ON FUNCTION fn_get_chunk_by_id(int) IS 'Get specific chunk by ID';
+COMMENT ON FUNCTION fn_get_chunk_neighbors(int[], int) IS 'Get the ±N neighbouring chunks of each hit within its document';
+COMMENT ON PROCEDURE sp_create_chunk IS 'Creates a new chunk at the end of its document. Returns success, code, and chk_id';
+COMMENT ON PROCEDURE sp_bulk_create_chunks IS 'Bulk creates chunks for a document in array order. Returns success, code, and count';
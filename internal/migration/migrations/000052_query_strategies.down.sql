@@ -0,0 +1,6 @@
+-- =====================================================
+-- Multi-query and HyDE Retrieval Strategies
+-- Migration: 000052_query_strategies.down.sql
+-- =====================================================
+
+DELETE FROM cht_parameters WHERE prm_code = 'QUERY_STRATEGY_CONFIG';
@@ -0,0 +1,23 @@
+-- =====================================================
+-- Multi-query and HyDE Retrieval Strategies
+-- Migration: 000052_query_strategies.up.sql
+-- Purpose: Per-category selection of the query strategy used by hybrid search
+-- =====================================================
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'QUERY_STRATEGY_CONFIG') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES (
+            'RAG_CONFIGURATION',
+            'QUERY_STRATEGY_CONFIG',
+            '{
+                "default": "none",
+                "categories": {},
+                "multiQuery": {"count": 3, "model": ""},
+                "hyde": {"model": "", "maxTokens": 250}
+            }'::jsonb,
+            'Query strategy per document category ("categories": {"<category>": "<strategy>"}, else "default"): "none", "multi_query" (LLM paraphrases fused with RRF) or "hyde" (embed an LLM-written hypothetical answer). Optional "prompt" in each section.'
+        );
+    END IF;
+END $$;
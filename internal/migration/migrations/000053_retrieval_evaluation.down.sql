@@ -0,0 +1,23 @@
+-- =====================================================
+-- Offline Retrieval Evaluation
+-- Migration: 000053_retrieval_evaluation.down.sql
+-- =====================================================
+
+DROP FUNCTION IF EXISTS fn_get_eval_runs(INT, VARCHAR, INT);
+DROP PROCEDURE IF EXISTS sp_create_eval_run(VARCHAR, VARCHAR, INT, INT, JSONB, JSONB, JSONB);
+DROP FUNCTION IF EXISTS fn_get_eval_questions(VARCHAR);
+DROP PROCEDURE IF EXISTS sp_delete_eval_question(INT);
+DROP PROCEDURE IF EXISTS sp_create_eval_question(VARCHAR, TEXT, VARCHAR, INT[], INT[]);
+
+DROP TABLE IF EXISTS cht_eval_runs;
+DROP TABLE IF EXISTS cht_eval_questions;
+
+DELETE FROM cht_parameters WHERE prm_code IN (
+    'ERR_CREATE_EVAL_QUESTION',
+    'ERR_EVAL_QUESTION_UNLABELLED',
+    'ERR_EVAL_QUESTION_NOT_FOUND',
+    'ERR_DELETE_EVAL_QUESTION',
+    'ERR_CREATE_EVAL_RUN',
+    'ERR_EVAL_SET_EMPTY',
+    'ERR_EVAL_RUN_NOT_FOUND'
+);
@@ -0,0 +1,268 @@
+-- =====================================================
+-- Offline Retrieval Evaluation
+-- Migration: 000053_retrieval_evaluation.up.sql
+-- Purpose: Golden questions with labelled relevant chunks/documents and the
+--          stored reports of evaluation runs against the current index
+-- =====================================================
+
+-- =====================================================
+-- Table: cht_eval_questions
+-- Description: Golden set questions. A retrieved chunk is relevant when its id is in
+--              evq_relevant_chunk_ids or its document is in evq_relevant_document_ids.
+-- =====================================================
+CREATE TABLE IF NOT EXISTS public.cht_eval_questions (
+    evq_id                      SERIAL PRIMARY KEY,
+    evq_set                     VARCHAR(50) NOT NULL DEFAULT 'default',
+    evq_question                TEXT NOT NULL,
+    evq_category                VARCHAR(50),
+    evq_relevant_chunk_ids      INT[] NOT NULL DEFAULT '{}',
+    evq_relevant_document_ids   INT[] NOT NULL DEFAULT '{}',
+    evq_active                  BOOLEAN NOT NULL DEFAULT true,
+    evq_created_at              TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_eval_question_labelled CHECK (
+        cardinality(evq_relevant_chunk_ids) > 0 OR cardinality(evq_relevant_document_ids) > 0
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_eval_questions_set ON cht_eval_questions(evq_set) WHERE evq_active = true;
+
+-- =====================================================
+-- Table: cht_eval_runs
+-- Description: One evaluation run: search settings snapshot, aggregate metrics
+--              and per-question results
+-- =====================================================
+CREATE TABLE IF NOT EXISTS public.cht_eval_runs (
+    evr_id              SERIAL PRIMARY KEY,
+    evr_set             VARCHAR(50) NOT NULL,
+    evr_label           VARCHAR(150),
+    evr_k               INT NOT NULL,
+    evr_question_count  INT NOT NULL,
+    evr_settings        JSONB NOT NULL DEFAULT '{}'::JSONB,
+    evr_metrics         JSONB NOT NULL DEFAULT '{}'::JSONB,
+    evr_results         JSONB NOT NULL DEFAULT '[]'::JSONB,
+    evr_created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_eval_runs_set ON cht_eval_runs(evr_set, evr_created_at DESC);
+
+-- =====================================================
+-- Stored Procedure: sp_create_eval_question
+-- Description: Add a labelled question to a golden set
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_create_eval_question(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_evq_id INT,
+    IN p_set VARCHAR,
+    IN p_question TEXT,
+    IN p_category VARCHAR,
+    IN p_relevant_chunk_ids INT[],
+    IN p_relevant_document_ids INT[]
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+    o_evq_id := NULL;
+
+    IF COALESCE(cardinality(p_relevant_chunk_ids), 0) = 0 AND COALESCE(cardinality(p_relevant_document_ids), 0) = 0 THEN
+        success := FALSE;
+        code := 'ERR_EVAL_QUESTION_UNLABELLED';
+        RETURN;
+    END IF;
+
+    INSERT INTO cht_eval_questions (evq_set, evq_question, evq_category, evq_relevant_chunk_ids, evq_relevant_document_ids)
+    VALUES (
+        COALESCE(NULLIF(p_set, ''), 'default'),
+        p_question,
+        NULLIF(p_category, ''),
+        COALESCE(p_relevant_chunk_ids, '{}'),
+        COALESCE(p_relevant_document_ids, '{}')
+    )
+    RETURNING evq_id INTO o_evq_id;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_CREATE_EVAL_QUESTION';
+        o_evq_id := NULL;
+        RAISE NOTICE 'Error creating evaluation question: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_delete_eval_question
+-- Description: Deactivate a golden set question (past run reports keep referring to it)
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_delete_eval_question(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_evq_id INT
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+
+    UPDATE cht_eval_questions
+    SET evq_active = false
+    WHERE evq_id = p_evq_id AND evq_active = true;
+
+    IF NOT FOUND THEN
+        success := FALSE;
+        code := 'ERR_EVAL_QUESTION_NOT_FOUND';
+    END IF;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_DELETE_EVAL_QUESTION';
+        RAISE NOTICE 'Error deleting evaluation question: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_get_eval_questions
+-- Description: Active questions of a golden set
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_eval_questions(
+    p_set VARCHAR DEFAULT 'default'
+)
+RETURNS TABLE (
+    evq_id INT,
+    evq_set VARCHAR(50),
+    evq_question TEXT,
+    evq_category VARCHAR(50),
+    evq_relevant_chunk_ids INT[],
+    evq_relevant_document_ids INT[],
+    evq_created_at TIMESTAMP
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RETURN QUERY
+    SELECT q.evq_id, q.evq_set, q.evq_question, q.evq_category,
+           q.evq_relevant_chunk_ids, q.evq_relevant_document_ids, q.evq_created_at
+    FROM cht_eval_questions q
+    WHERE q.evq_active = true
+      AND q.evq_set = COALESCE(NULLIF(p_set, ''), 'default')
+    ORDER BY q.evq_id;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_create_eval_run
+-- Description: Store the report of an evaluation run
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_create_eval_run(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_evr_id INT,
+    IN p_set VARCHAR,
+    IN p_label VARCHAR,
+    IN p_k INT,
+    IN p_question_count INT,
+    IN p_settings JSONB,
+    IN p_metrics JSONB,
+    IN p_results JSONB
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+
+    INSERT INTO cht_eval_runs (evr_set, evr_label, evr_k, evr_question_count, evr_settings, evr_metrics, evr_results)
+    VALUES (
+        p_set,
+        NULLIF(p_label, ''),
+        p_k,
+        p_question_count,
+        COALESCE(p_settings, '{}'::JSONB),
+        COALESCE(p_metrics, '{}'::JSONB),
+        COALESCE(p_results, '[]'::JSONB)
+    )
+    RETURNING evr_id INTO o_evr_id;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_CREATE_EVAL_RUN';
+        o_evr_id := NULL;
+        RAISE NOTICE 'Error storing evaluation run: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_get_eval_runs
+-- Description: Evaluation runs, newest first. Per-question results are only
+--              returned when a single run is requested (p_evr_id).
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_eval_runs(
+    p_evr_id INT DEFAULT NULL,
+    p_set VARCHAR DEFAULT NULL,
+    p_limit INT DEFAULT 20
+)
+RETURNS TABLE (
+    evr_id INT,
+    evr_set VARCHAR(50),
+    evr_label VARCHAR(150),
+    evr_k INT,
+    evr_question_count INT,
+    evr_settings JSONB,
+    evr_metrics JSONB,
+    evr_results JSONB,
+    evr_created_at TIMESTAMP
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RETURN QUERY
+    SELECT r.evr_id, r.evr_set, r.evr_label, r.evr_k, r.evr_question_count,
+           r.evr_settings, r.evr_metrics,
+           CASE WHEN p_evr_id IS NOT NULL THEN r.evr_results ELSE NULL END,
+           r.evr_created_at
+    FROM cht_eval_runs r
+    WHERE (p_evr_id IS NULL OR r.evr_id = p_evr_id)
+      AND (p_set IS NULL OR p_set = '' OR r.evr_set = p_set)
+    ORDER BY r.evr_created_at DESC
+    LIMIT p_limit;
+END;
+$$;
+
+-- =====================================================
+-- Error Codes
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_CREATE_EVAL_QUESTION') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_CREATE_EVAL_QUESTION', '{"message": "Error al crear la pregunta de evaluación"}'::jsonb, 'Error creating evaluation question');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EVAL_QUESTION_UNLABELLED') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EVAL_QUESTION_UNLABELLED', '{"message": "La pregunta debe indicar al menos un fragmento o documento relevante"}'::jsonb, 'Evaluation question has no relevance labels');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EVAL_QUESTION_NOT_FOUND') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EVAL_QUESTION_NOT_FOUND', '{"message": "Pregunta de evaluación no encontrada"}'::jsonb, 'Evaluation question not found');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_DELETE_EVAL_QUESTION') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_DELETE_EVAL_QUESTION', '{"message": "Error al eliminar la pregunta de evaluación"}'::jsonb, 'Error deleting evaluation question');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_CREATE_EVAL_RUN') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_CREATE_EVAL_RUN', '{"message": "Error al guardar la evaluación"}'::jsonb, 'Error storing evaluation run');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EVAL_SET_EMPTY') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EVAL_SET_EMPTY', '{"message": "El conjunto de evaluación no tiene preguntas"}'::jsonb, 'Evaluation set has no questions');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EVAL_RUN_NOT_FOUND') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EVAL_RUN_NOT_FOUND', '{"message": "Evaluación no encontrada"}'::jsonb, 'Evaluation run not found');
+    END IF;
+END $$;
@@ -0,0 +1,27 @@
+-- =====================================================
+-- Answer Feedback and Chunk Quality Metrics
+-- Migration: 000054_message_feedback.down.sql
+-- =====================================================
+
+DROP FUNCTION IF EXISTS fn_get_message_chunks(INT);
+DROP PROCEDURE IF EXISTS sp_set_message_chunk_relevance(INT, INT, BOOLEAN);
+DROP PROCEDURE IF EXISTS sp_set_message_feedback(VARCHAR, SMALLINT, VARCHAR, VARCHAR);
+DROP PROCEDURE IF EXISTS sp_link_message_chunks(INT, INT[], FLOAT[]);
+DROP FUNCTION IF EXISTS fn_refresh_chunk_feedback_metrics(INT[]);
+
+DROP TABLE IF EXISTS cht_message_chunks;
+
+DROP INDEX IF EXISTS idx_conversation_messages_feedback;
+ALTER TABLE cht_conversation_messages
+    DROP COLUMN IF EXISTS cvm_feedback_at,
+    DROP COLUMN IF EXISTS cvm_feedback_source;
+
+DELETE FROM cht_parameters WHERE prm_code IN (
+    'FEEDBACK_CONFIG',
+    'ERR_MESSAGE_NOT_FOUND',
+    'ERR_FEEDBACK_NOT_BOT_MESSAGE',
+    'ERR_INVALID_FEEDBACK',
+    'ERR_SET_MESSAGE_FEEDBACK',
+    'ERR_LINK_MESSAGE_CHUNKS',
+    'ERR_MESSAGE_CHUNK_NOT_FOUND'
+);
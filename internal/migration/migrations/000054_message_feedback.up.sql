@@ -0,0 +1,384 @@
+-- =====================================================
+-- Answer Feedback and Chunk Quality Metrics
+-- Migration: 000054_message_feedback.up.sql
+-- Purpose: Link bot answers to the chunks they were built from, capture user
+--          and admin feedback on them and derive the per-chunk quality metrics
+--          from that feedback instead of a similarity threshold
+-- =====================================================
+
+-- =====================================================
+-- Message columns: where the feedback came from and when
+-- =====================================================
+ALTER TABLE cht_conversation_messages
+    ADD COLUMN IF NOT EXISTS cvm_feedback_source VARCHAR(20) CHECK (cvm_feedback_source IN ('whatsapp', 'api', 'admin')),
+    ADD COLUMN IF NOT EXISTS cvm_feedback_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_conversation_messages_feedback ON cht_conversation_messages(cvm_feedback_at DESC) WHERE cvm_feedback IS NOT NULL;
+
+-- =====================================================
+-- Table: cht_message_chunks
+-- Description: Chunks used to build a bot answer, in retrieval order.
+--              mck_relevant is an admin label that overrides the answer feedback for one chunk.
+-- =====================================================
+CREATE TABLE IF NOT EXISTS public.cht_message_chunks (
+    mck_id              SERIAL PRIMARY KEY,
+    mck_fk_message      INT NOT NULL REFERENCES cht_conversation_messages(cvm_id) ON DELETE CASCADE,
+    mck_fk_chunk        INT NOT NULL REFERENCES cht_chunks(chk_id) ON DELETE CASCADE,
+    mck_rank            INT NOT NULL CHECK (mck_rank > 0),
+    mck_score           FLOAT,
+    mck_relevant        BOOLEAN,
+    mck_created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_message_chunk UNIQUE (mck_fk_message, mck_fk_chunk)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_chunks_chunk ON cht_message_chunks(mck_fk_chunk);
+
+-- The previous values came from a fixed similarity threshold, not from relevance
+UPDATE cht_chunk_statistics
+SET cst_precision_atk = NULL,
+    cst_recall_atk = NULL,
+    cst_f1_atk = NULL,
+    cst_mrr = NULL,
+    cst_map = NULL,
+    cst_ndcg = NULL;
+
+-- =====================================================
+-- Function: fn_refresh_chunk_feedback_metrics
+-- Description: Recompute the quality metrics of the given chunks from every judged answer
+--              that used them. A use is relevant when the admin marked the chunk relevant,
+--              or, without a label, when the answer was rated positive.
+--                precision@k  share of judged uses that were relevant
+--                recall@k     relevant uses / relevant answers drawing on the same document
+--                MRR          mean of 1/rank over judged uses (0 when not relevant)
+--                MAP          mean precision at the chunk's rank over judged uses (0 when not relevant)
+--                NDCG         mean of 1/log2(rank+1) over judged uses (0 when not relevant)
+--              Chunks left without judged uses get their metrics cleared.
+-- Returns: number of chunks updated
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_refresh_chunk_feedback_metrics(
+    p_chunk_ids INT[]
+)
+RETURNS INT
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_updated INT;
+BEGIN
+    INSERT INTO cht_chunk_statistics (cst_fk_chunk)
+    SELECT c.chk_id FROM cht_chunks c WHERE c.chk_id = ANY(p_chunk_ids)
+    ON CONFLICT (cst_fk_chunk) DO NOTHING;
+
+    WITH judged AS (
+        SELECT mc.mck_fk_message AS message_id,
+               mc.mck_fk_chunk AS chunk_id,
+               mc.mck_rank AS chunk_rank,
+               COALESCE(mc.mck_relevant, m.cvm_feedback = 1) AS relevant
+        FROM cht_message_chunks mc
+        JOIN cht_conversation_messages m ON m.cvm_id = mc.mck_fk_message
+        WHERE (mc.mck_relevant IS NOT NULL OR m.cvm_feedback IS NOT NULL)
+          AND mc.mck_fk_message IN (
+              SELECT x.mck_fk_message FROM cht_message_chunks x WHERE x.mck_fk_chunk = ANY(p_chunk_ids)
+          )
+    ),
+    ranked AS (
+        SELECT j.*,
+               (COUNT(*) FILTER (WHERE j.relevant) OVER (PARTITION BY j.message_id ORDER BY j.chunk_rank))::FLOAT
+                   / j.chunk_rank AS precision_at_rank
+        FROM judged j
+    ),
+    chunk_metrics AS (
+        SELECT r.chunk_id,
+               COUNT(*) FILTER (WHERE r.relevant) AS relevant_uses,
+               (COUNT(*) FILTER (WHERE r.relevant))::FLOAT / COUNT(*) AS precision_atk,
+               AVG(CASE WHEN r.relevant THEN 1.0 / r.chunk_rank ELSE 0 END) AS mrr,
+               AVG(CASE WHEN r.relevant THEN r.precision_at_rank ELSE 0 END) AS map,
+               AVG(CASE WHEN r.relevant THEN LN(2) / LN(r.chunk_rank + 1) ELSE 0 END) AS ndcg
+        FROM ranked r
+        WHERE r.chunk_id = ANY(p_chunk_ids)
+        GROUP BY r.chunk_id
+    ),
+    document_answers AS (
+        SELECT c.chk_fk_document AS document_id, COUNT(DISTINCT mc.mck_fk_message) AS relevant_answers
+        FROM cht_message_chunks mc
+        JOIN cht_chunks c ON c.chk_id = mc.mck_fk_chunk
+        JOIN cht_conversation_messages m ON m.cvm_id = mc.mck_fk_message
+        WHERE COALESCE(mc.mck_relevant, m.cvm_feedback = 1)
+          AND c.chk_fk_document IN (SELECT t.chk_fk_document FROM cht_chunks t WHERE t.chk_id = ANY(p_chunk_ids))
+        GROUP BY c.chk_fk_document
+    ),
+    scored AS (
+        SELECT cm.chunk_id, cm.precision_atk, cm.mrr, cm.map, cm.ndcg,
+               cm.relevant_uses::FLOAT / NULLIF(da.relevant_answers, 0) AS recall_atk
+        FROM chunk_metrics cm
+        JOIN cht_chunks c ON c.chk_id = cm.chunk_id
+        LEFT JOIN document_answers da ON da.document_id = c.chk_fk_document
+    )
+    UPDATE cht_chunk_statistics s
+    SET cst_precision_atk = sc.precision_atk,
+        cst_recall_atk = COALESCE(sc.recall_atk, 0),
+        cst_f1_atk = CASE
+            WHEN sc.precision_atk + COALESCE(sc.recall_atk, 0) = 0 THEN 0
+            ELSE 2 * sc.precision_atk * COALESCE(sc.recall_atk, 0) / (sc.precision_atk + COALESCE(sc.recall_atk, 0))
+        END,
+        cst_mrr = sc.mrr,
+        cst_map = sc.map,
+        cst_ndcg = sc.ndcg,
+        cst_updated_at = CURRENT_TIMESTAMP
+    FROM scored sc
+    WHERE s.cst_fk_chunk = sc.chunk_id;
+
+    GET DIAGNOSTICS v_updated = ROW_COUNT;
+
+    -- Feedback removed: no judged uses left
+    UPDATE cht_chunk_statistics s
+    SET cst_precision_atk = NULL,
+        cst_recall_atk = NULL,
+        cst_f1_atk = NULL,
+        cst_mrr = NULL,
+        cst_map = NULL,
+        cst_ndcg = NULL,
+        cst_updated_at = CURRENT_TIMESTAMP
+    WHERE s.cst_fk_chunk = ANY(p_chunk_ids)
+      AND NOT EXISTS (
+          SELECT 1
+          FROM cht_message_chunks mc
+          JOIN cht_conversation_messages m ON m.cvm_id = mc.mck_fk_message
+          WHERE mc.mck_fk_chunk = s.cst_fk_chunk
+            AND (mc.mck_relevant IS NOT NULL OR m.cvm_feedback IS NOT NULL)
+      );
+
+    RETURN v_updated;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_link_message_chunks
+-- Description: Record the chunks (in retrieval order) a bot answer was built from.
+--              Chunks deleted in the meantime are skipped.
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_link_message_chunks(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_cvm_id INT,
+    IN p_chunk_ids INT[],
+    IN p_scores FLOAT[] DEFAULT NULL
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+
+    IF NOT EXISTS (SELECT 1 FROM cht_conversation_messages WHERE cvm_id = p_cvm_id) THEN
+        success := FALSE;
+        code := 'ERR_MESSAGE_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    INSERT INTO cht_message_chunks (mck_fk_message, mck_fk_chunk, mck_rank, mck_score)
+    SELECT p_cvm_id, t.chunk_id, t.chunk_rank, t.score
+    FROM UNNEST(p_chunk_ids, p_scores) WITH ORDINALITY AS t(chunk_id, score, chunk_rank)
+    JOIN cht_chunks c ON c.chk_id = t.chunk_id
+    WHERE t.chunk_id IS NOT NULL
+    ON CONFLICT (mck_fk_message, mck_fk_chunk) DO NOTHING;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_LINK_MESSAGE_CHUNKS';
+        RAISE NOTICE 'Error linking message chunks: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_set_message_feedback
+-- Description: Rate a bot answer (1 positive, -1 negative, 0 clears the rating) and
+--              recompute the metrics of the chunks it used. p_chat_id, when given,
+--              must be the conversation the answer belongs to.
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_set_message_feedback(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_cvm_id INT,
+    IN p_message_id VARCHAR,
+    IN p_feedback SMALLINT,
+    IN p_source VARCHAR,
+    IN p_chat_id VARCHAR DEFAULT NULL
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_from_me BOOLEAN;
+    v_chunk_ids INT[];
+BEGIN
+    success := TRUE;
+    code := 'OK';
+    o_cvm_id := NULL;
+
+    IF p_feedback NOT IN (-1, 0, 1) THEN
+        success := FALSE;
+        code := 'ERR_INVALID_FEEDBACK';
+        RETURN;
+    END IF;
+
+    SELECT m.cvm_id, m.cvm_from_me
+    INTO o_cvm_id, v_from_me
+    FROM cht_conversation_messages m
+    JOIN cht_conversations c ON c.cnv_id = m.cvm_fk_conversation
+    WHERE m.cvm_message_id = p_message_id
+      AND (p_chat_id IS NULL OR c.cnv_chat_id = p_chat_id);
+
+    IF o_cvm_id IS NULL THEN
+        success := FALSE;
+        code := 'ERR_MESSAGE_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    IF NOT v_from_me THEN
+        success := FALSE;
+        code := 'ERR_FEEDBACK_NOT_BOT_MESSAGE';
+        o_cvm_id := NULL;
+        RETURN;
+    END IF;
+
+    UPDATE cht_conversation_messages
+    SET cvm_feedback = NULLIF(p_feedback, 0),
+        cvm_feedback_source = CASE WHEN p_feedback = 0 THEN NULL ELSE p_source END,
+        cvm_feedback_at = CASE WHEN p_feedback = 0 THEN NULL ELSE CURRENT_TIMESTAMP END
+    WHERE cvm_id = o_cvm_id;
+
+    SELECT ARRAY_AGG(mck_fk_chunk) INTO v_chunk_ids
+    FROM cht_message_chunks
+    WHERE mck_fk_message = o_cvm_id;
+
+    IF v_chunk_ids IS NOT NULL THEN
+        PERFORM fn_refresh_chunk_feedback_metrics(v_chunk_ids);
+    END IF;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_SET_MESSAGE_FEEDBACK';
+        o_cvm_id := NULL;
+        RAISE NOTICE 'Error setting message feedback: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_set_message_chunk_relevance
+-- Description: Admin label on one chunk of an answer (NULL removes the label
+--              so the answer feedback applies again)
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_set_message_chunk_relevance(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_cvm_id INT,
+    IN p_chunk_id INT,
+    IN p_relevant BOOLEAN
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+
+    UPDATE cht_message_chunks
+    SET mck_relevant = p_relevant
+    WHERE mck_fk_message = p_cvm_id AND mck_fk_chunk = p_chunk_id;
+
+    IF NOT FOUND THEN
+        success := FALSE;
+        code := 'ERR_MESSAGE_CHUNK_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    PERFORM fn_refresh_chunk_feedback_metrics(ARRAY[p_chunk_id]);
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_SET_MESSAGE_FEEDBACK';
+        RAISE NOTICE 'Error setting chunk relevance: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_get_message_chunks
+-- Description: Chunks a bot answer was built from, with the answer feedback and admin labels
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_message_chunks(
+    p_cvm_id INT
+)
+RETURNS TABLE (
+    mck_fk_message INT,
+    mck_fk_chunk INT,
+    mck_rank INT,
+    mck_score FLOAT,
+    mck_relevant BOOLEAN,
+    cvm_feedback SMALLINT,
+    chk_fk_document INT,
+    doc_title VARCHAR,
+    chk_content TEXT
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RETURN QUERY
+    SELECT mc.mck_fk_message, mc.mck_fk_chunk, mc.mck_rank, mc.mck_score, mc.mck_relevant,
+           m.cvm_feedback, c.chk_fk_document, d.doc_title, c.chk_content
+    FROM cht_message_chunks mc
+    JOIN cht_conversation_messages m ON m.cvm_id = mc.mck_fk_message
+    JOIN cht_chunks c ON c.chk_id = mc.mck_fk_chunk
+    JOIN cht_documents d ON d.doc_id = c.chk_fk_document
+    WHERE mc.mck_fk_message = p_cvm_id
+    ORDER BY mc.mck_rank;
+END;
+$$;
+
+-- =====================================================
+-- Feedback configuration and error codes
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'FEEDBACK_CONFIG') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES (
+            'RAG_CONFIGURATION',
+            'FEEDBACK_CONFIG',
+            '{
+                "whatsappReactions": true,
+                "positiveReactions": ["👍", "❤️", "🙏", "👏", "💯"],
+                "negativeReactions": ["👎", "😡", "😞"]
+            }'::jsonb,
+            'User feedback on bot answers: WhatsApp reactions counted as positive or negative (removing the reaction clears the rating)'
+        );
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_MESSAGE_NOT_FOUND') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_MESSAGE_NOT_FOUND', '{"message": "Mensaje no encontrado"}'::jsonb, 'Message not found');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_FEEDBACK_NOT_BOT_MESSAGE') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_FEEDBACK_NOT_BOT_MESSAGE', '{"message": "Solo se pueden calificar las respuestas del asistente"}'::jsonb, 'Feedback on a message not sent by the bot');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_INVALID_FEEDBACK') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_INVALID_FEEDBACK', '{"message": "La calificación debe ser 1, -1 o 0"}'::jsonb, 'Invalid feedback value');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_SET_MESSAGE_FEEDBACK') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_SET_MESSAGE_FEEDBACK', '{"message": "Error al registrar la calificación"}'::jsonb, 'Error setting message feedback');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_LINK_MESSAGE_CHUNKS') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_LINK_MESSAGE_CHUNKS', '{"message": "Error al registrar los fragmentos de la respuesta"}'::jsonb, 'Error linking answer chunks');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_MESSAGE_CHUNK_NOT_FOUND') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_MESSAGE_CHUNK_NOT_FOUND', '{"message": "El fragmento no se usó en esa respuesta"}'::jsonb, 'Chunk not used in that answer');
+    END IF;
+END $$;
+
+COMMENT ON COLUMN cht_conversation_messages.cvm_feedback_source IS 'Where the feedback came from: whatsapp (reaction), api or admin';
+COMMENT ON TABLE cht_message_chunks IS 'Chunks used to build each bot answer, in retrieval order, with optional admin relevance labels';
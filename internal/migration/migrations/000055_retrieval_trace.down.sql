@@ -0,0 +1,197 @@
+-- =====================================================
+-- Retrieval Trace of Bot Answers
+-- Migration: 000055_retrieval_trace.down.sql
+-- =====================================================
+
+-- Restore fn_get_conversation_messages from migration 000011
+DROP FUNCTION IF EXISTS fn_get_conversation_messages(INT, INT);
+
+CREATE OR REPLACE FUNCTION fn_get_conversation_messages(
+    p_conversation_id int,
+    p_limit int DEFAULT 100
+)
+RETURNS TABLE (
+    cvm_id int,
+    cvm_message_id varchar,
+    cvm_from_me boolean,
+    cvm_sender_name varchar,
+    cvm_sender_type varchar,
+    cvm_message_type varchar,
+    cvm_body text,
+    cvm_media_url varchar,
+    cvm_quoted_message varchar,
+    cvm_timestamp bigint,
+    cvm_is_forwarded boolean,
+    cvm_read boolean,
+    cvm_created_at timestamp,
+    admin_name varchar
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        m.cvm_id,
+        m.cvm_message_id,
+        m.cvm_from_me,
+        m.cvm_sender_name,
+        m.cvm_sender_type,
+        m.cvm_message_type,
+        m.cvm_body,
+        m.cvm_media_url,
+        m.cvm_quoted_message,
+        m.cvm_timestamp,
+        m.cvm_is_forwarded,
+        m.cvm_read,
+        m.cvm_created_at,
+        a.adm_username as admin_name
+    FROM public.cht_conversation_messages m
+    LEFT JOIN public.cht_admin_users a ON m.cvm_admin_id = a.adm_id
+    WHERE m.cvm_fk_conversation = p_conversation_id
+    ORDER BY m.cvm_timestamp ASC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION fn_get_conversation_messages IS 'Get all messages for a conversation';
+
+-- Restore sp_create_conversation_message from migration 000045
+DROP PROCEDURE IF EXISTS sp_create_conversation_message;
+
+CREATE OR REPLACE PROCEDURE sp_create_conversation_message(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_cvm_id INT,
+    IN p_conversation_id INT,
+    IN p_message_id VARCHAR,
+    IN p_from_me BOOLEAN,
+    IN p_sender_name VARCHAR DEFAULT NULL,
+    IN p_sender_type VARCHAR DEFAULT 'user',
+    IN p_message_type VARCHAR DEFAULT 'text',
+    IN p_body TEXT DEFAULT NULL,
+    IN p_media_url VARCHAR DEFAULT NULL,
+    IN p_quoted_message VARCHAR DEFAULT NULL,
+    IN p_timestamp BIGINT DEFAULT NULL,
+    IN p_is_forwarded BOOLEAN DEFAULT FALSE,
+    IN p_queue_time_ms INT DEFAULT NULL,
+    IN p_prompt_tokens INT DEFAULT NULL,
+    IN p_prompt_time_ms INT DEFAULT NULL,
+    IN p_completion_tokens INT DEFAULT NULL,
+    IN p_completion_time_ms INT DEFAULT NULL,
+    IN p_total_tokens INT DEFAULT NULL,
+    IN p_total_time_ms INT DEFAULT NULL,
+    IN p_variant_id INT DEFAULT NULL,
+    IN p_metadata JSONB DEFAULT NULL
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_exists BOOLEAN;
+BEGIN
+    success := TRUE;
+    code := 'OK';
+    o_cvm_id := NULL;
+
+    -- Check if conversation exists
+    SELECT EXISTS(
+        SELECT 1
+        FROM cht_conversations
+        WHERE cnv_id = p_conversation_id
+    ) INTO v_exists;
+
+    IF NOT v_exists THEN
+        success := FALSE;
+        code := 'ERR_CONVERSATION_NOT_FOUND';
+        RAISE NOTICE 'Conversation % not found', p_conversation_id;
+        RETURN;
+    END IF;
+
+    -- Check if message_id already exists
+    SELECT EXISTS(
+        SELECT 1
+        FROM cht_conversation_messages
+        WHERE cvm_message_id = p_message_id
+    ) INTO v_exists;
+
+    IF v_exists THEN
+        success := FALSE;
+        code := 'ERR_DUPLICATE_MESSAGE_ID';
+        RAISE NOTICE 'Message ID % already exists', p_message_id;
+        RETURN;
+    END IF;
+
+    -- Insert message
+    INSERT INTO cht_conversation_messages (
+        cvm_fk_conversation,
+        cvm_message_id,
+        cvm_from_me,
+        cvm_sender_name,
+        cvm_sender_type,
+        cvm_message_type,
+        cvm_body,
+        cvm_media_url,
+        cvm_quoted_message,
+        cvm_timestamp,
+        cvm_is_forwarded,
+        cvm_queue_time_ms,
+        cvm_prompt_tokens,
+        cvm_prompt_time_ms,
+        cvm_completion_tokens,
+        cvm_completion_time_ms,
+        cvm_total_tokens,
+        cvm_total_time_ms,
+        cvm_fk_variant,
+        cvm_metadata
+    ) VALUES (
+        p_conversation_id,
+        p_message_id,
+        p_from_me,
+        p_sender_name,
+        p_sender_type,
+        p_message_type,
+        p_body,
+        p_media_url,
+        p_quoted_message,
+        COALESCE(p_timestamp, EXTRACT(EPOCH FROM CURRENT_TIMESTAMP)::BIGINT),
+        p_is_forwarded,
+        p_queue_time_ms,
+        p_prompt_tokens,
+        p_prompt_time_ms,
+        p_completion_tokens,
+        p_completion_time_ms,
+        p_total_tokens,
+        p_total_time_ms,
+        NULLIF(p_variant_id, 0),
+        COALESCE(p_metadata, '{}'::JSONB)
+    )
+    RETURNING cvm_id INTO o_cvm_id;
+
+    -- Update conversation stats
+    UPDATE cht_conversations
+    SET
+        cnv_message_count = cnv_message_count + 1,
+        cnv_last_message_at = CURRENT_TIMESTAMP
+    WHERE cnv_id = p_conversation_id;
+
+EXCEPTION
+    WHEN unique_violation THEN
+        success := FALSE;
+        code := 'ERR_DUPLICATE_MESSAGE_ID';
+        o_cvm_id := NULL;
+        RAISE NOTICE 'Duplicate message ID: % (SQLSTATE: %)', p_message_id, SQLSTATE;
+    WHEN foreign_key_violation THEN
+        success := FALSE;
+        code := 'ERR_INVALID_CONVERSATION';
+        o_cvm_id := NULL;
+        RAISE NOTICE 'Invalid conversation ID: % (SQLSTATE: %)', p_conversation_id, SQLSTATE;
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_CREATE_MESSAGE';
+        o_cvm_id := NULL;
+        RAISE NOTICE 'Error creating message: % (SQLSTATE: %)', SQLERRM, SQLSTATE;
+END;
+$$;
+
+COMMENT ON PROCEDURE sp_create_conversation_message IS 'Create new message in conversation, tagged with the experiment variant when one applies';
+
+ALTER TABLE cht_conversation_messages
+    DROP COLUMN IF EXISTS cvm_retrieval_trace,
+    DROP COLUMN IF EXISTS cvm_rag_best_similarity;
@@ -0,0 +1,267 @@
+-- =====================================================
+-- Retrieval Trace of Bot Answers
+-- Migration: 000055_retrieval_trace.up.sql
+-- Purpose: Persist with every bot message how it was produced (query embedded,
+--          filters, candidate chunks and scores, chunks sent to the LLM, prompt
+--          and model) and the best retrieval score read by fn_get_top_queries
+-- =====================================================
+
+-- =====================================================
+-- Message columns: best retrieval score and retrieval trace
+-- =====================================================
+ALTER TABLE cht_conversation_messages
+    ADD COLUMN IF NOT EXISTS cvm_rag_best_similarity NUMERIC,
+    ADD COLUMN IF NOT EXISTS cvm_retrieval_trace JSONB;
+
+-- Backfill the score of existing bot answers from their metadata, then copy it to the questions
+UPDATE cht_conversation_messages
+SET cvm_rag_best_similarity = (cvm_metadata->>'ragBestSimilarity')::NUMERIC
+WHERE cvm_sender_type = 'bot'
+    AND cvm_metadata ? 'ragBestSimilarity'
+    AND cvm_rag_best_similarity IS NULL;
+
+UPDATE cht_conversation_messages q
+SET cvm_rag_best_similarity = a.cvm_rag_best_similarity
+FROM cht_conversation_messages a
+WHERE a.cvm_sender_type = 'bot'
+    AND a.cvm_rag_best_similarity IS NOT NULL
+    AND q.cvm_rag_best_similarity IS NULL
+    AND q.cvm_id = (
+        SELECT p.cvm_id
+        FROM cht_conversation_messages p
+        WHERE p.cvm_fk_conversation = a.cvm_fk_conversation
+            AND p.cvm_sender_type = 'user'
+            AND p.cvm_id < a.cvm_id
+        ORDER BY p.cvm_id DESC
+        LIMIT 1
+    );
+
+-- =====================================================
+-- Stored Procedure: sp_create_conversation_message
+-- Description: Recreated with the retrieval trace and the best retrieval score
+-- =====================================================
+DROP PROCEDURE IF EXISTS sp_create_conversation_message;
+
+CREATE OR REPLACE PROCEDURE sp_create_conversation_message(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_cvm_id INT,
+    IN p_conversation_id INT,
+    IN p_message_id VARCHAR,
+    IN p_from_me BOOLEAN,
+    IN p_sender_name VARCHAR DEFAULT NULL,
+    IN p_sender_type VARCHAR DEFAULT 'user',
+    IN p_message_type VARCHAR DEFAULT 'text',
+    IN p_body TEXT DEFAULT NULL,
+    IN p_media_url VARCHAR DEFAULT NULL,
+    IN p_quoted_message VARCHAR DEFAULT NULL,
+    IN p_timestamp BIGINT DEFAULT NULL,
+    IN p_is_forwarded BOOLEAN DEFAULT FALSE,
+    IN p_queue_time_ms INT DEFAULT NULL,
+    IN p_prompt_tokens INT DEFAULT NULL,
+    IN p_prompt_time_ms INT DEFAULT NULL,
+    IN p_completion_tokens INT DEFAULT NULL,
+    IN p_completion_time_ms INT DEFAULT NULL,
+    IN p_total_tokens INT DEFAULT NULL,
+    IN p_total_time_ms INT DEFAULT NULL,
+    IN p_variant_id INT DEFAULT NULL,
+    IN p_metadata JSONB DEFAULT NULL,
+    IN p_retrieval_trace JSONB DEFAULT NULL
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_exists BOOLEAN;
+    v_best_similarity NUMERIC;
+BEGIN
+    success := TRUE;
+    code := 'OK';
+    o_cvm_id := NULL;
+
+    -- Check if conversation exists
+    SELECT EXISTS(
+        SELECT 1
+        FROM cht_conversations
+        WHERE cnv_id = p_conversation_id
+    ) INTO v_exists;
+
+    IF NOT v_exists THEN
+        success := FALSE;
+        code := 'ERR_CONVERSATION_NOT_FOUND';
+        RAISE NOTICE 'Conversation % not found', p_conversation_id;
+        RETURN;
+    END IF;
+
+    -- Check if message_id already exists
+    SELECT EXISTS(
+        SELECT 1
+        FROM cht_conversation_messages
+        WHERE cvm_message_id = p_message_id
+    ) INTO v_exists;
+
+    IF v_exists THEN
+        success := FALSE;
+        code := 'ERR_DUPLICATE_MESSAGE_ID';
+        RAISE NOTICE 'Message ID % already exists', p_message_id;
+        RETURN;
+    END IF;
+
+    -- Best retrieval score of a bot answer (sent by the handlers in the message metadata)
+    IF p_metadata ? 'ragBestSimilarity' THEN
+        v_best_similarity := (p_metadata->>'ragBestSimilarity')::NUMERIC;
+    END IF;
+
+    -- Insert message
+    INSERT INTO cht_conversation_messages (
+        cvm_fk_conversation,
+        cvm_message_id,
+        cvm_from_me,
+        cvm_sender_name,
+        cvm_sender_type,
+        cvm_message_type,
+        cvm_body,
+        cvm_media_url,
+        cvm_quoted_message,
+        cvm_timestamp,
+        cvm_is_forwarded,
+        cvm_queue_time_ms,
+        cvm_prompt_tokens,
+        cvm_prompt_time_ms,
+        cvm_completion_tokens,
+        cvm_completion_time_ms,
+        cvm_total_tokens,
+        cvm_total_time_ms,
+        cvm_fk_variant,
+        cvm_metadata,
+        cvm_rag_best_similarity,
+        cvm_retrieval_trace
+    ) VALUES (
+        p_conversation_id,
+        p_message_id,
+        p_from_me,
+        p_sender_name,
+        p_sender_type,
+        p_message_type,
+        p_body,
+        p_media_url,
+        p_quoted_message,
+        COALESCE(p_timestamp, EXTRACT(EPOCH FROM CURRENT_TIMESTAMP)::BIGINT),
+        p_is_forwarded,
+        p_queue_time_ms,
+        p_prompt_tokens,
+        p_prompt_time_ms,
+        p_completion_tokens,
+        p_completion_time_ms,
+        p_total_tokens,
+        p_total_time_ms,
+        NULLIF(p_variant_id, 0),
+        COALESCE(p_metadata, '{}'::JSONB),
+        v_best_similarity,
+        p_retrieval_trace
+    )
+    RETURNING cvm_id INTO o_cvm_id;
+
+    -- fn_get_top_queries reads the score on the question, so copy it to the
+    -- user message the answer replies to
+    IF v_best_similarity IS NOT NULL THEN
+        UPDATE cht_conversation_messages
+        SET cvm_rag_best_similarity = v_best_similarity
+        WHERE cvm_id = (
+            SELECT q.cvm_id
+            FROM cht_conversation_messages q
+            WHERE q.cvm_fk_conversation = p_conversation_id
+                AND q.cvm_sender_type = 'user'
+                AND q.cvm_id < o_cvm_id
+            ORDER BY q.cvm_id DESC
+            LIMIT 1
+        )
+        AND cvm_rag_best_similarity IS NULL;
+    END IF;
+
+    -- Update conversation stats
+    UPDATE cht_conversations
+    SET
+        cnv_message_count = cnv_message_count + 1,
+        cnv_last_message_at = CURRENT_TIMESTAMP
+    WHERE cnv_id = p_conversation_id;
+
+EXCEPTION
+    WHEN unique_violation THEN
+        success := FALSE;
+        code := 'ERR_DUPLICATE_MESSAGE_ID';
+        o_cvm_id := NULL;
+        RAISE NOTICE 'Duplicate message ID: % (SQLSTATE: %)', p_message_id, SQLSTATE;
+    WHEN foreign_key_violation THEN
+        success := FALSE;
+        code := 'ERR_INVALID_CONVERSATION';
+        o_cvm_id := NULL;
+        RAISE NOTICE 'Invalid conversation ID: % (SQLSTATE: %)', p_conversation_id, SQLSTATE;
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_CREATE_MESSAGE';
+        o_cvm_id := NULL;
+        RAISE NOTICE 'Error creating message: % (SQLSTATE: %)', SQLERRM, SQLSTATE;
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_get_conversation_messages
+-- Description: Recreated with the feedback, best retrieval score and retrieval trace of bot answers
+-- =====================================================
+DROP FUNCTION IF EXISTS fn_get_conversation_messages(INT, INT);
+
+CREATE OR REPLACE FUNCTION fn_get_conversation_messages(
+    p_conversation_id INT,
+    p_limit INT DEFAULT 100
+)
+RETURNS TABLE (
+    cvm_id INT,
+    cvm_message_id VARCHAR,
+    cvm_from_me BOOLEAN,
+    cvm_sender_name VARCHAR,
+    cvm_sender_type VARCHAR,
+    cvm_message_type VARCHAR,
+    cvm_body TEXT,
+    cvm_media_url VARCHAR,
+    cvm_quoted_message VARCHAR,
+    cvm_timestamp BIGINT,
+    cvm_is_forwarded BOOLEAN,
+    cvm_read BOOLEAN,
+    cvm_created_at TIMESTAMP,
+    admin_name VARCHAR,
+    cvm_feedback SMALLINT,
+    cvm_rag_best_similarity NUMERIC,
+    cvm_retrieval_trace JSONB
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        m.cvm_id,
+        m.cvm_message_id,
+        m.cvm_from_me,
+        m.cvm_sender_name,
+        m.cvm_sender_type,
+        m.cvm_message_type,
+        m.cvm_body,
+        m.cvm_media_url,
+        m.cvm_quoted_message,
+        m.cvm_timestamp,
+        m.cvm_is_forwarded,
+        m.cvm_read,
+        m.cvm_created_at,
+        a.adm_username AS admin_name,
+        m.cvm_feedback,
+        m.cvm_rag_best_similarity,
+        m.cvm_retrieval_trace
+    FROM public.cht_conversation_messages m
+    LEFT JOIN public.cht_admin_users a ON m.cvm_admin_id = a.adm_id
+    WHERE m.cvm_fk_conversation = p_conversation_id
+    ORDER BY m.cvm_timestamp ASC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON COLUMN cht_conversation_messages.cvm_rag_best_similarity IS 'Best retrieval score of a bot answer, copied to the question it replies to';
+COMMENT ON COLUMN cht_conversation_messages.cvm_retrieval_trace IS 'How a bot answer was produced: query embedded, filters, candidates with scores, chunks sent to the LLM, prompt and model';
+COMMENT ON PROCEDURE sp_create_conversation_message IS 'Create new message in conversation, with the experiment variant and retrieval trace of bot answers';
+COMMENT ON FUNCTION fn_get_conversation_messages IS 'Get all messages for a conversation, with the feedback and retrieval trace of bot answers';
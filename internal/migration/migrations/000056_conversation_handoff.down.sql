@@ -0,0 +1,170 @@
+-- =====================================================
+-- Low-Confidence Handoff
+-- Migration: 000056_conversation_handoff.down.sql
+-- =====================================================
+
+-- Restore fn_get_all_conversations_for_admin from migration 000011
+DROP FUNCTION IF EXISTS fn_get_all_conversations_for_admin(INT, INT, VARCHAR);
+
+CREATE OR REPLACE FUNCTION fn_get_all_conversations_for_admin(
+    p_limit int DEFAULT 50,
+    p_offset int DEFAULT 0,
+    p_filter varchar DEFAULT NULL -- 'unread', 'blocked', 'active', 'all'
+)
+RETURNS TABLE (
+    cnv_id int,
+    cnv_chat_id varchar,
+    cnv_phone_number varchar,
+    cnv_contact_name varchar,
+    cnv_is_group boolean,
+    cnv_group_name varchar,
+    cnv_last_message_at timestamp,
+    cnv_message_count int,
+    cnv_unread_count int,
+    cnv_blocked boolean,
+    cnv_admin_intervened boolean,
+    cnv_temporary boolean,
+    cnv_expires_at timestamp,
+    usr_id int,
+    usr_name varchar,
+    usr_identity_number varchar,
+    usr_rol varchar,
+    usr_blocked boolean,
+    last_message_preview text,
+    last_message_from_me boolean
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        c.cnv_id,
+        c.cnv_chat_id,
+        c.cnv_phone_number,
+        c.cnv_contact_name,
+        c.cnv_is_group,
+        c.cnv_group_name,
+        c.cnv_last_message_at,
+        c.cnv_message_count,
+        c.cnv_unread_count,
+        c.cnv_blocked,
+        c.cnv_admin_intervened,
+        c.cnv_temporary,
+        c.cnv_expires_at,
+        u.usr_id,
+        u.usr_name,
+        u.usr_identity_number,
+        u.usr_rol,
+        u.usr_blocked,
+        (SELECT cvm_body FROM cht_conversation_messages
+         WHERE cvm_fk_conversation = c.cnv_id
+         ORDER BY cvm_timestamp DESC LIMIT 1) as last_message_preview,
+        (SELECT cvm_from_me FROM cht_conversation_messages
+         WHERE cvm_fk_conversation = c.cnv_id
+         ORDER BY cvm_timestamp DESC LIMIT 1) as last_message_from_me
+    FROM public.cht_conversations c
+    LEFT JOIN public.cht_users u ON c.cnv_fk_user = u.usr_id
+    WHERE
+        c.cnv_active = true
+        AND (
+            p_filter IS NULL
+            OR (p_filter = 'unread' AND c.cnv_unread_count > 0)
+            OR (p_filter = 'blocked' AND c.cnv_blocked = true)
+            OR (p_filter = 'active' AND c.cnv_blocked = false AND c.cnv_unread_count > 0)
+            OR (p_filter = 'all')
+        )
+    ORDER BY c.cnv_last_message_at DESC NULLS LAST
+    LIMIT p_limit
+    OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Restore sp_send_admin_message from migration 000011
+CREATE OR REPLACE PROCEDURE sp_send_admin_message(
+    OUT success boolean,
+    OUT code varchar,
+    OUT o_message_id int,
+    IN p_conversation_id int,
+    IN p_admin_id int,
+    IN p_message_id varchar,
+    IN p_body text
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_exists boolean;
+BEGIN
+    success := true;
+    code := 'OK';
+    o_message_id := NULL;
+
+    -- Check conversation exists
+    SELECT EXISTS(
+        SELECT 1 FROM public.cht_conversations WHERE cnv_id = p_conversation_id
+    ) INTO v_exists;
+
+    IF NOT v_exists THEN
+        success := false;
+        code := 'ERR_CONVERSATION_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    -- Insert admin message
+    INSERT INTO public.cht_conversation_messages (
+        cvm_fk_conversation,
+        cvm_message_id,
+        cvm_from_me,
+        cvm_sender_name,
+        cvm_sender_type,
+        cvm_message_type,
+        cvm_body,
+        cvm_timestamp,
+        cvm_admin_id,
+        cvm_read
+    ) VALUES (
+        p_conversation_id,
+        p_message_id,
+        true, -- from_me = true (we're sending)
+        'Admin',
+        'admin',
+        'text',
+        p_body,
+        EXTRACT(EPOCH FROM CURRENT_TIMESTAMP)::bigint,
+        p_admin_id,
+        true -- admin messages are always "read"
+    )
+    RETURNING cvm_id INTO o_message_id;
+
+    -- Update conversation
+    UPDATE public.cht_conversations
+    SET
+        cnv_message_count = cnv_message_count + 1,
+        cnv_last_message_at = CURRENT_TIMESTAMP,
+        cnv_admin_intervened = true,
+        cnv_last_admin_message_at = CURRENT_TIMESTAMP,
+        cnv_updated_at = CURRENT_TIMESTAMP
+    WHERE cnv_id = p_conversation_id;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_SEND_ADMIN_MESSAGE';
+        o_message_id := NULL;
+        RAISE NOTICE 'Error sending admin message: %', SQLERRM;
+END;
+$$;
+
+DROP PROCEDURE IF EXISTS sp_resolve_conversation_handoff(INT);
+DROP PROCEDURE IF EXISTS sp_request_conversation_handoff(INT, VARCHAR);
+
+DROP INDEX IF EXISTS idx_conversations_needs_admin;
+ALTER TABLE cht_conversations
+    DROP COLUMN IF EXISTS cnv_handoff_at,
+    DROP COLUMN IF EXISTS cnv_handoff_reason,
+    DROP COLUMN IF EXISTS cnv_needs_admin;
+
+DELETE FROM cht_parameters WHERE prm_code IN (
+    'HANDOFF_CONFIG',
+    'HANDOFF_MESSAGE',
+    'HANDOFF_ADMIN_NOTIFICATION',
+    'ERR_REQUEST_HANDOFF',
+    'ERR_RESOLVE_HANDOFF'
+);
@@ -0,0 +1,318 @@
+-- =====================================================
+-- Low-Confidence Handoff
+-- Migration: 000056_conversation_handoff.up.sql
+-- Purpose: Flag conversations the bot handed to an admin because it was not
+--          confident enough to answer, and configure the confidence policy
+-- =====================================================
+
+-- =====================================================
+-- Conversation columns: handoff flag, reason and time
+-- =====================================================
+ALTER TABLE cht_conversations
+    ADD COLUMN IF NOT EXISTS cnv_needs_admin BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS cnv_handoff_reason VARCHAR(30),
+    ADD COLUMN IF NOT EXISTS cnv_handoff_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_conversations_needs_admin ON cht_conversations(cnv_handoff_at DESC) WHERE cnv_needs_admin = TRUE;
+
+-- =====================================================
+-- Stored Procedure: sp_request_conversation_handoff
+-- Description: Flag a conversation as needing an admin. The unread count is
+--              recomputed from the unread user messages so the panel shows it.
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_request_conversation_handoff(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_conversation_id INT,
+    IN p_reason VARCHAR
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+
+    UPDATE cht_conversations
+    SET
+        cnv_needs_admin = TRUE,
+        cnv_handoff_reason = p_reason,
+        cnv_handoff_at = CURRENT_TIMESTAMP,
+        cnv_unread_count = GREATEST(
+            cnv_unread_count + 1,
+            (SELECT COUNT(*)::INT
+             FROM cht_conversation_messages
+             WHERE cvm_fk_conversation = p_conversation_id
+                AND cvm_sender_type = 'user'
+                AND cvm_read = FALSE)
+        ),
+        cnv_updated_at = CURRENT_TIMESTAMP
+    WHERE cnv_id = p_conversation_id;
+
+    IF NOT FOUND THEN
+        success := FALSE;
+        code := 'ERR_CONVERSATION_NOT_FOUND';
+        RETURN;
+    END IF;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_REQUEST_HANDOFF';
+        RAISE NOTICE 'Error requesting conversation handoff: % (SQLSTATE: %)', SQLERRM, SQLSTATE;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_resolve_conversation_handoff
+-- Description: Clear the needs-admin flag of a conversation
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_resolve_conversation_handoff(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_conversation_id INT
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+
+    UPDATE cht_conversations
+    SET
+        cnv_needs_admin = FALSE,
+        cnv_updated_at = CURRENT_TIMESTAMP
+    WHERE cnv_id = p_conversation_id;
+
+    IF NOT FOUND THEN
+        success := FALSE;
+        code := 'ERR_CONVERSATION_NOT_FOUND';
+        RETURN;
+    END IF;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_RESOLVE_HANDOFF';
+        RAISE NOTICE 'Error resolving conversation handoff: % (SQLSTATE: %)', SQLERRM, SQLSTATE;
+END;
+$$;
+
+-- =====================================================
+-- Procedure: sp_send_admin_message
+-- Description: Recreated so an admin reply resolves the handoff
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_send_admin_message(
+    OUT success boolean,
+    OUT code varchar,
+    OUT o_message_id int,
+    IN p_conversation_id int,
+    IN p_admin_id int,
+    IN p_message_id varchar,
+    IN p_body text
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_exists boolean;
+BEGIN
+    success := true;
+    code := 'OK';
+    o_message_id := NULL;
+
+    -- Check conversation exists
+    SELECT EXISTS(
+        SELECT 1 FROM public.cht_conversations WHERE cnv_id = p_conversation_id
+    ) INTO v_exists;
+
+    IF NOT v_exists THEN
+        success := false;
+        code := 'ERR_CONVERSATION_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    -- Insert admin message
+    INSERT INTO public.cht_conversation_messages (
+        cvm_fk_conversation,
+        cvm_message_id,
+        cvm_from_me,
+        cvm_sender_name,
+        cvm_sender_type,
+        cvm_message_type,
+        cvm_body,
+        cvm_timestamp,
+        cvm_admin_id,
+        cvm_read
+    ) VALUES (
+        p_conversation_id,
+        p_message_id,
+        true, -- from_me = true (we're sending)
+        'Admin',
+        'admin',
+        'text',
+        p_body,
+        EXTRACT(EPOCH FROM CURRENT_TIMESTAMP)::bigint,
+        p_admin_id,
+        true -- admin messages are always "read"
+    )
+    RETURNING cvm_id INTO o_message_id;
+
+    -- Update conversation
+    UPDATE public.cht_conversations
+    SET
+        cnv_message_count = cnv_message_count + 1,
+        cnv_last_message_at = CURRENT_TIMESTAMP,
+        cnv_admin_intervened = true,
+        cnv_last_admin_message_at = CURRENT_TIMESTAMP,
+        cnv_needs_admin = false,
+        cnv_updated_at = CURRENT_TIMESTAMP
+    WHERE cnv_id = p_conversation_id;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_SEND_ADMIN_MESSAGE';
+        o_message_id := NULL;
+        RAISE NOTICE 'Error sending admin message: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_get_all_conversations_for_admin
+-- Description: Recreated with the handoff columns and the 'handoff' filter
+-- =====================================================
+DROP FUNCTION IF EXISTS fn_get_all_conversations_for_admin(INT, INT, VARCHAR);
+
+CREATE OR REPLACE FUNCTION fn_get_all_conversations_for_admin(
+    p_limit int DEFAULT 50,
+    p_offset int DEFAULT 0,
+    p_filter varchar DEFAULT NULL -- 'unread', 'blocked', 'active', 'handoff', 'all'
+)
+RETURNS TABLE (
+    cnv_id int,
+    cnv_chat_id varchar,
+    cnv_phone_number varchar,
+    cnv_contact_name varchar,
+    cnv_is_group boolean,
+    cnv_group_name varchar,
+    cnv_last_message_at timestamp,
+    cnv_message_count int,
+    cnv_unread_count int,
+    cnv_blocked boolean,
+    cnv_admin_intervened boolean,
+    cnv_temporary boolean,
+    cnv_expires_at timestamp,
+    cnv_needs_admin boolean,
+    cnv_handoff_reason varchar,
+    cnv_handoff_at timestamp,
+    usr_id int,
+    usr_name varchar,
+    usr_identity_number varchar,
+    usr_rol varchar,
+    usr_blocked boolean,
+    last_message_preview text,
+    last_message_from_me boolean
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        c.cnv_id,
+        c.cnv_chat_id,
+        c.cnv_phone_number,
+        c.cnv_contact_name,
+        c.cnv_is_group,
+        c.cnv_group_name,
+        c.cnv_last_message_at,
+        c.cnv_message_count,
+        c.cnv_unread_count,
+        c.cnv_blocked,
+        c.cnv_admin_intervened,
+        c.cnv_temporary,
+        c.cnv_expires_at,
+        c.cnv_needs_admin,
+        c.cnv_handoff_reason,
+        c.cnv_handoff_at,
+        u.usr_id,
+        u.usr_name,
+        u.usr_identity_number,
+        u.usr_rol,
+        u.usr_blocked,
+        (SELECT cvm_body FROM cht_conversation_messages
+         WHERE cvm_fk_conversation = c.cnv_id
+         ORDER BY cvm_timestamp DESC LIMIT 1) as last_message_preview,
+        (SELECT cvm_from_me FROM cht_conversation_messages
+         WHERE cvm_fk_conversation = c.cnv_id
+         ORDER BY cvm_timestamp DESC LIMIT 1) as last_message_from_me
+    FROM public.cht_conversations c
+    LEFT JOIN public.cht_users u ON c.cnv_fk_user = u.usr_id
+    WHERE
+        c.cnv_active = true
+        AND (
+            p_filter IS NULL
+            OR (p_filter = 'unread' AND c.cnv_unread_count > 0)
+            OR (p_filter = 'blocked' AND c.cnv_blocked = true)
+            OR (p_filter = 'handoff' AND c.cnv_needs_admin = true)
+            OR (p_filter = 'active' AND c.cnv_blocked = false AND c.cnv_unread_count > 0)
+            OR (p_filter = 'all')
+        )
+    ORDER BY c.cnv_last_message_at DESC NULLS LAST
+    LIMIT p_limit
+    OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql;
+
+-- =====================================================
+-- Parameters
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'HANDOFF_CONFIG') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES (
+            'HANDOFF',
+            'HANDOFF_CONFIG',
+            '{
+                "enabled": true,
+                "minBestScore": 0.3,
+                "minHits": 1,
+                "selfCheck": {"enabled": false, "prompt": "", "model": ""},
+                "onDuty": []
+            }'::jsonb,
+            'Low-confidence handoff: minimum best combined score and hits, optional LLM self-check and admins on duty ([{"name", "whatsapp", "days": [0-6], "from": "HH:MM", "to": "HH:MM"}])'
+        );
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'HANDOFF_MESSAGE') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES (
+            'HANDOFF',
+            'HANDOFF_MESSAGE',
+            '{"message": "🙋 No tengo información suficiente para responderte con certeza. Derivé tu consulta a una persona del equipo, quien te responderá lo antes posible."}'::jsonb,
+            'Message sent when the bot hands a conversation off for low confidence'
+        );
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'HANDOFF_ADMIN_NOTIFICATION') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES (
+            'HANDOFF',
+            'HANDOFF_ADMIN_NOTIFICATION',
+            '{"message": "🔔 *Conversación derivada*\n\nContacto: %s\nMotivo: %s\nConsulta: %s\n\nRespóndela desde el panel de conversaciones."}'::jsonb,
+            'WhatsApp notification sent to the admins on duty (contact, reason and question placeholders)'
+        );
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_REQUEST_HANDOFF') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_REQUEST_HANDOFF', '{"message": "Error al derivar la conversación"}'::jsonb, 'Error flagging a conversation for an admin');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_RESOLVE_HANDOFF') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_RESOLVE_HANDOFF', '{"message": "Error al cerrar la derivación de la conversación"}'::jsonb, 'Error clearing a conversation handoff');
+    END IF;
+END $$;
+
+COMMENT ON COLUMN cht_conversations.cnv_needs_admin IS 'The bot handed the conversation to an admin (low confidence); cleared by an admin reply';
+COMMENT ON COLUMN cht_conversations.cnv_handoff_reason IS 'Why the bot handed off: no_results, few_hits, low_score, self_check';
+COMMENT ON PROCEDURE sp_request_conversation_handoff IS 'Flag a conversation as needing an admin and bump its unread count';
+COMMENT ON PROCEDURE sp_resolve_conversation_handoff IS 'Clear the needs-admin flag of a conversation';
@@ -0,0 +1,168 @@
+-- =====================================================
+-- Accent-Insensitive Full-Text Search and Search Glossary
+-- Migration: 000057_search_glossary.down.sql
+-- =====================================================
+
+DROP FUNCTION IF EXISTS fn_get_search_query_expansion(TEXT);
+DROP FUNCTION IF EXISTS fn_get_glossary_entries(VARCHAR);
+DROP PROCEDURE IF EXISTS sp_delete_glossary_entry(INT);
+DROP PROCEDURE IF EXISTS sp_save_glossary_entry(INT, VARCHAR, VARCHAR, TEXT[]);
+DROP PROCEDURE IF EXISTS sp_reindex_chunk_fts();
+
+-- Restore the generated Spanish full-text column
+DROP TRIGGER IF EXISTS trg_set_chunk_fts_vector ON cht_chunks;
+DROP FUNCTION IF EXISTS fn_set_chunk_fts_vector();
+
+ALTER TABLE cht_chunks DROP COLUMN IF EXISTS chk_fts_vector;
+ALTER TABLE cht_chunks ADD COLUMN chk_fts_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('spanish'::regconfig, chk_content)) STORED;
+CREATE INDEX IF NOT EXISTS chk_fts_idx ON public.cht_chunks USING GIN (chk_fts_vector);
+
+-- =====================================================
+-- Function: fn_similarity_search_chunks_hybrid (restored from 000051)
+-- Description: p_filter (JSONB) narrows the candidate documents:
+--              categories, includeTags (any), excludeTags, publishedFrom,
+--              publishedTo, sources and documentIds; empty keys are ignored.
+--              combined_score keeps the weighted semantic/keyword score in both
+--              modes; results are ordered by fusion_score, which is the weighted
+--              score or, with p_fusion = 'rrf', 1/(k + semantic rank) + 1/(k + keyword rank)
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_similarity_search_chunks_hybrid(
+    p_query_embedding vector,
+    p_query_text text,
+    p_limit int default 5,
+    p_min_similarity float default 0.2,
+    p_keyword_weight float default 0.15,
+    p_category varchar default null,
+    p_fusion varchar default 'weighted',
+    p_rrf_k int default 60,
+    p_with_embeddings boolean default false,
+    p_filter jsonb default null
+)
+RETURNS TABLE (
+    chk_id int,
+    chk_fk_document int,
+    chk_content text,
+    similarity_score float,
+    keyword_score float,
+    combined_score float,
+    fusion_score float,
+    doc_title varchar,
+    doc_category varchar,
+    chk_embedding vector
+) AS $$
+DECLARE
+    v_tsquery tsquery;
+    v_categories text[];
+    v_include_tags text[];
+    v_exclude_tags text[];
+    v_sources text[];
+    v_document_ids int[];
+    v_published_from timestamp;
+    v_published_to timestamp;
+BEGIN
+    v_tsquery := plainto_tsquery('spanish', p_query_text);
+
+    IF p_filter IS NOT NULL THEN
+        IF jsonb_typeof(p_filter->'categories') = 'array' THEN
+            v_categories := ARRAY(SELECT jsonb_array_elements_text(p_filter->'categories'));
+        END IF;
+        IF jsonb_typeof(p_filter->'includeTags') = 'array' THEN
+            v_include_tags := ARRAY(SELECT lower(jsonb_array_elements_text(p_filter->'includeTags')));
+        END IF;
+        IF jsonb_typeof(p_filter->'excludeTags') = 'array' THEN
+            v_exclude_tags := ARRAY(SELECT lower(jsonb_array_elements_text(p_filter->'excludeTags')));
+        END IF;
+        IF jsonb_typeof(p_filter->'sources') = 'array' THEN
+            v_sources := ARRAY(SELECT jsonb_array_elements_text(p_filter->'sources'));
+        END IF;
+        IF jsonb_typeof(p_filter->'documentIds') = 'array' THEN
+            v_document_ids := ARRAY(SELECT jsonb_array_elements_text(p_filter->'documentIds')::int);
+        END IF;
+        v_published_from := (p_filter->>'publishedFrom')::timestamptz;
+        v_published_to := (p_filter->>'publishedTo')::timestamptz;
+    END IF;
+
+    RETURN QUERY
+    WITH candidate_chunks AS (
+        SELECT
+            c.chk_id,
+            c.chk_fk_document,
+            c.chk_content,
+            c.chk_embedding,
+            (1 - (c.chk_embedding <=> p_query_embedding)) as semantic_score,
+            ts_rank(c.chk_fts_vector, v_tsquery)::double precision as keyword_rank,
+            (c.chk_fts_vector @@ v_tsquery) as keyword_match,
+            d.doc_title,
+            d.doc_category
+        FROM public.cht_chunks c
+        INNER JOIN public.cht_documents d ON c.chk_fk_document = d.doc_id
+        WHERE d.doc_active = true
+          AND c.chk_embedding IS NOT NULL
+          AND (p_category IS NULL OR p_category = '' OR d.doc_category = p_category)
+          AND (COALESCE(cardinality(v_categories), 0) = 0 OR d.doc_category = ANY(v_categories))
+          AND (COALESCE(cardinality(v_include_tags), 0) = 0 OR d.doc_tags && v_include_tags)
+          AND (COALESCE(cardinality(v_exclude_tags), 0) = 0 OR NOT (d.doc_tags && v_exclude_tags))
+          AND (COALESCE(cardinality(v_sources), 0) = 0 OR d.doc_source = ANY(v_sources))
+          AND (COALESCE(cardinality(v_document_ids), 0) = 0 OR d.doc_id = ANY(v_document_ids))
+          AND (v_published_from IS NULL OR d.doc_published_at >= v_published_from)
+          AND (v_published_to IS NULL OR d.doc_published_at <= v_published_to)
+          AND ((1 - (c.chk_embedding <=> p_query_embedding)) >= p_min_similarity
+               OR c.chk_fts_vector @@ v_tsquery)
+    ),
+    ranked_chunks AS (
+        SELECT
+            cc.*,
+            (cc.semantic_score * (1 - p_keyword_weight)) + (cc.keyword_rank * p_keyword_weight) as weighted_score,
+            ROW_NUMBER() OVER (ORDER BY cc.semantic_score DESC) as semantic_position,
+            -- Only chunks matching the full-text query take part in the keyword ranking
+            CASE WHEN cc.keyword_match
+                 THEN ROW_NUMBER() OVER (PARTITION BY cc.keyword_match ORDER BY cc.keyword_rank DESC)
+            END as keyword_position
+        FROM candidate_chunks cc
+    ),
+    fused_chunks AS (
+        SELECT
+            rc.*,
+            CASE WHEN p_fusion = 'rrf'
+                 THEN 1.0 / (p_rrf_k + rc.semantic_position)
+                      + COALESCE(1.0 / (p_rrf_k + rc.keyword_position), 0)
+                 ELSE rc.weighted_score
+            END::double precision as fused
+        FROM ranked_chunks rc
+    )
+    SELECT
+        fc.chk_id,
+        fc.chk_fk_document,
+        fc.chk_content,
+        fc.semantic_score,
+        fc.keyword_rank,
+        fc.weighted_score,
+        fc.fused,
+        fc.doc_title,
+        fc.doc_category,
+        CASE WHEN p_with_embeddings THEN fc.chk_embedding END
+    FROM fused_chunks fc
+    ORDER BY fc.fused DESC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+COMMENT ON FUNCTION fn_similarity_search_chunks_hybrid(vector, text, int, float, float, varchar, varchar, int, boolean, jsonb) IS 'Hybrid semantic + full-text search with weighted or RRF fusion and metadata filters';
+
+DROP FUNCTION IF EXISTS fn_expand_search_query(TEXT);
+DROP FUNCTION IF EXISTS fn_chunk_fts_vector(TEXT);
+DROP FUNCTION IF EXISTS fn_glossary_group_tsquery(TEXT[]);
+
+DROP TABLE IF EXISTS cht_search_glossary;
+
+DROP TEXT SEARCH CONFIGURATION IF EXISTS public.es_unaccent;
+DROP EXTENSION IF EXISTS unaccent;
+
+DELETE FROM cht_parameters WHERE prm_code IN (
+    'ERR_GLOSSARY_INVALID',
+    'ERR_GLOSSARY_TERM_EXISTS',
+    'ERR_GLOSSARY_NOT_FOUND',
+    'ERR_SAVE_GLOSSARY',
+    'ERR_DELETE_GLOSSARY',
+    'ERR_REINDEX_CHUNK_FTS'
+);
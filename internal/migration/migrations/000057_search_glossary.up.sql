@@ -0,0 +1,558 @@
+-- =====================================================
+-- Accent-Insensitive Full-Text Search and Search Glossary
+-- Migration: 000057_search_glossary.up.sql
+-- Purpose: Index and query chunks with an unaccented Spanish configuration and
+--          expand synonyms/acronyms from an admin-managed glossary, both when a
+--          chunk is indexed and when a search query is parsed
+-- =====================================================
+
+CREATE EXTENSION IF NOT EXISTS unaccent WITH SCHEMA ex;
+
+-- =====================================================
+-- Text Search Configuration: es_unaccent
+-- Description: Spanish stemming over unaccented words, so "matricula" and
+--              "matrícula" produce the same lexeme
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'es_unaccent') THEN
+        CREATE TEXT SEARCH CONFIGURATION public.es_unaccent (COPY = pg_catalog.spanish);
+        ALTER TEXT SEARCH CONFIGURATION public.es_unaccent
+            ALTER MAPPING FOR hword, hword_part, word WITH ex.unaccent, spanish_stem;
+    END IF;
+END $$;
+
+-- =====================================================
+-- Table: cht_search_glossary
+-- Description: Synonyms and acronyms. A term and its expansions are equivalent:
+--              a chunk containing any of them is indexed with all of them, and a
+--              query containing one of them matches any of them.
+-- =====================================================
+CREATE TABLE IF NOT EXISTS public.cht_search_glossary (
+    gls_id              SERIAL PRIMARY KEY,
+    gls_term            VARCHAR(150) NOT NULL,
+    gls_type            VARCHAR(20) NOT NULL DEFAULT 'synonym'
+                        CHECK (gls_type IN ('synonym', 'acronym')),
+    gls_expansions      TEXT[] NOT NULL CHECK (cardinality(gls_expansions) > 0),
+    gls_active          BOOLEAN NOT NULL DEFAULT true,
+    gls_created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    gls_updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_search_glossary_term ON cht_search_glossary(lower(gls_term)) WHERE gls_active = true;
+
+-- =====================================================
+-- Function: fn_glossary_group_tsquery
+-- Description: OR of the phrase queries of a glossary group (term + expansions);
+--              NULL when every member is made of stopwords
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_glossary_group_tsquery(
+    p_terms TEXT[]
+)
+RETURNS tsquery AS $$
+DECLARE
+    v_term TEXT;
+    v_member tsquery;
+    v_group tsquery;
+BEGIN
+    FOREACH v_term IN ARRAY p_terms LOOP
+        v_member := phraseto_tsquery('public.es_unaccent', v_term);
+        IF numnode(v_member) > 0 THEN
+            v_group := CASE WHEN v_group IS NULL THEN v_member ELSE v_group || v_member END;
+        END IF;
+    END LOOP;
+    RETURN v_group;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- =====================================================
+-- Function: fn_chunk_fts_vector
+-- Description: Full-text vector of a chunk: its unaccented Spanish lexemes plus
+--              every member of the glossary groups it mentions
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_chunk_fts_vector(
+    p_content TEXT
+)
+RETURNS tsvector AS $$
+DECLARE
+    v_vector tsvector;
+    v_group TEXT[];
+    v_group_query tsquery;
+    r RECORD;
+BEGIN
+    v_vector := to_tsvector('public.es_unaccent', COALESCE(p_content, ''));
+
+    FOR r IN
+        SELECT g.gls_term, g.gls_expansions
+        FROM cht_search_glossary g
+        WHERE g.gls_active = true
+    LOOP
+        v_group := ARRAY[r.gls_term] || r.gls_expansions;
+        v_group_query := fn_glossary_group_tsquery(v_group);
+        IF v_group_query IS NOT NULL AND v_vector @@ v_group_query THEN
+            v_vector := v_vector || to_tsvector('public.es_unaccent', array_to_string(v_group, ' '));
+        END IF;
+    END LOOP;
+
+    RETURN v_vector;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- =====================================================
+-- Function: fn_expand_search_query
+-- Description: Parses a search query with the unaccented configuration and
+--              rewrites each glossary member it contains into the OR of its group
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_expand_search_query(
+    p_query_text TEXT
+)
+RETURNS tsquery AS $$
+DECLARE
+    v_query tsquery;
+    v_group TEXT[];
+    v_group_query tsquery;
+    v_term TEXT;
+    v_target tsquery;
+    r RECORD;
+BEGIN
+    v_query := plainto_tsquery('public.es_unaccent', COALESCE(p_query_text, ''));
+    IF numnode(v_query) = 0 THEN
+        RETURN v_query;
+    END IF;
+
+    FOR r IN
+        SELECT g.gls_term, g.gls_expansions
+        FROM cht_search_glossary g
+        WHERE g.gls_active = true
+    LOOP
+        v_group := ARRAY[r.gls_term] || r.gls_expansions;
+        v_group_query := NULL;
+
+        FOREACH v_term IN ARRAY v_group LOOP
+            v_target := plainto_tsquery('public.es_unaccent', v_term);
+            IF numnode(v_target) > 0 AND v_query @> v_target THEN
+                v_group_query := COALESCE(v_group_query, fn_glossary_group_tsquery(v_group));
+                v_query := ts_rewrite(v_query, v_target, v_group_query);
+                -- The substitute already holds the whole group
+                EXIT;
+            END IF;
+        END LOOP;
+    END LOOP;
+
+    RETURN v_query;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- =====================================================
+-- Full-text vector maintained by trigger
+-- The generated column cannot read the glossary, so it becomes a plain column
+-- filled by fn_chunk_fts_vector on insert and content changes
+-- =====================================================
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'cht_chunks' AND column_name = 'chk_fts_vector' AND is_generated = 'ALWAYS'
+    ) THEN
+        ALTER TABLE cht_chunks ALTER COLUMN chk_fts_vector DROP EXPRESSION;
+    END IF;
+END $$;
+
+CREATE OR REPLACE FUNCTION fn_set_chunk_fts_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.chk_fts_vector := fn_chunk_fts_vector(NEW.chk_content);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_set_chunk_fts_vector ON cht_chunks;
+CREATE TRIGGER trg_set_chunk_fts_vector
+    BEFORE INSERT OR UPDATE OF chk_content ON cht_chunks
+    FOR EACH ROW
+    EXECUTE FUNCTION fn_set_chunk_fts_vector();
+
+UPDATE cht_chunks SET chk_fts_vector = fn_chunk_fts_vector(chk_content);
+
+-- =====================================================
+-- Stored Procedure: sp_reindex_chunk_fts
+-- Description: Recompute the full-text vectors of every chunk
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_reindex_chunk_fts(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_updated INT
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+
+    UPDATE cht_chunks SET chk_fts_vector = fn_chunk_fts_vector(chk_content);
+    GET DIAGNOSTICS o_updated = ROW_COUNT;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_REINDEX_CHUNK_FTS';
+        o_updated := 0;
+        RAISE NOTICE 'Error reindexing chunk full-text vectors: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_save_glossary_entry
+-- Description: Create (p_gls_id NULL) or update a glossary entry and reindex the
+--              chunks mentioning its old or new members
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_save_glossary_entry(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_gls_id INT,
+    OUT o_reindexed INT,
+    IN p_gls_id INT,
+    IN p_term VARCHAR,
+    IN p_type VARCHAR,
+    IN p_expansions TEXT[]
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_term VARCHAR;
+    v_expansions TEXT[];
+    v_old_query tsquery;
+    v_new_query tsquery;
+BEGIN
+    success := TRUE;
+    code := 'OK';
+    o_gls_id := NULL;
+    o_reindexed := 0;
+
+    v_term := btrim(p_term);
+    v_expansions := ARRAY(
+        SELECT DISTINCT btrim(e)
+        FROM unnest(p_expansions) e
+        WHERE btrim(e) <> '' AND lower(btrim(e)) <> lower(v_term)
+    );
+
+    IF v_term IS NULL OR v_term = '' OR cardinality(v_expansions) = 0 THEN
+        success := FALSE;
+        code := 'ERR_GLOSSARY_INVALID';
+        RETURN;
+    END IF;
+
+    -- A group made only of stopwords could never match
+    v_new_query := fn_glossary_group_tsquery(ARRAY[v_term] || v_expansions);
+    IF v_new_query IS NULL THEN
+        success := FALSE;
+        code := 'ERR_GLOSSARY_INVALID';
+        RETURN;
+    END IF;
+
+    IF EXISTS (
+        SELECT 1 FROM cht_search_glossary
+        WHERE gls_active = true
+          AND lower(ex.unaccent(gls_term)) = lower(ex.unaccent(v_term))
+          AND (p_gls_id IS NULL OR gls_id <> p_gls_id)
+    ) THEN
+        success := FALSE;
+        code := 'ERR_GLOSSARY_TERM_EXISTS';
+        RETURN;
+    END IF;
+
+    IF p_gls_id IS NULL THEN
+        INSERT INTO cht_search_glossary (gls_term, gls_type, gls_expansions)
+        VALUES (v_term, COALESCE(NULLIF(p_type, ''), 'synonym'), v_expansions)
+        RETURNING gls_id INTO o_gls_id;
+    ELSE
+        SELECT fn_glossary_group_tsquery(ARRAY[gls_term] || gls_expansions)
+        INTO v_old_query
+        FROM cht_search_glossary
+        WHERE gls_id = p_gls_id AND gls_active = true;
+
+        IF NOT FOUND THEN
+            success := FALSE;
+            code := 'ERR_GLOSSARY_NOT_FOUND';
+            RETURN;
+        END IF;
+
+        UPDATE cht_search_glossary
+        SET gls_term = v_term,
+            gls_type = COALESCE(NULLIF(p_type, ''), gls_type),
+            gls_expansions = v_expansions,
+            gls_updated_at = CURRENT_TIMESTAMP
+        WHERE gls_id = p_gls_id;
+
+        o_gls_id := p_gls_id;
+    END IF;
+
+    UPDATE cht_chunks
+    SET chk_fts_vector = fn_chunk_fts_vector(chk_content)
+    WHERE chk_fts_vector @@ v_new_query
+       OR (v_old_query IS NOT NULL AND chk_fts_vector @@ v_old_query);
+    GET DIAGNOSTICS o_reindexed = ROW_COUNT;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_SAVE_GLOSSARY';
+        o_gls_id := NULL;
+        o_reindexed := 0;
+        RAISE NOTICE 'Error saving glossary entry: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_delete_glossary_entry
+-- Description: Deactivate a glossary entry and reindex the chunks it expanded
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_delete_glossary_entry(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_reindexed INT,
+    IN p_gls_id INT
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_old_query tsquery;
+BEGIN
+    success := TRUE;
+    code := 'OK';
+    o_reindexed := 0;
+
+    UPDATE cht_search_glossary
+    SET gls_active = false,
+        gls_updated_at = CURRENT_TIMESTAMP
+    WHERE gls_id = p_gls_id AND gls_active = true
+    RETURNING fn_glossary_group_tsquery(ARRAY[gls_term] || gls_expansions) INTO v_old_query;
+
+    IF NOT FOUND THEN
+        success := FALSE;
+        code := 'ERR_GLOSSARY_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    IF v_old_query IS NOT NULL THEN
+        UPDATE cht_chunks
+        SET chk_fts_vector = fn_chunk_fts_vector(chk_content)
+        WHERE chk_fts_vector @@ v_old_query;
+        GET DIAGNOSTICS o_reindexed = ROW_COUNT;
+    END IF;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_DELETE_GLOSSARY';
+        o_reindexed := 0;
+        RAISE NOTICE 'Error deleting glossary entry: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_get_glossary_entries
+-- Description: Active glossary entries, optionally filtered by type
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_glossary_entries(
+    p_type VARCHAR DEFAULT NULL
+)
+RETURNS TABLE (
+    gls_id INT,
+    gls_term VARCHAR(150),
+    gls_type VARCHAR(20),
+    gls_expansions TEXT[],
+    gls_created_at TIMESTAMP,
+    gls_updated_at TIMESTAMP
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RETURN QUERY
+    SELECT g.gls_id, g.gls_term, g.gls_type, g.gls_expansions, g.gls_created_at, g.gls_updated_at
+    FROM cht_search_glossary g
+    WHERE g.gls_active = true
+      AND (p_type IS NULL OR p_type = '' OR g.gls_type = p_type)
+    ORDER BY lower(g.gls_term);
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_get_search_query_expansion
+-- Description: Shows how a search query is parsed, before and after the glossary
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_search_query_expansion(
+    p_query_text TEXT
+)
+RETURNS TABLE (
+    parsed_query TEXT,
+    expanded_query TEXT
+)
+LANGUAGE plpgsql STABLE
+AS $$
+BEGIN
+    RETURN QUERY
+    SELECT plainto_tsquery('public.es_unaccent', COALESCE(p_query_text, ''))::TEXT,
+           fn_expand_search_query(p_query_text)::TEXT;
+END;
+$$;
+
+-- =====================================================
+-- Function: fn_similarity_search_chunks_hybrid
+-- Description: Same as 000051; the keyword query is now accent-insensitive and
+--              expanded with the search glossary (fn_expand_search_query)
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_similarity_search_chunks_hybrid(
+    p_query_embedding vector,
+    p_query_text text,
+    p_limit int default 5,
+    p_min_similarity float default 0.2,
+    p_keyword_weight float default 0.15,
+    p_category varchar default null,
+    p_fusion varchar default 'weighted',
+    p_rrf_k int default 60,
+    p_with_embeddings boolean default false,
+    p_filter jsonb default null
+)
+RETURNS TABLE (
+    chk_id int,
+    chk_fk_document int,
+    chk_content text,
+    similarity_score float,
+    keyword_score float,
+    combined_score float,
+    fusion_score float,
+    doc_title varchar,
+    doc_category varchar,
+    chk_embedding vector
+) AS $$
+DECLARE
+    v_tsquery tsquery;
+    v_categories text[];
+    v_include_tags text[];
+    v_exclude_tags text[];
+    v_sources text[];
+    v_document_ids int[];
+    v_published_from timestamp;
+    v_published_to timestamp;
+BEGIN
+    v_tsquery := fn_expand_search_query(p_query_text);
+
+    IF p_filter IS NOT NULL THEN
+        IF jsonb_typeof(p_filter->'categories') = 'array' THEN
+            v_categories := ARRAY(SELECT jsonb_array_elements_text(p_filter->'categories'));
+        END IF;
+        IF jsonb_typeof(p_filter->'includeTags') = 'array' THEN
+            v_include_tags := ARRAY(SELECT lower(jsonb_array_elements_text(p_filter->'includeTags')));
+        END IF;
+        IF jsonb_typeof(p_filter->'excludeTags') = 'array' THEN
+            v_exclude_tags := ARRAY(SELECT lower(jsonb_array_elements_text(p_filter->'excludeTags')));
+        END IF;
+        IF jsonb_typeof(p_filter->'sources') = 'array' THEN
+            v_sources := ARRAY(SELECT jsonb_array_elements_text(p_filter->'sources'));
+        END IF;
+        IF jsonb_typeof(p_filter->'documentIds') = 'array' THEN
+            v_document_ids := ARRAY(SELECT jsonb_array_elements_text(p_filter->'documentIds')::int);
+        END IF;
+        v_published_from := (p_filter->>'publishedFrom')::timestamptz;
+        v_published_to := (p_filter->>'publishedTo')::timestamptz;
+    END IF;
+
+    RETURN QUERY
+    WITH candidate_chunks AS (
+        SELECT
+            c.chk_id,
+            c.chk_fk_document,
+            c.chk_content,
+            c.chk_embedding,
+            (1 - (c.chk_embedding <=> p_query_embedding)) as semantic_score,
+            ts_rank(c.chk_fts_vector, v_tsquery)::double precision as keyword_rank,
+            (c.chk_fts_vector @@ v_tsquery) as keyword_match,
+            d.doc_title,
+            d.doc_category
+        FROM public.cht_chunks c
+        INNER JOIN public.cht_documents d ON c.chk_fk_document = d.doc_id
+        WHERE d.doc_active = true
+          AND c.chk_embedding IS NOT NULL
+          AND (p_category IS NULL OR p_category = '' OR d.doc_category = p_category)
+          AND (COALESCE(cardinality(v_categories), 0) = 0 OR d.doc_category = ANY(v_categories))
+          AND (COALESCE(cardinality(v_include_tags), 0) = 0 OR d.doc_tags && v_include_tags)
+          AND (COALESCE(cardinality(v_exclude_tags), 0) = 0 OR NOT (d.doc_tags && v_exclude_tags))
+          AND (COALESCE(cardinality(v_sources), 0) = 0 OR d.doc_source = ANY(v_sources))
+          AND (COALESCE(cardinality(v_document_ids), 0) = 0 OR d.doc_id = ANY(v_document_ids))
+          AND (v_published_from IS NULL OR d.doc_published_at >= v_published_from)
+          AND (v_published_to IS NULL OR d.doc_published_at <= v_published_to)
+          AND ((1 - (c.chk_embedding <=> p_query_embedding)) >= p_min_similarity
+               OR c.chk_fts_vector @@ v_tsquery)
+    ),
+    ranked_chunks AS (
+        SELECT
+            cc.*,
+            (cc.semantic_score * (1 - p_keyword_weight)) + (cc.keyword_rank * p_keyword_weight) as weighted_score,
+            ROW_NUMBER() OVER (ORDER BY cc.semantic_score DESC) as semantic_position,
+            -- Only chunks matching the full-text query take part in the keyword ranking
+            CASE WHEN cc.keyword_match
+                 THEN ROW_NUMBER() OVER (PARTITION BY cc.keyword_match ORDER BY cc.keyword_rank DESC)
+            END as keyword_position
+        FROM candidate_chunks cc
+    ),
+    fused_chunks AS (
+        SELECT
+            rc.*,
+            CASE WHEN p_fusion = 'rrf'
+                 THEN 1.0 / (p_rrf_k + rc.semantic_position)
+                      + COALESCE(1.0 / (p_rrf_k + rc.keyword_position), 0)
+                 ELSE rc.weighted_score
+            END::double precision as fused
+        FROM ranked_chunks rc
+    )
+    SELECT
+        fc.chk_id,
+        fc.chk_fk_document,
+        fc.chk_content,
+        fc.semantic_score,
+        fc.keyword_rank,
+        fc.weighted_score,
+        fc.fused,
+        fc.doc_title,
+        fc.doc_category,
+        CASE WHEN p_with_embeddings THEN fc.chk_embedding END
+    FROM fused_chunks fc
+    ORDER BY fc.fused DESC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+COMMENT ON FUNCTION fn_similarity_search_chunks_hybrid(vector, text, int, float, float, varchar, varchar, int, boolean, jsonb) IS 'Hybrid semantic + accent-insensitive, glossary-expanded full-text search with weighted or RRF fusion and metadata filters';
+COMMENT ON TABLE cht_search_glossary IS 'Admin-managed synonyms and acronyms applied to chunk indexing and search queries';
+
+-- =====================================================
+-- Error Codes
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_GLOSSARY_INVALID') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_GLOSSARY_INVALID', '{"message": "El término y al menos una expansión válida son obligatorios"}'::jsonb, 'Glossary entry without term or searchable expansions');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_GLOSSARY_TERM_EXISTS') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_GLOSSARY_TERM_EXISTS', '{"message": "El término ya existe en el glosario"}'::jsonb, 'Glossary term already exists');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_GLOSSARY_NOT_FOUND') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_GLOSSARY_NOT_FOUND', '{"message": "Entrada del glosario no encontrada"}'::jsonb, 'Glossary entry not found');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_SAVE_GLOSSARY') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_SAVE_GLOSSARY', '{"message": "Error al guardar la entrada del glosario"}'::jsonb, 'Error saving glossary entry');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_DELETE_GLOSSARY') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_DELETE_GLOSSARY', '{"message": "Error al eliminar la entrada del glosario"}'::jsonb, 'Error deleting glossary entry');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_REINDEX_CHUNK_FTS') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_REINDEX_CHUNK_FTS', '{"message": "Error al reindexar la búsqueda por palabras clave"}'::jsonb, 'Error reindexing chunk full-text vectors');
+    END IF;
+END $$;
@@ -0,0 +1,432 @@
+-- =====================================================
+-- Role-Based Document Visibility
+-- Migration: 000058_document_audience.down.sql
+-- =====================================================
+
+DROP FUNCTION IF EXISTS fn_similarity_search_chunks_hybrid(vector, text, int, float, float, varchar, varchar, int, boolean, jsonb, varchar);
+
+-- =====================================================
+-- Restore document functions and procedures from 000051
+-- =====================================================
+DROP FUNCTION IF EXISTS fn_get_all_documents(int, int);
+
+-- =====================================================
+-- Function: fn_get_all_documents
+-- Description: Retrieves all active documents with pagination
+-- =====================================================
+create or replace function fn_get_all_documents(
+    p_limit int default 100,
+    p_offset int default 0
+)
+returns table (
+    doc_id int,
+    doc_category varchar,
+    doc_title varchar,
+    doc_summary text,
+    doc_source varchar,
+    doc_published_at timestamp,
+    doc_tags text[],
+    doc_active boolean,
+    doc_created_at timestamp,
+    doc_updated_at timestamp
+) as $$
+begin
+    return query
+    select
+        d.doc_id,
+        d.doc_category,
+        d.doc_title,
+        d.doc_summary,
+        d.doc_source,
+        d.doc_published_at,
+        d.doc_tags,
+        d.doc_active,
+        d.doc_created_at,
+        d.doc_updated_at
+    from public.cht_documents d
+    where d.doc_active = true
+    order by d.doc_created_at desc
+    limit p_limit
+    offset p_offset;
+end;
+$$ language plpgsql;
+
+DROP FUNCTION IF EXISTS fn_get_document_by_id(int);
+
+-- =====================================================
+-- Function: fn_get_document_by_id
+-- Description: Get specific document by ID
+-- =====================================================
+create or replace function fn_get_document_by_id(
+    p_doc_id int
+)
+returns table (
+    doc_id int,
+    doc_category varchar,
+    doc_title varchar,
+    doc_summary text,
+    doc_source varchar,
+    doc_published_at timestamp,
+    doc_tags text[],
+    doc_active boolean,
+    doc_created_at timestamp,
+    doc_updated_at timestamp
+) as $$
+begin
+    return query
+    select
+        d.doc_id,
+        d.doc_category,
+        d.doc_title,
+        d.doc_summary,
+        d.doc_source,
+        d.doc_published_at,
+        d.doc_tags,
+        d.doc_active,
+        d.doc_created_at,
+        d.doc_updated_at
+    from public.cht_documents d
+    where d.doc_id = p_doc_id
+    and d.doc_active = true;
+end;
+$$ language plpgsql;
+
+DROP FUNCTION IF EXISTS fn_get_documents_by_category(varchar, int, int);
+
+-- =====================================================
+-- Function: fn_get_documents_by_category
+-- Description: Get documents filtered by category
+-- =====================================================
+create or replace function fn_get_documents_by_category(
+    p_category varchar,
+    p_limit int default 100,
+    p_offset int default 0
+)
+returns table (
+    doc_id int,
+    doc_category varchar,
+    doc_title varchar,
+    doc_summary text,
+    doc_source varchar,
+    doc_published_at timestamp,
+    doc_tags text[],
+    doc_active boolean,
+    doc_created_at timestamp,
+    doc_updated_at timestamp
+) as $$
+begin
+    return query
+    select
+        d.doc_id,
+        d.doc_category,
+        d.doc_title,
+        d.doc_summary,
+        d.doc_source,
+        d.doc_published_at,
+        d.doc_tags,
+        d.doc_active,
+        d.doc_created_at,
+        d.doc_updated_at
+    from public.cht_documents d
+    where d.doc_category = p_category
+    and d.doc_active = true
+    order by d.doc_created_at desc
+    limit p_limit
+    offset p_offset;
+end;
+$$ language plpgsql;
+
+DROP FUNCTION IF EXISTS fn_search_documents_by_title(varchar, int);
+
+-- =====================================================
+-- Function: fn_search_documents_by_title
+-- Description: Search documents by title pattern
+-- =====================================================
+create or replace function fn_search_documents_by_title(
+    p_title_pattern varchar,
+    p_limit int default 100
+)
+returns table (
+    doc_id int,
+    doc_category varchar,
+    doc_title varchar,
+    doc_summary text,
+    doc_source varchar,
+    doc_published_at timestamp,
+    doc_tags text[],
+    doc_active boolean,
+    doc_created_at timestamp,
+    doc_updated_at timestamp
+) as $$
+begin
+    return query
+    select
+        d.doc_id,
+        d.doc_category,
+        d.doc_title,
+        d.doc_summary,
+        d.doc_source,
+        d.doc_published_at,
+        d.doc_tags,
+        d.doc_active,
+        d.doc_created_at,
+        d.doc_updated_at
+    from public.cht_documents d
+    where d.doc_title ilike '%' || p_title_pattern || '%'
+    and d.doc_active = true
+    order by d.doc_created_at desc
+    limit p_limit;
+end;
+$$ language plpgsql;
+
+DROP PROCEDURE IF EXISTS sp_create_document(varchar, varchar, text, varchar, timestamp, text[], text[]);
+
+-- =====================================================
+-- Procedure: sp_create_document
+-- Description: Creates a new document
+-- Returns: success (boolean), code (varchar), doc_id (int)
+-- =====================================================
+create or replace procedure sp_create_document(
+    out success boolean,
+    out code varchar,
+    out o_doc_id int,
+    in p_category varchar,
+    in p_title varchar,
+    in p_summary text,
+    in p_source varchar,
+    in p_published_at timestamp,
+    in p_tags text[]
+)
+language plpgsql
+as $$
+begin
+    success := true;
+    code := 'OK';
+    o_doc_id := null;
+
+    -- Validate required fields
+    if p_category is null or p_title is null then
+        success := false;
+        code := 'ERR_REQUIRED_FIELDS';
+        return;
+    end if;
+
+    -- Insert new document
+    insert into public.cht_documents (
+        doc_category,
+        doc_title,
+        doc_summary,
+        doc_source,
+        doc_published_at,
+        doc_tags,
+        doc_active
+    ) values (
+        p_category,
+        p_title,
+        p_summary,
+        p_source,
+        p_published_at,
+        coalesce(p_tags, '{}'),
+        true
+    )
+    returning doc_id into o_doc_id;
+
+exception
+    when others then
+        success := false;
+        code := 'ERR_CREATE_DOCUMENT';
+        raise notice 'Error creating document: %', sqlerrm;
+end;
+$$;
+
+DROP PROCEDURE IF EXISTS sp_update_document(int, varchar, varchar, text, varchar, timestamp, text[], text[]);
+
+-- =====================================================
+-- Procedure: sp_update_document
+-- Description: Updates an existing document
+-- Returns: success (boolean), code (varchar)
+-- =====================================================
+create or replace procedure sp_update_document(
+    out success boolean,
+    out code varchar,
+    in p_doc_id int,
+    in p_category varchar,
+    in p_title varchar,
+    in p_summary text,
+    in p_source varchar,
+    in p_published_at timestamp,
+    in p_tags text[]
+)
+language plpgsql
+as $$
+declare
+    v_exists boolean;
+begin
+    success := true;
+    code := 'OK';
+
+    -- Check if document exists
+    select exists(
+        select 1
+        from public.cht_documents
+        where doc_id = p_doc_id
+        and doc_active = true
+    ) into v_exists;
+
+    if not v_exists then
+        success := false;
+        code := 'ERR_DOCUMENT_NOT_FOUND';
+        return;
+    end if;
+
+    -- Update document
+    update public.cht_documents
+    set
+        doc_category = p_category,
+        doc_title = p_title,
+        doc_summary = p_summary,
+        doc_source = p_source,
+        doc_published_at = p_published_at,
+        doc_tags = coalesce(p_tags, '{}')
+    where doc_id = p_doc_id;
+
+exception
+    when others then
+        success := false;
+        code := 'ERR_UPDATE_DOCUMENT';
+        raise notice 'Error updating document: %', sqlerrm;
+end;
+$$;
+
+-- =====================================================
+-- Restore fn_similarity_search_chunks_hybrid from 000057
+-- Function: fn_similarity_search_chunks_hybrid
+-- Description: Same as 000051; the keyword query is now accent-insensitive and
+--              expanded with the search glossary (fn_expand_search_query)
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_similarity_search_chunks_hybrid(
+    p_query_embedding vector,
+    p_query_text text,
+    p_limit int default 5,
+    p_min_similarity float default 0.2,
+    p_keyword_weight float default 0.15,
+    p_category varchar default null,
+    p_fusion varchar default 'weighted',
+    p_rrf_k int default 60,
+    p_with_embeddings boolean default false,
+    p_filter jsonb default null
+)
+RETURNS TABLE (
+    chk_id int,
+    chk_fk_document int,
+    chk_content text,
+    similarity_score float,
+    keyword_score float,
+    combined_score float,
+    fusion_score float,
+    doc_title varchar,
+    doc_category varchar,
+    chk_embedding vector
+) AS $$
+DECLARE
+    v_tsquery tsquery;
+    v_categories text[];
+    v_include_tags text[];
+    v_exclude_tags text[];
+    v_sources text[];
+    v_document_ids int[];
+    v_published_from timestamp;
+    v_published_to timestamp;
+BEGIN
+    v_tsquery := fn_expand_search_query(p_query_text);
+
+    IF p_filter IS NOT NULL THEN
+        IF jsonb_typeof(p_filter->'categories') = 'array' THEN
+            v_categories := ARRAY(SELECT jsonb_array_elements_text(p_filter->'categories'));
+        END IF;
+        IF jsonb_typeof(p_filter->'includeTags') = 'array' THEN
+            v_include_tags := ARRAY(SELECT lower(jsonb_array_elements_text(p_filter->'includeTags')));
+        END IF;
+        IF jsonb_typeof(p_filter->'excludeTags') = 'array' THEN
+            v_exclude_tags := ARRAY(SELECT lower(jsonb_array_elements_text(p_filter->'excludeTags')));
+        END IF;
+        IF jsonb_typeof(p_filter->'sources') = 'array' THEN
+            v_sources := ARRAY(SELECT jsonb_array_elements_text(p_filter->'sources'));
+        END IF;
+        IF jsonb_typeof(p_filter->'documentIds') = 'array' THEN
+            v_document_ids := ARRAY(SELECT jsonb_array_elements_text(p_filter->'documentIds')::int);
+        END IF;
+        v_published_from := (p_filter->>'publishedFrom')::timestamptz;
+        v_published_to := (p_filter->>'publishedTo')::timestamptz;
+    END IF;
+
+    RETURN QUERY
+    WITH candidate_chunks AS (
+        SELECT
+            c.chk_id,
+            c.chk_fk_document,
+            c.chk_content,
+            c.chk_embedding,
+            (1 - (c.chk_embedding <=> p_query_embedding)) as semantic_score,
+            ts_rank(c.chk_fts_vector, v_tsquery)::double precision as keyword_rank,
+            (c.chk_fts_vector @@ v_tsquery) as keyword_match,
+            d.doc_title,
+            d.doc_category
+        FROM public.cht_chunks c
+        INNER JOIN public.cht_documents d ON c.chk_fk_document = d.doc_id
+        WHERE d.doc_active = true
+          AND c.chk_embedding IS NOT NULL
+          AND (p_category IS NULL OR p_category = '' OR d.doc_category = p_category)
+          AND (COALESCE(cardinality(v_categories), 0) = 0 OR d.doc_category = ANY(v_categories))
+          AND (COALESCE(cardinality(v_include_tags), 0) = 0 OR d.doc_tags && v_include_tags)
+          AND (COALESCE(cardinality(v_exclude_tags), 0) = 0 OR NOT (d.doc_tags && v_exclude_tags))
+          AND (COALESCE(cardinality(v_sources), 0) = 0 OR d.doc_source = ANY(v_sources))
+          AND (COALESCE(cardinality(v_document_ids), 0) = 0 OR d.doc_id = ANY(v_document_ids))
+          AND (v_published_from IS NULL OR d.doc_published_at >= v_published_from)
+          AND (v_published_to IS NULL OR d.doc_published_at <= v_published_to)
+          AND ((1 - (c.chk_embedding <=> p_query_embedding)) >= p_min_similarity
+               OR c.chk_fts_vector @@ v_tsquery)
+    ),
+    ranked_chunks AS (
+        SELECT
+            cc.*,
+            (cc.semantic_score * (1 - p_keyword_weight)) + (cc.keyword_rank * p_keyword_weight) as weighted_score,
+            ROW_NUMBER() OVER (ORDER BY cc.semantic_score DESC) as semantic_position,
+            -- Only chunks matching the full-text query take part in the keyword ranking
+            CASE WHEN cc.keyword_match
+                 THEN ROW_NUMBER() OVER (PARTITION BY cc.keyword_match ORDER BY cc.keyword_rank DESC)
+            END as keyword_position
+        FROM candidate_chunks cc
+    ),
+    fused_chunks AS (
+        SELECT
+            rc.*,
+            CASE WHEN p_fusion = 'rrf'
+                 THEN 1.0 / (p_rrf_k + rc.semantic_position)
+                      + COALESCE(1.0 / (p_rrf_k + rc.keyword_position), 0)
+                 ELSE rc.weighted_score
+            END::double precision as fused
+        FROM ranked_chunks rc
+    )
+    SELECT
+        fc.chk_id,
+        fc.chk_fk_document,
+        fc.chk_content,
+        fc.semantic_score,
+        fc.keyword_rank,
+        fc.weighted_score,
+        fc.fused,
+        fc.doc_title,
+        fc.doc_category,
+        CASE WHEN p_with_embeddings THEN fc.chk_embedding END
+    FROM fused_chunks fc
+    ORDER BY fc.fused DESC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+COMMENT ON FUNCTION fn_similarity_search_chunks_hybrid(vector, text, int, float, float, varchar, varchar, int, boolean, jsonb) IS 'Hybrid semantic + accent-insensitive, glossary-expanded full-text search with weighted or RRF fusion and metadata filters';
+
+DROP FUNCTION IF EXISTS fn_document_visible(TEXT[], VARCHAR, VARCHAR);
+
+ALTER TABLE cht_documents DROP COLUMN IF EXISTS doc_audience;
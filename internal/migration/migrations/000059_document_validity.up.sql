@@ -0,0 +1,591 @@
+-- =====================================================
+-- Document Validity Windows and Recency Boosting
+-- Migration: 000059_document_validity.up.sql
+-- Purpose: Valid-from/valid-until dates per document, enforced by hybrid search
+--          and by a scheduled job that deactivates expired documents, plus an
+--          optional recency boost of the search score
+-- =====================================================
+
+ALTER TABLE cht_documents ADD COLUMN IF NOT EXISTS doc_valid_from TIMESTAMP;
+ALTER TABLE cht_documents ADD COLUMN IF NOT EXISTS doc_valid_until TIMESTAMP;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'chk_document_validity') THEN
+        ALTER TABLE cht_documents ADD CONSTRAINT chk_document_validity
+            CHECK (doc_valid_from IS NULL OR doc_valid_until IS NULL OR doc_valid_from < doc_valid_until);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_cht_documents_valid_until ON cht_documents(doc_valid_until) WHERE doc_active = true AND doc_valid_until IS NOT NULL;
+
+COMMENT ON COLUMN cht_documents.doc_valid_from IS 'Document is not retrieved before this time (NULL = no lower bound)';
+COMMENT ON COLUMN cht_documents.doc_valid_until IS 'Document is not retrieved from this time on and is deactivated by the expiry job (NULL = never expires)';
+
+DROP FUNCTION IF EXISTS fn_get_all_documents(int, int);
+
+-- =====================================================
+-- Function: fn_get_all_documents
+-- Description: Retrieves all active documents with pagination
+-- =====================================================
+create or replace function fn_get_all_documents(
+    p_limit int default 100,
+    p_offset int default 0
+)
+returns table (
+    doc_id int,
+    doc_category varchar,
+    doc_title varchar,
+    doc_summary text,
+    doc_source varchar,
+    doc_published_at timestamp,
+    doc_tags text[],
+    doc_audience text[],
+    doc_valid_from timestamp,
+    doc_valid_until timestamp,
+    doc_active boolean,
+    doc_created_at timestamp,
+    doc_updated_at timestamp
+) as $$
+begin
+    return query
+    select
+        d.doc_id,
+        d.doc_category,
+        d.doc_title,
+        d.doc_summary,
+        d.doc_source,
+        d.doc_published_at,
+        d.doc_tags,
+        d.doc_audience,
+        d.doc_valid_from,
+        d.doc_valid_until,
+        d.doc_active,
+        d.doc_created_at,
+        d.doc_updated_at
+    from public.cht_documents d
+    where d.doc_active = true
+    order by d.doc_created_at desc
+    limit p_limit
+    offset p_offset;
+end;
+$$ language plpgsql;
+
+DROP FUNCTION IF EXISTS fn_get_document_by_id(int);
+
+-- =====================================================
+-- Function: fn_get_document_by_id
+-- Description: Get specific document by ID
+-- =====================================================
+create or replace function fn_get_document_by_id(
+    p_doc_id int
+)
+returns table (
+    doc_id int,
+    doc_category varchar,
+    doc_title varchar,
+    doc_summary text,
+    doc_source varchar,
+    doc_published_at timestamp,
+    doc_tags text[],
+    doc_audience text[],
+    doc_valid_from timestamp,
+    doc_valid_until timestamp,
+    doc_active boolean,
+    doc_created_at timestamp,
+    doc_updated_at timestamp
+) as $$
+begin
+    return query
+    select
+        d.doc_id,
+        d.doc_category,
+        d.doc_title,
+        d.doc_summary,
+        d.doc_source,
+        d.doc_published_at,
+        d.doc_tags,
+        d.doc_audience,
+        d.doc_valid_from,
+        d.doc_valid_until,
+        d.doc_active,
+        d.doc_created_at,
+        d.doc_updated_at
+    from public.cht_documents d
+    where d.doc_id = p_doc_id
+    and d.doc_active = true;
+end;
+$$ language plpgsql;
+
+DROP FUNCTION IF EXISTS fn_get_documents_by_category(varchar, int, int);
+
+-- =====================================================
+-- Function: fn_get_documents_by_category
+-- Description: Get documents filtered by category
+-- =====================================================
+create or replace function fn_get_documents_by_category(
+    p_category varchar,
+    p_limit int default 100,
+    p_offset int default 0
+)
+returns table (
+    doc_id int,
+    doc_category varchar,
+    doc_title varchar,
+    doc_summary text,
+    doc_source varchar,
+    doc_published_at timestamp,
+    doc_tags text[],
+    doc_audience text[],
+    doc_valid_from timestamp,
+    doc_valid_until timestamp,
+    doc_active boolean,
+    doc_created_at timestamp,
+    doc_updated_at timestamp
+) as $$
+begin
+    return query
+    select
+        d.doc_id,
+        d.doc_category,
+        d.doc_title,
+        d.doc_summary,
+        d.doc_source,
+        d.doc_published_at,
+        d.doc_tags,
+        d.doc_audience,
+        d.doc_valid_from,
+        d.doc_valid_until,
+        d.doc_active,
+        d.doc_created_at,
+        d.doc_updated_at
+    from public.cht_documents d
+    where d.doc_category = p_category
+    and d.doc_active = true
+    order by d.doc_created_at desc
+    limit p_limit
+    offset p_offset;
+end;
+$$ language plpgsql;
+
+DROP FUNCTION IF EXISTS fn_search_documents_by_title(varchar, int);
+
+-- =====================================================
+-- Function: fn_search_documents_by_title
+-- Description: Search documents by title pattern
+-- =====================================================
+create or replace function fn_search_documents_by_title(
+    p_title_pattern varchar,
+    p_limit int default 100
+)
+returns table (
+    doc_id int,
+    doc_category varchar,
+    doc_title varchar,
+    doc_summary text,
+    doc_source varchar,
+    doc_published_at timestamp,
+    doc_tags text[],
+    doc_audience text[],
+    doc_valid_from timestamp,
+    doc_valid_until timestamp,
+    doc_active boolean,
+    doc_created_at timestamp,
+    doc_updated_at timestamp
+) as $$
+begin
+    return query
+    select
+        d.doc_id,
+        d.doc_category,
+        d.doc_title,
+        d.doc_summary,
+        d.doc_source,
+        d.doc_published_at,
+        d.doc_tags,
+        d.doc_audience,
+        d.doc_valid_from,
+        d.doc_valid_until,
+        d.doc_active,
+        d.doc_created_at,
+        d.doc_updated_at
+    from public.cht_documents d
+    where d.doc_title ilike '%' || p_title_pattern || '%'
+    and d.doc_active = true
+    order by d.doc_created_at desc
+    limit p_limit;
+end;
+$$ language plpgsql;
+
+DROP PROCEDURE IF EXISTS sp_create_document(varchar, varchar, text, varchar, timestamp, text[], text[]);
+
+-- =====================================================
+-- Procedure: sp_create_document
+-- Description: Creates a new document
+-- Returns: success (boolean), code (varchar), doc_id (int)
+-- =====================================================
+create or replace procedure sp_create_document(
+    out success boolean,
+    out code varchar,
+    out o_doc_id int,
+    in p_category varchar,
+    in p_title varchar,
+    in p_summary text,
+    in p_source varchar,
+    in p_published_at timestamp,
+    in p_tags text[],
+    in p_audience text[] default null,
+    in p_valid_from timestamp default null,
+    in p_valid_until timestamp default null
+)
+language plpgsql
+as $$
+begin
+    success := true;
+    code := 'OK';
+    o_doc_id := null;
+
+    -- Validate required fields
+    if p_category is null or p_title is null then
+        success := false;
+        code := 'ERR_REQUIRED_FIELDS';
+        return;
+    end if;
+
+    if p_valid_from is not null and p_valid_until is not null and p_valid_from >= p_valid_until then
+        success := false;
+        code := 'ERR_DOCUMENT_VALIDITY_RANGE';
+        return;
+    end if;
+
+    -- Insert new document
+    insert into public.cht_documents (
+        doc_category,
+        doc_title,
+        doc_summary,
+        doc_source,
+        doc_published_at,
+        doc_tags,
+        doc_audience,
+        doc_valid_from,
+        doc_valid_until,
+        doc_active
+    ) values (
+        p_category,
+        p_title,
+        p_summary,
+        p_source,
+        p_published_at,
+        coalesce(p_tags, '{}'),
+        coalesce(p_audience, '{}'),
+        p_valid_from,
+        p_valid_until,
+        true
+    )
+    returning doc_id into o_doc_id;
+
+exception
+    when others then
+        success := false;
+        code := 'ERR_CREATE_DOCUMENT';
+        raise notice 'Error creating document: %', sqlerrm;
+end;
+$$;
+
+DROP PROCEDURE IF EXISTS sp_update_document(int, varchar, varchar, text, varchar, timestamp, text[], text[]);
+
+-- =====================================================
+-- Procedure: sp_update_document
+-- Description: Updates an existing document
+-- Returns: success (boolean), code (varchar)
+-- =====================================================
+create or replace procedure sp_update_document(
+    out success boolean,
+    out code varchar,
+    in p_doc_id int,
+    in p_category varchar,
+    in p_title varchar,
+    in p_summary text,
+    in p_source varchar,
+    in p_published_at timestamp,
+    in p_tags text[],
+    in p_audience text[] default null,
+    in p_valid_from timestamp default null,
+    in p_valid_until timestamp default null
+)
+language plpgsql
+as $$
+declare
+    v_exists boolean;
+begin
+    success := true;
+    code := 'OK';
+
+    -- Check if document exists
+    select exists(
+        select 1
+        from public.cht_documents
+        where doc_id = p_doc_id
+        and doc_active = true
+    ) into v_exists;
+
+    if not v_exists then
+        success := false;
+        code := 'ERR_DOCUMENT_NOT_FOUND';
+        return;
+    end if;
+
+    if p_valid_from is not null and p_valid_until is not null and p_valid_from >= p_valid_until then
+        success := false;
+        code := 'ERR_DOCUMENT_VALIDITY_RANGE';
+        return;
+    end if;
+
+    -- Update document
+    update public.cht_documents
+    set
+        doc_category = p_category,
+        doc_title = p_title,
+        doc_summary = p_summary,
+        doc_source = p_source,
+        doc_published_at = p_published_at,
+        doc_tags = coalesce(p_tags, '{}'),
+        doc_audience = coalesce(p_audience, '{}'),
+        doc_valid_from = p_valid_from,
+        doc_valid_until = p_valid_until
+    where doc_id = p_doc_id;
+
+exception
+    when others then
+        success := false;
+        code := 'ERR_UPDATE_DOCUMENT';
+        raise notice 'Error updating document: %', sqlerrm;
+end;
+$$;
+
+DROP FUNCTION IF EXISTS fn_similarity_search_chunks_hybrid(vector, text, int, float, float, varchar, varchar, int, boolean, jsonb, varchar);
+
+-- =====================================================
+-- Function: fn_similarity_search_chunks_hybrid
+-- Description: Same as 000058, plus validity windows and recency boosting:
+--              documents outside doc_valid_from/doc_valid_until are never searched,
+--              and with p_recency_weight > 0 the fusion score is multiplied by
+--              1 + weight * 0.5^(age in days / p_recency_half_life_days), the age
+--              being taken from the published, valid-from or creation date
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_similarity_search_chunks_hybrid(
+    p_query_embedding vector,
+    p_query_text text,
+    p_limit int default 5,
+    p_min_similarity float default 0.2,
+    p_keyword_weight float default 0.15,
+    p_category varchar default null,
+    p_fusion varchar default 'weighted',
+    p_rrf_k int default 60,
+    p_with_embeddings boolean default false,
+    p_filter jsonb default null,
+    p_role varchar default null,
+    p_recency_weight float default 0,
+    p_recency_half_life_days float default 180
+)
+RETURNS TABLE (
+    chk_id int,
+    chk_fk_document int,
+    chk_content text,
+    similarity_score float,
+    keyword_score float,
+    combined_score float,
+    fusion_score float,
+    doc_title varchar,
+    doc_category varchar,
+    chk_embedding vector
+) AS $$
+DECLARE
+    v_tsquery tsquery;
+    v_categories text[];
+    v_include_tags text[];
+    v_exclude_tags text[];
+    v_sources text[];
+    v_document_ids int[];
+    v_published_from timestamp;
+    v_published_to timestamp;
+BEGIN
+    v_tsquery := fn_expand_search_query(p_query_text);
+
+    IF p_filter IS NOT NULL THEN
+        IF jsonb_typeof(p_filter->'categories') = 'array' THEN
+            v_categories := ARRAY(SELECT jsonb_array_elements_text(p_filter->'categories'));
+        END IF;
+        IF jsonb_typeof(p_filter->'includeTags') = 'array' THEN
+            v_include_tags := ARRAY(SELECT lower(jsonb_array_elements_text(p_filter->'includeTags')));
+        END IF;
+        IF jsonb_typeof(p_filter->'excludeTags') = 'array' THEN
+            v_exclude_tags := ARRAY(SELECT lower(jsonb_array_elements_text(p_filter->'excludeTags')));
+        END IF;
+        IF jsonb_typeof(p_filter->'sources') = 'array' THEN
+            v_sources := ARRAY(SELECT jsonb_array_elements_text(p_filter->'sources'));
+        END IF;
+        IF jsonb_typeof(p_filter->'documentIds') = 'array' THEN
+            v_document_ids := ARRAY(SELECT jsonb_array_elements_text(p_filter->'documentIds')::int);
+        END IF;
+        v_published_from := (p_filter->>'publishedFrom')::timestamptz;
+        v_published_to := (p_filter->>'publishedTo')::timestamptz;
+    END IF;
+
+    RETURN QUERY
+    WITH candidate_chunks AS (
+        SELECT
+            c.chk_id,
+            c.chk_fk_document,
+            c.chk_content,
+            c.chk_embedding,
+            (1 - (c.chk_embedding <=> p_query_embedding)) as semantic_score,
+            ts_rank(c.chk_fts_vector, v_tsquery)::double precision as keyword_rank,
+            (c.chk_fts_vector @@ v_tsquery) as keyword_match,
+            d.doc_title,
+            d.doc_category,
+            GREATEST(EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - COALESCE(d.doc_published_at, d.doc_valid_from, d.doc_created_at))) / 86400, 0)::double precision as age_days
+        FROM public.cht_chunks c
+        INNER JOIN public.cht_documents d ON c.chk_fk_document = d.doc_id
+        WHERE d.doc_active = true
+          AND c.chk_embedding IS NOT NULL
+          AND fn_document_visible(d.doc_audience, d.doc_category, p_role)
+          AND (d.doc_valid_from IS NULL OR d.doc_valid_from <= CURRENT_TIMESTAMP)
+          AND (d.doc_valid_until IS NULL OR d.doc_valid_until > CURRENT_TIMESTAMP)
+          AND (p_category IS NULL OR p_category = '' OR d.doc_category = p_category)
+          AND (COALESCE(cardinality(v_categories), 0) = 0 OR d.doc_category = ANY(v_categories))
+          AND (COALESCE(cardinality(v_include_tags), 0) = 0 OR d.doc_tags && v_include_tags)
+          AND (COALESCE(cardinality(v_exclude_tags), 0) = 0 OR NOT (d.doc_tags && v_exclude_tags))
+          AND (COALESCE(cardinality(v_sources), 0) = 0 OR d.doc_source = ANY(v_sources))
+          AND (COALESCE(cardinality(v_document_ids), 0) = 0 OR d.doc_id = ANY(v_document_ids))
+          AND (v_published_from IS NULL OR d.doc_published_at >= v_published_from)
+          AND (v_published_to IS NULL OR d.doc_published_at <= v_published_to)
+          AND ((1 - (c.chk_embedding <=> p_query_embedding)) >= p_min_similarity
+               OR c.chk_fts_vector @@ v_tsquery)
+    ),
+    ranked_chunks AS (
+        SELECT
+            cc.*,
+            (cc.semantic_score * (1 - p_keyword_weight)) + (cc.keyword_rank * p_keyword_weight) as weighted_score,
+            ROW_NUMBER() OVER (ORDER BY cc.semantic_score DESC) as semantic_position,
+            -- Only chunks matching the full-text query take part in the keyword ranking
+            CASE WHEN cc.keyword_match
+                 THEN ROW_NUMBER() OVER (PARTITION BY cc.keyword_match ORDER BY cc.keyword_rank DESC)
+            END as keyword_position
+        FROM candidate_chunks cc
+    ),
+    fused_chunks AS (
+        SELECT
+            rc.*,
+            (CASE WHEN p_fusion = 'rrf'
+                  THEN 1.0 / (p_rrf_k + rc.semantic_position)
+                       + COALESCE(1.0 / (p_rrf_k + rc.keyword_position), 0)
+                  ELSE rc.weighted_score
+             END
+             * CASE WHEN COALESCE(p_recency_weight, 0) > 0 AND p_recency_half_life_days > 0
+                    THEN 1 + p_recency_weight * power(0.5, rc.age_days / p_recency_half_life_days)
+                    ELSE 1
+               END)::double precision as fused
+        FROM ranked_chunks rc
+    )
+    SELECT
+        fc.chk_id,
+        fc.chk_fk_document,
+        fc.chk_content,
+        fc.semantic_score,
+        fc.keyword_rank,
+        fc.weighted_score,
+        fc.fused,
+        fc.doc_title,
+        fc.doc_category,
+        CASE WHEN p_with_embeddings THEN fc.chk_embedding END
+    FROM fused_chunks fc
+    ORDER BY fc.fused DESC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+COMMENT ON FUNCTION fn_similarity_search_chunks_hybrid(vector, text, int, float, float, varchar, varchar, int, boolean, jsonb, varchar, float, float) IS 'Hybrid semantic + accent-insensitive, glossary-expanded full-text search with weighted or RRF fusion, metadata filters, role-based visibility, validity windows and recency boosting';
+-- =====================================================
+-- Stored Procedure: sp_expire_documents
+-- Description: Deactivate the active documents whose validity has ended and
+--              return them (id, title, category, validUntil) for the admin notice
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_expire_documents(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_expired JSONB
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+
+    WITH expired AS (
+        UPDATE cht_documents
+        SET doc_active = false
+        WHERE doc_active = true
+          AND doc_valid_until IS NOT NULL
+          AND doc_valid_until <= CURRENT_TIMESTAMP
+        RETURNING doc_id, doc_title, doc_category, doc_valid_until
+    )
+    SELECT COALESCE(jsonb_agg(jsonb_build_object(
+               'id', e.doc_id,
+               'title', e.doc_title,
+               'category', e.doc_category,
+               'validUntil', to_char(e.doc_valid_until, 'YYYY-MM-DD HH24:MI')
+           ) ORDER BY e.doc_valid_until), '[]'::JSONB)
+    INTO o_expired
+    FROM expired e;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_EXPIRE_DOCUMENTS';
+        o_expired := '[]'::JSONB;
+        RAISE NOTICE 'Error expiring documents: %', SQLERRM;
+END;
+$$;
+
+COMMENT ON PROCEDURE sp_create_document IS 'Creates a new document with tags, audience and validity window. Returns success, code, and doc_id';
+COMMENT ON PROCEDURE sp_update_document IS 'Updates an existing document, its tags, audience and validity window. Returns success and code';
+COMMENT ON PROCEDURE sp_expire_documents IS 'Deactivates expired documents. Returns success, code and the expired documents';
+
+-- =====================================================
+-- Parameters
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'RAG_RECENCY_WEIGHT') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('RAG_CONFIGURATION', 'RAG_RECENCY_WEIGHT', '{"value": 0}'::jsonb, 'Recency boost of the search score: a brand-new document scores up to (1 + weight) times higher (0 = disabled)');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'RAG_RECENCY_HALF_LIFE_DAYS') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('RAG_CONFIGURATION', 'RAG_RECENCY_HALF_LIFE_DAYS', '{"value": 180}'::jsonb, 'Age in days at which the recency boost is halved');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'DOCUMENT_EXPIRY_CONFIG') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('DOCUMENT_EXPIRY', 'DOCUMENT_EXPIRY_CONFIG', '{"enabled": true, "intervalMinutes": 60, "notify": []}'::jsonb, 'Scheduled deactivation of expired documents; notify lists the admin WhatsApp numbers told about them');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'DOCUMENT_EXPIRY_NOTIFICATION') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('DOCUMENT_EXPIRY', 'DOCUMENT_EXPIRY_NOTIFICATION', '{"message": "📅 Se desactivaron %d documentos vencidos:\n%s"}'::jsonb, 'Admin notice of expired documents (count, list)');
+    END IF;
+END $$;
+
+-- =====================================================
+-- Error Codes
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_DOCUMENT_VALIDITY_RANGE') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_DOCUMENT_VALIDITY_RANGE', '{"message": "La fecha de inicio de vigencia debe ser anterior a la de fin"}'::jsonb, 'Document valid-from is not before valid-until');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_EXPIRE_DOCUMENTS') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_EXPIRE_DOCUMENTS', '{"message": "Error al desactivar los documentos vencidos"}'::jsonb, 'Error deactivating expired documents');
+    END IF;
+END $$;
@@ -0,0 +1,23 @@
+-- =====================================================
+-- Curated FAQ Layer
+-- Migration: 000060_faq.down.sql
+-- =====================================================
+
+DROP PROCEDURE IF EXISTS sp_record_faq_hit(INT);
+DROP PROCEDURE IF EXISTS sp_delete_faq(INT);
+DROP PROCEDURE IF EXISTS sp_save_faq(INT, TEXT, TEXT[], TEXT, VARCHAR, TEXT, VARCHAR, FLOAT, VECTOR[], VARCHAR, BOOLEAN);
+DROP FUNCTION IF EXISTS fn_match_faqs(vector, text, varchar, float, varchar, int);
+DROP FUNCTION IF EXISTS fn_get_faqs(BOOLEAN);
+
+DROP TABLE IF EXISTS cht_faq_variants;
+DROP TABLE IF EXISTS cht_faqs;
+
+DELETE FROM cht_parameters WHERE prm_code IN (
+    'FAQ_CONFIG',
+    'ERR_FAQ_INVALID',
+    'ERR_FAQ_NOT_FOUND',
+    'ERR_SAVE_FAQ',
+    'ERR_DELETE_FAQ',
+    'ERR_RECORD_FAQ_HIT',
+    'ERR_FAQ_EMBEDDING'
+);
@@ -0,0 +1,389 @@
+-- =====================================================
+-- Curated FAQ Layer
+-- Migration: 000060_faq.up.sql
+-- Purpose: Approved answers to frequent questions (fees, office hours, authorities),
+--          matched by embeddings and keywords before the RAG search so they are
+--          answered with their exact wording instead of a generated one
+-- =====================================================
+
+-- =====================================================
+-- Table: cht_faqs
+-- Description: A canonical question, its variants and the approved answer, with
+--              optional media sent along with it and hit statistics
+-- =====================================================
+CREATE TABLE IF NOT EXISTS public.cht_faqs (
+    faq_id                  SERIAL PRIMARY KEY,
+    faq_question            TEXT NOT NULL,
+    faq_variants            TEXT[] NOT NULL DEFAULT '{}',
+    faq_answer              TEXT NOT NULL,
+    faq_category            VARCHAR(100),
+    faq_media_url           TEXT,
+    faq_media_type          VARCHAR(20) CHECK (faq_media_type IN ('image', 'document')),
+    faq_threshold           FLOAT CHECK (faq_threshold BETWEEN 0 AND 1),
+    faq_embedding_model     VARCHAR(100),
+    faq_hit_count           INT NOT NULL DEFAULT 0,
+    faq_last_hit_at         TIMESTAMP,
+    faq_active              BOOLEAN NOT NULL DEFAULT true,
+    faq_created_at          TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    faq_updated_at          TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_faqs_active ON cht_faqs(faq_active);
+
+-- =====================================================
+-- Table: cht_faq_variants
+-- Description: Match index of the FAQs: one row per phrasing (the canonical
+--              question and each variant) with its embedding and its unaccented
+--              Spanish lexemes. Rebuilt whenever the FAQ is saved.
+--              Untyped VECTOR so it follows the embedding model in use.
+-- =====================================================
+CREATE TABLE IF NOT EXISTS public.cht_faq_variants (
+    fqv_id                  SERIAL PRIMARY KEY,
+    fqv_fk_faq              INT NOT NULL REFERENCES cht_faqs(faq_id) ON DELETE CASCADE,
+    fqv_text                TEXT NOT NULL,
+    fqv_embedding           VECTOR,
+    fqv_lexemes             TEXT[] GENERATED ALWAYS AS (tsvector_to_array(to_tsvector('public.es_unaccent'::regconfig, fqv_text))) STORED
+);
+
+CREATE INDEX IF NOT EXISTS idx_faq_variants_faq ON cht_faq_variants(fqv_fk_faq);
+
+-- =====================================================
+-- Function: fn_get_faqs
+-- Description: FAQs ordered by hits, optionally including the inactive ones
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_faqs(
+    p_include_inactive BOOLEAN DEFAULT false
+)
+RETURNS TABLE (
+    faq_id INT,
+    faq_question TEXT,
+    faq_variants TEXT[],
+    faq_answer TEXT,
+    faq_category VARCHAR,
+    faq_media_url TEXT,
+    faq_media_type VARCHAR,
+    faq_threshold FLOAT,
+    faq_embedding_model VARCHAR,
+    faq_hit_count INT,
+    faq_last_hit_at TIMESTAMP,
+    faq_active BOOLEAN,
+    faq_created_at TIMESTAMP,
+    faq_updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        f.faq_id,
+        f.faq_question,
+        f.faq_variants,
+        f.faq_answer,
+        f.faq_category,
+        f.faq_media_url,
+        f.faq_media_type,
+        f.faq_threshold,
+        f.faq_embedding_model,
+        f.faq_hit_count,
+        f.faq_last_hit_at,
+        f.faq_active,
+        f.faq_created_at,
+        f.faq_updated_at
+    FROM cht_faqs f
+    WHERE p_include_inactive OR f.faq_active = true
+    ORDER BY f.faq_hit_count DESC, f.faq_id;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- =====================================================
+-- Function: fn_match_faqs
+-- Description: Best-matching active FAQs for a question. Each FAQ is scored by its
+--              best phrasing: (1 - keyword weight) * cosine similarity + keyword
+--              weight * Jaccard overlap of the unaccented lexemes. Embeddings of
+--              another model (or dimension) than p_model do not count, so after an
+--              embedding migration FAQs match by keywords until they are re-embedded.
+--              Only FAQs without category or in p_category are considered.
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_match_faqs(
+    p_query_embedding vector,
+    p_query_text text,
+    p_model varchar,
+    p_keyword_weight float default 0.3,
+    p_category varchar default null,
+    p_limit int default 3
+)
+RETURNS TABLE (
+    faq_id INT,
+    faq_question TEXT,
+    faq_answer TEXT,
+    faq_category VARCHAR,
+    faq_media_url TEXT,
+    faq_media_type VARCHAR,
+    faq_threshold FLOAT,
+    matched_variant TEXT,
+    similarity_score FLOAT,
+    keyword_score FLOAT,
+    score FLOAT
+) AS $$
+DECLARE
+    v_lexemes TEXT[];
+BEGIN
+    v_lexemes := tsvector_to_array(to_tsvector('public.es_unaccent', COALESCE(p_query_text, '')));
+
+    RETURN QUERY
+    WITH scored_variants AS (
+        SELECT
+            v.fqv_fk_faq,
+            v.fqv_text,
+            (CASE WHEN p_query_embedding IS NOT NULL
+                       AND v.fqv_embedding IS NOT NULL
+                       AND f.faq_embedding_model = p_model
+                       AND vector_dims(v.fqv_embedding) = vector_dims(p_query_embedding)
+                  THEN 1 - (v.fqv_embedding <=> p_query_embedding)
+                  ELSE 0
+             END)::double precision as semantic,
+            (CASE WHEN cardinality(v_lexemes) = 0 OR cardinality(v.fqv_lexemes) = 0 THEN 0
+                  ELSE (SELECT count(*) FROM (SELECT unnest(v_lexemes) INTERSECT SELECT unnest(v.fqv_lexemes)) i)::double precision
+                       / (SELECT count(*) FROM (SELECT unnest(v_lexemes) UNION SELECT unnest(v.fqv_lexemes)) u)
+             END)::double precision as keyword
+        FROM cht_faq_variants v
+        INNER JOIN cht_faqs f ON f.faq_id = v.fqv_fk_faq
+        WHERE f.faq_active = true
+          AND (f.faq_category IS NULL OR f.faq_category = p_category)
+    ),
+    best_variants AS (
+        SELECT DISTINCT ON (sv.fqv_fk_faq)
+            sv.*,
+            (sv.semantic * (1 - p_keyword_weight) + sv.keyword * p_keyword_weight)::double precision as combined
+        FROM scored_variants sv
+        ORDER BY sv.fqv_fk_faq, (sv.semantic * (1 - p_keyword_weight) + sv.keyword * p_keyword_weight) DESC
+    )
+    SELECT
+        f.faq_id,
+        f.faq_question,
+        f.faq_answer,
+        f.faq_category,
+        f.faq_media_url,
+        f.faq_media_type,
+        f.faq_threshold,
+        bv.fqv_text,
+        bv.semantic,
+        bv.keyword,
+        bv.combined
+    FROM best_variants bv
+    INNER JOIN cht_faqs f ON f.faq_id = bv.fqv_fk_faq
+    ORDER BY bv.combined DESC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- =====================================================
+-- Stored Procedure: sp_save_faq
+-- Description: Create (p_faq_id NULL) or update an FAQ and rebuild its match
+--              index. p_embeddings holds one embedding per phrasing: the question
+--              first, then the variants in order (duplicates removed by the caller).
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_save_faq(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_faq_id INT,
+    IN p_faq_id INT,
+    IN p_question TEXT,
+    IN p_variants TEXT[],
+    IN p_answer TEXT,
+    IN p_category VARCHAR,
+    IN p_media_url TEXT,
+    IN p_media_type VARCHAR,
+    IN p_threshold FLOAT,
+    IN p_embeddings VECTOR[],
+    IN p_embedding_model VARCHAR,
+    IN p_active BOOLEAN DEFAULT true
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_question TEXT;
+    v_answer TEXT;
+    v_phrasings TEXT[];
+BEGIN
+    success := TRUE;
+    code := 'OK';
+    o_faq_id := NULL;
+
+    v_question := btrim(p_question);
+    v_answer := btrim(p_answer);
+    IF v_question IS NULL OR v_question = '' OR v_answer IS NULL OR v_answer = '' THEN
+        success := FALSE;
+        code := 'ERR_FAQ_INVALID';
+        RETURN;
+    END IF;
+
+    IF NULLIF(p_media_url, '') IS NOT NULL AND NULLIF(p_media_type, '') IS NULL THEN
+        success := FALSE;
+        code := 'ERR_FAQ_INVALID';
+        RETURN;
+    END IF;
+
+    v_phrasings := ARRAY[v_question] || COALESCE(p_variants, '{}');
+    IF p_embeddings IS NOT NULL AND cardinality(p_embeddings) <> cardinality(v_phrasings) THEN
+        success := FALSE;
+        code := 'ERR_ARRAY_LENGTH_MISMATCH';
+        RETURN;
+    END IF;
+
+    IF p_faq_id IS NULL THEN
+        INSERT INTO cht_faqs (
+            faq_question, faq_variants, faq_answer, faq_category, faq_media_url,
+            faq_media_type, faq_threshold, faq_embedding_model, faq_active
+        )
+        VALUES (
+            v_question, COALESCE(p_variants, '{}'), v_answer, NULLIF(p_category, ''), NULLIF(p_media_url, ''),
+            NULLIF(p_media_type, ''), p_threshold, p_embedding_model, COALESCE(p_active, true)
+        )
+        RETURNING faq_id INTO o_faq_id;
+    ELSE
+        UPDATE cht_faqs
+        SET faq_question = v_question,
+            faq_variants = COALESCE(p_variants, '{}'),
+            faq_answer = v_answer,
+            faq_category = NULLIF(p_category, ''),
+            faq_media_url = NULLIF(p_media_url, ''),
+            faq_media_type = NULLIF(p_media_type, ''),
+            faq_threshold = p_threshold,
+            faq_embedding_model = p_embedding_model,
+            faq_active = COALESCE(p_active, faq_active),
+            faq_updated_at = CURRENT_TIMESTAMP
+        WHERE faq_id = p_faq_id;
+
+        IF NOT FOUND THEN
+            success := FALSE;
+            code := 'ERR_FAQ_NOT_FOUND';
+            RETURN;
+        END IF;
+
+        o_faq_id := p_faq_id;
+        DELETE FROM cht_faq_variants WHERE fqv_fk_faq = p_faq_id;
+    END IF;
+
+    INSERT INTO cht_faq_variants (fqv_fk_faq, fqv_text, fqv_embedding)
+    SELECT o_faq_id, t.phrasing, p_embeddings[t.idx]
+    FROM unnest(v_phrasings) WITH ORDINALITY AS t(phrasing, idx)
+    WHERE btrim(t.phrasing) <> '';
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_SAVE_FAQ';
+        o_faq_id := NULL;
+        RAISE NOTICE 'Error saving FAQ: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_delete_faq
+-- Description: Delete an FAQ and its match index
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_delete_faq(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_faq_id INT
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+
+    DELETE FROM cht_faqs WHERE faq_id = p_faq_id;
+
+    IF NOT FOUND THEN
+        success := FALSE;
+        code := 'ERR_FAQ_NOT_FOUND';
+    END IF;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_DELETE_FAQ';
+        RAISE NOTICE 'Error deleting FAQ: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_record_faq_hit
+-- Description: Count an FAQ answered instead of a generated response
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_record_faq_hit(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_faq_id INT
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+
+    UPDATE cht_faqs
+    SET faq_hit_count = faq_hit_count + 1,
+        faq_last_hit_at = CURRENT_TIMESTAMP
+    WHERE faq_id = p_faq_id;
+
+    IF NOT FOUND THEN
+        success := FALSE;
+        code := 'ERR_FAQ_NOT_FOUND';
+    END IF;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_RECORD_FAQ_HIT';
+        RAISE NOTICE 'Error recording FAQ hit: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Parameters
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'FAQ_CONFIG') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('FAQ', 'FAQ_CONFIG', '{"enabled": true, "threshold": 0.85, "keywordWeight": 0.3}'::jsonb, 'Curated FAQs: an FAQ scoring at least threshold (unless it sets its own) is answered instead of running RAG; keywordWeight is the lexeme overlap share of the score');
+    END IF;
+END $$;
+
+-- =====================================================
+-- Error Codes
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_FAQ_INVALID') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_FAQ_INVALID', '{"message": "La pregunta y la respuesta son obligatorias, y el contenido multimedia requiere su tipo"}'::jsonb, 'FAQ without question, answer or media type');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_FAQ_NOT_FOUND') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_FAQ_NOT_FOUND', '{"message": "Pregunta frecuente no encontrada"}'::jsonb, 'FAQ not found');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_SAVE_FAQ') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_SAVE_FAQ', '{"message": "Error al guardar la pregunta frecuente"}'::jsonb, 'Error saving FAQ');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_DELETE_FAQ') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_DELETE_FAQ', '{"message": "Error al eliminar la pregunta frecuente"}'::jsonb, 'Error deleting FAQ');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_RECORD_FAQ_HIT') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_RECORD_FAQ_HIT', '{"message": "Error al registrar el uso de la pregunta frecuente"}'::jsonb, 'Error recording FAQ hit');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_FAQ_EMBEDDING') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_FAQ_EMBEDDING', '{"message": "Error al generar los embeddings de la pregunta frecuente"}'::jsonb, 'Error embedding FAQ phrasings');
+    END IF;
+END $$;
+
+COMMENT ON FUNCTION fn_get_faqs(BOOLEAN) IS 'List FAQs ordered by hits';
+COMMENT ON FUNCTION fn_match_faqs(vector, text, varchar, float, varchar, int) IS 'Best-matching active FAQs by embedding similarity and lexeme overlap of their best phrasing';
+COMMENT ON PROCEDURE sp_save_faq IS 'Creates or updates an FAQ and rebuilds its match index. Returns success, code and faq_id';
+COMMENT ON PROCEDURE sp_delete_faq IS 'Deletes an FAQ. Returns success and code';
+COMMENT ON PROCEDURE sp_record_faq_hit IS 'Counts an FAQ answer. Returns success and code';
@@ -0,0 +1,18 @@
+-- =====================================================
+-- Knowledge-Gap Detection
+-- Migration: 000061_knowledge_gaps.down.sql
+-- =====================================================
+
+DROP PROCEDURE IF EXISTS sp_create_knowledge_gap_run(TIMESTAMP, TIMESTAMP, INT, JSONB, JSONB);
+DROP FUNCTION IF EXISTS fn_get_knowledge_gap_runs(INT, BOOLEAN, INT);
+DROP FUNCTION IF EXISTS fn_get_gap_questions(TIMESTAMP, TIMESTAMP, INT);
+
+DROP TABLE IF EXISTS cht_knowledge_gap_runs;
+
+DELETE FROM cht_parameters WHERE prm_code IN (
+    'KNOWLEDGE_GAP_CONFIG',
+    'ERR_SAVE_KNOWLEDGE_GAPS',
+    'ERR_KNOWLEDGE_GAP_RUN_NOT_FOUND',
+    'ERR_KNOWLEDGE_GAP_NO_QUESTIONS',
+    'ERR_KNOWLEDGE_GAP_EMBEDDING'
+);
@@ -0,0 +1,205 @@
+-- =====================================================
+-- Knowledge-Gap Detection
+-- Migration: 000061_knowledge_gaps.up.sql
+-- Purpose: Cluster the user questions of a period by meaning (embeddings) and
+--          store, per cluster, its representative questions, the retrieval
+--          confidence of its answers and the share answered without good
+--          sources, as the list of gaps to document
+-- =====================================================
+
+-- =====================================================
+-- Table: cht_knowledge_gap_runs
+-- Description: One clustering of the questions of a period. Clusters are stored
+--              as JSONB, worst documented first.
+-- =====================================================
+CREATE TABLE IF NOT EXISTS public.cht_knowledge_gap_runs (
+    kgr_id                  SERIAL PRIMARY KEY,
+    kgr_period_start        TIMESTAMP NOT NULL,
+    kgr_period_end          TIMESTAMP NOT NULL,
+    kgr_question_count      INT NOT NULL DEFAULT 0,
+    kgr_cluster_count       INT NOT NULL DEFAULT 0,
+    kgr_settings            JSONB NOT NULL DEFAULT '{}'::jsonb,
+    kgr_clusters            JSONB NOT NULL DEFAULT '[]'::jsonb,
+    kgr_created_at          TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_knowledge_gap_runs_created ON cht_knowledge_gap_runs(kgr_created_at DESC);
+
+-- =====================================================
+-- Function: fn_get_gap_questions
+-- Description: User questions of a period with how they were answered: the best
+--              retrieval score copied to the question (000055), whether the answer
+--              handed the conversation off and whether a curated FAQ answered it.
+--              Commands and very short messages are left out, newest first.
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_gap_questions(
+    p_start_date TIMESTAMP,
+    p_end_date TIMESTAMP,
+    p_limit INT DEFAULT 2000
+)
+RETURNS TABLE (
+    cvm_id INT,
+    question TEXT,
+    asked_at TIMESTAMP,
+    best_similarity FLOAT,
+    handed_off BOOLEAN,
+    faq_answered BOOLEAN
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        q.cvm_id,
+        btrim(q.cvm_body),
+        q.cvm_created_at,
+        q.cvm_rag_best_similarity::double precision,
+        COALESCE(a.cvm_metadata ? 'handoff', false),
+        COALESCE(a.cvm_metadata ? 'faqId', false)
+    FROM cht_conversation_messages q
+    LEFT JOIN LATERAL (
+        SELECT r.cvm_metadata
+        FROM cht_conversation_messages r
+        WHERE r.cvm_fk_conversation = q.cvm_fk_conversation
+          AND r.cvm_sender_type = 'bot'
+          AND r.cvm_id > q.cvm_id
+        ORDER BY r.cvm_id
+        LIMIT 1
+    ) a ON true
+    WHERE q.cvm_sender_type = 'user'
+      AND q.cvm_created_at BETWEEN p_start_date AND p_end_date
+      AND q.cvm_body IS NOT NULL
+      AND LENGTH(btrim(q.cvm_body)) > 10
+      AND left(btrim(q.cvm_body), 1) <> '/'
+    ORDER BY q.cvm_created_at DESC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- =====================================================
+-- Function: fn_get_knowledge_gap_runs
+-- Description: Latest clustering runs; the clusters are only returned for a
+--              single run (p_run_id) or for the latest one (p_latest)
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_knowledge_gap_runs(
+    p_run_id INT DEFAULT NULL,
+    p_latest BOOLEAN DEFAULT false,
+    p_limit INT DEFAULT 20
+)
+RETURNS TABLE (
+    kgr_id INT,
+    kgr_period_start TIMESTAMP,
+    kgr_period_end TIMESTAMP,
+    kgr_question_count INT,
+    kgr_cluster_count INT,
+    kgr_settings JSONB,
+    kgr_clusters JSONB,
+    kgr_created_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        r.kgr_id,
+        r.kgr_period_start,
+        r.kgr_period_end,
+        r.kgr_question_count,
+        r.kgr_cluster_count,
+        r.kgr_settings,
+        CASE WHEN p_run_id IS NOT NULL OR p_latest THEN r.kgr_clusters END,
+        r.kgr_created_at
+    FROM cht_knowledge_gap_runs r
+    WHERE p_run_id IS NULL OR r.kgr_id = p_run_id
+    ORDER BY r.kgr_created_at DESC, r.kgr_id DESC
+    LIMIT CASE WHEN p_latest THEN 1 ELSE p_limit END;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- =====================================================
+-- Stored Procedure: sp_create_knowledge_gap_run
+-- Description: Store a clustering run
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_create_knowledge_gap_run(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_kgr_id INT,
+    IN p_period_start TIMESTAMP,
+    IN p_period_end TIMESTAMP,
+    IN p_question_count INT,
+    IN p_settings JSONB,
+    IN p_clusters JSONB
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+    o_kgr_id := NULL;
+
+    IF jsonb_typeof(p_clusters) <> 'array' THEN
+        success := FALSE;
+        code := 'ERR_SAVE_KNOWLEDGE_GAPS';
+        RETURN;
+    END IF;
+
+    INSERT INTO cht_knowledge_gap_runs (
+        kgr_period_start,
+        kgr_period_end,
+        kgr_question_count,
+        kgr_cluster_count,
+        kgr_settings,
+        kgr_clusters
+    )
+    VALUES (
+        p_period_start,
+        p_period_end,
+        COALESCE(p_question_count, 0),
+        jsonb_array_length(p_clusters),
+        COALESCE(p_settings, '{}'::jsonb),
+        p_clusters
+    )
+    RETURNING kgr_id INTO o_kgr_id;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_SAVE_KNOWLEDGE_GAPS';
+        o_kgr_id := NULL;
+        RAISE NOTICE 'Error saving knowledge gap run: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Parameters
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'KNOWLEDGE_GAP_CONFIG') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('KNOWLEDGE_GAP', 'KNOWLEDGE_GAP_CONFIG', '{"similarityThreshold": 0.82, "minClusterSize": 3, "goodScore": 0.5, "maxQuestions": 2000, "representatives": 3, "schedule": {"enabled": false, "period": "week", "intervalHours": 168}}'::jsonb, 'Knowledge-gap clustering: questions join a cluster at similarityThreshold cosine to its centroid; an answer scoring below goodScore, or handed off, lacked good sources. schedule runs it periodically over the last period');
+    END IF;
+END $$;
+
+-- =====================================================
+-- Error Codes
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_SAVE_KNOWLEDGE_GAPS') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_SAVE_KNOWLEDGE_GAPS', '{"message": "Error al guardar el análisis de vacíos de conocimiento"}'::jsonb, 'Error saving knowledge gap run');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_KNOWLEDGE_GAP_RUN_NOT_FOUND') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_KNOWLEDGE_GAP_RUN_NOT_FOUND', '{"message": "Análisis de vacíos de conocimiento no encontrado"}'::jsonb, 'Knowledge gap run not found');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_KNOWLEDGE_GAP_NO_QUESTIONS') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_KNOWLEDGE_GAP_NO_QUESTIONS', '{"message": "No hay preguntas de usuarios en el periodo seleccionado"}'::jsonb, 'No user questions in the period');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_KNOWLEDGE_GAP_EMBEDDING') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_KNOWLEDGE_GAP_EMBEDDING', '{"message": "Error al generar los embeddings de las preguntas"}'::jsonb, 'Failed to embed the questions to cluster');
+    END IF;
+END $$;
+
+COMMENT ON FUNCTION fn_get_gap_questions(TIMESTAMP, TIMESTAMP, INT) IS 'User questions of a period with the retrieval score, handoff and FAQ flags of their answer';
+COMMENT ON FUNCTION fn_get_knowledge_gap_runs(INT, BOOLEAN, INT) IS 'Knowledge-gap clustering runs, with clusters for a single or the latest run';
+COMMENT ON PROCEDURE sp_create_knowledge_gap_run IS 'Stores a knowledge-gap clustering run. Returns success, code and kgr_id';
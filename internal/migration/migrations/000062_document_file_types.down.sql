@@ -0,0 +1,9 @@
+-- =====================================================
+-- Document File Types
+-- Migration: 000062_document_file_types.down.sql
+-- =====================================================
+
+DELETE FROM cht_parameters WHERE prm_code IN (
+    'ERR_DOCUMENT_PROCESSING',
+    'ERR_UNSUPPORTED_FILE_TYPE'
+);
@@ -0,0 +1,17 @@
+-- =====================================================
+-- Document File Types
+-- Migration: 000062_document_file_types.up.sql
+-- Purpose: Error codes of the file-type-aware upload (PDF, DOCX, ODT)
+-- =====================================================
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_DOCUMENT_PROCESSING') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_DOCUMENT_PROCESSING', '{"message": "Error al procesar el archivo del documento"}'::jsonb, 'Error processing document file');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_UNSUPPORTED_FILE_TYPE') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_UNSUPPORTED_FILE_TYPE', '{"message": "Tipo de archivo no soportado. Use PDF, DOCX u ODT"}'::jsonb, 'Unsupported document file type');
+    END IF;
+END $$;
@@ -0,0 +1,30 @@
+-- =====================================================
+-- Web Page and Sitemap Ingestion
+-- Migration: 000063_web_sources.down.sql
+-- =====================================================
+
+DROP PROCEDURE IF EXISTS sp_set_document_content_hash(INT, VARCHAR);
+DROP PROCEDURE IF EXISTS sp_sync_web_document(VARCHAR, VARCHAR, VARCHAR, TEXT, VARCHAR, TEXT[], TEXT[]);
+DROP PROCEDURE IF EXISTS sp_record_web_crawl(INT, JSONB);
+DROP PROCEDURE IF EXISTS sp_delete_web_source(INT);
+DROP PROCEDURE IF EXISTS sp_save_web_source(INT, VARCHAR, BOOLEAN, VARCHAR, TEXT[], TEXT[], INT, INT, TEXT[], TEXT[], INT, INT, INT, BOOLEAN);
+DROP FUNCTION IF EXISTS fn_get_due_web_sources();
+DROP FUNCTION IF EXISTS fn_get_web_sources(BOOLEAN, INT);
+
+DROP TABLE IF EXISTS cht_web_sources;
+
+DROP INDEX IF EXISTS idx_cht_documents_source;
+ALTER TABLE cht_documents DROP COLUMN IF EXISTS doc_content_hash;
+
+DELETE FROM cht_parameters WHERE prm_code IN (
+    'WEB_CRAWL_CONFIG',
+    'ERR_WEB_SOURCE_NOT_FOUND',
+    'ERR_WEB_SOURCE_EXISTS',
+    'ERR_WEB_SOURCE_INVALID',
+    'ERR_SAVE_WEB_SOURCE',
+    'ERR_DELETE_WEB_SOURCE',
+    'ERR_RECORD_WEB_CRAWL',
+    'ERR_SYNC_WEB_DOCUMENT',
+    'ERR_WEB_CRAWL',
+    'ERR_WEB_CRAWL_IN_PROGRESS'
+);
@@ -0,0 +1,449 @@
+-- =====================================================
+-- Web Page and Sitemap Ingestion
+-- Migration: 000063_web_sources.up.sql
+-- Purpose: Crawl a website (from a page or a sitemap) into one document per page,
+--          keyed by doc_source = page URL. A content hash per document lets
+--          re-crawls re-embed only the pages whose content changed.
+-- =====================================================
+
+ALTER TABLE cht_documents ADD COLUMN IF NOT EXISTS doc_content_hash VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_cht_documents_source ON cht_documents(doc_source) WHERE doc_source IS NOT NULL;
+
+-- =====================================================
+-- Table: cht_web_sources
+-- Description: A website to crawl into documents and how to crawl it
+-- =====================================================
+CREATE TABLE IF NOT EXISTS public.cht_web_sources (
+    wbs_id                  SERIAL PRIMARY KEY,
+    wbs_url                 VARCHAR(500) NOT NULL,
+    wbs_is_sitemap          BOOLEAN NOT NULL DEFAULT false,
+    wbs_category            VARCHAR(50) NOT NULL REFERENCES cht_parameters(prm_code),
+    wbs_tags                TEXT[] NOT NULL DEFAULT '{}',
+    wbs_audience            TEXT[] NOT NULL DEFAULT '{}',
+    wbs_max_depth           INT NOT NULL DEFAULT 2,
+    wbs_max_pages           INT NOT NULL DEFAULT 100,
+    wbs_include_patterns    TEXT[] NOT NULL DEFAULT '{}',
+    wbs_exclude_patterns    TEXT[] NOT NULL DEFAULT '{}',
+    wbs_recrawl_hours       INT,
+    wbs_chunk_size          INT NOT NULL DEFAULT 1000,
+    wbs_chunk_overlap       INT NOT NULL DEFAULT 200,
+    wbs_last_crawled_at     TIMESTAMP,
+    wbs_last_result         JSONB,
+    wbs_active              BOOLEAN NOT NULL DEFAULT true,
+    wbs_created_at          TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    wbs_updated_at          TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_web_sources_limits CHECK (wbs_max_depth >= 0 AND wbs_max_pages > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_web_sources_url ON cht_web_sources(wbs_url);
+
+-- =====================================================
+-- Function: fn_get_web_sources
+-- Description: Web sources, optionally including inactive ones
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_web_sources(
+    p_include_inactive BOOLEAN DEFAULT false,
+    p_source_id INT DEFAULT NULL
+)
+RETURNS TABLE (
+    wbs_id INT,
+    wbs_url VARCHAR,
+    wbs_is_sitemap BOOLEAN,
+    wbs_category VARCHAR,
+    wbs_tags TEXT[],
+    wbs_audience TEXT[],
+    wbs_max_depth INT,
+    wbs_max_pages INT,
+    wbs_include_patterns TEXT[],
+    wbs_exclude_patterns TEXT[],
+    wbs_recrawl_hours INT,
+    wbs_chunk_size INT,
+    wbs_chunk_overlap INT,
+    wbs_last_crawled_at TIMESTAMP,
+    wbs_last_result JSONB,
+    wbs_active BOOLEAN,
+    wbs_created_at TIMESTAMP,
+    wbs_updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        s.wbs_id, s.wbs_url, s.wbs_is_sitemap, s.wbs_category, s.wbs_tags, s.wbs_audience,
+        s.wbs_max_depth, s.wbs_max_pages, s.wbs_include_patterns, s.wbs_exclude_patterns,
+        s.wbs_recrawl_hours, s.wbs_chunk_size, s.wbs_chunk_overlap,
+        s.wbs_last_crawled_at, s.wbs_last_result, s.wbs_active, s.wbs_created_at, s.wbs_updated_at
+    FROM cht_web_sources s
+    WHERE (p_include_inactive OR s.wbs_active)
+      AND (p_source_id IS NULL OR s.wbs_id = p_source_id)
+    ORDER BY s.wbs_url;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- =====================================================
+-- Function: fn_get_due_web_sources
+-- Description: Active web sources with a re-crawl interval that is due
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_due_web_sources()
+RETURNS TABLE (
+    wbs_id INT,
+    wbs_url VARCHAR,
+    wbs_is_sitemap BOOLEAN,
+    wbs_category VARCHAR,
+    wbs_tags TEXT[],
+    wbs_audience TEXT[],
+    wbs_max_depth INT,
+    wbs_max_pages INT,
+    wbs_include_patterns TEXT[],
+    wbs_exclude_patterns TEXT[],
+    wbs_recrawl_hours INT,
+    wbs_chunk_size INT,
+    wbs_chunk_overlap INT,
+    wbs_last_crawled_at TIMESTAMP,
+    wbs_last_result JSONB,
+    wbs_active BOOLEAN,
+    wbs_created_at TIMESTAMP,
+    wbs_updated_at TIMESTAMP
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        s.wbs_id, s.wbs_url, s.wbs_is_sitemap, s.wbs_category, s.wbs_tags, s.wbs_audience,
+        s.wbs_max_depth, s.wbs_max_pages, s.wbs_include_patterns, s.wbs_exclude_patterns,
+        s.wbs_recrawl_hours, s.wbs_chunk_size, s.wbs_chunk_overlap,
+        s.wbs_last_crawled_at, s.wbs_last_result, s.wbs_active, s.wbs_created_at, s.wbs_updated_at
+    FROM cht_web_sources s
+    WHERE s.wbs_active
+      AND s.wbs_recrawl_hours IS NOT NULL
+      AND s.wbs_recrawl_hours > 0
+      AND (s.wbs_last_crawled_at IS NULL
+           OR s.wbs_last_crawled_at + make_interval(hours => s.wbs_recrawl_hours) <= CURRENT_TIMESTAMP)
+    ORDER BY s.wbs_last_crawled_at NULLS FIRST;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- =====================================================
+-- Stored Procedure: sp_save_web_source
+-- Description: Create (p_source_id NULL) or update a web source
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_save_web_source(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_source_id INT,
+    IN p_source_id INT,
+    IN p_url VARCHAR,
+    IN p_is_sitemap BOOLEAN,
+    IN p_category VARCHAR,
+    IN p_tags TEXT[],
+    IN p_audience TEXT[],
+    IN p_max_depth INT,
+    IN p_max_pages INT,
+    IN p_include_patterns TEXT[],
+    IN p_exclude_patterns TEXT[],
+    IN p_recrawl_hours INT,
+    IN p_chunk_size INT,
+    IN p_chunk_overlap INT,
+    IN p_active BOOLEAN DEFAULT true
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+    o_source_id := NULL;
+
+    IF p_url IS NULL OR btrim(p_url) = '' OR p_category IS NULL THEN
+        success := FALSE;
+        code := 'ERR_REQUIRED_FIELDS';
+        RETURN;
+    END IF;
+
+    IF p_source_id IS NULL THEN
+        INSERT INTO cht_web_sources (
+            wbs_url, wbs_is_sitemap, wbs_category, wbs_tags, wbs_audience,
+            wbs_max_depth, wbs_max_pages, wbs_include_patterns, wbs_exclude_patterns,
+            wbs_recrawl_hours, wbs_chunk_size, wbs_chunk_overlap, wbs_active
+        )
+        VALUES (
+            btrim(p_url), COALESCE(p_is_sitemap, false), p_category, COALESCE(p_tags, '{}'), COALESCE(p_audience, '{}'),
+            COALESCE(p_max_depth, 2), COALESCE(p_max_pages, 100), COALESCE(p_include_patterns, '{}'), COALESCE(p_exclude_patterns, '{}'),
+            p_recrawl_hours, COALESCE(p_chunk_size, 1000), COALESCE(p_chunk_overlap, 200), COALESCE(p_active, true)
+        )
+        RETURNING wbs_id INTO o_source_id;
+        RETURN;
+    END IF;
+
+    UPDATE cht_web_sources
+    SET wbs_url = btrim(p_url),
+        wbs_is_sitemap = COALESCE(p_is_sitemap, false),
+        wbs_category = p_category,
+        wbs_tags = COALESCE(p_tags, '{}'),
+        wbs_audience = COALESCE(p_audience, '{}'),
+        wbs_max_depth = COALESCE(p_max_depth, 2),
+        wbs_max_pages = COALESCE(p_max_pages, 100),
+        wbs_include_patterns = COALESCE(p_include_patterns, '{}'),
+        wbs_exclude_patterns = COALESCE(p_exclude_patterns, '{}'),
+        wbs_recrawl_hours = p_recrawl_hours,
+        wbs_chunk_size = COALESCE(p_chunk_size, 1000),
+        wbs_chunk_overlap = COALESCE(p_chunk_overlap, 200),
+        wbs_active = COALESCE(p_active, true),
+        wbs_updated_at = CURRENT_TIMESTAMP
+    WHERE wbs_id = p_source_id
+    RETURNING wbs_id INTO o_source_id;
+
+    IF o_source_id IS NULL THEN
+        success := FALSE;
+        code := 'ERR_WEB_SOURCE_NOT_FOUND';
+    END IF;
+
+EXCEPTION
+    WHEN unique_violation THEN
+        success := FALSE;
+        code := 'ERR_WEB_SOURCE_EXISTS';
+        o_source_id := NULL;
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_SAVE_WEB_SOURCE';
+        o_source_id := NULL;
+        RAISE NOTICE 'Error saving web source: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_delete_web_source
+-- Description: Delete a web source (its documents are kept)
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_delete_web_source(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_source_id INT
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+
+    DELETE FROM cht_web_sources WHERE wbs_id = p_source_id;
+    IF NOT FOUND THEN
+        success := FALSE;
+        code := 'ERR_WEB_SOURCE_NOT_FOUND';
+    END IF;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_DELETE_WEB_SOURCE';
+        RAISE NOTICE 'Error deleting web source: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_record_web_crawl
+-- Description: Store the outcome of a crawl of a web source
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_record_web_crawl(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_source_id INT,
+    IN p_result JSONB
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+
+    UPDATE cht_web_sources
+    SET wbs_last_crawled_at = CURRENT_TIMESTAMP,
+        wbs_last_result = p_result
+    WHERE wbs_id = p_source_id;
+
+    IF NOT FOUND THEN
+        success := FALSE;
+        code := 'ERR_WEB_SOURCE_NOT_FOUND';
+    END IF;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_RECORD_WEB_CRAWL';
+        RAISE NOTICE 'Error recording web crawl: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_sync_web_document
+-- Description: Create or update the document of a crawled page (doc_source = URL).
+--              o_status is created, updated (content hash changed: the chunks are
+--              deleted and the hash cleared until the new chunks are stored),
+--              unchanged, or inactive (deactivated by an admin: left alone).
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_sync_web_document(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_doc_id INT,
+    OUT o_status VARCHAR,
+    IN p_source VARCHAR,
+    IN p_category VARCHAR,
+    IN p_title VARCHAR,
+    IN p_summary TEXT,
+    IN p_content_hash VARCHAR,
+    IN p_tags TEXT[],
+    IN p_audience TEXT[]
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_active BOOLEAN;
+    v_hash VARCHAR;
+BEGIN
+    success := TRUE;
+    code := 'OK';
+    o_doc_id := NULL;
+    o_status := NULL;
+
+    IF p_source IS NULL OR p_category IS NULL OR p_title IS NULL THEN
+        success := FALSE;
+        code := 'ERR_REQUIRED_FIELDS';
+        RETURN;
+    END IF;
+
+    SELECT doc_id, doc_active, doc_content_hash
+    INTO o_doc_id, v_active, v_hash
+    FROM cht_documents
+    WHERE doc_source = p_source
+    ORDER BY doc_active DESC, doc_id DESC
+    LIMIT 1;
+
+    IF o_doc_id IS NULL THEN
+        INSERT INTO cht_documents (
+            doc_category, doc_title, doc_summary, doc_source, doc_tags, doc_audience, doc_active
+        )
+        VALUES (
+            p_category, left(p_title, 200), p_summary, p_source, COALESCE(p_tags, '{}'), COALESCE(p_audience, '{}'), true
+        )
+        RETURNING doc_id INTO o_doc_id;
+        o_status := 'created';
+        RETURN;
+    END IF;
+
+    IF NOT v_active THEN
+        o_status := 'inactive';
+        RETURN;
+    END IF;
+
+    IF v_hash IS NOT NULL AND v_hash = p_content_hash THEN
+        o_status := 'unchanged';
+        RETURN;
+    END IF;
+
+    UPDATE cht_documents
+    SET doc_category = p_category,
+        doc_title = left(p_title, 200),
+        doc_summary = p_summary,
+        doc_tags = COALESCE(p_tags, '{}'),
+        doc_audience = COALESCE(p_audience, '{}'),
+        doc_content_hash = NULL,
+        doc_updated_at = CURRENT_TIMESTAMP
+    WHERE doc_id = o_doc_id;
+
+    DELETE FROM cht_chunks WHERE chk_fk_document = o_doc_id;
+    o_status := 'updated';
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_SYNC_WEB_DOCUMENT';
+        o_doc_id := NULL;
+        o_status := NULL;
+        RAISE NOTICE 'Error syncing web document: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Stored Procedure: sp_set_document_content_hash
+-- Description: Record the content hash of a document once its chunks are stored
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_set_document_content_hash(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_doc_id INT,
+    IN p_content_hash VARCHAR
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+
+    UPDATE cht_documents SET doc_content_hash = p_content_hash WHERE doc_id = p_doc_id;
+    IF NOT FOUND THEN
+        success := FALSE;
+        code := 'ERR_DOCUMENT_NOT_FOUND';
+    END IF;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_SYNC_WEB_DOCUMENT';
+        RAISE NOTICE 'Error setting document content hash: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Parameters
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'WEB_CRAWL_CONFIG') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('WEB_CRAWL', 'WEB_CRAWL_CONFIG', '{"userAgent": "ApiChatbotCrawler", "requestDelayMs": 250, "timeoutSeconds": 20, "maxPageBytes": 5242880, "maxPages": 1000, "schedule": {"enabled": true, "checkIntervalMinutes": 60}}'::jsonb, 'Web crawler: user agent matched against robots.txt, delay between requests, per-request timeout, page size cap and hard page cap per crawl. schedule checks for web sources due a re-crawl');
+    END IF;
+END $$;
+
+-- =====================================================
+-- Error Codes
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_WEB_SOURCE_NOT_FOUND') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_WEB_SOURCE_NOT_FOUND', '{"message": "Fuente web no encontrada"}'::jsonb, 'Web source not found');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_WEB_SOURCE_EXISTS') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_WEB_SOURCE_EXISTS', '{"message": "Ya existe una fuente web con esa URL"}'::jsonb, 'Web source URL already registered');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_WEB_SOURCE_INVALID') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_WEB_SOURCE_INVALID', '{"message": "La URL o los patrones de la fuente web no son válidos"}'::jsonb, 'Invalid web source URL or patterns');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_SAVE_WEB_SOURCE') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_SAVE_WEB_SOURCE', '{"message": "Error al guardar la fuente web"}'::jsonb, 'Error saving web source');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_DELETE_WEB_SOURCE') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_DELETE_WEB_SOURCE', '{"message": "Error al eliminar la fuente web"}'::jsonb, 'Error deleting web source');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_RECORD_WEB_CRAWL') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_RECORD_WEB_CRAWL', '{"message": "Error al registrar el rastreo web"}'::jsonb, 'Error recording web crawl');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_SYNC_WEB_DOCUMENT') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_SYNC_WEB_DOCUMENT', '{"message": "Error al sincronizar el documento de la página web"}'::jsonb, 'Error syncing web page document');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_WEB_CRAWL') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_WEB_CRAWL', '{"message": "No se pudo rastrear el sitio web"}'::jsonb, 'Web crawl failed');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_WEB_CRAWL_IN_PROGRESS') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_WEB_CRAWL_IN_PROGRESS', '{"message": "La fuente web ya se está rastreando"}'::jsonb, 'Web source crawl already running');
+    END IF;
+END $$;
+
+COMMENT ON TABLE cht_web_sources IS 'Websites crawled into one document per page (doc_source = URL)';
+COMMENT ON PROCEDURE sp_sync_web_document IS 'Creates or updates the document of a crawled page. Returns success, code, doc_id and status';
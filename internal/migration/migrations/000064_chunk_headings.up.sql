@@ -0,0 +1,333 @@
+-- =====================================================
+-- Chunk Heading Paths
+-- Migration: 000064_chunk_headings.up.sql
+-- Purpose: Store the heading path of each chunk (e.g. {"Admisiones", "Requisitos"})
+--          so Markdown and plain-text documents chunked along their headings
+--          can be filtered by heading and cited with their section
+-- =====================================================
+
+ALTER TABLE cht_chunks ADD COLUMN IF NOT EXISTS chk_headings TEXT[] NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN cht_chunks.chk_headings IS 'Heading path of the chunk within its document, outermost first (empty when the document has no headings)';
+
+-- Markdown and plain-text files are now accepted by the document upload
+UPDATE cht_parameters
+SET prm_data = '{"message": "Tipo de archivo no soportado. Use PDF, DOCX, ODT, Markdown o TXT"}'::jsonb
+WHERE prm_code = 'ERR_UNSUPPORTED_FILE_TYPE';
+
+-- =====================================================
+-- Function: fn_get_chunks_by_document
+-- Description: Get all chunks for a specific document in document order
+-- =====================================================
+DROP FUNCTION IF EXISTS fn_get_chunks_by_document(int);
+
+CREATE OR REPLACE FUNCTION fn_get_chunks_by_document(
+    p_doc_id int
+)
+RETURNS TABLE (
+    chk_id int,
+    chk_fk_document int,
+    chk_content text,
+    chk_position int,
+    chk_headings text[],
+    chk_created_at timestamp,
+    chk_updated_at timestamp
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        c.chk_id,
+        c.chk_fk_document,
+        c.chk_content,
+        c.chk_position,
+        c.chk_headings,
+        c.chk_created_at,
+        c.chk_updated_at
+    FROM public.cht_chunks c
+    WHERE c.chk_fk_document = p_doc_id
+    ORDER BY c.chk_position, c.chk_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- =====================================================
+-- Function: fn_get_chunk_by_id
+-- Description: Get specific chunk by ID
+-- =====================================================
+DROP FUNCTION IF EXISTS fn_get_chunk_by_id(int);
+
+CREATE OR REPLACE FUNCTION fn_get_chunk_by_id(
+    p_chk_id int
+)
+RETURNS TABLE (
+    chk_id int,
+    chk_fk_document int,
+    chk_content text,
+    chk_position int,
+    chk_headings text[],
+    chk_created_at timestamp,
+    chk_updated_at timestamp
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        c.chk_id,
+        c.chk_fk_document,
+        c.chk_content,
+        c.chk_position,
+        c.chk_headings,
+        c.chk_created_at,
+        c.chk_updated_at
+    FROM public.cht_chunks c
+    WHERE c.chk_id = p_chk_id;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP PROCEDURE IF EXISTS sp_bulk_create_chunks(int, text[], vector[]);
+
+-- =====================================================
+-- Procedure: sp_bulk_create_chunks
+-- Description: Creates multiple chunks for a document at once, keeping the
+--              array order as their positions after any existing chunks.
+--              p_headings is a JSON array aligned with p_contents holding the
+--              heading path of each chunk (e.g. [["Admisiones", "Requisitos"]])
+-- Returns: success (boolean), code (varchar), chunks_created (int)
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_bulk_create_chunks(
+    OUT success boolean,
+    OUT code varchar,
+    OUT o_chunks_created int,
+    IN p_doc_id int,
+    IN p_contents text[],
+    IN p_embeddings vector[],
+    IN p_headings jsonb default null
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_doc_exists boolean;
+    v_next_position int;
+BEGIN
+    success := true;
+    code := 'OK';
+    o_chunks_created := 0;
+
+    -- 1. Validate document exists
+    SELECT EXISTS(
+        SELECT 1
+        FROM public.cht_documents
+        WHERE doc_id = p_doc_id
+        AND doc_active = true
+    ) INTO v_doc_exists;
+
+    IF NOT v_doc_exists THEN
+        success := false;
+        code := 'ERR_DOCUMENT_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    IF array_length(p_contents, 1) != array_length(p_embeddings, 1) THEN
+        success := false;
+        code := 'ERR_ARRAY_LENGTH_MISMATCH';
+        RAISE NOTICE 'Error: p_contents array length does not match p_embeddings array length.';
+        RETURN;
+    END IF;
+
+    SELECT COALESCE(MAX(c.chk_position) + 1, 0) INTO v_next_position
+    FROM public.cht_chunks c
+    WHERE c.chk_fk_document = p_doc_id;
+
+    -- 2. Bulk insert chunks (array index = position) and their statistics
+    WITH inserted_chunks AS (
+        INSERT INTO public.cht_chunks (
+            chk_fk_document,
+            chk_content,
+            chk_embedding,
+            chk_position,
+            chk_headings
+        )
+        SELECT
+            p_doc_id,
+            t.content,
+            t.embedding,
+            v_next_position + (t.idx - 1)::int,
+            CASE WHEN jsonb_typeof(p_headings->(t.idx::int - 1)) = 'array'
+                 THEN ARRAY(SELECT jsonb_array_elements_text(p_headings->(t.idx::int - 1)))
+                 ELSE '{}'::text[]
+            END
+        FROM unnest(p_contents, p_embeddings) WITH ORDINALITY AS t(content, embedding, idx)
+        RETURNING chk_id
+    )
+    INSERT INTO public.cht_chunk_statistics (
+        cst_fk_chunk,
+        cst_usage_count
+    )
+    SELECT
+        chk_id,
+        0
+    FROM inserted_chunks;
+
+    -- 3. Set output parameter
+    o_chunks_created := array_length(p_contents, 1);
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_BULK_CREATE_CHUNKS';
+        o_chunks_created := 0;
+        RAISE NOTICE 'Error bulk creating chunks: %', SQLERRM;
+END;
+$$;
+
+DROP FUNCTION IF EXISTS fn_similarity_search_chunks_hybrid(vector, text, int, float, float, varchar, varchar, int, boolean, jsonb, varchar, float, float);
+
+-- =====================================================
+-- Function: fn_similarity_search_chunks_hybrid
+-- Description: Same as 000059, plus the heading path of each chunk in the
+--              results (for citations) and a "headings" filter: only chunks
+--              under one of these headings (case-insensitive) are searched
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_similarity_search_chunks_hybrid(
+    p_query_embedding vector,
+    p_query_text text,
+    p_limit int default 5,
+    p_min_similarity float default 0.2,
+    p_keyword_weight float default 0.15,
+    p_category varchar default null,
+    p_fusion varchar default 'weighted',
+    p_rrf_k int default 60,
+    p_with_embeddings boolean default false,
+    p_filter jsonb default null,
+    p_role varchar default null,
+    p_recency_weight float default 0,
+    p_recency_half_life_days float default 180
+)
+RETURNS TABLE (
+    chk_id int,
+    chk_fk_document int,
+    chk_content text,
+    similarity_score float,
+    keyword_score float,
+    combined_score float,
+    fusion_score float,
+    doc_title varchar,
+    doc_category varchar,
+    chk_embedding vector,
+    chk_headings text[]
+) AS $$
+DECLARE
+    v_tsquery tsquery;
+    v_categories text[];
+    v_include_tags text[];
+    v_exclude_tags text[];
+    v_sources text[];
+    v_document_ids int[];
+    v_headings text[];
+    v_published_from timestamp;
+    v_published_to timestamp;
+BEGIN
+    v_tsquery := fn_expand_search_query(p_query_text);
+
+    IF p_filter IS NOT NULL THEN
+        IF jsonb_typeof(p_filter->'categories') = 'array' THEN
+            v_categories := ARRAY(SELECT jsonb_array_elements_text(p_filter->'categories'));
+        END IF;
+        IF jsonb_typeof(p_filter->'includeTags') = 'array' THEN
+            v_include_tags := ARRAY(SELECT lower(jsonb_array_elements_text(p_filter->'includeTags')));
+        END IF;
+        IF jsonb_typeof(p_filter->'excludeTags') = 'array' THEN
+            v_exclude_tags := ARRAY(SELECT lower(jsonb_array_elements_text(p_filter->'excludeTags')));
+        END IF;
+        IF jsonb_typeof(p_filter->'sources') = 'array' THEN
+            v_sources := ARRAY(SELECT jsonb_array_elements_text(p_filter->'sources'));
+        END IF;
+        IF jsonb_typeof(p_filter->'documentIds') = 'array' THEN
+            v_document_ids := ARRAY(SELECT jsonb_array_elements_text(p_filter->'documentIds')::int);
+        END IF;
+        IF jsonb_typeof(p_filter->'headings') = 'array' THEN
+            v_headings := ARRAY(SELECT lower(jsonb_array_elements_text(p_filter->'headings')));
+        END IF;
+        v_published_from := (p_filter->>'publishedFrom')::timestamptz;
+        v_published_to := (p_filter->>'publishedTo')::timestamptz;
+    END IF;
+
+    RETURN QUERY
+    WITH candidate_chunks AS (
+        SELECT
+            c.chk_id,
+            c.chk_fk_document,
+            c.chk_content,
+            c.chk_embedding,
+            c.chk_headings,
+            (1 - (c.chk_embedding <=> p_query_embedding)) as semantic_score,
+            ts_rank(c.chk_fts_vector, v_tsquery)::double precision as keyword_rank,
+            (c.chk_fts_vector @@ v_tsquery) as keyword_match,
+            d.doc_title,
+            d.doc_category,
+            GREATEST(EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - COALESCE(d.doc_published_at, d.doc_valid_from, d.doc_created_at))) / 86400, 0)::double precision as age_days
+        FROM public.cht_chunks c
+        INNER JOIN public.cht_documents d ON c.chk_fk_document = d.doc_id
+        WHERE d.doc_active = true
+          AND c.chk_embedding IS NOT NULL
+          AND fn_document_visible(d.doc_audience, d.doc_category, p_role)
+          AND (d.doc_valid_from IS NULL OR d.doc_valid_from <= CURRENT_TIMESTAMP)
+          AND (d.doc_valid_until IS NULL OR d.doc_valid_until > CURRENT_TIMESTAMP)
+          AND (p_category IS NULL OR p_category = '' OR d.doc_category = p_category)
+          AND (COALESCE(cardinality(v_categories), 0) = 0 OR d.doc_category = ANY(v_categories))
+          AND (COALESCE(cardinality(v_include_tags), 0) = 0 OR d.doc_tags && v_include_tags)
+          AND (COALESCE(cardinality(v_exclude_tags), 0) = 0 OR NOT (d.doc_tags && v_exclude_tags))
+          AND (COALESCE(cardinality(v_sources), 0) = 0 OR d.doc_source = ANY(v_sources))
+          AND (COALESCE(cardinality(v_document_ids), 0) = 0 OR d.doc_id = ANY(v_document_ids))
+          AND (COALESCE(cardinality(v_headings), 0) = 0
+               OR EXISTS (SELECT 1 FROM unnest(c.chk_headings) h WHERE lower(h) = ANY(v_headings)))
+          AND (v_published_from IS NULL OR d.doc_published_at >= v_published_from)
+          AND (v_published_to IS NULL OR d.doc_published_at <= v_published_to)
+          AND ((1 - (c.chk_embedding <=> p_query_embedding)) >= p_min_similarity
+               OR c.chk_fts_vector @@ v_tsquery)
+    ),
+    ranked_chunks AS (
+        SELECT
+            cc.*,
+            (cc.semantic_score * (1 - p_keyword_weight)) + (cc.keyword_rank * p_keyword_weight) as weighted_score,
+            ROW_NUMBER() OVER (ORDER BY cc.semantic_score DESC) as semantic_position,
+            -- Only chunks matching the full-text query take part in the keyword ranking
+            CASE WHEN cc.keyword_match
+                 THEN ROW_NUMBER() OVER (PARTITION BY cc.keyword_match ORDER BY cc.keyword_rank DESC)
+            END as keyword_position
+        FROM candidate_chunks cc
+    ),
+    fused_chunks AS (
+        SELECT
+            rc.*,
+            (CASE WHEN p_fusion = 'rrf'
+                  THEN 1.0 / (p_rrf_k + rc.semantic_position)
+                       + COALESCE(1.0 / (p_rrf_k + rc.keyword_position), 0)
+                  ELSE rc.weighted_score
+             END
+             * CASE WHEN COALESCE(p_recency_weight, 0) > 0 AND p_recency_half_life_days > 0
+                    THEN 1 + p_recency_weight * power(0.5, rc.age_days / p_recency_half_life_days)
+                    ELSE 1
+               END)::double precision as fused
+        FROM ranked_chunks rc
+    )
+    SELECT
+        fc.chk_id,
+        fc.chk_fk_document,
+        fc.chk_content,
+        fc.semantic_score,
+        fc.keyword_rank,
+        fc.weighted_score,
+        fc.fused,
+        fc.doc_title,
+        fc.doc_category,
+        CASE WHEN p_with_embeddings THEN fc.chk_embedding END,
+        fc.chk_headings
+    FROM fused_chunks fc
+    ORDER BY fc.fused DESC
+    LIMIT p_limit;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+COMMENT ON FUNCTION fn_get_chunks_by_document(int) IS 'Get all chunks for a specific document ordered by position, with their heading paths';
+COMMENT ON FUNCTION fn_get_chunk_by_id(int) IS 'Get specific chunk by ID with its heading path';
+COMMENT ON PROCEDURE sp_bulk_create_chunks IS 'Bulk creates chunks for a document in array order with their heading paths. Returns success, code, and count';
+COMMENT ON FUNCTION fn_similarity_search_chunks_hybrid(vector, text, int, float, float, varchar, varchar, int, boolean, jsonb, varchar, float, float) IS 'Hybrid semantic + accent-insensitive, glossary-expanded full-text search with weighted or RRF fusion, metadata and heading filters, role-based visibility, validity windows and recency boosting';
@@ -0,0 +1,118 @@
+-- =====================================================
+-- Spreadsheet and CSV Ingestion
+-- Migration: 000065_table_documents.down.sql
+-- =====================================================
+
+DROP PROCEDURE IF EXISTS sp_bulk_create_chunks(int, text[], vector[], jsonb, boolean);
+
+-- =====================================================
+-- Procedure: sp_bulk_create_chunks
+-- Description: Creates multiple chunks for a document at once, keeping the
+--              array order as their positions after any existing chunks.
+--              p_headings is a JSON array aligned with p_contents holding the
+--              heading path of each chunk (e.g. [["Admisiones", "Requisitos"]])
+-- Returns: success (boolean), code (varchar), chunks_created (int)
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_bulk_create_chunks(
+    OUT success boolean,
+    OUT code varchar,
+    OUT o_chunks_created int,
+    IN p_doc_id int,
+    IN p_contents text[],
+    IN p_embeddings vector[],
+    IN p_headings jsonb default null
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_doc_exists boolean;
+    v_next_position int;
+BEGIN
+    success := true;
+    code := 'OK';
+    o_chunks_created := 0;
+
+    -- 1. Validate document exists
+    SELECT EXISTS(
+        SELECT 1
+        FROM public.cht_documents
+        WHERE doc_id = p_doc_id
+        AND doc_active = true
+    ) INTO v_doc_exists;
+
+    IF NOT v_doc_exists THEN
+        success := false;
+        code := 'ERR_DOCUMENT_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    IF array_length(p_contents, 1) != array_length(p_embeddings, 1) THEN
+        success := false;
+        code := 'ERR_ARRAY_LENGTH_MISMATCH';
+        RAISE NOTICE 'Error: p_contents array length does not match p_embeddings array length.';
+        RETURN;
+    END IF;
+
+    SELECT COALESCE(MAX(c.chk_position) + 1, 0) INTO v_next_position
+    FROM public.cht_chunks c
+    WHERE c.chk_fk_document = p_doc_id;
+
+    -- 2. Bulk insert chunks (array index = position) and their statistics
+    WITH inserted_chunks AS (
+        INSERT INTO public.cht_chunks (
+            chk_fk_document,
+            chk_content,
+            chk_embedding,
+            chk_position,
+            chk_headings
+        )
+        SELECT
+            p_doc_id,
+            t.content,
+            t.embedding,
+            v_next_position + (t.idx - 1)::int,
+            CASE WHEN jsonb_typeof(p_headings->(t.idx::int - 1)) = 'array'
+                 THEN ARRAY(SELECT jsonb_array_elements_text(p_headings->(t.idx::int - 1)))
+                 ELSE '{}'::text[]
+            END
+        FROM unnest(p_contents, p_embeddings) WITH ORDINALITY AS t(content, embedding, idx)
+        RETURNING chk_id
+    )
+    INSERT INTO public.cht_chunk_statistics (
+        cst_fk_chunk,
+        cst_usage_count
+    )
+    SELECT
+        chk_id,
+        0
+    FROM inserted_chunks;
+
+    -- 3. Set output parameter
+    o_chunks_created := array_length(p_contents, 1);
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_BULK_CREATE_CHUNKS';
+        o_chunks_created := 0;
+        RAISE NOTICE 'Error bulk creating chunks: %', SQLERRM;
+END;
+$$;
+
+COMMENT ON PROCEDURE sp_bulk_create_chunks IS 'Bulk creates chunks for a document in array order with their heading paths. Returns success, code, and count';
+
+DROP PROCEDURE IF EXISTS sp_set_document_table_mapping(INT, TEXT, JSONB);
+DROP FUNCTION IF EXISTS fn_get_document_table_mapping(INT);
+
+ALTER TABLE cht_documents DROP COLUMN IF EXISTS doc_table_mapping;
+
+UPDATE cht_parameters
+SET prm_data = '{"message": "Tipo de archivo no soportado. Use PDF, DOCX, ODT, Markdown o TXT"}'::jsonb
+WHERE prm_code = 'ERR_UNSUPPORTED_FILE_TYPE';
+
+DELETE FROM cht_parameters WHERE prm_code IN (
+    'ERR_IMPORT_TABLE',
+    'ERR_TABLE_EMPTY',
+    'ERR_TABLE_COLUMN_NOT_FOUND',
+    'ERR_TABLE_SHEET_NOT_FOUND'
+);
@@ -0,0 +1,204 @@
+-- =====================================================
+-- Spreadsheet and CSV Ingestion
+-- Migration: 000065_table_documents.up.sql
+-- Purpose: Import XLSX/CSV tables (schedules, fee tables, directories) as one
+--          self-describing chunk per row or group of rows. The column mapping
+--          of each imported document is kept so the sheet can be re-imported,
+--          replacing its previous rows.
+-- =====================================================
+
+ALTER TABLE cht_documents ADD COLUMN IF NOT EXISTS doc_table_mapping JSONB;
+
+COMMENT ON COLUMN cht_documents.doc_table_mapping IS 'Column mapping used to import the document from a spreadsheet or CSV (NULL for other documents)';
+
+-- Spreadsheets and CSV files are now accepted by the document upload
+UPDATE cht_parameters
+SET prm_data = '{"message": "Tipo de archivo no soportado. Use PDF, DOCX, ODT, Markdown, TXT, XLSX o CSV"}'::jsonb
+WHERE prm_code = 'ERR_UNSUPPORTED_FILE_TYPE';
+
+-- =====================================================
+-- Function: fn_get_document_table_mapping
+-- Description: Get the table mapping of a document (empty when not found)
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_get_document_table_mapping(
+    p_doc_id INT
+)
+RETURNS TABLE (
+    doc_id INT,
+    doc_active BOOLEAN,
+    doc_table_mapping JSONB
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT d.doc_id, d.doc_active, d.doc_table_mapping
+    FROM cht_documents d
+    WHERE d.doc_id = p_doc_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- =====================================================
+-- Stored Procedure: sp_set_document_table_mapping
+-- Description: Record the table mapping and summary of an imported document
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_set_document_table_mapping(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    IN p_doc_id INT,
+    IN p_summary TEXT,
+    IN p_mapping JSONB
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    success := TRUE;
+    code := 'OK';
+
+    UPDATE cht_documents
+    SET doc_table_mapping = p_mapping,
+        doc_summary = COALESCE(p_summary, doc_summary),
+        doc_updated_at = CURRENT_TIMESTAMP
+    WHERE doc_id = p_doc_id
+    AND doc_active = true;
+
+    IF NOT FOUND THEN
+        success := FALSE;
+        code := 'ERR_DOCUMENT_NOT_FOUND';
+    END IF;
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_IMPORT_TABLE';
+        RAISE NOTICE 'Error setting document table mapping: %', SQLERRM;
+END;
+$$;
+
+DROP PROCEDURE IF EXISTS sp_bulk_create_chunks(int, text[], vector[], jsonb);
+
+-- =====================================================
+-- Procedure: sp_bulk_create_chunks
+-- Description: Creates multiple chunks for a document at once, keeping the
+--              array order as their positions after any existing chunks.
+--              p_headings is a JSON array aligned with p_contents holding the
+--              heading path of each chunk (e.g. [["Admisiones", "Requisitos"]]).
+--              With p_replace the existing chunks of the document are deleted
+--              first, in the same transaction, so a re-import never leaves the
+--              document half empty
+-- Returns: success (boolean), code (varchar), chunks_created (int)
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_bulk_create_chunks(
+    OUT success boolean,
+    OUT code varchar,
+    OUT o_chunks_created int,
+    IN p_doc_id int,
+    IN p_contents text[],
+    IN p_embeddings vector[],
+    IN p_headings jsonb default null,
+    IN p_replace boolean default false
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_doc_exists boolean;
+    v_next_position int;
+BEGIN
+    success := true;
+    code := 'OK';
+    o_chunks_created := 0;
+
+    -- 1. Validate document exists
+    SELECT EXISTS(
+        SELECT 1
+        FROM public.cht_documents
+        WHERE doc_id = p_doc_id
+        AND doc_active = true
+    ) INTO v_doc_exists;
+
+    IF NOT v_doc_exists THEN
+        success := false;
+        code := 'ERR_DOCUMENT_NOT_FOUND';
+        RETURN;
+    END IF;
+
+    IF array_length(p_contents, 1) != array_length(p_embeddings, 1) THEN
+        success := false;
+        code := 'ERR_ARRAY_LENGTH_MISMATCH';
+        RAISE NOTICE 'Error: p_contents array length does not match p_embeddings array length.';
+        RETURN;
+    END IF;
+
+    IF p_replace THEN
+        DELETE FROM public.cht_chunks WHERE chk_fk_document = p_doc_id;
+    END IF;
+
+    SELECT COALESCE(MAX(c.chk_position) + 1, 0) INTO v_next_position
+    FROM public.cht_chunks c
+    WHERE c.chk_fk_document = p_doc_id;
+
+    -- 2. Bulk insert chunks (array index = position) and their statistics
+    WITH inserted_chunks AS (
+        INSERT INTO public.cht_chunks (
+            chk_fk_document,
+            chk_content,
+            chk_embedding,
+            chk_position,
+            chk_headings
+        )
+        SELECT
+            p_doc_id,
+            t.content,
+            t.embedding,
+            v_next_position + (t.idx - 1)::int,
+            CASE WHEN jsonb_typeof(p_headings->(t.idx::int - 1)) = 'array'
+                 THEN ARRAY(SELECT jsonb_array_elements_text(p_headings->(t.idx::int - 1)))
+                 ELSE '{}'::text[]
+            END
+        FROM unnest(p_contents, p_embeddings) WITH ORDINALITY AS t(content, embedding, idx)
+        RETURNING chk_id
+    )
+    INSERT INTO public.cht_chunk_statistics (
+        cst_fk_chunk,
+        cst_usage_count
+    )
+    SELECT
+        chk_id,
+        0
+    FROM inserted_chunks;
+
+    -- 3. Set output parameter
+    o_chunks_created := array_length(p_contents, 1);
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := false;
+        code := 'ERR_BULK_CREATE_CHUNKS';
+        o_chunks_created := 0;
+        RAISE NOTICE 'Error bulk creating chunks: %', SQLERRM;
+END;
+$$;
+
+-- =====================================================
+-- Error Codes
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_IMPORT_TABLE') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_IMPORT_TABLE', '{"message": "Error al importar la tabla"}'::jsonb, 'Error importing a spreadsheet or CSV');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_TABLE_EMPTY') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_TABLE_EMPTY', '{"message": "La hoja de cálculo no tiene filas para importar"}'::jsonb, 'Spreadsheet or CSV without data rows');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_TABLE_COLUMN_NOT_FOUND') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_TABLE_COLUMN_NOT_FOUND', '{"message": "Una columna del mapeo no existe en la hoja de cálculo"}'::jsonb, 'Mapped column missing from the spreadsheet');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_TABLE_SHEET_NOT_FOUND') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_TABLE_SHEET_NOT_FOUND', '{"message": "Una hoja del mapeo no existe en el libro"}'::jsonb, 'Mapped sheet missing from the workbook');
+    END IF;
+END $$;
+
+COMMENT ON PROCEDURE sp_bulk_create_chunks IS 'Bulk creates (or, with p_replace, replaces) the chunks of a document in array order with their heading paths. Returns success, code, and count';
+COMMENT ON PROCEDURE sp_set_document_table_mapping IS 'Records the table mapping and summary of a document imported from a spreadsheet or CSV. Returns success and code';
@@ -0,0 +1,8 @@
+-- =====================================================
+-- Guardrails: System Prompt Leak Check
+-- Migration: 000066_guardrails_prompt_leak.down.sql
+-- =====================================================
+
+UPDATE cht_parameters
+SET prm_data = prm_data - 'promptLeak'
+WHERE prm_code = 'GUARDRAILS_CONFIG';
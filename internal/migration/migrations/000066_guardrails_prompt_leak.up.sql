@@ -0,0 +1,18 @@
+-- =====================================================
+-- Guardrails: System Prompt Leak Check
+-- Migration: 000066_guardrails_prompt_leak.up.sql
+-- Purpose: Configure the output check that blocks answers reproducing the
+--          system prompt (word sequences shared with it)
+-- =====================================================
+
+-- =====================================================
+-- GUARDRAILS_CONFIG: promptLeak section
+-- =====================================================
+UPDATE cht_parameters
+SET prm_data = jsonb_set(
+        prm_data,
+        '{promptLeak}',
+        '{"enabled": true, "stages": ["output"], "action": "block", "ngram": 8, "minOverlap": 0.2, "minMatches": 10}'::jsonb
+    )
+WHERE prm_code = 'GUARDRAILS_CONFIG'
+    AND NOT prm_data ? 'promptLeak';
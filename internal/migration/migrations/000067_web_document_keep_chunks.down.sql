@@ -0,0 +1,93 @@
+-- =====================================================
+-- Web Page Re-crawl: Keep Chunks Until Replaced
+-- Migration: 000067_web_document_keep_chunks.down.sql
+-- =====================================================
+
+-- =====================================================
+-- Stored Procedure: sp_sync_web_document
+-- Description: Create or update the document of a crawled page (doc_source = URL).
+--              o_status is created, updated (content hash changed: the chunks are
+--              deleted and the hash cleared until the new chunks are stored),
+--              unchanged, or inactive (deactivated by an admin: left alone).
+-- =====================================================
+CREATE OR REPLACE PROCEDURE sp_sync_web_document(
+    OUT success BOOLEAN,
+    OUT code VARCHAR,
+    OUT o_doc_id INT,
+    OUT o_status VARCHAR,
+    IN p_source VARCHAR,
+    IN p_category VARCHAR,
+    IN p_title VARCHAR,
+    IN p_summary TEXT,
+    IN p_content_hash VARCHAR,
+    IN p_tags TEXT[],
+    IN p_audience TEXT[]
+)
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_active BOOLEAN;
+    v_hash VARCHAR;
+BEGIN
+    success := TRUE;
+    code := 'OK';
+    o_doc_id := NULL;
+    o_status := NULL;
+
+    IF p_source IS NULL OR p_category IS NULL OR p_title IS NULL THEN
+        success := FALSE;
+        code := 'ERR_REQUIRED_FIELDS';
+        RETURN;
+    END IF;
+
+    SELECT doc_id, doc_active, doc_content_hash
+    INTO o_doc_id, v_active, v_hash
+    FROM cht_documents
+    WHERE doc_source = p_source
+    ORDER BY doc_active DESC, doc_id DESC
+    LIMIT 1;
+
+    IF o_doc_id IS NULL THEN
+        INSERT INTO cht_documents (
+            doc_category, doc_title, doc_summary, doc_source, doc_tags, doc_audience, doc_active
+        )
+        VALUES (
+            p_category, left(p_title, 200), p_summary, p_source, COALESCE(p_tags, '{}'), COALESCE(p_audience, '{}'), true
+        )
+        RETURNING doc_id INTO o_doc_id;
+        o_status := 'created';
+        RETURN;
+    END IF;
+
+    IF NOT v_active THEN
+        o_status := 'inactive';
+        RETURN;
+    END IF;
+
+    IF v_hash IS NOT NULL AND v_hash = p_content_hash THEN
+        o_status := 'unchanged';
+        RETURN;
+    END IF;
+
+    UPDATE cht_documents
+    SET doc_category = p_category,
+        doc_title = left(p_title, 200),
+        doc_summary = p_summary,
+        doc_tags = COALESCE(p_tags, '{}'),
+        doc_audience = COALESCE(p_audience, '{}'),
+        doc_content_hash = NULL,
+        doc_updated_at = CURRENT_TIMESTAMP
+    WHERE doc_id = o_doc_id;
+
+    DELETE FROM cht_chunks WHERE chk_fk_document = o_doc_id;
+    o_status := 'updated';
+
+EXCEPTION
+    WHEN OTHERS THEN
+        success := FALSE;
+        code := 'ERR_SYNC_WEB_DOCUMENT';
+        o_doc_id := NULL;
+        o_status := NULL;
+        RAISE NOTICE 'Error syncing web document: %', SQLERRM;
+END;
+$$;
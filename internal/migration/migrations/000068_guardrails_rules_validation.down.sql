@@ -0,0 +1,6 @@
+-- =====================================================
+-- Guardrails: Rules Validation
+-- Migration: 000068_guardrails_rules_validation.down.sql
+-- =====================================================
+
+DELETE FROM cht_parameters WHERE prm_code = 'ERR_INVALID_GUARDRAILS_RULES';
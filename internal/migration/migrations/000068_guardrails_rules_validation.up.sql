@@ -0,0 +1,17 @@
+-- =====================================================
+-- Guardrails: Rules Validation
+-- Migration: 000068_guardrails_rules_validation.up.sql
+-- Purpose: Error returned when GUARDRAILS_RULES is saved with a rule that
+--          cannot be compiled
+-- =====================================================
+
+-- =====================================================
+-- Error Codes
+-- =====================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM cht_parameters WHERE prm_code = 'ERR_INVALID_GUARDRAILS_RULES') THEN
+        INSERT INTO cht_parameters (prm_name, prm_code, prm_data, prm_description)
+        VALUES ('ERROR_CODES', 'ERR_INVALID_GUARDRAILS_RULES', '{"message": "Las reglas de guardrails contienen un patrón inválido"}'::jsonb, 'GUARDRAILS_RULES with a pattern that does not compile');
+    END IF;
+END $$;
@@ -0,0 +1,84 @@
+-- =====================================================
+-- Experiments: Handoff Rate
+-- Migration: 000069_experiment_handoff_rate.up.sql
+-- Purpose: Count the handoffs requested by the bot (000056) in the handoff
+--          rate of the variant comparison, not only admin interventions
+-- =====================================================
+
+-- =====================================================
+-- Function: fn_compare_experiment_variants
+-- Description: Per-variant latency, tokens, retrieval similarity, feedback and handoff rate.
+--              A conversation counts as handed off when the bot asked for a human
+--              (cnv_handoff_at) or an admin intervened.
+-- =====================================================
+CREATE OR REPLACE FUNCTION fn_compare_experiment_variants(
+    p_exp_id INT,
+    p_start_date TIMESTAMP DEFAULT NULL,
+    p_end_date TIMESTAMP DEFAULT NULL
+)
+RETURNS TABLE (
+    variant_id INT,
+    variant_code VARCHAR(50),
+    variant_weight INT,
+    conversation_count BIGINT,
+    response_count BIGINT,
+    avg_response_time_ms DOUBLE PRECISION,
+    avg_llm_time_ms DOUBLE PRECISION,
+    avg_prompt_tokens DOUBLE PRECISION,
+    avg_completion_tokens DOUBLE PRECISION,
+    avg_total_tokens DOUBLE PRECISION,
+    avg_best_similarity DOUBLE PRECISION,
+    no_results_rate DOUBLE PRECISION,
+    positive_feedback BIGINT,
+    negative_feedback BIGINT,
+    satisfaction_rate DOUBLE PRECISION,
+    handoff_rate DOUBLE PRECISION
+)
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RETURN QUERY
+    WITH responses AS (
+        SELECT
+            m.cvm_fk_variant,
+            m.cvm_fk_conversation,
+            m.cvm_total_time_ms,
+            m.cvm_prompt_tokens,
+            m.cvm_completion_tokens,
+            m.cvm_total_tokens,
+            m.cvm_feedback,
+            (m.cvm_metadata->>'responseTimeMs')::DOUBLE PRECISION AS response_time_ms,
+            (m.cvm_metadata->>'ragBestSimilarity')::DOUBLE PRECISION AS best_similarity,
+            (m.cvm_metadata->>'ragChunks')::INT AS rag_chunks
+        FROM cht_conversation_messages m
+        WHERE m.cvm_fk_variant IN (SELECT exv_id FROM cht_experiment_variants WHERE exv_fk_experiment = p_exp_id)
+          AND m.cvm_from_me = true
+          AND (p_start_date IS NULL OR m.cvm_created_at >= p_start_date)
+          AND (p_end_date IS NULL OR m.cvm_created_at <= p_end_date)
+    )
+    SELECT
+        v.exv_id,
+        v.exv_code,
+        v.exv_weight,
+        COUNT(DISTINCT r.cvm_fk_conversation),
+        COUNT(r.cvm_fk_conversation),
+        AVG(r.response_time_ms),
+        AVG(r.cvm_total_time_ms)::DOUBLE PRECISION,
+        AVG(r.cvm_prompt_tokens)::DOUBLE PRECISION,
+        AVG(r.cvm_completion_tokens)::DOUBLE PRECISION,
+        AVG(r.cvm_total_tokens)::DOUBLE PRECISION,
+        AVG(r.best_similarity),
+        (COUNT(*) FILTER (WHERE r.rag_chunks = 0))::DOUBLE PRECISION / NULLIF(COUNT(r.rag_chunks), 0),
+        COUNT(*) FILTER (WHERE r.cvm_feedback = 1),
+        COUNT(*) FILTER (WHERE r.cvm_feedback = -1),
+        (COUNT(*) FILTER (WHERE r.cvm_feedback = 1))::DOUBLE PRECISION / NULLIF(COUNT(r.cvm_feedback), 0),
+        (SELECT COUNT(*) FILTER (WHERE c.cnv_handoff_at IS NOT NULL OR c.cnv_admin_intervened)::DOUBLE PRECISION / NULLIF(COUNT(*), 0)
+         FROM cht_conversations c
+         WHERE c.cnv_id IN (SELECT r2.cvm_fk_conversation FROM responses r2 WHERE r2.cvm_fk_variant = v.exv_id))
+    FROM cht_experiment_variants v
+    LEFT JOIN responses r ON r.cvm_fk_variant = v.exv_id
+    WHERE v.exv_fk_experiment = p_exp_id
+    GROUP BY v.exv_id, v.exv_code, v.exv_weight
+    ORDER BY v.exv_id;
+END;
+$$;
@@ -0,0 +1,8 @@
+-- =====================================================
+-- Query Strategies: Default for Uncategorized Searches
+-- Migration: 000070_query_strategy_default.down.sql
+-- =====================================================
+
+UPDATE cht_parameters
+SET prm_description = 'Query strategy per document category ("categories": {"<category>": "<strategy>"}, else "default"): "none", "multi_query" (LLM paraphrases fused with RRF) or "hyde" (embed an LLM-written hypothetical answer). Optional "prompt" in each section.'
+WHERE prm_code = 'QUERY_STRATEGY_CONFIG';
@@ -0,0 +1,10 @@
+-- =====================================================
+-- Query Strategies: Default for Uncategorized Searches
+-- Migration: 000070_query_strategy_default.up.sql
+-- Purpose: Document that "default" is the strategy of searches without a
+--          document category, which includes every WhatsApp message
+-- =====================================================
+
+UPDATE cht_parameters
+SET prm_description = 'Query strategy per document category ("categories": {"<category>": "<strategy>"}, else "default"): "none", "multi_query" (LLM paraphrases fused with RRF) or "hyde" (embed an LLM-written hypothetical answer). Searches without a category filter, like WhatsApp messages, always use "default". Optional "prompt" in each section.'
+WHERE prm_code = 'QUERY_STRATEGY_CONFIG';
@@ -0,0 +1,14 @@
+-- =====================================================
+-- Document Expiry: Named Placeholders
+-- Migration: 000071_document_expiry_placeholders.down.sql
+-- =====================================================
+
+UPDATE cht_parameters
+SET prm_data = jsonb_set(
+        prm_data,
+        '{message}',
+        to_jsonb(replace(replace(prm_data->>'message', '{count}', '%d'), '{documents}', '%s'))
+    ),
+    prm_description = 'Admin notice of expired documents (count, list)'
+WHERE prm_code = 'DOCUMENT_EXPIRY_NOTIFICATION'
+    AND prm_data ? 'message';
@@ -0,0 +1,16 @@
+-- =====================================================
+-- Document Expiry: Named Placeholders
+-- Migration: 000071_document_expiry_placeholders.up.sql
+-- Purpose: DOCUMENT_EXPIRY_NOTIFICATION uses {count} and {documents} instead
+--          of format verbs, so an edited message cannot break the notice
+-- =====================================================
+
+UPDATE cht_parameters
+SET prm_data = jsonb_set(
+        prm_data,
+        '{message}',
+        to_jsonb(replace(replace(prm_data->>'message', '%d', '{count}'), '%s', '{documents}'))
+    ),
+    prm_description = 'Admin notice of expired documents; {count} and {documents} are replaced with the number and the list of documents'
+WHERE prm_code = 'DOCUMENT_EXPIRY_NOTIFICATION'
+    AND prm_data ? 'message';
@@ -0,0 +1,391 @@
+package officeprocessor
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WordprocessingML namespaces (transitional and strict)
+const (
+	wordNamespace       = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	wordStrictNamespace = "http://purl.oclc.org/ooxml/wordprocessingml/main"
+)
+
+var headingStyleRegex = regexp.MustCompile(`^heading\s*([1-9])$`)
+
+// numberingRef is the list a paragraph (or its style) belongs to
+type numberingRef struct {
+	numID string
+	level int
+}
+
+// wordStyle is a paragraph style of styles.xml
+type wordStyle struct {
+	name      string
+	basedOn   string
+	outline   int // -1: not an outline level
+	numbering *numberingRef
+}
+
+// docxParser walks word/document.xml and collects its blocks
+type docxParser struct {
+	styles   map[string]wordStyle
+	ordered  map[string]map[int]bool // numId -> level -> numbered (not bulleted)
+	counters listCounters
+	blocks   []block
+	tables   []*table
+}
+
+// docxParagraph is the paragraph being parsed
+type docxParagraph struct {
+	text      strings.Builder
+	style     string
+	outline   int
+	numbering *numberingRef
+}
+
+// ExtractTextFromDOCX extracts the text of a Word document, keeping headings (from the
+// paragraph styles or outline levels), bulleted and numbered lists and tables as text.
+// Deleted tracked changes and the fallback copies of text boxes are left out.
+func ExtractTextFromDOCX(data []byte) (string, error) {
+	archive, err := openArchive(data)
+	if err != nil {
+		return "", err
+	}
+
+	document, err := readPart(archive, "word/document.xml")
+	if err != nil {
+		return "", err
+	}
+
+	parser := &docxParser{
+		styles:   map[string]wordStyle{},
+		ordered:  map[string]map[int]bool{},
+		counters: listCounters{},
+	}
+	// Styles and numbering are optional: without them headings and lists fall back to paragraphs
+	if styles, err := readPart(archive, "word/styles.xml"); err == nil {
+		parser.parseStyles(styles)
+	}
+	if numbering, err := readPart(archive, "word/numbering.xml"); err == nil {
+		parser.parseNumbering(numbering)
+	}
+
+	if err := parser.parseDocument(document); err != nil {
+		return "", fmt.Errorf("failed to parse DOCX document: %w", err)
+	}
+	return render(parser.blocks), nil
+}
+
+func isWordElement(name xml.Name) bool {
+	return name.Space == wordNamespace || name.Space == wordStrictNamespace
+}
+
+// parseStyles reads the paragraph styles: their name, parent, outline level and list
+func (p *docxParser) parseStyles(data []byte) {
+	var doc struct {
+		Styles []struct {
+			Type    string `xml:"type,attr"`
+			StyleID string `xml:"styleId,attr"`
+			Name    struct {
+				Val string `xml:"val,attr"`
+			} `xml:"name"`
+			BasedOn struct {
+				Val string `xml:"val,attr"`
+			} `xml:"basedOn"`
+			PPr struct {
+				OutlineLvl *struct {
+					Val string `xml:"val,attr"`
+				} `xml:"outlineLvl"`
+				NumPr *struct {
+					Ilvl struct {
+						Val string `xml:"val,attr"`
+					} `xml:"ilvl"`
+					NumID struct {
+						Val string `xml:"val,attr"`
+					} `xml:"numId"`
+				} `xml:"numPr"`
+			} `xml:"pPr"`
+		} `xml:"style"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return
+	}
+
+	for _, s := range doc.Styles {
+		if s.Type != "" && s.Type != "paragraph" {
+			continue
+		}
+		style := wordStyle{
+			name:    strings.ToLower(strings.TrimSpace(s.Name.Val)),
+			basedOn: s.BasedOn.Val,
+			outline: -1,
+		}
+		if s.PPr.OutlineLvl != nil {
+			if level, err := strconv.Atoi(s.PPr.OutlineLvl.Val); err == nil {
+				style.outline = level
+			}
+		}
+		if s.PPr.NumPr != nil && s.PPr.NumPr.NumID.Val != "" {
+			level, _ := strconv.Atoi(s.PPr.NumPr.Ilvl.Val)
+			style.numbering = &numberingRef{numID: s.PPr.NumPr.NumID.Val, level: level}
+		}
+		p.styles[s.StyleID] = style
+	}
+}
+
+// parseNumbering reads which list levels are numbered rather than bulleted
+func (p *docxParser) parseNumbering(data []byte) {
+	var doc struct {
+		AbstractNums []struct {
+			ID     string `xml:"abstractNumId,attr"`
+			Levels []struct {
+				Ilvl   int `xml:"ilvl,attr"`
+				NumFmt struct {
+					Val string `xml:"val,attr"`
+				} `xml:"numFmt"`
+			} `xml:"lvl"`
+		} `xml:"abstractNum"`
+		Nums []struct {
+			NumID         string `xml:"numId,attr"`
+			AbstractNumID struct {
+				Val string `xml:"val,attr"`
+			} `xml:"abstractNumId"`
+		} `xml:"num"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return
+	}
+
+	abstract := make(map[string]map[int]bool, len(doc.AbstractNums))
+	for _, a := range doc.AbstractNums {
+		levels := make(map[int]bool, len(a.Levels))
+		for _, level := range a.Levels {
+			format := level.NumFmt.Val
+			levels[level.Ilvl] = format != "" && format != "bullet" && format != "none"
+		}
+		abstract[a.ID] = levels
+	}
+	for _, num := range doc.Nums {
+		p.ordered[num.NumID] = abstract[num.AbstractNumID.Val]
+	}
+}
+
+// headingLevel resolves the heading level of a paragraph style through its parents
+func (p *docxParser) headingLevel(styleID string) int {
+	for depth := 0; styleID != "" && depth < 10; depth++ {
+		style, exists := p.styles[styleID]
+		if !exists {
+			return 0
+		}
+		if style.outline >= 0 && style.outline < 9 {
+			return style.outline + 1
+		}
+		if match := headingStyleRegex.FindStringSubmatch(style.name); match != nil {
+			level, _ := strconv.Atoi(match[1])
+			return level
+		}
+		if style.name == "title" {
+			return 1
+		}
+		styleID = style.basedOn
+	}
+	return 0
+}
+
+// styleNumbering resolves the list a paragraph style puts its paragraphs in
+func (p *docxParser) styleNumbering(styleID string) *numberingRef {
+	for depth := 0; styleID != "" && depth < 10; depth++ {
+		style, exists := p.styles[styleID]
+		if !exists {
+			return nil
+		}
+		if style.numbering != nil {
+			return style.numbering
+		}
+		styleID = style.basedOn
+	}
+	return nil
+}
+
+func (p *docxParser) parseDocument(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var paragraph *docxParagraph
+	inRun := 0
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			// Text boxes are stored twice (DrawingML and VML fallback): keep the first copy
+			if t.Name.Local == "Fallback" {
+				if err := decoder.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if !isWordElement(t.Name) {
+				continue
+			}
+
+			switch t.Name.Local {
+			case "del", "moveFrom":
+				if err := decoder.Skip(); err != nil {
+					return err
+				}
+			case "tbl":
+				p.tables = append(p.tables, &table{})
+			case "tr":
+				if current := p.currentTable(); current != nil {
+					current.row = []string{}
+				}
+			case "tc":
+				if current := p.currentTable(); current != nil {
+					current.cell = &strings.Builder{}
+				}
+			case "p":
+				paragraph = &docxParagraph{outline: -1}
+			case "pStyle":
+				if paragraph != nil {
+					paragraph.style = attr(t, "val")
+				}
+			case "outlineLvl":
+				if paragraph != nil {
+					paragraph.outline = attrInt(t, "val", -1)
+				}
+			case "numPr":
+				if paragraph != nil {
+					paragraph.numbering = &numberingRef{}
+				}
+			case "ilvl":
+				if paragraph != nil && paragraph.numbering != nil {
+					paragraph.numbering.level = attrInt(t, "val", 0)
+				}
+			case "numId":
+				if paragraph != nil && paragraph.numbering != nil {
+					paragraph.numbering.numID = attr(t, "val")
+				}
+			case "r":
+				inRun++
+			case "t":
+				var text string
+				if err := decoder.DecodeElement(&text, &t); err != nil {
+					return err
+				}
+				if paragraph != nil {
+					paragraph.text.WriteString(text)
+				}
+			case "tab":
+				// Tab stops are also declared as <w:tab> in the paragraph properties
+				if paragraph != nil && inRun > 0 {
+					paragraph.text.WriteString(" ")
+				}
+			case "br", "cr":
+				if paragraph != nil && inRun > 0 {
+					paragraph.text.WriteString("\n")
+				}
+			}
+
+		case xml.EndElement:
+			if !isWordElement(t.Name) {
+				continue
+			}
+
+			switch t.Name.Local {
+			case "r":
+				inRun--
+			case "p":
+				if paragraph != nil {
+					p.endParagraph(paragraph)
+				}
+				paragraph = nil
+			case "tc":
+				if current := p.currentTable(); current != nil {
+					current.endCell()
+				}
+			case "tr":
+				if current := p.currentTable(); current != nil {
+					current.endRow()
+				}
+			case "tbl":
+				p.endTable()
+			}
+		}
+	}
+	return nil
+}
+
+func (p *docxParser) currentTable() *table {
+	if len(p.tables) == 0 {
+		return nil
+	}
+	return p.tables[len(p.tables)-1]
+}
+
+// endParagraph adds a finished paragraph to the current table cell or as a block
+func (p *docxParser) endParagraph(paragraph *docxParagraph) {
+	text := normalizeText(paragraph.text.String())
+	if text == "" {
+		return
+	}
+	if current := p.currentTable(); current != nil {
+		current.appendCell(text)
+		return
+	}
+
+	level := 0
+	if paragraph.outline >= 0 && paragraph.outline < 9 {
+		level = paragraph.outline + 1
+	} else {
+		level = p.headingLevel(paragraph.style)
+	}
+	if level > 0 {
+		p.blocks = append(p.blocks, block{kind: blockHeading, level: level, text: strings.ReplaceAll(text, "\n", " ")})
+		return
+	}
+
+	numbering := paragraph.numbering
+	if numbering == nil {
+		numbering = p.styleNumbering(paragraph.style)
+	}
+	// numId 0 removes the numbering inherited from the style
+	if numbering != nil && numbering.numID != "" && numbering.numID != "0" {
+		ordered := p.ordered[numbering.numID][numbering.level]
+		item := block{kind: blockListItem, level: numbering.level, ordered: ordered, text: text}
+		if ordered {
+			item.number = p.counters.next(numbering.numID, numbering.level)
+		}
+		p.blocks = append(p.blocks, item)
+		return
+	}
+
+	p.blocks = append(p.blocks, block{kind: blockParagraph, text: text})
+}
+
+// endTable closes the current table: a nested table becomes text of its parent cell
+func (p *docxParser) endTable() {
+	current := p.currentTable()
+	if current == nil {
+		return
+	}
+	current.endRow()
+	p.tables = p.tables[:len(p.tables)-1]
+
+	if parent := p.currentTable(); parent != nil {
+		parent.appendCell(renderTable(current.rows))
+		return
+	}
+	p.blocks = append(p.blocks, block{kind: blockTable, rows: current.rows})
+}
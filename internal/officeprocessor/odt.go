@@ -0,0 +1,309 @@
+package officeprocessor
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// OpenDocument namespaces
+const (
+	odfTextNamespace   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odfTableNamespace  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odfOfficeNamespace = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+)
+
+// odtList is an open <text:list>
+type odtList struct {
+	id        string
+	style     string
+	itemStart bool // The next paragraph starts a list item
+}
+
+// odtParagraph is an open <text:p> or <text:h>
+type odtParagraph struct {
+	text    strings.Builder
+	heading int // Outline level of a <text:h>, 0 for paragraphs
+	list    *odtList
+	depth   int
+}
+
+// odtParser walks content.xml and collects its blocks
+type odtParser struct {
+	ordered    map[string]map[int]bool // List style -> level (1-based) -> numbered
+	counters   listCounters
+	lists      []*odtList
+	listCount  int
+	paragraphs []*odtParagraph
+	tables     []*table
+	blocks     []block
+}
+
+// ExtractTextFromODT extracts the text of an OpenDocument text, keeping headings, bulleted
+// and numbered lists and tables as text. Annotations, notes and tracked deletions are left out.
+func ExtractTextFromODT(data []byte) (string, error) {
+	archive, err := openArchive(data)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := readPart(archive, "content.xml")
+	if err != nil {
+		return "", err
+	}
+
+	parser := &odtParser{
+		ordered:  map[string]map[int]bool{},
+		counters: listCounters{},
+	}
+	// List styles live in the automatic styles of content.xml or in styles.xml
+	if styles, err := readPart(archive, "styles.xml"); err == nil {
+		parser.parseListStyles(styles)
+	}
+	parser.parseListStyles(content)
+
+	if err := parser.parseContent(content); err != nil {
+		return "", fmt.Errorf("failed to parse ODT document: %w", err)
+	}
+	return render(parser.blocks), nil
+}
+
+// parseListStyles reads which levels of every list style are numbered rather than bulleted
+func (p *odtParser) parseListStyles(data []byte) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var current map[int]bool
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != odfTextNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "list-style":
+				current = map[int]bool{}
+				p.ordered[attr(t, "name")] = current
+			case "list-level-style-number":
+				if current != nil {
+					current[attrInt(t, "level", 1)] = attr(t, "num-format") != ""
+				}
+			case "list-level-style-bullet", "list-level-style-image":
+				if current != nil {
+					current[attrInt(t, "level", 1)] = false
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Space == odfTextNamespace && t.Name.Local == "list-style" {
+				current = nil
+			}
+		}
+	}
+}
+
+func (p *odtParser) parseContent(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if err := p.start(decoder, t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			p.end(t)
+		case xml.CharData:
+			if paragraph := p.currentParagraph(); paragraph != nil {
+				paragraph.text.Write(t)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *odtParser) start(decoder *xml.Decoder, t xml.StartElement) error {
+	switch t.Name.Space {
+	case odfOfficeNamespace:
+		if t.Name.Local == "annotation" {
+			return decoder.Skip()
+		}
+
+	case odfTextNamespace:
+		switch t.Name.Local {
+		case "note", "tracked-changes", "sequence-decls":
+			return decoder.Skip()
+		case "list":
+			p.startList(t)
+		case "list-item", "list-header":
+			if list := p.currentList(); list != nil {
+				list.itemStart = t.Name.Local == "list-item"
+			}
+		case "p", "h":
+			paragraph := &odtParagraph{list: p.currentList(), depth: len(p.lists) - 1}
+			if t.Name.Local == "h" {
+				paragraph.heading = attrInt(t, "outline-level", 1)
+			}
+			p.paragraphs = append(p.paragraphs, paragraph)
+		case "s":
+			if paragraph := p.currentParagraph(); paragraph != nil {
+				paragraph.text.WriteString(strings.Repeat(" ", max(attrInt(t, "c", 1), 1)))
+			}
+		case "tab":
+			if paragraph := p.currentParagraph(); paragraph != nil {
+				paragraph.text.WriteString(" ")
+			}
+		case "line-break":
+			if paragraph := p.currentParagraph(); paragraph != nil {
+				paragraph.text.WriteString("\n")
+			}
+		}
+
+	case odfTableNamespace:
+		switch t.Name.Local {
+		case "table":
+			p.tables = append(p.tables, &table{})
+		case "table-row":
+			if current := p.currentTable(); current != nil {
+				current.row = []string{}
+			}
+		case "table-cell", "covered-table-cell":
+			if current := p.currentTable(); current != nil {
+				current.cell = &strings.Builder{}
+			}
+		}
+	}
+	return nil
+}
+
+func (p *odtParser) end(t xml.EndElement) {
+	switch t.Name.Space {
+	case odfTextNamespace:
+		switch t.Name.Local {
+		case "list":
+			if len(p.lists) > 0 {
+				p.lists = p.lists[:len(p.lists)-1]
+			}
+		case "p", "h":
+			if len(p.paragraphs) > 0 {
+				paragraph := p.paragraphs[len(p.paragraphs)-1]
+				p.paragraphs = p.paragraphs[:len(p.paragraphs)-1]
+				p.endParagraph(paragraph)
+			}
+		}
+
+	case odfTableNamespace:
+		switch t.Name.Local {
+		case "table-cell", "covered-table-cell":
+			if current := p.currentTable(); current != nil {
+				current.endCell()
+			}
+		case "table-row":
+			if current := p.currentTable(); current != nil {
+				current.endRow()
+			}
+		case "table":
+			p.endTable()
+		}
+	}
+}
+
+// startList opens a list; nested lists inherit the style of the enclosing one
+func (p *odtParser) startList(t xml.StartElement) {
+	list := &odtList{style: attr(t, "style-name")}
+	if parent := p.currentList(); parent != nil {
+		list.id = parent.id
+		if list.style == "" {
+			list.style = parent.style
+		}
+	} else {
+		p.listCount++
+		list.id = strconv.Itoa(p.listCount)
+	}
+	p.lists = append(p.lists, list)
+}
+
+func (p *odtParser) currentList() *odtList {
+	if len(p.lists) == 0 {
+		return nil
+	}
+	return p.lists[len(p.lists)-1]
+}
+
+func (p *odtParser) currentParagraph() *odtParagraph {
+	if len(p.paragraphs) == 0 {
+		return nil
+	}
+	return p.paragraphs[len(p.paragraphs)-1]
+}
+
+func (p *odtParser) currentTable() *table {
+	if len(p.tables) == 0 {
+		return nil
+	}
+	return p.tables[len(p.tables)-1]
+}
+
+// endParagraph adds a finished paragraph to the current table cell or as a block.
+// The first paragraph of a list item is the item; the following ones are plain paragraphs.
+func (p *odtParser) endParagraph(paragraph *odtParagraph) {
+	text := normalizeText(paragraph.text.String())
+	if text == "" {
+		return
+	}
+	if current := p.currentTable(); current != nil {
+		current.appendCell(text)
+		return
+	}
+
+	if paragraph.heading > 0 {
+		if paragraph.list != nil {
+			paragraph.list.itemStart = false
+		}
+		p.blocks = append(p.blocks, block{kind: blockHeading, level: paragraph.heading, text: strings.ReplaceAll(text, "\n", " ")})
+		return
+	}
+
+	if list := paragraph.list; list != nil && list.itemStart {
+		list.itemStart = false
+		ordered := p.ordered[list.style][paragraph.depth+1]
+		item := block{kind: blockListItem, level: paragraph.depth, ordered: ordered, text: text}
+		if ordered {
+			item.number = p.counters.next(list.id, paragraph.depth)
+		}
+		p.blocks = append(p.blocks, item)
+		return
+	}
+
+	p.blocks = append(p.blocks, block{kind: blockParagraph, text: text})
+}
+
+// endTable closes the current table: a nested table becomes text of its parent cell
+func (p *odtParser) endTable() {
+	current := p.currentTable()
+	if current == nil {
+		return
+	}
+	current.endRow()
+	p.tables = p.tables[:len(p.tables)-1]
+
+	if parent := p.currentTable(); parent != nil {
+		parent.appendCell(renderTable(current.rows))
+		return
+	}
+	p.blocks = append(p.blocks, block{kind: blockTable, rows: current.rows})
+}
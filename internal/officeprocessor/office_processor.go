@@ -0,0 +1,293 @@
+package officeprocessor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"api-chatbot/domain"
+)
+
+// maxPartSize caps how much of a single archive member is read (zip bomb guard)
+const maxPartSize = 64 * 1024 * 1024
+
+const odtMimeType = "application/vnd.oasis.opendocument.text"
+
+var spaceRegex = regexp.MustCompile(`[ \t\x{00a0}]+`)
+
+// DetectFileType sniffs the type of an uploaded file: a PDF, a Word (DOCX), an
+// OpenDocument (ODT) text or an Excel (XLSX) workbook. Returns "" for anything else.
+func DetectFileType(data []byte) string {
+	if bytes.HasPrefix(data, []byte("%PDF")) {
+		return domain.FileTypePDF
+	}
+	if !bytes.HasPrefix(data, []byte("PK")) {
+		return ""
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return ""
+	}
+	if mimetype, err := readPart(archive, "mimetype"); err == nil && strings.TrimSpace(string(mimetype)) == odtMimeType {
+		return domain.FileTypeODT
+	}
+	if findPart(archive, "word/document.xml") != nil {
+		return domain.FileTypeDOCX
+	}
+	if findPart(archive, "xl/workbook.xml") != nil {
+		return domain.FileTypeXLSX
+	}
+	return ""
+}
+
+// openArchive opens the zip container of a DOCX or ODT file
+func openArchive(data []byte) (*zip.Reader, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open document archive: %w", err)
+	}
+	return archive, nil
+}
+
+func findPart(archive *zip.Reader, name string) *zip.File {
+	for _, file := range archive.File {
+		if file.Name == name {
+			return file
+		}
+	}
+	return nil
+}
+
+// readPart reads an archive member, failing when it is missing
+func readPart(archive *zip.Reader, name string) ([]byte, error) {
+	file := findPart(archive, name)
+	if file == nil {
+		return nil, fmt.Errorf("%s not found in document", name)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxPartSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// attr returns the value of an attribute by local name
+func attr(element xml.StartElement, local string) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func attrInt(element xml.StartElement, local string, defaultValue int) int {
+	value, err := strconv.Atoi(attr(element, local))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// =====================================================
+// Document model
+// =====================================================
+
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockHeading
+	blockListItem
+	blockTable
+)
+
+// block is a paragraph, heading, list item or table of the extracted document
+type block struct {
+	kind    blockKind
+	level   int // Heading level (1-6) or list nesting (0-based)
+	ordered bool
+	number  int
+	text    string
+	rows    [][]string
+}
+
+// listCounters numbers the items of an ordered list per nesting level
+type listCounters map[string][]int
+
+// next returns the number of the next item at level, restarting the deeper levels
+func (c listCounters) next(list string, level int) int {
+	counts := c[list]
+	for len(counts) <= level {
+		counts = append(counts, 0)
+	}
+	counts[level]++
+	for i := level + 1; i < len(counts); i++ {
+		counts[i] = 0
+	}
+	c[list] = counts
+	return counts[level]
+}
+
+// render writes the blocks as plain text: headings as Markdown headings ("## Title"),
+// list items as "- item" or "1. item" indented by level and tables via renderTable.
+// Consecutive list items stay on consecutive lines; other blocks are separated by a blank line.
+func render(blocks []block) string {
+	var b strings.Builder
+	previous := blockParagraph
+	for _, blk := range blocks {
+		var text string
+		switch blk.kind {
+		case blockHeading:
+			text = strings.Repeat("#", min(max(blk.level, 1), 6)) + " " + blk.text
+		case blockListItem:
+			marker := "-"
+			if blk.ordered {
+				marker = strconv.Itoa(blk.number) + "."
+			}
+			text = strings.Repeat("  ", blk.level) + marker + " " + blk.text
+		case blockTable:
+			text = renderTable(blk.rows)
+		default:
+			text = blk.text
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		if b.Len() > 0 {
+			if blk.kind == blockListItem && previous == blockListItem {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+		b.WriteString(text)
+		previous = blk.kind
+	}
+	return b.String()
+}
+
+// renderTable turns a table into readable sentences. When the first row is a header
+// every other row is written as "Header: value; Header: value", which keeps each row
+// meaningful on its own once chunked; otherwise the cells are joined with " | ".
+func renderTable(rows [][]string) string {
+	filtered := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		empty := true
+		for i, cell := range row {
+			cells[i] = strings.Join(strings.Fields(cell), " ")
+			if cells[i] != "" {
+				empty = false
+			}
+		}
+		if !empty {
+			filtered = append(filtered, cells)
+		}
+	}
+	if len(filtered) == 0 {
+		return ""
+	}
+
+	header := filtered[0]
+	hasHeader := len(filtered) > 1 && len(header) > 1
+	for _, cell := range header {
+		if cell == "" {
+			hasHeader = false
+		}
+	}
+
+	lines := make([]string, 0, len(filtered))
+	if !hasHeader {
+		for _, row := range filtered {
+			lines = append(lines, joinNonEmpty(row, " | "))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	for _, row := range filtered[1:] {
+		parts := make([]string, 0, len(row))
+		for i, cell := range row {
+			if cell == "" {
+				continue
+			}
+			if i < len(header) {
+				parts = append(parts, header[i]+": "+cell)
+			} else {
+				parts = append(parts, cell)
+			}
+		}
+		lines = append(lines, strings.Join(parts, "; "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func joinNonEmpty(cells []string, separator string) string {
+	parts := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		if cell != "" {
+			parts = append(parts, cell)
+		}
+	}
+	return strings.Join(parts, separator)
+}
+
+// normalizeText collapses runs of spaces and trims every line, dropping blank lines
+func normalizeText(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(spaceRegex.ReplaceAllString(line, " "))
+		if line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// table collects the rows of a table being parsed
+type table struct {
+	rows [][]string
+	row  []string
+	cell *strings.Builder
+}
+
+// appendCell adds text to the current cell, separating paragraphs with a space
+func (t *table) appendCell(text string) {
+	if t.cell == nil {
+		return
+	}
+	if t.cell.Len() > 0 {
+		t.cell.WriteString(" ")
+	}
+	t.cell.WriteString(text)
+}
+
+func (t *table) endCell() {
+	if t.cell == nil {
+		return
+	}
+	t.row = append(t.row, t.cell.String())
+	t.cell = nil
+}
+
+func (t *table) endRow() {
+	t.endCell()
+	if t.row != nil {
+		t.rows = append(t.rows, t.row)
+	}
+	t.row = nil
+}
@@ -0,0 +1,445 @@
+package officeprocessor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxSheetRows    = 100000
+	maxSheetColumns = 500
+)
+
+// Sheet is a worksheet of an XLSX workbook as a grid of cell texts. Merged cells repeat the
+// value of their top-left cell, so every row of a merged "Carrera" column carries it.
+type Sheet struct {
+	Name   string
+	Hidden bool
+	Rows   [][]string
+}
+
+// xlsxParser holds the workbook-wide parts needed to read the cells of a sheet
+type xlsxParser struct {
+	sharedStrings []string
+	dateStyles    map[int]bool // Cell style index -> formatted as a date or time
+	date1904      bool
+}
+
+// ReadXLSX reads the worksheets of an XLSX workbook in workbook order. Numbers are written
+// without exponent, dates as 2006-01-02, times as 15:04 and booleans as TRUE/FALSE.
+func ReadXLSX(data []byte) ([]Sheet, error) {
+	archive, err := openArchive(data)
+	if err != nil {
+		return nil, err
+	}
+
+	workbookData, err := readPart(archive, "xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	var workbook struct {
+		Properties struct {
+			Date1904 string `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name  string     `xml:"name,attr"`
+			State string     `xml:"state,attr"`
+			Attrs []xml.Attr `xml:",any,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(workbookData, &workbook); err != nil {
+		return nil, fmt.Errorf("failed to parse workbook: %w", err)
+	}
+
+	targets, err := workbookRelationships(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &xlsxParser{
+		dateStyles: map[int]bool{},
+		date1904:   workbook.Properties.Date1904 == "1" || workbook.Properties.Date1904 == "true",
+	}
+	// Shared strings and styles are optional parts
+	if part, err := readPart(archive, "xl/sharedStrings.xml"); err == nil {
+		if err := p.parseSharedStrings(part); err != nil {
+			return nil, err
+		}
+	}
+	if part, err := readPart(archive, "xl/styles.xml"); err == nil {
+		p.parseStyles(part)
+	}
+
+	sheets := make([]Sheet, 0, len(workbook.Sheets))
+	for _, entry := range workbook.Sheets {
+		relID := ""
+		for _, a := range entry.Attrs {
+			if a.Name.Local == "id" {
+				relID = a.Value
+			}
+		}
+		target, ok := targets[relID]
+		if !ok {
+			continue // Chart sheets and dangling entries have no worksheet
+		}
+		part, err := readPart(archive, target)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := p.parseSheet(part)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sheet %q: %w", entry.Name, err)
+		}
+		sheets = append(sheets, Sheet{
+			Name:   entry.Name,
+			Hidden: entry.State == "hidden" || entry.State == "veryHidden",
+			Rows:   rows,
+		})
+	}
+	return sheets, nil
+}
+
+// workbookRelationships maps the relationship IDs of the workbook to worksheet part names
+func workbookRelationships(archive *zip.Reader) (map[string]string, error) {
+	data, err := readPart(archive, "xl/_rels/workbook.xml.rels")
+	if err != nil {
+		return nil, err
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Type   string `xml:"Type,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := xml.Unmarshal(data, &rels); err != nil {
+		return nil, fmt.Errorf("failed to parse workbook relationships: %w", err)
+	}
+
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		if strings.HasSuffix(rel.Type, "/worksheet") {
+			targets[rel.ID] = worksheetPath(rel.Target)
+		}
+	}
+	return targets, nil
+}
+
+// parseSharedStrings reads the shared string table, concatenating rich text runs and
+// skipping phonetic hints
+func (p *xlsxParser) parseSharedStrings(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var current strings.Builder
+	inItem, inText, inPhonetic := false, false, false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse shared strings: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				inItem = true
+				current.Reset()
+			case "rPh":
+				inPhonetic = true
+			case "t":
+				inText = inItem && !inPhonetic
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				inItem = false
+				p.sharedStrings = append(p.sharedStrings, current.String())
+			case "rPh":
+				inPhonetic = false
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				current.Write(t)
+			}
+		}
+	}
+}
+
+// parseStyles finds the cell styles whose number format shows a date or a time
+func (p *xlsxParser) parseStyles(data []byte) {
+	var styles struct {
+		NumFmts []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		CellXfs []struct {
+			NumFmtID int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if err := xml.Unmarshal(data, &styles); err != nil {
+		return
+	}
+
+	customDates := map[int]bool{}
+	for _, format := range styles.NumFmts {
+		customDates[format.ID] = isDateFormat(format.Code)
+	}
+	for i, xf := range styles.CellXfs {
+		if isBuiltinDateFormat(xf.NumFmtID) || customDates[xf.NumFmtID] {
+			p.dateStyles[i] = true
+		}
+	}
+}
+
+// parseSheet reads the cells of a worksheet into a grid, filling merged ranges
+func (p *xlsxParser) parseSheet(data []byte) ([][]string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var rows [][]string
+	var merges []string
+
+	rowIndex := -1
+	col := -1
+	var cellType, cellValue string
+	var cellStyle int
+	var text strings.Builder
+	inValue, inInline := false, false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				rowIndex = attrInt(t, "r", rowIndex+2) - 1
+				col = -1
+			case "c":
+				if r, _, ok := cellRef(attr(t, "r")); ok {
+					col = r
+				} else {
+					col++
+				}
+				cellType = attr(t, "t")
+				cellStyle = attrInt(t, "s", 0)
+				cellValue = ""
+				text.Reset()
+			case "v":
+				inValue = true
+			case "is":
+				inInline = true
+			case "mergeCell":
+				merges = append(merges, attr(t, "ref"))
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v":
+				inValue = false
+				cellValue = text.String()
+			case "is":
+				inInline = false
+				cellValue = text.String()
+			case "c":
+				if rowIndex < 0 || rowIndex >= maxSheetRows || col < 0 || col >= maxSheetColumns {
+					continue
+				}
+				value := p.cellText(cellType, cellStyle, cellValue)
+				if value == "" {
+					continue
+				}
+				for len(rows) <= rowIndex {
+					rows = append(rows, nil)
+				}
+				for len(rows[rowIndex]) <= col {
+					rows[rowIndex] = append(rows[rowIndex], "")
+				}
+				rows[rowIndex][col] = value
+			}
+		case xml.CharData:
+			if inValue || inInline {
+				text.Write(t)
+			}
+		}
+	}
+
+	fillMerges(rows, merges)
+	return rows, nil
+}
+
+// cellText renders the raw value of a cell according to its type and style
+func (p *xlsxParser) cellText(cellType string, style int, raw string) string {
+	switch cellType {
+	case "s":
+		index, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || index < 0 || index >= len(p.sharedStrings) {
+			return ""
+		}
+		return normalizeCell(p.sharedStrings[index])
+	case "inlineStr", "str":
+		return normalizeCell(raw)
+	case "b":
+		if strings.TrimSpace(raw) == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	case "e":
+		return "" // Formula errors (#N/A, #REF!...) carry no information
+	}
+
+	raw = strings.TrimSpace(raw)
+	number, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return normalizeCell(raw)
+	}
+	if p.dateStyles[style] {
+		return formatSerialDate(number, p.date1904)
+	}
+	return formatNumber(number)
+}
+
+// fillMerges copies the top-left value of every merged range into its other cells
+func fillMerges(rows [][]string, merges []string) {
+	for _, ref := range merges {
+		from, to, ok := strings.Cut(ref, ":")
+		if !ok {
+			continue
+		}
+		startCol, startRow, ok1 := cellRef(from)
+		endCol, endRow, ok2 := cellRef(to)
+		if !ok1 || !ok2 || startRow >= len(rows) || startCol >= len(rows[startRow]) {
+			continue
+		}
+		value := rows[startRow][startCol]
+		if value == "" {
+			continue
+		}
+		endRow = min(endRow, len(rows)-1, maxSheetRows-1)
+		endCol = min(endCol, maxSheetColumns-1)
+		for r := startRow; r <= endRow; r++ {
+			for len(rows[r]) <= endCol {
+				rows[r] = append(rows[r], "")
+			}
+			for c := startCol; c <= endCol; c++ {
+				rows[r][c] = value
+			}
+		}
+	}
+}
+
+// cellRef parses an A1-style reference into 0-based column and row indexes
+func cellRef(ref string) (col, row int, ok bool) {
+	ref = strings.ToUpper(strings.ReplaceAll(ref, "$", ""))
+	i := 0
+	for i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z' {
+		col = col*26 + int(ref[i]-'A'+1)
+		i++
+	}
+	if i == 0 || i > 3 {
+		return 0, 0, false
+	}
+	rowNumber, err := strconv.Atoi(ref[i:])
+	if err != nil || rowNumber < 1 {
+		return 0, 0, false
+	}
+	return col - 1, rowNumber - 1, true
+}
+
+// isBuiltinDateFormat reports whether a built-in number format shows a date or a time
+func isBuiltinDateFormat(id int) bool {
+	return (id >= 14 && id <= 22) || (id >= 27 && id <= 36) || (id >= 45 && id <= 47) || (id >= 50 && id <= 58)
+}
+
+// isDateFormat reports whether a custom number format code shows a date or a time,
+// ignoring quoted literals, escaped characters and bracketed colours or locales
+func isDateFormat(code string) bool {
+	var cleaned strings.Builder
+	inQuote, inBracket := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case inQuote:
+			inQuote = c != '"'
+		case inBracket:
+			inBracket = c != ']'
+		case c == '"':
+			inQuote = true
+		case c == '[':
+			// Elapsed time ([h]:mm) is a time format; colours and locales are not
+			if i+1 < len(code) && strings.ContainsRune("hHmMsS", rune(code[i+1])) {
+				cleaned.WriteByte('h')
+			}
+			inBracket = true
+		case c == '\\' || c == '_' || c == '*':
+			i++ // Escaped or padding character
+		default:
+			cleaned.WriteByte(c)
+		}
+	}
+	format := strings.ToLower(cleaned.String())
+	if strings.Contains(format, "general") || !strings.ContainsAny(format, "ymdhs") {
+		return false
+	}
+	// A section for text only ("@") is not a date
+	return !strings.Contains(format, "@")
+}
+
+// formatSerialDate converts an Excel serial date: whole days since 1899-12-30 (or 1904-01-01),
+// with the time of day as the fraction
+func formatSerialDate(serial float64, date1904 bool) string {
+	if serial < 0 {
+		return formatNumber(serial)
+	}
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 86400)
+	moment := epoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+
+	switch {
+	case days == 0 && !date1904:
+		return moment.Format("15:04") // Time of day only
+	case seconds == 0:
+		return moment.Format("2006-01-02")
+	default:
+		return moment.Format("2006-01-02 15:04")
+	}
+}
+
+// formatNumber writes a number without exponent, rounding away binary float noise
+func formatNumber(number float64) string {
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(number, 'g', 15, 64), 64)
+	if err != nil {
+		rounded = number
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+// normalizeCell collapses the whitespace of a cell text into single spaces
+func normalizeCell(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// worksheetPath resolves a relationship target relative to the xl/ folder
+func worksheetPath(target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Clean(path.Join("xl", target))
+}
@@ -0,0 +1,178 @@
+package queryexpansion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/llm"
+)
+
+const (
+	defaultParaphrases    = 3
+	defaultHyDEMaxTokens  = 250
+	defaultParaphrasesMax = 5
+)
+
+const defaultMultiQueryPrompt = `Eres un asistente que reformula consultas de estudiantes para un buscador de documentos de un instituto educativo.
+Recibirás una consulta, a menudo corta o ambigua (por ejemplo "becas" o "matrícula").
+Escribe %d reformulaciones distintas y completas en español que cubran las interpretaciones más probables.
+Responde ÚNICAMENTE con JSON en el formato: {"queries": ["<reformulación 1>", "<reformulación 2>", ...]}`
+
+const defaultHyDEPrompt = `Eres el asistente virtual de un instituto educativo.
+Escribe un párrafo breve, en español y con tono de documento oficial, que responda la consulta del estudiante
+como si fuera un fragmento de la normativa o de la información institucional. No menciones que es hipotético.`
+
+// Expander runs the LLM step of the multi-query and HyDE retrieval strategies.
+// Configured by QUERY_STRATEGY_CONFIG, read on every search:
+//
+//	{"default": "none", "categories": {"DOC_INDTEC": "hyde"},
+//	 "multiQuery": {"count": 3, "prompt": "...", "model": "..."},
+//	 "hyde": {"prompt": "...", "model": "...", "maxTokens": 250}}
+//
+// "categories" only apply to searches filtered by a document category. Searches without
+// one, like every WhatsApp message, use "default".
+type Expander struct {
+	paramCache  domain.ParameterCache
+	llmProvider llm.Provider
+}
+
+// NewExpander creates a query expander backed by the LLM provider
+func NewExpander(paramCache domain.ParameterCache, llmProvider llm.Provider) *Expander {
+	return &Expander{
+		paramCache:  paramCache,
+		llmProvider: llmProvider,
+	}
+}
+
+// Strategy returns the strategy configured for the document category, or the default one
+// (also used when category is "")
+func (e *Expander) Strategy(category string) string {
+	if e == nil {
+		return domain.QueryStrategyNone
+	}
+	data, exists := e.paramCache.GetValue("QUERY_STRATEGY_CONFIG")
+	if !exists {
+		return domain.QueryStrategyNone
+	}
+
+	if categories, ok := data["categories"].(map[string]any); ok && category != "" {
+		if strategy, ok := categories[category].(string); ok && strategy != "" {
+			return strategy
+		}
+	}
+	if strategy, ok := data["default"].(string); ok && strategy != "" {
+		return strategy
+	}
+	return domain.QueryStrategyNone
+}
+
+type paraphrases struct {
+	Queries []string `json:"queries"`
+}
+
+// Paraphrases asks the LLM for alternative phrasings of the query (the query itself is not included)
+func (e *Expander) Paraphrases(ctx context.Context, query string) ([]string, error) {
+	if e == nil || e.llmProvider == nil || !e.llmProvider.IsAvailable() {
+		return nil, fmt.Errorf("LLM provider not available")
+	}
+
+	section := e.getSection("multiQuery")
+	count := defaultParaphrases
+	if c, ok := section["count"].(float64); ok && c > 0 {
+		count = min(int(c), defaultParaphrasesMax)
+	}
+	prompt, ok := section["prompt"].(string)
+	if !ok || prompt == "" {
+		prompt = fmt.Sprintf(defaultMultiQueryPrompt, count)
+	}
+	model, _ := section["model"].(string)
+
+	response, err := e.llmProvider.GenerateResponse(ctx, llm.GenerateRequest{
+		SystemPrompt: prompt,
+		UserMessage:  query,
+		Temperature:  llm.Temperature(0.3),
+		MaxTokens:    60 * count,
+		Model:        model,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate paraphrases: %w", err)
+	}
+
+	start := strings.Index(response.Content, "{")
+	end := strings.LastIndex(response.Content, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("multi-query returned no JSON: %q", response.Content)
+	}
+	var result paraphrases
+	if err := json.Unmarshal([]byte(response.Content[start:end+1]), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse paraphrases: %w", err)
+	}
+
+	// Drop blanks and repeats of the original query
+	queries := make([]string, 0, count)
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(query)): true}
+	for _, q := range result.Queries {
+		q = strings.TrimSpace(q)
+		key := strings.ToLower(q)
+		if q == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		queries = append(queries, q)
+		if len(queries) == count {
+			break
+		}
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("multi-query returned no usable paraphrases")
+	}
+	return queries, nil
+}
+
+// HypotheticalAnswer asks the LLM for a passage that would answer the query (HyDE)
+func (e *Expander) HypotheticalAnswer(ctx context.Context, query string) (string, error) {
+	if e == nil || e.llmProvider == nil || !e.llmProvider.IsAvailable() {
+		return "", fmt.Errorf("LLM provider not available")
+	}
+
+	section := e.getSection("hyde")
+	prompt, ok := section["prompt"].(string)
+	if !ok || prompt == "" {
+		prompt = defaultHyDEPrompt
+	}
+	model, _ := section["model"].(string)
+	maxTokens := defaultHyDEMaxTokens
+	if tokens, ok := section["maxTokens"].(float64); ok && tokens > 0 {
+		maxTokens = int(tokens)
+	}
+
+	response, err := e.llmProvider.GenerateResponse(ctx, llm.GenerateRequest{
+		SystemPrompt: prompt,
+		UserMessage:  query,
+		Temperature:  llm.Temperature(0.2),
+		MaxTokens:    maxTokens,
+		Model:        model,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate hypothetical answer: %w", err)
+	}
+
+	answer := strings.TrimSpace(response.Content)
+	if answer == "" {
+		return "", fmt.Errorf("HyDE returned an empty answer")
+	}
+	return answer, nil
+}
+
+// getSection returns a strategy's configuration section (e.g. "multiQuery", "hyde")
+func (e *Expander) getSection(name string) map[string]any {
+	data, exists := e.paramCache.GetValue("QUERY_STRATEGY_CONFIG")
+	if !exists {
+		return nil
+	}
+	section, _ := data[name].(map[string]any)
+	return section
+}
@@ -0,0 +1,98 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+
+	"api-chatbot/domain"
+)
+
+// CrossEncoderScorer calls a rerank endpoint with the Cohere/Jina request shape
+// ({model, query, documents} → {results: [{index, relevance_score}]}), which
+// hosted rerankers and self-hosted cross-encoder servers commonly expose.
+// Configured by the "crossEncoder" section of RERANK_CONFIG: url, apiKey, model.
+type CrossEncoderScorer struct {
+	paramCache domain.ParameterCache
+	httpClient domain.HTTPClient
+}
+
+// NewCrossEncoderScorer creates a scorer backed by an HTTP cross-encoder
+func NewCrossEncoderScorer(paramCache domain.ParameterCache, httpClient domain.HTTPClient) *CrossEncoderScorer {
+	return &CrossEncoderScorer{
+		paramCache: paramCache,
+		httpClient: httpClient,
+	}
+}
+
+func (s *CrossEncoderScorer) Name() string {
+	return "cross-encoder"
+}
+
+type crossEncoderRequest struct {
+	Model           string   `json:"model,omitempty"`
+	Query           string   `json:"query"`
+	Documents       []string `json:"documents"`
+	TopN            int      `json:"top_n"`
+	ReturnDocuments bool     `json:"return_documents"`
+}
+
+type crossEncoderResult struct {
+	Index          int     `json:"index"`
+	RelevanceScore float64 `json:"relevance_score"`
+}
+
+type crossEncoderResponse struct {
+	Results []crossEncoderResult `json:"results"`
+	Data    []crossEncoderResult `json:"data"` // Voyage-style responses
+}
+
+func (s *CrossEncoderScorer) Score(ctx context.Context, query string, documents []string) ([]float64, error) {
+	section := getSection(s.paramCache, "crossEncoder")
+	url, _ := section["url"].(string)
+	if url == "" {
+		return nil, fmt.Errorf("cross-encoder url not configured in RERANK_CONFIG")
+	}
+	apiKey, _ := section["apiKey"].(string)
+	model, _ := section["model"].(string)
+
+	httpReq := domain.HTTPRequest{
+		URL:    url,
+		Method: "POST",
+		Body: crossEncoderRequest{
+			Model:     model,
+			Query:     query,
+			Documents: documents,
+			TopN:      len(documents),
+		},
+	}
+	if apiKey != "" {
+		httpReq.AdditionalHeaders = []domain.HTTPHeader{
+			{Key: "Authorization", Value: "Bearer " + apiKey},
+		}
+	}
+
+	var response crossEncoderResponse
+	if err := s.httpClient.Do(ctx, httpReq, &response); err != nil {
+		return nil, fmt.Errorf("error calling cross-encoder: %w", err)
+	}
+
+	results := response.Results
+	if len(results) == 0 {
+		results = response.Data
+	}
+	if len(results) != len(documents) {
+		return nil, fmt.Errorf("cross-encoder returned %d scores, expected %d", len(results), len(documents))
+	}
+
+	// Results come sorted by relevance; map them back to document order
+	scores := make([]float64, len(documents))
+	seen := make([]bool, len(documents))
+	for _, result := range results {
+		if result.Index < 0 || result.Index >= len(documents) || seen[result.Index] {
+			return nil, fmt.Errorf("cross-encoder returned invalid index: %d", result.Index)
+		}
+		seen[result.Index] = true
+		scores[result.Index] = result.RelevanceScore
+	}
+	return scores, nil
+}
@@ -0,0 +1,115 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/llm"
+)
+
+const defaultLLMPrompt = `Eres un evaluador de relevancia para el asistente virtual de un instituto educativo.
+Recibirás una consulta y una lista numerada de fragmentos. Califica qué tan útil es cada fragmento para responder la consulta,
+de 0 (irrelevante) a 10 (responde directamente la consulta).
+Responde ÚNICAMENTE con JSON en el formato: {"scores": [<puntaje fragmento 1>, <puntaje fragmento 2>, ...]} con un puntaje por fragmento y en el mismo orden.`
+
+// defaultMaxChars limits each fragment sent to the LLM to keep the prompt small
+const defaultMaxChars = 1500
+
+// LLMScorer asks the LLM to grade every candidate in a single request.
+// Configured by the "llm" section of RERANK_CONFIG: prompt, model, maxChars.
+type LLMScorer struct {
+	paramCache  domain.ParameterCache
+	llmProvider llm.Provider
+}
+
+// NewLLMScorer creates an LLM-as-reranker scorer
+func NewLLMScorer(paramCache domain.ParameterCache, llmProvider llm.Provider) *LLMScorer {
+	return &LLMScorer{
+		paramCache:  paramCache,
+		llmProvider: llmProvider,
+	}
+}
+
+func (s *LLMScorer) Name() string {
+	return "llm"
+}
+
+type llmScores struct {
+	Scores []float64 `json:"scores"`
+}
+
+// Score returns the LLM grades normalised to 0-1
+func (s *LLMScorer) Score(ctx context.Context, query string, documents []string) ([]float64, error) {
+	if s.llmProvider == nil || !s.llmProvider.IsAvailable() {
+		return nil, fmt.Errorf("LLM provider not available")
+	}
+
+	section := getSection(s.paramCache, "llm")
+	prompt, ok := section["prompt"].(string)
+	if !ok || prompt == "" {
+		prompt = defaultLLMPrompt
+	}
+	model, _ := section["model"].(string)
+	maxChars := defaultMaxChars
+	if chars, ok := section["maxChars"].(float64); ok && chars > 0 {
+		maxChars = int(chars)
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Consulta: %s\n\nFragmentos:\n", query))
+	for i, document := range documents {
+		builder.WriteString(fmt.Sprintf("[%d] %s\n\n", i+1, truncateText(document, maxChars)))
+	}
+
+	response, err := s.llmProvider.GenerateResponse(ctx, llm.GenerateRequest{
+		SystemPrompt: prompt,
+		UserMessage:  builder.String(),
+		Temperature:  llm.Temperature(0),
+		MaxTokens:    20 + 6*len(documents),
+		Model:        model,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to grade candidates: %w", err)
+	}
+
+	result, err := parseScores(response.Content)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Scores) != len(documents) {
+		return nil, fmt.Errorf("LLM graded %d fragments, expected %d", len(result.Scores), len(documents))
+	}
+
+	scores := make([]float64, len(result.Scores))
+	for i, grade := range result.Scores {
+		scores[i] = min(max(grade, 0), 10) / 10
+	}
+	return scores, nil
+}
+
+// parseScores extracts the JSON object from the LLM response
+func parseScores(content string) (*llmScores, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("reranker returned no JSON: %q", content)
+	}
+
+	var result llmScores
+	if err := json.Unmarshal([]byte(content[start:end+1]), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse reranker response: %w", err)
+	}
+	return &result, nil
+}
+
+// truncateText cuts a text to maxChars runes
+func truncateText(text string, maxChars int) string {
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text
+	}
+	return string(runes[:maxChars]) + "..."
+}
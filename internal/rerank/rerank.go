@@ -0,0 +1,156 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/llm"
+)
+
+const (
+	defaultProvider   = "llm"
+	defaultCandidates = 20
+)
+
+// Scorer rescores documents against a query.
+// It returns one score per document, in order; higher is more relevant.
+type Scorer interface {
+	Name() string
+	Score(ctx context.Context, query string, documents []string) ([]float64, error)
+}
+
+// Reranker is the optional stage after hybrid search: the search over-fetches
+// candidates, a scorer rescores them against the query and the best top-k are kept.
+// Configured by RERANK_CONFIG (disabled by default), read on every search.
+type Reranker struct {
+	paramCache domain.ParameterCache
+	scorers    map[string]Scorer
+}
+
+// NewReranker creates a reranker with the LLM and cross-encoder scorers
+func NewReranker(paramCache domain.ParameterCache, llmProvider llm.Provider, httpClient domain.HTTPClient) *Reranker {
+	return NewRerankerWithScorers(paramCache,
+		NewLLMScorer(paramCache, llmProvider),
+		NewCrossEncoderScorer(paramCache, httpClient),
+	)
+}
+
+// NewRerankerWithScorers creates a reranker choosing among the given scorers by name
+func NewRerankerWithScorers(paramCache domain.ParameterCache, scorers ...Scorer) *Reranker {
+	byName := make(map[string]Scorer, len(scorers))
+	for _, scorer := range scorers {
+		byName[scorer.Name()] = scorer
+	}
+	return &Reranker{
+		paramCache: paramCache,
+		scorers:    byName,
+	}
+}
+
+// config is the resolved RERANK_CONFIG parameter
+type config struct {
+	enabled    bool
+	provider   string
+	candidates int
+	minScore   *float64
+}
+
+func (r *Reranker) getConfig() config {
+	cfg := config{provider: defaultProvider, candidates: defaultCandidates}
+
+	data, exists := r.paramCache.GetValue("RERANK_CONFIG")
+	if !exists {
+		return cfg
+	}
+
+	cfg.enabled, _ = data["enabled"].(bool)
+	if provider, ok := data["provider"].(string); ok && provider != "" {
+		cfg.provider = provider
+	}
+	if candidates, ok := data["candidates"].(float64); ok && candidates > 0 {
+		cfg.candidates = int(candidates)
+	}
+	if minScore, ok := data["minScore"].(float64); ok {
+		cfg.minScore = &minScore
+	}
+	return cfg
+}
+
+// getSection returns a scorer's configuration section (e.g. "llm", "crossEncoder")
+func getSection(paramCache domain.ParameterCache, name string) map[string]any {
+	data, exists := paramCache.GetValue("RERANK_CONFIG")
+	if !exists {
+		return nil
+	}
+	section, _ := data[name].(map[string]any)
+	return section
+}
+
+// CandidateLimit returns how many candidates the search should fetch for a final top-k
+func (r *Reranker) CandidateLimit(topK int) int {
+	if r == nil {
+		return topK
+	}
+	cfg := r.getConfig()
+	if !cfg.enabled {
+		return topK
+	}
+	return max(cfg.candidates, topK)
+}
+
+// Rerank rescores the candidates, sets their RerankScore and keeps the best topK.
+// On error the candidates are returned in their original order, cut to topK.
+func (r *Reranker) Rerank(ctx context.Context, query string, candidates []domain.ChunkWithHybridSimilarity, topK int) ([]domain.ChunkWithHybridSimilarity, error) {
+	if r == nil || len(candidates) == 0 {
+		return truncate(candidates, topK), nil
+	}
+
+	cfg := r.getConfig()
+	if !cfg.enabled {
+		return truncate(candidates, topK), nil
+	}
+
+	scorer, ok := r.scorers[cfg.provider]
+	if !ok {
+		return truncate(candidates, topK), fmt.Errorf("unknown rerank provider %q", cfg.provider)
+	}
+
+	documents := make([]string, len(candidates))
+	for i, chunk := range candidates {
+		documents[i] = chunk.Content
+	}
+
+	scores, err := scorer.Score(ctx, query, documents)
+	if err != nil {
+		return truncate(candidates, topK), fmt.Errorf("%s reranker failed: %w", scorer.Name(), err)
+	}
+	if len(scores) != len(candidates) {
+		return truncate(candidates, topK), fmt.Errorf("%s reranker returned %d scores, expected %d", scorer.Name(), len(scores), len(candidates))
+	}
+
+	reranked := make([]domain.ChunkWithHybridSimilarity, 0, len(candidates))
+	for i, chunk := range candidates {
+		if cfg.minScore != nil && scores[i] < *cfg.minScore {
+			continue
+		}
+		score := scores[i]
+		chunk.RerankScore = &score
+		reranked = append(reranked, chunk)
+	}
+
+	// Stable so equal scores keep the hybrid search order
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return *reranked[i].RerankScore > *reranked[j].RerankScore
+	})
+
+	return truncate(reranked, topK), nil
+}
+
+func truncate(chunks []domain.ChunkWithHybridSimilarity, topK int) []domain.ChunkWithHybridSimilarity {
+	if topK > 0 && len(chunks) > topK {
+		return chunks[:topK]
+	}
+	return chunks
+}
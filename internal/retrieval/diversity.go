@@ -0,0 +1,119 @@
+package retrieval
+
+import (
+	"math"
+
+	"api-chatbot/domain"
+)
+
+// DiversityOptions configures the diversity pass over hybrid search results
+type DiversityOptions struct {
+	MMR                  bool    // Apply Maximal Marginal Relevance
+	Lambda               float64 // Relevance (1.0) vs diversity (0.0) trade-off for MMR
+	MaxChunksPerDocument int     // 0 = no cap
+}
+
+// Diversify selects up to limit chunks from candidates (already ordered by relevance).
+// With MMR, each step picks the candidate maximising
+// lambda*relevance - (1-lambda)*max cosine similarity to the chunks already picked;
+// candidates without an embedding are only judged by relevance.
+// Chunks beyond MaxChunksPerDocument for the same document are skipped.
+func Diversify(candidates []domain.ChunkWithHybridSimilarity, limit int, opts DiversityOptions) []domain.ChunkWithHybridSimilarity {
+	if limit <= 0 || len(candidates) == 0 {
+		return candidates
+	}
+
+	relevance := relevanceScores(candidates)
+	perDocument := make(map[int]int)
+	used := make([]bool, len(candidates))
+	selected := make([]int, 0, min(limit, len(candidates)))
+
+	for len(selected) < limit {
+		best := -1
+		bestScore := math.Inf(-1)
+
+		for i, candidate := range candidates {
+			if used[i] {
+				continue
+			}
+			if opts.MaxChunksPerDocument > 0 && perDocument[candidate.DocumentID] >= opts.MaxChunksPerDocument {
+				continue
+			}
+
+			score := relevance[i]
+			if opts.MMR {
+				score = opts.Lambda*relevance[i] - (1-opts.Lambda)*maxSimilarity(candidates, selected, i)
+			}
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+			if !opts.MMR {
+				break // Candidates are ordered by relevance: the first eligible one wins
+			}
+		}
+
+		if best < 0 {
+			break
+		}
+		used[best] = true
+		perDocument[candidates[best].DocumentID]++
+		selected = append(selected, best)
+	}
+
+	result := make([]domain.ChunkWithHybridSimilarity, len(selected))
+	for i, idx := range selected {
+		result[i] = candidates[idx]
+	}
+	return result
+}
+
+// relevanceScores uses the rerank score when present, otherwise the fusion score
+// normalised by the best one so it is comparable with cosine similarity (0-1)
+func relevanceScores(candidates []domain.ChunkWithHybridSimilarity) []float64 {
+	maxFusion := 0.0
+	for _, c := range candidates {
+		maxFusion = max(maxFusion, c.FusionScore)
+	}
+
+	scores := make([]float64, len(candidates))
+	for i, c := range candidates {
+		switch {
+		case c.RerankScore != nil:
+			scores[i] = *c.RerankScore
+		case maxFusion > 0:
+			scores[i] = c.FusionScore / maxFusion
+		}
+	}
+	return scores
+}
+
+// maxSimilarity returns the highest cosine similarity between candidate i and the selected chunks
+func maxSimilarity(candidates []domain.ChunkWithHybridSimilarity, selected []int, i int) float64 {
+	if candidates[i].Embedding == nil {
+		return 0
+	}
+	highest := 0.0
+	for _, j := range selected {
+		if candidates[j].Embedding == nil {
+			continue
+		}
+		highest = max(highest, cosineSimilarity(candidates[i].Embedding.Slice(), candidates[j].Embedding.Slice()))
+	}
+	return highest
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,51 @@
+package retrieval
+
+import (
+	"sort"
+
+	"api-chatbot/domain"
+)
+
+// FuseRankings merges the result lists of several queries with Reciprocal Rank Fusion:
+// each chunk scores the sum of 1/(k + rank) over the lists it appears in.
+// FusionScore is replaced by the fused score and MatchedQuery names the query
+// that ranked the chunk best. At most limit chunks are kept (0 = all).
+func FuseRankings(lists [][]domain.ChunkWithHybridSimilarity, queries []string, k int, limit int) []domain.ChunkWithHybridSimilarity {
+	type fusedChunk struct {
+		chunk    domain.ChunkWithHybridSimilarity
+		score    float64
+		bestRank int
+	}
+
+	byID := make(map[int]*fusedChunk)
+	order := make([]int, 0)
+	for i, list := range lists {
+		for rank, chunk := range list {
+			score := 1.0 / float64(k+rank+1)
+			entry, exists := byID[chunk.ID]
+			if !exists {
+				entry = &fusedChunk{chunk: chunk, bestRank: rank}
+				entry.chunk.MatchedQuery = queries[i]
+				byID[chunk.ID] = entry
+				order = append(order, chunk.ID)
+			} else if rank < entry.bestRank {
+				entry.bestRank = rank
+				entry.chunk.MatchedQuery = queries[i]
+			}
+			entry.score += score
+		}
+	}
+
+	result := make([]domain.ChunkWithHybridSimilarity, len(order))
+	for i, id := range order {
+		entry := byID[id]
+		entry.chunk.FusionScore = entry.score
+		result[i] = entry.chunk
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].FusionScore > result[j].FusionScore })
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package retrieval
+
+import (
+	"sort"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/textchunker"
+)
+
+// expansion is a contiguous run of chunks of one document built from one or more hits
+type expansion struct {
+	hit     domain.ChunkWithHybridSimilarity
+	lo, hi  int // Ordinal range covered
+	members map[int]domain.ChunkNeighbor
+}
+
+func (e *expansion) touches(documentID, lo, hi int) bool {
+	return e.hit.DocumentID == documentID && lo <= e.hi+1 && hi >= e.lo-1
+}
+
+func (e *expansion) absorb(lo, hi int, members []domain.ChunkNeighbor) {
+	e.lo, e.hi = min(e.lo, lo), max(e.hi, hi)
+	for _, m := range members {
+		e.members[m.Ordinal] = m
+	}
+}
+
+// ExpandNeighbors replaces the content of each hit with the hit and its neighbouring
+// chunks joined in document order. Hits whose windows overlap or touch in the same
+// document are merged into the best ranked one, so no chunk appears twice.
+// Hits without neighbours are kept unchanged.
+func ExpandNeighbors(hits []domain.ChunkWithHybridSimilarity, neighbors []domain.ChunkNeighbor) []domain.ChunkWithHybridSimilarity {
+	if len(neighbors) == 0 {
+		return hits
+	}
+
+	byHit := make(map[int][]domain.ChunkNeighbor)
+	for _, n := range neighbors {
+		byHit[n.HitID] = append(byHit[n.HitID], n)
+	}
+
+	var expansions []*expansion
+	for _, hit := range hits {
+		window := byHit[hit.ID]
+		if len(window) == 0 {
+			expansions = append(expansions, &expansion{hit: hit})
+			continue
+		}
+
+		lo, hi := window[0].Ordinal, window[0].Ordinal
+		for _, n := range window {
+			lo, hi = min(lo, n.Ordinal), max(hi, n.Ordinal)
+		}
+
+		var target *expansion
+		for _, e := range expansions {
+			if e.members != nil && e.touches(hit.DocumentID, lo, hi) {
+				target = e
+				break
+			}
+		}
+		if target == nil {
+			target = &expansion{hit: hit, lo: lo, hi: hi, members: make(map[int]domain.ChunkNeighbor)}
+			expansions = append(expansions, target)
+		}
+		target.absorb(lo, hi, window)
+
+		// The grown range may now bridge into a lower ranked expansion of the same document
+		kept := expansions[:0]
+		for _, e := range expansions {
+			if e != target && e.members != nil && target.touches(e.hit.DocumentID, e.lo, e.hi) {
+				target.absorb(e.lo, e.hi, mapValues(e.members))
+				continue
+			}
+			kept = append(kept, e)
+		}
+		expansions = kept
+	}
+
+	result := make([]domain.ChunkWithHybridSimilarity, 0, len(expansions))
+	for _, e := range expansions {
+		if e.members == nil {
+			result = append(result, e.hit)
+			continue
+		}
+
+		ordered := mapValues(e.members)
+		sort.Slice(ordered, func(i, j int) bool { return ordered[i].Ordinal < ordered[j].Ordinal })
+
+		contents := make([]string, len(ordered))
+		ids := make([]int, len(ordered))
+		for i, m := range ordered {
+			contents[i] = m.Content
+			ids[i] = m.ID
+		}
+
+		chunk := e.hit
+		chunk.Content = textchunker.JoinChunks(contents)
+		chunk.NeighborIDs = ids
+		result = append(result, chunk)
+	}
+	return result
+}
+
+func mapValues(m map[int]domain.ChunkNeighbor) []domain.ChunkNeighbor {
+	values := make([]domain.ChunkNeighbor, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
@@ -0,0 +1,130 @@
+package tableprocessor
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"api-chatbot/internal/officeprocessor"
+)
+
+// candidateDelimiters are tried, in this order, when a CSV file does not declare its delimiter
+var candidateDelimiters = []rune{',', ';', '\t', '|'}
+
+// ReadCSV parses CSV text into a table. An empty delimiter is detected from the first lines:
+// spreadsheets saved with a Spanish locale use ";".
+func ReadCSV(text, delimiter string) (Table, error) {
+	text = strings.TrimPrefix(text, "\ufeff")
+
+	comma := ','
+	switch {
+	case delimiter == `\t` || strings.EqualFold(delimiter, "tab"):
+		comma = '\t'
+	case delimiter != "":
+		runes := []rune(delimiter)
+		if len(runes) != 1 || runes[0] == '"' || runes[0] == '\n' || runes[0] == '\r' {
+			return Table{}, fmt.Errorf("invalid CSV delimiter %q", delimiter)
+		}
+		comma = runes[0]
+	default:
+		comma = detectDelimiter(text)
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && parseErr.Err == csv.ErrFieldCount {
+				continue
+			}
+			return Table{}, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		rows = append(rows, record)
+	}
+	return Table{Rows: rows}, nil
+}
+
+// detectDelimiter picks the candidate that splits the first lines into the same number of
+// fields (more than one), preferring the one yielding the most fields
+func detectDelimiter(text string) rune {
+	lines := strings.SplitN(strings.ReplaceAll(text, "\r\n", "\n"), "\n", 11)
+	if len(lines) > 10 {
+		lines = lines[:10]
+	}
+
+	best, bestFields := ',', 1
+	for _, candidate := range candidateDelimiters {
+		fields, consistent := -1, true
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			count := countUnquoted(line, candidate) + 1
+			if fields == -1 {
+				fields = count
+			} else if count != fields {
+				consistent = false
+				break
+			}
+		}
+		if consistent && fields > bestFields {
+			best, bestFields = candidate, fields
+		}
+	}
+	return best
+}
+
+// countUnquoted counts the occurrences of a delimiter outside double-quoted fields
+func countUnquoted(line string, delimiter rune) int {
+	count, quoted := 0, false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == delimiter && !quoted:
+			count++
+		}
+	}
+	return count
+}
+
+// FromSheets selects the sheets of a workbook to import by name (ignoring case); no names
+// selects every visible sheet
+func FromSheets(sheets []officeprocessor.Sheet, names []string) ([]Table, error) {
+	if len(names) == 0 {
+		tables := make([]Table, 0, len(sheets))
+		for _, sheet := range sheets {
+			if !sheet.Hidden {
+				tables = append(tables, Table{Name: sheet.Name, Rows: sheet.Rows})
+			}
+		}
+		return tables, nil
+	}
+
+	tables := make([]Table, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, sheet := range sheets {
+			if strings.EqualFold(sheet.Name, strings.TrimSpace(name)) {
+				tables = append(tables, Table{Name: sheet.Name, Rows: sheet.Rows})
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %q", ErrSheetNotFound, name)
+		}
+	}
+	return tables, nil
+}
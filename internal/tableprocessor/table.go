@@ -0,0 +1,248 @@
+package tableprocessor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"api-chatbot/internal/textchunker"
+)
+
+// Errors returned when the mapping does not fit the table
+var (
+	ErrNoRows         = errors.New("table has no data rows")
+	ErrColumnNotFound = errors.New("column not found")
+	ErrSheetNotFound  = errors.New("sheet not found")
+)
+
+const (
+	columnSeparator = " | "
+	groupRowPrefix  = "- "
+)
+
+// Table is a sheet or CSV file as rows of cell texts
+type Table struct {
+	Name string // Sheet name; empty for a CSV file
+	Rows [][]string
+}
+
+// Mapping configures how the rows of a table become chunks
+type Mapping struct {
+	HeaderRow    int               // 1-based row holding the column names (default 1)
+	Columns      []string          // Columns to keep, in this order (default: every named column)
+	Labels       map[string]string // Column name -> label written in the chunks
+	GroupBy      []string          // Rows sharing these column values become one chunk
+	RowsPerChunk int               // Rows per chunk without GroupBy (default 1)
+}
+
+// column is a kept column of a table and the label it is written with
+type column struct {
+	index int
+	label string
+}
+
+// Chunks renders the rows of the tables as self-describing chunks: every row is written as
+// "Carrera: Software | Semestre: 3 | Lunes: 08:00", skipping empty cells. With GroupBy the
+// rows sharing the group values become one chunk headed by those values, each row listed
+// below with the remaining columns; a group longer than chunkSize is split, repeating the
+// group line. When there are several tables, each chunk is prefixed with its sheet name and
+// carries it as its heading.
+func Chunks(tables []Table, mapping Mapping, chunkSize int) ([]textchunker.Chunk, error) {
+	if chunkSize <= 0 {
+		chunkSize = 1000 // Default chunk size
+	}
+
+	var chunks []textchunker.Chunk
+	rows := 0
+	for _, table := range tables {
+		var headings []string
+		if len(tables) > 1 && table.Name != "" {
+			headings = []string{table.Name}
+		}
+
+		tableChunks, tableRows, err := chunkTable(table, mapping, chunkSize, headings)
+		if err != nil {
+			if table.Name != "" {
+				return nil, fmt.Errorf("sheet %q: %w", table.Name, err)
+			}
+			return nil, err
+		}
+		chunks = append(chunks, tableChunks...)
+		rows += tableRows
+	}
+
+	if rows == 0 {
+		return nil, ErrNoRows
+	}
+	return chunks, nil
+}
+
+// chunkTable renders the data rows of one table, returning the chunks and the rows rendered
+func chunkTable(table Table, mapping Mapping, chunkSize int, headings []string) ([]textchunker.Chunk, int, error) {
+	headerRow := max(mapping.HeaderRow, 1) - 1
+	if headerRow >= len(table.Rows) {
+		return nil, 0, nil // Empty sheet
+	}
+	header := table.Rows[headerRow]
+
+	columns, err := resolveColumns(header, mapping.Columns, mapping.Labels)
+	if err != nil {
+		return nil, 0, err
+	}
+	var groupColumns []column
+	if len(mapping.GroupBy) > 0 {
+		groupColumns, err = resolveColumns(header, mapping.GroupBy, mapping.Labels)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	if len(columns) == 0 {
+		return nil, 0, nil
+	}
+
+	prefix := textchunker.Breadcrumb(headings)
+	var chunks []textchunker.Chunk
+	emit := func(content string) {
+		if prefix != "" {
+			content = prefix + "\n\n" + content
+		}
+		chunks = append(chunks, textchunker.Chunk{Content: content, Headings: headings})
+	}
+
+	dataRows := table.Rows[headerRow+1:]
+	rendered := 0
+
+	if len(groupColumns) == 0 {
+		perChunk := max(mapping.RowsPerChunk, 1)
+		var lines []string
+		size := 0
+		flush := func() {
+			if len(lines) > 0 {
+				emit(strings.Join(lines, "\n"))
+				lines, size = nil, 0
+			}
+		}
+		for _, row := range dataRows {
+			line := renderRow(row, columns, nil)
+			if line == "" {
+				continue
+			}
+			rendered++
+			if len(lines) >= perChunk || (len(lines) > 0 && size+len(line)+1 > chunkSize) {
+				flush()
+			}
+			lines = append(lines, line)
+			size += len(line) + 1
+		}
+		flush()
+		return chunks, rendered, nil
+	}
+
+	// Group the rows by the values of the group columns, in order of first appearance
+	type group struct {
+		line string
+		rows []string
+	}
+	var groups []*group
+	byKey := map[string]*group{}
+	for _, row := range dataRows {
+		line := renderRow(row, columns, groupColumns)
+		groupLine := renderRow(row, groupColumns, nil)
+		if line == "" && groupLine == "" {
+			continue
+		}
+		rendered++
+		g, ok := byKey[groupLine]
+		if !ok {
+			g = &group{line: groupLine}
+			byKey[groupLine] = g
+			groups = append(groups, g)
+		}
+		if line != "" {
+			g.rows = append(g.rows, groupRowPrefix+line)
+		}
+	}
+
+	for _, g := range groups {
+		lines := []string{g.line}
+		size := len(g.line)
+		for _, row := range g.rows {
+			if len(lines) > 1 && size+len(row)+1 > chunkSize {
+				emit(strings.Join(lines, "\n"))
+				lines, size = []string{g.line}, len(g.line)
+			}
+			lines = append(lines, row)
+			size += len(row) + 1
+		}
+		emit(strings.TrimSpace(strings.Join(lines, "\n")))
+	}
+	return chunks, rendered, nil
+}
+
+// resolveColumns finds the mapped columns in the header, ignoring case. No names keeps
+// every column with a name.
+func resolveColumns(header []string, names []string, labels map[string]string) ([]column, error) {
+	labelFor := func(name string) string {
+		for key, label := range labels {
+			if strings.EqualFold(strings.TrimSpace(key), name) && strings.TrimSpace(label) != "" {
+				return strings.TrimSpace(label)
+			}
+		}
+		return name
+	}
+
+	if len(names) == 0 {
+		columns := make([]column, 0, len(header))
+		for i, name := range header {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			columns = append(columns, column{index: i, label: labelFor(name)})
+		}
+		return columns, nil
+	}
+
+	columns := make([]column, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		index := -1
+		for i, cell := range header {
+			if strings.EqualFold(strings.TrimSpace(cell), name) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrColumnNotFound, name)
+		}
+		columns = append(columns, column{index: index, label: labelFor(strings.TrimSpace(header[index]))})
+	}
+	return columns, nil
+}
+
+// renderRow writes the non-empty cells of a row as "Label: value" pairs, leaving out the
+// skipped columns
+func renderRow(row []string, columns []column, skip []column) string {
+	parts := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if containsColumn(skip, col.index) || col.index >= len(row) {
+			continue
+		}
+		value := strings.Join(strings.Fields(row[col.index]), " ")
+		if value == "" {
+			continue
+		}
+		parts = append(parts, col.label+": "+value)
+	}
+	return strings.Join(parts, columnSeparator)
+}
+
+func containsColumn(columns []column, index int) bool {
+	for _, col := range columns {
+		if col.index == index {
+			return true
+		}
+	}
+	return false
+}
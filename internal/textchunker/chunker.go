@@ -0,0 +1,254 @@
+package textchunker
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Chunking strategies
+const (
+	StrategySentence  = "sentence"  // Whole sentences (and list items) packed up to the chunk size
+	StrategyRecursive = "recursive" // Paragraphs, then lines, sentences, clauses and words, as needed
+	StrategyTokens    = "tokens"    // Like sentence, with the size counted in estimated tokens
+	StrategyHeadings  = "headings"  // One section per heading, chunked by sentence with its breadcrumb
+)
+
+// Strategies lists the chunking strategies
+var Strategies = []string{StrategySentence, StrategyRecursive, StrategyTokens, StrategyHeadings}
+
+const (
+	DefaultChunkSize    = 1000 // Characters
+	DefaultOverlap      = 200  // Characters
+	DefaultTokenSize    = 256  // Estimated tokens
+	DefaultTokenOverlap = 32   // Estimated tokens
+)
+
+// Chunker splits a text into chunks
+type Chunker interface {
+	Chunk(text string) []Chunk
+}
+
+// Options selects a chunking strategy and its size. Sizes are counted in characters (runes),
+// or in estimated tokens for the token strategy.
+type Options struct {
+	Strategy  string
+	ChunkSize int // 0: the strategy default
+	Overlap   int // Negative: the strategy default
+}
+
+// IsStrategy reports whether name is a chunking strategy
+func IsStrategy(name string) bool {
+	for _, strategy := range Strategies {
+		if strategy == name {
+			return true
+		}
+	}
+	return false
+}
+
+// New creates the chunker of a strategy; an empty strategy is sentence
+func New(opts Options) (Chunker, error) {
+	opts = WithDefaults(opts)
+	runes := packer{size: opts.ChunkSize, overlap: opts.Overlap, measure: utf8.RuneCountInString}
+
+	switch opts.Strategy {
+	case StrategySentence:
+		return sentenceChunker{packer: runes}, nil
+	case StrategyRecursive:
+		return recursiveChunker{packer: runes}, nil
+	case StrategyTokens:
+		return sentenceChunker{packer: packer{size: opts.ChunkSize, overlap: opts.Overlap, measure: CountTokens}}, nil
+	case StrategyHeadings:
+		return headingChunker{size: opts.ChunkSize, overlap: opts.Overlap}, nil
+	default:
+		return nil, fmt.Errorf("unknown chunking strategy %q", opts.Strategy)
+	}
+}
+
+// WithDefaults fills the strategy, size and overlap left unset, keeping the overlap below the size
+func WithDefaults(opts Options) Options {
+	if opts.Strategy == "" {
+		opts.Strategy = StrategySentence
+	}
+	size, overlap := DefaultChunkSize, DefaultOverlap
+	if opts.Strategy == StrategyTokens {
+		size, overlap = DefaultTokenSize, DefaultTokenOverlap
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = size
+	}
+	if opts.Overlap < 0 {
+		opts.Overlap = min(overlap, opts.ChunkSize/2)
+	}
+	if opts.Overlap >= opts.ChunkSize {
+		opts.Overlap = opts.ChunkSize / 2 // Ensure overlap is less than chunk size
+	}
+	return opts
+}
+
+// Contents returns the text of the chunks
+func Contents(chunks []Chunk) []string {
+	contents := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		contents[i] = chunk.Content
+	}
+	return contents
+}
+
+// CountTokens estimates the tokens of a text the way subword tokenizers split Spanish and
+// English: a word counts one token per four characters (rounded up), and every punctuation
+// mark or symbol one token
+func CountTokens(text string) int {
+	tokens, wordLen := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			wordLen++
+			continue
+		case unicode.IsSpace(r):
+		default:
+			tokens++
+		}
+		tokens += (wordLen + 3) / 4
+		wordLen = 0
+	}
+	return tokens + (wordLen+3)/4
+}
+
+// unit is a piece of text that is never split unless it alone exceeds the chunk size,
+// with the separator that precedes it in the text ("\n\n", "\n" or " ")
+type unit struct {
+	text string
+	sep  string
+}
+
+// packer groups units into chunks of at most size (as measured), starting every chunk
+// after the first with the tail of the previous one, up to overlap
+type packer struct {
+	size    int
+	overlap int
+	measure func(string) int
+}
+
+func (p packer) pack(units []unit) []string {
+	var chunks []string
+	current := ""
+
+	add := func(u unit) {
+		if current != "" && p.measure(current+u.sep+u.text) > p.size {
+			chunks = append(chunks, current)
+			// The overlap is dropped when it leaves no room for the unit
+			current = p.tail(current)
+			if current != "" && p.measure(current+" "+u.text) > p.size {
+				current = ""
+			}
+		}
+		if current == "" {
+			current = u.text
+		} else {
+			current += u.sep + u.text
+		}
+	}
+
+	for _, u := range units {
+		if u.sep == "" {
+			u.sep = " "
+		}
+		if p.measure(u.text) <= p.size {
+			add(u)
+			continue
+		}
+		for i, piece := range p.splitOversized(u.text) {
+			sep := " "
+			if i == 0 {
+				sep = u.sep
+			}
+			add(unit{text: piece, sep: sep})
+		}
+	}
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// tail returns the longest end of a chunk that fits in the overlap, preferring one that
+// starts a sentence or a line over one that starts mid-sentence at a word
+func (p packer) tail(chunk string) string {
+	if p.overlap <= 0 {
+		return ""
+	}
+	best, bestSentence := "", ""
+	for i := len(chunk) - 1; i > 0; i-- {
+		if !utf8.RuneStart(chunk[i]) {
+			continue
+		}
+		previous, _ := utf8.DecodeLastRuneInString(chunk[:i])
+		next, _ := utf8.DecodeRuneInString(chunk[i:])
+		if !unicode.IsSpace(previous) || unicode.IsSpace(next) {
+			continue
+		}
+		if p.measure(chunk[i:]) > p.overlap {
+			break
+		}
+		best = chunk[i:]
+		before := strings.TrimRightFunc(chunk[:i], unicode.IsSpace)
+		last, _ := utf8.DecodeLastRuneInString(before)
+		if previous == '\n' || isTerminal(last) || isCloser(last) || last == ':' {
+			bestSentence = best
+		}
+	}
+	if bestSentence != "" {
+		return bestSentence
+	}
+	return best
+}
+
+// splitOversized splits a text longer than the chunk size on words, cutting words that
+// are longer on their own
+func (p packer) splitOversized(text string) []string {
+	var pieces []string
+	current := ""
+	for _, word := range strings.Fields(text) {
+		if p.measure(word) > p.size {
+			if current != "" {
+				pieces = append(pieces, current)
+				current = ""
+			}
+			pieces = append(pieces, p.cutWord(word)...)
+			continue
+		}
+		if current != "" && p.measure(current+" "+word) > p.size {
+			pieces = append(pieces, current)
+			current = ""
+		}
+		if current == "" {
+			current = word
+		} else {
+			current += " " + word
+		}
+	}
+	if current != "" {
+		pieces = append(pieces, current)
+	}
+	return pieces
+}
+
+// cutWord cuts a word (a long URL or identifier) into pieces that fit the chunk size
+func (p packer) cutWord(word string) []string {
+	var pieces []string
+	var current strings.Builder
+	for _, r := range word {
+		if current.Len() > 0 && p.measure(current.String()+string(r)) > p.size {
+			pieces = append(pieces, current.String())
+			current.Reset()
+		}
+		current.WriteRune(r)
+	}
+	if current.Len() > 0 {
+		pieces = append(pieces, current.String())
+	}
+	return pieces
+}
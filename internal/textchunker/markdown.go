@@ -0,0 +1,178 @@
+package textchunker
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// BreadcrumbSeparator joins the headings of a chunk's breadcrumb
+const BreadcrumbSeparator = " > "
+
+var (
+	atxHeading    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextH1      = regexp.MustCompile(`^ {0,3}=+[ \t]*$`)
+	setextH2      = regexp.MustCompile(`^ {0,3}-+[ \t]*$`)
+	codeFence     = regexp.MustCompile("^ {0,3}(```|~~~)")
+	markdownLink  = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	listItemStart = regexp.MustCompile(`^ {0,3}([-*+]|\d+[.)])[ \t]`)
+)
+
+// Chunk is a piece of a document with the path of headings it falls under
+type Chunk struct {
+	Content  string
+	Headings []string // Outermost heading first; empty before the first heading
+}
+
+// section is the text between two headings
+type section struct {
+	headings []string
+	lines    []string
+}
+
+// Breadcrumb renders a heading path as "Admisiones > Requisitos"
+func Breadcrumb(headings []string) string {
+	return strings.Join(headings, BreadcrumbSeparator)
+}
+
+// headingChunker splits Markdown (or plain text) on its heading hierarchy: the text under
+// each heading is chunked by sentence on its own, so no chunk spans two sections, and every
+// chunk is prefixed with its heading breadcrumb
+type headingChunker struct {
+	size    int
+	overlap int
+}
+
+// ChunkMarkdown chunks Markdown (or plain text) with the headings strategy. ATX ("## Title")
+// and setext (underlined) headings are recognised; headings inside fenced code blocks and a
+// leading YAML front matter are ignored. Text without headings is chunked as with ChunkText.
+func ChunkMarkdown(text string, chunkSize, overlap int) []Chunk {
+	opts := WithDefaults(Options{Strategy: StrategyHeadings, ChunkSize: chunkSize, Overlap: max(overlap, 0)})
+	return headingChunker{size: opts.ChunkSize, overlap: opts.Overlap}.Chunk(text)
+}
+
+func (c headingChunker) Chunk(text string) []Chunk {
+	var chunks []Chunk
+	for _, sec := range splitSections(text) {
+		body := strings.TrimSpace(strings.Join(sec.lines, "\n"))
+		if body == "" {
+			continue
+		}
+
+		prefix := Breadcrumb(sec.headings)
+		// The breadcrumb counts towards the chunk size, but never leaves less than half of it for the text
+		size := c.size
+		if prefix != "" {
+			size = max(c.size-utf8.RuneCountInString(prefix)-2, c.size/2)
+		}
+		sentences := packer{size: size, overlap: min(c.overlap, size/2), measure: utf8.RuneCountInString}
+
+		for _, piece := range sentences.pack(sentenceUnits(body)) {
+			content := piece
+			if prefix != "" {
+				content = prefix + "\n\n" + piece
+			}
+			chunks = append(chunks, Chunk{Content: content, Headings: sec.headings})
+		}
+	}
+	return chunks
+}
+
+// splitSections walks the lines of a Markdown text, starting a new section at every heading
+func splitSections(text string) []section {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+	lines := strings.Split(text, "\n")
+	lines = skipFrontMatter(lines)
+
+	type openHeading struct {
+		level int
+		text  string
+	}
+	var stack []openHeading
+	current := &section{}
+	sections := []*section{current}
+
+	startSection := func(level int, title string) {
+		for len(stack) > 0 && stack[len(stack)-1].level >= level {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, openHeading{level: level, text: title})
+		headings := make([]string, len(stack))
+		for i, h := range stack {
+			headings[i] = h.text
+		}
+		current = &section{headings: headings}
+		sections = append(sections, current)
+	}
+
+	inFence := ""
+	for _, line := range lines {
+		if match := codeFence.FindStringSubmatch(line); match != nil {
+			switch {
+			case inFence == "":
+				inFence = match[1]
+			case inFence == match[1]:
+				inFence = ""
+			}
+			current.lines = append(current.lines, line)
+			continue
+		}
+		if inFence != "" {
+			current.lines = append(current.lines, line)
+			continue
+		}
+
+		if match := atxHeading.FindStringSubmatch(line); match != nil {
+			if title := cleanHeading(match[2]); title != "" {
+				startSection(len(match[1]), title)
+				continue
+			}
+		}
+
+		// Setext heading: a single paragraph line underlined with "=" or "-"
+		if n := len(current.lines); n > 0 && (setextH1.MatchString(line) || setextH2.MatchString(line)) {
+			previous := current.lines[n-1]
+			startsParagraph := n == 1 || strings.TrimSpace(current.lines[n-2]) == ""
+			if strings.TrimSpace(previous) != "" && startsParagraph && !listItemStart.MatchString(previous) {
+				if title := cleanHeading(previous); title != "" {
+					current.lines = current.lines[:n-1]
+					level := 2
+					if setextH1.MatchString(line) {
+						level = 1
+					}
+					startSection(level, title)
+					continue
+				}
+			}
+		}
+
+		current.lines = append(current.lines, line)
+	}
+
+	result := make([]section, len(sections))
+	for i, sec := range sections {
+		result[i] = *sec
+	}
+	return result
+}
+
+// skipFrontMatter drops a leading YAML front matter block ("---" ... "---")
+func skipFrontMatter(lines []string) []string {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return lines
+	}
+	for i := 1; i < len(lines); i++ {
+		if trimmed := strings.TrimSpace(lines[i]); trimmed == "---" || trimmed == "..." {
+			return lines[i+1:]
+		}
+	}
+	return lines
+}
+
+// cleanHeading strips inline Markdown (emphasis, code, links) from a heading
+func cleanHeading(heading string) string {
+	heading = markdownLink.ReplaceAllString(heading, "$1")
+	heading = strings.NewReplacer("**", "", "__", "", "`", "").Replace(heading)
+	heading = strings.Trim(strings.TrimSpace(heading), "*_")
+	return strings.Join(strings.Fields(heading), " ")
+}
@@ -0,0 +1,75 @@
+package textchunker
+
+import "strings"
+
+// recursiveLevels are the separators tried in turn on a piece still larger than the chunk
+// size: paragraphs, lines, sentences, then clauses. Words and characters are left to the
+// packer.
+var recursiveLevels = []struct {
+	split func(string) []string
+	sep   string
+}{
+	{split: func(text string) []string { return splitKeeping(text, "\n\n", "") }, sep: "\n\n"},
+	{split: func(text string) []string { return splitKeeping(text, "\n", "") }, sep: "\n"},
+	{split: splitSentences, sep: " "},
+	{split: func(text string) []string { return splitKeeping(text, "; ", ";") }, sep: " "},
+	{split: func(text string) []string { return splitKeeping(text, ", ", ",") }, sep: " "},
+}
+
+// recursiveChunker splits a text on the coarsest separator that brings its pieces under
+// the chunk size, so paragraphs stay whole when they fit, and packs the pieces
+type recursiveChunker struct {
+	packer packer
+}
+
+func (c recursiveChunker) Chunk(text string) []Chunk {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+
+	var chunks []Chunk
+	for _, content := range c.packer.pack(c.units(text, "", 0)) {
+		chunks = append(chunks, Chunk{Content: content})
+	}
+	return chunks
+}
+
+// units splits a piece at the given level and, recursively, the sub-pieces that are still
+// larger than the chunk size
+func (c recursiveChunker) units(text, sep string, level int) []unit {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if c.packer.measure(text) <= c.packer.size || level >= len(recursiveLevels) {
+		return []unit{{text: text, sep: sep}}
+	}
+
+	pieces := recursiveLevels[level].split(text)
+	if len(pieces) <= 1 {
+		return c.units(text, sep, level+1)
+	}
+
+	var units []unit
+	for i, piece := range pieces {
+		pieceSep := recursiveLevels[level].sep
+		if i == 0 {
+			pieceSep = sep
+		}
+		units = append(units, c.units(piece, pieceSep, level+1)...)
+	}
+	return units
+}
+
+// splitKeeping splits a text on a separator, keeping the given mark at the end of each piece
+func splitKeeping(text, separator, mark string) []string {
+	parts := strings.Split(text, separator)
+	pieces := make([]string, 0, len(parts))
+	for i, part := range parts {
+		if i < len(parts)-1 {
+			part += mark
+		}
+		if part = strings.TrimSpace(part); part != "" {
+			pieces = append(pieces, part)
+		}
+	}
+	return pieces
+}
@@ -0,0 +1,218 @@
+package textchunker
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	bulletItem   = regexp.MustCompile(`^\s*([-*+•·▪◦‣–]|\d{1,3}[.)]|[a-z]\))\s`)
+	tableRowLine = regexp.MustCompile(`^\s*\|`)
+	listNumber   = regexp.MustCompile(`^\s*(\d{1,3}|[a-zA-Z])\.$`)
+)
+
+// abbreviations are words commonly written with a period that does not end the sentence
+var abbreviations = map[string]bool{
+	"sr": true, "sra": true, "srta": true, "sres": true, "dr": true, "dra": true, "ing": true,
+	"lic": true, "arq": true, "abg": true, "prof": true, "mgs": true, "msc": true, "mtr": true,
+	"phd": true, "econ": true, "tlgo": true, "ej": true, "etc": true, "núm": true, "num": true,
+	"art": true, "arts": true, "pág": true, "pag": true, "págs": true, "cap": true, "av": true,
+	"ud": true, "uds": true, "vs": true, "aprox": true, "tel": true, "telf": true, "ext": true,
+	"dpto": true, "depto": true, "mr": true, "mrs": true, "ms": true,
+}
+
+// sentenceChunker packs whole sentences, list items, headings and table rows into chunks,
+// splitting a sentence only when it alone exceeds the chunk size
+type sentenceChunker struct {
+	packer packer
+}
+
+func (c sentenceChunker) Chunk(text string) []Chunk {
+	var chunks []Chunk
+	for _, content := range c.packer.pack(sentenceUnits(text)) {
+		chunks = append(chunks, Chunk{Content: content})
+	}
+	return chunks
+}
+
+// sentenceUnits splits a text into blocks (paragraphs, list items, headings, table rows and
+// code blocks) and the blocks of prose into sentences
+func sentenceUnits(text string) []unit {
+	var units []unit
+	for _, block := range splitBlocks(text) {
+		if block.verbatim {
+			units = append(units, unit{text: block.text, sep: block.sep})
+			continue
+		}
+		for i, sentence := range splitSentences(block.text) {
+			sep := " "
+			if i == 0 {
+				sep = block.sep
+			}
+			units = append(units, unit{text: sentence, sep: sep})
+		}
+	}
+	return units
+}
+
+// block is a paragraph, list item, heading, table row or code block
+type block struct {
+	text     string
+	sep      string // "\n\n" after a blank line, else "\n"
+	verbatim bool   // Headings, table rows and code blocks are not split into sentences
+}
+
+// splitBlocks groups the lines of a text into blocks. Lines wrapped inside a paragraph or
+// list item (as extracted from PDFs) are joined with a space.
+func splitBlocks(text string) []block {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+
+	var blocks []block
+	var lines []string
+	sep := ""
+	verbatim := false
+
+	flush := func() {
+		if len(lines) == 0 {
+			return
+		}
+		joined := strings.Join(lines, " ")
+		if verbatim {
+			joined = strings.Join(lines, "\n")
+		}
+		blocks = append(blocks, block{text: joined, sep: sep, verbatim: verbatim})
+		lines, sep, verbatim = nil, "\n", false
+	}
+
+	inFence := ""
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if match := codeFence.FindStringSubmatch(line); match != nil {
+			if inFence == "" {
+				flush()
+				inFence = match[1]
+				verbatim = true
+				lines = append(lines, strings.TrimRight(line, " \t"))
+				continue
+			}
+			if inFence == match[1] {
+				lines = append(lines, strings.TrimRight(line, " \t"))
+				flush()
+				inFence = ""
+				continue
+			}
+		}
+		if inFence != "" {
+			lines = append(lines, strings.TrimRight(line, " \t"))
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flush()
+			if len(blocks) > 0 {
+				sep = "\n\n"
+			}
+		case atxHeading.MatchString(line) || tableRowLine.MatchString(line):
+			flush()
+			lines, verbatim = []string{trimmed}, true
+			flush()
+		case bulletItem.MatchString(line):
+			flush()
+			lines = []string{strings.TrimRight(line, " \t")}
+		default:
+			lines = append(lines, trimmed)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// splitSentences splits prose into sentences. A sentence ends with ".", "!", "?" or "…"
+// (and any closing quotes or brackets) followed by a space and the start of another
+// sentence: an uppercase letter, a digit, an opening "¿", "¡", quote or bracket.
+// Abbreviations (Sr., Ing., etc.) and initials do not end a sentence.
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	text = strings.ToValidUTF8(text, "\uFFFD") // Keeps rune and byte offsets in step
+	runes := []rune(text)
+	offsets := make([]int, len(runes)+1)
+	pos := 0
+	for i, r := range runes {
+		offsets[i] = pos
+		pos += utf8.RuneLen(r)
+	}
+	offsets[len(runes)] = pos
+
+	for i := 0; i < len(runes); i++ {
+		if !isTerminal(runes[i]) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && (isTerminal(runes[end]) || isCloser(runes[end])) {
+			end++
+		}
+		if end >= len(runes) || !unicode.IsSpace(runes[end]) {
+			i = end - 1
+			continue
+		}
+		next := end
+		for next < len(runes) && unicode.IsSpace(runes[next]) {
+			next++
+		}
+		if next >= len(runes) || !startsSentence(runes[next]) {
+			i = end - 1
+			continue
+		}
+		if runes[i] == '.' && end == i+1 && isAbbreviation(runes[:i]) {
+			continue
+		}
+		sentence := strings.TrimSpace(text[offsets[start]:offsets[end]])
+		if listNumber.MatchString(sentence) {
+			continue // "1. Pagar la matrícula" is a list item, not a sentence "1."
+		}
+
+		if sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = next
+		i = next - 1
+	}
+
+	if sentence := strings.TrimSpace(text[offsets[start]:]); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+	return sentences
+}
+
+func isTerminal(r rune) bool {
+	return r == '.' || r == '!' || r == '?' || r == '…'
+}
+
+func isCloser(r rune) bool {
+	return strings.ContainsRune(`"')]»”’`, r)
+}
+
+func startsSentence(r rune) bool {
+	return unicode.IsUpper(r) || unicode.IsDigit(r) || strings.ContainsRune(`¿¡"'([«“‘`, r)
+}
+
+// isAbbreviation reports whether the word before a period is an abbreviation or an initial
+func isAbbreviation(before []rune) bool {
+	i := len(before)
+	for i > 0 && unicode.IsLetter(before[i-1]) {
+		i--
+	}
+	word := before[i:]
+	if len(word) == 0 {
+		return false
+	}
+	if len(word) == 1 {
+		return true // Initial ("J. Pérez") or enumeration ("a. b.")
+	}
+	return abbreviations[strings.ToLower(string(word))]
+}
@@ -0,0 +1,391 @@
+package webcrawler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	defaultUserAgent      = "ApiChatbotCrawler"
+	defaultRequestTimeout = 20 * time.Second
+	defaultMaxPageBytes   = 5 * 1024 * 1024
+	maxCrawlDelay         = 10 * time.Second
+	maxSitemapURLs        = 50000
+	maxSitemapDepth       = 3
+)
+
+// skippedExtensions are linked files that are not web pages
+var skippedExtensions = map[string]bool{
+	".pdf": true, ".doc": true, ".docx": true, ".odt": true, ".xls": true, ".xlsx": true,
+	".ppt": true, ".pptx": true, ".zip": true, ".rar": true, ".jpg": true, ".jpeg": true,
+	".png": true, ".gif": true, ".svg": true, ".webp": true, ".mp3": true, ".mp4": true,
+	".avi": true, ".css": true, ".js": true, ".xml": true, ".ico": true,
+}
+
+// Options configures a crawl
+type Options struct {
+	MaxDepth       int      // Link levels followed from the start page or the sitemap pages
+	MaxPages       int      // Pages fetched at most
+	Include        []string // Regexps: when set, only matching URLs become pages (others are still followed)
+	Exclude        []string // Regexps: matching URLs are neither fetched nor followed
+	UserAgent      string   // Sent with every request and matched against robots.txt
+	Delay          time.Duration
+	RequestTimeout time.Duration
+	MaxPageBytes   int64
+}
+
+// Page is the main content of a crawled page
+type Page struct {
+	URL         string
+	Title       string
+	Content     string
+	ContentHash string // SHA-256 of title and content
+	Depth       int
+}
+
+// PageError is a page that could not be crawled
+type PageError struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// Result is the outcome of a crawl
+type Result struct {
+	Pages   []Page
+	Errors  []PageError
+	Fetched int
+	Skipped int // Disallowed by robots.txt, excluded, noindex or empty
+}
+
+// Crawler fetches a site from a start page or a sitemap, staying on the host of the start URL
+type Crawler struct {
+	client *http.Client
+}
+
+// NewCrawler creates a crawler; a nil client uses a default one
+func NewCrawler(client *http.Client) *Crawler {
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &Crawler{client: client}
+}
+
+// CompilePatterns compiles include or exclude patterns
+func CompilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+type queued struct {
+	url   *url.URL
+	depth int
+}
+
+// Crawl fetches the start page (or every page of the sitemap) and follows links on the same
+// host breadth-first up to MaxDepth levels and MaxPages fetches, honouring robots.txt
+// (including Crawl-delay) and the page robots meta tag
+func (c *Crawler) Crawl(ctx context.Context, startURL string, sitemap bool, opts Options) (*Result, error) {
+	opts = withDefaults(opts)
+	include, err := CompilePatterns(opts.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := CompilePatterns(opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := parseHTTPURL(startURL)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var queue []queued
+	if sitemap {
+		urls, err := c.sitemapURLs(ctx, start, opts, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range urls {
+			if u.Host != start.Host || seen[u.String()] {
+				continue
+			}
+			seen[u.String()] = true
+			queue = append(queue, queued{url: u, depth: 0})
+		}
+	} else {
+		seen[start.String()] = true
+		queue = append(queue, queued{url: start, depth: 0})
+	}
+
+	result := &Result{}
+	robotsCache := map[string]*robotsRules{}
+
+	for len(queue) > 0 && result.Fetched < opts.MaxPages {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		item := queue[0]
+		queue = queue[1:]
+
+		rules := c.robots(ctx, item.url, opts, robotsCache)
+		if !rules.allowed(item.url) || matchesAny(exclude, item.url.String()) {
+			result.Skipped++
+			continue
+		}
+
+		if result.Fetched > 0 {
+			delay := opts.Delay
+			if rules.crawlDelay > delay {
+				delay = min(rules.crawlDelay, maxCrawlDelay)
+			}
+			select {
+			case <-ctx.Done():
+				return result, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		result.Fetched++
+		finalURL, page, err := c.fetchPage(ctx, item.url, opts)
+		if err != nil {
+			result.Errors = append(result.Errors, PageError{URL: item.url.String(), Error: err.Error()})
+			continue
+		}
+		if finalURL.Host != start.Host {
+			result.Skipped++
+			continue
+		}
+
+		pageURL := finalURL.String()
+		ingest := !page.noindex && page.content != "" && (len(include) == 0 || matchesAny(include, pageURL))
+		if ingest {
+			title := page.title
+			if title == "" {
+				title = pageURL
+			}
+			result.Pages = append(result.Pages, Page{
+				URL:         pageURL,
+				Title:       title,
+				Content:     page.content,
+				ContentHash: contentHash(title, page.content),
+				Depth:       item.depth,
+			})
+		} else {
+			result.Skipped++
+		}
+
+		if page.nofollow || item.depth >= opts.MaxDepth {
+			continue
+		}
+		for _, href := range page.links {
+			link, ok := resolveLink(finalURL, href)
+			if !ok || link.Host != start.Host || seen[link.String()] {
+				continue
+			}
+			seen[link.String()] = true
+			queue = append(queue, queued{url: link, depth: item.depth + 1})
+		}
+	}
+
+	return result, nil
+}
+
+// fetchPage downloads an HTML page and extracts its content, returning the URL it was
+// served from after redirects
+func (c *Crawler) fetchPage(ctx context.Context, u *url.URL, opts Options) (*url.URL, extracted, error) {
+	resp, err := c.get(ctx, u, opts)
+	if err != nil {
+		return nil, extracted{}, err
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.Contains(contentType, "html") {
+		return nil, extracted{}, fmt.Errorf("not an HTML page (%s)", contentType)
+	}
+
+	reader, err := charset.NewReader(io.LimitReader(resp.Body, opts.MaxPageBytes), contentType)
+	if err != nil {
+		return nil, extracted{}, fmt.Errorf("failed to decode page: %w", err)
+	}
+	doc, err := html.Parse(reader)
+	if err != nil {
+		return nil, extracted{}, fmt.Errorf("failed to parse page: %w", err)
+	}
+
+	finalURL := resp.Request.URL
+	finalURL.Fragment = ""
+	return finalURL, extract(doc), nil
+}
+
+// get sends a GET request and fails on non-2xx responses
+func (c *Crawler) get(ctx context.Context, u *url.URL, opts Options) (*http.Response, error) {
+	requestCtx, cancel := context.WithTimeout(ctx, opts.RequestTimeout)
+	req, err := http.NewRequestWithContext(requestCtx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", opts.UserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the request timeout once the body is read
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// sitemapURLs reads a sitemap (<urlset>) or a sitemap index (<sitemapindex>), following
+// nested sitemaps on the same host
+func (c *Crawler) sitemapURLs(ctx context.Context, u *url.URL, opts Options, depth int) ([]*url.URL, error) {
+	resp, err := c.get(ctx, u, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	var doc struct {
+		XMLName  xml.Name
+		URLs     []string `xml:"url>loc"`
+		Sitemaps []string `xml:"sitemap>loc"`
+	}
+	decoder := xml.NewDecoder(io.LimitReader(resp.Body, 50*1024*1024))
+	decoder.CharsetReader = charset.NewReaderLabel
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap %s: %w", u, err)
+	}
+
+	urls := make([]*url.URL, 0, len(doc.URLs))
+	for _, loc := range doc.URLs {
+		if parsed, err := parseHTTPURL(strings.TrimSpace(loc)); err == nil {
+			urls = append(urls, parsed)
+		}
+		if len(urls) >= maxSitemapURLs {
+			return urls, nil
+		}
+	}
+
+	if depth >= maxSitemapDepth {
+		return urls, nil
+	}
+	for _, loc := range doc.Sitemaps {
+		nested, err := parseHTTPURL(strings.TrimSpace(loc))
+		if err != nil || nested.Host != u.Host {
+			continue
+		}
+		nestedURLs, err := c.sitemapURLs(ctx, nested, opts, depth+1)
+		if err != nil {
+			continue
+		}
+		urls = append(urls, nestedURLs...)
+		if len(urls) >= maxSitemapURLs {
+			return urls[:maxSitemapURLs], nil
+		}
+	}
+	return urls, nil
+}
+
+func withDefaults(opts Options) Options {
+	if opts.UserAgent == "" {
+		opts.UserAgent = defaultUserAgent
+	}
+	if opts.RequestTimeout <= 0 {
+		opts.RequestTimeout = defaultRequestTimeout
+	}
+	if opts.MaxPageBytes <= 0 {
+		opts.MaxPageBytes = defaultMaxPageBytes
+	}
+	if opts.MaxPages <= 0 {
+		opts.MaxPages = 100
+	}
+	if opts.MaxDepth < 0 {
+		opts.MaxDepth = 0
+	}
+	return opts
+}
+
+// parseHTTPURL parses an absolute http(s) URL without its fragment
+func parseHTTPURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q: must be an absolute http(s) URL", raw)
+	}
+	u.Fragment = ""
+	u.Host = strings.ToLower(u.Host)
+	return u, nil
+}
+
+// resolveLink resolves a link of a page, skipping non-http links and files
+func resolveLink(base *url.URL, href string) (*url.URL, bool) {
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return nil, false
+	}
+	u := base.ResolveReference(ref)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, false
+	}
+	if skippedExtensions[strings.ToLower(path.Ext(u.Path))] {
+		return nil, false
+	}
+	u.Fragment = ""
+	u.Host = strings.ToLower(u.Host)
+	return u, true
+}
+
+func matchesAny(patterns []*regexp.Regexp, value string) bool {
+	for _, re := range patterns {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func contentHash(title, content string) string {
+	sum := sha256.Sum256([]byte(title + "\n" + content))
+	return hex.EncodeToString(sum[:])
+}
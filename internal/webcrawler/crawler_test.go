@@ -0,0 +1,355 @@
+package webcrawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSite is a local site served by httptest. Pages are HTML bodies by path; every request
+// is recorded with its time.
+type testSite struct {
+	server *httptest.Server
+	robots string
+	pages  map[string]string
+	files  map[string]string // Non-HTML responses (sitemaps), served as XML
+
+	mu       sync.Mutex
+	requests []request
+}
+
+type request struct {
+	path string
+	at   time.Time
+}
+
+func newTestSite(t *testing.T, robots string, pages map[string]string) *testSite {
+	t.Helper()
+	site := &testSite{robots: robots, pages: pages, files: map[string]string{}}
+	site.server = httptest.NewServer(http.HandlerFunc(site.serve))
+	t.Cleanup(site.server.Close)
+	return site
+}
+
+func (s *testSite) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, request{path: r.URL.Path, at: time.Now()})
+	s.mu.Unlock()
+
+	if r.URL.Path == "/robots.txt" {
+		if s.robots == "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, s.robots)
+		return
+	}
+	if body, ok := s.files[r.URL.Path]; ok {
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, strings.ReplaceAll(body, "{base}", s.server.URL))
+		return
+	}
+	body, ok := s.pages[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, strings.ReplaceAll(body, "{base}", s.server.URL))
+}
+
+// requested returns the paths requested, robots.txt excluded, in order
+func (s *testSite) requested() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var paths []string
+	for _, req := range s.requests {
+		if req.path != "/robots.txt" {
+			paths = append(paths, req.path)
+		}
+	}
+	return paths
+}
+
+func (s *testSite) url(path string) string {
+	return s.server.URL + path
+}
+
+// page renders an HTML page with a paragraph of content and links
+func page(title, content string, links ...string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<html><head><title>%s</title></head><body><main><h1>%s</h1><p>%s</p><ul>", title, title, content)
+	for _, link := range links {
+		fmt.Fprintf(&b, `<li><a href="%s">%s</a></li>`, link, link)
+	}
+	b.WriteString("</ul></main></body></html>")
+	return b.String()
+}
+
+// pageURLs returns the sorted paths of the crawled pages
+func pageURLs(site *testSite, result *Result) []string {
+	paths := make([]string, 0, len(result.Pages))
+	for _, p := range result.Pages {
+		paths = append(paths, strings.TrimPrefix(p.URL, site.server.URL))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func assertPaths(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	sort.Strings(want)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("pages = %v, want %v", got, want)
+	}
+}
+
+func crawl(t *testing.T, startURL string, sitemap bool, opts Options) *Result {
+	t.Helper()
+	result, err := NewCrawler(nil).Crawl(context.Background(), startURL, sitemap, opts)
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+	return result
+}
+
+// basicSite has two levels of pages below the home page
+func basicSite(t *testing.T, robots string) *testSite {
+	return newTestSite(t, robots, map[string]string{
+		"/":                            page("Inicio", "Bienvenidos al instituto.", "/admisiones", "/carreras", "/privado/notas"),
+		"/admisiones":                  page("Admisiones", "Requisitos de ingreso.", "/admisiones/becas"),
+		"/admisiones/becas":            page("Becas", "Becas por rendimiento académico.", "/admisiones/becas/requisitos"),
+		"/admisiones/becas/requisitos": page("Requisitos de becas", "Promedio mínimo de 9."),
+		"/carreras":                    page("Carreras", "Desarrollo de software y electrónica."),
+		"/privado/notas":               page("Notas", "Calificaciones internas."),
+	})
+}
+
+func TestCrawlRobotsDisallow(t *testing.T) {
+	site := basicSite(t, "User-agent: *\nDisallow: /privado\n\nUser-agent: OtherBot\nDisallow: /\n")
+
+	result := crawl(t, site.url("/"), false, Options{MaxDepth: 1})
+
+	assertPaths(t, pageURLs(site, result), "/", "/admisiones", "/carreras")
+	for _, path := range site.requested() {
+		if strings.HasPrefix(path, "/privado") {
+			t.Fatalf("disallowed path %s was requested", path)
+		}
+	}
+	if result.Skipped != 1 {
+		t.Errorf("Skipped = %d, want 1", result.Skipped)
+	}
+}
+
+func TestCrawlRobotsUserAgentGroup(t *testing.T) {
+	site := basicSite(t, "User-agent: *\nDisallow:\n\nUser-agent: ApiChatbotCrawler\nDisallow: /carreras\n")
+
+	result := crawl(t, site.url("/"), false, Options{MaxDepth: 1})
+
+	assertPaths(t, pageURLs(site, result), "/", "/admisiones", "/privado/notas")
+}
+
+func TestCrawlRobotsCrawlDelay(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	site := basicSite(t, "User-agent: *\nCrawl-delay: 0.2\n")
+
+	crawl(t, site.url("/"), false, Options{MaxDepth: 1})
+
+	site.mu.Lock()
+	defer site.mu.Unlock()
+	var previous time.Time
+	pages := 0
+	for _, req := range site.requests {
+		if req.path == "/robots.txt" {
+			continue
+		}
+		if pages > 0 {
+			if gap := req.at.Sub(previous); gap < delay-10*time.Millisecond {
+				t.Errorf("request to %s came %v after the previous one, want at least %v", req.path, gap, delay)
+			}
+		}
+		previous = req.at
+		pages++
+	}
+	if pages < 3 {
+		t.Fatalf("only %d pages requested", pages)
+	}
+}
+
+func TestCrawlMaxDepth(t *testing.T) {
+	tests := []struct {
+		maxDepth int
+		want     []string
+	}{
+		{0, []string{"/"}},
+		{1, []string{"/", "/admisiones", "/carreras", "/privado/notas"}},
+		{2, []string{"/", "/admisiones", "/admisiones/becas", "/carreras", "/privado/notas"}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("depth %d", tt.maxDepth), func(t *testing.T) {
+			site := basicSite(t, "")
+
+			result := crawl(t, site.url("/"), false, Options{MaxDepth: tt.maxDepth})
+
+			assertPaths(t, pageURLs(site, result), tt.want...)
+			for _, p := range result.Pages {
+				if p.Depth > tt.maxDepth {
+					t.Errorf("page %s has depth %d, above %d", p.URL, p.Depth, tt.maxDepth)
+				}
+			}
+		})
+	}
+}
+
+func TestCrawlMaxPages(t *testing.T) {
+	site := basicSite(t, "")
+
+	result := crawl(t, site.url("/"), false, Options{MaxDepth: 5, MaxPages: 2})
+
+	if result.Fetched != 2 || len(site.requested()) != 2 {
+		t.Fatalf("fetched %d pages (%v), want 2", result.Fetched, site.requested())
+	}
+}
+
+func TestCrawlIncludePatterns(t *testing.T) {
+	site := basicSite(t, "")
+
+	result := crawl(t, site.url("/"), false, Options{MaxDepth: 2, Include: []string{`/admisiones`}})
+
+	// The home page is not a page of the result, but its links are still followed
+	assertPaths(t, pageURLs(site, result), "/admisiones", "/admisiones/becas")
+	if !contains(site.requested(), "/carreras") {
+		t.Errorf("pages outside the include patterns should still be followed, requested %v", site.requested())
+	}
+}
+
+func TestCrawlExcludePatterns(t *testing.T) {
+	site := basicSite(t, "")
+
+	result := crawl(t, site.url("/"), false, Options{MaxDepth: 3, Exclude: []string{`/admisiones/becas$`, `/privado/`}})
+
+	assertPaths(t, pageURLs(site, result), "/", "/admisiones", "/carreras")
+	for _, path := range site.requested() {
+		if path == "/admisiones/becas" || path == "/admisiones/becas/requisitos" || strings.HasPrefix(path, "/privado") {
+			t.Errorf("excluded path %s was requested or followed", path)
+		}
+	}
+}
+
+func TestCrawlInvalidPattern(t *testing.T) {
+	site := basicSite(t, "")
+
+	if _, err := NewCrawler(nil).Crawl(context.Background(), site.url("/"), false, Options{Include: []string{"("}}); err == nil {
+		t.Fatal("expected an error for an invalid include pattern")
+	}
+}
+
+func TestCrawlSkipsOtherHosts(t *testing.T) {
+	other := newTestSite(t, "", map[string]string{
+		"/": page("Otro sitio", "Contenido externo."),
+	})
+	site := newTestSite(t, "", map[string]string{
+		"/":         page("Inicio", "Bienvenidos.", "/contacto", other.url("/"), "mailto:info@instituto.edu.ec", "/reglamento.pdf"),
+		"/contacto": page("Contacto", "Escríbenos.", other.url("/")),
+	})
+
+	result := crawl(t, site.url("/"), false, Options{MaxDepth: 3})
+
+	assertPaths(t, pageURLs(site, result), "/", "/contacto")
+	if requested := other.requested(); len(requested) > 0 {
+		t.Fatalf("other host was requested: %v", requested)
+	}
+	if contains(site.requested(), "/reglamento.pdf") {
+		t.Error("linked files should not be fetched")
+	}
+}
+
+func TestCrawlSitemap(t *testing.T) {
+	other := newTestSite(t, "", map[string]string{"/": page("Otro", "Externo.")})
+	site := basicSite(t, "User-agent: *\nDisallow: /privado\n")
+	site.files["/sitemap.xml"] = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>{base}/admisiones</loc></url>
+  <url><loc>{base}/carreras</loc></url>
+  <url><loc>{base}/carreras</loc></url>
+  <url><loc>{base}/privado/notas</loc></url>
+  <url><loc>` + other.url("/") + `</loc></url>
+</urlset>`
+
+	result := crawl(t, site.url("/sitemap.xml"), true, Options{MaxDepth: 0})
+
+	assertPaths(t, pageURLs(site, result), "/admisiones", "/carreras")
+	if len(other.requested()) > 0 {
+		t.Fatalf("sitemap URL on another host was requested: %v", other.requested())
+	}
+}
+
+func TestCrawlSitemapIndex(t *testing.T) {
+	site := basicSite(t, "")
+	site.files["/sitemap_index.xml"] = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>{base}/sitemap-admisiones.xml</loc></sitemap>
+  <sitemap><loc>{base}/sitemap-carreras.xml</loc></sitemap>
+  <sitemap><loc>{base}/sitemap-missing.xml</loc></sitemap>
+</sitemapindex>`
+	site.files["/sitemap-admisiones.xml"] = `<urlset><url><loc>{base}/admisiones</loc></url><url><loc>{base}/admisiones/becas</loc></url></urlset>`
+	site.files["/sitemap-carreras.xml"] = `<urlset><url><loc>{base}/carreras</loc></url></urlset>`
+
+	result := crawl(t, site.url("/sitemap_index.xml"), true, Options{MaxDepth: 0})
+
+	assertPaths(t, pageURLs(site, result), "/admisiones", "/admisiones/becas", "/carreras")
+}
+
+func TestCrawlSitemapMissing(t *testing.T) {
+	site := basicSite(t, "")
+
+	if _, err := NewCrawler(nil).Crawl(context.Background(), site.url("/sitemap.xml"), true, Options{}); err == nil {
+		t.Fatal("expected an error for a missing sitemap")
+	}
+}
+
+func TestCrawlContentHashStable(t *testing.T) {
+	site := basicSite(t, "")
+
+	first := crawl(t, site.url("/"), false, Options{MaxDepth: 1})
+	second := crawl(t, site.url("/"), false, Options{MaxDepth: 1})
+
+	hashes := map[string]string{}
+	for _, p := range first.Pages {
+		hashes[p.URL] = p.ContentHash
+	}
+	if len(second.Pages) != len(first.Pages) {
+		t.Fatalf("second crawl found %d pages, first %d", len(second.Pages), len(first.Pages))
+	}
+	for _, p := range second.Pages {
+		if hashes[p.URL] != p.ContentHash {
+			t.Errorf("hash of unchanged page %s changed: %s -> %s", p.URL, hashes[p.URL], p.ContentHash)
+		}
+	}
+
+	// A changed page gets a new hash; the others keep theirs
+	site.pages["/carreras"] = page("Carreras", "Desarrollo de software, electrónica y turismo.")
+	third := crawl(t, site.url("/"), false, Options{MaxDepth: 1})
+	for _, p := range third.Pages {
+		changed := hashes[p.URL] != p.ContentHash
+		if wantChanged := strings.HasSuffix(p.URL, "/carreras"); changed != wantChanged {
+			t.Errorf("page %s: hash changed = %v, want %v", p.URL, changed, wantChanged)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,411 @@
+package webcrawler
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skippedElements never hold the main content of a page
+var skippedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+	atom.Nav:      true,
+	atom.Header:   true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Head:     true,
+}
+
+// blockElements start a new paragraph
+var blockElements = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.Div:        true,
+	atom.Section:    true,
+	atom.Article:    true,
+	atom.Main:       true,
+	atom.Blockquote: true,
+	atom.Pre:        true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Dd:         true,
+	atom.Figure:     true,
+	atom.Figcaption: true,
+	atom.Address:    true,
+	atom.Hr:         true,
+	atom.Details:    true,
+	atom.Summary:    true,
+}
+
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// extracted is the readable content of a page
+type extracted struct {
+	title    string
+	content  string
+	links    []string
+	noindex  bool
+	nofollow bool
+}
+
+// extractor renders the main content of a page as text: headings as Markdown
+// headings, list items as "- item" or "1. item" and tables as "Header: value" rows
+type extractor struct {
+	blocks   []string
+	list     []bool // Consecutive list item blocks (joined by a single line break)
+	current  strings.Builder
+	prefix   string
+	isItem   bool
+	counters []int // Item counters of the open lists (-1: unordered)
+}
+
+// extract parses a page and returns its title, main content and links
+func extract(doc *html.Node) extracted {
+	result := extracted{}
+	var title, firstHeading string
+
+	var scan func(n *html.Node)
+	scan = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Title:
+				if title == "" {
+					title = textContent(n)
+				}
+			case atom.H1:
+				if firstHeading == "" {
+					firstHeading = textContent(n)
+				}
+			case atom.A:
+				if href := attr(n, "href"); href != "" && !strings.Contains(attr(n, "rel"), "nofollow") {
+					result.links = append(result.links, href)
+				}
+			case atom.Meta:
+				if strings.EqualFold(attr(n, "name"), "robots") {
+					directives := strings.ToLower(attr(n, "content"))
+					result.noindex = strings.Contains(directives, "noindex") || strings.Contains(directives, "none")
+					result.nofollow = strings.Contains(directives, "nofollow") || strings.Contains(directives, "none")
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			scan(child)
+		}
+	}
+	scan(doc)
+
+	result.title = collapseSpaces(title)
+	if result.title == "" {
+		result.title = collapseSpaces(firstHeading)
+	}
+
+	e := &extractor{}
+	e.walk(mainContent(doc))
+	e.flush()
+	result.content = e.render()
+	return result
+}
+
+// mainContent picks the element holding the main content: <main>, role="main", the
+// first <article> or else <body>
+func mainContent(doc *html.Node) *html.Node {
+	var body, article, main *html.Node
+	var find func(n *html.Node)
+	find = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch {
+			case n.DataAtom == atom.Main || attr(n, "role") == "main":
+				if main == nil {
+					main = n
+				}
+			case n.DataAtom == atom.Article:
+				if article == nil {
+					article = n
+				}
+			case n.DataAtom == atom.Body:
+				body = n
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			find(child)
+		}
+	}
+	find(doc)
+
+	switch {
+	case main != nil:
+		return main
+	case article != nil:
+		return article
+	case body != nil:
+		return body
+	}
+	return doc
+}
+
+func (e *extractor) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		e.current.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			e.walk(child)
+		}
+		return
+	}
+
+	if skippedElements[n.DataAtom] || hidden(n) {
+		return
+	}
+
+	switch {
+	case n.DataAtom == atom.Br:
+		e.current.WriteString("\n")
+		return
+	case n.DataAtom == atom.Table:
+		e.flush()
+		if text := renderTable(tableRows(n)); text != "" {
+			e.blocks = append(e.blocks, text)
+			e.list = append(e.list, false)
+		}
+		return
+	case headingLevels[n.DataAtom] > 0:
+		e.startBlock(strings.Repeat("#", headingLevels[n.DataAtom])+" ", false)
+		e.walkChildren(n)
+		e.flush()
+		return
+	case n.DataAtom == atom.Ul || n.DataAtom == atom.Ol:
+		e.flush()
+		counter := -1
+		if n.DataAtom == atom.Ol {
+			counter = 0
+		}
+		e.counters = append(e.counters, counter)
+		e.walkChildren(n)
+		e.flush()
+		e.counters = e.counters[:len(e.counters)-1]
+		return
+	case n.DataAtom == atom.Li:
+		marker := "- "
+		depth := max(len(e.counters)-1, 0)
+		if len(e.counters) > 0 && e.counters[len(e.counters)-1] >= 0 {
+			e.counters[len(e.counters)-1]++
+			marker = strconv.Itoa(e.counters[len(e.counters)-1]) + ". "
+		}
+		e.startBlock(strings.Repeat("  ", depth)+marker, true)
+		e.walkChildren(n)
+		e.flush()
+		return
+	case blockElements[n.DataAtom]:
+		e.flush()
+		e.walkChildren(n)
+		e.flush()
+		return
+	}
+
+	e.walkChildren(n)
+}
+
+func (e *extractor) walkChildren(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		e.walk(child)
+	}
+}
+
+// startBlock closes the pending text and opens a block written with prefix
+func (e *extractor) startBlock(prefix string, isItem bool) {
+	e.flush()
+	e.prefix = prefix
+	e.isItem = isItem
+}
+
+// flush closes the pending text as a block. The prefix of an open heading or list item
+// waits for its first text (e.g. <li><p>text</p></li>).
+func (e *extractor) flush() {
+	text := normalizeText(e.current.String())
+	e.current.Reset()
+	if text == "" {
+		return
+	}
+	if e.prefix != "" {
+		text = e.prefix + strings.ReplaceAll(text, "\n", " ")
+	}
+	e.blocks = append(e.blocks, text)
+	e.list = append(e.list, e.isItem)
+	e.prefix = ""
+	e.isItem = false
+}
+
+// render joins the blocks: list items on consecutive lines, other blocks separated by a blank line
+func (e *extractor) render() string {
+	var b strings.Builder
+	for i, block := range e.blocks {
+		if i > 0 {
+			if e.list[i] && e.list[i-1] {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+		b.WriteString(block)
+	}
+	return b.String()
+}
+
+// tableRows returns the text of the cells of a table, without nested tables' structure
+func tableRows(tableNode *html.Node) [][]string {
+	var rows [][]string
+	var find func(n *html.Node)
+	find = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Tr:
+				var row []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						row = append(row, collapseSpaces(textContent(cell)))
+					}
+				}
+				rows = append(rows, row)
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				find(child)
+			}
+		}
+	}
+	find(tableNode)
+	return rows
+}
+
+// renderTable writes a table as "Header: value; Header: value" rows when its first row is
+// a header, otherwise with the cells joined by " | "
+func renderTable(rows [][]string) string {
+	filtered := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		for _, cell := range row {
+			if cell != "" {
+				filtered = append(filtered, row)
+				break
+			}
+		}
+	}
+	if len(filtered) == 0 {
+		return ""
+	}
+
+	header := filtered[0]
+	hasHeader := len(filtered) > 1 && len(header) > 1
+	for _, cell := range header {
+		if cell == "" {
+			hasHeader = false
+		}
+	}
+
+	lines := make([]string, 0, len(filtered))
+	if !hasHeader {
+		for _, row := range filtered {
+			lines = append(lines, joinNonEmpty(row, " | "))
+		}
+		return strings.Join(lines, "\n")
+	}
+	for _, row := range filtered[1:] {
+		parts := make([]string, 0, len(row))
+		for i, cell := range row {
+			if cell == "" {
+				continue
+			}
+			if i < len(header) {
+				parts = append(parts, header[i]+": "+cell)
+			} else {
+				parts = append(parts, cell)
+			}
+		}
+		lines = append(lines, strings.Join(parts, "; "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func joinNonEmpty(cells []string, separator string) string {
+	parts := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		if cell != "" {
+			parts = append(parts, cell)
+		}
+	}
+	return strings.Join(parts, separator)
+}
+
+// hidden reports elements not shown to readers
+func hidden(n *html.Node) bool {
+	for _, a := range n.Attr {
+		switch a.Key {
+		case "hidden":
+			return true
+		case "aria-hidden":
+			if a.Val == "true" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteString(" ")
+			return
+		}
+		if n.Type == html.ElementNode && (n.DataAtom == atom.Script || n.DataAtom == atom.Style) {
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(n)
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func collapseSpaces(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// normalizeText collapses runs of spaces and trims every line, dropping blank lines
+func normalizeText(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = collapseSpaces(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
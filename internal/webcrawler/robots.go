@@ -0,0 +1,187 @@
+package webcrawler
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsRule is an Allow or Disallow line of robots.txt
+type robotsRule struct {
+	pattern string
+	regex   *regexp.Regexp
+	allow   bool
+}
+
+// robotsRules are the rules of the robots.txt group that applies to the crawler
+type robotsRules struct {
+	rules       []robotsRule
+	crawlDelay  time.Duration
+	disallowAll bool
+}
+
+// allowed applies the most specific (longest) matching rule; Allow wins ties.
+// Paths without a matching rule are allowed.
+func (r *robotsRules) allowed(u *url.URL) bool {
+	if r == nil {
+		return true
+	}
+	if r.disallowAll {
+		return false
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	best := -1
+	allow := true
+	for _, rule := range r.rules {
+		if !rule.regex.MatchString(path) {
+			continue
+		}
+		if len(rule.pattern) > best || (len(rule.pattern) == best && rule.allow) {
+			best = len(rule.pattern)
+			allow = rule.allow
+		}
+	}
+	return allow
+}
+
+// compileRobotsPattern turns a robots.txt path pattern into a regexp: a prefix match
+// where "*" matches any sequence and a trailing "$" anchors the end of the path
+func compileRobotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// parseRobots reads the group of robots.txt that applies to userAgent: the group naming the
+// longest token contained in the user agent, else the "*" group
+func parseRobots(body io.Reader, userAgent string) *robotsRules {
+	agent := strings.ToLower(userAgent)
+
+	type group struct {
+		agents []string
+		rules  robotsRules
+	}
+	var groups []*group
+	var current *group
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if index := strings.Index(line, "#"); index >= 0 {
+			line = line[:index]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || !lastWasAgent {
+				current = &group{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+		case "allow", "disallow":
+			lastWasAgent = false
+			// An empty Disallow allows everything: it adds no rule
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules.rules = append(current.rules.rules, robotsRule{pattern: value, regex: compileRobotsPattern(value), allow: key == "allow"})
+		case "crawl-delay":
+			lastWasAgent = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.rules.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		default:
+			lastWasAgent = false
+		}
+	}
+
+	var selected, wildcard *group
+	bestLength := 0
+	for _, g := range groups {
+		for _, name := range g.agents {
+			if name == "*" {
+				if wildcard == nil {
+					wildcard = g
+				}
+				continue
+			}
+			if name != "" && strings.Contains(agent, name) && len(name) > bestLength {
+				selected = g
+				bestLength = len(name)
+			}
+		}
+	}
+	if selected == nil {
+		selected = wildcard
+	}
+	if selected == nil {
+		return &robotsRules{}
+	}
+	return &selected.rules
+}
+
+// robots returns the rules of the host of u, fetching its robots.txt once per crawl.
+// A missing robots.txt (4xx) allows everything; a server error disallows the host.
+func (c *Crawler) robots(ctx context.Context, u *url.URL, opts Options, cache map[string]*robotsRules) *robotsRules {
+	host := u.Scheme + "://" + u.Host
+	if rules, exists := cache[host]; exists {
+		return rules
+	}
+
+	rules := &robotsRules{}
+	requestCtx, cancel := context.WithTimeout(ctx, opts.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(requestCtx, http.MethodGet, host+"/robots.txt", nil)
+	if err == nil {
+		req.Header.Set("User-Agent", opts.UserAgent)
+		resp, err := c.client.Do(req)
+		switch {
+		case err != nil:
+			rules.disallowAll = true
+		case resp.StatusCode >= 500:
+			resp.Body.Close()
+			rules.disallowAll = true
+		case resp.StatusCode == http.StatusOK:
+			rules = parseRobots(io.LimitReader(resp.Body, 512*1024), opts.UserAgent)
+			resp.Body.Close()
+		default:
+			resp.Body.Close()
+		}
+	}
+
+	cache[host] = rules
+	return rules
+}
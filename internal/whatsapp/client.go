@@ -3,6 +3,11 @@ package whatsapp
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
 
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waE2E"
@@ -11,6 +16,9 @@ import (
 	waLog "go.mau.fi/whatsmeow/util/log"
 )
 
+// maxMediaSize caps the media downloaded to be sent (WhatsApp rejects larger documents anyway)
+const maxMediaSize = 100 << 20
+
 // Client wraps whatsmeow client for WhatsApp integration
 type Client struct {
 	WAClient    *whatsmeow.Client
@@ -104,26 +112,33 @@ func (c *Client) SendTextMessage(chatJID types.JID, text string) (types.MessageI
 
 // SendText sends a text message to a chat using string chatID
 func (c *Client) SendText(chatID, text string) error {
+	_, err := c.SendTextWithID(chatID, text)
+	return err
+}
+
+// SendTextWithID sends a text message to a chat using string chatID and returns the
+// WhatsApp message ID (reactions to the message refer to it)
+func (c *Client) SendTextWithID(chatID, text string) (string, error) {
 	if !c.IsConnected() {
-		return fmt.Errorf("not connected to WhatsApp")
+		return "", fmt.Errorf("not connected to WhatsApp")
 	}
 
 	// Parse chatID string to JID
 	jid, err := types.ParseJID(chatID)
 	if err != nil {
-		return fmt.Errorf("invalid chat ID: %w", err)
+		return "", fmt.Errorf("invalid chat ID: %w", err)
 	}
 
 	msg := &waE2E.Message{
 		Conversation: &text,
 	}
 
-	_, err = c.WAClient.SendMessage(context.Background(), jid, msg)
+	resp, err := c.WAClient.SendMessage(context.Background(), jid, msg)
 	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+		return "", fmt.Errorf("failed to send message: %w", err)
 	}
 
-	return nil
+	return resp.ID, nil
 }
 
 // SendChatPresence sends a chat presence (typing indicator)
@@ -179,6 +194,100 @@ func (c *Client) SendSticker(chatID, stickerURL string) error {
 	return nil
 }
 
+// SendMedia downloads an image or document from a URL, uploads it to WhatsApp
+// and sends it to a chat using string chatID
+func (c *Client) SendMedia(chatID, mediaURL, mediaType, caption string) error {
+	if !c.IsConnected() {
+		return fmt.Errorf("not connected to WhatsApp")
+	}
+
+	jid, err := types.ParseJID(chatID)
+	if err != nil {
+		return fmt.Errorf("invalid chat ID: %w", err)
+	}
+
+	data, mimeType, err := downloadMedia(mediaURL)
+	if err != nil {
+		return err
+	}
+
+	appInfo := whatsmeow.MediaDocument
+	if mediaType == "image" {
+		appInfo = whatsmeow.MediaImage
+	}
+	uploaded, err := c.WAClient.Upload(context.Background(), data, appInfo)
+	if err != nil {
+		return fmt.Errorf("failed to upload media: %w", err)
+	}
+
+	var msg *waE2E.Message
+	if mediaType == "image" {
+		msg = &waE2E.Message{
+			ImageMessage: &waE2E.ImageMessage{
+				Caption:       stringPtr(caption),
+				Mimetype:      stringPtr(mimeType),
+				URL:           &uploaded.URL,
+				DirectPath:    &uploaded.DirectPath,
+				MediaKey:      uploaded.MediaKey,
+				FileEncSHA256: uploaded.FileEncSHA256,
+				FileSHA256:    uploaded.FileSHA256,
+				FileLength:    uint64Ptr(uploaded.FileLength),
+			},
+		}
+	} else {
+		fileName := path.Base(strings.SplitN(mediaURL, "?", 2)[0])
+		msg = &waE2E.Message{
+			DocumentMessage: &waE2E.DocumentMessage{
+				Caption:       stringPtr(caption),
+				Title:         stringPtr(fileName),
+				FileName:      stringPtr(fileName),
+				Mimetype:      stringPtr(mimeType),
+				URL:           &uploaded.URL,
+				DirectPath:    &uploaded.DirectPath,
+				MediaKey:      uploaded.MediaKey,
+				FileEncSHA256: uploaded.FileEncSHA256,
+				FileSHA256:    uploaded.FileSHA256,
+				FileLength:    uint64Ptr(uploaded.FileLength),
+			},
+		}
+	}
+
+	_, err = c.WAClient.SendMessage(context.Background(), jid, msg)
+	if err != nil {
+		return fmt.Errorf("failed to send media: %w", err)
+	}
+
+	return nil
+}
+
+// downloadMedia fetches a media file, returning its content and MIME type
+func downloadMedia(mediaURL string) ([]byte, string, error) {
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	resp, err := httpClient.Get(mediaURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download media: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to download media: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMediaSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read media: %w", err)
+	}
+	if len(data) > maxMediaSize {
+		return nil, "", fmt.Errorf("media larger than %d bytes", maxMediaSize)
+	}
+
+	mimeType := resp.Header.Get("Content-Type")
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = http.DetectContentType(data)
+	}
+	return data, mimeType, nil
+}
+
 // Helper functions for protobuf pointers
 func stringPtr(s string) *string {
 	return &s
@@ -50,7 +50,9 @@ func (d *MessageDispatcher) Dispatch(ctx context.Context, msg *domain.IncomingMe
 	// Check if chatbot is active (hot-reloadable via parameter cache)
 	if !d.isChatbotActive() {
 		slog.Info("Chatbot is deactivated, skipping message processing", "messageID", msg.MessageID)
-		d.sendDeactivatedMessage(msg.ChatID)
+		if msg.MessageType != "reaction" {
+			d.sendDeactivatedMessage(msg.ChatID)
+		}
 		return nil
 	}
 
@@ -20,7 +20,9 @@ type CommandHandler struct {
 
 type WhatsAppClient interface {
 	SendText(chatID, message string) error
+	SendTextWithID(chatID, message string) (string, error)
 	SendChatPresence(chatID string, state types.ChatPresence, media types.ChatPresenceMedia) error
+	SendMedia(chatID, mediaURL, mediaType, caption string) error
 }
 
 func NewCommandHandler(
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+
+	"api-chatbot/domain"
+)
+
+// FeedbackHandler records reactions to bot answers as answer feedback.
+// It matches every reaction so they never reach the registration or RAG handlers.
+type FeedbackHandler struct {
+	feedbackUseCase domain.FeedbackUseCase
+	priority        int
+}
+
+func NewFeedbackHandler(
+	feedbackUseCase domain.FeedbackUseCase,
+	priority int,
+) *FeedbackHandler {
+	return &FeedbackHandler{
+		feedbackUseCase: feedbackUseCase,
+		priority:        priority,
+	}
+}
+
+func (h *FeedbackHandler) Match(ctx context.Context, msg *domain.IncomingMessage) bool {
+	return msg.MessageType == "reaction" && !msg.FromMe && !msg.IsGroup
+}
+
+func (h *FeedbackHandler) Handle(ctx context.Context, msg *domain.IncomingMessage) error {
+	rating, ok := h.feedbackUseCase.ReactionRating(msg.Body)
+	if !ok || msg.ReactionTo == "" {
+		slog.Debug("Reaction ignored", "reaction", msg.Body, "chatID", msg.ChatID)
+		return nil
+	}
+
+	// Reactions are silent: no reply is sent, whatever the outcome
+	chatID := msg.ChatID
+	result := h.feedbackUseCase.SetMessageFeedback(ctx, domain.SetMessageFeedbackParams{
+		MessageID: msg.ReactionTo,
+		Rating:    rating,
+		Source:    domain.FeedbackSourceWhatsApp,
+		ChatID:    &chatID,
+	})
+	if !result.Success {
+		slog.Debug("Reaction not recorded as feedback",
+			"reactionTo", msg.ReactionTo,
+			"code", result.Code,
+		)
+	}
+
+	return nil
+}
+
+func (h *FeedbackHandler) Priority() int {
+	return h.priority
+}
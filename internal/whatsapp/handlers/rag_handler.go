@@ -9,15 +9,21 @@ import (
 	"go.mau.fi/whatsmeow/types"
 
 	"api-chatbot/domain"
+	"api-chatbot/internal/guardrails"
+	"api-chatbot/internal/handoff"
 	"api-chatbot/internal/llm"
 	"api-chatbot/internal/logger"
 )
 
 type RAGHandler struct {
 	chunkUseCase domain.ChunkUseCase
+	faqUseCase   domain.FAQUseCase
 	convUseCase  domain.ConversationUseCase
 	userUseCase  domain.WhatsAppUserUseCase
 	llmProvider  llm.Provider
+	guardrails   *guardrails.Pipeline
+	handoff      *handoff.Policy
+	experiments  domain.ExperimentUseCase
 	client       WhatsAppClient
 	paramCache   domain.ParameterCache
 	priority     int
@@ -25,18 +31,26 @@ type RAGHandler struct {
 
 func NewRAGHandler(
 	chunkUseCase domain.ChunkUseCase,
+	faqUseCase domain.FAQUseCase,
 	convUseCase domain.ConversationUseCase,
 	userUseCase domain.WhatsAppUserUseCase,
 	llmProvider llm.Provider,
+	guardrailPipeline *guardrails.Pipeline,
+	handoffPolicy *handoff.Policy,
+	experimentUseCase domain.ExperimentUseCase,
 	client WhatsAppClient,
 	paramCache domain.ParameterCache,
 	priority int,
 ) *RAGHandler {
 	return &RAGHandler{
 		chunkUseCase: chunkUseCase,
+		faqUseCase:   faqUseCase,
 		convUseCase:  convUseCase,
 		userUseCase:  userUseCase,
 		llmProvider:  llmProvider,
+		guardrails:   guardrailPipeline,
+		handoff:      handoffPolicy,
+		experiments:  experimentUseCase,
 		client:       client,
 		paramCache:   paramCache,
 		priority:     priority,
@@ -60,6 +74,7 @@ func (h *RAGHandler) Match(ctx context.Context, msg *domain.IncomingMessage) boo
 }
 
 func (h *RAGHandler) Handle(ctx context.Context, msg *domain.IncomingMessage) error {
+	startTime := time.Now()
 	query := strings.TrimSpace(msg.Body)
 
 	// Check if user is registered and get user info
@@ -141,14 +156,32 @@ func (h *RAGHandler) Handle(ctx context.Context, msg *domain.IncomingMessage) er
 	conversation := convResult.Data
 	timestamp := time.Now().Unix()
 
+	// Input guardrails run before the message is stored and before retrieval
+	guardMeta := guardrails.Meta{Channel: "whatsapp", ChatID: msg.ChatID, ConversationID: conversation.ID}
+	inputCheck := h.guardrails.Run(ctx, guardrails.StageInput, query, guardMeta)
+	query = inputCheck.Text
+
 	storeResult := h.convUseCase.StoreMessage(ctx, conversation.ID, msg.MessageID, false, query, timestamp)
 	if !storeResult.Success {
 		logger.LogWarn(ctx, "Failed to store user message", "error", storeResult.Code)
 	}
 
+	if inputCheck.Stopped() {
+		return h.replyGuardrail(ctx, msg, conversation.ID, query, inputCheck, timestamp, startTime)
+	}
+
 	// Send typing indicator to make it more natural
 	h.sendTypingIndicator(msg.ChatID, true)
 
+	// Curated FAQs are matched before retrieval; one reaching its threshold is answered as written
+	faqResult := h.faqUseCase.Answer(ctx, query, nil)
+	if !faqResult.Success {
+		logger.LogWarn(ctx, "FAQ matching failed, continuing with RAG", "error", faqResult.Code)
+	}
+	if faqResult.Data != nil {
+		return h.answerFAQ(ctx, msg, conversation.ID, faqResult.Data, timestamp, startTime)
+	}
+
 	historyLimit := h.getParamInt("RAG_CONVERSATION_HISTORY_LIMIT", 10)
 	historyResult = h.convUseCase.GetConversationHistory(ctx, msg.ChatID, historyLimit)
 	var conversationHistory []llm.Message
@@ -172,13 +205,40 @@ func (h *RAGHandler) Handle(ctx context.Context, msg *domain.IncomingMessage) er
 	minSimilarity := h.getParamFloat("RAG_MIN_SIMILARITY", 0.2)
 	keywordWeight := h.getParamFloat("RAG_KEYWORD_WEIGHT", 0.15)
 
-	searchResult := h.chunkUseCase.HybridSearch(ctx, query, searchLimit, minSimilarity, keywordWeight)
+	// Running experiment: the chat's variant overrides the retrieval and generation settings
+	variant := h.assignVariant(ctx, msg.ChatID)
+	if variant != nil {
+		if variant.Config.SearchLimit != nil {
+			searchLimit = *variant.Config.SearchLimit
+		}
+		if variant.Config.KeywordWeight != nil {
+			keywordWeight = *variant.Config.KeywordWeight
+		}
+	}
+
+	// Documents are filtered by the user's role; unregistered users search as external
+	// users, like API callers without a role, so documents for ROLE_EXTERNAL reach them too
+	role := "ROLE_EXTERNAL"
+	if isRegistered && userResult.Data.Role != "" {
+		role = userResult.Data.Role
+	}
+
+	// WhatsApp messages carry no document category, so the search uses the "default"
+	// strategy of QUERY_STRATEGY_CONFIG (per-category strategies need a category filter)
+	trace := &domain.RetrievalTrace{}
+	searchResult := h.chunkUseCase.HybridSearch(ctx, query, searchLimit, minSimilarity, keywordWeight, domain.RetrievalOptions{Trace: trace, Role: &role})
 
 	if !searchResult.Success {
 		logger.LogError(ctx, "Hybrid search failed", nil, "error", searchResult.Code)
 		return h.sendMessage(msg.ChatID, h.getParam("RAG_ERROR_MESSAGE", "Lo siento, ocurrió un error al buscar información relevante."))
 	}
 
+	// Confidence policy: too few or too weak hits hand the conversation to an admin instead of answering
+	confidence := h.handoff.CheckRetrieval(searchResult.Data)
+	if confidence.Handoff {
+		return h.handOff(ctx, msg, conversation.ID, query, confidence, timestamp, startTime, variant, trace)
+	}
+
 	var answer string
 	var err error
 
@@ -187,7 +247,7 @@ func (h *RAGHandler) Handle(ctx context.Context, msg *domain.IncomingMessage) er
 	if len(searchResult.Data) == 0 {
 		// No results found - include contact information in context
 		contactInfo := h.getContactInformation()
-		llmResponse, err = h.generateLLMResponse(ctx, query, contactInfo, conversationHistory, userName)
+		llmResponse, err = h.generateLLMResponse(ctx, query, contactInfo, conversationHistory, userName, variant)
 		if err != nil {
 			h.sendTypingIndicator(msg.ChatID, false) // Stop typing
 			noResultsMsg := h.getParam("RAG_NO_RESULTS_MESSAGE", "Lo siento, no encontré información relevante sobre tu consulta.")
@@ -200,21 +260,76 @@ func (h *RAGHandler) Handle(ctx context.Context, msg *domain.IncomingMessage) er
 		answer = llmResponse.Content
 	} else {
 		contextStr := h.buildHybridContext(searchResult.Data)
-		llmResponse, err = h.generateLLMResponse(ctx, query, contextStr, conversationHistory, userName)
+		llmResponse, err = h.generateLLMResponse(ctx, query, contextStr, conversationHistory, userName, variant)
 		if err != nil {
 			logger.LogError(ctx, "LLM generation failed", err)
 			answer = h.generateSimpleAnswer(searchResult.Data)
 		} else {
 			answer = llmResponse.Content
+
+			// Optional LLM self-check: an answer not supported by its context is handed off too
+			check, checkErr := h.handoff.CheckAnswer(ctx, query, contextStr, answer)
+			if checkErr != nil {
+				logger.LogWarn(ctx, "Answer self-check failed, keeping the answer", "error", checkErr.Error())
+			}
+			if check.Handoff {
+				check.BestScore, check.Hits = confidence.BestScore, confidence.Hits
+				return h.handOff(ctx, msg, conversation.ID, query, check, timestamp, startTime, variant, trace)
+			}
 		}
 	}
 
-	h.storeAssistantMessage(ctx, conversation.ID, answer, timestamp+2, llmResponse)
+	// Output guardrails run on the generated answer before it is stored and sent
+	outputCheck := h.guardrails.Run(guardrails.WithSystemPrompt(ctx, h.systemPrompt(variant)), guardrails.StageOutput, answer, guardMeta)
+	if outputCheck.Stopped() {
+		answer = h.getGuardrailMessage(outputCheck.Action)
+	} else {
+		answer = outputCheck.Text
+	}
+
+	metadata := domain.Data{
+		"ragChunks":      len(searchResult.Data),
+		"responseTimeMs": time.Since(startTime).Milliseconds(),
+	}
+	// Results are in rerank order when reranking is enabled, so look for the best similarity
+	var bestSimilarity float64
+	var rerankScores []float64
+	for i, chunk := range searchResult.Data {
+		if i == 0 || chunk.CombinedScore > bestSimilarity {
+			bestSimilarity = chunk.CombinedScore
+		}
+		if chunk.RerankScore != nil {
+			rerankScores = append(rerankScores, *chunk.RerankScore)
+		}
+	}
+	if len(searchResult.Data) > 0 {
+		metadata["ragBestSimilarity"] = bestSimilarity
+	}
+	if outputCheck.Stopped() {
+		metadata["guardrail"] = string(outputCheck.Action)
+		if rule := guardrailRule(outputCheck); rule != "" {
+			metadata["guardrailRule"] = rule
+		}
+	}
+	if len(rerankScores) > 0 {
+		metadata["ragRerankScores"] = rerankScores
+	}
+	if len(searchResult.Data) > 0 && searchResult.Data[0].QueryStrategy != "" {
+		metadata["ragQueryStrategy"] = searchResult.Data[0].QueryStrategy
+	}
 
 	// Stop typing indicator before sending response
 	h.sendTypingIndicator(msg.ChatID, false)
 
-	return h.sendMessage(msg.ChatID, answer)
+	// The answer is stored under its WhatsApp ID so reactions to it can be recorded as feedback
+	sentID, sendErr := h.client.SendTextWithID(msg.ChatID, answer)
+	h.storeAssistantMessage(ctx, conversation.ID, sentID, answer, timestamp+2, llmResponse, variant, metadata, searchResult.Data, trace)
+
+	if outputCheck.Action == guardrails.ActionEscalate {
+		h.escalate(ctx, msg, conversation.ID, query, outputCheck)
+	}
+
+	return sendErr
 }
 
 func (h *RAGHandler) Priority() int {
@@ -233,12 +348,12 @@ func (h *RAGHandler) buildHybridContext(chunks []domain.ChunkWithHybridSimilarit
 	return builder.String()
 }
 
-func (h *RAGHandler) generateLLMResponse(ctx context.Context, query, ragContext string, conversationHistory []llm.Message, userName string) (*llm.GenerateResponse, error) {
+func (h *RAGHandler) generateLLMResponse(ctx context.Context, query, ragContext string, conversationHistory []llm.Message, userName string, variant *domain.ExperimentVariant) (*llm.GenerateResponse, error) {
 	if h.llmProvider == nil || !h.llmProvider.IsAvailable() {
 		return nil, fmt.Errorf("LLM provider not available")
 	}
 
-	systemPrompt := h.getParam("RAG_SYSTEM_PROMPT", "Eres un asistente virtual del instituto educativo.")
+	systemPrompt := h.systemPrompt(variant)
 
 	// Add user name to system prompt if available
 	if userName != "" {
@@ -253,10 +368,17 @@ func (h *RAGHandler) generateLLMResponse(ctx context.Context, query, ragContext
 		UserMessage:         query,
 		Context:             ragContext,
 		ConversationHistory: conversationHistory,
-		Temperature:         temperature,
+		Temperature:         &temperature,
 		MaxTokens:           maxTokens,
 	}
 
+	if variant != nil {
+		if variant.Config.Temperature != nil {
+			request.Temperature = variant.Config.Temperature
+		}
+		request.Model = variant.Config.Model
+	}
+
 	response, err := h.llmProvider.GenerateResponse(ctx, request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate LLM response: %w", err)
@@ -265,31 +387,70 @@ func (h *RAGHandler) generateLLMResponse(ctx context.Context, query, ragContext
 	return response, nil
 }
 
-func (h *RAGHandler) storeAssistantMessage(ctx context.Context, conversationID int, message string, timestamp int64, llmResponse *llm.GenerateResponse) {
-	if llmResponse == nil {
-		result := h.convUseCase.StoreMessage(ctx, conversationID, fmt.Sprintf("assistant_%d", timestamp), true, message, timestamp)
-		if !result.Success {
-			logger.LogWarn(ctx, "Failed to store assistant message", "error", result.Code)
+// systemPrompt returns the variant system prompt, or RAG_SYSTEM_PROMPT
+func (h *RAGHandler) systemPrompt(variant *domain.ExperimentVariant) string {
+	if variant != nil {
+		if variantPrompt := variant.Config.ResolveSystemPrompt(h.paramCache); variantPrompt != "" {
+			return variantPrompt
 		}
-		return
+	}
+	return h.getParam("RAG_SYSTEM_PROMPT", "Eres un asistente virtual del instituto educativo.")
+}
+
+func (h *RAGHandler) storeAssistantMessage(ctx context.Context, conversationID int, messageID, message string, timestamp int64, llmResponse *llm.GenerateResponse, variant *domain.ExperimentVariant, metadata domain.Data, chunks []domain.ChunkWithHybridSimilarity, trace *domain.RetrievalTrace) {
+	if messageID == "" {
+		messageID = fmt.Sprintf("assistant_%d", timestamp)
 	}
 
 	params := domain.CreateConversationMessageParams{
-		ConversationID:   conversationID,
-		MessageID:        fmt.Sprintf("assistant_%d", timestamp),
-		FromMe:           true,
-		SenderType:       "bot",
-		MessageType:      "text",
-		Body:             &message,
-		Timestamp:        timestamp,
-		IsForwarded:      false,
-		QueueTimeMs:      llmResponse.QueueTimeMs,
-		PromptTokens:     llmResponse.PromptTokens,
-		PromptTimeMs:     llmResponse.PromptTimeMs,
-		CompletionTokens: llmResponse.CompletionTokens,
-		CompletionTimeMs: llmResponse.CompletionTimeMs,
-		TotalTokens:      llmResponse.TotalTokens,
-		TotalTimeMs:      llmResponse.TotalTimeMs,
+		ConversationID: conversationID,
+		MessageID:      messageID,
+		FromMe:         true,
+		SenderType:     "bot",
+		MessageType:    "text",
+		Body:           &message,
+		Timestamp:      timestamp,
+		IsForwarded:    false,
+		Metadata:       metadata,
+	}
+
+	// Chunks the answer was built from, in retrieval order, so feedback can be attributed to them
+	for _, chunk := range chunks {
+		params.ChunkIDs = append(params.ChunkIDs, chunk.ID)
+		params.ChunkScores = append(params.ChunkScores, chunk.CombinedScore)
+	}
+
+	// The search filled the query and candidates; record what was sent to the LLM
+	if trace != nil {
+		trace.ContextChunkIDs = params.ChunkIDs
+		trace.PromptCode = "RAG_SYSTEM_PROMPT"
+		if variant != nil && (variant.Config.PromptCode != "" || variant.Config.SystemPrompt != "") {
+			trace.PromptCode = variant.Config.PromptCode
+		}
+		if variant != nil {
+			trace.PromptVersion = variant.Config.PromptVersion
+		}
+		if llmResponse != nil {
+			trace.Model = llmResponse.Model
+		}
+		params.RetrievalTrace = trace
+	}
+
+	if llmResponse != nil {
+		params.QueueTimeMs = llmResponse.QueueTimeMs
+		params.PromptTokens = llmResponse.PromptTokens
+		params.PromptTimeMs = llmResponse.PromptTimeMs
+		params.CompletionTokens = llmResponse.CompletionTokens
+		params.CompletionTimeMs = llmResponse.CompletionTimeMs
+		params.TotalTokens = llmResponse.TotalTokens
+		params.TotalTimeMs = llmResponse.TotalTimeMs
+	}
+
+	if variant != nil {
+		params.VariantID = &variant.ID
+		for key, value := range variant.MessageMetadata() {
+			params.Metadata[key] = value
+		}
 	}
 
 	result := h.convUseCase.StoreMessageWithStats(ctx, params)
@@ -298,6 +459,149 @@ func (h *RAGHandler) storeAssistantMessage(ctx context.Context, conversationID i
 	}
 }
 
+// answerFAQ replies with the approved answer of an FAQ and its media, if any
+func (h *RAGHandler) answerFAQ(ctx context.Context, msg *domain.IncomingMessage, conversationID int, faq *domain.FAQMatch, timestamp int64, startTime time.Time) error {
+	h.sendTypingIndicator(msg.ChatID, false)
+
+	sentID, sendErr := h.client.SendTextWithID(msg.ChatID, faq.Answer)
+	if sendErr == nil && faq.MediaURL != nil && faq.MediaType != nil {
+		if err := h.client.SendMedia(msg.ChatID, *faq.MediaURL, *faq.MediaType, ""); err != nil {
+			logger.LogWarn(ctx, "Failed to send FAQ media",
+				"faqID", faq.FAQID,
+				"error", err.Error(),
+			)
+		}
+	}
+
+	metadata := domain.Data{
+		"faqId":          faq.FAQID,
+		"faqScore":       faq.Score,
+		"responseTimeMs": time.Since(startTime).Milliseconds(),
+	}
+	h.storeAssistantMessage(ctx, conversationID, sentID, faq.Answer, timestamp+2, nil, nil, metadata, nil, nil)
+
+	return sendErr
+}
+
+// handOff replies with the handoff message, flags the conversation as needing an admin
+// and notifies the admins on duty
+func (h *RAGHandler) handOff(ctx context.Context, msg *domain.IncomingMessage, conversationID int, query string, decision handoff.Decision, timestamp int64, startTime time.Time, variant *domain.ExperimentVariant, trace *domain.RetrievalTrace) error {
+	logger.LogInfo(ctx, "Low confidence, handing the conversation off to an admin",
+		"chatID", msg.ChatID,
+		"reason", decision.Reason,
+		"bestScore", decision.BestScore,
+		"hits", decision.Hits,
+	)
+
+	h.sendTypingIndicator(msg.ChatID, false)
+
+	message := h.getParam("HANDOFF_MESSAGE",
+		"🙋 No tengo información suficiente para responderte con certeza. Derivé tu consulta a una persona del equipo, quien te responderá lo antes posible.")
+	sentID, sendErr := h.client.SendTextWithID(msg.ChatID, message)
+
+	metadata := domain.Data{
+		"ragChunks":      decision.Hits,
+		"handoff":        decision.Reason,
+		"responseTimeMs": time.Since(startTime).Milliseconds(),
+	}
+	if decision.Hits > 0 {
+		metadata["ragBestSimilarity"] = decision.BestScore
+	}
+	// No chunks are linked: feedback on the handoff message says nothing about them
+	h.storeAssistantMessage(ctx, conversationID, sentID, message, timestamp+2, nil, variant, metadata, nil, trace)
+
+	result := h.convUseCase.RequestHandoff(ctx, domain.RequestHandoffParams{
+		ConversationID: conversationID,
+		Reason:         decision.Reason,
+	})
+	if !result.Success {
+		logger.LogWarn(ctx, "Failed to flag conversation for an admin", "error", result.Code)
+	}
+
+	h.notifyOnDuty(ctx, msg, query, decision.Reason)
+
+	return sendErr
+}
+
+// replyGuardrail replies to a message stopped by an input guardrail and stores the reply,
+// escalating it to an admin when the guardrail asks for it
+func (h *RAGHandler) replyGuardrail(ctx context.Context, msg *domain.IncomingMessage, conversationID int, query string, outcome guardrails.Outcome, timestamp int64, startTime time.Time) error {
+	h.sendTypingIndicator(msg.ChatID, false)
+
+	message := h.getGuardrailMessage(outcome.Action)
+	sentID, sendErr := h.client.SendTextWithID(msg.ChatID, message)
+
+	metadata := domain.Data{
+		"guardrail":      string(outcome.Action),
+		"responseTimeMs": time.Since(startTime).Milliseconds(),
+	}
+	if rule := guardrailRule(outcome); rule != "" {
+		metadata["guardrailRule"] = rule
+	}
+	h.storeAssistantMessage(ctx, conversationID, sentID, message, timestamp+2, nil, nil, metadata, nil, nil)
+
+	if outcome.Action == guardrails.ActionEscalate {
+		h.escalate(ctx, msg, conversationID, query, outcome)
+	}
+
+	return sendErr
+}
+
+// escalate flags the conversation as needing an admin and notifies the admins on duty
+func (h *RAGHandler) escalate(ctx context.Context, msg *domain.IncomingMessage, conversationID int, query string, outcome guardrails.Outcome) {
+	result := h.convUseCase.RequestHandoff(ctx, domain.RequestHandoffParams{
+		ConversationID: conversationID,
+		Reason:         handoff.ReasonGuardrail,
+	})
+	if !result.Success {
+		logger.LogWarn(ctx, "Failed to flag conversation for an admin", "error", result.Code)
+	}
+
+	reason := handoff.ReasonGuardrail
+	if rule := guardrailRule(outcome); rule != "" {
+		reason = fmt.Sprintf("%s (%s)", reason, rule)
+	}
+	h.notifyOnDuty(ctx, msg, query, reason)
+}
+
+// guardrailRule names the rule, or else the check, that stopped a text
+func guardrailRule(outcome guardrails.Outcome) string {
+	if len(outcome.Verdicts) == 0 {
+		return ""
+	}
+	last := outcome.Verdicts[len(outcome.Verdicts)-1]
+	if last.Rule != "" {
+		return last.Rule
+	}
+	return last.Check
+}
+
+// notifyOnDuty sends the handed-off question to the admins on duty (HANDOFF_CONFIG.onDuty)
+func (h *RAGHandler) notifyOnDuty(ctx context.Context, msg *domain.IncomingMessage, query, reason string) {
+	contacts := h.handoff.OnDuty(time.Now())
+	if len(contacts) == 0 {
+		return
+	}
+
+	contact := msg.From
+	if msg.SenderName != "" {
+		contact = fmt.Sprintf("%s (%s)", msg.SenderName, msg.From)
+	}
+	template := h.getParam("HANDOFF_ADMIN_NOTIFICATION",
+		"🔔 *Conversación derivada*\n\nContacto: %s\nMotivo: %s\nConsulta: %s\n\nRespóndela desde el panel de conversaciones.")
+	notification := fmt.Sprintf(template, contact, reason, query)
+
+	for _, admin := range contacts {
+		jid := types.NewJID(admin.WhatsApp, types.DefaultUserServer).String()
+		if err := h.client.SendText(jid, notification); err != nil {
+			logger.LogWarn(ctx, "Failed to notify admin on duty",
+				"admin", admin.Name,
+				"error", err.Error(),
+			)
+		}
+	}
+}
+
 func (h *RAGHandler) generateSimpleAnswer(chunks []domain.ChunkWithHybridSimilarity) string {
 	if len(chunks) == 0 {
 		return h.getParam("RAG_NO_RELEVANT_INFO", "No encontré información relevante.")
@@ -319,6 +623,22 @@ func (h *RAGHandler) generateSimpleAnswer(chunks []domain.ChunkWithHybridSimilar
 	return answer
 }
 
+// assignVariant returns the experiment variant for the chat, or nil when no experiment is running
+func (h *RAGHandler) assignVariant(ctx context.Context, chatID string) *domain.ExperimentVariant {
+	if h.experiments == nil {
+		return nil
+	}
+	return h.experiments.AssignVariant(ctx, chatID)
+}
+
+// getGuardrailMessage returns the reply sent when a guardrail blocks or escalates
+func (h *RAGHandler) getGuardrailMessage(action guardrails.Action) string {
+	if action == guardrails.ActionEscalate {
+		return h.getParam("GUARDRAILS_ESCALATED_MESSAGE", "🙋 Tu mensaje fue derivado a una persona del equipo, quien te responderá lo antes posible.")
+	}
+	return h.getParam("GUARDRAILS_BLOCKED_MESSAGE", "⚠️ No puedo ayudarte con esa solicitud.")
+}
+
 func (h *RAGHandler) sendMessage(chatID, message string) error {
 	return h.client.SendText(chatID, message)
 }
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"api-chatbot/domain"
+	"api-chatbot/internal/cache"
+	"api-chatbot/internal/handoff"
+	"api-chatbot/internal/llm"
+	"api-chatbot/internal/queryexpansion"
+	"api-chatbot/usecase"
+)
+
+const (
+	testHyDEPrompt         = "Escribe un fragmento de la normativa que responda la consulta."
+	testHypotheticalAnswer = "Las becas por rendimiento académico requieren un promedio mínimo de 9."
+)
+
+// The fakes embed the use case interfaces; only the methods the RAG flow calls are implemented
+
+type fakeUsers struct{ domain.WhatsAppUserUseCase }
+
+func (fakeUsers) GetUserByWhatsApp(ctx context.Context, whatsapp string) domain.Result[*domain.WhatsAppUser] {
+	return domain.Result[*domain.WhatsAppUser]{Success: false, Code: "ERR_USER_NOT_FOUND"}
+}
+
+type fakeConversations struct{ domain.ConversationUseCase }
+
+func (fakeConversations) GetOrCreateConversation(ctx context.Context, chatID, phoneNumber string, contactName *string, isGroup bool, groupName *string) domain.Result[*domain.Conversation] {
+	return domain.Success(&domain.Conversation{ID: 1, ChatID: chatID})
+}
+
+func (fakeConversations) GetConversationHistory(ctx context.Context, chatID string, limit int) domain.Result[[]domain.ConversationMessage] {
+	return domain.Success([]domain.ConversationMessage{})
+}
+
+func (fakeConversations) StoreMessage(ctx context.Context, conversationID int, messageID string, fromMe bool, body string, timestamp int64) domain.Result[domain.Data] {
+	return domain.Success(domain.Data{})
+}
+
+func (fakeConversations) StoreMessageWithStats(ctx context.Context, params domain.CreateConversationMessageParams) domain.Result[domain.Data] {
+	return domain.Success(domain.Data{})
+}
+
+type fakeFAQs struct{ domain.FAQUseCase }
+
+func (fakeFAQs) Answer(ctx context.Context, query string, category *string) domain.Result[*domain.FAQMatch] {
+	return domain.Success[*domain.FAQMatch](nil)
+}
+
+type fakeClient struct{}
+
+func (fakeClient) SendText(chatID, message string) error { return nil }
+func (fakeClient) SendTextWithID(chatID, message string) (string, error) {
+	return "answer-id", nil
+}
+func (fakeClient) SendChatPresence(chatID string, state types.ChatPresence, media types.ChatPresenceMedia) error {
+	return nil
+}
+func (fakeClient) SendMedia(chatID, mediaURL, mediaType, caption string) error { return nil }
+
+// fakeProvider writes the HyDE passage for the HyDE prompt and a plain answer otherwise
+type fakeProvider struct{}
+
+func (fakeProvider) GenerateResponse(ctx context.Context, req llm.GenerateRequest) (*llm.GenerateResponse, error) {
+	if req.SystemPrompt == testHyDEPrompt {
+		return &llm.GenerateResponse{Content: testHypotheticalAnswer}, nil
+	}
+	return &llm.GenerateResponse{Content: "Respuesta", Model: "test-model"}, nil
+}
+func (fakeProvider) GetProviderName() string { return "test" }
+func (fakeProvider) IsAvailable() bool       { return true }
+
+// searchRecorder records the texts embedded and the searches run against the chunk repository
+type searchRecorder struct {
+	domain.ChunkRepository
+	mu       sync.Mutex
+	embedded []string
+	searches []domain.HybridSearchParams
+}
+
+func (r *searchRecorder) HybridSearch(ctx context.Context, params domain.HybridSearchParams) ([]domain.ChunkWithHybridSimilarity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.searches = append(r.searches, params)
+	return nil, nil
+}
+
+func (r *searchRecorder) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.embedded = append(r.embedded, text)
+	return []float32{0.1, 0.2, 0.3}, nil
+}
+
+func (r *searchRecorder) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i], _ = r.GenerateEmbedding(ctx, text)
+	}
+	return embeddings, nil
+}
+
+func (r *searchRecorder) Model() (string, error) { return "test-embedding-model", nil }
+
+// newTestRAGHandler builds the handler on the real chunk use case and query expander
+func newTestRAGHandler(t *testing.T, params map[string]string) (*RAGHandler, *searchRecorder) {
+	t.Helper()
+	paramCache := cache.NewParameterCache()
+	for code, data := range params {
+		paramCache.Set(code, &domain.Parameter{Code: code, Data: json.RawMessage(data)})
+	}
+
+	recorder := &searchRecorder{}
+	provider := fakeProvider{}
+	chunkUseCase := usecase.NewChunkUseCase(recorder, nil, paramCache, recorder, nil, queryexpansion.NewExpander(paramCache, provider), 5*time.Second)
+
+	handler := NewRAGHandler(chunkUseCase, fakeFAQs{}, fakeConversations{}, fakeUsers{}, provider,
+		nil, handoff.NewPolicy(paramCache, provider), nil, fakeClient{}, paramCache, 0)
+	return handler, recorder
+}
+
+func handleText(t *testing.T, handler *RAGHandler, body string) {
+	t.Helper()
+	msg := &domain.IncomingMessage{ChatID: "593999999999@s.whatsapp.net", From: "593999999999", Body: body, MessageID: "msg-1"}
+	if err := handler.Handle(context.Background(), msg); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+}
+
+func TestRAGHandlerAppliesDefaultQueryStrategy(t *testing.T) {
+	handler, recorder := newTestRAGHandler(t, map[string]string{
+		"QUERY_STRATEGY_CONFIG": `{"default": "hyde", "categories": {"DOC_INDTEC": "multi_query"}, "hyde": {"prompt": "` + testHyDEPrompt + `"}}`,
+	})
+
+	handleText(t, handler, "¿Qué necesito para una beca?")
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.embedded) != 1 || recorder.embedded[0] != testHypotheticalAnswer {
+		t.Fatalf("embedded texts = %q, want the HyDE passage", recorder.embedded)
+	}
+	if len(recorder.searches) != 1 || recorder.searches[0].QueryText != "¿Qué necesito para una beca?" {
+		t.Fatalf("searches = %+v, want one search with the question as keyword text", recorder.searches)
+	}
+}
+
+func TestRAGHandlerSearchesAsExternalWhenUnregistered(t *testing.T) {
+	handler, recorder := newTestRAGHandler(t, nil)
+
+	handleText(t, handler, "¿Cuándo empiezan las clases?")
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.searches) != 1 || recorder.searches[0].Role == nil || *recorder.searches[0].Role != "ROLE_EXTERNAL" {
+		t.Fatalf("searches = %+v, want one search as ROLE_EXTERNAL", recorder.searches)
+	}
+}
+
+func TestRAGHandlerWithoutQueryStrategy(t *testing.T) {
+	handler, recorder := newTestRAGHandler(t, map[string]string{
+		"QUERY_STRATEGY_CONFIG": `{"default": "none", "categories": {"DOC_INDTEC": "hyde"}, "hyde": {"prompt": "` + testHyDEPrompt + `"}}`,
+	})
+
+	handleText(t, handler, "¿Qué necesito para una beca?")
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.embedded) != 1 || recorder.embedded[0] != "¿Qué necesito para una beca?" {
+		t.Fatalf("embedded texts = %q, want the question", recorder.embedded)
+	}
+}
@@ -72,3 +72,13 @@ func (m *Manager) Reconnect(ctx context.Context) error {
 	}
 	return m.service.Reconnect(ctx)
 }
+
+// SendText sends a text message through the active service
+func (m *Manager) SendText(chatID, text string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.service == nil || m.service.client == nil {
+		return fmt.Errorf("WhatsApp service not initialized")
+	}
+	return m.service.client.SendText(chatID, text)
+}
@@ -515,6 +515,10 @@ func convertEventToMessage(evt *events.Message) *d.IncomingMessage {
 	} else if aud := evt.Message.GetAudioMessage(); aud != nil {
 		msg.MessageType = "audio"
 		msg.MediaURL = aud.GetURL()
+	} else if reaction := evt.Message.GetReactionMessage(); reaction != nil {
+		msg.MessageType = "reaction"
+		msg.Body = reaction.GetText()
+		msg.ReactionTo = reaction.GetKey().GetID()
 	}
 
 	return msg
@@ -14,11 +14,12 @@ const (
 	fnGetConversationMessages     = "fn_get_conversation_messages"
 
 	// Procedures
-	spBlockUser                = "sp_block_user"
-	spDeleteConversation       = "sp_delete_conversation"
-	spSendAdminMessage         = "sp_send_admin_message"
-	spMarkMessagesAsRead       = "sp_mark_messages_as_read"
-	spSetConversationTemporary = "sp_set_conversation_temporary"
+	spBlockUser                  = "sp_block_user"
+	spDeleteConversation         = "sp_delete_conversation"
+	spSendAdminMessage           = "sp_send_admin_message"
+	spMarkMessagesAsRead         = "sp_mark_messages_as_read"
+	spSetConversationTemporary   = "sp_set_conversation_temporary"
+	spResolveConversationHandoff = "sp_resolve_conversation_handoff"
 )
 
 type adminConversationRepository struct {
@@ -170,3 +171,18 @@ func (r *adminConversationRepository) GetConversationByChatID(ctx context.Contex
 
 	return &conversations[0], nil
 }
+
+// ResolveHandoff clears the needs-admin flag of a conversation
+func (r *adminConversationRepository) ResolveHandoff(ctx context.Context, conversationID int) (*d.ResolveHandoffResult, error) {
+	result, err := dal.ExecProc[d.ResolveHandoffResult](
+		r.dal,
+		ctx,
+		spResolveConversationHandoff,
+		conversationID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spResolveConversationHandoff, err)
+	}
+
+	return result, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"api-chatbot/api/dal"
@@ -14,6 +15,7 @@ const (
 	fnGetChunkByID                 = "fn_get_chunk_by_id"
 	fnSimilaritySearchChunks       = "fn_similarity_search_chunks"
 	fnSimilaritySearchChunksHybrid = "fn_similarity_search_chunks_hybrid"
+	fnGetChunkNeighbors            = "fn_get_chunk_neighbors"
 	// Stored Procedures (Writes)
 	spCreateChunk          = "sp_create_chunk"
 	spUpdateChunkEmbedding = "sp_update_chunk_embedding"
@@ -78,6 +80,15 @@ func (r *chunkRepository) SimilaritySearch(ctx context.Context, params d.Similar
 
 // HybridSearch performs hybrid search combining vector similarity and full-text search
 func (r *chunkRepository) HybridSearch(ctx context.Context, params d.HybridSearchParams) ([]d.ChunkWithHybridSimilarity, error) {
+	var filterJSON []byte
+	if params.Filter != nil {
+		data, err := json.Marshal(params.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal search filter: %w", err)
+		}
+		filterJSON = data
+	}
+
 	chunks, err := dal.QueryRows[d.ChunkWithHybridSimilarity](
 		r.dal,
 		ctx,
@@ -88,6 +99,13 @@ func (r *chunkRepository) HybridSearch(ctx context.Context, params d.HybridSearc
 		params.MinSimilarity,
 		params.KeywordWeight,
 		params.Category, // Pass category filter (can be nil)
+		params.Fusion,
+		params.RRFK,
+		params.WithEmbeddings,
+		filterJSON,
+		params.Role,
+		params.RecencyWeight,
+		params.RecencyHalfLifeDays,
 	)
 
 	if err != nil {
@@ -97,6 +115,17 @@ func (r *chunkRepository) HybridSearch(ctx context.Context, params d.HybridSearc
 	return chunks, nil
 }
 
+// GetNeighbors retrieves the chunks within ±window positions of each hit, ordered by hit and position
+func (r *chunkRepository) GetNeighbors(ctx context.Context, chunkIDs []int, window int) ([]d.ChunkNeighbor, error) {
+	neighbors, err := dal.QueryRows[d.ChunkNeighbor](r.dal, ctx, fnGetChunkNeighbors, chunkIDs, window)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chunk neighbors via %s: %w", fnGetChunkNeighbors, err)
+	}
+
+	return neighbors, nil
+}
+
 // Create creates a new chunk
 func (r *chunkRepository) Create(ctx context.Context, params d.CreateChunkParams) (*d.CreateChunkResult, error) {
 	result, err := dal.ExecProc[d.CreateChunkResult](
@@ -148,8 +177,18 @@ func (r *chunkRepository) Delete(ctx context.Context, chunkID int) (*d.DeleteChu
 	return result, nil
 }
 
-// BulkCreate creates multiple chunks at once
+// BulkCreate creates multiple chunks at once, with the heading path of each one when given,
+// optionally replacing the existing chunks of the document
 func (r *chunkRepository) BulkCreate(ctx context.Context, params d.BulkCreateChunksParams) (*d.BulkCreateChunksResult, error) {
+	var headingsJSON []byte
+	if params.Headings != nil {
+		var err error
+		headingsJSON, err = json.Marshal(params.Headings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal chunk headings: %w", err)
+		}
+	}
+
 	result, err := dal.ExecProc[d.BulkCreateChunksResult](
 		r.dal,
 		ctx,
@@ -157,6 +196,8 @@ func (r *chunkRepository) BulkCreate(ctx context.Context, params d.BulkCreateChu
 		params.DocumentID,
 		params.Contents,
 		params.Embeddings,
+		headingsJSON,
+		params.Replace,
 	)
 
 	if err != nil {
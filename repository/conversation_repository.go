@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"api-chatbot/api/dal"
@@ -13,9 +14,11 @@ const (
 	fnGetConversationByChatID = "fn_get_conversation_by_chat_id"
 	fnGetConversationHistory  = "fn_get_conversation_history"
 	// Stored Procedures (Writes)
-	spCreateConversation        = "sp_create_conversation"
-	spLinkUserToConversation    = "sp_link_user_to_conversation"
-	spCreateConversationMessage = "sp_create_conversation_message"
+	spCreateConversation         = "sp_create_conversation"
+	spLinkUserToConversation     = "sp_link_user_to_conversation"
+	spCreateConversationMessage  = "sp_create_conversation_message"
+	spLinkMessageChunks          = "sp_link_message_chunks"
+	spRequestConversationHandoff = "sp_request_conversation_handoff"
 )
 
 type conversationRepository struct {
@@ -91,6 +94,24 @@ func (r *conversationRepository) GetHistory(ctx context.Context, chatID string,
 
 // CreateMessage stores a new message in a conversation
 func (r *conversationRepository) CreateMessage(ctx context.Context, params d.CreateConversationMessageParams) (*d.CreateConversationMessageResult, error) {
+	var metadataJSON []byte
+	if params.Metadata != nil {
+		data, err := json.Marshal(params.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal message metadata: %w", err)
+		}
+		metadataJSON = data
+	}
+
+	var traceJSON []byte
+	if params.RetrievalTrace != nil {
+		data, err := json.Marshal(params.RetrievalTrace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal retrieval trace: %w", err)
+		}
+		traceJSON = data
+	}
+
 	result, err := dal.ExecProc[d.CreateConversationMessageResult](
 		r.dal,
 		ctx,
@@ -113,6 +134,9 @@ func (r *conversationRepository) CreateMessage(ctx context.Context, params d.Cre
 		params.CompletionTimeMs,
 		params.TotalTokens,
 		params.TotalTimeMs,
+		params.VariantID,
+		metadataJSON,
+		traceJSON,
 	)
 
 	if err != nil {
@@ -121,3 +145,38 @@ func (r *conversationRepository) CreateMessage(ctx context.Context, params d.Cre
 
 	return result, nil
 }
+
+// LinkMessageChunks records the chunks a bot answer was built from, in retrieval order
+func (r *conversationRepository) LinkMessageChunks(ctx context.Context, messageID int, chunkIDs []int, scores []float64) (*d.LinkMessageChunksResult, error) {
+	result, err := dal.ExecProc[d.LinkMessageChunksResult](
+		r.dal,
+		ctx,
+		spLinkMessageChunks,
+		messageID,
+		chunkIDs,
+		scores,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spLinkMessageChunks, err)
+	}
+
+	return result, nil
+}
+
+// RequestHandoff flags a conversation as needing an admin
+func (r *conversationRepository) RequestHandoff(ctx context.Context, params d.RequestHandoffParams) (*d.RequestHandoffResult, error) {
+	result, err := dal.ExecProc[d.RequestHandoffResult](
+		r.dal,
+		ctx,
+		spRequestConversationHandoff,
+		params.ConversationID,
+		params.Reason,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spRequestConversationHandoff, err)
+	}
+
+	return result, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"api-chatbot/api/dal"
@@ -10,14 +11,17 @@ import (
 
 const (
 	// Functions (Read-only)
-	fnGetAllDocuments        = "fn_get_all_documents"
-	fnGetDocumentByID        = "fn_get_document_by_id"
-	fnGetDocumentsByCategory = "fn_get_documents_by_category"
-	fnSearchDocumentsByTitle = "fn_search_documents_by_title"
+	fnGetAllDocuments         = "fn_get_all_documents"
+	fnGetDocumentByID         = "fn_get_document_by_id"
+	fnGetDocumentsByCategory  = "fn_get_documents_by_category"
+	fnSearchDocumentsByTitle  = "fn_search_documents_by_title"
+	fnGetDocumentTableMapping = "fn_get_document_table_mapping"
 	// Stored Procedures (Writes)
-	spCreateDocument = "sp_create_document"
-	spUpdateDocument = "sp_update_document"
-	spDeleteDocument = "sp_delete_document"
+	spCreateDocument          = "sp_create_document"
+	spUpdateDocument          = "sp_update_document"
+	spDeleteDocument          = "sp_delete_document"
+	spExpireDocuments         = "sp_expire_documents"
+	spSetDocumentTableMapping = "sp_set_document_table_mapping"
 )
 
 type documentRepository struct {
@@ -82,6 +86,10 @@ func (r *documentRepository) Create(ctx context.Context, params d.CreateDocument
 		params.Summary,
 		params.Source,
 		params.PublishedAt,
+		params.Tags,
+		params.Audience,
+		params.ValidFrom,
+		params.ValidUntil,
 	)
 
 	if err != nil {
@@ -103,6 +111,10 @@ func (r *documentRepository) Update(ctx context.Context, params d.UpdateDocument
 		params.Summary,
 		params.Source,
 		params.PublishedAt,
+		params.Tags,
+		params.Audience,
+		params.ValidFrom,
+		params.ValidUntil,
 	)
 
 	if err != nil {
@@ -127,3 +139,44 @@ func (r *documentRepository) Delete(ctx context.Context, docID int) (*d.DeleteDo
 
 	return result, nil
 }
+
+// ExpireDocuments deactivates the documents whose validity has ended
+func (r *documentRepository) ExpireDocuments(ctx context.Context) (*d.ExpireDocumentsResult, error) {
+	result, err := dal.ExecProc[d.ExpireDocumentsResult](r.dal, ctx, spExpireDocuments)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spExpireDocuments, err)
+	}
+
+	return result, nil
+}
+
+// GetTableMapping retrieves a document with the mapping it was imported from a spreadsheet with
+func (r *documentRepository) GetTableMapping(ctx context.Context, docID int) (*d.DocumentTableMapping, error) {
+	docs, err := dal.QueryRows[d.DocumentTableMapping](r.dal, ctx, fnGetDocumentTableMapping, docID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document table mapping via %s: %w", fnGetDocumentTableMapping, err)
+	}
+
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	return &docs[0], nil
+}
+
+// SetTableMapping records the mapping and summary of a document imported from a spreadsheet
+func (r *documentRepository) SetTableMapping(ctx context.Context, docID int, summary *string, mapping d.TableMapping) (*d.SetDocumentTableMappingResult, error) {
+	mappingJSON, err := json.Marshal(mapping)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal table mapping: %w", err)
+	}
+
+	result, err := dal.ExecProc[d.SetDocumentTableMappingResult](r.dal, ctx, spSetDocumentTableMapping, docID, summary, mappingJSON)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spSetDocumentTableMapping, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"api-chatbot/api/dal"
+	d "api-chatbot/domain"
+)
+
+const (
+	// Functions (Read-only)
+	fnGetCachedEmbeddings    = "fn_get_cached_embeddings"
+	fnGetEmbeddingCacheStats = "fn_get_embedding_cache_stats"
+	// Stored Procedures (Writes)
+	spRecordEmbeddingCache = "sp_record_embedding_cache"
+	spPurgeEmbeddingCache  = "sp_purge_embedding_cache"
+)
+
+type embeddingCacheRepository struct {
+	dal *dal.DAL
+}
+
+func NewEmbeddingCacheRepository(dal *dal.DAL) d.EmbeddingCacheRepository {
+	return &embeddingCacheRepository{
+		dal: dal,
+	}
+}
+
+// GetCached retrieves the cached vectors of a model for the given text hashes
+func (r *embeddingCacheRepository) GetCached(ctx context.Context, model string, textHashes []string) ([]d.CachedEmbedding, error) {
+	cached, err := dal.QueryRows[d.CachedEmbedding](r.dal, ctx, fnGetCachedEmbeddings, model, textHashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached embeddings via %s: %w", fnGetCachedEmbeddings, err)
+	}
+	return cached, nil
+}
+
+// Record stores new vectors and counts the hits and misses of a lookup
+func (r *embeddingCacheRepository) Record(ctx context.Context, params d.RecordEmbeddingCacheParams) (*d.RecordEmbeddingCacheResult, error) {
+	result, err := dal.ExecProc[d.RecordEmbeddingCacheResult](
+		r.dal,
+		ctx,
+		spRecordEmbeddingCache,
+		params.Model,
+		params.HitHashes,
+		params.MissHashes,
+		params.MissEmbeddings,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spRecordEmbeddingCache, err)
+	}
+	return result, nil
+}
+
+// GetStats retrieves cache size and hit rate per model for the last days
+func (r *embeddingCacheRepository) GetStats(ctx context.Context, days int) ([]d.EmbeddingCacheStats, error) {
+	stats, err := dal.QueryRows[d.EmbeddingCacheStats](r.dal, ctx, fnGetEmbeddingCacheStats, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embedding cache stats via %s: %w", fnGetEmbeddingCacheStats, err)
+	}
+	return stats, nil
+}
+
+// Purge deletes cached vectors
+func (r *embeddingCacheRepository) Purge(ctx context.Context, params d.PurgeEmbeddingCacheParams) (*d.PurgeEmbeddingCacheResult, error) {
+	result, err := dal.ExecProc[d.PurgeEmbeddingCacheResult](
+		r.dal,
+		ctx,
+		spPurgeEmbeddingCache,
+		params.Model,
+		params.UnusedDays,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spPurgeEmbeddingCache, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"api-chatbot/api/dal"
+	d "api-chatbot/domain"
+)
+
+const (
+	// Functions (Read-only)
+	fnGetEmbeddingJobs            = "fn_get_embedding_jobs"
+	fnGetChunksPendingReembedding = "fn_get_chunks_pending_reembedding"
+	// Stored Procedures (Writes)
+	spCreateEmbeddingJob       = "sp_create_embedding_job"
+	spStoreChunkEmbeddingsNext = "sp_store_chunk_embeddings_next"
+	spUpdateEmbeddingJobStatus = "sp_update_embedding_job_status"
+	spSwapEmbeddingSet         = "sp_swap_embedding_set"
+)
+
+type embeddingMigrationRepository struct {
+	dal *dal.DAL
+}
+
+func NewEmbeddingMigrationRepository(dal *dal.DAL) d.EmbeddingMigrationRepository {
+	return &embeddingMigrationRepository{
+		dal: dal,
+	}
+}
+
+// CreateJob opens a re-embedding job
+func (r *embeddingMigrationRepository) CreateJob(ctx context.Context, params d.CreateEmbeddingJobParams) (*d.CreateEmbeddingJobResult, error) {
+	result, err := dal.ExecProc[d.CreateEmbeddingJobResult](
+		r.dal,
+		ctx,
+		spCreateEmbeddingJob,
+		params.ConfigCode,
+		params.TargetModel,
+		params.TargetDimensions,
+		params.BatchSize,
+		params.AutoSwap,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spCreateEmbeddingJob, err)
+	}
+	return result, nil
+}
+
+// GetJobs retrieves one job (jobID set) or the latest jobs with live progress
+func (r *embeddingMigrationRepository) GetJobs(ctx context.Context, jobID *int, limit int) ([]d.EmbeddingJob, error) {
+	jobs, err := dal.QueryRows[d.EmbeddingJob](r.dal, ctx, fnGetEmbeddingJobs, jobID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embedding jobs via %s: %w", fnGetEmbeddingJobs, err)
+	}
+	return jobs, nil
+}
+
+// GetPendingChunks retrieves the next chunks without a shadow embedding after a chunk ID
+func (r *embeddingMigrationRepository) GetPendingChunks(ctx context.Context, afterID int, limit int) ([]d.PendingChunk, error) {
+	chunks, err := dal.QueryRows[d.PendingChunk](r.dal, ctx, fnGetChunksPendingReembedding, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending chunks via %s: %w", fnGetChunksPendingReembedding, err)
+	}
+	return chunks, nil
+}
+
+// StoreNextEmbeddings writes a batch of shadow embeddings
+func (r *embeddingMigrationRepository) StoreNextEmbeddings(ctx context.Context, params d.StoreNextEmbeddingsParams) (*d.StoreNextEmbeddingsResult, error) {
+	result, err := dal.ExecProc[d.StoreNextEmbeddingsResult](
+		r.dal,
+		ctx,
+		spStoreChunkEmbeddingsNext,
+		params.JobID,
+		params.ChunkIDs,
+		params.ContentHashes,
+		params.Embeddings,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spStoreChunkEmbeddingsNext, err)
+	}
+	return result, nil
+}
+
+// UpdateJobStatus pauses, resumes, completes, fails or cancels a job
+func (r *embeddingMigrationRepository) UpdateJobStatus(ctx context.Context, params d.UpdateEmbeddingJobStatusParams) (*d.UpdateEmbeddingJobStatusResult, error) {
+	result, err := dal.ExecProc[d.UpdateEmbeddingJobStatusResult](
+		r.dal,
+		ctx,
+		spUpdateEmbeddingJobStatus,
+		params.JobID,
+		params.Status,
+		params.FailedChunks,
+		params.Error,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spUpdateEmbeddingJobStatus, err)
+	}
+	return result, nil
+}
+
+// SwapEmbeddingSet activates the shadow embeddings and rebuilds the vector index
+func (r *embeddingMigrationRepository) SwapEmbeddingSet(ctx context.Context, jobID int) (*d.SwapEmbeddingSetResult, error) {
+	result, err := dal.ExecProc[d.SwapEmbeddingSetResult](r.dal, ctx, spSwapEmbeddingSet, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spSwapEmbeddingSet, err)
+	}
+	return result, nil
+}
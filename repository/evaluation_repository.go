@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"api-chatbot/api/dal"
+	d "api-chatbot/domain"
+)
+
+const (
+	// Functions (Read-only)
+	fnGetEvalQuestions = "fn_get_eval_questions"
+	fnGetEvalRuns      = "fn_get_eval_runs"
+	// Stored Procedures (Writes)
+	spCreateEvalQuestion = "sp_create_eval_question"
+	spDeleteEvalQuestion = "sp_delete_eval_question"
+	spCreateEvalRun      = "sp_create_eval_run"
+)
+
+type evaluationRepository struct {
+	dal *dal.DAL
+}
+
+func NewEvaluationRepository(dal *dal.DAL) d.EvaluationRepository {
+	return &evaluationRepository{
+		dal: dal,
+	}
+}
+
+// CreateQuestion adds a labelled question to a golden set
+func (r *evaluationRepository) CreateQuestion(ctx context.Context, params d.CreateEvalQuestionParams) (*d.CreateEvalQuestionResult, error) {
+	result, err := dal.ExecProc[d.CreateEvalQuestionResult](
+		r.dal,
+		ctx,
+		spCreateEvalQuestion,
+		params.Set,
+		params.Question,
+		params.Category,
+		params.RelevantChunkIDs,
+		params.RelevantDocumentIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spCreateEvalQuestion, err)
+	}
+	return result, nil
+}
+
+// DeleteQuestion deactivates a golden set question
+func (r *evaluationRepository) DeleteQuestion(ctx context.Context, questionID int) (*d.DeleteEvalQuestionResult, error) {
+	result, err := dal.ExecProc[d.DeleteEvalQuestionResult](
+		r.dal,
+		ctx,
+		spDeleteEvalQuestion,
+		questionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spDeleteEvalQuestion, err)
+	}
+	return result, nil
+}
+
+// GetQuestions retrieves the active questions of a golden set
+func (r *evaluationRepository) GetQuestions(ctx context.Context, set string) ([]d.EvalQuestion, error) {
+	questions, err := dal.QueryRows[d.EvalQuestion](r.dal, ctx, fnGetEvalQuestions, set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get evaluation questions via %s: %w", fnGetEvalQuestions, err)
+	}
+	return questions, nil
+}
+
+// CreateRun stores the report of an evaluation run
+func (r *evaluationRepository) CreateRun(ctx context.Context, params d.CreateEvalRunParams) (*d.CreateEvalRunResult, error) {
+	settingsJSON, err := json.Marshal(params.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal evaluation settings: %w", err)
+	}
+	metricsJSON, err := json.Marshal(params.Metrics)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal evaluation metrics: %w", err)
+	}
+	resultsJSON, err := json.Marshal(params.Results)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal evaluation results: %w", err)
+	}
+
+	result, err := dal.ExecProc[d.CreateEvalRunResult](
+		r.dal,
+		ctx,
+		spCreateEvalRun,
+		params.Set,
+		params.Label,
+		params.K,
+		params.QuestionCount,
+		settingsJSON,
+		metricsJSON,
+		resultsJSON,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spCreateEvalRun, err)
+	}
+	return result, nil
+}
+
+// GetRuns retrieves evaluation runs, newest first. Per-question results are only loaded for a single run.
+func (r *evaluationRepository) GetRuns(ctx context.Context, runID *int, set *string, limit int) ([]d.EvalRun, error) {
+	runs, err := dal.QueryRows[d.EvalRun](r.dal, ctx, fnGetEvalRuns, runID, set, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get evaluation runs via %s: %w", fnGetEvalRuns, err)
+	}
+	return runs, nil
+}
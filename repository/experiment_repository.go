@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"api-chatbot/api/dal"
+	d "api-chatbot/domain"
+)
+
+const (
+	// Functions (Read-only)
+	fnGetExperiments              = "fn_get_experiments"
+	fnGetActiveExperimentVariants = "fn_get_active_experiment_variants"
+	fnCompareExperimentVariants   = "fn_compare_experiment_variants"
+	// Stored Procedures (Writes)
+	spCreateExperiment    = "sp_create_experiment"
+	spSetExperimentStatus = "sp_set_experiment_status"
+)
+
+type experimentRepository struct {
+	dal *dal.DAL
+}
+
+func NewExperimentRepository(dal *dal.DAL) d.ExperimentRepository {
+	return &experimentRepository{
+		dal: dal,
+	}
+}
+
+// Create creates an experiment together with its variants
+func (r *experimentRepository) Create(ctx context.Context, params d.CreateExperimentParams) (*d.CreateExperimentResult, error) {
+	variantsJSON, err := json.Marshal(params.Variants)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal experiment variants: %w", err)
+	}
+
+	result, err := dal.ExecProc[d.CreateExperimentResult](
+		r.dal,
+		ctx,
+		spCreateExperiment,
+		params.Code,
+		params.Name,
+		params.Description,
+		variantsJSON,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spCreateExperiment, err)
+	}
+	return result, nil
+}
+
+// SetStatus starts or stops an experiment
+func (r *experimentRepository) SetStatus(ctx context.Context, experimentID int, active bool) (*d.SetExperimentStatusResult, error) {
+	result, err := dal.ExecProc[d.SetExperimentStatusResult](
+		r.dal,
+		ctx,
+		spSetExperimentStatus,
+		experimentID,
+		active,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spSetExperimentStatus, err)
+	}
+	return result, nil
+}
+
+// GetAll retrieves all experiments with their variants
+func (r *experimentRepository) GetAll(ctx context.Context) ([]d.Experiment, error) {
+	experiments, err := dal.QueryRows[d.Experiment](r.dal, ctx, fnGetExperiments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get experiments via %s: %w", fnGetExperiments, err)
+	}
+	return experiments, nil
+}
+
+// GetActiveVariants retrieves the variants of the running experiment
+func (r *experimentRepository) GetActiveVariants(ctx context.Context) ([]d.ExperimentVariant, error) {
+	variants, err := dal.QueryRows[d.ExperimentVariant](r.dal, ctx, fnGetActiveExperimentVariants)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active experiment variants via %s: %w", fnGetActiveExperimentVariants, err)
+	}
+	return variants, nil
+}
+
+// CompareVariants retrieves outcome metrics per variant of an experiment
+func (r *experimentRepository) CompareVariants(ctx context.Context, params d.CompareVariantsParams) ([]d.VariantComparison, error) {
+	comparison, err := dal.QueryRows[d.VariantComparison](
+		r.dal,
+		ctx,
+		fnCompareExperimentVariants,
+		params.ExperimentID,
+		params.StartDate,
+		params.EndDate,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare experiment variants via %s: %w", fnCompareExperimentVariants, err)
+	}
+	return comparison, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"api-chatbot/api/dal"
+	d "api-chatbot/domain"
+
+	"github.com/pgvector/pgvector-go"
+)
+
+const (
+	// Functions (Read-only)
+	fnGetFAQs   = "fn_get_faqs"
+	fnMatchFAQs = "fn_match_faqs"
+	// Stored Procedures (Writes)
+	spSaveFAQ      = "sp_save_faq"
+	spDeleteFAQ    = "sp_delete_faq"
+	spRecordFAQHit = "sp_record_faq_hit"
+)
+
+type faqRepository struct {
+	dal *dal.DAL
+}
+
+func NewFAQRepository(dal *dal.DAL) d.FAQRepository {
+	return &faqRepository{
+		dal: dal,
+	}
+}
+
+// GetFAQs retrieves the FAQs ordered by hits
+func (r *faqRepository) GetFAQs(ctx context.Context, includeInactive bool) ([]d.FAQ, error) {
+	faqs, err := dal.QueryRows[d.FAQ](r.dal, ctx, fnGetFAQs, includeInactive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get FAQs via %s: %w", fnGetFAQs, err)
+	}
+	return faqs, nil
+}
+
+// Match scores the active FAQs against a question, best first
+func (r *faqRepository) Match(ctx context.Context, params d.MatchFAQParams) ([]d.FAQMatch, error) {
+	matches, err := dal.QueryRows[d.FAQMatch](
+		r.dal,
+		ctx,
+		fnMatchFAQs,
+		params.QueryEmbedding,
+		params.QueryText,
+		params.Model,
+		params.KeywordWeight,
+		params.Category,
+		params.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match FAQs via %s: %w", fnMatchFAQs, err)
+	}
+	return matches, nil
+}
+
+// Save creates or updates an FAQ and rebuilds its match index
+func (r *faqRepository) Save(ctx context.Context, params d.SaveFAQParams, embeddings []pgvector.Vector, model string) (*d.SaveFAQResult, error) {
+	result, err := dal.ExecProc[d.SaveFAQResult](
+		r.dal,
+		ctx,
+		spSaveFAQ,
+		params.ID,
+		params.Question,
+		params.Variants,
+		params.Answer,
+		params.Category,
+		params.MediaURL,
+		params.MediaType,
+		params.Threshold,
+		embeddings,
+		model,
+		params.Active,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spSaveFAQ, err)
+	}
+	return result, nil
+}
+
+// Delete removes an FAQ
+func (r *faqRepository) Delete(ctx context.Context, faqID int) (*d.DeleteFAQResult, error) {
+	result, err := dal.ExecProc[d.DeleteFAQResult](r.dal, ctx, spDeleteFAQ, faqID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spDeleteFAQ, err)
+	}
+	return result, nil
+}
+
+// RecordHit counts an FAQ answered instead of a generated response
+func (r *faqRepository) RecordHit(ctx context.Context, faqID int) (*d.RecordFAQHitResult, error) {
+	result, err := dal.ExecProc[d.RecordFAQHitResult](r.dal, ctx, spRecordFAQHit, faqID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spRecordFAQHit, err)
+	}
+	return result, nil
+}
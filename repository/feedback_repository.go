@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"api-chatbot/api/dal"
+	d "api-chatbot/domain"
+)
+
+const (
+	// Functions (Read-only)
+	fnGetMessageChunks = "fn_get_message_chunks"
+	// Stored Procedures (Writes)
+	spSetMessageFeedback       = "sp_set_message_feedback"
+	spSetMessageChunkRelevance = "sp_set_message_chunk_relevance"
+)
+
+type feedbackRepository struct {
+	dal *dal.DAL
+}
+
+func NewFeedbackRepository(dal *dal.DAL) d.FeedbackRepository {
+	return &feedbackRepository{
+		dal: dal,
+	}
+}
+
+// SetMessageFeedback rates a bot answer and recomputes the metrics of the chunks it used
+func (r *feedbackRepository) SetMessageFeedback(ctx context.Context, params d.SetMessageFeedbackParams) (*d.SetMessageFeedbackResult, error) {
+	result, err := dal.ExecProc[d.SetMessageFeedbackResult](
+		r.dal,
+		ctx,
+		spSetMessageFeedback,
+		params.MessageID,
+		int16(params.Rating),
+		params.Source,
+		params.ChatID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spSetMessageFeedback, err)
+	}
+	return result, nil
+}
+
+// SetChunkRelevance labels one chunk of a bot answer and recomputes its metrics
+func (r *feedbackRepository) SetChunkRelevance(ctx context.Context, params d.SetChunkRelevanceParams) (*d.SetChunkRelevanceResult, error) {
+	result, err := dal.ExecProc[d.SetChunkRelevanceResult](
+		r.dal,
+		ctx,
+		spSetMessageChunkRelevance,
+		params.MessageID,
+		params.ChunkID,
+		params.Relevant,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spSetMessageChunkRelevance, err)
+	}
+	return result, nil
+}
+
+// GetMessageChunks retrieves the chunks a bot answer was built from, in retrieval order
+func (r *feedbackRepository) GetMessageChunks(ctx context.Context, messageID int) ([]d.MessageChunk, error) {
+	chunks, err := dal.QueryRows[d.MessageChunk](r.dal, ctx, fnGetMessageChunks, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message chunks via %s: %w", fnGetMessageChunks, err)
+	}
+	return chunks, nil
+}
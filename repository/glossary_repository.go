@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"api-chatbot/api/dal"
+	d "api-chatbot/domain"
+)
+
+const (
+	// Functions (Read-only)
+	fnGetGlossaryEntries      = "fn_get_glossary_entries"
+	fnGetSearchQueryExpansion = "fn_get_search_query_expansion"
+	// Stored Procedures (Writes)
+	spSaveGlossaryEntry   = "sp_save_glossary_entry"
+	spDeleteGlossaryEntry = "sp_delete_glossary_entry"
+	spReindexChunkFTS     = "sp_reindex_chunk_fts"
+)
+
+type glossaryRepository struct {
+	dal *dal.DAL
+}
+
+func NewGlossaryRepository(dal *dal.DAL) d.GlossaryRepository {
+	return &glossaryRepository{
+		dal: dal,
+	}
+}
+
+// GetEntries retrieves the active glossary entries, optionally of one type
+func (r *glossaryRepository) GetEntries(ctx context.Context, entryType *string) ([]d.GlossaryEntry, error) {
+	entries, err := dal.QueryRows[d.GlossaryEntry](r.dal, ctx, fnGetGlossaryEntries, entryType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get glossary entries via %s: %w", fnGetGlossaryEntries, err)
+	}
+	return entries, nil
+}
+
+// SaveEntry creates or updates a glossary entry and reindexes the chunks it affects
+func (r *glossaryRepository) SaveEntry(ctx context.Context, params d.SaveGlossaryEntryParams) (*d.SaveGlossaryEntryResult, error) {
+	result, err := dal.ExecProc[d.SaveGlossaryEntryResult](
+		r.dal,
+		ctx,
+		spSaveGlossaryEntry,
+		params.ID,
+		params.Term,
+		params.Type,
+		params.Expansions,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spSaveGlossaryEntry, err)
+	}
+	return result, nil
+}
+
+// DeleteEntry deactivates a glossary entry and reindexes the chunks it expanded
+func (r *glossaryRepository) DeleteEntry(ctx context.Context, entryID int) (*d.DeleteGlossaryEntryResult, error) {
+	result, err := dal.ExecProc[d.DeleteGlossaryEntryResult](r.dal, ctx, spDeleteGlossaryEntry, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spDeleteGlossaryEntry, err)
+	}
+	return result, nil
+}
+
+// ReindexChunks recomputes the full-text vectors of every chunk
+func (r *glossaryRepository) ReindexChunks(ctx context.Context) (*d.ReindexChunkFTSResult, error) {
+	result, err := dal.ExecProc[d.ReindexChunkFTSResult](r.dal, ctx, spReindexChunkFTS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spReindexChunkFTS, err)
+	}
+	return result, nil
+}
+
+// GetQueryExpansion parses a search query the way the hybrid search does
+func (r *glossaryRepository) GetQueryExpansion(ctx context.Context, query string) (*d.SearchQueryExpansion, error) {
+	expansion, err := dal.QueryRow[d.SearchQueryExpansion](r.dal, ctx, fnGetSearchQueryExpansion, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query expansion via %s: %w", fnGetSearchQueryExpansion, err)
+	}
+	return expansion, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"api-chatbot/api/dal"
+	d "api-chatbot/domain"
+)
+
+const (
+	// Functions (Read-only)
+	fnGetGuardrailEvents = "fn_get_guardrail_events"
+	// Stored Procedures (Writes)
+	spCreateGuardrailEvent = "sp_create_guardrail_event"
+	spReviewGuardrailEvent = "sp_review_guardrail_event"
+)
+
+type guardrailRepository struct {
+	dal *dal.DAL
+}
+
+func NewGuardrailRepository(dal *dal.DAL) d.GuardrailRepository {
+	return &guardrailRepository{
+		dal: dal,
+	}
+}
+
+// CreateEvent logs a guardrail trigger
+func (r *guardrailRepository) CreateEvent(ctx context.Context, params d.CreateGuardrailEventParams) (*d.CreateGuardrailEventResult, error) {
+	result, err := dal.ExecProc[d.CreateGuardrailEventResult](
+		r.dal,
+		ctx,
+		spCreateGuardrailEvent,
+		params.Stage,
+		params.Check,
+		params.Rule,
+		params.Action,
+		params.Reason,
+		params.Channel,
+		params.ChatID,
+		params.ConversationID,
+		params.OriginalText,
+		params.RewrittenText,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spCreateGuardrailEvent, err)
+	}
+	return result, nil
+}
+
+// GetEvents retrieves guardrail triggers for admin review
+func (r *guardrailRepository) GetEvents(ctx context.Context, params d.GetGuardrailEventsParams) ([]d.GuardrailEvent, error) {
+	events, err := dal.QueryRows[d.GuardrailEvent](
+		r.dal,
+		ctx,
+		fnGetGuardrailEvents,
+		params.Stage,
+		params.Action,
+		params.OnlyPending,
+		params.Limit,
+		params.Offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guardrail events via %s: %w", fnGetGuardrailEvents, err)
+	}
+	return events, nil
+}
+
+// ReviewEvent marks a guardrail trigger as reviewed
+func (r *guardrailRepository) ReviewEvent(ctx context.Context, params d.ReviewGuardrailEventParams) (*d.ReviewGuardrailEventResult, error) {
+	result, err := dal.ExecProc[d.ReviewGuardrailEventResult](
+		r.dal,
+		ctx,
+		spReviewGuardrailEvent,
+		params.EventID,
+		params.AdminID,
+		params.Notes,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spReviewGuardrailEvent, err)
+	}
+	return result, nil
+}
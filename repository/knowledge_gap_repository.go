@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"api-chatbot/api/dal"
+	d "api-chatbot/domain"
+)
+
+const (
+	// Functions (Read-only)
+	fnGetGapQuestions     = "fn_get_gap_questions"
+	fnGetKnowledgeGapRuns = "fn_get_knowledge_gap_runs"
+
+	// Stored Procedures (Writes)
+	spCreateKnowledgeGapRun = "sp_create_knowledge_gap_run"
+)
+
+type knowledgeGapRepository struct {
+	dal *dal.DAL
+}
+
+func NewKnowledgeGapRepository(dal *dal.DAL) d.KnowledgeGapRepository {
+	return &knowledgeGapRepository{
+		dal: dal,
+	}
+}
+
+// GetQuestions retrieves the user questions of a period, newest first
+func (r *knowledgeGapRepository) GetQuestions(ctx context.Context, startDate, endDate time.Time, limit int) ([]d.GapQuestion, error) {
+	questions, err := dal.QueryRows[d.GapQuestion](r.dal, ctx, fnGetGapQuestions, startDate, endDate, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gap questions via %s: %w", fnGetGapQuestions, err)
+	}
+	return questions, nil
+}
+
+// CreateRun stores the clusters of a knowledge-gap analysis
+func (r *knowledgeGapRepository) CreateRun(ctx context.Context, params d.CreateKnowledgeGapRunParams) (*d.CreateKnowledgeGapRunResult, error) {
+	settingsJSON, err := json.Marshal(params.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal knowledge gap settings: %w", err)
+	}
+	clusters := params.Clusters
+	if clusters == nil {
+		clusters = []d.KnowledgeGap{}
+	}
+	clustersJSON, err := json.Marshal(clusters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal knowledge gap clusters: %w", err)
+	}
+
+	result, err := dal.ExecProc[d.CreateKnowledgeGapRunResult](
+		r.dal,
+		ctx,
+		spCreateKnowledgeGapRun,
+		params.PeriodStart,
+		params.PeriodEnd,
+		params.QuestionCount,
+		settingsJSON,
+		clustersJSON,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spCreateKnowledgeGapRun, err)
+	}
+	return result, nil
+}
+
+// GetRuns retrieves knowledge-gap runs, newest first. Clusters are only loaded for a single or the latest run.
+func (r *knowledgeGapRepository) GetRuns(ctx context.Context, runID *int, latest bool, limit int) ([]d.KnowledgeGapRun, error) {
+	runs, err := dal.QueryRows[d.KnowledgeGapRun](r.dal, ctx, fnGetKnowledgeGapRuns, runID, latest, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get knowledge gap runs via %s: %w", fnGetKnowledgeGapRuns, err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"api-chatbot/api/dal"
+	d "api-chatbot/domain"
+)
+
+const (
+	// Functions (Read-only)
+	fnGetWebSources    = "fn_get_web_sources"
+	fnGetDueWebSources = "fn_get_due_web_sources"
+
+	// Stored Procedures (Writes)
+	spSaveWebSource          = "sp_save_web_source"
+	spDeleteWebSource        = "sp_delete_web_source"
+	spRecordWebCrawl         = "sp_record_web_crawl"
+	spSyncWebDocument        = "sp_sync_web_document"
+	spSetDocumentContentHash = "sp_set_document_content_hash"
+)
+
+type webSourceRepository struct {
+	dal *dal.DAL
+}
+
+func NewWebSourceRepository(dal *dal.DAL) d.WebSourceRepository {
+	return &webSourceRepository{
+		dal: dal,
+	}
+}
+
+// GetSources retrieves the web sources (or a single one) ordered by URL
+func (r *webSourceRepository) GetSources(ctx context.Context, includeInactive bool, sourceID *int) ([]d.WebSource, error) {
+	sources, err := dal.QueryRows[d.WebSource](r.dal, ctx, fnGetWebSources, includeInactive, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get web sources via %s: %w", fnGetWebSources, err)
+	}
+	return sources, nil
+}
+
+// GetDueSources retrieves the active web sources due a re-crawl, never crawled first
+func (r *webSourceRepository) GetDueSources(ctx context.Context) ([]d.WebSource, error) {
+	sources, err := dal.QueryRows[d.WebSource](r.dal, ctx, fnGetDueWebSources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due web sources via %s: %w", fnGetDueWebSources, err)
+	}
+	return sources, nil
+}
+
+// Save creates or updates a web source
+func (r *webSourceRepository) Save(ctx context.Context, params d.SaveWebSourceParams) (*d.SaveWebSourceResult, error) {
+	result, err := dal.ExecProc[d.SaveWebSourceResult](
+		r.dal,
+		ctx,
+		spSaveWebSource,
+		params.ID,
+		params.URL,
+		params.IsSitemap,
+		params.Category,
+		params.Tags,
+		params.Audience,
+		params.MaxDepth,
+		params.MaxPages,
+		params.IncludePatterns,
+		params.ExcludePatterns,
+		params.RecrawlHours,
+		params.ChunkSize,
+		params.ChunkOverlap,
+		params.Active,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spSaveWebSource, err)
+	}
+	return result, nil
+}
+
+// Delete removes a web source; the documents of its pages are kept
+func (r *webSourceRepository) Delete(ctx context.Context, sourceID int) (*d.DeleteWebSourceResult, error) {
+	result, err := dal.ExecProc[d.DeleteWebSourceResult](r.dal, ctx, spDeleteWebSource, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spDeleteWebSource, err)
+	}
+	return result, nil
+}
+
+// RecordCrawl stores the outcome of a crawl as the last result of the source
+func (r *webSourceRepository) RecordCrawl(ctx context.Context, sourceID int, crawl d.WebCrawlResult) (*d.RecordWebCrawlResult, error) {
+	crawlJSON, err := json.Marshal(crawl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal web crawl result: %w", err)
+	}
+
+	result, err := dal.ExecProc[d.RecordWebCrawlResult](r.dal, ctx, spRecordWebCrawl, sourceID, crawlJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spRecordWebCrawl, err)
+	}
+	return result, nil
+}
+
+// SyncDocument creates or updates the document of a crawled page
+func (r *webSourceRepository) SyncDocument(ctx context.Context, params d.SyncWebDocumentParams) (*d.SyncWebDocumentResult, error) {
+	result, err := dal.ExecProc[d.SyncWebDocumentResult](
+		r.dal,
+		ctx,
+		spSyncWebDocument,
+		params.Source,
+		params.Category,
+		params.Title,
+		params.Summary,
+		params.ContentHash,
+		params.Tags,
+		params.Audience,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spSyncWebDocument, err)
+	}
+	return result, nil
+}
+
+// SetContentHash records the content hash of a document once its chunks are stored
+func (r *webSourceRepository) SetContentHash(ctx context.Context, docID int, contentHash string) (*d.SetDocumentContentHashResult, error) {
+	result, err := dal.ExecProc[d.SetDocumentContentHashResult](r.dal, ctx, spSetDocumentContentHash, docID, contentHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", spSetDocumentContentHash, err)
+	}
+	return result, nil
+}
@@ -44,7 +44,7 @@ func (uc *adminConversationUseCase) GetAllConversations(
 	defer cancel()
 
 	// Validate filter
-	validFilters := map[string]bool{"all": true, "unread": true, "blocked": true, "active": true}
+	validFilters := map[string]bool{"all": true, "unread": true, "blocked": true, "active": true, "handoff": true}
 	if !validFilters[filter] {
 		filter = "all"
 	}
@@ -261,3 +261,35 @@ func (uc *adminConversationUseCase) SetConversationTemporary(
 		"expiresAt":      expiresAt,
 	})
 }
+
+// ResolveHandoff clears the needs-admin flag of a conversation
+func (uc *adminConversationUseCase) ResolveHandoff(
+	c context.Context,
+	conversationID int,
+) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, uc.contextTimeout)
+	defer cancel()
+
+	result, err := uc.repo.ResolveHandoff(ctx, conversationID)
+	if err != nil {
+		logger.LogError(ctx, "Failed to resolve conversation handoff", err,
+			"operation", "ResolveHandoff",
+			"conversationID", conversationID,
+		)
+		return d.Error[d.Data](uc.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Resolve handoff failed with business logic error",
+			"operation", "ResolveHandoff",
+			"code", result.Code,
+			"conversationID", conversationID,
+		)
+		return d.Error[d.Data](uc.paramCache, result.Code)
+	}
+
+	return d.Success(d.Data{
+		"conversationId": conversationID,
+		"needsAdmin":     false,
+	})
+}
@@ -2,11 +2,12 @@ package usecase
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	d "api-chatbot/domain"
 	"api-chatbot/internal/logger"
-	"api-chatbot/internal/metrics"
+	"api-chatbot/internal/retrieval"
 	"github.com/pgvector/pgvector-go"
 )
 
@@ -15,7 +16,8 @@ type chunkUseCase struct {
 	statsRepo        d.ChunkStatisticsRepository
 	cache            d.ParameterCache
 	embeddingService d.EmbeddingService
-	metricsCalc      *metrics.RAGMetrics
+	reranker         d.Reranker
+	queryExpander    d.QueryExpander
 	contextTimeout   time.Duration
 }
 
@@ -24,6 +26,8 @@ func NewChunkUseCase(
 	statsRepo d.ChunkStatisticsRepository,
 	cache d.ParameterCache,
 	embeddingService d.EmbeddingService,
+	reranker d.Reranker,
+	queryExpander d.QueryExpander,
 	timeout time.Duration,
 ) d.ChunkUseCase {
 	return &chunkUseCase{
@@ -31,7 +35,8 @@ func NewChunkUseCase(
 		statsRepo:        statsRepo,
 		cache:            cache,
 		embeddingService: embeddingService,
-		metricsCalc:      metrics.NewRAGMetrics(),
+		reranker:         reranker,
+		queryExpander:    queryExpander,
 		contextTimeout:   timeout,
 	}
 }
@@ -175,29 +180,49 @@ func (u *chunkUseCase) SimilaritySearch(c context.Context, queryText string, lim
 	return d.Success(chunks)
 }
 
-func (u *chunkUseCase) HybridSearch(c context.Context, queryText string, limit int, minSimilarity float64, keywordWeight float64) d.Result[[]d.ChunkWithHybridSimilarity] {
+func (u *chunkUseCase) HybridSearch(c context.Context, queryText string, limit int, minSimilarity float64, keywordWeight float64, opts d.RetrievalOptions) d.Result[[]d.ChunkWithHybridSimilarity] {
+	return u.hybridSearch(c, "HybridSearch", queryText, limit, minSimilarity, keywordWeight, nil, opts)
+}
+
+// HybridSearchWithCategory performs hybrid search with optional document category filtering
+func (u *chunkUseCase) HybridSearchWithCategory(c context.Context, queryText string, limit int, minSimilarity float64, keywordWeight float64, category *string, opts d.RetrievalOptions) d.Result[[]d.ChunkWithHybridSimilarity] {
+	return u.hybridSearch(c, "HybridSearchWithCategory", queryText, limit, minSimilarity, keywordWeight, category, opts)
+}
+
+// hybridSearch runs the retrieval pipeline: query strategy, embedding, database search,
+// multi-query fusion, reranking, diversification and neighbor expansion. A nil category searches every document.
+func (u *chunkUseCase) hybridSearch(c context.Context, operation string, queryText string, limit int, minSimilarity float64, keywordWeight float64, category *string, opts d.RetrievalOptions) d.Result[[]d.ChunkWithHybridSimilarity] {
+	categoryName := "none"
+	if category != nil {
+		categoryName = *category
+	}
+
 	logger.LogInfo(c, "Starting hybrid search",
-		"operation", "HybridSearch",
+		"operation", operation,
 		"queryText", queryText,
 		"queryLength", len(queryText),
 		"limit", limit,
 		"minSimilarity", minSimilarity,
 		"keywordWeight", keywordWeight,
+		"category", categoryName,
 	)
 
+	// Optional LLM step of the query strategy: HyDE embeds a hypothetical answer, multi-query adds paraphrases
+	embeddingText, paraphrases, strategy := u.prepareQuery(c, operation, queryText, u.queryStrategy(category, opts))
+
 	// Use longer timeout for embedding generation (OpenAI can be slow)
 	embeddingCtx, embeddingCancel := context.WithTimeout(c, 30*time.Second)
 	defer embeddingCancel()
 
 	// Generate embedding from query text
 	logger.LogInfo(embeddingCtx, "Generating embedding for hybrid search query",
-		"operation", "HybridSearch",
+		"operation", operation,
 		"queryText", queryText,
 	)
-	queryEmbedding, err := u.embeddingService.GenerateEmbedding(embeddingCtx, queryText)
+	queryEmbedding, err := u.embeddingService.GenerateEmbedding(embeddingCtx, embeddingText)
 	if err != nil {
 		logger.LogError(embeddingCtx, "Failed to generate embedding for hybrid search", err,
-			"operation", "HybridSearch",
+			"operation", operation,
 			"queryTextLength", len(queryText),
 		)
 		return d.Error[[]d.ChunkWithHybridSimilarity](u.cache, "ERR_EMBEDDING_GENERATION")
@@ -207,26 +232,39 @@ func (u *chunkUseCase) HybridSearch(c context.Context, queryText string, limit i
 	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
 	defer cancel()
 
-	// Create params with generated embedding and query text
+	settings := u.retrievalSettings(opts)
+
+	// Create params with generated embedding, query text, and category filter
 	params := d.HybridSearchParams{
-		QueryEmbedding: pgvector.NewVector(queryEmbedding),
-		QueryText:      queryText,
-		Limit:          limit,
-		MinSimilarity:  minSimilarity,
-		KeywordWeight:  keywordWeight,
+		QueryEmbedding:      pgvector.NewVector(queryEmbedding),
+		QueryText:           queryText,
+		Limit:               u.fetchLimit(limit, settings),
+		MinSimilarity:       minSimilarity,
+		KeywordWeight:       keywordWeight,
+		Category:            category,
+		Fusion:              settings.fusion,
+		RRFK:                settings.rrfK,
+		WithEmbeddings:      settings.diversity.MMR,
+		Filter:              opts.Filter,
+		Role:                opts.Role,
+		RecencyWeight:       settings.recencyWeight,
+		RecencyHalfLifeDays: settings.recencyHalfLifeDays,
 	}
 
 	logger.LogInfo(ctx, "Performing database hybrid search",
-		"operation", "HybridSearch",
+		"operation", operation,
 		"limit", limit,
 		"minSimilarity", minSimilarity,
 		"keywordWeight", keywordWeight,
+		"category", categoryName,
+		"fusion", settings.fusion,
+		"filtered", opts.Filter != nil,
 	)
 
 	chunks, err := u.chunkRepo.HybridSearch(ctx, params)
 	if err != nil {
 		logger.LogError(ctx, "Failed to perform hybrid search in database", err,
-			"operation", "HybridSearch",
+			"operation", operation,
 			"limit", limit,
 			"minSimilarity", minSimilarity,
 			"keywordWeight", keywordWeight,
@@ -234,22 +272,32 @@ func (u *chunkUseCase) HybridSearch(c context.Context, queryText string, limit i
 		return d.Error[[]d.ChunkWithHybridSimilarity](u.cache, "ERR_INTERNAL_DB")
 	}
 
+	chunks = u.multiQuerySearch(c, ctx, operation, params, chunks, paraphrases, settings.rrfK)
+	traceSearch(opts.Trace, params, limit, embeddingText, paraphrases, strategy, chunks)
+	chunks = u.rerank(c, operation, queryText, chunks, u.rerankLimit(limit, settings))
+	chunks = u.diversify(ctx, operation, chunks, limit, settings)
+	chunks = u.expandNeighbors(ctx, operation, chunks, settings)
+	setQueryStrategy(chunks, strategy)
+	traceRerankScores(opts.Trace, chunks)
+
 	logger.LogInfo(ctx, "Hybrid search completed",
-		"operation", "HybridSearch",
+		"operation", operation,
 		"chunksFound", len(chunks),
 		"limit", limit,
 		"minSimilarity", minSimilarity,
 		"keywordWeight", keywordWeight,
+		"category", categoryName,
 	)
 
 	// Log details of each chunk found
 	if len(chunks) > 0 {
 		for i, chunk := range chunks {
 			logger.LogInfo(ctx, "Retrieved chunk",
-				"operation", "HybridSearch",
+				"operation", operation,
 				"position", i+1,
 				"chunkID", chunk.ID,
 				"docTitle", chunk.DocTitle,
+				"docCategory", chunk.DocCategory,
 				"similarityScore", chunk.SimilarityScore,
 				"keywordScore", chunk.KeywordScore,
 				"combinedScore", chunk.CombinedScore,
@@ -263,270 +311,420 @@ func (u *chunkUseCase) HybridSearch(c context.Context, queryText string, limit i
 		}
 	} else {
 		logger.LogWarn(ctx, "No chunks found matching criteria",
-			"operation", "HybridSearch",
+			"operation", operation,
 			"queryText", queryText,
 			"limit", limit,
 			"minSimilarity", minSimilarity,
 			"keywordWeight", keywordWeight,
+			"category", categoryName,
 		)
 	}
 
 	// Automatically update statistics for each retrieved chunk
 	// This happens asynchronously to not block the response
-	go u.updateHybridChunkStatistics(chunks)
+	if !opts.SkipStatistics {
+		go u.updateHybridChunkStatistics(chunks)
+	}
 
 	return d.Success(chunks)
 }
 
-// HybridSearchWithCategory performs hybrid search with optional document category filtering
-func (u *chunkUseCase) HybridSearchWithCategory(c context.Context, queryText string, limit int, minSimilarity float64, keywordWeight float64, category *string) d.Result[[]d.ChunkWithHybridSimilarity] {
-	logger.LogInfo(c, "Starting hybrid search with category filter",
-		"operation", "HybridSearchWithCategory",
-		"queryText", queryText,
-		"queryLength", len(queryText),
-		"limit", limit,
-		"minSimilarity", minSimilarity,
-		"keywordWeight", keywordWeight,
-		"category", func() string {
-			if category != nil {
-				return *category
-			}
-			return "none"
-		}(),
-	)
+// queryStrategy resolves the query strategy: per-request override, then QUERY_STRATEGY_CONFIG for the
+// category, then its "default" (the only one that applies to searches without a category)
+func (u *chunkUseCase) queryStrategy(category *string, opts d.RetrievalOptions) string {
+	if opts.QueryStrategy != nil && *opts.QueryStrategy != "" {
+		return *opts.QueryStrategy
+	}
+	if u.queryExpander == nil {
+		return d.QueryStrategyNone
+	}
+	if category != nil {
+		return u.queryExpander.Strategy(*category)
+	}
+	return u.queryExpander.Strategy("")
+}
 
-	// Use longer timeout for embedding generation (OpenAI can be slow)
-	embeddingCtx, embeddingCancel := context.WithTimeout(c, 30*time.Second)
-	defer embeddingCancel()
+// prepareQuery runs the LLM step of the strategy and returns the text to embed and the paraphrases to search.
+// An LLM failure is not fatal: the search falls back to the plain question.
+func (u *chunkUseCase) prepareQuery(c context.Context, operation string, queryText string, strategy string) (string, []string, string) {
+	if u.queryExpander == nil || (strategy != d.QueryStrategyMultiQuery && strategy != d.QueryStrategyHyDE) {
+		return queryText, nil, d.QueryStrategyNone
+	}
 
-	// Generate embedding from query text
-	logger.LogInfo(embeddingCtx, "Generating embedding for hybrid search query with category filter",
-		"operation", "HybridSearchWithCategory",
-		"queryText", queryText,
-	)
-	queryEmbedding, err := u.embeddingService.GenerateEmbedding(embeddingCtx, queryText)
-	if err != nil {
-		logger.LogError(embeddingCtx, "Failed to generate embedding for hybrid search", err,
-			"operation", "HybridSearchWithCategory",
-			"queryTextLength", len(queryText),
+	// Use longer timeout for the LLM call
+	llmCtx, llmCancel := context.WithTimeout(c, 30*time.Second)
+	defer llmCancel()
+
+	if strategy == d.QueryStrategyHyDE {
+		answer, err := u.queryExpander.HypotheticalAnswer(llmCtx, queryText)
+		if err != nil {
+			logger.LogWarn(llmCtx, "HyDE failed, searching with the question",
+				"operation", operation,
+				"error", err.Error(),
+			)
+			return queryText, nil, d.QueryStrategyNone
+		}
+		logger.LogInfo(llmCtx, "Generated hypothetical answer for HyDE",
+			"operation", operation,
+			"answerLength", len(answer),
 		)
-		return d.Error[[]d.ChunkWithHybridSimilarity](u.cache, "ERR_EMBEDDING_GENERATION")
+		return answer, nil, strategy
 	}
 
-	// Use normal timeout for database operations
-	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
-	defer cancel()
+	paraphrases, err := u.queryExpander.Paraphrases(llmCtx, queryText)
+	if err != nil {
+		logger.LogWarn(llmCtx, "Multi-query failed, searching with the question only",
+			"operation", operation,
+			"error", err.Error(),
+		)
+		return queryText, nil, d.QueryStrategyNone
+	}
+	logger.LogInfo(llmCtx, "Generated paraphrases for multi-query",
+		"operation", operation,
+		"paraphrases", paraphrases,
+	)
+	return queryText, paraphrases, strategy
+}
 
-	// Create params with generated embedding, query text, and category filter
-	params := d.HybridSearchParams{
-		QueryEmbedding: pgvector.NewVector(queryEmbedding),
-		QueryText:      queryText,
-		Limit:          limit,
-		MinSimilarity:  minSimilarity,
-		KeywordWeight:  keywordWeight,
-		Category:       category,
+// multiQuerySearch runs the search for each paraphrase and fuses the result lists of all
+// queries with Reciprocal Rank Fusion. Failed paraphrase searches are skipped.
+func (u *chunkUseCase) multiQuerySearch(c context.Context, ctx context.Context, operation string, params d.HybridSearchParams, chunks []d.ChunkWithHybridSimilarity, paraphrases []string, rrfK int) []d.ChunkWithHybridSimilarity {
+	if len(paraphrases) == 0 {
+		return chunks
 	}
 
-	logger.LogInfo(ctx, "Performing database hybrid search with category filter",
-		"operation", "HybridSearchWithCategory",
-		"limit", limit,
-		"minSimilarity", minSimilarity,
-		"keywordWeight", keywordWeight,
-		"category", func() string {
-			if category != nil {
-				return *category
-			}
-			return "none"
-		}(),
-	)
+	// Use longer timeout for embedding generation (OpenAI can be slow)
+	embeddingCtx, embeddingCancel := context.WithTimeout(c, 30*time.Second)
+	defer embeddingCancel()
 
-	chunks, err := u.chunkRepo.HybridSearch(ctx, params)
+	embeddings, err := u.embeddingService.GenerateEmbeddings(embeddingCtx, paraphrases)
 	if err != nil {
-		logger.LogError(ctx, "Failed to perform hybrid search with category filter in database", err,
-			"operation", "HybridSearchWithCategory",
-			"limit", limit,
-			"minSimilarity", minSimilarity,
-			"keywordWeight", keywordWeight,
+		logger.LogWarn(embeddingCtx, "Failed to embed paraphrases, keeping the question results",
+			"operation", operation,
+			"error", err.Error(),
 		)
-		return d.Error[[]d.ChunkWithHybridSimilarity](u.cache, "ERR_INTERNAL_DB")
+		return chunks
 	}
 
-	logger.LogInfo(ctx, "Hybrid search with category filter completed",
-		"operation", "HybridSearchWithCategory",
-		"chunksFound", len(chunks),
-		"limit", limit,
-		"minSimilarity", minSimilarity,
-		"keywordWeight", keywordWeight,
-		"category", func() string {
-			if category != nil {
-				return *category
-			}
-			return "none"
-		}(),
-	)
-
-	// Log details of each chunk found
-	if len(chunks) > 0 {
-		for i, chunk := range chunks {
-			logger.LogInfo(ctx, "Retrieved chunk",
-				"operation", "HybridSearchWithCategory",
-				"position", i+1,
-				"chunkID", chunk.ID,
-				"docTitle", chunk.DocTitle,
-				"docCategory", chunk.DocCategory,
-				"similarityScore", chunk.SimilarityScore,
-				"keywordScore", chunk.KeywordScore,
-				"combinedScore", chunk.CombinedScore,
-				"contentPreview", func() string {
-					if len(chunk.Content) > 100 {
-						return chunk.Content[:100] + "..."
-					}
-					return chunk.Content
-				}(),
+	queries := []string{params.QueryText}
+	lists := [][]d.ChunkWithHybridSimilarity{chunks}
+	for i, paraphrase := range paraphrases {
+		if embeddings[i] == nil {
+			continue
+		}
+		paraphraseParams := params
+		paraphraseParams.QueryText = paraphrase
+		paraphraseParams.QueryEmbedding = pgvector.NewVector(embeddings[i])
+
+		results, err := u.chunkRepo.HybridSearch(ctx, paraphraseParams)
+		if err != nil {
+			logger.LogWarn(ctx, "Paraphrase search failed, skipping it",
+				"operation", operation,
+				"paraphrase", paraphrase,
+				"error", err.Error(),
 			)
+			continue
 		}
-	} else {
-		logger.LogWarn(ctx, "No chunks found matching criteria with category filter",
-			"operation", "HybridSearchWithCategory",
-			"queryText", queryText,
-			"limit", limit,
-			"minSimilarity", minSimilarity,
-			"keywordWeight", keywordWeight,
-			"category", func() string {
-				if category != nil {
-					return *category
-				}
-				return "none"
-			}(),
-		)
+		queries = append(queries, paraphrase)
+		lists = append(lists, results)
 	}
 
-	// Automatically update statistics for each retrieved chunk
-	// This happens asynchronously to not block the response
-	go u.updateHybridChunkStatistics(chunks)
+	fused := retrieval.FuseRankings(lists, queries, rrfK, params.Limit)
 
-	return d.Success(chunks)
+	logger.LogInfo(ctx, "Fused multi-query results",
+		"operation", operation,
+		"queries", len(queries),
+		"fused", len(fused),
+	)
+	return fused
 }
 
-// updateHybridChunkStatistics updates usage statistics for hybrid search results
-func (u *chunkUseCase) updateHybridChunkStatistics(chunks []d.ChunkWithHybridSimilarity) {
-	asyncCtx, asyncCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer asyncCancel()
-
-	// Build metrics input from retrieved chunks
-	metricsChunks := make([]metrics.RetrievedChunk, len(chunks))
-	relevanceThreshold := 0.75 // Chunks with similarity >= 0.75 are considered relevant
+// setQueryStrategy records the query strategy on the results
+func setQueryStrategy(chunks []d.ChunkWithHybridSimilarity, strategy string) {
+	if strategy == d.QueryStrategyNone {
+		return
+	}
+	for i := range chunks {
+		chunks[i].QueryStrategy = strategy
+	}
+}
 
-	for i, chunk := range chunks {
-		metricsChunks[i] = metrics.RetrievedChunk{
+// traceSearch records the query, the settings and the database candidates of a search in the trace
+func traceSearch(trace *d.RetrievalTrace, params d.HybridSearchParams, limit int, embeddingText string, paraphrases []string, strategy string, candidates []d.ChunkWithHybridSimilarity) {
+	if trace == nil {
+		return
+	}
+	trace.Query = params.QueryText
+	trace.EmbeddedQuery = embeddingText
+	trace.Paraphrases = paraphrases
+	trace.QueryStrategy = strategy
+	trace.Category = params.Category
+	trace.Filter = params.Filter
+	trace.Role = params.Role
+	trace.RecencyWeight = params.RecencyWeight
+	trace.Limit = limit
+	trace.MinSimilarity = params.MinSimilarity
+	trace.KeywordWeight = params.KeywordWeight
+	trace.Fusion = params.Fusion
+	trace.Candidates = make([]d.TraceCandidate, len(candidates))
+	for i, chunk := range candidates {
+		trace.Candidates[i] = d.TraceCandidate{
 			ChunkID:         chunk.ID,
-			SimilarityScore: chunk.CombinedScore, // Use combined score for relevance
-			Position:        i + 1,               // 1-based position
-			IsRelevant:      metrics.EstimateRelevanceFromSimilarity(chunk.CombinedScore, relevanceThreshold),
+			DocumentID:      chunk.DocumentID,
+			SimilarityScore: chunk.SimilarityScore,
+			KeywordScore:    chunk.KeywordScore,
+			CombinedScore:   chunk.CombinedScore,
 		}
 	}
+}
 
-	// Count total relevant chunks (those above threshold)
-	totalRelevant := 0
-	for _, mc := range metricsChunks {
-		if mc.IsRelevant {
-			totalRelevant++
+// traceRerankScores copies the rerank scores of the results to the trace candidates
+func traceRerankScores(trace *d.RetrievalTrace, results []d.ChunkWithHybridSimilarity) {
+	if trace == nil {
+		return
+	}
+	scores := make(map[int]*float64, len(results))
+	for _, chunk := range results {
+		if chunk.RerankScore != nil {
+			scores[chunk.ID] = chunk.RerankScore
 		}
 	}
+	for i := range trace.Candidates {
+		trace.Candidates[i].RerankScore = scores[trace.Candidates[i].ChunkID]
+	}
+}
 
-	// Calculate all quality metrics
-	result := u.metricsCalc.CalculateAllMetrics(metricsChunks, totalRelevant)
+// retrievalSettings holds the resolved fusion and diversity settings of a hybrid search
+type retrievalSettings struct {
+	fusion              string
+	rrfK                int
+	diversity           retrieval.DiversityOptions
+	neighborWindow      int
+	recencyWeight       float64
+	recencyHalfLifeDays float64
+}
 
-	// Update each chunk's statistics
-	for i, chunk := range chunks {
-		chunkID := chunk.ID
-		position := i + 1
-
-		// Increment usage count
-		_, _ = u.statsRepo.IncrementUsage(asyncCtx, chunkID)
-
-		// Only update quality metrics for chunks in top positions (more accurate)
-		// Update metrics more aggressively for top 3 results
-		if position <= 3 {
-			// Calculate individual chunk metrics
-			// Use exponential moving average to smooth metrics over time
-			params := d.UpdateChunkQualityMetricsParams{
-				ChunkID: chunkID,
-			}
+// retrievalSettings applies the per-request overrides on top of the global RAG parameters
+func (u *chunkUseCase) retrievalSettings(opts d.RetrievalOptions) retrievalSettings {
+	settings := retrievalSettings{
+		fusion: u.getParamString("RAG_FUSION_STRATEGY", d.FusionWeighted),
+		rrfK:   int(u.getParamFloat("RAG_RRF_K", 60)),
+		diversity: retrieval.DiversityOptions{
+			MMR:                  u.getParamBool("RAG_MMR_ENABLED", false),
+			Lambda:               u.getParamFloat("RAG_MMR_LAMBDA", 0.7),
+			MaxChunksPerDocument: int(u.getParamFloat("RAG_MAX_CHUNKS_PER_DOCUMENT", 0)),
+		},
+		neighborWindow:      int(u.getParamFloat("RAG_NEIGHBOR_WINDOW", 0)),
+		recencyWeight:       u.getParamFloat("RAG_RECENCY_WEIGHT", 0),
+		recencyHalfLifeDays: u.getParamFloat("RAG_RECENCY_HALF_LIFE_DAYS", 180),
+	}
 
-			// For top-ranked chunks, update with calculated metrics
-			if metricsChunks[i].IsRelevant {
-				params.PrecisionAtK = &result.PrecisionAtK
-				params.RecallAtK = &result.RecallAtK
-				params.F1AtK = &result.F1AtK
-				params.MRR = &result.MRR
-				params.MAP = &result.MAP
-				params.NDCG = &result.NDCG
+	if opts.Fusion != nil {
+		settings.fusion = *opts.Fusion
+	}
+	if opts.RRFK != nil {
+		settings.rrfK = *opts.RRFK
+	}
+	if opts.MMR != nil {
+		settings.diversity.MMR = *opts.MMR
+	}
+	if opts.MMRLambda != nil {
+		settings.diversity.Lambda = *opts.MMRLambda
+	}
+	if opts.MaxChunksPerDocument != nil {
+		settings.diversity.MaxChunksPerDocument = *opts.MaxChunksPerDocument
+	}
+	if opts.NeighborWindow != nil {
+		settings.neighborWindow = *opts.NeighborWindow
+	}
+	if opts.RecencyWeight != nil {
+		settings.recencyWeight = *opts.RecencyWeight
+	}
 
-				_, _ = u.statsRepo.UpdateQualityMetrics(asyncCtx, params)
-			}
-		}
+	if settings.fusion != d.FusionRRF {
+		settings.fusion = d.FusionWeighted
+	}
+	if settings.rrfK <= 0 {
+		settings.rrfK = 60
 	}
+	if settings.diversity.Lambda < 0 || settings.diversity.Lambda > 1 {
+		settings.diversity.Lambda = 0.7
+	}
+	if settings.recencyWeight < 0 {
+		settings.recencyWeight = 0
+	}
+	if settings.recencyHalfLifeDays <= 0 {
+		settings.recencyHalfLifeDays = 180
+	}
+	return settings
 }
 
-// updateChunkStatistics updates usage statistics and calculates quality metrics
-func (u *chunkUseCase) updateChunkStatistics(chunks []d.ChunkWithSimilarity) {
-	asyncCtx, asyncCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer asyncCancel()
+// diversifies reports whether the results go through the diversity pass
+func (s retrievalSettings) diversifies() bool {
+	return s.diversity.MMR || s.diversity.MaxChunksPerDocument > 0
+}
+
+// fetchLimit returns how many hybrid search results to fetch for the reranking and diversity stages
+func (u *chunkUseCase) fetchLimit(limit int, settings retrievalSettings) int {
+	fetch := u.candidateLimit(limit)
+	if settings.diversifies() {
+		fetch = max(fetch, limit*3) // Enough candidates to replace near-duplicates
+	}
+	return fetch
+}
+
+// rerankLimit returns how many reranked candidates to keep (all of them when the diversity pass follows)
+func (u *chunkUseCase) rerankLimit(limit int, settings retrievalSettings) int {
+	if settings.diversifies() {
+		return u.fetchLimit(limit, settings)
+	}
+	return limit
+}
 
-	// Build metrics input from retrieved chunks
-	metricsChunks := make([]metrics.RetrievedChunk, len(chunks))
-	relevanceThreshold := 0.75 // Chunks with similarity >= 0.75 are considered relevant
+// diversify applies the MMR pass and the per-document cap, keeping at most limit chunks
+func (u *chunkUseCase) diversify(ctx context.Context, operation string, chunks []d.ChunkWithHybridSimilarity, limit int, settings retrievalSettings) []d.ChunkWithHybridSimilarity {
+	if !settings.diversifies() {
+		return chunks
+	}
+
+	candidates := len(chunks)
+	chunks = retrieval.Diversify(chunks, limit, settings.diversity)
+
+	logger.LogInfo(ctx, "Applied diversity pass to hybrid search results",
+		"operation", operation,
+		"candidates", candidates,
+		"kept", len(chunks),
+		"mmr", settings.diversity.MMR,
+		"mmrLambda", settings.diversity.Lambda,
+		"maxChunksPerDocument", settings.diversity.MaxChunksPerDocument,
+	)
+	return chunks
+}
+
+// expandNeighbors replaces each hit's content with the hit and its ±N neighbouring chunks.
+// A lookup failure is not fatal: the hits are returned unexpanded.
+func (u *chunkUseCase) expandNeighbors(ctx context.Context, operation string, chunks []d.ChunkWithHybridSimilarity, settings retrievalSettings) []d.ChunkWithHybridSimilarity {
+	if settings.neighborWindow <= 0 || len(chunks) == 0 {
+		return chunks
+	}
 
+	chunkIDs := make([]int, len(chunks))
 	for i, chunk := range chunks {
-		metricsChunks[i] = metrics.RetrievedChunk{
-			ChunkID:         chunk.ID,
-			SimilarityScore: chunk.SimilarityScore,
-			Position:        i + 1, // 1-based position
-			IsRelevant:      metrics.EstimateRelevanceFromSimilarity(chunk.SimilarityScore, relevanceThreshold),
+		chunkIDs[i] = chunk.ID
+	}
+
+	neighbors, err := u.chunkRepo.GetNeighbors(ctx, chunkIDs, settings.neighborWindow)
+	if err != nil {
+		logger.LogWarn(ctx, "Neighbour expansion failed, keeping hits unexpanded",
+			"operation", operation,
+			"neighborWindow", settings.neighborWindow,
+			"error", err.Error(),
+		)
+		return chunks
+	}
+
+	hits := len(chunks)
+	chunks = retrieval.ExpandNeighbors(chunks, neighbors)
+
+	logger.LogInfo(ctx, "Expanded hybrid search hits with neighbouring chunks",
+		"operation", operation,
+		"hits", hits,
+		"kept", len(chunks),
+		"neighborWindow", settings.neighborWindow,
+		"neighborsLoaded", len(neighbors),
+	)
+	return chunks
+}
+
+func (u *chunkUseCase) getParamString(code string, defaultValue string) string {
+	if data, exists := u.cache.GetValue(code); exists {
+		if val, ok := data["value"].(string); ok && val != "" {
+			return val
 		}
 	}
+	return defaultValue
+}
 
-	// Count total relevant chunks (those above threshold)
-	totalRelevant := 0
-	for _, mc := range metricsChunks {
-		if mc.IsRelevant {
-			totalRelevant++
+func (u *chunkUseCase) getParamFloat(code string, defaultValue float64) float64 {
+	if data, exists := u.cache.GetValue(code); exists {
+		if val, ok := data["value"].(float64); ok {
+			return val
 		}
 	}
+	return defaultValue
+}
 
-	// Calculate all quality metrics
-	result := u.metricsCalc.CalculateAllMetrics(metricsChunks, totalRelevant)
+func (u *chunkUseCase) getParamBool(code string, defaultValue bool) bool {
+	if data, exists := u.cache.GetValue(code); exists {
+		if val, ok := data["value"].(bool); ok {
+			return val
+		}
+	}
+	return defaultValue
+}
 
-	// Update each chunk's statistics
-	for i, chunk := range chunks {
-		chunkID := chunk.ID
-		position := i + 1
-
-		// Increment usage count
-		_, _ = u.statsRepo.IncrementUsage(asyncCtx, chunkID)
-
-		// Only update quality metrics for chunks in top positions (more accurate)
-		// Update metrics more aggressively for top 3 results
-		if position <= 3 {
-			// Calculate individual chunk metrics
-			// Use exponential moving average to smooth metrics over time
-			params := d.UpdateChunkQualityMetricsParams{
-				ChunkID: chunkID,
-			}
+// candidateLimit returns how many hybrid search results to fetch for the reranking stage
+func (u *chunkUseCase) candidateLimit(limit int) int {
+	if u.reranker == nil {
+		return limit
+	}
+	return u.reranker.CandidateLimit(limit)
+}
 
-			// For top-ranked chunks, update with calculated metrics
-			if metricsChunks[i].IsRelevant {
-				params.PrecisionAtK = &result.PrecisionAtK
-				params.RecallAtK = &result.RecallAtK
-				params.F1AtK = &result.F1AtK
-				params.MRR = &result.MRR
-				params.MAP = &result.MAP
-				params.NDCG = &result.NDCG
+// rerank rescores the over-fetched candidates and keeps the best limit.
+// A reranker failure is not fatal: the hybrid search order is kept.
+func (u *chunkUseCase) rerank(c context.Context, operation string, queryText string, chunks []d.ChunkWithHybridSimilarity, limit int) []d.ChunkWithHybridSimilarity {
+	if u.reranker == nil {
+		return chunks
+	}
 
-				_, _ = u.statsRepo.UpdateQualityMetrics(asyncCtx, params)
-			}
-		}
+	// Use longer timeout for reranking (LLM reranker can be slow)
+	rerankCtx, rerankCancel := context.WithTimeout(c, 30*time.Second)
+	defer rerankCancel()
+
+	candidates := len(chunks)
+	reranked, err := u.reranker.Rerank(rerankCtx, queryText, chunks, limit)
+	if err != nil {
+		logger.LogWarn(rerankCtx, "Reranking failed, keeping hybrid search order",
+			"operation", operation,
+			"candidates", candidates,
+			"error", err.Error(),
+		)
+		return reranked
+	}
+
+	if len(reranked) == 0 || reranked[0].RerankScore == nil {
+		return reranked // Reranking disabled
+	}
+
+	logger.LogInfo(rerankCtx, "Reranked hybrid search candidates",
+		"operation", operation,
+		"candidates", candidates,
+		"kept", len(reranked),
+	)
+	return reranked
+}
+
+// updateHybridChunkStatistics updates usage statistics for hybrid search results.
+// Quality metrics (precision, recall, MRR...) are derived from answer feedback in the
+// database (fn_refresh_chunk_feedback_metrics), not from the search scores.
+func (u *chunkUseCase) updateHybridChunkStatistics(chunks []d.ChunkWithHybridSimilarity) {
+	asyncCtx, asyncCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer asyncCancel()
+
+	for _, chunk := range chunks {
+		_, _ = u.statsRepo.IncrementUsage(asyncCtx, chunk.ID)
+	}
+}
+
+// updateChunkStatistics updates usage statistics for similarity search results
+// (quality metrics come from answer feedback, see updateHybridChunkStatistics)
+func (u *chunkUseCase) updateChunkStatistics(chunks []d.ChunkWithSimilarity) {
+	asyncCtx, asyncCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer asyncCancel()
+
+	for _, chunk := range chunks {
+		_, _ = u.statsRepo.IncrementUsage(asyncCtx, chunk.ID)
 	}
 }
 
@@ -642,13 +840,53 @@ func (u *chunkUseCase) Delete(c context.Context, chunkID int) d.Result[d.Data] {
 }
 
 func (u *chunkUseCase) BulkCreate(c context.Context, documentID int, contents []string) d.Result[d.Data] {
-	ctx, cancel := context.WithTimeout(c, 60*time.Second)
+	return u.BulkCreateWithHeadings(c, documentID, contents, nil)
+}
+
+func (u *chunkUseCase) BulkCreateWithHeadings(c context.Context, documentID int, contents []string, headings [][]string) d.Result[d.Data] {
+	return u.bulkCreate(c, "BulkCreate", documentID, contents, headings, false)
+}
+
+func (u *chunkUseCase) ReplaceWithHeadings(c context.Context, documentID int, contents []string, headings [][]string) d.Result[d.Data] {
+	return u.bulkCreate(c, "ReplaceChunks", documentID, contents, headings, true)
+}
+
+// bulkCreate embeds the contents and stores them as chunks of the document; with replace the
+// previous chunks are deleted in the same transaction, so a failed embedding leaves them intact
+func (u *chunkUseCase) bulkCreate(c context.Context, operation string, documentID int, contents []string, headings [][]string, replace bool) d.Result[d.Data] {
+	// Large documents need several embedding sub-batches
+	ctx, cancel := context.WithTimeout(c, 5*time.Minute)
 	defer cancel()
 
+	// Blank chunks have no embedding; drop them so contents, headings and embeddings stay aligned
+	nonBlank := make([]string, 0, len(contents))
+	var nonBlankHeadings [][]string
+	if headings != nil {
+		nonBlankHeadings = make([][]string, 0, len(contents))
+	}
+	for i, content := range contents {
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		nonBlank = append(nonBlank, content)
+		if headings != nil {
+			var path []string
+			if i < len(headings) {
+				path = headings[i]
+			}
+			nonBlankHeadings = append(nonBlankHeadings, path)
+		}
+	}
+	contents = nonBlank
+	headings = nonBlankHeadings
+	if len(contents) == 0 {
+		return d.Success(d.Data{"chunksCreated": 0})
+	}
+
 	embeddingsFloat32, err := u.embeddingService.GenerateEmbeddings(ctx, contents)
 	if err != nil {
 		logger.LogError(ctx, "Failed to generate embeddings for bulk chunk creation", err,
-			"operation", "BulkCreate",
+			"operation", operation,
 			"documentID", documentID,
 			"chunksCount", len(contents),
 		)
@@ -665,12 +903,14 @@ func (u *chunkUseCase) BulkCreate(c context.Context, documentID int, contents []
 		DocumentID: documentID,
 		Contents:   contents,
 		Embeddings: &vectorEmbeddings,
+		Headings:   headings,
+		Replace:    replace,
 	}
 
 	result, err := u.chunkRepo.BulkCreate(ctx, params)
 	if err != nil || result == nil {
 		logger.LogError(ctx, "Failed to bulk create chunks in database", err,
-			"operation", "BulkCreate",
+			"operation", operation,
 			"documentID", documentID,
 			"chunksCount", len(contents),
 		)
@@ -679,7 +919,7 @@ func (u *chunkUseCase) BulkCreate(c context.Context, documentID int, contents []
 
 	if !result.Success {
 		logger.LogWarn(ctx, "Bulk chunk creation failed with business logic error",
-			"operation", "BulkCreate",
+			"operation", operation,
 			"code", result.Code,
 			"documentID", documentID,
 			"chunksCount", len(contents),
@@ -198,5 +198,55 @@ func (u *conversationUseCase) StoreMessageWithStats(c context.Context, params d.
 		return d.Error[d.Data](u.paramCache, result.Code)
 	}
 
+	// Link the answer to its chunks so feedback on it reaches the chunk quality metrics
+	if len(params.ChunkIDs) > 0 && result.MessageID != nil {
+		linkResult, err := u.convRepo.LinkMessageChunks(ctx, *result.MessageID, params.ChunkIDs, params.ChunkScores)
+		if err != nil || linkResult == nil {
+			logger.LogError(ctx, "Failed to link message to its chunks in database", err,
+				"operation", "StoreMessageWithStats",
+				"messageID", *result.MessageID,
+				"chunks", len(params.ChunkIDs),
+			)
+		} else if !linkResult.Success {
+			logger.LogWarn(ctx, "Message chunk link failed with business logic error",
+				"operation", "StoreMessageWithStats",
+				"code", linkResult.Code,
+				"messageID", *result.MessageID,
+			)
+		}
+	}
+
 	return d.Success(d.Data{"messageId": result.MessageID})
 }
+
+func (u *conversationUseCase) RequestHandoff(c context.Context, params d.RequestHandoffParams) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.convRepo.RequestHandoff(ctx, params)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to request conversation handoff in database", err,
+			"operation", "RequestHandoff",
+			"conversationID", params.ConversationID,
+			"reason", params.Reason,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Conversation handoff failed with business logic error",
+			"operation", "RequestHandoff",
+			"code", result.Code,
+			"conversationID", params.ConversationID,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	logger.LogInfo(ctx, "Conversation handed off to an admin",
+		"operation", "RequestHandoff",
+		"conversationID", params.ConversationID,
+		"reason", params.Reason,
+	)
+
+	return d.Success(d.Data{"conversationId": params.ConversationID, "reason": params.Reason})
+}
@@ -1,16 +1,31 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	d "api-chatbot/domain"
 	"api-chatbot/internal/logger"
+	"api-chatbot/internal/officeprocessor"
 	"api-chatbot/internal/pdfprocessor"
+	"api-chatbot/internal/tableprocessor"
 	"api-chatbot/internal/textchunker"
+
+	"golang.org/x/text/encoding/charmap"
 )
 
+// tableStrategy names the row-per-chunk rendering of spreadsheets and CSV files in previews
+const tableStrategy = "table"
+
+var errUnsupportedFileType = errors.New("unsupported file type")
+
 type documentUseCase struct {
 	docRepo        d.DocumentRepository
 	chunkUseCase   d.ChunkUseCase
@@ -112,6 +127,9 @@ func (u *documentUseCase) Create(c context.Context, params d.CreateDocumentParam
 	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
 	defer cancel()
 
+	params.Tags = normalizeTags(params.Tags)
+	params.Audience = normalizeAudience(params.Audience)
+
 	result, err := u.docRepo.Create(ctx, params)
 	if err != nil || result == nil {
 		logger.LogError(ctx, "Failed to create document in database", err,
@@ -138,6 +156,9 @@ func (u *documentUseCase) Update(c context.Context, params d.UpdateDocumentParam
 	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
 	defer cancel()
 
+	params.Tags = normalizeTags(params.Tags)
+	params.Audience = normalizeAudience(params.Audience)
+
 	result, err := u.docRepo.Update(ctx, params)
 	if err != nil || result == nil {
 		logger.LogError(ctx, "Failed to update document in database", err, params)
@@ -181,102 +202,251 @@ func (u *documentUseCase) Delete(c context.Context, docID int) d.Result[d.Data]
 	return d.Success(d.Data{})
 }
 
+func (u *documentUseCase) ExpireDocuments(c context.Context) d.Result[[]d.ExpiredDocument] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.docRepo.ExpireDocuments(ctx)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to expire documents in database", err,
+			"operation", "ExpireDocuments",
+		)
+		return d.Error[[]d.ExpiredDocument](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Document expiry failed with business logic error",
+			"operation", "ExpireDocuments",
+			"code", result.Code,
+		)
+		return d.Error[[]d.ExpiredDocument](u.paramCache, result.Code)
+	}
+
+	if len(result.Expired) > 0 {
+		logger.LogInfo(ctx, "Expired documents deactivated",
+			"operation", "ExpireDocuments",
+			"count", len(result.Expired),
+		)
+	}
+
+	return d.Success(result.Expired)
+}
+
 func (u *documentUseCase) UploadPDF(c context.Context, params d.UploadPDFDocumentParams) d.Result[d.Data] {
-	// Use longer timeout for PDF processing
-	ctx, cancel := context.WithTimeout(c, 2*time.Minute)
+	return u.UploadDocument(c, d.UploadDocumentParams{
+		Category:     params.Category,
+		Title:        params.Title,
+		Source:       params.Source,
+		Tags:         params.Tags,
+		Audience:     params.Audience,
+		ValidFrom:    params.ValidFrom,
+		ValidUntil:   params.ValidUntil,
+		FileBase64:   params.FileBase64,
+		FileType:     d.FileTypePDF,
+		ChunkSize:    params.ChunkSize,
+		ChunkOverlap: &params.ChunkOverlap,
+	})
+}
+
+// UploadDocument extracts the text of a PDF, DOCX, ODT, Markdown or plain-text file and runs it
+// through the same create, chunk and embed flow as the other documents. Spreadsheets and CSV
+// files are imported as tables with the default mapping.
+func (u *documentUseCase) UploadDocument(c context.Context, params d.UploadDocumentParams) d.Result[d.Data] {
+	// Use longer timeout for file processing
+	ctx, cancel := context.WithTimeout(c, 5*time.Minute)
 	defer cancel()
 
-	logger.LogInfo(ctx, "Starting PDF upload",
-		"operation", "UploadPDF",
+	logger.LogInfo(ctx, "Starting document upload",
+		"operation", "UploadDocument",
 		"title", params.Title,
 		"category", params.Category,
+		"fileType", params.FileType,
 		"chunkSize", params.ChunkSize,
 		"chunkOverlap", params.ChunkOverlap,
 	)
 
-	// Step 1: Extract text from PDF
-	text, err := pdfprocessor.ExtractTextFromBase64PDF(params.FileBase64)
+	// Step 1: Extract text from the file
+	fileData, err := base64.StdEncoding.DecodeString(params.FileBase64)
 	if err != nil {
-		logger.LogError(ctx, "Failed to extract text from PDF", err,
-			"operation", "UploadPDF",
+		logger.LogWarn(ctx, "Uploaded file is not valid base64",
+			"operation", "UploadDocument",
+			"code", processingErrorCode(params.FileType),
 			"title", params.Title,
 		)
-		return d.Error[d.Data](u.paramCache, "ERR_PDF_PROCESSING")
+		return d.Error[d.Data](u.paramCache, processingErrorCode(params.FileType))
 	}
 
-	// Generate summary from first 500 characters
-	summary := text
-	if len(text) > 500 {
-		summary = text[:500] + "..."
+	fileType := resolveFileType(params.FileType, params.FileName, fileData)
+	if fileType == d.FileTypeXLSX || fileType == d.FileTypeCSV {
+		// Tables are imported row by row rather than as running text
+		return u.ImportTable(c, d.ImportTableParams{
+			Category:   params.Category,
+			Title:      params.Title,
+			Source:     params.Source,
+			Tags:       params.Tags,
+			Audience:   params.Audience,
+			ValidFrom:  params.ValidFrom,
+			ValidUntil: params.ValidUntil,
+			FileBase64: params.FileBase64,
+			FileType:   fileType,
+			ChunkSize:  params.ChunkSize,
+		})
 	}
 
-	logger.LogInfo(ctx, "Text extracted from PDF",
-		"operation", "UploadPDF",
+	text, err := extractText(fileType, params.FileBase64, fileData)
+	if errors.Is(err, errUnsupportedFileType) {
+		logger.LogWarn(ctx, "Unsupported document file type",
+			"operation", "UploadDocument",
+			"code", "ERR_UNSUPPORTED_FILE_TYPE",
+			"fileType", params.FileType,
+			"title", params.Title,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_UNSUPPORTED_FILE_TYPE")
+	}
+	if err != nil {
+		logger.LogError(ctx, "Failed to extract text from document", err,
+			"operation", "UploadDocument",
+			"fileType", fileType,
+			"title", params.Title,
+		)
+		return d.Error[d.Data](u.paramCache, processingErrorCode(fileType))
+	}
+
+	logger.LogInfo(ctx, "Text extracted from document",
+		"operation", "UploadDocument",
+		"fileType", fileType,
 		"textLength", len(text),
 		"title", params.Title,
 	)
 
-	// Step 2: Create the document
 	docParams := d.CreateDocumentParams{
-		Category: params.Category,
-		Title:    params.Title,
-		Summary:  &summary,
-		Source:   params.Source,
+		Category:   params.Category,
+		Title:      params.Title,
+		Source:     params.Source,
+		Tags:       params.Tags,
+		Audience:   params.Audience,
+		ValidFrom:  params.ValidFrom,
+		ValidUntil: params.ValidUntil,
 	}
+	chunking := chunkingOptions(u.paramCache, params.Category, fileType, params.ChunkStrategy, params.ChunkSize, params.ChunkOverlap)
+	return u.createWithChunks(ctx, "UploadDocument", docParams, fileType, text, chunking)
+}
+
+func (u *documentUseCase) CreateFromText(c context.Context, params d.CreateTextDocumentParams) d.Result[d.Data] {
+	// Embedding every chunk of a long text takes a while
+	ctx, cancel := context.WithTimeout(c, 5*time.Minute)
+	defer cancel()
+
+	format := params.Format
+	if format == "" {
+		format = d.FileTypeMarkdown
+	}
+
+	logger.LogInfo(ctx, "Creating document from text",
+		"operation", "CreateDocumentFromText",
+		"title", params.Title,
+		"category", params.Category,
+		"format", format,
+		"textLength", len(params.Content),
+	)
+
+	docParams := d.CreateDocumentParams{
+		Category:   params.Category,
+		Title:      params.Title,
+		Source:     params.Source,
+		Tags:       params.Tags,
+		Audience:   params.Audience,
+		ValidFrom:  params.ValidFrom,
+		ValidUntil: params.ValidUntil,
+	}
+	chunking := chunkingOptions(u.paramCache, params.Category, format, params.ChunkStrategy, params.ChunkSize, params.ChunkOverlap)
+	return u.createWithChunks(ctx, "CreateDocumentFromText", docParams, format, params.Content, chunking)
+}
+
+// createWithChunks creates a document and stores its text as embedded chunks, split with the
+// given strategy; the headings strategy stores the heading path of each chunk
+func (u *documentUseCase) createWithChunks(ctx context.Context, operation string, docParams d.CreateDocumentParams, fileType, text string, chunking textchunker.Options) d.Result[d.Data] {
+	// Generate summary from first 500 characters
+	summary := text
+	if len(text) > 500 {
+		summary = text[:500] + "..."
+	}
+
+	// Step 2: Create the document
+	docParams.Summary = &summary
+	docParams.Tags = normalizeTags(docParams.Tags)
+	docParams.Audience = normalizeAudience(docParams.Audience)
 
 	docResult, err := u.docRepo.Create(ctx, docParams)
 	if err != nil || docResult == nil {
 		logger.LogError(ctx, "Failed to create document in database", err,
-			"operation", "UploadPDF",
-			"title", params.Title,
+			"operation", operation,
+			"title", docParams.Title,
 		)
 		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
 	}
 
 	if !docResult.Success {
 		logger.LogWarn(ctx, "Document creation failed with business logic error",
-			"operation", "UploadPDF",
+			"operation", operation,
 			"code", docResult.Code,
-			"title", params.Title,
+			"title", docParams.Title,
 		)
 		return d.Error[d.Data](u.paramCache, docResult.Code)
 	}
 
 	docID := docResult.DocID
 	logger.LogInfo(ctx, "Document created successfully",
-		"operation", "UploadPDF",
+		"operation", operation,
 		"docID", docID,
-		"title", params.Title,
+		"title", docParams.Title,
 	)
 
 	// Step 3: Split text into chunks
-	chunks := textchunker.ChunkText(text, params.ChunkSize, params.ChunkOverlap)
+	chunker, err := textchunker.New(chunking)
+	if err != nil {
+		logger.LogError(ctx, "Invalid chunking strategy, using the sentence strategy", err,
+			"operation", operation,
+			"docID", docID,
+		)
+		chunking.Strategy = textchunker.StrategySentence
+		chunker, _ = textchunker.New(chunking)
+	}
+	var chunks []string
+	var headings [][]string
+	for _, chunk := range chunker.Chunk(text) {
+		chunks = append(chunks, chunk.Content)
+		headings = append(headings, chunk.Headings)
+	}
 
 	logger.LogInfo(ctx, "Text split into chunks",
-		"operation", "UploadPDF",
+		"operation", operation,
 		"docID", docID,
+		"strategy", chunking.Strategy,
 		"chunksCount", len(chunks),
 	)
 
 	if len(chunks) == 0 {
-		logger.LogWarn(ctx, "No chunks created from PDF text",
-			"operation", "UploadPDF",
+		logger.LogWarn(ctx, "No chunks created from document text",
+			"operation", operation,
 			"docID", docID,
 			"textLength", len(text),
 		)
 		return d.Success(d.Data{
 			"docId":         docID,
+			"fileType":      fileType,
+			"chunkStrategy": chunking.Strategy,
 			"chunksCreated": 0,
 			"message":       "Document created but no chunks generated (text might be empty)",
 		})
 	}
 
 	// Step 4: Create chunks using ChunkUseCase
-	chunkResult := u.chunkUseCase.BulkCreate(ctx, docID, chunks)
+	chunkResult := u.chunkUseCase.BulkCreateWithHeadings(ctx, docID, chunks, headings)
 	if !chunkResult.Success {
 		logger.LogError(ctx, "Failed to create chunks",
 			fmt.Errorf("chunk creation failed: %s", chunkResult.Code),
-			"operation", "UploadPDF",
+			"operation", operation,
 			"docID", docID,
 			"chunksCount", len(chunks),
 		)
@@ -285,15 +455,486 @@ func (u *documentUseCase) UploadPDF(c context.Context, params d.UploadPDFDocumen
 
 	chunksCreated, _ := chunkResult.Data["chunksCreated"].(int)
 
-	logger.LogInfo(ctx, "PDF upload completed successfully",
-		"operation", "UploadPDF",
+	logger.LogInfo(ctx, "Document upload completed successfully",
+		"operation", operation,
+		"docID", docID,
+		"fileType", fileType,
+		"chunksCreated", chunksCreated,
+	)
+
+	return d.Success(d.Data{
+		"docId":         docID,
+		"fileType":      fileType,
+		"chunkStrategy": chunking.Strategy,
+		"chunksCreated": chunksCreated,
+		"message":       strings.ToUpper(fileType) + " uploaded and processed successfully",
+	})
+}
+
+func (u *documentUseCase) ImportTable(c context.Context, params d.ImportTableParams) d.Result[d.Data] {
+	// Embedding every row of a large sheet takes a while
+	ctx, cancel := context.WithTimeout(c, 5*time.Minute)
+	defer cancel()
+
+	logger.LogInfo(ctx, "Starting table import",
+		"operation", "ImportTable",
+		"docID", params.DocID,
+		"title", params.Title,
+		"fileType", params.FileType,
+	)
+
+	fileData, err := base64.StdEncoding.DecodeString(params.FileBase64)
+	if err != nil {
+		logger.LogWarn(ctx, "Uploaded table is not valid base64",
+			"operation", "ImportTable",
+			"code", "ERR_DOCUMENT_PROCESSING",
+			"title", params.Title,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_DOCUMENT_PROCESSING")
+	}
+
+	fileType := resolveFileType(params.FileType, params.FileName, fileData)
+	if fileType != d.FileTypeXLSX && fileType != d.FileTypeCSV {
+		logger.LogWarn(ctx, "Unsupported table file type",
+			"operation", "ImportTable",
+			"code", "ERR_UNSUPPORTED_FILE_TYPE",
+			"fileType", params.FileType,
+			"title", params.Title,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_UNSUPPORTED_FILE_TYPE")
+	}
+
+	// A re-import keeps the mapping the document was imported with unless a new one is given
+	mapping := d.TableMapping{}
+	if params.DocID != nil {
+		existing, err := u.docRepo.GetTableMapping(ctx, *params.DocID)
+		if err != nil {
+			logger.LogError(ctx, "Failed to fetch document table mapping from database", err,
+				"operation", "ImportTable",
+				"docID", *params.DocID,
+			)
+			return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+		}
+		if existing == nil || !existing.Active {
+			logger.LogWarn(ctx, "Document to re-import not found",
+				"operation", "ImportTable",
+				"code", "ERR_DOCUMENT_NOT_FOUND",
+				"docID", *params.DocID,
+			)
+			return d.Error[d.Data](u.paramCache, "ERR_DOCUMENT_NOT_FOUND")
+		}
+		if existing.Mapping != nil {
+			mapping = *existing.Mapping
+		}
+	}
+	if params.Mapping != nil {
+		mapping = *params.Mapping
+	}
+
+	// Render the rows before touching the database, so a bad file or mapping changes nothing
+	chunks, err := tableChunks(fileType, fileData, mapping, params.ChunkSize)
+	if err != nil {
+		code := tableErrorCode(err)
+		if code == "ERR_DOCUMENT_PROCESSING" {
+			logger.LogError(ctx, "Failed to read table", err,
+				"operation", "ImportTable",
+				"fileType", fileType,
+				"title", params.Title,
+			)
+		} else {
+			logger.LogWarn(ctx, "Table does not fit its mapping",
+				"operation", "ImportTable",
+				"code", code,
+				"error", err.Error(),
+				"title", params.Title,
+			)
+		}
+		return d.Error[d.Data](u.paramCache, code)
+	}
+
+	contents := make([]string, len(chunks))
+	headings := make([][]string, len(chunks))
+	for i, chunk := range chunks {
+		contents[i] = chunk.Content
+		headings[i] = chunk.Headings
+	}
+
+	summary := summarize(strings.Join(contents, "\n"), 500)
+
+	replace := params.DocID != nil
+	var docID int
+	if replace {
+		docID = *params.DocID
+	} else {
+		docResult, err := u.docRepo.Create(ctx, d.CreateDocumentParams{
+			Category:   params.Category,
+			Title:      params.Title,
+			Summary:    &summary,
+			Source:     params.Source,
+			Tags:       normalizeTags(params.Tags),
+			Audience:   normalizeAudience(params.Audience),
+			ValidFrom:  params.ValidFrom,
+			ValidUntil: params.ValidUntil,
+		})
+		if err != nil || docResult == nil {
+			logger.LogError(ctx, "Failed to create document in database", err,
+				"operation", "ImportTable",
+				"title", params.Title,
+			)
+			return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+		}
+		if !docResult.Success {
+			logger.LogWarn(ctx, "Document creation failed with business logic error",
+				"operation", "ImportTable",
+				"code", docResult.Code,
+				"title", params.Title,
+			)
+			return d.Error[d.Data](u.paramCache, docResult.Code)
+		}
+		docID = docResult.DocID
+	}
+
+	var chunkResult d.Result[d.Data]
+	if replace {
+		chunkResult = u.chunkUseCase.ReplaceWithHeadings(ctx, docID, contents, headings)
+	} else {
+		chunkResult = u.chunkUseCase.BulkCreateWithHeadings(ctx, docID, contents, headings)
+	}
+	if !chunkResult.Success {
+		logger.LogError(ctx, "Failed to create chunks",
+			fmt.Errorf("chunk creation failed: %s", chunkResult.Code),
+			"operation", "ImportTable",
+			"docID", docID,
+			"chunksCount", len(contents),
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_CHUNK_CREATION")
+	}
+
+	mappingResult, err := u.docRepo.SetTableMapping(ctx, docID, &summary, mapping)
+	if err != nil || mappingResult == nil {
+		logger.LogError(ctx, "Failed to store document table mapping in database", err,
+			"operation", "ImportTable",
+			"docID", docID,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+	if !mappingResult.Success {
+		logger.LogWarn(ctx, "Storing the table mapping failed with business logic error",
+			"operation", "ImportTable",
+			"code", mappingResult.Code,
+			"docID", docID,
+		)
+		return d.Error[d.Data](u.paramCache, mappingResult.Code)
+	}
+
+	chunksCreated, _ := chunkResult.Data["chunksCreated"].(int)
+
+	logger.LogInfo(ctx, "Table import completed successfully",
+		"operation", "ImportTable",
 		"docID", docID,
+		"fileType", fileType,
+		"replaced", replace,
 		"chunksCreated", chunksCreated,
 	)
 
 	return d.Success(d.Data{
 		"docId":         docID,
+		"fileType":      fileType,
+		"replaced":      replace,
 		"chunksCreated": chunksCreated,
-		"message":       "PDF uploaded and processed successfully",
+		"message":       strings.ToUpper(fileType) + " imported successfully",
 	})
 }
+
+func (u *documentUseCase) PreviewChunks(c context.Context, params d.PreviewChunksParams) d.Result[*d.ChunkPreviewResult] {
+	// Use longer timeout for file processing, as on upload
+	ctx, cancel := context.WithTimeout(c, 5*time.Minute)
+	defer cancel()
+
+	var fileType, text string
+	var chunks []textchunker.Chunk
+	var chunking textchunker.Options
+
+	switch {
+	case params.FileBase64 != "":
+		fileData, err := base64.StdEncoding.DecodeString(params.FileBase64)
+		if err != nil {
+			logger.LogWarn(ctx, "Previewed file is not valid base64",
+				"operation", "PreviewChunks",
+				"code", "ERR_DOCUMENT_PROCESSING",
+			)
+			return d.Error[*d.ChunkPreviewResult](u.paramCache, "ERR_DOCUMENT_PROCESSING")
+		}
+		fileType = resolveFileType(params.FileType, params.FileName, fileData)
+
+		if fileType == d.FileTypeXLSX || fileType == d.FileTypeCSV {
+			mapping := d.TableMapping{}
+			if params.Mapping != nil {
+				mapping = *params.Mapping
+			}
+			chunks, err = tableChunks(fileType, fileData, mapping, params.ChunkSize)
+			if err != nil {
+				code := tableErrorCode(err)
+				logger.LogWarn(ctx, "Previewed table could not be chunked",
+					"operation", "PreviewChunks",
+					"code", code,
+					"error", err.Error(),
+				)
+				return d.Error[*d.ChunkPreviewResult](u.paramCache, code)
+			}
+			chunking = textchunker.Options{Strategy: tableStrategy, ChunkSize: max(params.ChunkSize, 0)}
+			break
+		}
+
+		text, err = extractText(fileType, params.FileBase64, fileData)
+		if err != nil {
+			code := processingErrorCode(fileType)
+			if errors.Is(err, errUnsupportedFileType) {
+				code = "ERR_UNSUPPORTED_FILE_TYPE"
+			}
+			logger.LogWarn(ctx, "Previewed file could not be read",
+				"operation", "PreviewChunks",
+				"code", code,
+				"fileType", fileType,
+				"error", err.Error(),
+			)
+			return d.Error[*d.ChunkPreviewResult](u.paramCache, code)
+		}
+	case strings.TrimSpace(params.Content) != "":
+		fileType = params.Format
+		if fileType == "" {
+			fileType = d.FileTypeMarkdown
+		}
+		text = params.Content
+	default:
+		logger.LogWarn(ctx, "Nothing to preview",
+			"operation", "PreviewChunks",
+			"code", "ERR_VALIDATION_FAILED",
+		)
+		return d.Error[*d.ChunkPreviewResult](u.paramCache, "ERR_VALIDATION_FAILED")
+	}
+
+	if chunking.Strategy != tableStrategy {
+		chunking = chunkingOptions(u.paramCache, params.Category, fileType, params.ChunkStrategy, params.ChunkSize, params.ChunkOverlap)
+		chunker, err := textchunker.New(chunking)
+		if err != nil {
+			logger.LogWarn(ctx, "Invalid chunking strategy",
+				"operation", "PreviewChunks",
+				"code", "ERR_VALIDATION_FAILED",
+				"strategy", chunking.Strategy,
+			)
+			return d.Error[*d.ChunkPreviewResult](u.paramCache, "ERR_VALIDATION_FAILED")
+		}
+		chunks = chunker.Chunk(text)
+	}
+
+	previews := make([]d.ChunkPreview, len(chunks))
+	for i, chunk := range chunks {
+		headings := chunk.Headings
+		if headings == nil {
+			headings = []string{}
+		}
+		previews[i] = d.ChunkPreview{
+			Position:   i,
+			Content:    chunk.Content,
+			Headings:   headings,
+			Characters: utf8.RuneCountInString(chunk.Content),
+			Tokens:     textchunker.CountTokens(chunk.Content),
+		}
+	}
+
+	return d.Success(&d.ChunkPreviewResult{
+		FileType:     fileType,
+		Strategy:     chunking.Strategy,
+		ChunkSize:    chunking.ChunkSize,
+		ChunkOverlap: chunking.Overlap,
+		TextLength:   utf8.RuneCountInString(text),
+		TotalChunks:  len(previews),
+		Chunks:       previews,
+	})
+}
+
+// chunkingOptions resolves how a document is chunked: the strategy, size and overlap given
+// with the upload, else the "chunking" of its category (DOCUMENT_CATEGORY prm_data, e.g.
+// {"chunking": {"strategy": "recursive", "chunkSize": 800, "chunkOverlap": 100}}), else the
+// defaults: headings for Markdown and plain text, sentence for everything else. The size and
+// overlap of the category only apply with its own strategy, as tokens and characters differ.
+func chunkingOptions(cache d.ParameterCache, category, fileType, strategy string, chunkSize int, chunkOverlap *int) textchunker.Options {
+	opts := textchunker.Options{Strategy: strategy, ChunkSize: chunkSize, Overlap: -1}
+	if chunkOverlap != nil {
+		opts.Overlap = max(*chunkOverlap, 0)
+	}
+
+	if config, exists := cache.GetValue(category); exists {
+		if chunking, ok := config["chunking"].(map[string]any); ok {
+			categoryStrategy, _ := chunking["strategy"].(string)
+			if !textchunker.IsStrategy(categoryStrategy) {
+				categoryStrategy = ""
+			}
+			if opts.Strategy == "" {
+				opts.Strategy = categoryStrategy
+			}
+			if categoryStrategy == "" || categoryStrategy == opts.Strategy {
+				if val, ok := chunking["chunkSize"].(float64); ok && val > 0 && opts.ChunkSize <= 0 {
+					opts.ChunkSize = int(val)
+				}
+				if val, ok := chunking["chunkOverlap"].(float64); ok && val >= 0 && opts.Overlap < 0 {
+					opts.Overlap = int(val)
+				}
+			}
+		}
+	}
+
+	if opts.Strategy == "" {
+		opts.Strategy = textchunker.StrategySentence
+		if fileType == d.FileTypeMarkdown || fileType == d.FileTypeText {
+			opts.Strategy = textchunker.StrategyHeadings
+		}
+	}
+	return textchunker.WithDefaults(opts)
+}
+
+// extractText extracts the text of a PDF, DOCX, ODT, Markdown or plain-text file
+func extractText(fileType, fileBase64 string, data []byte) (string, error) {
+	switch fileType {
+	case d.FileTypePDF:
+		return pdfprocessor.ExtractTextFromBase64PDF(fileBase64)
+	case d.FileTypeDOCX:
+		return officeprocessor.ExtractTextFromDOCX(data)
+	case d.FileTypeODT:
+		return officeprocessor.ExtractTextFromODT(data)
+	case d.FileTypeMarkdown, d.FileTypeText:
+		return decodeText(data)
+	default:
+		return "", errUnsupportedFileType
+	}
+}
+
+// tableChunks reads the sheets of an XLSX file (or a CSV file) and renders their rows as chunks
+func tableChunks(fileType string, data []byte, mapping d.TableMapping, chunkSize int) ([]textchunker.Chunk, error) {
+	var tables []tableprocessor.Table
+	if fileType == d.FileTypeCSV {
+		text, err := decodeText(data)
+		if err != nil {
+			return nil, err
+		}
+		table, err := tableprocessor.ReadCSV(text, mapping.Delimiter)
+		if err != nil {
+			return nil, err
+		}
+		tables = []tableprocessor.Table{table}
+	} else {
+		sheets, err := officeprocessor.ReadXLSX(data)
+		if err != nil {
+			return nil, err
+		}
+		tables, err = tableprocessor.FromSheets(sheets, mapping.Sheets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tableprocessor.Chunks(tables, tableprocessor.Mapping{
+		HeaderRow:    mapping.HeaderRow,
+		Columns:      mapping.Columns,
+		Labels:       mapping.Labels,
+		GroupBy:      mapping.GroupBy,
+		RowsPerChunk: mapping.RowsPerChunk,
+	}, chunkSize)
+}
+
+// tableErrorCode maps a table import error to its error code
+func tableErrorCode(err error) string {
+	switch {
+	case errors.Is(err, tableprocessor.ErrNoRows):
+		return "ERR_TABLE_EMPTY"
+	case errors.Is(err, tableprocessor.ErrColumnNotFound):
+		return "ERR_TABLE_COLUMN_NOT_FOUND"
+	case errors.Is(err, tableprocessor.ErrSheetNotFound):
+		return "ERR_TABLE_SHEET_NOT_FOUND"
+	default:
+		return "ERR_DOCUMENT_PROCESSING"
+	}
+}
+
+// processingErrorCode keeps the PDF-specific error code of the original PDF upload
+func processingErrorCode(fileType string) string {
+	if fileType == d.FileTypePDF {
+		return "ERR_PDF_PROCESSING"
+	}
+	return "ERR_DOCUMENT_PROCESSING"
+}
+
+// resolveFileType returns the declared file type, else the one of the file name extension,
+// else the one sniffed from the content ("" when unsupported)
+func resolveFileType(fileType string, fileName *string, data []byte) string {
+	if fileType != "" {
+		return strings.ToLower(fileType)
+	}
+	if fileName != nil {
+		switch strings.ToLower(filepath.Ext(*fileName)) {
+		case ".pdf":
+			return d.FileTypePDF
+		case ".docx":
+			return d.FileTypeDOCX
+		case ".odt":
+			return d.FileTypeODT
+		case ".md", ".markdown":
+			return d.FileTypeMarkdown
+		case ".txt", ".text":
+			return d.FileTypeText
+		case ".xlsx":
+			return d.FileTypeXLSX
+		case ".csv":
+			return d.FileTypeCSV
+		}
+	}
+	return officeprocessor.DetectFileType(data)
+}
+
+// decodeText reads an uploaded text file as UTF-8 (dropping a byte order mark), falling back
+// to Windows-1252 for legacy files. Binary content is rejected.
+func decodeText(data []byte) (string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", fmt.Errorf("file is not text")
+	}
+	if utf8.Valid(data) {
+		return string(data), nil
+	}
+	decoded, err := charmap.Windows1252.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode text: %w", err)
+	}
+	return string(decoded), nil
+}
+
+// normalizeTags lowercases and trims tags, dropping blanks and duplicates
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// normalizeAudience uppercases and trims role codes, dropping blanks and duplicates
+func normalizeAudience(roles []string) []string {
+	normalized := make([]string, 0, len(roles))
+	seen := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		role = strings.ToUpper(strings.TrimSpace(role))
+		if role == "" || seen[role] {
+			continue
+		}
+		seen[role] = true
+		normalized = append(normalized, role)
+	}
+	return normalized
+}
@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	d "api-chatbot/domain"
+	"api-chatbot/internal/logger"
+)
+
+type embeddingCacheUseCase struct {
+	cacheRepo      d.EmbeddingCacheRepository
+	paramCache     d.ParameterCache
+	contextTimeout time.Duration
+}
+
+func NewEmbeddingCacheUseCase(
+	cacheRepo d.EmbeddingCacheRepository,
+	paramCache d.ParameterCache,
+	timeout time.Duration,
+) d.EmbeddingCacheUseCase {
+	return &embeddingCacheUseCase{
+		cacheRepo:      cacheRepo,
+		paramCache:     paramCache,
+		contextTimeout: timeout,
+	}
+}
+
+func (u *embeddingCacheUseCase) GetStats(c context.Context, days int) d.Result[[]d.EmbeddingCacheStats] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	stats, err := u.cacheRepo.GetStats(ctx, days)
+	if err != nil {
+		logger.LogError(ctx, "Failed to get embedding cache stats from database", err,
+			"operation", "GetStats",
+			"days", days,
+		)
+		return d.Error[[]d.EmbeddingCacheStats](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	return d.Success(stats)
+}
+
+func (u *embeddingCacheUseCase) Purge(c context.Context, params d.PurgeEmbeddingCacheParams) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.cacheRepo.Purge(ctx, params)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to purge embedding cache in database", err,
+			"operation", "Purge",
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Embedding cache purge failed with business logic error",
+			"operation", "Purge",
+			"code", result.Code,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	logger.LogInfo(ctx, "Embedding cache purged",
+		"deleted", result.Deleted,
+	)
+
+	return d.Success(d.Data{"deleted": result.Deleted})
+}
@@ -0,0 +1,560 @@
+package usecase
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pgvector/pgvector-go"
+
+	d "api-chatbot/domain"
+	"api-chatbot/internal/logger"
+)
+
+const (
+	embeddingMigrationMaxRetries = 3
+	embeddingMigrationRetryDelay = 5 * time.Second
+	// Swapping rebuilds the vector index, which can outlast the request timeout
+	embeddingSwapTimeout = 10 * time.Minute
+)
+
+// EmbeddingServiceFactory builds an embedding service reading its configuration from the given parameter code
+type EmbeddingServiceFactory func(configCode string) d.EmbeddingService
+
+type embeddingMigrationUseCase struct {
+	migrationRepo   d.EmbeddingMigrationRepository
+	paramCache      d.ParameterCache
+	newEmbeddingSvc EmbeddingServiceFactory
+	contextTimeout  time.Duration
+	mu              sync.Mutex
+	workers         map[int]context.CancelFunc
+}
+
+func NewEmbeddingMigrationUseCase(
+	migrationRepo d.EmbeddingMigrationRepository,
+	paramCache d.ParameterCache,
+	newEmbeddingSvc EmbeddingServiceFactory,
+	timeout time.Duration,
+) d.EmbeddingMigrationUseCase {
+	return &embeddingMigrationUseCase{
+		migrationRepo:   migrationRepo,
+		paramCache:      paramCache,
+		newEmbeddingSvc: newEmbeddingSvc,
+		contextTimeout:  timeout,
+		workers:         make(map[int]context.CancelFunc),
+	}
+}
+
+// Start validates the target configuration with a probe embedding, opens a job and starts its worker
+func (u *embeddingMigrationUseCase) Start(c context.Context, params d.StartEmbeddingMigrationParams) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if _, exists := u.paramCache.Get(params.ConfigCode); !exists {
+		logger.LogWarn(ctx, "Embedding migration target configuration not found",
+			"operation", "StartEmbeddingMigration",
+			"configCode", params.ConfigCode,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_EMBEDDING_CONFIG_NOT_FOUND")
+	}
+
+	embeddingSvc := u.newEmbeddingSvc(params.ConfigCode)
+	model, err := embeddingSvc.Model()
+	if err != nil {
+		logger.LogWarn(ctx, "Embedding migration target configuration is invalid",
+			"operation", "StartEmbeddingMigration",
+			"configCode", params.ConfigCode,
+			"error", err.Error(),
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_EMBEDDING_CONFIG_INVALID")
+	}
+
+	// The probe checks credentials and gives the real vector size before any chunk is touched
+	probe, err := embeddingSvc.GenerateEmbedding(ctx, "embedding migration probe")
+	if err != nil || len(probe) == 0 {
+		logger.LogError(ctx, "Embedding migration probe failed", err,
+			"operation", "StartEmbeddingMigration",
+			"configCode", params.ConfigCode,
+			"model", model,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_EMBEDDING_CONFIG_INVALID")
+	}
+
+	result, err := u.migrationRepo.CreateJob(ctx, d.CreateEmbeddingJobParams{
+		ConfigCode:       params.ConfigCode,
+		TargetModel:      model,
+		TargetDimensions: len(probe),
+		BatchSize:        params.BatchSize,
+		AutoSwap:         params.AutoSwap,
+	})
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to create embedding job in database", err,
+			"operation", "StartEmbeddingMigration",
+			"configCode", params.ConfigCode,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Embedding job creation failed with business logic error",
+			"operation", "StartEmbeddingMigration",
+			"code", result.Code,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	jobID := *result.ID
+	u.launch(jobID, embeddingSvc, params.BatchSize, params.AutoSwap)
+
+	logger.LogInfo(ctx, "Embedding migration started",
+		"jobId", jobID,
+		"model", model,
+		"dimensions", len(probe),
+	)
+
+	return d.Success(d.Data{
+		"jobId":      jobID,
+		"model":      model,
+		"dimensions": len(probe),
+	})
+}
+
+// Pause stops the worker; shadow embeddings written so far are kept
+func (u *embeddingMigrationUseCase) Pause(c context.Context, jobID int) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	job, errResult := u.getJob(ctx, jobID, "PauseEmbeddingMigration")
+	if errResult != nil {
+		return *errResult
+	}
+	if job.Status != d.EmbeddingJobRunning {
+		return d.Error[d.Data](u.paramCache, "ERR_EMBEDDING_JOB_NOT_RUNNING")
+	}
+
+	if errResult := u.updateStatus(ctx, jobID, d.EmbeddingJobPaused, "PauseEmbeddingMigration"); errResult != nil {
+		return *errResult
+	}
+	u.stop(jobID)
+
+	return d.Success(d.Data{"jobId": jobID, "status": d.EmbeddingJobPaused})
+}
+
+// Resume restarts a paused job from the chunks still missing a shadow embedding. A completed
+// job can be resumed too, to catch up with chunks created or edited after it finished.
+func (u *embeddingMigrationUseCase) Resume(c context.Context, jobID int) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	job, errResult := u.getJob(ctx, jobID, "ResumeEmbeddingMigration")
+	if errResult != nil {
+		return *errResult
+	}
+	if job.Status != d.EmbeddingJobPaused && job.Status != d.EmbeddingJobCompleted {
+		return d.Error[d.Data](u.paramCache, "ERR_EMBEDDING_JOB_NOT_PAUSED")
+	}
+
+	if errResult := u.updateStatus(ctx, jobID, d.EmbeddingJobRunning, "ResumeEmbeddingMigration"); errResult != nil {
+		return *errResult
+	}
+	u.launch(jobID, u.newEmbeddingSvc(job.ConfigCode), job.BatchSize, job.AutoSwap)
+
+	return d.Success(d.Data{"jobId": jobID, "status": d.EmbeddingJobRunning})
+}
+
+// Cancel stops the job and discards its shadow embeddings
+func (u *embeddingMigrationUseCase) Cancel(c context.Context, jobID int) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if errResult := u.updateStatus(ctx, jobID, d.EmbeddingJobCancelled, "CancelEmbeddingMigration"); errResult != nil {
+		return *errResult
+	}
+	u.stop(jobID)
+
+	return d.Success(d.Data{"jobId": jobID, "status": d.EmbeddingJobCancelled})
+}
+
+// Swap activates the shadow embeddings of a finished job. When chunks were created or edited
+// after the job completed, the job goes back to running to embed them and swaps once it is done.
+func (u *embeddingMigrationUseCase) Swap(c context.Context, jobID int) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	job, errResult := u.getJob(ctx, jobID, "SwapEmbeddingSet")
+	if errResult != nil {
+		return *errResult
+	}
+
+	swapCtx, swapCancel := context.WithTimeout(c, embeddingSwapTimeout)
+	defer swapCancel()
+
+	errResult = u.swap(swapCtx, job)
+	if errResult != nil && errResult.Code == "ERR_EMBEDDING_JOB_PENDING_CHUNKS" && job.Status == d.EmbeddingJobCompleted {
+		if errResult := u.updateStatus(ctx, jobID, d.EmbeddingJobRunning, "SwapEmbeddingSet"); errResult != nil {
+			return *errResult
+		}
+		u.launch(jobID, u.newEmbeddingSvc(job.ConfigCode), job.BatchSize, true)
+
+		logger.LogInfo(ctx, "Embedding migration catching up before swap", "jobId", jobID)
+
+		return d.Success(d.Data{"jobId": jobID, "status": d.EmbeddingJobRunning})
+	}
+	if errResult != nil {
+		return *errResult
+	}
+
+	return d.Success(d.Data{
+		"jobId":      jobID,
+		"model":      job.TargetModel,
+		"dimensions": job.TargetDimensions,
+	})
+}
+
+func (u *embeddingMigrationUseCase) GetJobs(c context.Context, jobID *int) d.Result[[]d.EmbeddingJob] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	jobs, err := u.migrationRepo.GetJobs(ctx, jobID, 20)
+	if err != nil {
+		logger.LogError(ctx, "Failed to get embedding jobs from database", err,
+			"operation", "GetEmbeddingJobs",
+		)
+		return d.Error[[]d.EmbeddingJob](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	return d.Success(jobs)
+}
+
+func (u *embeddingMigrationUseCase) ResumeRunning(c context.Context) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	jobs, err := u.migrationRepo.GetJobs(ctx, nil, 20)
+	if err != nil {
+		logger.LogError(ctx, "Failed to get embedding jobs from database", err,
+			"operation", "ResumeRunningEmbeddingMigrations",
+		)
+		return
+	}
+
+	for _, job := range jobs {
+		if job.Status != d.EmbeddingJobRunning {
+			continue
+		}
+		logger.LogInfo(ctx, "Resuming embedding migration",
+			"jobId", job.ID,
+			"model", job.TargetModel,
+			"pending", job.PendingChunks,
+		)
+		u.launch(job.ID, u.newEmbeddingSvc(job.ConfigCode), job.BatchSize, job.AutoSwap)
+	}
+}
+
+func (u *embeddingMigrationUseCase) getJob(ctx context.Context, jobID int, operation string) (*d.EmbeddingJob, *d.Result[d.Data]) {
+	jobs, err := u.migrationRepo.GetJobs(ctx, &jobID, 1)
+	if err != nil {
+		logger.LogError(ctx, "Failed to get embedding job from database", err,
+			"operation", operation,
+			"jobId", jobID,
+		)
+		errResult := d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+		return nil, &errResult
+	}
+	if len(jobs) == 0 {
+		errResult := d.Error[d.Data](u.paramCache, "ERR_EMBEDDING_JOB_NOT_FOUND")
+		return nil, &errResult
+	}
+	return &jobs[0], nil
+}
+
+func (u *embeddingMigrationUseCase) updateStatus(ctx context.Context, jobID int, status string, operation string) *d.Result[d.Data] {
+	result, err := u.migrationRepo.UpdateJobStatus(ctx, d.UpdateEmbeddingJobStatusParams{
+		JobID:  jobID,
+		Status: status,
+	})
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to update embedding job in database", err,
+			"operation", operation,
+			"jobId", jobID,
+		)
+		errResult := d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+		return &errResult
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Embedding job update failed with business logic error",
+			"operation", operation,
+			"code", result.Code,
+		)
+		errResult := d.Error[d.Data](u.paramCache, result.Code)
+		return &errResult
+	}
+
+	return nil
+}
+
+// swap runs the atomic swap and points the cached EMBEDDING_CONFIG at the new model
+func (u *embeddingMigrationUseCase) swap(ctx context.Context, job *d.EmbeddingJob) *d.Result[d.Data] {
+	u.stop(job.ID)
+
+	result, err := u.migrationRepo.SwapEmbeddingSet(ctx, job.ID)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to swap embedding set in database", err,
+			"operation", "SwapEmbeddingSet",
+			"jobId", job.ID,
+		)
+		errResult := d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+		return &errResult
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Embedding swap failed with business logic error",
+			"operation", "SwapEmbeddingSet",
+			"code", result.Code,
+		)
+		errResult := d.Error[d.Data](u.paramCache, result.Code)
+		return &errResult
+	}
+
+	// The database already holds the new EMBEDDING_CONFIG; mirror it so queries
+	// are embedded with the new model without waiting for a cache reload
+	target, targetExists := u.paramCache.Get(job.ConfigCode)
+	active, activeExists := u.paramCache.Get("EMBEDDING_CONFIG")
+	if targetExists && activeExists {
+		updated := *active
+		updated.Data = target.Data
+		u.paramCache.Set("EMBEDDING_CONFIG", &updated)
+	}
+
+	logger.LogInfo(ctx, "Embedding set swapped",
+		"jobId", job.ID,
+		"model", job.TargetModel,
+		"dimensions", job.TargetDimensions,
+	)
+
+	return nil
+}
+
+// launch starts the background worker of a job unless one is already running
+func (u *embeddingMigrationUseCase) launch(jobID int, embeddingSvc d.EmbeddingService, batchSize int, autoSwap bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, running := u.workers[jobID]; running {
+		return
+	}
+
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	u.workers[jobID] = workerCancel
+
+	go func() {
+		defer u.stop(jobID)
+		u.run(workerCtx, jobID, embeddingSvc, batchSize, autoSwap)
+	}()
+}
+
+func (u *embeddingMigrationUseCase) stop(jobID int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if workerCancel, running := u.workers[jobID]; running {
+		workerCancel()
+		delete(u.workers, jobID)
+	}
+}
+
+// run walks the chunks missing a shadow embedding in ID order. Chunks created or edited
+// meanwhile are picked up by another pass; the job completes after a pass that finds nothing to do.
+func (u *embeddingMigrationUseCase) run(ctx context.Context, jobID int, embeddingSvc d.EmbeddingService, batchSize int, autoSwap bool) {
+	for {
+		stored, skipped, err := u.runPass(ctx, jobID, embeddingSvc, batchSize)
+		if ctx.Err() != nil {
+			return // Paused or cancelled
+		}
+		if err != nil {
+			u.pauseOnError(jobID, skipped, err)
+			return
+		}
+		if stored > 0 {
+			continue
+		}
+
+		if skipped > 0 {
+			u.pauseOnError(jobID, skipped, fmt.Errorf("%d chunks have no content to embed", skipped))
+			return
+		}
+
+		u.complete(jobID, autoSwap)
+		return
+	}
+}
+
+// runPass embeds every pending chunk once and returns how many were stored and skipped
+func (u *embeddingMigrationUseCase) runPass(ctx context.Context, jobID int, embeddingSvc d.EmbeddingService, batchSize int) (int, int, error) {
+	stored, skipped := 0, 0
+	afterID := 0
+
+	for {
+		if ctx.Err() != nil {
+			return stored, skipped, nil
+		}
+
+		batchCtx, batchCancel := context.WithTimeout(ctx, u.contextTimeout)
+		chunks, err := u.migrationRepo.GetPendingChunks(batchCtx, afterID, batchSize)
+		batchCancel()
+		if err != nil {
+			return stored, skipped, err
+		}
+		if len(chunks) == 0 {
+			return stored, skipped, nil
+		}
+		afterID = chunks[len(chunks)-1].ID
+
+		var ids []int
+		var texts, hashes []string
+		for _, chunk := range chunks {
+			if chunk.Content == "" {
+				skipped++
+				continue
+			}
+			sum := md5.Sum([]byte(chunk.Content))
+			ids = append(ids, chunk.ID)
+			texts = append(texts, chunk.Content)
+			hashes = append(hashes, hex.EncodeToString(sum[:]))
+		}
+		if len(texts) == 0 {
+			continue
+		}
+
+		embeddings, err := u.embedWithRetry(ctx, embeddingSvc, texts)
+		if err != nil {
+			return stored, skipped, err
+		}
+
+		vectors := make([]pgvector.Vector, len(embeddings))
+		for i, embedding := range embeddings {
+			vectors[i] = pgvector.NewVector(embedding)
+		}
+
+		storeCtx, storeCancel := context.WithTimeout(ctx, u.contextTimeout)
+		result, err := u.migrationRepo.StoreNextEmbeddings(storeCtx, d.StoreNextEmbeddingsParams{
+			JobID:         jobID,
+			ChunkIDs:      ids,
+			ContentHashes: hashes,
+			Embeddings:    vectors,
+		})
+		storeCancel()
+		if err != nil || result == nil {
+			return stored, skipped, fmt.Errorf("failed to store shadow embeddings: %w", err)
+		}
+		if !result.Success {
+			if result.Code == "ERR_EMBEDDING_JOB_NOT_RUNNING" {
+				return stored, skipped, context.Canceled // Paused or cancelled elsewhere
+			}
+			return stored, skipped, fmt.Errorf("failed to store shadow embeddings: %s", result.Code)
+		}
+		if result.Updated != nil {
+			stored += *result.Updated
+		}
+	}
+}
+
+func (u *embeddingMigrationUseCase) embedWithRetry(ctx context.Context, embeddingSvc d.EmbeddingService, texts []string) ([][]float32, error) {
+	var lastErr error
+	for attempt := 1; attempt <= embeddingMigrationMaxRetries; attempt++ {
+		embeddings, err := embeddingSvc.GenerateEmbeddings(ctx, texts)
+		if err == nil && len(embeddings) == len(texts) {
+			return embeddings, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("embedding service returned %d embeddings, expected %d", len(embeddings), len(texts))
+		}
+		lastErr = err
+
+		logger.LogWarn(ctx, "Embedding migration batch failed",
+			"operation", "RunEmbeddingMigration",
+			"attempt", attempt,
+			"error", err.Error(),
+		)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(attempt) * embeddingMigrationRetryDelay):
+		}
+	}
+	return nil, lastErr
+}
+
+// pauseOnError pauses the job with the error so an admin can fix the cause and resume
+func (u *embeddingMigrationUseCase) pauseOnError(jobID int, skipped int, cause error) {
+	asyncCtx, asyncCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer asyncCancel()
+
+	if errors.Is(cause, context.Canceled) {
+		return
+	}
+
+	message := cause.Error()
+	logger.LogError(asyncCtx, "Embedding migration paused after error", cause,
+		"operation", "RunEmbeddingMigration",
+		"jobId", jobID,
+	)
+
+	result, err := u.migrationRepo.UpdateJobStatus(asyncCtx, d.UpdateEmbeddingJobStatusParams{
+		JobID:        jobID,
+		Status:       d.EmbeddingJobPaused,
+		FailedChunks: &skipped,
+		Error:        &message,
+	})
+	if err != nil || result == nil || !result.Success {
+		logger.LogError(asyncCtx, "Failed to pause embedding job in database", err,
+			"operation", "RunEmbeddingMigration",
+			"jobId", jobID,
+		)
+	}
+}
+
+// complete marks the job as completed and swaps right away when requested
+func (u *embeddingMigrationUseCase) complete(jobID int, autoSwap bool) {
+	asyncCtx, asyncCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer asyncCancel()
+
+	noFailures := 0
+	result, err := u.migrationRepo.UpdateJobStatus(asyncCtx, d.UpdateEmbeddingJobStatusParams{
+		JobID:        jobID,
+		Status:       d.EmbeddingJobCompleted,
+		FailedChunks: &noFailures,
+	})
+	if err != nil || result == nil || !result.Success {
+		logger.LogError(asyncCtx, "Failed to complete embedding job in database", err,
+			"operation", "RunEmbeddingMigration",
+			"jobId", jobID,
+		)
+		return
+	}
+
+	logger.LogInfo(asyncCtx, "Embedding migration completed", "jobId", jobID)
+
+	if !autoSwap {
+		return
+	}
+
+	swapCtx, swapCancel := context.WithTimeout(context.Background(), embeddingSwapTimeout)
+	defer swapCancel()
+
+	jobs, err := u.migrationRepo.GetJobs(swapCtx, &jobID, 1)
+	if err != nil || len(jobs) == 0 {
+		logger.LogError(swapCtx, "Failed to get embedding job from database", err,
+			"operation", "AutoSwapEmbeddingSet",
+			"jobId", jobID,
+		)
+		return
+	}
+	u.swap(swapCtx, &jobs[0])
+}
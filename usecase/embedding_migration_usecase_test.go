@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"api-chatbot/api/dal"
+	d "api-chatbot/domain"
+	"api-chatbot/internal/cache"
+)
+
+// fakeMigrationRepo keeps chunks and the job in memory, following the rules of the
+// embedding migration procedures (000047)
+type fakeMigrationRepo struct {
+	mu     sync.Mutex
+	job    d.EmbeddingJob
+	chunks map[int]string
+	next   map[int]bool // Chunks with a shadow embedding
+	active map[int]bool // Chunks whose embedding was swapped in
+}
+
+func newFakeMigrationRepo(chunks map[int]string) *fakeMigrationRepo {
+	return &fakeMigrationRepo{chunks: chunks, next: map[int]bool{}, active: map[int]bool{}}
+}
+
+// addChunk simulates a chunk created while the job is not running
+func (r *fakeMigrationRepo) addChunk(id int, content string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chunks[id] = content
+}
+
+func (r *fakeMigrationRepo) status() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.job.Status
+}
+
+func (r *fakeMigrationRepo) pending() int {
+	count := 0
+	for id := range r.chunks {
+		if !r.next[id] {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *fakeMigrationRepo) CreateJob(ctx context.Context, params d.CreateEmbeddingJobParams) (*d.CreateEmbeddingJobResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := 1
+	r.job = d.EmbeddingJob{
+		ID:               id,
+		Status:           d.EmbeddingJobRunning,
+		ConfigCode:       params.ConfigCode,
+		TargetModel:      params.TargetModel,
+		TargetDimensions: params.TargetDimensions,
+		BatchSize:        params.BatchSize,
+		AutoSwap:         params.AutoSwap,
+	}
+	return &d.CreateEmbeddingJobResult{DbResult: dal.DbResult{Success: true, Code: "OK"}, ID: &id}, nil
+}
+
+func (r *fakeMigrationRepo) GetJobs(ctx context.Context, jobID *int, limit int) ([]d.EmbeddingJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.job.ID == 0 || (jobID != nil && *jobID != r.job.ID) {
+		return nil, nil
+	}
+	job := r.job
+	job.TotalChunks = len(r.chunks)
+	job.PendingChunks = r.pending()
+	return []d.EmbeddingJob{job}, nil
+}
+
+func (r *fakeMigrationRepo) GetPendingChunks(ctx context.Context, afterID int, limit int) ([]d.PendingChunk, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var chunks []d.PendingChunk
+	for id, content := range r.chunks {
+		if id > afterID && !r.next[id] {
+			chunks = append(chunks, d.PendingChunk{ID: id, Content: content})
+		}
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].ID < chunks[j].ID })
+	if len(chunks) > limit {
+		chunks = chunks[:limit]
+	}
+	return chunks, nil
+}
+
+func (r *fakeMigrationRepo) StoreNextEmbeddings(ctx context.Context, params d.StoreNextEmbeddingsParams) (*d.StoreNextEmbeddingsResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.job.Status != d.EmbeddingJobRunning {
+		return &d.StoreNextEmbeddingsResult{DbResult: dal.DbResult{Success: false, Code: "ERR_EMBEDDING_JOB_NOT_RUNNING"}}, nil
+	}
+	for _, id := range params.ChunkIDs {
+		r.next[id] = true
+	}
+	updated := len(params.ChunkIDs)
+	return &d.StoreNextEmbeddingsResult{DbResult: dal.DbResult{Success: true, Code: "OK"}, Updated: &updated}, nil
+}
+
+func (r *fakeMigrationRepo) UpdateJobStatus(ctx context.Context, params d.UpdateEmbeddingJobStatusParams) (*d.UpdateEmbeddingJobStatusResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.job.Status == d.EmbeddingJobSwapped || r.job.Status == d.EmbeddingJobCancelled {
+		return &d.UpdateEmbeddingJobStatusResult{DbResult: dal.DbResult{Success: false, Code: "ERR_EMBEDDING_JOB_FINISHED"}}, nil
+	}
+	r.job.Status = params.Status
+	return &d.UpdateEmbeddingJobStatusResult{DbResult: dal.DbResult{Success: true, Code: "OK"}}, nil
+}
+
+func (r *fakeMigrationRepo) SwapEmbeddingSet(ctx context.Context, jobID int) (*d.SwapEmbeddingSetResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.job.Status != d.EmbeddingJobRunning && r.job.Status != d.EmbeddingJobCompleted {
+		return &d.SwapEmbeddingSetResult{DbResult: dal.DbResult{Success: false, Code: "ERR_EMBEDDING_JOB_NOT_COMPLETED"}}, nil
+	}
+	if r.pending() > 0 {
+		return &d.SwapEmbeddingSetResult{DbResult: dal.DbResult{Success: false, Code: "ERR_EMBEDDING_JOB_PENDING_CHUNKS"}}, nil
+	}
+	for id := range r.chunks {
+		r.active[id] = true
+	}
+	r.job.Status = d.EmbeddingJobSwapped
+	return &d.SwapEmbeddingSetResult{DbResult: dal.DbResult{Success: true, Code: "OK"}}, nil
+}
+
+// fakeEmbeddingService returns a fixed vector per text
+type fakeEmbeddingService struct{}
+
+func (fakeEmbeddingService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return []float32{0.1, 0.2, 0.3}, nil
+}
+
+func (s fakeEmbeddingService) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i := range texts {
+		embeddings[i], _ = s.GenerateEmbedding(ctx, texts[i])
+	}
+	return embeddings, nil
+}
+
+func (fakeEmbeddingService) Model() (string, error) {
+	return "test-embedding-model", nil
+}
+
+func newTestMigrationUseCase(repo *fakeMigrationRepo) d.EmbeddingMigrationUseCase {
+	paramCache := cache.NewParameterCache()
+	paramCache.Set("EMBEDDING_CONFIG_NEXT", &d.Parameter{Code: "EMBEDDING_CONFIG_NEXT", Data: json.RawMessage(`{"model": "test-embedding-model"}`)})
+	paramCache.Set("EMBEDDING_CONFIG", &d.Parameter{Code: "EMBEDDING_CONFIG", Data: json.RawMessage(`{"model": "old-model"}`)})
+	factory := func(configCode string) d.EmbeddingService { return fakeEmbeddingService{} }
+	return NewEmbeddingMigrationUseCase(repo, paramCache, factory, 5*time.Second)
+}
+
+// waitForStatus waits for the background worker to leave the job in the given status
+func waitForStatus(t *testing.T, repo *fakeMigrationRepo, status string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for repo.status() != status {
+		if time.Now().After(deadline) {
+			t.Fatalf("job status = %s, want %s", repo.status(), status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// startCompletedJob runs a job without auto swap over two chunks until it completes
+func startCompletedJob(t *testing.T, repo *fakeMigrationRepo, uc d.EmbeddingMigrationUseCase) {
+	t.Helper()
+	result := uc.Start(context.Background(), d.StartEmbeddingMigrationParams{ConfigCode: "EMBEDDING_CONFIG_NEXT", BatchSize: 10})
+	if !result.Success {
+		t.Fatalf("Start: %s", result.Code)
+	}
+	waitForStatus(t, repo, d.EmbeddingJobCompleted)
+}
+
+func TestEmbeddingMigrationSwapCatchesUpChunksAddedAfterCompletion(t *testing.T) {
+	repo := newFakeMigrationRepo(map[int]string{1: "Horarios de atención", 2: "Requisitos de matrícula"})
+	uc := newTestMigrationUseCase(repo)
+	startCompletedJob(t, repo, uc)
+
+	repo.addChunk(3, "Calendario académico")
+
+	result := uc.Swap(context.Background(), 1)
+	if !result.Success {
+		t.Fatalf("Swap: %s", result.Code)
+	}
+	if result.Data["status"] != d.EmbeddingJobRunning {
+		t.Fatalf("Swap status = %v, want %s", result.Data["status"], d.EmbeddingJobRunning)
+	}
+
+	waitForStatus(t, repo, d.EmbeddingJobSwapped)
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if !repo.next[3] || !repo.active[3] {
+		t.Fatalf("chunk added after completion was not embedded before the swap")
+	}
+}
+
+func TestEmbeddingMigrationResumeCompletedJob(t *testing.T) {
+	repo := newFakeMigrationRepo(map[int]string{1: "Horarios de atención", 2: "Requisitos de matrícula"})
+	uc := newTestMigrationUseCase(repo)
+	startCompletedJob(t, repo, uc)
+
+	repo.addChunk(3, "Calendario académico")
+
+	result := uc.Resume(context.Background(), 1)
+	if !result.Success {
+		t.Fatalf("Resume: %s", result.Code)
+	}
+	waitForStatus(t, repo, d.EmbeddingJobCompleted)
+
+	result = uc.Swap(context.Background(), 1)
+	if !result.Success {
+		t.Fatalf("Swap: %s", result.Code)
+	}
+	if repo.status() != d.EmbeddingJobSwapped {
+		t.Fatalf("job status = %s, want %s", repo.status(), d.EmbeddingJobSwapped)
+	}
+}
@@ -0,0 +1,408 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	d "api-chatbot/domain"
+	"api-chatbot/internal/evaluation"
+	"api-chatbot/internal/logger"
+)
+
+// evaluationSettingCodes are the parameters recorded with every run so reports can be compared
+var evaluationSettingCodes = []string{
+	"RAG_FUSION_STRATEGY",
+	"RAG_RRF_K",
+	"RAG_MMR_ENABLED",
+	"RAG_MMR_LAMBDA",
+	"RAG_MAX_CHUNKS_PER_DOCUMENT",
+	"RAG_NEIGHBOR_WINDOW",
+	"RERANK_CONFIG",
+	"QUERY_STRATEGY_CONFIG",
+	"EMBEDDING_CONFIG",
+}
+
+type evaluationUseCase struct {
+	evaluationRepo d.EvaluationRepository
+	chunkUseCase   d.ChunkUseCase
+	paramCache     d.ParameterCache
+	contextTimeout time.Duration
+}
+
+func NewEvaluationUseCase(
+	evaluationRepo d.EvaluationRepository,
+	chunkUseCase d.ChunkUseCase,
+	paramCache d.ParameterCache,
+	timeout time.Duration,
+) d.EvaluationUseCase {
+	return &evaluationUseCase{
+		evaluationRepo: evaluationRepo,
+		chunkUseCase:   chunkUseCase,
+		paramCache:     paramCache,
+		contextTimeout: timeout,
+	}
+}
+
+func (u *evaluationUseCase) CreateQuestion(c context.Context, params d.CreateEvalQuestionParams) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if params.Set == "" {
+		params.Set = d.DefaultEvalSet
+	}
+
+	result, err := u.evaluationRepo.CreateQuestion(ctx, params)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to create evaluation question in database", err,
+			"operation", "CreateQuestion",
+			"set", params.Set,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Evaluation question creation failed with business logic error",
+			"operation", "CreateQuestion",
+			"code", result.Code,
+			"set", params.Set,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	return d.Success(d.Data{"questionId": result.QuestionID})
+}
+
+func (u *evaluationUseCase) DeleteQuestion(c context.Context, questionID int) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.evaluationRepo.DeleteQuestion(ctx, questionID)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to delete evaluation question in database", err,
+			"operation", "DeleteQuestion",
+			"questionID", questionID,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Evaluation question deletion failed with business logic error",
+			"operation", "DeleteQuestion",
+			"code", result.Code,
+			"questionID", questionID,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	return d.Success(d.Data{"questionId": questionID})
+}
+
+func (u *evaluationUseCase) GetQuestions(c context.Context, set string) d.Result[[]d.EvalQuestion] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if set == "" {
+		set = d.DefaultEvalSet
+	}
+
+	questions, err := u.evaluationRepo.GetQuestions(ctx, set)
+	if err != nil {
+		logger.LogError(ctx, "Failed to get evaluation questions from database", err,
+			"operation", "GetQuestions",
+			"set", set,
+		)
+		return d.Error[[]d.EvalQuestion](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	return d.Success(questions)
+}
+
+// ImportQuestions adds the questions one by one; invalid or rejected ones are reported, not fatal
+func (u *evaluationUseCase) ImportQuestions(c context.Context, set string, questions []d.EvalQuestion) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if set == "" {
+		set = d.DefaultEvalSet
+	}
+
+	imported := 0
+	var failed []string
+	for i, question := range questions {
+		if err := evaluation.Validate(question); err != nil {
+			failed = append(failed, fmt.Sprintf("#%d: %s", i+1, err.Error()))
+			continue
+		}
+
+		result, err := u.evaluationRepo.CreateQuestion(ctx, d.CreateEvalQuestionParams{
+			Set:                 set,
+			Question:            question.Question,
+			Category:            question.Category,
+			RelevantChunkIDs:    question.RelevantChunkIDs,
+			RelevantDocumentIDs: question.RelevantDocumentIDs,
+		})
+		if err != nil || result == nil {
+			logger.LogError(ctx, "Failed to import evaluation question", err,
+				"operation", "ImportQuestions",
+				"set", set,
+				"index", i,
+			)
+			return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+		}
+		if !result.Success {
+			failed = append(failed, fmt.Sprintf("#%d: %s", i+1, result.Code))
+			continue
+		}
+		imported++
+	}
+
+	logger.LogInfo(ctx, "Evaluation questions imported",
+		"operation", "ImportQuestions",
+		"set", set,
+		"imported", imported,
+		"failed", len(failed),
+	)
+
+	return d.Success(d.Data{"set": set, "imported": imported, "failed": failed})
+}
+
+// RunEvaluation runs every golden question through hybrid search with the current index and
+// settings. Searches do not update the chunk usage statistics.
+func (u *evaluationUseCase) RunEvaluation(c context.Context, params d.RunEvaluationParams) d.Result[*d.EvalRun] {
+	if params.Set == "" {
+		params.Set = d.DefaultEvalSet
+	}
+
+	questions := params.Questions
+	if len(questions) == 0 {
+		loadCtx, loadCancel := context.WithTimeout(c, u.contextTimeout)
+		stored, err := u.evaluationRepo.GetQuestions(loadCtx, params.Set)
+		loadCancel()
+		if err != nil {
+			logger.LogError(c, "Failed to get evaluation questions from database", err,
+				"operation", "RunEvaluation",
+				"set", params.Set,
+			)
+			return d.Error[*d.EvalRun](u.paramCache, "ERR_INTERNAL_DB")
+		}
+		questions = stored
+	}
+	if len(questions) == 0 {
+		logger.LogWarn(c, "Evaluation set has no questions",
+			"operation", "RunEvaluation",
+			"code", "ERR_EVAL_SET_EMPTY",
+			"set", params.Set,
+		)
+		return d.Error[*d.EvalRun](u.paramCache, "ERR_EVAL_SET_EMPTY")
+	}
+
+	k := u.getParamInt("RAG_SEARCH_LIMIT", 5)
+	if params.K != nil && *params.K > 0 {
+		k = *params.K
+	}
+	minSimilarity := u.getParamFloat("RAG_MIN_SIMILARITY", 0.2)
+	if params.MinSimilarity != nil {
+		minSimilarity = *params.MinSimilarity
+	}
+	keywordWeight := u.getParamFloat("RAG_KEYWORD_WEIGHT", 0.15)
+	if params.KeywordWeight != nil {
+		keywordWeight = *params.KeywordWeight
+	}
+
+	opts := params.Options
+	opts.SkipStatistics = true
+
+	logger.LogInfo(c, "Starting retrieval evaluation",
+		"operation", "RunEvaluation",
+		"set", params.Set,
+		"questions", len(questions),
+		"k", k,
+	)
+
+	search := func(ctx context.Context, question d.EvalQuestion) ([]d.ChunkWithHybridSimilarity, error) {
+		result := u.chunkUseCase.HybridSearchWithCategory(ctx, question.Question, k, minSimilarity, keywordWeight, question.Category, opts)
+		if !result.Success {
+			return nil, fmt.Errorf("%s: %s", result.Code, result.Info)
+		}
+		return result.Data, nil
+	}
+	results, metrics := evaluation.Run(c, questions, search)
+
+	run := &d.EvalRun{
+		Set:           params.Set,
+		Label:         params.Label,
+		K:             k,
+		QuestionCount: len(results),
+		Settings:      u.settingsSnapshot(minSimilarity, keywordWeight, params.Options),
+		Metrics:       metrics,
+		Results:       results,
+		CreatedAt:     time.Now(),
+	}
+
+	logger.LogInfo(c, "Retrieval evaluation completed",
+		"operation", "RunEvaluation",
+		"set", params.Set,
+		"questions", run.QuestionCount,
+		"recallAtK", metrics.RecallAtK,
+		"mrr", metrics.MRR,
+		"ndcg", metrics.NDCG,
+	)
+
+	if !params.Save {
+		return d.Success(run)
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	saved, err := u.evaluationRepo.CreateRun(ctx, d.CreateEvalRunParams{
+		Set:           run.Set,
+		Label:         run.Label,
+		K:             run.K,
+		QuestionCount: run.QuestionCount,
+		Settings:      run.Settings,
+		Metrics:       run.Metrics,
+		Results:       run.Results,
+	})
+	if err != nil || saved == nil {
+		logger.LogError(ctx, "Failed to store evaluation run in database", err,
+			"operation", "RunEvaluation",
+			"set", params.Set,
+		)
+		return d.Error[*d.EvalRun](u.paramCache, "ERR_INTERNAL_DB")
+	}
+	if !saved.Success {
+		logger.LogWarn(ctx, "Evaluation run storage failed with business logic error",
+			"operation", "RunEvaluation",
+			"code", saved.Code,
+		)
+		return d.Error[*d.EvalRun](u.paramCache, saved.Code)
+	}
+	if saved.RunID != nil {
+		run.ID = *saved.RunID
+	}
+
+	return d.Success(run)
+}
+
+func (u *evaluationUseCase) GetRuns(c context.Context, set *string, limit int) d.Result[[]d.EvalRun] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if limit <= 0 {
+		limit = 20
+	}
+
+	runs, err := u.evaluationRepo.GetRuns(ctx, nil, set, limit)
+	if err != nil {
+		logger.LogError(ctx, "Failed to get evaluation runs from database", err,
+			"operation", "GetRuns",
+		)
+		return d.Error[[]d.EvalRun](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	return d.Success(runs)
+}
+
+func (u *evaluationUseCase) GetRun(c context.Context, runID int) d.Result[*d.EvalRun] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	runs, err := u.evaluationRepo.GetRuns(ctx, &runID, nil, 1)
+	if err != nil {
+		logger.LogError(ctx, "Failed to get evaluation run from database", err,
+			"operation", "GetRun",
+			"runID", runID,
+		)
+		return d.Error[*d.EvalRun](u.paramCache, "ERR_INTERNAL_DB")
+	}
+	if len(runs) == 0 {
+		return d.Error[*d.EvalRun](u.paramCache, "ERR_EVAL_RUN_NOT_FOUND")
+	}
+
+	return d.Success(&runs[0])
+}
+
+// settingsSnapshot records the search settings of a run: the request values, the overrides
+// and the retrieval parameters in effect (API keys are left out)
+func (u *evaluationUseCase) settingsSnapshot(minSimilarity, keywordWeight float64, opts d.RetrievalOptions) d.Data {
+	snapshot := d.Data{
+		"minSimilarity": minSimilarity,
+		"keywordWeight": keywordWeight,
+	}
+
+	overrides := d.Data{}
+	if opts.Fusion != nil {
+		overrides["fusion"] = *opts.Fusion
+	}
+	if opts.RRFK != nil {
+		overrides["rrfK"] = *opts.RRFK
+	}
+	if opts.MMR != nil {
+		overrides["mmr"] = *opts.MMR
+	}
+	if opts.MMRLambda != nil {
+		overrides["mmrLambda"] = *opts.MMRLambda
+	}
+	if opts.MaxChunksPerDocument != nil {
+		overrides["maxChunksPerDocument"] = *opts.MaxChunksPerDocument
+	}
+	if opts.NeighborWindow != nil {
+		overrides["neighborWindow"] = *opts.NeighborWindow
+	}
+	if opts.RecencyWeight != nil {
+		overrides["recencyWeight"] = *opts.RecencyWeight
+	}
+	if opts.QueryStrategy != nil {
+		overrides["queryStrategy"] = *opts.QueryStrategy
+	}
+	if opts.Filter != nil {
+		overrides["filter"] = opts.Filter
+	}
+	snapshot["overrides"] = overrides
+
+	parameters := d.Data{}
+	for _, code := range evaluationSettingCodes {
+		if data, exists := u.paramCache.GetValue(code); exists {
+			parameters[code] = withoutSecrets(data)
+		}
+	}
+	snapshot["parameters"] = parameters
+
+	return snapshot
+}
+
+// withoutSecrets copies parameter data dropping API keys at any depth
+func withoutSecrets(data map[string]any) map[string]any {
+	clean := make(map[string]any, len(data))
+	for key, value := range data {
+		if strings.Contains(strings.ToLower(key), "apikey") {
+			continue
+		}
+		if nested, ok := value.(map[string]any); ok {
+			value = withoutSecrets(nested)
+		}
+		clean[key] = value
+	}
+	return clean
+}
+
+func (u *evaluationUseCase) getParamInt(code string, defaultValue int) int {
+	if data, exists := u.paramCache.GetValue(code); exists {
+		if val, ok := data["value"].(float64); ok {
+			return int(val)
+		}
+	}
+	return defaultValue
+}
+
+func (u *evaluationUseCase) getParamFloat(code string, defaultValue float64) float64 {
+	if data, exists := u.paramCache.GetValue(code); exists {
+		if val, ok := data["value"].(float64); ok {
+			return val
+		}
+	}
+	return defaultValue
+}
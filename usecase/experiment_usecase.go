@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	d "api-chatbot/domain"
+	"api-chatbot/internal/logger"
+)
+
+type experimentUseCase struct {
+	experimentRepo d.ExperimentRepository
+	paramCache     d.ParameterCache
+	contextTimeout time.Duration
+}
+
+func NewExperimentUseCase(
+	experimentRepo d.ExperimentRepository,
+	paramCache d.ParameterCache,
+	timeout time.Duration,
+) d.ExperimentUseCase {
+	return &experimentUseCase{
+		experimentRepo: experimentRepo,
+		paramCache:     paramCache,
+		contextTimeout: timeout,
+	}
+}
+
+func (u *experimentUseCase) CreateExperiment(c context.Context, params d.CreateExperimentParams) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.experimentRepo.Create(ctx, params)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to create experiment in database", err,
+			"operation", "CreateExperiment",
+			"code", params.Code,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Experiment creation failed with business logic error",
+			"operation", "CreateExperiment",
+			"code", result.Code,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	return d.Success(d.Data{"experimentId": result.ExperimentID})
+}
+
+func (u *experimentUseCase) SetExperimentStatus(c context.Context, experimentID int, active bool) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.experimentRepo.SetStatus(ctx, experimentID, active)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to update experiment status in database", err,
+			"operation", "SetExperimentStatus",
+			"experimentID", experimentID,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Experiment status update failed with business logic error",
+			"operation", "SetExperimentStatus",
+			"code", result.Code,
+			"experimentID", experimentID,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	logger.LogInfo(ctx, "Experiment status updated",
+		"experimentID", experimentID,
+		"active", active,
+	)
+
+	return d.Success(d.Data{"experimentId": experimentID, "active": active})
+}
+
+func (u *experimentUseCase) GetExperiments(c context.Context) d.Result[[]d.Experiment] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	experiments, err := u.experimentRepo.GetAll(ctx)
+	if err != nil {
+		logger.LogError(ctx, "Failed to get experiments from database", err,
+			"operation", "GetExperiments",
+		)
+		return d.Error[[]d.Experiment](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	return d.Success(experiments)
+}
+
+// AssignVariant picks the variant for a chat by hashing the chat ID over the variant weights,
+// so the same chat always lands on the same variant while the experiment runs
+func (u *experimentUseCase) AssignVariant(c context.Context, chatID string) *d.ExperimentVariant {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	variants, err := u.experimentRepo.GetActiveVariants(ctx)
+	if err != nil {
+		logger.LogError(ctx, "Failed to get active experiment variants from database", err,
+			"operation", "AssignVariant",
+			"chatID", chatID,
+		)
+		return nil
+	}
+	if len(variants) == 0 {
+		return nil
+	}
+
+	totalWeight := 0
+	for _, variant := range variants {
+		totalWeight += variant.Weight
+	}
+	if totalWeight <= 0 {
+		return nil
+	}
+
+	// Salt with the experiment code so assignments are independent across experiments
+	hash := fnv.New32a()
+	hash.Write([]byte(variants[0].ExperimentCode + ":" + chatID))
+	bucket := int(hash.Sum32() % uint32(totalWeight))
+
+	for i := range variants {
+		bucket -= variants[i].Weight
+		if bucket < 0 {
+			return &variants[i]
+		}
+	}
+
+	return &variants[len(variants)-1]
+}
+
+func (u *experimentUseCase) CompareVariants(c context.Context, params d.CompareVariantsParams) d.Result[[]d.VariantComparison] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	comparison, err := u.experimentRepo.CompareVariants(ctx, params)
+	if err != nil {
+		logger.LogError(ctx, "Failed to compare experiment variants in database", err,
+			"operation", "CompareVariants",
+			"experimentID", params.ExperimentID,
+		)
+		return d.Error[[]d.VariantComparison](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	return d.Success(comparison)
+}
@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	d "api-chatbot/domain"
+	"api-chatbot/internal/logger"
+
+	"github.com/pgvector/pgvector-go"
+)
+
+const (
+	defaultFAQThreshold     = 0.85
+	defaultFAQKeywordWeight = 0.3
+	faqPreviewLimit         = 5
+)
+
+type faqUseCase struct {
+	faqRepo          d.FAQRepository
+	embeddingService d.EmbeddingService
+	paramCache       d.ParameterCache
+	contextTimeout   time.Duration
+}
+
+func NewFAQUseCase(
+	faqRepo d.FAQRepository,
+	embeddingService d.EmbeddingService,
+	paramCache d.ParameterCache,
+	timeout time.Duration,
+) d.FAQUseCase {
+	return &faqUseCase{
+		faqRepo:          faqRepo,
+		embeddingService: embeddingService,
+		paramCache:       paramCache,
+		contextTimeout:   timeout,
+	}
+}
+
+func (u *faqUseCase) GetFAQs(c context.Context, includeInactive bool) d.Result[[]d.FAQ] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	faqs, err := u.faqRepo.GetFAQs(ctx, includeInactive)
+	if err != nil {
+		logger.LogError(ctx, "Failed to fetch FAQs from database", err,
+			"operation", "GetFAQs",
+		)
+		return d.Error[[]d.FAQ](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	return d.Success(faqs)
+}
+
+func (u *faqUseCase) Save(c context.Context, params d.SaveFAQParams) d.Result[d.Data] {
+	params.Question = strings.TrimSpace(params.Question)
+	params.Variants = normalizeVariants(params.Question, params.Variants)
+
+	// Every phrasing is embedded with the current model: the question first, then the variants
+	embeddingCtx, embeddingCancel := context.WithTimeout(c, 30*time.Second)
+	defer embeddingCancel()
+
+	model, err := u.embeddingService.Model()
+	if err != nil {
+		logger.LogError(embeddingCtx, "Failed to resolve the embedding model for FAQ", err,
+			"operation", "SaveFAQ",
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_FAQ_EMBEDDING")
+	}
+	phrasings := append([]string{params.Question}, params.Variants...)
+	vectors, err := u.embeddingService.GenerateEmbeddings(embeddingCtx, phrasings)
+	if err != nil || len(vectors) != len(phrasings) {
+		logger.LogError(embeddingCtx, "Failed to generate embeddings for FAQ", err,
+			"operation", "SaveFAQ",
+			"phrasings", len(phrasings),
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_FAQ_EMBEDDING")
+	}
+	embeddings := make([]pgvector.Vector, len(vectors))
+	for i, vector := range vectors {
+		embeddings[i] = pgvector.NewVector(vector)
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.faqRepo.Save(ctx, params, embeddings, model)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to save FAQ in database", err,
+			"operation", "SaveFAQ",
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "FAQ save failed with business logic error",
+			"operation", "SaveFAQ",
+			"code", result.Code,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	logger.LogInfo(ctx, "FAQ saved",
+		"operation", "SaveFAQ",
+		"faqID", result.FAQID,
+		"phrasings", len(phrasings),
+		"model", model,
+	)
+
+	return d.Success(d.Data{"faqId": result.FAQID, "phrasings": len(phrasings)})
+}
+
+func (u *faqUseCase) Delete(c context.Context, faqID int) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.faqRepo.Delete(ctx, faqID)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to delete FAQ in database", err,
+			"operation", "DeleteFAQ",
+			"faqID", faqID,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "FAQ deletion failed with business logic error",
+			"operation", "DeleteFAQ",
+			"code", result.Code,
+			"faqID", faqID,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	return d.Success(d.Data{"faqId": faqID})
+}
+
+func (u *faqUseCase) Reembed(c context.Context) d.Result[d.Data] {
+	faqsResult := u.GetFAQs(c, true)
+	if !faqsResult.Success {
+		return d.Error[d.Data](u.paramCache, faqsResult.Code)
+	}
+
+	reembedded, failed := 0, 0
+	for _, faq := range faqsResult.Data {
+		id, active := faq.ID, faq.Active
+		result := u.Save(c, d.SaveFAQParams{
+			ID:        &id,
+			Question:  faq.Question,
+			Variants:  faq.Variants,
+			Answer:    faq.Answer,
+			Category:  faq.Category,
+			MediaURL:  faq.MediaURL,
+			MediaType: faq.MediaType,
+			Threshold: faq.Threshold,
+			Active:    &active,
+		})
+		if !result.Success {
+			failed++
+			continue
+		}
+		reembedded++
+	}
+
+	logger.LogInfo(c, "FAQs re-embedded",
+		"operation", "ReembedFAQs",
+		"reembedded", reembedded,
+		"failed", failed,
+	)
+
+	return d.Success(d.Data{"reembedded": reembedded, "failed": failed})
+}
+
+func (u *faqUseCase) Match(c context.Context, query string, category *string) d.Result[[]d.FAQMatch] {
+	matches, err := u.match(c, query, category, faqPreviewLimit)
+	if err != nil {
+		return d.Error[[]d.FAQMatch](u.paramCache, "ERR_INTERNAL_DB")
+	}
+	return d.Success(matches)
+}
+
+func (u *faqUseCase) Answer(c context.Context, query string, category *string) d.Result[*d.FAQMatch] {
+	if !u.enabled() {
+		return d.Success[*d.FAQMatch](nil)
+	}
+
+	matches, err := u.match(c, query, category, 1)
+	if err != nil {
+		return d.Error[*d.FAQMatch](u.paramCache, "ERR_INTERNAL_DB")
+	}
+	if len(matches) == 0 || !matches[0].Overrides {
+		return d.Success[*d.FAQMatch](nil)
+	}
+	best := matches[0]
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.faqRepo.RecordHit(ctx, best.FAQID)
+	if err != nil || result == nil || !result.Success {
+		logger.LogWarn(ctx, "Failed to record FAQ hit",
+			"operation", "AnswerFAQ",
+			"faqID", best.FAQID,
+		)
+	}
+
+	logger.LogInfo(ctx, "Question answered by FAQ",
+		"operation", "AnswerFAQ",
+		"faqID", best.FAQID,
+		"score", best.Score,
+		"threshold", best.Threshold,
+	)
+
+	return d.Success(&best)
+}
+
+// match scores the FAQs against a question and marks those reaching their threshold.
+// A failed query embedding does not stop the match: FAQs are then matched by keywords.
+func (u *faqUseCase) match(c context.Context, query string, category *string, limit int) ([]d.FAQMatch, error) {
+	params := d.MatchFAQParams{
+		QueryText:     strings.TrimSpace(query),
+		KeywordWeight: u.configFloat("keywordWeight", defaultFAQKeywordWeight),
+		Category:      category,
+		Limit:         limit,
+	}
+
+	embeddingCtx, embeddingCancel := context.WithTimeout(c, 30*time.Second)
+	defer embeddingCancel()
+
+	if model, err := u.embeddingService.Model(); err == nil {
+		params.Model = model
+		if vector, err := u.embeddingService.GenerateEmbedding(embeddingCtx, params.QueryText); err == nil {
+			embedding := pgvector.NewVector(vector)
+			params.QueryEmbedding = &embedding
+		} else {
+			logger.LogWarn(embeddingCtx, "Failed to embed question for FAQ matching, using keywords only",
+				"operation", "MatchFAQ",
+				"error", err.Error(),
+			)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	matches, err := u.faqRepo.Match(ctx, params)
+	if err != nil {
+		logger.LogError(ctx, "Failed to match FAQs in database", err,
+			"operation", "MatchFAQ",
+		)
+		return nil, err
+	}
+
+	threshold := u.configFloat("threshold", defaultFAQThreshold)
+	for i := range matches {
+		matches[i].Threshold = threshold
+		if matches[i].OwnThreshold != nil {
+			matches[i].Threshold = *matches[i].OwnThreshold
+		}
+		matches[i].Overrides = matches[i].Score >= matches[i].Threshold
+	}
+	return matches, nil
+}
+
+func (u *faqUseCase) enabled() bool {
+	data, exists := u.paramCache.GetValue("FAQ_CONFIG")
+	if !exists {
+		return true
+	}
+	enabled, ok := data["enabled"].(bool)
+	return !ok || enabled
+}
+
+func (u *faqUseCase) configFloat(key string, defaultValue float64) float64 {
+	if data, exists := u.paramCache.GetValue("FAQ_CONFIG"); exists {
+		if val, ok := data[key].(float64); ok {
+			return val
+		}
+	}
+	return defaultValue
+}
+
+// normalizeVariants trims the variants and drops empty ones and repetitions of the question
+func normalizeVariants(question string, variants []string) []string {
+	seen := map[string]bool{strings.ToLower(question): true}
+	normalized := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		variant = strings.TrimSpace(variant)
+		key := strings.ToLower(variant)
+		if variant == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, variant)
+	}
+	return normalized
+}
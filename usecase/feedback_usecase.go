@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	d "api-chatbot/domain"
+	"api-chatbot/internal/logger"
+)
+
+var (
+	defaultPositiveReactions = []string{"👍", "❤️", "🙏", "👏", "💯"}
+	defaultNegativeReactions = []string{"👎", "😡", "😞"}
+)
+
+type feedbackUseCase struct {
+	feedbackRepo   d.FeedbackRepository
+	paramCache     d.ParameterCache
+	contextTimeout time.Duration
+}
+
+func NewFeedbackUseCase(
+	feedbackRepo d.FeedbackRepository,
+	paramCache d.ParameterCache,
+	timeout time.Duration,
+) d.FeedbackUseCase {
+	return &feedbackUseCase{
+		feedbackRepo:   feedbackRepo,
+		paramCache:     paramCache,
+		contextTimeout: timeout,
+	}
+}
+
+func (u *feedbackUseCase) SetMessageFeedback(c context.Context, params d.SetMessageFeedbackParams) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.feedbackRepo.SetMessageFeedback(ctx, params)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to set message feedback in database", err,
+			"operation", "SetMessageFeedback",
+			"messageID", params.MessageID,
+			"source", params.Source,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Message feedback failed with business logic error",
+			"operation", "SetMessageFeedback",
+			"code", result.Code,
+			"messageID", params.MessageID,
+			"source", params.Source,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	logger.LogInfo(ctx, "Message feedback recorded",
+		"operation", "SetMessageFeedback",
+		"messageID", params.MessageID,
+		"rating", params.Rating,
+		"source", params.Source,
+	)
+
+	return d.Success(d.Data{"messageId": result.MessageID, "rating": params.Rating})
+}
+
+func (u *feedbackUseCase) SetChunkRelevance(c context.Context, params d.SetChunkRelevanceParams) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.feedbackRepo.SetChunkRelevance(ctx, params)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to set chunk relevance in database", err,
+			"operation", "SetChunkRelevance",
+			"messageID", params.MessageID,
+			"chunkID", params.ChunkID,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Chunk relevance label failed with business logic error",
+			"operation", "SetChunkRelevance",
+			"code", result.Code,
+			"messageID", params.MessageID,
+			"chunkID", params.ChunkID,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	return d.Success(d.Data{"messageId": params.MessageID, "chunkId": params.ChunkID, "relevant": params.Relevant})
+}
+
+func (u *feedbackUseCase) GetMessageChunks(c context.Context, messageID int) d.Result[[]d.MessageChunk] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	chunks, err := u.feedbackRepo.GetMessageChunks(ctx, messageID)
+	if err != nil {
+		logger.LogError(ctx, "Failed to get message chunks from database", err,
+			"operation", "GetMessageChunks",
+			"messageID", messageID,
+		)
+		return d.Error[[]d.MessageChunk](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	return d.Success(chunks)
+}
+
+// ReactionRating maps a WhatsApp reaction to a rating using FEEDBACK_CONFIG
+func (u *feedbackUseCase) ReactionRating(reaction string) (int, bool) {
+	positive, negative := defaultPositiveReactions, defaultNegativeReactions
+	if data, exists := u.paramCache.GetValue("FEEDBACK_CONFIG"); exists {
+		if enabled, ok := data["whatsappReactions"].(bool); ok && !enabled {
+			return 0, false
+		}
+		if list := toStringSlice(data["positiveReactions"]); len(list) > 0 {
+			positive = list
+		}
+		if list := toStringSlice(data["negativeReactions"]); len(list) > 0 {
+			negative = list
+		}
+	}
+
+	reaction = strings.TrimSpace(reaction)
+	if reaction == "" {
+		return 0, true // Reaction removed
+	}
+	if matchesReaction(reaction, positive) {
+		return 1, true
+	}
+	if matchesReaction(reaction, negative) {
+		return -1, true
+	}
+	return 0, false
+}
+
+// matchesReaction compares ignoring emoji variation selectors and skin tone modifiers
+func matchesReaction(reaction string, list []string) bool {
+	base := baseEmoji(reaction)
+	for _, candidate := range list {
+		if baseEmoji(candidate) == base {
+			return true
+		}
+	}
+	return false
+}
+
+func baseEmoji(emoji string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\uFE0F' || r == '\uFE0E' || (r >= 0x1F3FB && r <= 0x1F3FF) {
+			return -1
+		}
+		return r
+	}, emoji)
+}
+
+func toStringSlice(value any) []string {
+	items, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok && s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	d "api-chatbot/domain"
+	"api-chatbot/internal/logger"
+)
+
+type glossaryUseCase struct {
+	glossaryRepo   d.GlossaryRepository
+	paramCache     d.ParameterCache
+	contextTimeout time.Duration
+}
+
+func NewGlossaryUseCase(
+	glossaryRepo d.GlossaryRepository,
+	paramCache d.ParameterCache,
+	timeout time.Duration,
+) d.GlossaryUseCase {
+	return &glossaryUseCase{
+		glossaryRepo:   glossaryRepo,
+		paramCache:     paramCache,
+		contextTimeout: timeout,
+	}
+}
+
+func (u *glossaryUseCase) GetEntries(c context.Context, entryType *string) d.Result[[]d.GlossaryEntry] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	entries, err := u.glossaryRepo.GetEntries(ctx, entryType)
+	if err != nil {
+		logger.LogError(ctx, "Failed to fetch glossary entries from database", err,
+			"operation", "GetEntries",
+		)
+		return d.Error[[]d.GlossaryEntry](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	return d.Success(entries)
+}
+
+func (u *glossaryUseCase) SaveEntry(c context.Context, params d.SaveGlossaryEntryParams) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.glossaryRepo.SaveEntry(ctx, params)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to save glossary entry in database", err,
+			"operation", "SaveEntry",
+			"term", params.Term,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Glossary entry save failed with business logic error",
+			"operation", "SaveEntry",
+			"code", result.Code,
+			"term", params.Term,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	logger.LogInfo(ctx, "Glossary entry saved",
+		"operation", "SaveEntry",
+		"entryID", result.EntryID,
+		"term", params.Term,
+		"reindexed", result.Reindexed,
+	)
+
+	return d.Success(d.Data{"entryId": result.EntryID, "reindexed": result.Reindexed})
+}
+
+func (u *glossaryUseCase) DeleteEntry(c context.Context, entryID int) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.glossaryRepo.DeleteEntry(ctx, entryID)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to delete glossary entry in database", err,
+			"operation", "DeleteEntry",
+			"entryID", entryID,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Glossary entry deletion failed with business logic error",
+			"operation", "DeleteEntry",
+			"code", result.Code,
+			"entryID", entryID,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	return d.Success(d.Data{"entryId": entryID, "reindexed": result.Reindexed})
+}
+
+func (u *glossaryUseCase) ReindexChunks(c context.Context) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.glossaryRepo.ReindexChunks(ctx)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to reindex chunk full-text vectors in database", err,
+			"operation", "ReindexChunks",
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Chunk full-text reindex failed with business logic error",
+			"operation", "ReindexChunks",
+			"code", result.Code,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	logger.LogInfo(ctx, "Chunk full-text vectors reindexed",
+		"operation", "ReindexChunks",
+		"updated", result.Updated,
+	)
+
+	return d.Success(d.Data{"updated": result.Updated})
+}
+
+func (u *glossaryUseCase) PreviewQuery(c context.Context, query string) d.Result[*d.SearchQueryExpansion] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	expansion, err := u.glossaryRepo.GetQueryExpansion(ctx, query)
+	if err != nil || expansion == nil {
+		logger.LogError(ctx, "Failed to expand search query in database", err,
+			"operation", "PreviewQuery",
+		)
+		return d.Error[*d.SearchQueryExpansion](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	return d.Success(expansion)
+}
@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	d "api-chatbot/domain"
+	"api-chatbot/internal/logger"
+)
+
+type guardrailUseCase struct {
+	guardrailRepo  d.GuardrailRepository
+	paramCache     d.ParameterCache
+	contextTimeout time.Duration
+}
+
+func NewGuardrailUseCase(
+	guardrailRepo d.GuardrailRepository,
+	paramCache d.ParameterCache,
+	timeout time.Duration,
+) d.GuardrailUseCase {
+	return &guardrailUseCase{
+		guardrailRepo:  guardrailRepo,
+		paramCache:     paramCache,
+		contextTimeout: timeout,
+	}
+}
+
+func (u *guardrailUseCase) LogEvent(c context.Context, params d.CreateGuardrailEventParams) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.guardrailRepo.CreateEvent(ctx, params)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to log guardrail event in database", err,
+			"operation", "LogEvent",
+			"stage", params.Stage,
+			"check", params.Check,
+			"action", params.Action,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Guardrail event logging failed with business logic error",
+			"operation", "LogEvent",
+			"code", result.Code,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	return d.Success(d.Data{"eventId": result.EventID})
+}
+
+func (u *guardrailUseCase) GetEvents(c context.Context, params d.GetGuardrailEventsParams) d.Result[[]d.GuardrailEvent] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	events, err := u.guardrailRepo.GetEvents(ctx, params)
+	if err != nil {
+		logger.LogError(ctx, "Failed to fetch guardrail events from database", err,
+			"operation", "GetEvents",
+			"limit", params.Limit,
+			"offset", params.Offset,
+		)
+		return d.Error[[]d.GuardrailEvent](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	return d.Success(events)
+}
+
+func (u *guardrailUseCase) ReviewEvent(c context.Context, params d.ReviewGuardrailEventParams) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.guardrailRepo.ReviewEvent(ctx, params)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to review guardrail event in database", err,
+			"operation", "ReviewEvent",
+			"eventID", params.EventID,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Guardrail event review failed with business logic error",
+			"operation", "ReviewEvent",
+			"code", result.Code,
+			"eventID", params.EventID,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	return d.Success(d.Data{})
+}
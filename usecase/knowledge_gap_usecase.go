@@ -0,0 +1,247 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	d "api-chatbot/domain"
+	"api-chatbot/internal/knowledgegap"
+	"api-chatbot/internal/logger"
+)
+
+const (
+	defaultGapSimilarityThreshold = 0.82
+	defaultGapMinClusterSize      = 3
+	defaultGapGoodScore           = 0.5
+	defaultGapMaxQuestions        = 2000
+	defaultGapRepresentatives     = 3
+)
+
+type knowledgeGapUseCase struct {
+	knowledgeGapRepo d.KnowledgeGapRepository
+	embeddingService d.EmbeddingService
+	paramCache       d.ParameterCache
+	contextTimeout   time.Duration
+}
+
+func NewKnowledgeGapUseCase(
+	knowledgeGapRepo d.KnowledgeGapRepository,
+	embeddingService d.EmbeddingService,
+	paramCache d.ParameterCache,
+	timeout time.Duration,
+) d.KnowledgeGapUseCase {
+	return &knowledgeGapUseCase{
+		knowledgeGapRepo: knowledgeGapRepo,
+		embeddingService: embeddingService,
+		paramCache:       paramCache,
+		contextTimeout:   timeout,
+	}
+}
+
+// Analyze embeds the user questions of the period, clusters them by meaning and labels every
+// cluster with its representative questions and how well it was answered
+func (u *knowledgeGapUseCase) Analyze(c context.Context, params d.AnalyzeKnowledgeGapsParams) d.Result[*d.KnowledgeGapRun] {
+	end := time.Now()
+	if params.EndDate != nil {
+		end = *params.EndDate
+	}
+	start := periodStart(end, params.Period)
+	if params.StartDate != nil {
+		start = *params.StartDate
+	}
+
+	opts := knowledgegap.Options{
+		SimilarityThreshold: u.configFloat("similarityThreshold", defaultGapSimilarityThreshold),
+		MinClusterSize:      int(u.configFloat("minClusterSize", defaultGapMinClusterSize)),
+		GoodScore:           u.configFloat("goodScore", defaultGapGoodScore),
+		Representatives:     int(u.configFloat("representatives", defaultGapRepresentatives)),
+	}
+	if params.SimilarityThreshold != nil {
+		opts.SimilarityThreshold = *params.SimilarityThreshold
+	}
+	if params.MinClusterSize != nil {
+		opts.MinClusterSize = *params.MinClusterSize
+	}
+	if params.GoodScore != nil {
+		opts.GoodScore = *params.GoodScore
+	}
+	maxQuestions := int(u.configFloat("maxQuestions", defaultGapMaxQuestions))
+
+	loadCtx, loadCancel := context.WithTimeout(c, u.contextTimeout)
+	questions, err := u.knowledgeGapRepo.GetQuestions(loadCtx, start, end, maxQuestions)
+	loadCancel()
+	if err != nil {
+		logger.LogError(c, "Failed to get user questions from database", err,
+			"operation", "AnalyzeKnowledgeGaps",
+		)
+		return d.Error[*d.KnowledgeGapRun](u.paramCache, "ERR_INTERNAL_DB")
+	}
+	if len(questions) == 0 {
+		logger.LogWarn(c, "No user questions to cluster in the period",
+			"operation", "AnalyzeKnowledgeGaps",
+			"code", "ERR_KNOWLEDGE_GAP_NO_QUESTIONS",
+			"start", start,
+			"end", end,
+		)
+		return d.Error[*d.KnowledgeGapRun](u.paramCache, "ERR_KNOWLEDGE_GAP_NO_QUESTIONS")
+	}
+
+	// Repeated questions are embedded once
+	texts := make([]string, 0, len(questions))
+	textIndex := make(map[string]int)
+	for _, question := range questions {
+		key := strings.ToLower(question.Question)
+		if _, exists := textIndex[key]; !exists {
+			textIndex[key] = len(texts)
+			texts = append(texts, question.Question)
+		}
+	}
+
+	embeddingCtx, embeddingCancel := context.WithTimeout(c, 2*time.Minute)
+	embeddings, err := u.embeddingService.GenerateEmbeddings(embeddingCtx, texts)
+	embeddingCancel()
+	if err != nil {
+		logger.LogError(c, "Failed to embed user questions", err,
+			"operation", "AnalyzeKnowledgeGaps",
+			"questions", len(texts),
+		)
+		return d.Error[*d.KnowledgeGapRun](u.paramCache, "ERR_KNOWLEDGE_GAP_EMBEDDING")
+	}
+
+	vectors := make([][]float32, len(questions))
+	for i, question := range questions {
+		if j := textIndex[strings.ToLower(question.Question)]; j < len(embeddings) {
+			vectors[i] = embeddings[j]
+		}
+	}
+	clusters := knowledgegap.Cluster(questions, vectors, opts)
+
+	run := &d.KnowledgeGapRun{
+		PeriodStart:   start,
+		PeriodEnd:     end,
+		QuestionCount: len(questions),
+		ClusterCount:  len(clusters),
+		Settings: d.Data{
+			"similarityThreshold": opts.SimilarityThreshold,
+			"minClusterSize":      opts.MinClusterSize,
+			"goodScore":           opts.GoodScore,
+			"representatives":     opts.Representatives,
+			"maxQuestions":        maxQuestions,
+		},
+		Clusters:  clusters,
+		CreatedAt: time.Now(),
+	}
+
+	logger.LogInfo(c, "Knowledge gap analysis completed",
+		"operation", "AnalyzeKnowledgeGaps",
+		"questions", run.QuestionCount,
+		"clusters", run.ClusterCount,
+	)
+
+	if !params.Save {
+		return d.Success(run)
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	saved, err := u.knowledgeGapRepo.CreateRun(ctx, d.CreateKnowledgeGapRunParams{
+		PeriodStart:   run.PeriodStart,
+		PeriodEnd:     run.PeriodEnd,
+		QuestionCount: run.QuestionCount,
+		Settings:      run.Settings,
+		Clusters:      run.Clusters,
+	})
+	if err != nil || saved == nil {
+		logger.LogError(ctx, "Failed to store knowledge gap run in database", err,
+			"operation", "AnalyzeKnowledgeGaps",
+		)
+		return d.Error[*d.KnowledgeGapRun](u.paramCache, "ERR_INTERNAL_DB")
+	}
+	if !saved.Success {
+		logger.LogWarn(ctx, "Knowledge gap run storage failed with business logic error",
+			"operation", "AnalyzeKnowledgeGaps",
+			"code", saved.Code,
+		)
+		return d.Error[*d.KnowledgeGapRun](u.paramCache, saved.Code)
+	}
+	if saved.RunID != nil {
+		run.ID = *saved.RunID
+	}
+
+	return d.Success(run)
+}
+
+// GetGaps returns the gaps to document of a stored run: its clusters with at least
+// minUnansweredShare answered without good sources, worst documented first
+func (u *knowledgeGapUseCase) GetGaps(c context.Context, runID *int, minUnansweredShare float64, limit int) d.Result[*d.KnowledgeGapRun] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	runs, err := u.knowledgeGapRepo.GetRuns(ctx, runID, runID == nil, 1)
+	if err != nil {
+		logger.LogError(ctx, "Failed to get knowledge gap run from database", err,
+			"operation", "GetKnowledgeGaps",
+		)
+		return d.Error[*d.KnowledgeGapRun](u.paramCache, "ERR_INTERNAL_DB")
+	}
+	if len(runs) == 0 {
+		return d.Error[*d.KnowledgeGapRun](u.paramCache, "ERR_KNOWLEDGE_GAP_RUN_NOT_FOUND")
+	}
+
+	run := &runs[0]
+	gaps := make([]d.KnowledgeGap, 0, len(run.Clusters))
+	for _, gap := range run.Clusters {
+		if gap.UnansweredShare < minUnansweredShare {
+			continue
+		}
+		if limit > 0 && len(gaps) >= limit {
+			break
+		}
+		gaps = append(gaps, gap)
+	}
+	run.Clusters = gaps
+
+	return d.Success(run)
+}
+
+func (u *knowledgeGapUseCase) GetRuns(c context.Context, limit int) d.Result[[]d.KnowledgeGapRun] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if limit <= 0 {
+		limit = 20
+	}
+
+	runs, err := u.knowledgeGapRepo.GetRuns(ctx, nil, false, limit)
+	if err != nil {
+		logger.LogError(ctx, "Failed to get knowledge gap runs from database", err,
+			"operation", "GetKnowledgeGapRuns",
+		)
+		return d.Error[[]d.KnowledgeGapRun](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	return d.Success(runs)
+}
+
+func (u *knowledgeGapUseCase) configFloat(key string, defaultValue float64) float64 {
+	if data, exists := u.paramCache.GetValue("KNOWLEDGE_GAP_CONFIG"); exists {
+		if val, ok := data[key].(float64); ok && val > 0 {
+			return val
+		}
+	}
+	return defaultValue
+}
+
+// periodStart returns the start of the last day, week (default) or month before end
+func periodStart(end time.Time, period string) time.Time {
+	switch period {
+	case "day":
+		return end.AddDate(0, 0, -1)
+	case "month":
+		return end.AddDate(0, -1, 0)
+	default:
+		return end.AddDate(0, 0, -7)
+	}
+}
@@ -2,9 +2,11 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	d "api-chatbot/domain"
+	"api-chatbot/internal/guardrails"
 	"api-chatbot/internal/logger"
 )
 
@@ -142,6 +144,10 @@ func (u *parameterUseCase) AddParameter(c context.Context, params d.AddParameter
 	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
 	defer cancel()
 
+	if errResult := u.validate(ctx, params.Code, params.Data, "AddParameter"); errResult != nil {
+		return *errResult
+	}
+
 	result, err := u.repo.AddParameter(ctx, params)
 	if err != nil {
 		return d.Error[d.Data](u.cache, "ERR_INTERNAL_DB")
@@ -164,6 +170,10 @@ func (u *parameterUseCase) UpdParameter(c context.Context, params d.UpdParameter
 	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
 	defer cancel()
 
+	if errResult := u.validate(ctx, params.Code, params.Data, "UpdParameter"); errResult != nil {
+		return *errResult
+	}
+
 	result, err := u.repo.UpdParameter(ctx, params)
 	if err != nil {
 		return d.Error[d.Data](u.cache, "ERR_INTERNAL_DB")
@@ -216,3 +226,22 @@ func (u *parameterUseCase) ReloadCache(c context.Context) d.Result[d.Data] {
 
 	return d.Success(d.Data{"count": len(params)})
 }
+
+// validate checks the data of parameters the application parses beyond plain values,
+// so a broken configuration is rejected on save instead of failing at runtime
+func (u *parameterUseCase) validate(ctx context.Context, code string, data json.RawMessage, operation string) *d.Result[d.Data] {
+	switch code {
+	case "GUARDRAILS_RULES":
+		if err := guardrails.ValidateRules(data); err != nil {
+			logger.LogWarn(ctx, "Invalid guardrail rules",
+				"operation", operation,
+				"code", "ERR_INVALID_GUARDRAILS_RULES",
+				"error", err.Error(),
+			)
+			errResult := d.Error[d.Data](u.cache, "ERR_INVALID_GUARDRAILS_RULES")
+			errResult.Data = d.Data{"error": err.Error()}
+			return &errResult
+		}
+	}
+	return nil
+}
@@ -0,0 +1,439 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	d "api-chatbot/domain"
+	"api-chatbot/internal/logger"
+	"api-chatbot/internal/textchunker"
+	"api-chatbot/internal/webcrawler"
+)
+
+const (
+	webCrawlTimeout         = time.Hour // A crawl fetches and embeds up to maxPages pages
+	defaultWebCrawlMaxPages = 1000
+	webSummaryLength        = 500
+)
+
+type webSourceUseCase struct {
+	webSourceRepo  d.WebSourceRepository
+	chunkUseCase   d.ChunkUseCase
+	crawler        *webcrawler.Crawler
+	paramCache     d.ParameterCache
+	contextTimeout time.Duration
+
+	mu       sync.Mutex
+	crawling map[int]bool // Sources being crawled
+}
+
+func NewWebSourceUseCase(
+	webSourceRepo d.WebSourceRepository,
+	chunkUseCase d.ChunkUseCase,
+	crawler *webcrawler.Crawler,
+	paramCache d.ParameterCache,
+	timeout time.Duration,
+) d.WebSourceUseCase {
+	return &webSourceUseCase{
+		webSourceRepo:  webSourceRepo,
+		chunkUseCase:   chunkUseCase,
+		crawler:        crawler,
+		paramCache:     paramCache,
+		contextTimeout: timeout,
+		crawling:       make(map[int]bool),
+	}
+}
+
+func (u *webSourceUseCase) GetSources(c context.Context, includeInactive bool) d.Result[[]d.WebSource] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	sources, err := u.webSourceRepo.GetSources(ctx, includeInactive, nil)
+	if err != nil {
+		logger.LogError(ctx, "Failed to fetch web sources from database", err,
+			"operation", "GetWebSources",
+		)
+		return d.Error[[]d.WebSource](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	return d.Success(sources)
+}
+
+func (u *webSourceUseCase) Save(c context.Context, params d.SaveWebSourceParams) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	params.URL = strings.TrimSpace(params.URL)
+	params.Tags = normalizeTags(params.Tags)
+	params.Audience = normalizeAudience(params.Audience)
+	if err := validateWebSource(params); err != nil {
+		logger.LogWarn(ctx, "Invalid web source",
+			"operation", "SaveWebSource",
+			"code", "ERR_WEB_SOURCE_INVALID",
+			"url", params.URL,
+			"error", err.Error(),
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_WEB_SOURCE_INVALID")
+	}
+
+	result, err := u.webSourceRepo.Save(ctx, params)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to save web source in database", err,
+			"operation", "SaveWebSource",
+			"url", params.URL,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Web source save failed with business logic error",
+			"operation", "SaveWebSource",
+			"code", result.Code,
+			"url", params.URL,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	return d.Success(d.Data{"sourceId": result.SourceID})
+}
+
+func (u *webSourceUseCase) Delete(c context.Context, sourceID int) d.Result[d.Data] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	result, err := u.webSourceRepo.Delete(ctx, sourceID)
+	if err != nil || result == nil {
+		logger.LogError(ctx, "Failed to delete web source in database", err,
+			"operation", "DeleteWebSource",
+			"sourceID", sourceID,
+		)
+		return d.Error[d.Data](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	if !result.Success {
+		logger.LogWarn(ctx, "Web source deletion failed with business logic error",
+			"operation", "DeleteWebSource",
+			"code", result.Code,
+			"sourceID", sourceID,
+		)
+		return d.Error[d.Data](u.paramCache, result.Code)
+	}
+
+	return d.Success(d.Data{"sourceId": sourceID})
+}
+
+func (u *webSourceUseCase) Crawl(c context.Context, sourceID int) d.Result[*d.WebCrawlResult] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	sources, err := u.webSourceRepo.GetSources(ctx, true, &sourceID)
+	cancel()
+	if err != nil {
+		logger.LogError(c, "Failed to fetch web source from database", err,
+			"operation", "CrawlWebSource",
+			"sourceID", sourceID,
+		)
+		return d.Error[*d.WebCrawlResult](u.paramCache, "ERR_INTERNAL_DB")
+	}
+	if len(sources) == 0 {
+		logger.LogWarn(c, "Web source not found",
+			"operation", "CrawlWebSource",
+			"code", "ERR_WEB_SOURCE_NOT_FOUND",
+			"sourceID", sourceID,
+		)
+		return d.Error[*d.WebCrawlResult](u.paramCache, "ERR_WEB_SOURCE_NOT_FOUND")
+	}
+
+	return u.crawlSource(c, sources[0])
+}
+
+func (u *webSourceUseCase) CrawlDue(c context.Context) d.Result[[]d.WebCrawlResult] {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	sources, err := u.webSourceRepo.GetDueSources(ctx)
+	cancel()
+	if err != nil {
+		logger.LogError(c, "Failed to fetch due web sources from database", err,
+			"operation", "CrawlDueWebSources",
+		)
+		return d.Error[[]d.WebCrawlResult](u.paramCache, "ERR_INTERNAL_DB")
+	}
+
+	results := make([]d.WebCrawlResult, 0, len(sources))
+	for _, source := range sources {
+		result := u.crawlSource(c, source)
+		if !result.Success {
+			continue
+		}
+		results = append(results, *result.Data)
+	}
+
+	return d.Success(results)
+}
+
+// crawlSource crawls a source and syncs its pages: new pages are created, pages whose content
+// hash changed are re-chunked and re-embedded and unchanged pages are left as they are
+func (u *webSourceUseCase) crawlSource(c context.Context, source d.WebSource) d.Result[*d.WebCrawlResult] {
+	if !u.startCrawl(source.ID) {
+		logger.LogWarn(c, "Web source is already being crawled",
+			"operation", "CrawlWebSource",
+			"code", "ERR_WEB_CRAWL_IN_PROGRESS",
+			"sourceID", source.ID,
+		)
+		return d.Error[*d.WebCrawlResult](u.paramCache, "ERR_WEB_CRAWL_IN_PROGRESS")
+	}
+	defer u.finishCrawl(source.ID)
+
+	ctx, cancel := context.WithTimeout(c, webCrawlTimeout)
+	defer cancel()
+
+	result := &d.WebCrawlResult{
+		SourceID:  source.ID,
+		URL:       source.URL,
+		Errors:    []d.WebPageError{},
+		StartedAt: time.Now(),
+	}
+
+	logger.LogInfo(ctx, "Starting web crawl",
+		"operation", "CrawlWebSource",
+		"sourceID", source.ID,
+		"url", source.URL,
+		"sitemap", source.IsSitemap,
+	)
+
+	crawl, err := u.crawler.Crawl(ctx, source.URL, source.IsSitemap, u.crawlOptions(source))
+	if err != nil && crawl == nil {
+		logger.LogError(ctx, "Web crawl failed", err,
+			"operation", "CrawlWebSource",
+			"sourceID", source.ID,
+			"url", source.URL,
+		)
+		result.Errors = append(result.Errors, d.WebPageError{URL: source.URL, Error: err.Error()})
+		result.FinishedAt = time.Now()
+		u.recordCrawl(context.WithoutCancel(ctx), *result)
+		return d.Error[*d.WebCrawlResult](u.paramCache, "ERR_WEB_CRAWL")
+	}
+	if err != nil {
+		// Cancelled or timed out: the pages fetched so far are still synced
+		result.Errors = append(result.Errors, d.WebPageError{URL: source.URL, Error: err.Error()})
+	}
+
+	result.PagesFetched = crawl.Fetched
+	result.PagesFound = len(crawl.Pages)
+	result.Skipped = crawl.Skipped
+	result.Failed = len(crawl.Errors)
+	for _, pageErr := range crawl.Errors {
+		result.Errors = append(result.Errors, d.WebPageError{URL: pageErr.URL, Error: pageErr.Error})
+	}
+
+	syncCtx := context.WithoutCancel(ctx)
+	for _, page := range crawl.Pages {
+		status, chunksCreated, err := u.syncPage(syncCtx, source, page)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, d.WebPageError{URL: page.URL, Error: err.Error()})
+			continue
+		}
+		result.ChunksCreated += chunksCreated
+		switch status {
+		case d.WebDocumentCreated:
+			result.Created++
+		case d.WebDocumentUpdated:
+			result.Updated++
+		case d.WebDocumentUnchanged:
+			result.Unchanged++
+		case d.WebDocumentInactive:
+			result.Inactive++
+		}
+	}
+
+	result.FinishedAt = time.Now()
+	u.recordCrawl(syncCtx, *result)
+
+	logger.LogInfo(ctx, "Web crawl completed",
+		"operation", "CrawlWebSource",
+		"sourceID", source.ID,
+		"pagesFetched", result.PagesFetched,
+		"created", result.Created,
+		"updated", result.Updated,
+		"unchanged", result.Unchanged,
+		"failed", result.Failed,
+		"chunksCreated", result.ChunksCreated,
+	)
+
+	return d.Success(result)
+}
+
+// syncPage stores a crawled page as a document. Its content hash is only recorded once its
+// chunks are stored, so a page whose embedding failed is retried on the next crawl. A changed
+// page keeps its previous chunks until the new ones replace them.
+func (u *webSourceUseCase) syncPage(ctx context.Context, source d.WebSource, page webcrawler.Page) (string, int, error) {
+	summary := summarize(page.Content, webSummaryLength)
+	syncResult, err := u.webSourceRepo.SyncDocument(ctx, d.SyncWebDocumentParams{
+		Source:      page.URL,
+		Category:    source.Category,
+		Title:       page.Title,
+		Summary:     &summary,
+		ContentHash: page.ContentHash,
+		Tags:        source.Tags,
+		Audience:    source.Audience,
+	})
+	if err != nil || syncResult == nil {
+		logger.LogError(ctx, "Failed to sync web page document in database", err,
+			"operation", "CrawlWebSource",
+			"sourceID", source.ID,
+			"url", page.URL,
+		)
+		return "", 0, fmt.Errorf("database error")
+	}
+	if !syncResult.Success || syncResult.DocID == nil || syncResult.Status == nil {
+		logger.LogWarn(ctx, "Web page document sync failed with business logic error",
+			"operation", "CrawlWebSource",
+			"code", syncResult.Code,
+			"url", page.URL,
+		)
+		return "", 0, fmt.Errorf("sync failed: %s", syncResult.Code)
+	}
+
+	status := *syncResult.Status
+	if status != d.WebDocumentCreated && status != d.WebDocumentUpdated {
+		return status, 0, nil
+	}
+
+	docID := *syncResult.DocID
+	// The source sets the size; the category may choose another strategy
+	chunking := chunkingOptions(u.paramCache, source.Category, "", "", source.ChunkSize, &source.ChunkOverlap)
+	chunker, err := textchunker.New(chunking)
+	if err != nil {
+		return "", 0, err
+	}
+	var chunks []string
+	var headings [][]string
+	for _, chunk := range chunker.Chunk(page.Content) {
+		chunks = append(chunks, chunk.Content)
+		headings = append(headings, chunk.Headings)
+	}
+	if len(chunks) == 0 && status == d.WebDocumentUpdated {
+		// The previous chunks stay; the hash is left unset so the next crawl retries the page
+		return status, 0, nil
+	}
+	chunksCreated := 0
+	if len(chunks) > 0 {
+		// An updated page swaps its chunks in one transaction, keeping the old ones on failure
+		var chunkResult d.Result[d.Data]
+		if status == d.WebDocumentUpdated {
+			chunkResult = u.chunkUseCase.ReplaceWithHeadings(ctx, docID, chunks, headings)
+		} else {
+			chunkResult = u.chunkUseCase.BulkCreateWithHeadings(ctx, docID, chunks, headings)
+		}
+		if !chunkResult.Success {
+			logger.LogError(ctx, "Failed to create chunks for web page",
+				fmt.Errorf("chunk creation failed: %s", chunkResult.Code),
+				"operation", "CrawlWebSource",
+				"docID", docID,
+				"url", page.URL,
+			)
+			return "", 0, fmt.Errorf("chunk creation failed: %s", chunkResult.Code)
+		}
+		chunksCreated, _ = chunkResult.Data["chunksCreated"].(int)
+	}
+
+	hashResult, err := u.webSourceRepo.SetContentHash(ctx, docID, page.ContentHash)
+	if err != nil || hashResult == nil || !hashResult.Success {
+		logger.LogError(ctx, "Failed to record web page content hash", err,
+			"operation", "CrawlWebSource",
+			"docID", docID,
+			"url", page.URL,
+		)
+	}
+
+	return status, chunksCreated, nil
+}
+
+func (u *webSourceUseCase) recordCrawl(ctx context.Context, crawl d.WebCrawlResult) {
+	result, err := u.webSourceRepo.RecordCrawl(ctx, crawl.SourceID, crawl)
+	if err != nil || result == nil || !result.Success {
+		logger.LogError(ctx, "Failed to record web crawl result", err,
+			"operation", "CrawlWebSource",
+			"sourceID", crawl.SourceID,
+		)
+	}
+}
+
+// crawlOptions combines the limits of the source with WEB_CRAWL_CONFIG; maxPages of the
+// config caps every source
+func (u *webSourceUseCase) crawlOptions(source d.WebSource) webcrawler.Options {
+	config, _ := u.paramCache.GetValue("WEB_CRAWL_CONFIG")
+
+	opts := webcrawler.Options{
+		MaxDepth: source.MaxDepth,
+		MaxPages: source.MaxPages,
+		Include:  source.IncludePatterns,
+		Exclude:  source.ExcludePatterns,
+	}
+	maxPages := defaultWebCrawlMaxPages
+	if val, ok := config["maxPages"].(float64); ok && val > 0 {
+		maxPages = int(val)
+	}
+	opts.MaxPages = min(opts.MaxPages, maxPages)
+	if val, ok := config["userAgent"].(string); ok {
+		opts.UserAgent = val
+	}
+	if val, ok := config["requestDelayMs"].(float64); ok && val > 0 {
+		opts.Delay = time.Duration(val * float64(time.Millisecond))
+	}
+	if val, ok := config["timeoutSeconds"].(float64); ok && val > 0 {
+		opts.RequestTimeout = time.Duration(val * float64(time.Second))
+	}
+	if val, ok := config["maxPageBytes"].(float64); ok && val > 0 {
+		opts.MaxPageBytes = int64(val)
+	}
+	return opts
+}
+
+func (u *webSourceUseCase) startCrawl(sourceID int) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.crawling[sourceID] {
+		return false
+	}
+	u.crawling[sourceID] = true
+	return true
+}
+
+func (u *webSourceUseCase) finishCrawl(sourceID int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.crawling, sourceID)
+}
+
+// validateWebSource checks the URL, the limits and that the patterns compile
+func validateWebSource(params d.SaveWebSourceParams) error {
+	parsed, err := url.Parse(params.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	if params.MaxDepth < 0 || params.MaxPages <= 0 {
+		return fmt.Errorf("maxDepth must be >= 0 and maxPages > 0")
+	}
+	if params.ChunkSize <= 0 || params.ChunkOverlap < 0 || params.ChunkOverlap >= params.ChunkSize {
+		return fmt.Errorf("chunkOverlap must be smaller than chunkSize")
+	}
+	if _, err := webcrawler.CompilePatterns(params.IncludePatterns); err != nil {
+		return err
+	}
+	if _, err := webcrawler.CompilePatterns(params.ExcludePatterns); err != nil {
+		return err
+	}
+	return nil
+}
+
+// summarize returns the first length characters of text
+func summarize(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length]) + "..."
+}